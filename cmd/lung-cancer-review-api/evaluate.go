@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/stackvity/lung-server/internal/evaluation"
+)
+
+// Exit codes of the evaluate subcommand.
+const (
+	evaluateOK         = 0
+	evaluateRegression = 1 // The scorecard regressed against the baseline
+	evaluateFailed     = 2 // Bad flags, unreadable corpus or baseline, or setup failure
+)
+
+// runEvaluate implements the evaluate subcommand: it runs a labelled corpus (see evaluation.Corpus) through the
+// processing and diagnosis services with the configured model client and writes a JSON and, optionally, HTML
+// scorecard. With -baseline, the scorecard is compared with a previous one and the command exits with status 1 if
+// any metric regressed beyond the tolerance, so that prompt or model changes can be gated in CI:
+//
+//	lung-cancer-review-api evaluate -corpus testdata/corpus -out scorecard.json -html scorecard.html -baseline baseline.json
+func runEvaluate(args []string) int {
+	// 1. Parse Flags
+	fs := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	corpusDir := fs.String("corpus", "", "directory of the labelled corpus, containing "+evaluation.ManifestFile+" (required)")
+	out := fs.String("out", "-", "file to write the JSON scorecard to, - for standard output")
+	htmlOut := fs.String("html", "", "file to write the HTML scorecard to")
+	baseline := fs.String("baseline", "", "JSON scorecard to compare with; regressions exit with status 1")
+	tolerance := fs.Float64("tolerance", 0.02, "drop of a rate (0-1) tolerated before it counts as a regression")
+	sizeTolerance := fs.Float64("size-tolerance", 1.0, "increase of the nodule size error (mm) tolerated before it counts as a regression")
+	fakeLLM, fakeLLMRecord := fakeLLMFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return evaluateFailed
+	}
+	if *corpusDir == "" {
+		fmt.Fprintln(os.Stderr, "evaluate: -corpus is required")
+		fs.Usage()
+		return evaluateFailed
+	}
+	setFakeLLM(*fakeLLM, *fakeLLMRecord)
+
+	// 2. Load Corpus and Baseline (before the services, so that mistakes fail fast)
+	corpus, err := evaluation.LoadCorpus(*corpusDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+		return evaluateFailed
+	}
+	var base *evaluation.Scorecard
+	if *baseline != "" {
+		if base, err = evaluation.LoadScorecard(*baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+			return evaluateFailed
+		}
+	}
+
+	// 3. Initialize Runner via Wire
+	runner, cleanup, err := InitializeEvaluation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "evaluate: initializing services: %v\n", err)
+		return evaluateFailed
+	}
+	defer cleanup()
+
+	// 4. Run Corpus (interruptible with Ctrl-C)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	card, err := runner.Run(ctx, corpus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+		return evaluateFailed
+	}
+	if base != nil {
+		card.Compare(base, *tolerance, *sizeTolerance)
+	}
+
+	// 5. Write Scorecards
+	if err := writeScorecard(*out, card.WriteJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+		return evaluateFailed
+	}
+	if *htmlOut != "" {
+		if err := writeScorecard(*htmlOut, card.WriteHTML); err != nil {
+			fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+			return evaluateFailed
+		}
+	}
+
+	// 6. Gate on Regressions
+	for _, regression := range card.Regressions {
+		fmt.Fprintf(os.Stderr, "evaluate: regression in %s: %.4f -> %.4f\n", regression.Metric, regression.Baseline, regression.Current)
+	}
+	if len(card.Regressions) > 0 {
+		return evaluateRegression
+	}
+	return evaluateOK
+}
+
+// writeScorecard writes a scorecard to path, or to standard output if path is "-".
+func writeScorecard(path string, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating scorecard file: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
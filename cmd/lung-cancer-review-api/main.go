@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -13,14 +14,24 @@ import (
 )
 
 func main() {
-	// 1. Load Configuration
+	// The evaluate subcommand scores a labelled corpus instead of serving the API (see evaluate.go).
+	if len(os.Args) > 1 && os.Args[1] == "evaluate" {
+		os.Exit(runEvaluate(os.Args[2:]))
+	}
+
+	// 1. Parse Flags: -fake-llm swaps the model provider for fixtures (development only, enforced by LoadConfig).
+	fakeLLM, fakeLLMRecord := fakeLLMFlags(flag.CommandLine)
+	flag.Parse()
+	setFakeLLM(*fakeLLM, *fakeLLMRecord)
+
+	// 2. Load Configuration
 	cfg, err := config.LoadConfig(context.Background(), ".")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 		os.Exit(1)
 	}
 
-	// 2. Initialize Logger
+	// 3. Initialize Logger
 	logger, err := utils.NewLogger(&cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
@@ -34,7 +45,7 @@ func main() {
 
 	logger.Info("Starting Lung Cancer Review API service...", zap.String("version", "1.0.0"))
 
-	// 3. Initialize API via Wire
+	// 4. Initialize API via Wire
 	app, cleanup, err := InitializeAPI()
 	if err != nil {
 		logger.Error("Failed to initialize API", zap.Error(err))
@@ -44,13 +55,13 @@ func main() {
 		cleanup()
 	}()
 
-	// 4. Start HTTP Server
+	// 5. Start HTTP Server
 	if err := app.StartServer(); err != nil {
 		logger.Error("API server failed to start", zap.Error(err))
 		os.Exit(1)
 	}
 
-	// 5. Handle Panic Recovery
+	// 6. Handle Panic Recovery
 	defer func() {
 		if r := recover(); r != nil {
 			err := fmt.Errorf("application panicked: %v\nStack Trace: %s", r, debug.Stack())
@@ -61,3 +72,23 @@ func main() {
 
 	logger.Info("Service stopped gracefully.")
 }
+
+// fakeLLMFlags defines the -fake-llm and -fake-llm-record flags on fs.
+func fakeLLMFlags(fs *flag.FlagSet) (fixtures *string, record *bool) {
+	fixtures = fs.String("fake-llm", "", "answer model calls from the fixtures in this directory instead of a live model (ENVIRONMENT=development only)")
+	record = fs.Bool("fake-llm-record", false, "with -fake-llm, call the real provider for calls without a fixture and save its answer")
+	return fixtures, record
+}
+
+// setFakeLLM passes the -fake-llm flags on as environment variables, which take precedence in every LoadConfig call,
+// including Wire's. It does nothing if fixtures is empty.
+func setFakeLLM(fixtures string, record bool) {
+	if fixtures == "" {
+		return
+	}
+	os.Setenv("LLM_PROVIDER", "fake")
+	os.Setenv("FAKE_LLM_FIXTURES", fixtures)
+	if record {
+		os.Setenv("FAKE_LLM_MODE", "record")
+	}
+}
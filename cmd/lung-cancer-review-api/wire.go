@@ -9,6 +9,7 @@ import (
 	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
 	postgresRepo "github.com/stackvity/lung-server/internal/data/repositories/postgres" // Alias for clarity
 	"github.com/stackvity/lung-server/internal/domain/services"                         // Corrected import: Explicitly import services package
+	"github.com/stackvity/lung-server/internal/evaluation"
 	"github.com/stackvity/lung-server/internal/gemini"
 	"github.com/stackvity/lung-server/internal/knowledge"
 	"github.com/stackvity/lung-server/internal/ocr"
@@ -23,10 +24,15 @@ import (
 // Defines providers for all services, including ProcessingService, ReportService, and LinkService.
 // Binds concrete service implementations to their interface types for dependency injection.
 var serviceSet = wire.NewSet(
-	services.NewProcessingService, // Provider for Processing Service
-	services.NewReportService,     // Provider for Report Service
-	services.NewLinkService,       // Provider for Link Service
-	services.NewDiagnosisService,  // Provider for Diagnosis Service
+	services.NewProcessingService,       // Provider for Processing Service
+	services.NewReportService,           // Provider for Report Service
+	services.NewLinkService,             // Provider for Link Service
+	services.NewDiagnosisService,        // Provider for Diagnosis Service
+	services.NewSessionSecretService,    // Provider for Session Secret Service (per-session pseudonymization secrets)
+	services.NewDateShiftService,        // Provider for Date Shift Service (per-session date offset)
+	services.NewPromptRegistryService,   // Provider for Prompt Registry Service (prompt versions and their review lifecycle)
+	services.NewPromptExperimentService, // Provider for Prompt Experiment Service (A/B experiments, call outcomes, clinician feedback, reports)
+	services.NewLLMUsageService,         // Provider for LLM Usage Service (token usage and cost reports)
 )
 
 // repositorySet: Wire set for repository layer dependencies.
@@ -40,7 +46,14 @@ var repositorySet = wire.NewSet(
 	postgresRepo.NewNoduleRepository,                                                                                   // Provider for NoduleRepository (PostgreSQL implementation)
 	postgresRepo.NewDiagnosisRepository,                                                                                // Provider for DiagnosisRepository (PostgreSQL implementation)
 	postgresRepo.NewStageRepository,                                                                                    // Provider for StageRepository (PostgreSQL implementation)
+	postgresRepo.NewAuditLogRepository,                                                                                 // Provider for AuditLogRepository (PostgreSQL implementation)
+	postgresRepo.NewSessionSecretRepository,                                                                            // Provider for SessionSecretRepository (PostgreSQL implementation)
 	postgresRepo.NewTreatmentRecommendationRepository,                                                                  // Provider for TreatmentRecommendationRepository (PostgreSQL implementation)
+	postgresRepo.NewPromptRepository,                                                                                   // Provider for PromptRepository (PostgreSQL implementation)
+	postgresRepo.NewPromptExperimentRepository,                                                                         // Provider for PromptExperimentRepository (PostgreSQL implementation)
+	postgresRepo.NewLLMCacheRepository,                                                                                 // Provider for LLMCacheRepository (PostgreSQL implementation)
+	postgresRepo.NewLLMUsageRepository,                                                                                 // Provider for LLMUsageRepository (PostgreSQL implementation)
+	postgresRepo.NewLabObservationRepository,                                                                           // Provider for LabObservationRepository (PostgreSQL implementation)
 	wire.Bind(new(interfaces.PatientRepository), new(*postgresRepo.PatientRepository)),                                 // Binds PatientRepository interface to its PostgreSQL implementation
 	wire.Bind(new(interfaces.StudyRepository), new(*postgresRepo.StudyRepository)),                                     // Binds StudyRepository interface to its PostgreSQL implementation
 	wire.Bind(new(interfaces.ImageRepository), new(*postgresRepo.ImageRepository)),                                     // Binds ImageRepository interface to its PostgreSQL implementation
@@ -48,34 +61,58 @@ var repositorySet = wire.NewSet(
 	wire.Bind(new(interfaces.NoduleRepository), new(*postgresRepo.NoduleRepository)),                                   // Binds NoduleRepository interface to its PostgreSQL implementation
 	wire.Bind(new(interfaces.DiagnosisRepository), new(*postgresRepo.DiagnosisRepository)),                             // Binds DiagnosisRepository interface to its PostgreSQL implementation
 	wire.Bind(new(interfaces.StageRepository), new(*postgresRepo.StageRepository)),                                     // Binds StageRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.AuditLogRepository), new(*postgresRepo.AuditLogRepository)),                               // Binds AuditLogRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.SessionSecretRepository), new(*postgresRepo.SessionSecretRepository)),                     // Binds SessionSecretRepository interface to its PostgreSQL implementation
 	wire.Bind(new(interfaces.TreatmentRecommendationRepository), new(*postgresRepo.TreatmentRecommendationRepository)), // Binds TreatmentRecommendationRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.PromptRepository), new(*postgresRepo.PromptRepository)),                                   // Binds PromptRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.PromptExperimentRepository), new(*postgresRepo.PromptExperimentRepository)),               // Binds PromptExperimentRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.LLMCacheRepository), new(*postgresRepo.LLMCacheRepository)),                               // Binds LLMCacheRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.LLMUsageRepository), new(*postgresRepo.LLMUsageRepository)),                               // Binds LLMUsageRepository interface to its PostgreSQL implementation
+	wire.Bind(new(interfaces.LabObservationRepository), new(*postgresRepo.LabObservationRepository)),                   // Binds LabObservationRepository interface to its PostgreSQL implementation
 )
 
 // handlerSet: Wire set for API handler dependencies.
 // Defines providers for all API handlers and the grouped Handler struct.
 // This set ensures that the API layer has access to all necessary handlers for request processing.
 var handlerSet = wire.NewSet(
-	handlers.NewFileHandler,      // Provider for FileHandler
-	handlers.NewReportHandler,    // Provider for Report Handler
-	handlers.NewHealthHandler,    // Provider for Health Handler
-	handlers.NewDiagnosisHandler, // Provider for Diagnosis Handler
-	handlers.NewHandler,          // Provider for the grouped Handler struct
+	handlers.NewFileHandler,        // Provider for FileHandler
+	handlers.NewReportHandler,      // Provider for Report Handler
+	handlers.NewHealthHandler,      // Provider for Health Handler
+	handlers.NewDiagnosisHandler,   // Provider for Diagnosis Handler
+	handlers.NewPromptAdminHandler, // Provider for Prompt Admin Handler (prompt registry admin endpoints)
+	handlers.NewUsageAdminHandler,  // Provider for Usage Admin Handler (model usage admin endpoint)
+	handlers.NewHandler,            // Provider for the grouped Handler struct
 )
 
-// geminiSet: Wire set for Gemini API client dependency.
-// Includes the provider for the GeminiProClient and binds it to the GeminiClient interface.
-// This set facilitates the integration with the Google AI Gemini Pro API for AI functionalities.
+// geminiSet: Wire set for the model client dependency.
+// Provides the client of the provider selected by LLM_PROVIDER (Gemini API or an OpenAI-compatible server), decorated
+// with retries and circuit breakers (bound to BreakerReporter for the health endpoint), with token accounting and
+// budgets and with the response cache selected by LLM_CACHE_BACKEND, and binds the outermost decorator to the
+// GeminiClient interface.
+// It also provides the managed prompts sent with every call: the active prompt registry version (or, while an experiment
+// runs, the version the session is assigned to), or the prompt file.
+// This set facilitates the integration with the Google AI Gemini Pro API, or a self-hosted model, for AI functionalities.
 var geminiSet = wire.NewSet(
-	gemini.NewGeminiProClient, // Provider for GeminiProClient (concrete implementation for Gemini API)
-	wire.Bind(new(gemini.GeminiClient), new(*gemini.GeminiProClient)), // Binds GeminiClient interface to its concrete implementation (GeminiProClient)
+	gemini.NewProviderClient, // Provider for ResilientClient around the selected provider's client (GeminiProClient or OpenAIClient)
+	gemini.NewMeteredClient,  // Provider for MeteredClient (token usage and budgets) around the ResilientClient
+	gemini.NewResponseCache,  // Provider for the response cache backend (LRUResponseCache, the llmcache table, or none)
+	gemini.NewCachingClient,  // Provider for CachingClient around the MeteredClient
+	wire.Bind(new(gemini.GeminiClient), new(*gemini.CachingClient)),                  // Binds GeminiClient interface to the caching decorator
+	wire.Bind(new(gemini.ResponseCacheStore), new(*postgresRepo.LLMCacheRepository)), // Binds ResponseCacheStore to the model response cache repository
+	wire.Bind(new(gemini.UsageStore), new(*postgresRepo.LLMUsageRepository)),         // Binds UsageStore to the model usage repository
+	wire.Bind(new(gemini.BreakerReporter), new(*gemini.ResilientClient)),             // Binds BreakerReporter interface for the health endpoint
+	gemini.NewFilePromptManager, // Provider for FilePromptManager (prompt files under PROMPT_TEMPLATE_PATH, used for prompts without an active registry version)
+	gemini.NewDBPromptManager,   // Provider for DBPromptManager (active prompt registry versions and running experiments, cached)
+	wire.Bind(new(gemini.PromptManager), new(*gemini.DBPromptManager)),                           // Binds PromptManager interface to the registry-backed implementation
+	wire.Bind(new(gemini.ActivePromptSource), new(*postgresRepo.PromptRepository)),               // Binds ActivePromptSource to the prompt registry repository
+	wire.Bind(new(gemini.PromptExperimentSource), new(*postgresRepo.PromptExperimentRepository)), // Binds PromptExperimentSource to the prompt experiment repository
 )
 
 // ocrSet: Wire set for OCR service dependency.
-// Defines the provider for the GoogleVisionService and binds it to the OCRService interface.
-// This set enables the use of Google Cloud Vision API for Optical Character Recognition tasks within the application.
+// Defines the provider for the OCRService backend selected by OCR_BACKEND.
+// This set enables the use of Google Cloud Vision API or a local Tesseract installation for Optical Character Recognition tasks within the application.
 var ocrSet = wire.NewSet(
-	ocr.NewGoogleVisionService,                                    // Provider for GoogleVisionService (concrete implementation using Google Cloud Vision API)
-	wire.Bind(new(ocr.OCRService), new(*ocr.GoogleVisionService)), // Binds OCRService interface to its concrete implementation (GoogleVisionService)
+	ocr.NewOCRService, // Provider for OCRService (GoogleVisionService or TesseractService, per OCR_BACKEND)
 )
 
 // storageSet: Wire set for storage service dependency.
@@ -115,6 +152,11 @@ var dataSet = wire.NewSet(
 	data.NewData, // Provider for Data layer
 )
 
+// evaluationSet: Wire set for the evaluate subcommand.
+var evaluationSet = wire.NewSet(
+	evaluation.NewRunner, // Provider for the corpus Runner (scores ProcessingService and DiagnosisService against ground truth)
+)
+
 // InitializeAPI: Assembles API dependencies using Wire.
 func InitializeAPI() (*api.API, func(), error) {
 	panic(wire.Build(
@@ -131,3 +173,19 @@ func InitializeAPI() (*api.API, func(), error) {
 		apiSet,        // API Set
 	))
 }
+
+// InitializeEvaluation: Assembles the dependencies of the evaluate subcommand using Wire.
+func InitializeEvaluation() (*evaluation.Runner, func(), error) {
+	panic(wire.Build(
+		configSet,     // Configuration Set
+		utilsSet,      // Utility Set
+		dataSet,       // Data Layer Set
+		repositorySet, // Repository Set
+		serviceSet,    // Service Set
+		geminiSet,     // Gemini Set
+		ocrSet,        // OCR Set
+		storageSet,    // Storage Set
+		knowledgeSet,  // Knowledge Set
+		evaluationSet, // Evaluation Set
+	))
+}
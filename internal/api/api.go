@@ -60,9 +60,10 @@ func NewAPI(handler *handlers.Handler, cfg *config.Config, logger *zap.Logger) (
 		Logger:      logger,
 		Config:      cfg,
 	}))
+	engine.Use(handlers.LLMCacheMiddleware(cfg, logger)) // X-LLM-Cache header of admins
 
 	// 3. Route Setup
-	routes.SetupRouter(engine, handler.FileHandler, handler.ReportHandler, handler.HealthHandler, handler.DiagnosisHandler)
+	routes.SetupRouter(engine, handler.FileHandler, handler.ReportHandler, handler.HealthHandler, handler.DiagnosisHandler, handler.PromptAdminHandler, handler.UsageAdminHandler, handlers.AdminAuthMiddleware(cfg, logger))
 
 	api := &API{
 		Engine:  engine,
@@ -9,7 +9,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stackvity/lung-server/internal/domain"          // Import domain for custom errors
 	"github.com/stackvity/lung-server/internal/domain/services" // Import services
-	"github.com/stackvity/lung-server/internal/utils"           // Import utils
+	"github.com/stackvity/lung-server/internal/gemini"
+	"github.com/stackvity/lung-server/internal/utils" // Import utils
 	"go.uber.org/zap"
 )
 
@@ -67,7 +68,10 @@ func (h *DiagnosisHandler) GeneratePreliminaryDiagnosisHandler(c *gin.Context) {
 		h.logger.Error("Preliminary diagnosis generation failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("patient_id", patientID.String()), zap.Error(err)) // Error log for diagnosis generation failure in the service layer.
 
 		// Enhanced Error Handling: Check for specific error types from service and respond accordingly - Recommendation 2 (Enhanced Error Handling) - Differentiated error responses based on error type.
-		if errors.Is(err, &domain.ErrGeminiDiagnosisFailed{}) { // Check if the error is specifically a Gemini API related error using errors.Is and type assertion.
+		var budgetErr *gemini.ErrGeminiBudgetExceeded
+		if errors.As(err, &budgetErr) { // The model was not called: a token budget is used up
+			utils.RespondWithError(c, http.StatusTooManyRequests, "Diagnosis unavailable: "+budgetErr.Budget+" model usage budget exceeded")
+		} else if errors.Is(err, &domain.ErrGeminiDiagnosisFailed{}) { // Check if the error is specifically a Gemini API related error using errors.Is and type assertion.
 			utils.RespondWithError(c, http.StatusServiceUnavailable, "Diagnosis service unavailable: "+err.Error()) // Respond with 503 Service Unavailable if Gemini API is the root cause, indicating external service dependency issue.
 		} else {
 			// General Internal Server Error for other unclassified service errors - Fallback for any other errors from the service layer that are not Gemini API related.
@@ -2,13 +2,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strings" // ADDED: Import strings package for Content-Type check
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stackvity/lung-server/internal/domain/services" // Import services
-	"github.com/stackvity/lung-server/internal/utils"           // Import utils
+	"github.com/stackvity/lung-server/internal/gemini"
+	"github.com/stackvity/lung-server/internal/utils" // Import utils
 	"go.uber.org/zap"
 )
 
@@ -79,6 +82,12 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	}
 	defer file.Close() // Ensure file is closed after handler execution
 
+	// 4a. ZIP archives (e.g. the contents of an imaging CD) are ingested entry by entry - BE-010, BE-012
+	if services.IsArchiveUpload(filename, contentType) {
+		h.uploadArchive(c, patientID, filename, file, fileHeader.Size)
+		return
+	}
+
 	// 5. Pass to Processing Service - Delegate file processing to the ProcessingService
 	if err := h.ProcessingService.ProcessDocument(c.Request.Context(), patientID, filename, contentType, file); err != nil { // Call ProcessDocument on ProcessingService to handle business logic
 		h.logger.Error("File processing failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err)) // Log error if processing fails
@@ -101,6 +110,77 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 
 }
 
+// uploadArchive hands a ZIP upload to the ProcessingService and reports the per-entry outcome.
+// Archives that break the zip-bomb limits are rejected with 413 before any entry is processed.
+func (h *FileHandler) uploadArchive(c *gin.Context, patientID uuid.UUID, filename string, archive io.ReaderAt, size int64) {
+	const operation = "FileHandler.uploadArchive" // Define operation name for structured logging
+	requestID := utils.GetRequestID(c.Request.Context())
+
+	result, err := h.ProcessingService.ProcessArchive(c.Request.Context(), patientID, filename, archive, size)
+	if err != nil {
+		var limitErr *utils.ErrArchiveLimitExceeded
+		var sizeErr *utils.ErrFileSizeExceeded
+		switch {
+		case errors.As(err, &limitErr), errors.As(err, &sizeErr):
+			h.logger.Warn("Archive rejected", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "Archive exceeds upload limits")
+		case result == nil:
+			h.logger.Warn("Archive could not be opened", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid ZIP archive")
+		default:
+			h.logger.Error("Archive processing failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+			utils.RespondWithError(c, http.StatusInternalServerError, "Archive processing failed")
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Archive uploaded and processed",
+		"upload_id": patientID, // patientID acts as upload_id for this session, as for single-file uploads
+		"archive":   result,    // Per-entry status and series analysis summary
+	})
+	h.logger.Info("Archive upload request handled successfully", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Int("processed", result.Processed), zap.Int("skipped", result.Skipped), zap.Int("failed", result.Failed))
+}
+
+// AnalyzeUploads runs series-level analysis over the DICOM instances uploaded in this session.
+// Instances are grouped into series and nodule detection runs once per series, so it should be called
+// after all slices of a study have been uploaded. - BE-029, BE-030
+func (h *FileHandler) AnalyzeUploads(c *gin.Context) {
+	const operation = "FileHandler.AnalyzeUploads" // Define operation name for structured logging
+	requestID := utils.GetRequestID(c.Request.Context())
+
+	patientIDRaw, exists := c.Get("patientID") // Retrieve patientID from Gin context, set by LinkValidationMiddleware
+	if !exists {
+		h.logger.Error("Patient ID not found in context", zap.String("operation", operation), zap.String("request_id", requestID))
+		utils.RespondWithError(c, http.StatusBadRequest, "Patient ID missing from request context")
+		return
+	}
+	patientID, ok := patientIDRaw.(uuid.UUID) // Type assert patientID from interface{} to uuid.UUID
+	if !ok {
+		h.logger.Error("Invalid patient ID format in context", zap.String("operation", operation), zap.String("request_id", requestID), zap.Any("patient_id_raw", patientIDRaw))
+		utils.RespondWithError(c, http.StatusInternalServerError, "Invalid patient ID format")
+		return
+	}
+
+	results, err := h.ProcessingService.AnalyzeSeries(c.Request.Context(), patientID)
+	var budgetErr *gemini.ErrGeminiBudgetExceeded
+	if errors.As(err, &budgetErr) {
+		h.logger.Warn("Series analysis rejected by model usage budget", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("budget", budgetErr.Budget), zap.Error(err))
+		utils.RespondWithError(c, http.StatusTooManyRequests, "Series analysis unavailable: "+budgetErr.Budget+" model usage budget exceeded")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Series analysis failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("analyzed_series", len(results)), zap.Error(err))
+		utils.RespondWithError(c, http.StatusInternalServerError, "Series analysis failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Series analysis completed",
+		"series":  results, // Per-series slice counts, geometry issues and nodule counts
+	})
+}
+
 // GetUploadStatus is a placeholder for future implementation. - Recommendation 2 (Placeholder for GetUploadStatus)
 func (h *FileHandler) GetUploadStatus(c *gin.Context) {
 	// Placeholder for GetUploadStatus handler function (BE-017) - Recommendation 2
@@ -5,10 +5,12 @@ package handlers
 // This is used for dependency injection in api.go and wire.go,
 // making it easier to manage and inject all handlers as a single dependency.
 type Handler struct {
-	FileHandler      *FileHandler
-	ReportHandler    *ReportHandler
-	HealthHandler    *HealthHandler
-	DiagnosisHandler *DiagnosisHandler
+	FileHandler        *FileHandler
+	ReportHandler      *ReportHandler
+	HealthHandler      *HealthHandler
+	DiagnosisHandler   *DiagnosisHandler
+	PromptAdminHandler *PromptAdminHandler
+	UsageAdminHandler  *UsageAdminHandler
 	// Add other handlers here as you create them (e.g., AdminHandler, etc.)
 }
 
@@ -21,13 +23,17 @@ func NewHandler(
 	reportHandler *ReportHandler,
 	healthHandler *HealthHandler,
 	diagnosisHandler *DiagnosisHandler,
+	promptAdminHandler *PromptAdminHandler,
+	usageAdminHandler *UsageAdminHandler,
 	// Inject other handlers here as arguments (e.g., adminHandler *AdminHandler)
 ) *Handler {
 	return &Handler{
-		FileHandler:      fileHandler,
-		ReportHandler:    reportHandler,
-		HealthHandler:    healthHandler,
-		DiagnosisHandler: diagnosisHandler,
+		FileHandler:        fileHandler,
+		ReportHandler:      reportHandler,
+		HealthHandler:      healthHandler,
+		DiagnosisHandler:   diagnosisHandler,
+		PromptAdminHandler: promptAdminHandler,
+		UsageAdminHandler:  usageAdminHandler,
 		// Initialize other handlers here (e.g., AdminHandler: adminHandler)
 	}
 }
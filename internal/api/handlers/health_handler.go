@@ -7,29 +7,33 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool" // Import pgxpool for database interaction
-	"go.uber.org/zap"                 // Import zap for structured logging
+	"github.com/stackvity/lung-server/internal/gemini"
+	"go.uber.org/zap" // Import zap for structured logging
 )
 
 // HealthHandler handles health check requests for the application.
 // It is responsible for providing endpoints that can be used by monitoring systems and load balancers
 // to verify the health and operational status of the application and its dependencies.
 type HealthHandler struct {
-	dbPool *pgxpool.Pool // dbPool: Database connection pool dependency for database health checks. Injected during handler creation for modularity and testability.
-	logger *zap.Logger   // logger: Logger dependency for structured logging within the handler.  Injected for consistent logging practices.
+	dbPool         *pgxpool.Pool          // dbPool: Database connection pool dependency for database health checks. Injected during handler creation for modularity and testability.
+	geminiBreakers gemini.BreakerReporter // geminiBreakers: Circuit breakers of the Gemini client, reported so monitoring sees Gemini outages.
+	logger         *zap.Logger            // logger: Logger dependency for structured logging within the handler.  Injected for consistent logging practices.
 }
 
 // NewHealthHandler creates a new HealthHandler instance.
-// It takes a *pgxpool.Pool and a *zap.Logger as dependencies, enabling database health checks and structured logging.
+// It takes a *pgxpool.Pool, the Gemini client's circuit breakers and a *zap.Logger as dependencies, enabling database
+// and Gemini health checks and structured logging.
 // This constructor ensures that the handler is properly initialized with its required dependencies.
-func NewHealthHandler(dbPool *pgxpool.Pool, logger *zap.Logger) *HealthHandler { // MODIFIED: Accept dbPool and logger
+func NewHealthHandler(dbPool *pgxpool.Pool, geminiBreakers gemini.BreakerReporter, logger *zap.Logger) *HealthHandler { // MODIFIED: Accept dbPool and logger
 	return &HealthHandler{
-		dbPool: dbPool,
-		logger: logger.Named("HealthHandler"), // logger: Creates a named logger for HealthHandler to provide contextual information in logs.
+		dbPool:         dbPool,
+		geminiBreakers: geminiBreakers,
+		logger:         logger.Named("HealthHandler"), // logger: Creates a named logger for HealthHandler to provide contextual information in logs.
 	}
 }
 
 // HealthCheck performs a comprehensive health check of the application.
-// It verifies the overall application status, including its database connectivity,
+// It verifies the overall application status, including its database connectivity and the Gemini circuit breakers,
 // and returns a detailed JSON response indicating the health of each component.
 // This endpoint is designed to be used by monitoring systems, load balancers, and orchestration platforms
 // to automatically assess the health of the application and route traffic accordingly.
@@ -37,6 +41,9 @@ func NewHealthHandler(dbPool *pgxpool.Pool, logger *zap.Logger) *HealthHandler {
 // Returns:
 //   - 200 OK: If the application and all critical dependencies (currently just the database) are healthy. The response body contains a JSON payload with detailed health information.
 //   - 503 Service Unavailable: If any critical dependency is unhealthy (e.g., database connection fails). The response body contains a JSON payload detailing the degraded health status.
+//
+// An open Gemini circuit breaker marks "gemini" as Degraded but keeps 200 OK: the outage is upstream, so taking this
+// instance out of rotation would not help, and uploads and reports still work without AI analysis.
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	const operation = "HealthHandler.HealthCheck" // operation: Operation name for structured logging, aids in log filtering and analysis.
 
@@ -58,13 +65,19 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 		return                                                                  // Return immediately after sending 503 response to indicate unhealthy status and prevent further request processing.
 	}
 
-	// Future Enhancement: Add checks for other dependencies (e.g., Gemini API connectivity, caching service, etc.) - Recommendation: Extend Health Checks
-	// Example (Conceptual - not implemented in this iteration):
-	// if !h.geminiClient.IsHealthy(ctx) {
-	// 	healthStatus["geminiAPI"] = "Unavailable"
-	// 	healthStatus["status"] = "Degraded" // or "Warning" depending on criticality
-	// 	healthStatus["message"] = "System degraded: Gemini API unavailable"
-	// }
+	// Gemini Health Check - Report the per-method circuit breakers of the Gemini client.
+	breakers := h.geminiBreakers.BreakerStates()
+	healthStatus["gemini"] = "OK"
+	healthStatus["gemini_breakers"] = breakers
+	for method, breaker := range breakers {
+		if breaker.State != gemini.BreakerClosed {
+			h.logger.Warn("Gemini circuit breaker not closed", zap.String("operation", operation), zap.String("method", method), zap.String("state", breaker.State))
+			healthStatus["gemini"] = "Degraded"                                      // Update Gemini status; the overall status stays OK (see above)
+			healthStatus["message"] = "System healthy; Gemini API calls are failing" // Update overall message so the outage is visible
+		}
+	}
+
+	// Future Enhancement: Add checks for other dependencies (e.g., caching service, etc.) - Recommendation: Extend Health Checks
 	// Future Enhancement: Metrics Exposure - Recommendation: Metrics Integration
 	// In production, integrate with Prometheus or a similar metrics system to expose health check metrics.
 	// Example (Conceptual - not implemented in this iteration):
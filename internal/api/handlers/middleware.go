@@ -3,7 +3,9 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,7 @@ import (
 	"github.com/stackvity/lung-server/internal/config"
 	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
 	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/gemini"
 	"github.com/stackvity/lung-server/internal/utils"
 	"go.uber.org/zap"
 )
@@ -24,18 +27,24 @@ type MiddlewareConfig struct {
 	Config      *config.Config // Include Config for potential future use in middleware, e.g., for content type validation, rate limiting configs
 }
 
+// AdminPathPrefix is the path prefix of the admin routes.
+const AdminPathPrefix = "/api/v1/admin"
+
 // MiddlewareSetup initializes and returns the complete middleware chain for the application.
 // It takes a MiddlewareConfig struct to inject dependencies and configuration.
 // The middleware chain is executed in the order they are registered here, which is crucial for request processing flow.
 // 1. Request Logging (executed first for logging as early as possible).
-// 2. Link Validation (executed second for security access control before further processing).
+// 2. Link Validation (executed second for security access control before further processing). Admin routes are
+// authenticated by AdminAuthMiddleware instead.
 func MiddlewareSetup(cfg MiddlewareConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Request Logging Middleware (Executed first): Logs incoming requests for monitoring, debugging, and audit trails.
 		RequestLoggerMiddleware(cfg.Logger)(c)
 
 		// 2. Link Validation Middleware (Executed second, after logging): Validates the access link from the request header for secure access control.
-		LinkValidationMiddleware(cfg.PatientRepo, cfg.Logger)(c)
+		if !strings.HasPrefix(c.Request.URL.Path, AdminPathPrefix) {
+			LinkValidationMiddleware(cfg.PatientRepo, cfg.Logger)(c)
+		}
 
 		c.Next() // Process the request - continue to the next middleware or handler in the chain
 	}
@@ -130,3 +139,101 @@ func LinkValidationMiddleware(repo interfaces.PatientRepository, logger *zap.Log
 		c.Next() // Go to the next middleware/handler - Proceed to the next stage in request processing if link is valid
 	}
 }
+
+// AdminAuthMiddleware authenticates admin requests with a bearer token from ADMIN_TOKENS ("user:token" pairs) and
+// stores the admin's user name in the Gin context under "adminUser". Every admin request is rejected when no tokens
+// are configured.
+func AdminAuthMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
+	tokens := parseAdminTokens(cfg, logger, "AdminAuthMiddleware")
+
+	return func(c *gin.Context) {
+		const operation = "AdminAuthMiddleware"
+		requestID := utils.GetRequestID(c.Request.Context())
+
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || presented == "" || len(tokens) == 0 {
+			logger.Warn("Admin request without credentials", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("path", c.Request.URL.Path), zap.Bool("admin_configured", len(tokens) > 0))
+			utils.RespondWithError(c, http.StatusUnauthorized, "Admin authentication required")
+			return
+		}
+
+		adminUser := matchAdminToken(tokens, presented)
+		if adminUser == "" {
+			logger.Warn("Invalid admin token", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("path", c.Request.URL.Path))
+			utils.RespondWithError(c, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+
+		c.Set("adminUser", adminUser)
+		c.Next()
+	}
+}
+
+// LLMCacheHeader is the request header with which admins choose the model response cache mode of one request (see
+// the gemini.CacheMode constants): "bypass" neither reads nor writes the cache, "refresh" skips the lookup and
+// replaces the cached responses.
+const LLMCacheHeader = "X-LLM-Cache"
+
+// LLMCacheMiddleware applies the X-LLM-Cache header to the request context. The header is honored only together with
+// an admin bearer token from ADMIN_TOKENS; requests sending it without one are rejected with 403, and unknown modes
+// with 400.
+func LLMCacheMiddleware(cfg *config.Config, logger *zap.Logger) gin.HandlerFunc {
+	tokens := parseAdminTokens(cfg, logger, "LLMCacheMiddleware")
+
+	return func(c *gin.Context) {
+		const operation = "LLMCacheMiddleware"
+
+		mode := strings.ToLower(strings.TrimSpace(c.GetHeader(LLMCacheHeader)))
+		if mode == "" {
+			c.Next()
+			return
+		}
+		requestID := utils.GetRequestID(c.Request.Context())
+		if mode != gemini.CacheModeBypass && mode != gemini.CacheModeRefresh {
+			utils.RespondWithError(c, http.StatusBadRequest, LLMCacheHeader+" must be 'bypass' or 'refresh'")
+			return
+		}
+		presented, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		adminUser := matchAdminToken(tokens, presented)
+		if adminUser == "" {
+			logger.Warn("Model response cache header without admin credentials", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("path", c.Request.URL.Path))
+			utils.RespondWithError(c, http.StatusForbidden, LLMCacheHeader+" requires admin credentials")
+			return
+		}
+
+		logger.Info("Model response cache mode set by admin", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("admin_user", adminUser), zap.String("mode", mode), zap.String("path", c.Request.URL.Path))
+		c.Request = c.Request.WithContext(gemini.WithCacheMode(c.Request.Context(), mode))
+		c.Next()
+	}
+}
+
+// parseAdminTokens parses ADMIN_TOKENS into a map of user to token, skipping malformed entries.
+func parseAdminTokens(cfg *config.Config, logger *zap.Logger, operation string) map[string]string {
+	tokens := make(map[string]string) // user -> token
+	for _, pair := range strings.Split(cfg.AdminTokens, ",") {
+		user, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || user == "" || token == "" {
+			if strings.TrimSpace(pair) != "" {
+				logger.Warn("Ignoring malformed ADMIN_TOKENS entry", zap.String("operation", operation), zap.String("user", user))
+			}
+			continue
+		}
+		tokens[user] = token
+	}
+	return tokens
+}
+
+// matchAdminToken returns the user whose token was presented, or "" if none matches.
+func matchAdminToken(tokens map[string]string, presented string) string {
+	if presented == "" {
+		return ""
+	}
+	// Compare against every token in constant time, so the response time does not reveal which users exist
+	adminUser := ""
+	for user, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			adminUser = user
+		}
+	}
+	return adminUser
+}
@@ -0,0 +1,365 @@
+// internal/api/handlers/prompt_admin_handler.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/domain/services"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// PromptAdminHandler handles the admin endpoints of the prompt registry: version history, drafting, review,
+// activation, diffs and rollbacks, plus prompt experiments, clinician feedback and version reports. The acting admin
+// is the "adminUser" set by AdminAuthMiddleware.
+type PromptAdminHandler struct {
+	registry    *services.PromptRegistryService
+	experiments *services.PromptExperimentService
+	logger      *zap.Logger
+}
+
+// NewPromptAdminHandler creates a new PromptAdminHandler instance, injecting the PromptRegistryService,
+// PromptExperimentService and Logger.
+func NewPromptAdminHandler(registry *services.PromptRegistryService, experiments *services.PromptExperimentService, logger *zap.Logger) *PromptAdminHandler {
+	return &PromptAdminHandler{
+		registry:    registry,
+		experiments: experiments,
+		logger:      logger.Named("PromptAdminHandler"),
+	}
+}
+
+// lifecycleRequest is the optional body of the lifecycle endpoints.
+type lifecycleRequest struct {
+	Comment string `json:"comment"`
+}
+
+// rollbackRequest is the body of the rollback endpoint. Version 0 (or no body) rolls back to the most recently
+// deactivated version.
+type rollbackRequest struct {
+	Version int    `json:"version"`
+	Comment string `json:"comment"`
+}
+
+// experimentRequest is the body of the start experiment endpoint. A TreatmentPercent of 0 (or none) assigns half of
+// the sessions to the treatment.
+type experimentRequest struct {
+	TreatmentVersion int `json:"treatment_version" binding:"required"`
+	TreatmentPercent int `json:"treatment_percent"`
+}
+
+// stopExperimentRequest is the optional body of the stop experiment endpoint.
+type stopExperimentRequest struct {
+	Reason string `json:"reason"`
+}
+
+// feedbackRequest is the body of the feedback endpoint.
+type feedbackRequest struct {
+	TargetType string    `json:"target_type" binding:"required"` // diagnosis, stage, nodule
+	TargetID   uuid.UUID `json:"target_id" binding:"required"`
+	Rating     int       `json:"rating" binding:"required"` // 1 (wrong) to 5 (fully agree)
+	Comment    string    `json:"comment"`
+}
+
+// ListVersions handles GET /admin/prompts/:name/versions, returning every version with its events, newest first.
+func (h *PromptAdminHandler) ListVersions(c *gin.Context) {
+	history, err := h.registry.History(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.respondWithError(c, "PromptAdminHandler.ListVersions", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"name": c.Param("name"), "versions": history})
+}
+
+// CreateDraft handles POST /admin/prompts/:name/versions, storing a services.PromptDraft body as a new draft version.
+func (h *PromptAdminHandler) CreateDraft(c *gin.Context) {
+	const operation = "PromptAdminHandler.CreateDraft"
+
+	var draft services.PromptDraft
+	if err := c.ShouldBindJSON(&draft); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid prompt draft: "+err.Error())
+		return
+	}
+	prompt, err := h.registry.CreateDraft(c.Request.Context(), c.Param("name"), c.GetString("adminUser"), draft)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"prompt": prompt})
+}
+
+// UpdateDraft handles PUT /admin/prompts/:name/versions/:version, replacing the content of a draft.
+func (h *PromptAdminHandler) UpdateDraft(c *gin.Context) {
+	const operation = "PromptAdminHandler.UpdateDraft"
+
+	version, ok := versionParam(c, "version")
+	if !ok {
+		return
+	}
+	var draft services.PromptDraft
+	if err := c.ShouldBindJSON(&draft); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid prompt draft: "+err.Error())
+		return
+	}
+	prompt, err := h.registry.UpdateDraft(c.Request.Context(), c.Param("name"), version, c.GetString("adminUser"), draft)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"prompt": prompt})
+}
+
+// Submit handles POST /admin/prompts/:name/versions/:version/submit.
+func (h *PromptAdminHandler) Submit(c *gin.Context) {
+	h.lifecycle(c, "PromptAdminHandler.Submit", h.registry.Submit)
+}
+
+// Approve handles POST /admin/prompts/:name/versions/:version/approve.
+func (h *PromptAdminHandler) Approve(c *gin.Context) {
+	h.lifecycle(c, "PromptAdminHandler.Approve", h.registry.Approve)
+}
+
+// Reject handles POST /admin/prompts/:name/versions/:version/reject.
+func (h *PromptAdminHandler) Reject(c *gin.Context) {
+	h.lifecycle(c, "PromptAdminHandler.Reject", h.registry.Reject)
+}
+
+// Activate handles POST /admin/prompts/:name/versions/:version/activate.
+func (h *PromptAdminHandler) Activate(c *gin.Context) {
+	h.lifecycle(c, "PromptAdminHandler.Activate", h.registry.Activate)
+}
+
+// Rollback handles POST /admin/prompts/:name/rollback, reactivating a previously active version.
+func (h *PromptAdminHandler) Rollback(c *gin.Context) {
+	const operation = "PromptAdminHandler.Rollback"
+
+	var req rollbackRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid rollback request: "+err.Error())
+			return
+		}
+	}
+	prompt, err := h.registry.Rollback(c.Request.Context(), c.Param("name"), req.Version, c.GetString("adminUser"), req.Comment)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"prompt": prompt})
+}
+
+// Diff handles GET /admin/prompts/:name/diff?from=<version>&to=<version>.
+func (h *PromptAdminHandler) Diff(c *gin.Context) {
+	const operation = "PromptAdminHandler.Diff"
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Query parameter 'from' must be a version number")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Query parameter 'to' must be a version number")
+		return
+	}
+	diff, err := h.registry.Diff(c.Request.Context(), c.Param("name"), from, to)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// ListExperiments handles GET /admin/prompts/:name/experiments, returning every experiment of a prompt, newest first.
+func (h *PromptAdminHandler) ListExperiments(c *gin.Context) {
+	experiments, err := h.experiments.ListExperiments(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.respondWithError(c, "PromptAdminHandler.ListExperiments", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"name": c.Param("name"), "experiments": experiments})
+}
+
+// StartExperiment handles POST /admin/prompts/:name/experiments, running an approved version against the active one.
+func (h *PromptAdminHandler) StartExperiment(c *gin.Context) {
+	const operation = "PromptAdminHandler.StartExperiment"
+
+	var req experimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid experiment request: "+err.Error())
+		return
+	}
+	experiment, err := h.experiments.StartExperiment(c.Request.Context(), c.Param("name"), req.TreatmentVersion, req.TreatmentPercent, c.GetString("adminUser"))
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"experiment": experiment})
+}
+
+// StopExperiment handles POST /admin/prompts/:name/experiments/:id/stop.
+func (h *PromptAdminHandler) StopExperiment(c *gin.Context) {
+	const operation = "PromptAdminHandler.StopExperiment"
+
+	experimentID, ok := experimentParam(c)
+	if !ok {
+		return
+	}
+	var req stopExperimentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	experiment, err := h.experiments.StopExperiment(c.Request.Context(), c.Param("name"), experimentID, c.GetString("adminUser"), req.Reason)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"experiment": experiment})
+}
+
+// ExperimentReport handles GET /admin/prompts/:name/experiments/:id/report, comparing the arms of an experiment.
+func (h *PromptAdminHandler) ExperimentReport(c *gin.Context) {
+	experimentID, ok := experimentParam(c)
+	if !ok {
+		return
+	}
+	report, err := h.experiments.ExperimentReport(c.Request.Context(), c.Param("name"), experimentID)
+	if err != nil {
+		h.respondWithError(c, "PromptAdminHandler.ExperimentReport", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Report handles GET /admin/prompts/:name/report?from=<RFC 3339>&to=<RFC 3339>, comparing every version used in the
+// period (by default the last 30 days).
+func (h *PromptAdminHandler) Report(c *gin.Context) {
+	from, to, ok := periodParams(c)
+	if !ok {
+		return
+	}
+	report, err := h.experiments.Report(c.Request.Context(), c.Param("name"), from, to)
+	if err != nil {
+		h.respondWithError(c, "PromptAdminHandler.Report", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// SubmitFeedback handles POST /admin/feedback, storing the acting admin's rating of a stored diagnosis, stage or
+// nodule against the prompt version that generated it.
+func (h *PromptAdminHandler) SubmitFeedback(c *gin.Context) {
+	const operation = "PromptAdminHandler.SubmitFeedback"
+
+	var req feedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid feedback: "+err.Error())
+		return
+	}
+	feedback := &models.PromptFeedback{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reviewer:   c.GetString("adminUser"),
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+	}
+	if err := h.experiments.SubmitFeedback(c.Request.Context(), feedback); err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"feedback": feedback})
+}
+
+// lifecycle runs a review or activation action on the version named by the request path.
+func (h *PromptAdminHandler) lifecycle(c *gin.Context, operation string, action func(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error)) {
+	version, ok := versionParam(c, "version")
+	if !ok {
+		return
+	}
+	var req lifecycleRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	prompt, err := action(c.Request.Context(), c.Param("name"), version, c.GetString("adminUser"), req.Comment)
+	if err != nil {
+		h.respondWithError(c, operation, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"prompt": prompt})
+}
+
+// respondWithError maps registry errors to HTTP status codes.
+func (h *PromptAdminHandler) respondWithError(c *gin.Context, operation string, err error) {
+	requestID := utils.GetRequestID(c.Request.Context())
+
+	var (
+		notFoundErr   *domain.NotFoundError
+		validationErr *domain.ValidationError
+		forbiddenErr  *domain.ForbiddenError
+		transitionErr *domain.InvalidTransitionError
+		conflictErr   *domain.ConflictError
+	)
+	switch {
+	case errors.As(err, &notFoundErr):
+		utils.RespondWithError(c, http.StatusNotFound, err.Error())
+	case errors.As(err, &validationErr):
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+	case errors.As(err, &forbiddenErr):
+		h.logger.Warn("Prompt registry action forbidden", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("admin_user", c.GetString("adminUser")), zap.Error(err))
+		utils.RespondWithError(c, http.StatusForbidden, err.Error())
+	case errors.As(err, &transitionErr), errors.As(err, &conflictErr):
+		utils.RespondWithError(c, http.StatusConflict, err.Error())
+	default:
+		h.logger.Error("Prompt registry request failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		utils.RespondWithError(c, http.StatusInternalServerError, "Prompt registry request failed")
+	}
+}
+
+// periodParams parses the optional from and to query parameters (RFC 3339 times), responding with 400 when one is
+// malformed. Missing parameters are zero.
+func periodParams(c *gin.Context) (from, to time.Time, ok bool) {
+	for param, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				utils.RespondWithError(c, http.StatusBadRequest, "Query parameter '"+param+"' must be an RFC 3339 time")
+				return time.Time{}, time.Time{}, false
+			}
+			*t = parsed
+		}
+	}
+	return from, to, true
+}
+
+// experimentParam parses the experiment ID path parameter, responding with 400 when it is not a UUID.
+func experimentParam(c *gin.Context) (uuid.UUID, bool) {
+	experimentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Path parameter 'id' must be an experiment ID")
+		return uuid.Nil, false
+	}
+	return experimentID, true
+}
+
+// versionParam parses a version number path parameter, responding with 400 when it is not one.
+func versionParam(c *gin.Context, name string) (int, bool) {
+	version, err := strconv.Atoi(c.Param(name))
+	if err != nil || version < 1 {
+		utils.RespondWithError(c, http.StatusBadRequest, "Path parameter '"+name+"' must be a version number")
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,50 @@
+// internal/api/handlers/usage_admin_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/domain/services"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// UsageAdminHandler handles the admin endpoint reporting the token usage and estimated cost of model calls.
+type UsageAdminHandler struct {
+	usage  *services.LLMUsageService
+	logger *zap.Logger
+}
+
+// NewUsageAdminHandler creates a new UsageAdminHandler instance, injecting the LLMUsageService and Logger.
+func NewUsageAdminHandler(usage *services.LLMUsageService, logger *zap.Logger) *UsageAdminHandler {
+	return &UsageAdminHandler{
+		usage:  usage,
+		logger: logger.Named("UsageAdminHandler"),
+	}
+}
+
+// Report handles GET /admin/usage?from=<RFC 3339>&to=<RFC 3339>, aggregating the model calls of the period (by
+// default the last 30 days) by UTC day, method and prompt version.
+func (h *UsageAdminHandler) Report(c *gin.Context) {
+	const operation = "UsageAdminHandler.Report"
+
+	from, to, ok := periodParams(c)
+	if !ok {
+		return
+	}
+	report, err := h.usage.Report(c.Request.Context(), from, to)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("Model usage report failed", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(c.Request.Context())), zap.Error(err))
+		utils.RespondWithError(c, http.StatusInternalServerError, "Model usage report failed")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
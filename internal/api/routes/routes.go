@@ -20,12 +20,18 @@ import (
 //   - reportHandler *handlers.ReportHandler: Handler for report-related endpoints.
 //   - healthHandler *handlers.HealthHandler: Handler for health check endpoints.
 //   - diagnosisHandler *handlers.DiagnosisHandler: Handler for diagnosis-related endpoints.
+//   - promptAdminHandler *handlers.PromptAdminHandler: Handler for the prompt registry admin endpoints.
+//   - usageAdminHandler *handlers.UsageAdminHandler: Handler for the model usage admin endpoint.
+//   - adminAuth gin.HandlerFunc: Middleware authenticating admin requests (handlers.AdminAuthMiddleware).
 func SetupRouter(
 	r *gin.Engine,
 	fileHandler *handlers.FileHandler, // Corrected: Use specific handler types instead of handlers.Handler
 	reportHandler *handlers.ReportHandler, // Corrected: Use specific handler types instead of handlers.Handler
 	healthHandler *handlers.HealthHandler, // Corrected: Use specific handler types instead of handlers.Handler
 	diagnosisHandler *handlers.DiagnosisHandler, // Corrected: Use specific handler types instead of handlers.Handler
+	promptAdminHandler *handlers.PromptAdminHandler,
+	usageAdminHandler *handlers.UsageAdminHandler,
+	adminAuth gin.HandlerFunc,
 ) {
 	// --- API Version 1 Routes ---
 	// Group for API version 1, under the path "/api/v1".
@@ -40,8 +46,10 @@ func SetupRouter(
 		// Apply LinkValidationMiddleware and DataQualityCheckMiddleware to the /upload group to ensure secure access and data integrity.
 		fileUpload := v1.Group("/upload" /*, middleware.LinkValidationMiddleware(), middleware.DataQualityCheckMiddleware() */) // Example of commented-out middleware application for future implementation
 		{
-			// POST /api/v1/upload: Endpoint for patients to upload medical documents (DICOM, PDF, JPEG, PNG, CSV). - BE-010, US-003
+			// POST /api/v1/upload: Endpoint for patients to upload medical documents (DICOM, PDF, JPEG, PNG, CSV) or a ZIP archive of them, e.g. an imaging CD with a DICOMDIR. - BE-010, US-003
 			fileUpload.POST("", fileHandler.UploadFile) // Corrected: Use fileHandler parameter
+			// POST /api/v1/upload/analyze: Assembles the DICOM instances uploaded so far into series and runs nodule detection per series. - BE-029, BE-030
+			fileUpload.POST("/analyze", fileHandler.AnalyzeUploads)
 			// GET /api/v1/status/:upload_id: Endpoint for retrieving the status of a file upload and processing job, using upload_id as a path parameter. - BE-017
 			v1.GET("/status/:upload_id", fileHandler.GetUploadStatus) // Corrected: Use fileHandler parameter
 		}
@@ -71,11 +79,46 @@ func SetupRouter(
 		// v1.POST("/feedback", h.FeedbackHandler.SubmitFeedback) // Placeholder for feedback submission - US-014
 	}
 
-	// --- Example Admin Routes Group (Not fully implemented in current scope) ---
-	// Group for admin-specific functionalities, under the path "/api/v1/admin".
-	// Requires separate admin authentication and authorization middleware (not implemented in this version).
-	admin := v1.Group("/admin" /*, middleware.AdminAuthMiddleware(), middleware.AdminAccessControlMiddleware()*/) // Example of grouped admin routes with placeholder middleware
+	// --- Admin Routes Group ---
+	// Group for admin-specific functionalities, under the path "/api/v1/admin" (handlers.AdminPathPrefix).
+	// Authenticated with the bearer tokens of ADMIN_TOKENS instead of access links.
+	admin := v1.Group("/admin", adminAuth)
 	{
+		// --- Prompt Registry Endpoints ---
+		// GET /api/v1/admin/prompts/:name/versions: Every version of a managed prompt with its lifecycle events, newest first.
+		admin.GET("/prompts/:name/versions", promptAdminHandler.ListVersions)
+		// POST /api/v1/admin/prompts/:name/versions: Creates a draft version (template, variables, output_format).
+		admin.POST("/prompts/:name/versions", promptAdminHandler.CreateDraft)
+		// PUT /api/v1/admin/prompts/:name/versions/:version: Edits a draft (author only).
+		admin.PUT("/prompts/:name/versions/:version", promptAdminHandler.UpdateDraft)
+		// POST /api/v1/admin/prompts/:name/versions/:version/{submit,approve,reject,activate}: Lifecycle actions; approve and reject are not allowed to the author.
+		admin.POST("/prompts/:name/versions/:version/submit", promptAdminHandler.Submit)
+		admin.POST("/prompts/:name/versions/:version/approve", promptAdminHandler.Approve)
+		admin.POST("/prompts/:name/versions/:version/reject", promptAdminHandler.Reject)
+		admin.POST("/prompts/:name/versions/:version/activate", promptAdminHandler.Activate)
+		// GET /api/v1/admin/prompts/:name/diff?from=&to=: Line diff between two versions.
+		admin.GET("/prompts/:name/diff", promptAdminHandler.Diff)
+		// POST /api/v1/admin/prompts/:name/rollback: Reactivates a previously active version (default: the most recently deactivated one).
+		admin.POST("/prompts/:name/rollback", promptAdminHandler.Rollback)
+
+		// --- Prompt Experiment Endpoints ---
+		// GET /api/v1/admin/prompts/:name/experiments: Every A/B experiment of a prompt, newest first.
+		admin.GET("/prompts/:name/experiments", promptAdminHandler.ListExperiments)
+		// POST /api/v1/admin/prompts/:name/experiments: Runs an approved version (treatment_version) against the active one for treatment_percent of the sessions.
+		admin.POST("/prompts/:name/experiments", promptAdminHandler.StartExperiment)
+		// POST /api/v1/admin/prompts/:name/experiments/:id/stop: Stops an experiment; every session gets the active version again.
+		admin.POST("/prompts/:name/experiments/:id/stop", promptAdminHandler.StopExperiment)
+		// GET /api/v1/admin/prompts/:name/experiments/:id/report: Parse failures, errors and clinician ratings per experiment arm.
+		admin.GET("/prompts/:name/experiments/:id/report", promptAdminHandler.ExperimentReport)
+		// GET /api/v1/admin/prompts/:name/report?from=&to=: Parse failures, errors and clinician ratings per prompt version (default: last 30 days).
+		admin.GET("/prompts/:name/report", promptAdminHandler.Report)
+		// POST /api/v1/admin/feedback: Clinician rating (1-5) of a stored diagnosis, stage or nodule.
+		admin.POST("/feedback", promptAdminHandler.SubmitFeedback)
+
+		// --- Model Usage Endpoints ---
+		// GET /api/v1/admin/usage?from=&to=: Calls, tokens, latency and estimated cost per UTC day, method and prompt version (default: last 30 days), with the token budgets.
+		admin.GET("/usage", usageAdminHandler.Report)
+
 		// --- Admin Monitoring Endpoints (Example) ---
 		// GET /api/v1/admin/metrics: Placeholder for admin metrics endpoint (system performance monitoring). - US-017, BE-059
 		admin.GET("/metrics", healthHandler.HealthCheck /* h.AdminHandler.GetMetrics*/) // Example placeholder for admin metrics endpoint - US-017, BE-059 // Corrected: Use healthHandler parameter
@@ -37,10 +37,58 @@ type Config struct {
 	DBConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"` // Maximum time a connection may be idle before being closed
 
 	GeminiAPIKey     string        `mapstructure:"GEMINI_API_KEY"`     // Google AI Gemini API key (sensitive, use secrets management in production)
-	GeminiAPITimeout time.Duration `mapstructure:"GEMINI_API_TIMEOUT"` // Timeout for Gemini API calls, e.g., "30s", "1m"
-	GcloudProject    string        `mapstructure:"GCLOUD_PROJECT"`     // Google Cloud Project ID (required if using Google Cloud services)
+	GeminiAPITimeout time.Duration `mapstructure:"GEMINI_API_TIMEOUT"` // Timeout for Gemini API calls, e.g., "30s", "1m" (also used by the OpenAI-compatible provider)
+	GeminiModel      string        `mapstructure:"GEMINI_MODEL"`       // Gemini model used for generateContent calls. Default: "gemini-1.5-pro"
+	GeminiBaseURL    string        `mapstructure:"GEMINI_BASE_URL"`    // Base URL of the Gemini REST API. Default: "https://generativelanguage.googleapis.com/v1beta"
+
+	LLMProvider   string `mapstructure:"LLM_PROVIDER"`    // Model provider behind gemini.GeminiClient: "gemini", "openai" (any OpenAI-compatible server, e.g. vLLM, llama.cpp) or "fake" (fixtures, development only). Default: "gemini"
+	OpenAIBaseURL string `mapstructure:"OPENAI_BASE_URL"` // Base URL of the OpenAI-compatible API, including the version path. Default: "http://localhost:8000/v1"
+	OpenAIAPIKey  string `mapstructure:"OPENAI_API_KEY"`  // Bearer token for the OpenAI-compatible API; empty sends none (sensitive)
+	OpenAIModel   string `mapstructure:"OPENAI_MODEL"`    // Model name served by the OpenAI-compatible API. Required when LLM_PROVIDER is "openai"
+
+	FakeLLMFixtures   string        `mapstructure:"FAKE_LLM_FIXTURES"`    // Directory of the fake provider's fixtures (<Method>/<key>.json). Required when LLM_PROVIDER is "fake"
+	FakeLLMMode       string        `mapstructure:"FAKE_LLM_MODE"`        // "replay" (fixtures only) or "record" (save the real provider's answers for calls without a fixture). Default: "replay"
+	FakeLLMRecordFrom string        `mapstructure:"FAKE_LLM_RECORD_FROM"` // Real provider ("gemini" or "openai") called in record mode. Default: "gemini"
+	FakeLLMLatency    time.Duration `mapstructure:"FAKE_LLM_LATENCY"`     // Latency added to every fake call, on top of a fixture's own. Default: 0
+
+	GeminiMaxRetries       int           `mapstructure:"GEMINI_MAX_RETRIES"`       // Retries of a Gemini call after a 429, 5xx or timeout. Default: 3
+	GeminiRetryBaseDelay   time.Duration `mapstructure:"GEMINI_RETRY_BASE_DELAY"`  // Backoff before the first retry, doubled (with jitter) for each further one. Default: 500ms
+	GeminiBreakerThreshold int           `mapstructure:"GEMINI_BREAKER_THRESHOLD"` // Consecutive failed calls of one method that open its circuit breaker. Default: 5
+	GeminiBreakerCooldown  time.Duration `mapstructure:"GEMINI_BREAKER_COOLDOWN"`  // Time an open breaker rejects calls before letting a probe through. Default: 30s
+	GcloudProject          string        `mapstructure:"GCLOUD_PROJECT"`           // Google Cloud Project ID (required if using Google Cloud services)
+
+	LLMCacheBackend string        `mapstructure:"LLM_CACHE_BACKEND"` // Cache of model responses: "memory" (LRU per process), "postgres" (llmcache table, shared) or "none". Default: "memory" if FILE_ENCRYPTION_KEY is set, else "none"
+	LLMCacheTTL     time.Duration `mapstructure:"LLM_CACHE_TTL"`     // Time a cached model response is reused. Default: 24h
+	LLMCacheSize    int           `mapstructure:"LLM_CACHE_SIZE"`    // Responses kept by the "memory" backend before the least recently used is evicted. Default: 1000
+
+	LLMDailyTokenBudget   int64   `mapstructure:"LLM_DAILY_TOKEN_BUDGET"`   // Prompt and output tokens all model calls may use per UTC day before calls are rejected; 0 is unlimited. Default: 0
+	LLMSessionTokenBudget int64   `mapstructure:"LLM_SESSION_TOKEN_BUDGET"` // Prompt and output tokens the model calls of one session may use before they are rejected; 0 is unlimited. Default: 0
+	LLMPromptTokenPrice   float64 `mapstructure:"LLM_PROMPT_TOKEN_PRICE"`   // Price of one million prompt tokens, used for the cost estimates of the usage report. Default: 0
+	LLMOutputTokenPrice   float64 `mapstructure:"LLM_OUTPUT_TOKEN_PRICE"`   // Price of one million output tokens, used for the cost estimates of the usage report. Default: 0
+
+	OCRBackend           string `mapstructure:"OCR_BACKEND"`              // OCR engine for scanned reports: "google" (Cloud Vision API) or "tesseract" (local tesseract CLI, no network access). Default: "google"
+	TesseractPath        string `mapstructure:"TESSERACT_PATH"`           // Tesseract executable, looked up in PATH unless it is a path. Default: "tesseract"
+	TesseractLanguages   string `mapstructure:"TESSERACT_LANGUAGES"`      // Tesseract languages joined with "+", e.g. "eng+deu"; their traineddata must be installed. Default: "eng"
+	OCRPDFRendererPath   string `mapstructure:"OCR_PDF_RENDERER_PATH"`    // pdftoppm (poppler-utils) executable rendering PDF pages for Tesseract. Default: "pdftoppm"
+	OCRPDFDPI            int    `mapstructure:"OCR_PDF_DPI"`              // Resolution PDF pages are rendered at for Tesseract. Default: 300
+	OCRPDFMaxPages       int    `mapstructure:"OCR_PDF_MAX_PAGES"`        // Pages of a PDF rendered and recognized by Tesseract in one call; later pages are ignored. Default: 50
+	PDFTextLayerMinChars int    `mapstructure:"PDF_TEXT_LAYER_MIN_CHARS"` // Letters and digits a PDF page's text layer needs to be used instead of OCRing the page. Default: 50
+
+	OCRLowConfidenceThreshold float64 `mapstructure:"OCR_LOW_CONFIDENCE_THRESHOLD"` // OCRed lines below this confidence (0.0-1.0) mark the findings taken from them as low confidence, shown as "please verify with the original". Default: 0.8
 
 	ReportTemplatePath string `mapstructure:"REPORT_TEMPLATE_PATH"` // Path to the directory containing report templates (e.g., "./internal/pdf/templates")
+	PromptTemplatePath string `mapstructure:"PROMPT_TEMPLATE_PATH"` // Path to the directory containing the Gemini prompt files (JSON, YAML, TOML). Default: "./internal/gemini/prompts"
+
+	PromptCacheTTL time.Duration `mapstructure:"PROMPT_CACHE_TTL"` // Time an active prompt version read from the prompt registry is cached; changes made through the admin API invalidate it at once. Default: 1m
+	AdminTokens    string        `mapstructure:"ADMIN_TOKENS"`     // Admin API credentials as "user:token" pairs, comma-separated, e.g. "alice:s3cret,bob:t0ken". Empty disables the admin API (sensitive)
+
+	ImageWindowPreset string `mapstructure:"IMAGE_WINDOW_PRESET"` // CT window used when rendering slices for Gemini: "lung", "mediastinal" or "bone". Default: "lung"
+	ImageMaxEdge      int    `mapstructure:"IMAGE_MAX_EDGE"`      // Longest edge (pixels) of rendered slices sent to Gemini; 0 keeps the native size. Default: 512
+	SeriesMaxVoxels   int64  `mapstructure:"SERIES_MAX_VOXELS"`   // Rows × columns × slices above which a DICOM series is refused before any slice is decoded. Default: 512×512×2000
+
+	DeidRetainLongitudinalDates bool   `mapstructure:"DEID_RETAIN_LONGITUDINAL_DATES"` // PS3.15 Retain Longitudinal Temporal Information with Full Dates option: keep DICOM dates/times instead of shifting dates by the session offset. Default: false
+	DeidCleanDescriptors        bool   `mapstructure:"DEID_CLEAN_DESCRIPTORS"`         // PS3.15 Clean Descriptors option: clean study/series descriptions instead of removing them. Default: false
+	DeidTextMethods             string `mapstructure:"DEID_TEXT_METHODS"`              // Per-category methods for report text, e.g. "name=surrogate,date=date_shift"; unlisted categories are redacted, except dates, which are shifted by the session offset. Default: ""
 
 	StorageType        string `mapstructure:"STORAGE_TYPE"`         // Storage type: "cloud" (AWS S3, GCP Storage, Azure Blob Storage) or "local" (local filesystem)
 	CloudStorageBucket string `mapstructure:"CLOUD_STORAGE_BUCKET"` // Name of the cloud storage bucket (required if STORAGE_TYPE=cloud, sensitive!)
@@ -147,6 +195,150 @@ func LoadConfig(ctx context.Context, path string) (config Config, err error) {
 		config.GeminiAPITimeout = 30 * time.Second                          // Default Gemini API timeout to 30 seconds
 		log.Println("GEMINI_API_TIMEOUT not set, defaulting to 30 seconds") // Log default value assignment
 	}
+	if config.GeminiModel == "" {
+		config.GeminiModel = "gemini-1.5-pro"                               // Default Gemini model
+		log.Println("GEMINI_MODEL not set, defaulting to 'gemini-1.5-pro'") // Log default value assignment
+	}
+	if config.GeminiBaseURL == "" {
+		config.GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta" // Default to the public Gemini REST API
+		log.Println("GEMINI_BASE_URL not set, using the public Gemini API")       // Log default value assignment
+	}
+	if config.LLMProvider == "" {
+		config.LLMProvider = "gemini"                               // Default to the Gemini API
+		log.Println("LLM_PROVIDER not set, defaulting to 'gemini'") // Log default value assignment
+	}
+	if config.LLMProvider == "openai" {
+		if config.OpenAIModel == "" {
+			return Config{}, fmt.Errorf("environment variable OPENAI_MODEL is required when LLM_PROVIDER is 'openai'")
+		}
+		if config.OpenAIBaseURL == "" {
+			config.OpenAIBaseURL = "http://localhost:8000/v1"                                // Default to a local vLLM server
+			log.Println("OPENAI_BASE_URL not set, defaulting to 'http://localhost:8000/v1'") // Log default value assignment
+		}
+	}
+	if config.LLMProvider == "fake" {
+		if config.Environment != DevelopmentEnvironment {
+			return Config{}, fmt.Errorf("LLM_PROVIDER 'fake' is only allowed when ENVIRONMENT is '%s'", DevelopmentEnvironment)
+		}
+		if config.FakeLLMFixtures == "" {
+			return Config{}, fmt.Errorf("environment variable FAKE_LLM_FIXTURES is required when LLM_PROVIDER is 'fake'")
+		}
+		if config.FakeLLMMode == "" {
+			config.FakeLLMMode = "replay"                                // Default to answering from fixtures only
+			log.Println("FAKE_LLM_MODE not set, defaulting to 'replay'") // Log default value assignment
+		}
+		if config.FakeLLMRecordFrom == "" {
+			config.FakeLLMRecordFrom = "gemini" // Default to recording Gemini API answers
+		}
+	}
+	if config.GeminiMaxRetries == 0 {
+		config.GeminiMaxRetries = 3                                // Default to three retries per Gemini call
+		log.Println("GEMINI_MAX_RETRIES not set, defaulting to 3") // Log default value assignment
+	}
+	if config.GeminiRetryBaseDelay == 0 {
+		config.GeminiRetryBaseDelay = 500 * time.Millisecond                // Default first backoff to 500 milliseconds
+		log.Println("GEMINI_RETRY_BASE_DELAY not set, defaulting to 500ms") // Log default value assignment
+	}
+	if config.GeminiBreakerThreshold == 0 {
+		config.GeminiBreakerThreshold = 5                                // Default to opening a breaker after five consecutive failures
+		log.Println("GEMINI_BREAKER_THRESHOLD not set, defaulting to 5") // Log default value assignment
+	}
+	if config.GeminiBreakerCooldown == 0 {
+		config.GeminiBreakerCooldown = 30 * time.Second                          // Default breaker cooldown to 30 seconds
+		log.Println("GEMINI_BREAKER_COOLDOWN not set, defaulting to 30 seconds") // Log default value assignment
+	}
+	if config.LLMCacheBackend == "" {
+		if config.FileEncryptionKey != "" {
+			config.LLMCacheBackend = "memory"                                // Default to a per-process cache of model responses
+			log.Println("LLM_CACHE_BACKEND not set, defaulting to 'memory'") // Log default value assignment
+		} else {
+			config.LLMCacheBackend = "none"                                                                 // Cached responses are encrypted; without a key there is no cache
+			log.Println("LLM_CACHE_BACKEND not set and FILE_ENCRYPTION_KEY is empty, defaulting to 'none'") // Log default value assignment
+		}
+	}
+	if config.LLMCacheBackend != "none" && config.FileEncryptionKey == "" {
+		return Config{}, fmt.Errorf("environment variable FILE_ENCRYPTION_KEY is required when LLM_CACHE_BACKEND is '%s'", config.LLMCacheBackend)
+	}
+	if config.LLMCacheTTL == 0 {
+		config.LLMCacheTTL = 24 * time.Hour                          // Default to reusing model responses for a day
+		log.Println("LLM_CACHE_TTL not set, defaulting to 24 hours") // Log default value assignment
+	}
+	if config.LLMCacheSize == 0 {
+		config.LLMCacheSize = 1000                                // Default to keeping 1000 responses in memory
+		log.Println("LLM_CACHE_SIZE not set, defaulting to 1000") // Log default value assignment
+	}
+	if config.LLMDailyTokenBudget < 0 || config.LLMSessionTokenBudget < 0 {
+		return Config{}, fmt.Errorf("LLM_DAILY_TOKEN_BUDGET and LLM_SESSION_TOKEN_BUDGET must not be negative")
+	}
+	if config.LLMPromptTokenPrice < 0 || config.LLMOutputTokenPrice < 0 {
+		return Config{}, fmt.Errorf("LLM_PROMPT_TOKEN_PRICE and LLM_OUTPUT_TOKEN_PRICE must not be negative")
+	}
+	if config.OCRBackend == "" {
+		config.OCRBackend = "google"                               // Default to the Google Cloud Vision API
+		log.Println("OCR_BACKEND not set, defaulting to 'google'") // Log default value assignment
+	}
+	if config.OCRBackend == "tesseract" {
+		if config.TesseractPath == "" {
+			config.TesseractPath = "tesseract"                               // Default to tesseract in PATH
+			log.Println("TESSERACT_PATH not set, defaulting to 'tesseract'") // Log default value assignment
+		}
+		if config.TesseractLanguages == "" {
+			config.TesseractLanguages = "eng"                               // Default to English
+			log.Println("TESSERACT_LANGUAGES not set, defaulting to 'eng'") // Log default value assignment
+		}
+		if config.OCRPDFRendererPath == "" {
+			config.OCRPDFRendererPath = "pdftoppm"                                 // Default to pdftoppm in PATH
+			log.Println("OCR_PDF_RENDERER_PATH not set, defaulting to 'pdftoppm'") // Log default value assignment
+		}
+		if config.OCRPDFDPI == 0 {
+			config.OCRPDFDPI = 300                                // Default to the resolution Tesseract is trained for
+			log.Println("OCR_PDF_DPI not set, defaulting to 300") // Log default value assignment
+		}
+		if config.OCRPDFDPI < 0 {
+			return Config{}, fmt.Errorf("OCR_PDF_DPI must be positive")
+		}
+		if config.OCRPDFMaxPages == 0 {
+			config.OCRPDFMaxPages = 50                                 // Default to far more pages than a clinical report has
+			log.Println("OCR_PDF_MAX_PAGES not set, defaulting to 50") // Log default value assignment
+		}
+		if config.OCRPDFMaxPages < 0 {
+			return Config{}, fmt.Errorf("OCR_PDF_MAX_PAGES must be positive")
+		}
+	}
+	if config.PDFTextLayerMinChars == 0 {
+		config.PDFTextLayerMinChars = 50                                  // Default to about a line of text
+		log.Println("PDF_TEXT_LAYER_MIN_CHARS not set, defaulting to 50") // Log default value assignment
+	}
+	if config.PDFTextLayerMinChars < 0 {
+		return Config{}, fmt.Errorf("PDF_TEXT_LAYER_MIN_CHARS must be positive")
+	}
+	if config.OCRLowConfidenceThreshold == 0 {
+		config.OCRLowConfidenceThreshold = 0.8                                 // Default to flagging lines with more than about one doubtful word in five
+		log.Println("OCR_LOW_CONFIDENCE_THRESHOLD not set, defaulting to 0.8") // Log default value assignment
+	}
+	if config.OCRLowConfidenceThreshold < 0 || config.OCRLowConfidenceThreshold > 1 {
+		return Config{}, fmt.Errorf("OCR_LOW_CONFIDENCE_THRESHOLD must be between 0 and 1")
+	}
+	if config.PromptTemplatePath == "" {
+		config.PromptTemplatePath = "./internal/gemini/prompts"                                // Default to the prompts shipped with the source tree
+		log.Println("PROMPT_TEMPLATE_PATH not set, defaulting to './internal/gemini/prompts'") // Log default value assignment
+	}
+	if config.PromptCacheTTL == 0 {
+		config.PromptCacheTTL = time.Minute                             // Default to re-reading active prompt versions every minute
+		log.Println("PROMPT_CACHE_TTL not set, defaulting to 1 minute") // Log default value assignment
+	}
+	if config.ImageWindowPreset == "" {
+		config.ImageWindowPreset = "lung"                                // Default to the lung window for nodule detection
+		log.Println("IMAGE_WINDOW_PRESET not set, defaulting to 'lung'") // Log default value assignment
+	}
+	if config.ImageMaxEdge == 0 {
+		config.ImageMaxEdge = 512                                // Default rendered slice size to 512 pixels on the longest edge
+		log.Println("IMAGE_MAX_EDGE not set, defaulting to 512") // Log default value assignment
+	}
+	if config.SeriesMaxVoxels == 0 {
+		config.SeriesMaxVoxels = 512 * 512 * 2000                            // Default to 2000 slices of 512×512, the pending instance limit
+		log.Println("SERIES_MAX_VOXELS not set, defaulting to 512×512×2000") // Log default value assignment
+	}
 	if config.MaxFileSize == 0 {
 		config.MaxFileSize = 50 * 1024 * 1024                    // Default max file size to 50MB if not set
 		log.Println("MAX_FILE_SIZE not set, defaulting to 50MB") // Log default value assignment
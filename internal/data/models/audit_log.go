@@ -0,0 +1,21 @@
+// internal/data/models/audit_log.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog represents one entry of the security audit trail. Details must never contain PHI or clinical findings,
+// only what happened (e.g. which region of an image was redacted and why).
+type AuditLog struct {
+	ID        int64           `json:"log_id" db:"log_id"`
+	Timestamp time.Time       `json:"timestamp" db:"timestamp"`
+	Action    string          `json:"action" db:"action"`         // e.g., "burned_in_text_redacted"
+	Details   json.RawMessage `json:"details" db:"details"`       // Action-specific JSON object
+	SessionID uuid.UUID       `json:"session_id" db:"session_id"` // Optional; uuid.Nil is stored as NULL
+	ContentID uuid.UUID       `json:"content_id" db:"content_id"` // Optional; uuid.Nil is stored as NULL
+	ResultID  uuid.UUID       `json:"result_id" db:"result_id"`   // Optional; uuid.Nil is stored as NULL
+}
@@ -17,4 +17,6 @@ type Diagnosis struct {
 	Justification string    `json:"justification" db:"justification"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	PromptVersionID uuid.UUID `json:"prompt_version_id,omitempty" db:"prompt_version_id"` // Prompt registry version used; uuid.Nil for the prompt file
 }
@@ -13,4 +13,6 @@ type Finding struct {
 	Description      string    `json:"description" db:"description"`             // Patient-friendly description.
 	ImageCoordinates []float64 `json:"image_coordinates" db:"image_coordinates"` // Optional: Image coordinates (if applicable).
 	Source           string    `json:"source" db:"source"`                       // e.g., "radiology report", "pathology report", "patient input"
+	LowConfidence    bool      `json:"low_confidence" db:"low_confidence"`       // Taken from text OCR read with low confidence: to be verified against the original
+	Quote            string    `json:"-" db:"-"`                                 // Report text the finding was taken from, used to trace it to OCRed lines; not stored
 }
@@ -0,0 +1,32 @@
+// internal/data/models/lab_observation.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Formats of the uploads lab observations are read from (LabObservation.Source).
+const (
+	LabSourceCSV = "csv" // CSV export of a patient portal or lab system
+	LabSourceHL7 = "hl7" // HL7 v2 ORU^R01 result message
+)
+
+// LabObservation is a normalized lab result of a session. Flag is one of the labs.Flag constants, or "" if unknown.
+type LabObservation struct {
+	ID             uuid.UUID  `json:"id" db:"observation_id"`
+	SessionID      uuid.UUID  `json:"session_id" db:"session_id"`
+	Source         string     `json:"source" db:"source"` // One of the LabSource constants
+	Analyte        string     `json:"analyte" db:"analyte"`
+	Code           string     `json:"code,omitempty" db:"code"`                       // e.g. LOINC code
+	Value          string     `json:"value" db:"value"`                               // As reported: "5.2", "<0.5", "Positive"
+	NumericValue   *float64   `json:"numeric_value,omitempty" db:"numeric_value"`     // nil for text and censored values
+	Unit           string     `json:"unit,omitempty" db:"unit"`                       // Normalized spelling
+	ReferenceRange string     `json:"reference_range,omitempty" db:"reference_range"` // As reported
+	ReferenceLow   *float64   `json:"reference_low,omitempty" db:"reference_low"`
+	ReferenceHigh  *float64   `json:"reference_high,omitempty" db:"reference_high"`
+	Flag           string     `json:"flag,omitempty" db:"flag"`
+	ObservedAt     *time.Time `json:"observed_at,omitempty" db:"observed_at"` // Date-shifted; nil if unknown
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
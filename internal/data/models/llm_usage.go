@@ -0,0 +1,36 @@
+// internal/data/models/llm_usage.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LLMUsage is the recorded token usage and latency of one model call. Outcome is one of the PromptCall outcome
+// constants.
+type LLMUsage struct {
+	SessionID       uuid.UUID     `json:"session_id" db:"session_id"` // uuid.Nil if the call was not made for a session
+	Method          string        `json:"method" db:"method"`         // GeminiClient method
+	PromptName      string        `json:"prompt_name" db:"prompt_name"`
+	PromptVersion   int           `json:"prompt_version" db:"prompt_version"`       // 0 for the prompt file
+	PromptVersionID uuid.UUID     `json:"prompt_version_id" db:"prompt_version_id"` // uuid.Nil for the prompt file
+	PromptTokens    int           `json:"prompt_tokens" db:"prompt_tokens"`
+	OutputTokens    int           `json:"output_tokens" db:"output_tokens"`
+	Latency         time.Duration `json:"latency" db:"latency_ms"`
+	Outcome         string        `json:"outcome" db:"outcome"`
+}
+
+// LLMUsageSummary aggregates the recorded calls of one method and prompt version on one UTC day.
+type LLMUsageSummary struct {
+	Day              time.Time `json:"day"`
+	Method           string    `json:"method"`
+	PromptName       string    `json:"prompt_name"`
+	PromptVersion    int       `json:"prompt_version"` // 0 for the prompt file
+	Calls            int       `json:"calls"`
+	Failures         int       `json:"failures"` // Calls with a parse_failure or error outcome
+	PromptTokens     int64     `json:"prompt_tokens"`
+	OutputTokens     int64     `json:"output_tokens"`
+	AverageLatencyMS float64   `json:"average_latency_ms"`
+	P95LatencyMS     float64   `json:"p95_latency_ms"`
+}
@@ -12,4 +12,6 @@ type Nodule struct {
 	Location string    `json:"location" db:"description"`   // Corrected db tag to "description" - maps to finding description.
 	Size     float64   `json:"size" db:"image_coordinates"` // Corrected db tag to "image_coordinates", assuming size is derived from coordinates.
 	Shape    string    `json:"shape" db:"source"`           // Corrected db tag to "source" -  maps to finding source.
+
+	PromptVersionID uuid.UUID `json:"prompt_version_id,omitempty" db:"prompt_version_id"` // Prompt registry version used; uuid.Nil for the prompt file
 }
@@ -0,0 +1,53 @@
+// internal/data/models/prompt.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle statuses of a prompt version. A version is written as a draft, submitted for review, approved by an
+// admin other than its author and then activated; activating a version makes the previously active one inactive.
+const (
+	PromptStatusDraft         = "draft"
+	PromptStatusPendingReview = "pending_review"
+	PromptStatusApproved      = "approved"
+	PromptStatusActive        = "active"
+	PromptStatusInactive      = "inactive" // Previously active; may be reactivated by a rollback
+)
+
+// Review outcomes recorded in Prompt.ApprovalStatus.
+const (
+	PromptApprovalPending  = "pending_review"
+	PromptApprovalApproved = "approved"
+	PromptApprovalRejected = "rejected"
+)
+
+// Prompt is one version of a managed prompt in the prompt registry. Versions other than drafts are immutable.
+type Prompt struct {
+	ID             uuid.UUID       `json:"prompt_id" db:"prompt_id"`
+	Name           string          `json:"name" db:"description"`                          // Prompt ID used by the call sites, e.g. "staging_information"
+	Template       string          `json:"template" db:"template"`                         // text/template source
+	InputVariables json.RawMessage `json:"input_variables" db:"input_variables"`           // JSON array of gemini.PromptVariable
+	OutputFormat   json.RawMessage `json:"output_format" db:"output_format"`               // JSON object of gemini.OutputFormat
+	Version        int             `json:"version" db:"version"`                           // 1, 2, ... per prompt
+	Author         string          `json:"author" db:"author"`                             // Admin user who wrote the version
+	Status         string          `json:"status" db:"status"`                             // One of the PromptStatus constants
+	ApprovalStatus string          `json:"approval_status,omitempty" db:"approval_status"` // One of the PromptApproval constants; empty before submission
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// PromptEvent is one entry of a prompt version's append-only lifecycle history.
+type PromptEvent struct {
+	ID         uuid.UUID `json:"event_id" db:"event_id"`
+	PromptID   uuid.UUID `json:"prompt_id" db:"prompt_id"`
+	Action     string    `json:"action" db:"action"` // create, edit, submit, approve, reject, activate, deactivate, rollback
+	Actor      string    `json:"actor" db:"actor"`
+	FromStatus string    `json:"from_status,omitempty" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Comment    string    `json:"comment,omitempty" db:"comment"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
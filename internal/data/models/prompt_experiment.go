@@ -0,0 +1,87 @@
+// internal/data/models/prompt_experiment.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a prompt experiment.
+const (
+	PromptExperimentRunning = "running"
+	PromptExperimentStopped = "stopped"
+)
+
+// Arms of a prompt experiment a session can be assigned to.
+const (
+	PromptArmControl   = "control"   // The version that was active when the experiment started
+	PromptArmTreatment = "treatment" // The candidate version
+)
+
+// Outcomes of a model call recorded in PromptCall.Outcome.
+const (
+	PromptCallSuccess      = "success"
+	PromptCallParseFailure = "parse_failure" // The model answered, but not in the declared output format
+	PromptCallError        = "error"         // The call failed for another reason (provider error, timeout, ...)
+)
+
+// Kinds of stored model output clinicians can rate, recorded in PromptFeedback.TargetType.
+const (
+	FeedbackTargetDiagnosis = "diagnosis"
+	FeedbackTargetStage     = "stage"
+	FeedbackTargetNodule    = "nodule"
+)
+
+// PromptExperiment is an A/B experiment running two versions of one prompt side by side. Each session is assigned to
+// one of the arms by a hash of its session ID, so all calls of a session use the same version.
+type PromptExperiment struct {
+	ID                uuid.UUID  `json:"experiment_id" db:"experiment_id"`
+	PromptName        string     `json:"prompt_name" db:"prompt_name"`
+	ControlPromptID   uuid.UUID  `json:"control_prompt_id" db:"control_prompt_id"`
+	TreatmentPromptID uuid.UUID  `json:"treatment_prompt_id" db:"treatment_prompt_id"`
+	TreatmentPercent  int        `json:"treatment_percent" db:"treatment_percent"` // Share of sessions (1-99) assigned to the treatment
+	Status            string     `json:"status" db:"status"`                       // One of the PromptExperiment status constants
+	StartedBy         string     `json:"started_by" db:"started_by"`
+	StoppedBy         string     `json:"stopped_by,omitempty" db:"stopped_by"`
+	StopReason        string     `json:"stop_reason,omitempty" db:"stop_reason"`
+	StartedAt         time.Time  `json:"started_at" db:"started_at"`
+	StoppedAt         *time.Time `json:"stopped_at,omitempty" db:"stopped_at"`
+}
+
+// PromptCall is the recorded outcome of one model call made with a managed prompt.
+type PromptCall struct {
+	PromptName      string    `json:"prompt_name" db:"prompt_name"`
+	PromptVersionID uuid.UUID `json:"prompt_version_id" db:"prompt_version_id"` // uuid.Nil for the prompt file
+	ExperimentID    uuid.UUID `json:"experiment_id" db:"experiment_id"`         // uuid.Nil outside experiments
+	Arm             string    `json:"arm,omitempty" db:"arm"`
+	Outcome         string    `json:"outcome" db:"outcome"` // One of the PromptCall outcome constants
+}
+
+// PromptFeedback is a clinician's rating of a stored model output.
+type PromptFeedback struct {
+	ID              uuid.UUID `json:"feedback_id" db:"feedback_id"`
+	TargetType      string    `json:"target_type" db:"target_type"` // One of the FeedbackTarget constants
+	TargetID        uuid.UUID `json:"target_id" db:"target_id"`
+	PromptName      string    `json:"prompt_name" db:"prompt_name"`
+	PromptVersionID uuid.UUID `json:"prompt_version_id" db:"prompt_version_id"` // uuid.Nil for the prompt file
+	Reviewer        string    `json:"reviewer" db:"reviewer"`
+	Rating          int       `json:"rating" db:"rating"` // 1 (wrong) to 5 (fully agree)
+	Comment         string    `json:"comment,omitempty" db:"comment"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// PromptCallStats counts the recorded calls of one prompt version.
+type PromptCallStats struct {
+	PromptVersionID uuid.UUID `json:"prompt_version_id"` // uuid.Nil for the prompt file
+	Calls           int       `json:"calls"`
+	ParseFailures   int       `json:"parse_failures"`
+	Errors          int       `json:"errors"`
+}
+
+// PromptFeedbackStats aggregates the ratings of the outputs of one prompt version.
+type PromptFeedbackStats struct {
+	PromptVersionID uuid.UUID `json:"prompt_version_id"` // uuid.Nil for the prompt file
+	Ratings         int       `json:"ratings"`
+	AverageRating   float64   `json:"average_rating"`
+}
@@ -18,3 +18,19 @@ type Report struct {
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// Methods that produced the text of a report page (ReportPage.ExtractionMethod).
+const (
+	ExtractionMethodTextLayer = "text_layer" // Read from the PDF's own text layer
+	ExtractionMethodOCR       = "ocr"        // Recognized by the OCR service
+	ExtractionMethodNone      = "none"       // Blank page: neither text nor images
+)
+
+// ReportPage records how the text of one page of a PDF report was extracted.
+type ReportPage struct {
+	ReportID         uuid.UUID `json:"report_id" db:"report_id"`
+	PageNumber       int       `json:"page_number" db:"page_number"`             // 1-based
+	ExtractionMethod string    `json:"extraction_method" db:"extraction_method"` // One of the ExtractionMethod constants
+	Confidence       float64   `json:"confidence" db:"confidence"`               // OCR confidence (0.0-1.0); 0 unless OCRed
+	CharCount        int       `json:"char_count" db:"char_count"`               // Characters of extracted text
+}
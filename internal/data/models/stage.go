@@ -19,4 +19,6 @@ type Stage struct {
 	Explanation string    `json:"explanation" db:"explanation"` // Added Explanation field - Recommendation 6
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	PromptVersionID uuid.UUID `json:"prompt_version_id,omitempty" db:"prompt_version_id"` // Prompt registry version used; uuid.Nil for the prompt file
 }
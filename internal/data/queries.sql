@@ -142,6 +142,98 @@ WHERE action = $1
 ORDER BY timestamp DESC;
 
 
+-- ------------- SessionSecret Queries -------------
+
+-- CreateSessionSecret: Stores an encrypted per-session secret. An existing secret is kept, so concurrent uploads agree on one secret.
+-- name: CreateSessionSecret :exec
+INSERT INTO sessionsecret (session_id, purpose, encrypted_secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (session_id, purpose) DO NOTHING;
+
+-- GetSessionSecret: Retrieves the encrypted secret of a session for one purpose.
+-- name: GetSessionSecret :one
+SELECT session_id, purpose, encrypted_secret, created_at FROM sessionsecret
+WHERE session_id = $1 AND purpose = $2;
+
+-- DeleteSessionSecrets: Deletes every secret of a session, making its pseudonyms unlinkable.
+-- name: DeleteSessionSecrets :exec
+DELETE FROM sessionsecret
+WHERE session_id = $1;
+
+-- ------------- LLMCache Queries -------------
+
+-- GetLLMCacheEntry: Retrieves the encrypted cached model response of a key, unless it has expired.
+-- name: GetLLMCacheEntry :one
+SELECT cache_key, method, body, created_at, expires_at, session_id FROM llmcache
+WHERE cache_key = $1 AND expires_at > NOW();
+
+-- UpsertLLMCacheEntry: Stores an encrypted model response, replacing an earlier one for the same key.
+-- name: UpsertLLMCacheEntry :exec
+INSERT INTO llmcache (cache_key, method, body, expires_at, session_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (cache_key) DO UPDATE SET method = EXCLUDED.method, body = EXCLUDED.body, created_at = NOW(), expires_at = EXCLUDED.expires_at, session_id = EXCLUDED.session_id;
+
+-- DeleteExpiredLLMCacheEntries: Deletes expired cached model responses.
+-- name: DeleteExpiredLLMCacheEntries :exec
+DELETE FROM llmcache
+WHERE expires_at <= NOW();
+
+-- DeleteLLMCacheEntriesBySessionID: Deletes the cached model responses generated for a session.
+-- name: DeleteLLMCacheEntriesBySessionID :exec
+DELETE FROM llmcache
+WHERE session_id = $1;
+
+-- ------------- LLMUsage Queries -------------
+
+-- CreateLLMUsage: Records the tokens and latency of a model call.
+-- name: CreateLLMUsage :exec
+INSERT INTO llmusage (session_id, method, prompt_name, prompt_version, prompt_version_id, prompt_tokens, output_tokens, latency_ms, outcome)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- GetLLMTokensSince: Sums the prompt and output tokens of every model call made since a time.
+-- name: GetLLMTokensSince :one
+SELECT COALESCE(SUM(prompt_tokens + output_tokens), 0)::bigint AS tokens
+FROM llmusage
+WHERE created_at >= $1;
+
+-- GetSessionLLMTokens: Sums the prompt and output tokens of every model call made for a session.
+-- name: GetSessionLLMTokens :one
+SELECT COALESCE(SUM(prompt_tokens + output_tokens), 0)::bigint AS tokens
+FROM llmusage
+WHERE session_id = $1;
+
+-- GetLLMUsageSummary: Aggregates model calls per UTC day, method and prompt version between two times.
+-- name: GetLLMUsageSummary :many
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+    method,
+    prompt_name,
+    prompt_version,
+    COUNT(*)::integer AS calls,
+    (COUNT(*) FILTER (WHERE outcome <> 'success'))::integer AS failures,
+    SUM(prompt_tokens)::bigint AS prompt_tokens,
+    SUM(output_tokens)::bigint AS output_tokens,
+    AVG(latency_ms)::float8 AS average_latency_ms,
+    (percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms))::float8 AS p95_latency_ms
+FROM llmusage
+WHERE created_at >= sqlc.arg(created_from)
+AND created_at < sqlc.arg(created_to)
+GROUP BY day, method, prompt_name, prompt_version
+ORDER BY day, method, prompt_name, prompt_version;
+
+-- ------------- LabObservation Queries -------------
+
+-- CreateLabObservation: Stores a normalized lab result of a session.
+-- name: CreateLabObservation :exec
+INSERT INTO labobservations (session_id, source, analyte, code, value, numeric_value, unit, reference_range, reference_low, reference_high, flag, observed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- GetLabObservationsBySessionID: Retrieves the lab results of a session by analyte, oldest first.
+-- name: GetLabObservationsBySessionID :many
+SELECT observation_id, session_id, source, analyte, code, value, numeric_value, unit, reference_range, reference_low, reference_high, flag, observed_at, created_at
+FROM labobservations
+WHERE session_id = $1
+ORDER BY analyte, observed_at NULLS LAST, created_at;
+
 -- ------------- ExternalResource Queries -------------
 -- These don't interact with patient data directly, so they are less sensitive.
 
@@ -238,6 +330,125 @@ DELETE
 FROM prompts
 WHERE prompt_id = $1;
 
+-- ListPromptVersions: Retrieves every version of a prompt, newest first (non-numeric versions last).
+-- name: ListPromptVersions :many
+SELECT prompt_id, description, template, input_variables, output_format, version, author, status, approval_status, created_at, updated_at
+FROM prompts
+WHERE description = $1
+ORDER BY CASE WHEN version ~ '^[0-9]+$' THEN version::integer ELSE 0 END DESC, created_at DESC;
+
+-- GetNextPromptVersion: Returns the version number following the latest numeric version of a prompt (1 for a new prompt).
+-- name: GetNextPromptVersion :one
+SELECT (COALESCE(MAX(CASE WHEN version ~ '^[0-9]+$' THEN version::integer ELSE 0 END), 0) + 1)::integer AS next_version
+FROM prompts
+WHERE description = $1;
+
+-- DeactivatePrompt: Makes the active version of a prompt inactive, returning it (no rows if none was active).
+-- name: DeactivatePrompt :many
+UPDATE prompts
+SET status = 'inactive'
+WHERE description = $1
+AND status = 'active'
+RETURNING prompt_id, description, template, input_variables, output_format, version, author, status, approval_status, created_at, updated_at;
+
+-- ------------- PromptEvent Queries -------------
+
+-- CreatePromptEvent: Appends a lifecycle event to a prompt version's history.
+-- name: CreatePromptEvent :one
+INSERT INTO promptevent (prompt_id, action, actor, from_status, to_status, comment)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING event_id, prompt_id, action, actor, from_status, to_status, comment, created_at;
+
+-- ListPromptEvents: Retrieves the lifecycle history of a prompt version, oldest first.
+-- name: ListPromptEvents :many
+SELECT event_id, prompt_id, action, actor, from_status, to_status, comment, created_at
+FROM promptevent
+WHERE prompt_id = $1
+ORDER BY created_at, event_id;
+
+-- ------------- PromptExperiment Queries -------------
+
+-- CreatePromptExperiment: Starts an A/B experiment between the active version of a prompt and a candidate version.
+-- name: CreatePromptExperiment :one
+INSERT INTO promptexperiment (prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, started_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at;
+
+-- GetPromptExperimentByID: Retrieves an experiment by its ID.
+-- name: GetPromptExperimentByID :one
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE experiment_id = $1;
+
+-- GetRunningPromptExperiment: Retrieves the running experiment of a prompt, if any.
+-- name: GetRunningPromptExperiment :one
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE prompt_name = $1
+AND status = 'running';
+
+-- ListPromptExperiments: Retrieves every experiment of a prompt, newest first.
+-- name: ListPromptExperiments :many
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE prompt_name = $1
+ORDER BY started_at DESC;
+
+-- StopPromptExperiment: Stops a running experiment, returning it (no rows if it was not running).
+-- name: StopPromptExperiment :one
+UPDATE promptexperiment
+SET status = 'stopped', stopped_by = $2, stop_reason = $3, stopped_at = now()
+WHERE experiment_id = $1
+AND status = 'running'
+RETURNING experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at;
+
+-- CreatePromptCall: Records the outcome of a model call made with a managed prompt.
+-- name: CreatePromptCall :exec
+INSERT INTO promptcall (prompt_name, prompt_version_id, experiment_id, arm, outcome)
+VALUES ($1, $2, $3, $4, $5);
+
+-- CreatePromptFeedback: Stores a clinician's rating of a model output.
+-- name: CreatePromptFeedback :one
+INSERT INTO promptfeedback (target_type, target_id, prompt_name, prompt_version_id, reviewer, rating, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING feedback_id, target_type, target_id, prompt_name, prompt_version_id, reviewer, rating, comment, created_at;
+
+-- GetPromptCallStats: Counts the calls of a prompt per version and outcome between two times, optionally within one experiment.
+-- name: GetPromptCallStats :many
+SELECT prompt_version_id,
+    COUNT(*)::integer AS calls,
+    (COUNT(*) FILTER (WHERE outcome = 'parse_failure'))::integer AS parse_failures,
+    (COUNT(*) FILTER (WHERE outcome = 'error'))::integer AS errors
+FROM promptcall
+WHERE prompt_name = sqlc.arg(prompt_name)
+AND created_at >= sqlc.arg(created_from)
+AND created_at < sqlc.arg(created_to)
+AND (sqlc.narg(experiment_id)::uuid IS NULL OR experiment_id = sqlc.narg(experiment_id)::uuid)
+GROUP BY prompt_version_id;
+
+-- GetPromptFeedbackStats: Aggregates the ratings of a prompt's outputs per version, for feedback given between two times.
+-- name: GetPromptFeedbackStats :many
+SELECT prompt_version_id,
+    COUNT(*)::integer AS ratings,
+    AVG(rating)::float8 AS average_rating
+FROM promptfeedback
+WHERE prompt_name = sqlc.arg(prompt_name)
+AND created_at >= sqlc.arg(created_from)
+AND created_at < sqlc.arg(created_to)
+GROUP BY prompt_version_id;
+
+-- GetDiagnosisPromptVersion: Returns the prompt version a stored diagnosis was generated with.
+-- name: GetDiagnosisPromptVersion :one
+SELECT prompt_version_id FROM diagnosis WHERE id = $1;
+
+-- GetStagePromptVersion: Returns the prompt version a stored staging record was generated with.
+-- name: GetStagePromptVersion :one
+SELECT prompt_version_id FROM stages WHERE id = $1;
+
+-- GetNodulePromptVersion: Returns the prompt version a stored nodule was detected with.
+-- name: GetNodulePromptVersion :one
+SELECT prompt_version_id FROM findings WHERE finding_id = $1 AND finding_type = 'nodule';
+
 
 -- ------------- Study Queries -------------
 
@@ -335,41 +546,54 @@ WHERE id = $1;
 DELETE FROM reports
 WHERE patient_id = $1;
 
+-- CreateReportPage records how the text of one page of a report was extracted.
+-- name: CreateReportPage :exec
+INSERT INTO reportpages (report_id, page_number, extraction_method, confidence, char_count)
+VALUES ($1, $2, $3, $4, $5);
+
 -- CreateFinding inserts a new finding record.
 -- name: CreateFinding :one
-INSERT INTO findings (finding_id, file_id, finding_type, description, image_coordinates, source)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING finding_id, file_id, finding_type, description, image_coordinates, source, created_at, updated_at;
+INSERT INTO findings (finding_id, file_id, finding_type, description, image_coordinates, source, prompt_version_id, low_confidence)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING finding_id, file_id, finding_type, description, image_coordinates, source, created_at, updated_at, prompt_version_id, low_confidence;
+
+-- GetFindingsByPatientID retrieves the findings of every report of a patient, oldest first.
+-- name: GetFindingsByPatientID :many
+SELECT findings.finding_id, findings.file_id, findings.finding_type, findings.description, findings.image_coordinates, findings.source, findings.created_at, findings.updated_at, findings.prompt_version_id, findings.low_confidence
+FROM findings
+JOIN reports ON reports.id = findings.file_id
+WHERE reports.patient_id = $1
+ORDER BY findings.created_at;
 
 -- GetNoduleByID retrieves a nodule by its ID.
 -- name: GetNoduleByID :one
-SELECT finding_id, file_id, description, image_coordinates, source FROM findings WHERE finding_id = $1;
+SELECT finding_id, file_id, description, image_coordinates, source, prompt_version_id FROM findings WHERE finding_id = $1;
 
 
 -- ------------- Diagnosis Queries -------------
 
 -- CreateDiagnosis inserts a new diagnosis record.
 -- name: CreateDiagnosis :one
-INSERT INTO diagnosis (result_id, session_id, diagnosis_text, confidence, justification)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at;
+INSERT INTO diagnosis (result_id, session_id, diagnosis_text, confidence, justification, prompt_version_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at, prompt_version_id;
 
 -- GetDiagnosisByID retrieves a diagnosis by its ID.
 -- name: GetDiagnosisByID :one
-SELECT id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at FROM diagnosis
+SELECT id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at, prompt_version_id FROM diagnosis
 WHERE id = $1;
 
 -- ------------- Stage Queries -------------
 
 -- CreateStaging inserts a new staging record.
 -- name: CreateStaging :one
-INSERT INTO stages (result_id, session_id, t, n, m, confidence)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, result_id, session_id, t, n, m, confidence, created_at, updated_at;
+INSERT INTO stages (result_id, session_id, t, n, m, confidence, prompt_version_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, result_id, session_id, t, n, m, confidence, created_at, updated_at, prompt_version_id;
 
 -- GetStageByID retrieves a staging record by its ID.
 -- name: GetStageByID :one
-SELECT id, result_id, session_id, t, n, m, confidence, created_at, updated_at
+SELECT id, result_id, session_id, t, n, m, confidence, created_at, updated_at, prompt_version_id
 FROM stages
 WHERE id = $1;
 
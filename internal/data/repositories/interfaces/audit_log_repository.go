@@ -0,0 +1,17 @@
+// internal/data/repositories/interfaces/audit_log_repository.go
+package interfaces
+
+import (
+	"context"
+
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// AuditLogRepository defines the interface for writing the security audit trail.
+type AuditLogRepository interface {
+	Repository // Embed the common repository interface
+
+	// CreateAuditLog appends an entry to the audit trail. ID and Timestamp are set from the stored row.
+	// Audit entries are append-only; there are no update or delete methods.
+	CreateAuditLog(ctx context.Context, entry *models.AuditLog) error
+}
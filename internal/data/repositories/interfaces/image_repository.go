@@ -30,7 +30,4 @@ type ImageRepository interface {
 
 	// DeleteAllImagesByPatientID deletes all image records associated with a given patient ID.
 	DeleteAllImagesByPatientID(ctx context.Context, patientID uuid.UUID) error
-
-	// CreateNodule creates a new nodule record associated with an image. (BE-031)
-	CreateNodule(ctx context.Context, nodule *models.Nodule) error
 }
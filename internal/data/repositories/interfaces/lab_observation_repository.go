@@ -0,0 +1,20 @@
+// internal/data/repositories/interfaces/lab_observation_repository.go
+package interfaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// LabObservationRepository defines the interface for the normalized lab results of patient sessions.
+type LabObservationRepository interface {
+	Repository // Embed the common repository interface
+
+	// CreateLabObservations stores the lab results read from one upload, all or none.
+	CreateLabObservations(ctx context.Context, observations []*models.LabObservation) error
+
+	// GetLabObservationsBySessionID retrieves the lab results of a session, ordered by analyte and then date.
+	GetLabObservationsBySessionID(ctx context.Context, sessionID uuid.UUID) ([]*models.LabObservation, error)
+}
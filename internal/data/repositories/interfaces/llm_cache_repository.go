@@ -0,0 +1,29 @@
+// internal/data/repositories/interfaces/llm_cache_repository.go
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LLMCacheRepository defines the interface for the persistent cache of model responses (gemini.CachingClient).
+// Responses are stored and returned encrypted; encryption and decryption are the caller's responsibility.
+type LLMCacheRepository interface {
+	Repository // Embed the common repository interface
+
+	// GetCachedResponse retrieves the encrypted response cached under key. found is false if none is cached or it
+	// has expired.
+	GetCachedResponse(ctx context.Context, key string) (body []byte, found bool, err error)
+
+	// PutCachedResponse stores an encrypted response of a GeminiClient method, generated for a session (uuid.Nil if
+	// none), under key until expiresAt, replacing an earlier one.
+	PutCachedResponse(ctx context.Context, key, method string, sessionID uuid.UUID, body []byte, expiresAt time.Time) error
+
+	// DeleteSessionResponses deletes every response generated for a session.
+	DeleteSessionResponses(ctx context.Context, sessionID uuid.UUID) error
+
+	// DeleteExpiredResponses deletes every expired response.
+	DeleteExpiredResponses(ctx context.Context) error
+}
@@ -0,0 +1,29 @@
+// internal/data/repositories/interfaces/llm_usage_repository.go
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// LLMUsageRepository defines the interface for the token usage of model calls (gemini.MeteredClient) and its
+// aggregation for the admin API.
+type LLMUsageRepository interface {
+	Repository // Embed the common repository interface
+
+	// RecordLLMUsage stores the token usage and latency of a model call.
+	RecordLLMUsage(ctx context.Context, usage *models.LLMUsage) error
+
+	// GetTokensUsedSince sums the prompt and output tokens of every model call made since a time.
+	GetTokensUsedSince(ctx context.Context, since time.Time) (int64, error)
+
+	// GetSessionTokensUsed sums the prompt and output tokens of every model call made for a session.
+	GetSessionTokensUsed(ctx context.Context, sessionID uuid.UUID) (int64, error)
+
+	// GetUsageSummary aggregates the model calls made in [from, to) per UTC day, method and prompt version, ordered
+	// by day, method and prompt.
+	GetUsageSummary(ctx context.Context, from, to time.Time) ([]*models.LLMUsageSummary, error)
+}
@@ -0,0 +1,52 @@
+// internal/data/repositories/interfaces/prompt_experiment_repository.go
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// PromptExperimentRepository defines the interface for prompt A/B experiments and the metrics they are judged on:
+// the outcome of every model call made with a managed prompt and clinician ratings of stored outputs.
+type PromptExperimentRepository interface {
+	Repository // Embed the common repository interface
+
+	// CreateExperiment stores a new running experiment. The experiment's ID and start time are set.
+	// Returns a domain.ConflictError if the prompt already has a running experiment.
+	CreateExperiment(ctx context.Context, experiment *models.PromptExperiment) error
+
+	// GetExperiment retrieves an experiment by its ID. Returns a domain.NotFoundError if it does not exist.
+	GetExperiment(ctx context.Context, experimentID uuid.UUID) (*models.PromptExperiment, error)
+
+	// GetRunningPromptExperiment retrieves the running experiment of a prompt. Returns a domain.NotFoundError if
+	// none is running.
+	GetRunningPromptExperiment(ctx context.Context, name string) (*models.PromptExperiment, error)
+
+	// ListExperiments retrieves every experiment of a prompt, newest first.
+	ListExperiments(ctx context.Context, name string) ([]*models.PromptExperiment, error)
+
+	// StopExperiment stops a running experiment and returns it. Returns a domain.NotFoundError if it does not exist
+	// or is not running.
+	StopExperiment(ctx context.Context, experimentID uuid.UUID, actor, reason string) (*models.PromptExperiment, error)
+
+	// RecordPromptCall stores the outcome of a model call.
+	RecordPromptCall(ctx context.Context, call *models.PromptCall) error
+
+	// CreatePromptFeedback stores a clinician's rating. The feedback's ID and timestamp are set.
+	CreatePromptFeedback(ctx context.Context, feedback *models.PromptFeedback) error
+
+	// GetOutputPromptVersion returns the prompt version a stored diagnosis, stage or nodule (see the FeedbackTarget
+	// constants) was generated with, uuid.Nil for the prompt file. Returns a domain.NotFoundError if the output does
+	// not exist.
+	GetOutputPromptVersion(ctx context.Context, targetType string, targetID uuid.UUID) (uuid.UUID, error)
+
+	// GetCallStats counts the calls of a prompt per version in [from, to), restricted to one experiment unless
+	// experimentID is uuid.Nil.
+	GetCallStats(ctx context.Context, name string, from, to time.Time, experimentID uuid.UUID) ([]*models.PromptCallStats, error)
+
+	// GetFeedbackStats aggregates the ratings of a prompt's outputs per version, for feedback given in [from, to).
+	GetFeedbackStats(ctx context.Context, name string, from, to time.Time) ([]*models.PromptFeedbackStats, error)
+}
@@ -0,0 +1,39 @@
+// internal/data/repositories/interfaces/prompt_repository.go
+package interfaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// PromptRepository defines the interface for the prompt registry: versioned prompts and their lifecycle history.
+// Lifecycle rules (who may move a version where) are enforced by the caller; the database only guarantees that
+// reviewed versions and events are immutable and that at most one version of a prompt is active.
+type PromptRepository interface {
+	Repository // Embed the common repository interface
+
+	// CreatePrompt stores a new prompt version and its "create" event. The version's ID and timestamps are set.
+	CreatePrompt(ctx context.Context, prompt *models.Prompt, event *models.PromptEvent) error
+
+	// GetPromptByID retrieves a prompt version by its ID. Returns a domain.NotFoundError if it does not exist.
+	GetPromptByID(ctx context.Context, promptID uuid.UUID) (*models.Prompt, error)
+
+	// GetActivePrompt retrieves the active version of a prompt. Returns a domain.NotFoundError if none is active.
+	GetActivePrompt(ctx context.Context, name string) (*models.Prompt, error)
+
+	// ListPromptVersions retrieves every version of a prompt, newest first.
+	ListPromptVersions(ctx context.Context, name string) ([]*models.Prompt, error)
+
+	// NextPromptVersion returns the version number of the next version of a prompt (1 for a new prompt).
+	NextPromptVersion(ctx context.Context, name string) (int, error)
+
+	// UpdatePrompt stores the prompt's content and lifecycle columns and appends the event, in one transaction.
+	// When the prompt becomes active, the previously active version is made inactive (with a "deactivate" event by
+	// the same actor) in the same transaction.
+	UpdatePrompt(ctx context.Context, prompt *models.Prompt, event *models.PromptEvent) error
+
+	// ListPromptEvents retrieves the lifecycle history of a prompt version, oldest first.
+	ListPromptEvents(ctx context.Context, promptID uuid.UUID) ([]*models.PromptEvent, error)
+}
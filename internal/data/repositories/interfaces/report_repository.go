@@ -32,9 +32,15 @@ type ReportRepository interface {
 	// as part of the complete data deletion process.
 	DeleteAllReportsByPatientID(ctx context.Context, patientID uuid.UUID) error
 
+	// CreateReportPage records how the text of one page of a report was extracted.
+	CreateReportPage(ctx context.Context, page *models.ReportPage) error
+
 	//CreateFinding creates new finding data to report
 	CreateFinding(ctx context.Context, finding *models.Finding) error
 
+	// GetFindingsByPatientID retrieves the findings of every report of a patient (session), oldest first.
+	GetFindingsByPatientID(ctx context.Context, patientID uuid.UUID) ([]*models.Finding, error)
+
 	// BeginTx, CommitTx, and RollbackTx are explicitly defined here *and* in the
 	// embedded `Repository` interface.  This redundancy is intentional.  It
 	// clarifies that the ReportRepository *must* support transactions, as it will
@@ -0,0 +1,26 @@
+// internal/data/repositories/interfaces/session_secret_repository.go
+package interfaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// SessionSecretRepository defines the interface for storing per-session pseudonymization secrets.
+// Secrets are stored and returned encrypted; encryption and decryption are the caller's responsibility.
+type SessionSecretRepository interface {
+	Repository // Embed the common repository interface
+
+	// CreateSessionSecret stores the encrypted secret of a session for one purpose.
+	// If a secret already exists for the session and purpose it is kept and no error is returned.
+	CreateSessionSecret(ctx context.Context, sessionID uuid.UUID, purpose string, encryptedSecret []byte) error
+
+	// GetSessionSecret retrieves the encrypted secret of a session for one purpose.
+	// Returns a domain.NotFoundError if no secret has been created yet.
+	GetSessionSecret(ctx context.Context, sessionID uuid.UUID, purpose string) ([]byte, error)
+
+	// DeleteSessionSecrets deletes every secret of a session (session, i.e. patient data deletion).
+	// Once deleted, pseudonyms derived from the secrets can no longer be linked back to the originals.
+	DeleteSessionSecrets(ctx context.Context, sessionID uuid.UUID) error
+}
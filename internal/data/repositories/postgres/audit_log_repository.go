@@ -0,0 +1,89 @@
+// internal/data/repositories/postgres/audit_log_repository.go
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"go.uber.org/zap"
+)
+
+var _ interfaces.AuditLogRepository = (*AuditLogRepository)(nil)
+
+// AuditLogRepository implements the interfaces.AuditLogRepository for PostgreSQL.
+type AuditLogRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewAuditLogRepository(db *pgxpool.Pool, logger *zap.Logger) *AuditLogRepository {
+	return &AuditLogRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// CreateAuditLog implements interfaces.AuditLogRepository.
+// CreateAuditLog inserts a new audit log entry and fills in its generated ID and timestamp.
+func (r *AuditLogRepository) CreateAuditLog(ctx context.Context, entry *models.AuditLog) error {
+	const operation = "postgres.AuditLogRepository.CreateAuditLog"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("action", entry.Action))
+
+	params := &postgres.CreateAuditLogParams{
+		SessionID: optionalUUID(entry.SessionID),
+		ContentID: optionalUUID(entry.ContentID),
+		ResultID:  optionalUUID(entry.ResultID),
+		Action:    entry.Action,
+		Details:   entry.Details,
+	}
+	row, err := r.queries.CreateAuditLog(ctx, r.db, params)
+	if err != nil {
+		r.logger.Error("Database error in CreateAuditLog", zap.String("operation", operation), zap.String("action", entry.Action), zap.Error(err))
+		return fmt.Errorf("could not create audit log: %w", err)
+	}
+	entry.ID = row.LogID
+	entry.Timestamp = row.Timestamp.Time
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.Int64("log_id", entry.ID))
+	return nil
+}
+
+// optionalUUID maps uuid.Nil to SQL NULL, for optional foreign keys.
+func optionalUUID(id uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: id, Valid: id != uuid.Nil}
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *AuditLogRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.AuditLogRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *AuditLogRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.AuditLogRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *AuditLogRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.AuditLogRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
@@ -50,6 +50,8 @@ func (r *DiagnosisRepository) CreateDiagnosis(ctx context.Context, diagnosis *mo
 		DiagnosisText: pgtype.Text{String: diagnosis.DiagnosisText, Valid: true},
 		Confidence:    pgtype.Text{String: diagnosis.Confidence, Valid: true},
 		Justification: pgtype.Text{String: diagnosis.Justification, Valid: true},
+
+		PromptVersionID: optionalUUID(diagnosis.PromptVersionID),
 	}
 
 	if r.logger.Core().Enabled(zapcore.DebugLevel) {
@@ -95,6 +97,8 @@ func (r *DiagnosisRepository) GetDiagnosisByID(ctx context.Context, diagnosisID
 		Justification: diagnosis.Justification.String,
 		CreatedAt:     diagnosis.CreatedAt.Time,
 		UpdatedAt:     diagnosis.UpdatedAt.Time,
+
+		PromptVersionID: uuid.UUID(diagnosis.PromptVersionID.Bytes), // uuid.Nil when NULL
 	}
 
 	r.logger.Debug("Successfully completed DB operation", zap.String("operation", operation), zap.String("diagnosis_id", diagnosisID.String()), zap.String("request_id", requestID))
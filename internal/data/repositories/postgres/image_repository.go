@@ -4,7 +4,6 @@ package postgres
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -194,16 +193,6 @@ func (r *ImageRepository) DeleteAllImagesByPatientID(ctx context.Context, patien
 	return nil
 }
 
-// CreateNodule implements interfaces.ImageRepository.
-func (r *ImageRepository) CreateNodule(ctx context.Context, nodule *models.Nodule) error {
-	// Implementation for CreateNodule (if needed, based on your design)
-	// This might involve direct SQL queries or using sqlc generated code if you extend queries.sql
-	const operation = "postgres.ImageRepository.CreateNodule"
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
-	r.logger.Warn("Operation not implemented", zap.String("operation", operation), zap.String("request_id", requestID)) // Use Warn level as it's not critical yet
-	return fmt.Errorf("%s: not implemented", operation)                                                                 // Return a "not implemented" error
-}
-
 // BeginTx implements interfaces.Repository.
 func (r *ImageRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
 	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.ImageRepository.BeginTx"), zap.String("request_id", utils.GetRequestID(ctx.(*gin.Context))))
@@ -0,0 +1,168 @@
+// internal/data/repositories/postgres/lab_observation_repository.go
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+var _ interfaces.LabObservationRepository = (*LabObservationRepository)(nil)
+
+// LabObservationRepository implements the interfaces.LabObservationRepository for PostgreSQL.
+type LabObservationRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewLabObservationRepository creates a new LabObservationRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewLabObservationRepository(db *pgxpool.Pool, logger *zap.Logger) *LabObservationRepository {
+	return &LabObservationRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// CreateLabObservations implements interfaces.LabObservationRepository.
+// CreateLabObservations stores the lab results read from one upload in a single transaction.
+func (r *LabObservationRepository) CreateLabObservations(ctx context.Context, observations []*models.LabObservation) (err error) {
+	const operation = "postgres.LabObservationRepository.CreateLabObservations"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("observations", len(observations)))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not begin lab observation transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	for _, o := range observations {
+		params := &postgres.CreateLabObservationParams{
+			SessionID:      pgtype.UUID{Bytes: o.SessionID, Valid: true},
+			Source:         o.Source,
+			Analyte:        o.Analyte,
+			Code:           optionalText(o.Code),
+			Value:          o.Value,
+			NumericValue:   optionalFloat8(o.NumericValue),
+			Unit:           optionalText(o.Unit),
+			ReferenceRange: optionalText(o.ReferenceRange),
+			ReferenceLow:   optionalFloat8(o.ReferenceLow),
+			ReferenceHigh:  optionalFloat8(o.ReferenceHigh),
+			Flag:           optionalText(o.Flag),
+		}
+		if o.ObservedAt != nil {
+			params.ObservedAt = pgtype.Timestamptz{Time: *o.ObservedAt, Valid: true}
+		}
+		if err = r.queries.CreateLabObservation(ctx, tx, params); err != nil {
+			r.logger.Error("Database error in CreateLabObservations", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", o.SessionID.String()), zap.Error(err))
+			return utils.NewErrDBQuery("CreateLabObservation failed", operation, "CreateLabObservation", o.SessionID.String(), err) // Values are left out of the error
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("could not commit lab observations: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("observations", len(observations)))
+	return nil
+}
+
+// GetLabObservationsBySessionID implements interfaces.LabObservationRepository.
+// GetLabObservationsBySessionID retrieves the lab results of a session, ordered by analyte and then date.
+func (r *LabObservationRepository) GetLabObservationsBySessionID(ctx context.Context, sessionID uuid.UUID) ([]*models.LabObservation, error) {
+	const operation = "postgres.LabObservationRepository.GetLabObservationsBySessionID"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()))
+
+	rows, err := r.queries.GetLabObservationsBySessionID(ctx, r.db, pgtype.UUID{Bytes: sessionID, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in GetLabObservationsBySessionID", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return nil, utils.NewErrDBQuery("GetLabObservationsBySessionID failed", operation, "GetLabObservationsBySessionID", sessionID.String(), err)
+	}
+	observations := make([]*models.LabObservation, len(rows))
+	for i, row := range rows {
+		observations[i] = toModelLabObservation(row)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("observations", len(observations)))
+	return observations, nil
+}
+
+func toModelLabObservation(o *postgres.Labobservation) *models.LabObservation {
+	observation := &models.LabObservation{
+		ID:             uuid.UUID(o.ObservationID.Bytes),
+		SessionID:      uuid.UUID(o.SessionID.Bytes),
+		Source:         o.Source,
+		Analyte:        o.Analyte,
+		Code:           o.Code.String,
+		Value:          o.Value,
+		NumericValue:   float8Pointer(o.NumericValue),
+		Unit:           o.Unit.String,
+		ReferenceRange: o.ReferenceRange.String,
+		ReferenceLow:   float8Pointer(o.ReferenceLow),
+		ReferenceHigh:  float8Pointer(o.ReferenceHigh),
+		Flag:           o.Flag.String,
+		CreatedAt:      o.CreatedAt.Time,
+	}
+	if o.ObservedAt.Valid {
+		observedAt := o.ObservedAt.Time.In(time.UTC)
+		observation.ObservedAt = &observedAt
+	}
+	return observation
+}
+
+// optionalFloat8 maps a nil number to NULL.
+func optionalFloat8(v *float64) pgtype.Float8 {
+	if v == nil {
+		return pgtype.Float8{}
+	}
+	return pgtype.Float8{Float64: *v, Valid: true}
+}
+
+// float8Pointer maps NULL to a nil number.
+func float8Pointer(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *LabObservationRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.LabObservationRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *LabObservationRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.LabObservationRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *LabObservationRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.LabObservationRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
@@ -0,0 +1,132 @@
+// internal/data/repositories/postgres/llm_cache_repository.go
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"go.uber.org/zap"
+)
+
+var _ interfaces.LLMCacheRepository = (*LLMCacheRepository)(nil)
+
+// LLMCacheRepository implements the interfaces.LLMCacheRepository for PostgreSQL.
+type LLMCacheRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewLLMCacheRepository creates a new LLMCacheRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewLLMCacheRepository(db *pgxpool.Pool, logger *zap.Logger) *LLMCacheRepository {
+	return &LLMCacheRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// GetCachedResponse implements interfaces.LLMCacheRepository.
+// GetCachedResponse retrieves the encrypted response cached under key, unless it has expired.
+func (r *LLMCacheRepository) GetCachedResponse(ctx context.Context, key string) ([]byte, bool, error) {
+	const operation = "postgres.LLMCacheRepository.GetCachedResponse"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("cache_key", key))
+
+	entry, err := r.queries.GetLLMCacheEntry(ctx, r.db, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil // Cache miss
+		}
+		r.logger.Error("Database error in GetCachedResponse", zap.String("operation", operation), zap.String("cache_key", key), zap.Error(err))
+		return nil, false, fmt.Errorf("could not get cached response: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("cache_key", key))
+	return entry.Body, true, nil
+}
+
+// PutCachedResponse implements interfaces.LLMCacheRepository.
+// PutCachedResponse stores an encrypted response generated for a session under key until expiresAt, replacing an
+// earlier one. Responses generated outside a session (uuid.Nil) are stored without one.
+func (r *LLMCacheRepository) PutCachedResponse(ctx context.Context, key, method string, sessionID uuid.UUID, body []byte, expiresAt time.Time) error {
+	const operation = "postgres.LLMCacheRepository.PutCachedResponse"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("cache_key", key), zap.String("method", method))
+
+	params := &postgres.UpsertLLMCacheEntryParams{
+		CacheKey:  key,
+		Method:    method,
+		Body:      body,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		SessionID: pgtype.UUID{Bytes: sessionID, Valid: sessionID != uuid.Nil},
+	}
+	if err := r.queries.UpsertLLMCacheEntry(ctx, r.db, params); err != nil {
+		r.logger.Error("Database error in PutCachedResponse", zap.String("operation", operation), zap.String("cache_key", key), zap.Error(err))
+		return fmt.Errorf("could not store cached response: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("cache_key", key))
+	return nil
+}
+
+// DeleteExpiredResponses implements interfaces.LLMCacheRepository.
+// DeleteExpiredResponses deletes every expired response.
+func (r *LLMCacheRepository) DeleteExpiredResponses(ctx context.Context) error {
+	const operation = "postgres.LLMCacheRepository.DeleteExpiredResponses"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation))
+
+	if err := r.queries.DeleteExpiredLLMCacheEntries(ctx, r.db); err != nil {
+		r.logger.Error("Database error in DeleteExpiredResponses", zap.String("operation", operation), zap.Error(err))
+		return fmt.Errorf("could not delete expired cached responses: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation))
+	return nil
+}
+
+// DeleteSessionResponses implements interfaces.LLMCacheRepository.
+// DeleteSessionResponses deletes every response generated for a session.
+func (r *LLMCacheRepository) DeleteSessionResponses(ctx context.Context, sessionID uuid.UUID) error {
+	const operation = "postgres.LLMCacheRepository.DeleteSessionResponses"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+
+	if err := r.queries.DeleteLLMCacheEntriesBySessionID(ctx, r.db, pgtype.UUID{Bytes: sessionID, Valid: true}); err != nil {
+		r.logger.Error("Database error in DeleteSessionResponses", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return fmt.Errorf("could not delete cached responses of session: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+	return nil
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *LLMCacheRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.LLMCacheRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *LLMCacheRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.LLMCacheRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *LLMCacheRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.LLMCacheRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
@@ -0,0 +1,145 @@
+// internal/data/repositories/postgres/llm_usage_repository.go
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+var _ interfaces.LLMUsageRepository = (*LLMUsageRepository)(nil)
+
+// LLMUsageRepository implements the interfaces.LLMUsageRepository for PostgreSQL.
+type LLMUsageRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewLLMUsageRepository creates a new LLMUsageRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewLLMUsageRepository(db *pgxpool.Pool, logger *zap.Logger) *LLMUsageRepository {
+	return &LLMUsageRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// RecordLLMUsage implements interfaces.LLMUsageRepository.
+// RecordLLMUsage stores the token usage and latency of a model call.
+func (r *LLMUsageRepository) RecordLLMUsage(ctx context.Context, usage *models.LLMUsage) error {
+	const operation = "postgres.LLMUsageRepository.RecordLLMUsage"
+
+	err := r.queries.CreateLLMUsage(ctx, r.db, &postgres.CreateLLMUsageParams{
+		SessionID:       optionalUUID(usage.SessionID),
+		Method:          usage.Method,
+		PromptName:      usage.PromptName,
+		PromptVersion:   int32(usage.PromptVersion),
+		PromptVersionID: optionalUUID(usage.PromptVersionID),
+		PromptTokens:    int32(usage.PromptTokens),
+		OutputTokens:    int32(usage.OutputTokens),
+		LatencyMs:       int32(usage.Latency.Milliseconds()),
+		Outcome:         usage.Outcome,
+	})
+	if err != nil {
+		r.logger.Error("Database error in RecordLLMUsage", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("method", usage.Method), zap.Error(err))
+		return fmt.Errorf("could not record model usage: %w", err)
+	}
+	return nil
+}
+
+// GetTokensUsedSince implements interfaces.LLMUsageRepository.
+// GetTokensUsedSince sums the tokens of every model call made since a time.
+func (r *LLMUsageRepository) GetTokensUsedSince(ctx context.Context, since time.Time) (int64, error) {
+	const operation = "postgres.LLMUsageRepository.GetTokensUsedSince"
+
+	tokens, err := r.queries.GetLLMTokensSince(ctx, r.db, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in GetTokensUsedSince", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.Time("since", since), zap.Error(err))
+		return 0, fmt.Errorf("could not sum model tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetSessionTokensUsed implements interfaces.LLMUsageRepository.
+// GetSessionTokensUsed sums the tokens of every model call made for a session.
+func (r *LLMUsageRepository) GetSessionTokensUsed(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	const operation = "postgres.LLMUsageRepository.GetSessionTokensUsed"
+
+	tokens, err := r.queries.GetSessionLLMTokens(ctx, r.db, pgtype.UUID{Bytes: sessionID, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in GetSessionTokensUsed", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return 0, fmt.Errorf("could not sum session model tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetUsageSummary implements interfaces.LLMUsageRepository.
+// GetUsageSummary aggregates the model calls made in [from, to) per UTC day, method and prompt version.
+func (r *LLMUsageRepository) GetUsageSummary(ctx context.Context, from, to time.Time) ([]*models.LLMUsageSummary, error) {
+	const operation = "postgres.LLMUsageRepository.GetUsageSummary"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.Time("from", from), zap.Time("to", to))
+
+	rows, err := r.queries.GetLLMUsageSummary(ctx, r.db, &postgres.GetLLMUsageSummaryParams{
+		CreatedFrom: pgtype.Timestamptz{Time: from, Valid: true},
+		CreatedTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		r.logger.Error("Database error in GetUsageSummary", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return nil, fmt.Errorf("could not get model usage summary: %w", err)
+	}
+	summary := make([]*models.LLMUsageSummary, len(rows))
+	for i, row := range rows {
+		summary[i] = &models.LLMUsageSummary{
+			Day:              row.Day.Time,
+			Method:           row.Method,
+			PromptName:       row.PromptName,
+			PromptVersion:    int(row.PromptVersion),
+			Calls:            int(row.Calls),
+			Failures:         int(row.Failures),
+			PromptTokens:     row.PromptTokens,
+			OutputTokens:     row.OutputTokens,
+			AverageLatencyMS: row.AverageLatencyMs,
+			P95LatencyMS:     row.P95LatencyMs,
+		}
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("rows", len(summary)))
+	return summary, nil
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *LLMUsageRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.LLMUsageRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *LLMUsageRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.LLMUsageRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *LLMUsageRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.LLMUsageRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
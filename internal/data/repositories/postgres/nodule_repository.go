@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 
-	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -40,7 +39,7 @@ func NewNoduleRepository(db *pgxpool.Pool, logger *zap.Logger) *NoduleRepository
 // CreateNodule implements interfaces.NoduleRepository.
 func (r *NoduleRepository) CreateNodule(ctx context.Context, nodule *models.Nodule) error {
 	const operation = "postgres.NoduleRepository.CreateNodule"
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 
 	r.logger.Debug("Starting DB operation", zap.String("operation", operation), zap.String("nodule_id", nodule.ID.String()), zap.String("request_id", requestID))
 
@@ -51,6 +50,7 @@ func (r *NoduleRepository) CreateNodule(ctx context.Context, nodule *models.Nodu
 		Description:      nodule.Location,                                            // Mapped to Location
 		ImageCoordinates: []float64{nodule.Size},                                     // Mapped to Size (as first element)
 		Source:           nodule.Shape,                                               // Mapped to Shape
+		PromptVersionID:  optionalUUID(nodule.PromptVersionID),
 	}
 
 	if r.logger.Core().Enabled(zapcore.DebugLevel) {
@@ -74,7 +74,7 @@ func (r *NoduleRepository) CreateNodule(ctx context.Context, nodule *models.Nodu
 // GetNoduleByID implements interfaces.NoduleRepository.
 func (r *NoduleRepository) GetNoduleByID(ctx context.Context, noduleID uuid.UUID) (*models.Nodule, error) {
 	const operation = "postgres.NoduleRepository.GetNoduleByID"
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 	r.logger.Debug("Starting DB operation", zap.String("operation", operation), zap.String("nodule_id", noduleID.String()), zap.String("request_id", requestID))
 
 	noduleRow, err := r.queries.GetNoduleByID(ctx, r.db, pgtype.UUID{Bytes: uuid.UUID(noduleID), Valid: true}) // Corrected to use GetNoduleByID and renamed variable
@@ -99,6 +99,8 @@ func (r *NoduleRepository) GetNoduleByID(ctx context.Context, noduleID uuid.UUID
 		Location: noduleRow.Description,                // Corrected: Use noduleRow.Description
 		Size:     noduleRow.ImageCoordinates[0],        // Corrected: Use noduleRow.ImageCoordinates
 		Shape:    noduleRow.Source,                     // Corrected: Use noduleRow.Source
+
+		PromptVersionID: uuid.UUID(noduleRow.PromptVersionID.Bytes), // uuid.Nil when NULL
 	}
 
 	r.logger.Debug("Successfully completed DB operation", zap.String("operation", operation), zap.String("nodule_id", noduleID.String()), zap.String("request_id", requestID))
@@ -107,7 +109,7 @@ func (r *NoduleRepository) GetNoduleByID(ctx context.Context, noduleID uuid.UUID
 
 // BeginTx implements interfaces.Repository.
 func (r *NoduleRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
-	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.NoduleRepository.BeginTx"), zap.String("request_id", utils.GetRequestID(ctx.(*gin.Context))))
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.NoduleRepository.BeginTx"), zap.String("request_id", utils.GetRequestID(ctx)))
 	if len(opts) > 0 {
 		return r.db.BeginTx(ctx, opts[0])
 	}
@@ -116,12 +118,12 @@ func (r *NoduleRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (
 
 // CommitTx implements interfaces.Repository.
 func (r *NoduleRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
-	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.NoduleRepository.CommitTx"), zap.String("request_id", utils.GetRequestID(ctx.(*gin.Context))))
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.NoduleRepository.CommitTx"), zap.String("request_id", utils.GetRequestID(ctx)))
 	return tx.Commit(ctx)
 }
 
 // RollbackTx implements interfaces.Repository.
 func (r *NoduleRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
-	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.NoduleRepository.RollbackTx"), zap.String("request_id", utils.GetRequestID(ctx.(*gin.Context))))
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.NoduleRepository.RollbackTx"), zap.String("request_id", utils.GetRequestID(ctx)))
 	return tx.Rollback(ctx)
 }
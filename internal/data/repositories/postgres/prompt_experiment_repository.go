@@ -0,0 +1,322 @@
+// internal/data/repositories/postgres/prompt_experiment_repository.go
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+var _ interfaces.PromptExperimentRepository = (*PromptExperimentRepository)(nil)
+
+// uniqueViolation is the PostgreSQL error code of unique constraint violations.
+const uniqueViolation = "23505"
+
+// PromptExperimentRepository implements the interfaces.PromptExperimentRepository for PostgreSQL.
+type PromptExperimentRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewPromptExperimentRepository creates a new PromptExperimentRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewPromptExperimentRepository(db *pgxpool.Pool, logger *zap.Logger) *PromptExperimentRepository {
+	return &PromptExperimentRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// CreateExperiment implements interfaces.PromptExperimentRepository.
+// CreateExperiment stores a new running experiment; the database allows one running experiment per prompt.
+func (r *PromptExperimentRepository) CreateExperiment(ctx context.Context, experiment *models.PromptExperiment) error {
+	const operation = "postgres.PromptExperimentRepository.CreateExperiment"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", experiment.PromptName))
+
+	created, err := r.queries.CreatePromptExperiment(ctx, r.db, &postgres.CreatePromptExperimentParams{
+		PromptName:        experiment.PromptName,
+		ControlPromptID:   pgtype.UUID{Bytes: experiment.ControlPromptID, Valid: true},
+		TreatmentPromptID: pgtype.UUID{Bytes: experiment.TreatmentPromptID, Valid: true},
+		TreatmentPercent:  int32(experiment.TreatmentPercent),
+		StartedBy:         experiment.StartedBy,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			conflictErr := domain.NewConflictError("running prompt experiment", experiment.PromptName)
+			conflictErr.SetLogger(r.logger)
+			return conflictErr
+		}
+		r.logger.Error("Database error in CreateExperiment", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", experiment.PromptName), zap.Error(err))
+		return fmt.Errorf("could not create prompt experiment: %w", err)
+	}
+	*experiment = *toModelPromptExperiment(created)
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("experiment_id", experiment.ID.String()))
+	return nil
+}
+
+// GetExperiment implements interfaces.PromptExperimentRepository.
+// GetExperiment retrieves an experiment by its ID. Returns a domain.NotFoundError if it does not exist.
+func (r *PromptExperimentRepository) GetExperiment(ctx context.Context, experimentID uuid.UUID) (*models.PromptExperiment, error) {
+	const operation = "postgres.PromptExperimentRepository.GetExperiment"
+
+	experiment, err := r.queries.GetPromptExperimentByID(ctx, r.db, pgtype.UUID{Bytes: experimentID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			notFoundErr := domain.NewNotFoundError("prompt experiment", experimentID.String())
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetExperiment", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("experiment_id", experimentID.String()), zap.Error(err))
+		return nil, fmt.Errorf("could not get prompt experiment: %w", err)
+	}
+	return toModelPromptExperiment(experiment), nil
+}
+
+// GetRunningPromptExperiment implements interfaces.PromptExperimentRepository.
+// GetRunningPromptExperiment retrieves the running experiment of a prompt. Returns a domain.NotFoundError if none is
+// running.
+func (r *PromptExperimentRepository) GetRunningPromptExperiment(ctx context.Context, name string) (*models.PromptExperiment, error) {
+	const operation = "postgres.PromptExperimentRepository.GetRunningPromptExperiment"
+
+	experiment, err := r.queries.GetRunningPromptExperiment(ctx, r.db, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			notFoundErr := domain.NewNotFoundError("running prompt experiment", name) // Expected while no experiment runs
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetRunningPromptExperiment", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not get running prompt experiment: %w", err)
+	}
+	return toModelPromptExperiment(experiment), nil
+}
+
+// ListExperiments implements interfaces.PromptExperimentRepository.
+// ListExperiments retrieves every experiment of a prompt, newest first.
+func (r *PromptExperimentRepository) ListExperiments(ctx context.Context, name string) ([]*models.PromptExperiment, error) {
+	const operation = "postgres.PromptExperimentRepository.ListExperiments"
+
+	rows, err := r.queries.ListPromptExperiments(ctx, r.db, name)
+	if err != nil {
+		r.logger.Error("Database error in ListExperiments", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not list prompt experiments: %w", err)
+	}
+	experiments := make([]*models.PromptExperiment, len(rows))
+	for i, row := range rows {
+		experiments[i] = toModelPromptExperiment(row)
+	}
+	return experiments, nil
+}
+
+// StopExperiment implements interfaces.PromptExperimentRepository.
+// StopExperiment stops a running experiment. Returns a domain.NotFoundError if it does not exist or is not running.
+func (r *PromptExperimentRepository) StopExperiment(ctx context.Context, experimentID uuid.UUID, actor, reason string) (*models.PromptExperiment, error) {
+	const operation = "postgres.PromptExperimentRepository.StopExperiment"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("experiment_id", experimentID.String()))
+
+	stopped, err := r.queries.StopPromptExperiment(ctx, r.db, &postgres.StopPromptExperimentParams{
+		ExperimentID: pgtype.UUID{Bytes: experimentID, Valid: true},
+		StoppedBy:    pgtype.Text{String: actor, Valid: true},
+		StopReason:   optionalText(reason),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			notFoundErr := domain.NewNotFoundError("running prompt experiment", experimentID.String())
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in StopExperiment", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("experiment_id", experimentID.String()), zap.Error(err))
+		return nil, fmt.Errorf("could not stop prompt experiment: %w", err)
+	}
+	return toModelPromptExperiment(stopped), nil
+}
+
+// RecordPromptCall implements interfaces.PromptExperimentRepository.
+// RecordPromptCall stores the outcome of a model call.
+func (r *PromptExperimentRepository) RecordPromptCall(ctx context.Context, call *models.PromptCall) error {
+	const operation = "postgres.PromptExperimentRepository.RecordPromptCall"
+
+	err := r.queries.CreatePromptCall(ctx, r.db, &postgres.CreatePromptCallParams{
+		PromptName:      call.PromptName,
+		PromptVersionID: optionalUUID(call.PromptVersionID),
+		ExperimentID:    optionalUUID(call.ExperimentID),
+		Arm:             optionalText(call.Arm),
+		Outcome:         call.Outcome,
+	})
+	if err != nil {
+		r.logger.Error("Database error in RecordPromptCall", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", call.PromptName), zap.Error(err))
+		return fmt.Errorf("could not record prompt call: %w", err)
+	}
+	return nil
+}
+
+// CreatePromptFeedback implements interfaces.PromptExperimentRepository.
+// CreatePromptFeedback stores a clinician's rating of a model output.
+func (r *PromptExperimentRepository) CreatePromptFeedback(ctx context.Context, feedback *models.PromptFeedback) error {
+	const operation = "postgres.PromptExperimentRepository.CreatePromptFeedback"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("target_type", feedback.TargetType), zap.String("target_id", feedback.TargetID.String()))
+
+	created, err := r.queries.CreatePromptFeedback(ctx, r.db, &postgres.CreatePromptFeedbackParams{
+		TargetType:      feedback.TargetType,
+		TargetID:        pgtype.UUID{Bytes: feedback.TargetID, Valid: true},
+		PromptName:      feedback.PromptName,
+		PromptVersionID: optionalUUID(feedback.PromptVersionID),
+		Reviewer:        feedback.Reviewer,
+		Rating:          int16(feedback.Rating),
+		Comment:         optionalText(feedback.Comment),
+	})
+	if err != nil {
+		r.logger.Error("Database error in CreatePromptFeedback", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return fmt.Errorf("could not store prompt feedback: %w", err)
+	}
+	feedback.ID = uuid.UUID(created.FeedbackID.Bytes)
+	feedback.CreatedAt = created.CreatedAt.Time
+	return nil
+}
+
+// GetOutputPromptVersion implements interfaces.PromptExperimentRepository.
+// GetOutputPromptVersion returns the prompt version a stored diagnosis, stage or nodule was generated with.
+func (r *PromptExperimentRepository) GetOutputPromptVersion(ctx context.Context, targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	const operation = "postgres.PromptExperimentRepository.GetOutputPromptVersion"
+
+	var lookup func(ctx context.Context, db postgres.DBTX, id pgtype.UUID) (pgtype.UUID, error)
+	switch targetType {
+	case models.FeedbackTargetDiagnosis:
+		lookup = r.queries.GetDiagnosisPromptVersion
+	case models.FeedbackTargetStage:
+		lookup = r.queries.GetStagePromptVersion
+	case models.FeedbackTargetNodule:
+		lookup = r.queries.GetNodulePromptVersion
+	default:
+		return uuid.Nil, domain.NewValidationError(fmt.Sprintf("unknown feedback target type %q", targetType))
+	}
+
+	versionID, err := lookup(ctx, r.db, pgtype.UUID{Bytes: targetID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			notFoundErr := domain.NewNotFoundError(targetType, targetID.String())
+			notFoundErr.SetLogger(r.logger)
+			return uuid.Nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetOutputPromptVersion", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("target_type", targetType), zap.String("target_id", targetID.String()), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("could not get prompt version of %s: %w", targetType, err)
+	}
+	return uuid.UUID(versionID.Bytes), nil // uuid.Nil when NULL
+}
+
+// GetCallStats implements interfaces.PromptExperimentRepository.
+// GetCallStats counts the calls of a prompt per version in [from, to), optionally within one experiment.
+func (r *PromptExperimentRepository) GetCallStats(ctx context.Context, name string, from, to time.Time, experimentID uuid.UUID) ([]*models.PromptCallStats, error) {
+	const operation = "postgres.PromptExperimentRepository.GetCallStats"
+
+	rows, err := r.queries.GetPromptCallStats(ctx, r.db, &postgres.GetPromptCallStatsParams{
+		PromptName:   name,
+		CreatedFrom:  pgtype.Timestamptz{Time: from, Valid: true},
+		CreatedTo:    pgtype.Timestamptz{Time: to, Valid: true},
+		ExperimentID: optionalUUID(experimentID),
+	})
+	if err != nil {
+		r.logger.Error("Database error in GetCallStats", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not get prompt call stats: %w", err)
+	}
+	stats := make([]*models.PromptCallStats, len(rows))
+	for i, row := range rows {
+		stats[i] = &models.PromptCallStats{
+			PromptVersionID: uuid.UUID(row.PromptVersionID.Bytes),
+			Calls:           int(row.Calls),
+			ParseFailures:   int(row.ParseFailures),
+			Errors:          int(row.Errors),
+		}
+	}
+	return stats, nil
+}
+
+// GetFeedbackStats implements interfaces.PromptExperimentRepository.
+// GetFeedbackStats aggregates the ratings of a prompt's outputs per version, for feedback given in [from, to).
+func (r *PromptExperimentRepository) GetFeedbackStats(ctx context.Context, name string, from, to time.Time) ([]*models.PromptFeedbackStats, error) {
+	const operation = "postgres.PromptExperimentRepository.GetFeedbackStats"
+
+	rows, err := r.queries.GetPromptFeedbackStats(ctx, r.db, &postgres.GetPromptFeedbackStatsParams{
+		PromptName:  name,
+		CreatedFrom: pgtype.Timestamptz{Time: from, Valid: true},
+		CreatedTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		r.logger.Error("Database error in GetFeedbackStats", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not get prompt feedback stats: %w", err)
+	}
+	stats := make([]*models.PromptFeedbackStats, len(rows))
+	for i, row := range rows {
+		stats[i] = &models.PromptFeedbackStats{
+			PromptVersionID: uuid.UUID(row.PromptVersionID.Bytes),
+			Ratings:         int(row.Ratings),
+			AverageRating:   row.AverageRating,
+		}
+	}
+	return stats, nil
+}
+
+// toModelPromptExperiment converts a promptexperiment row.
+func toModelPromptExperiment(e *postgres.Promptexperiment) *models.PromptExperiment {
+	experiment := &models.PromptExperiment{
+		ID:                uuid.UUID(e.ExperimentID.Bytes),
+		PromptName:        e.PromptName,
+		ControlPromptID:   uuid.UUID(e.ControlPromptID.Bytes),
+		TreatmentPromptID: uuid.UUID(e.TreatmentPromptID.Bytes),
+		TreatmentPercent:  int(e.TreatmentPercent),
+		Status:            e.Status,
+		StartedBy:         e.StartedBy,
+		StoppedBy:         e.StoppedBy.String,
+		StopReason:        e.StopReason.String,
+		StartedAt:         e.StartedAt.Time,
+	}
+	if e.StoppedAt.Valid {
+		stoppedAt := e.StoppedAt.Time
+		experiment.StoppedAt = &stoppedAt
+	}
+	return experiment
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *PromptExperimentRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.PromptExperimentRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *PromptExperimentRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.PromptExperimentRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *PromptExperimentRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.PromptExperimentRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
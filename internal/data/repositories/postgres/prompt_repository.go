@@ -0,0 +1,315 @@
+// internal/data/repositories/postgres/prompt_repository.go
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+var _ interfaces.PromptRepository = (*PromptRepository)(nil)
+
+// PromptRepository implements the interfaces.PromptRepository for PostgreSQL.
+type PromptRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewPromptRepository creates a new PromptRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewPromptRepository(db *pgxpool.Pool, logger *zap.Logger) *PromptRepository {
+	return &PromptRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// CreatePrompt implements interfaces.PromptRepository.
+// CreatePrompt stores a new prompt version and its "create" event in one transaction.
+func (r *PromptRepository) CreatePrompt(ctx context.Context, prompt *models.Prompt, event *models.PromptEvent) error {
+	const operation = "postgres.PromptRepository.CreatePrompt"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", prompt.Name), zap.Int("version", prompt.Version))
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		created, err := r.queries.CreatePrompt(ctx, tx, &postgres.CreatePromptParams{
+			Description:    pgtype.Text{String: prompt.Name, Valid: true},
+			Template:       prompt.Template,
+			InputVariables: optionalText(string(prompt.InputVariables)),
+			OutputFormat:   optionalText(string(prompt.OutputFormat)),
+			Version:        strconv.Itoa(prompt.Version),
+			Author:         pgtype.Text{String: prompt.Author, Valid: true},
+			Status:         prompt.Status,
+			ApprovalStatus: optionalText(prompt.ApprovalStatus),
+		})
+		if err != nil {
+			return err
+		}
+		*prompt = *toModelPrompt(created)
+		event.PromptID = prompt.ID
+		return r.createEvent(ctx, tx, event)
+	})
+	if err != nil {
+		r.logger.Error("Database error in CreatePrompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", prompt.Name), zap.Error(err))
+		return fmt.Errorf("could not create prompt version: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", prompt.ID.String()))
+	return nil
+}
+
+// GetPromptByID implements interfaces.PromptRepository.
+// GetPromptByID retrieves a prompt version by its ID. Returns a domain.NotFoundError if it does not exist.
+func (r *PromptRepository) GetPromptByID(ctx context.Context, promptID uuid.UUID) (*models.Prompt, error) {
+	const operation = "postgres.PromptRepository.GetPromptByID"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", promptID.String()))
+
+	prompt, err := r.queries.GetPromptByID(ctx, r.db, pgtype.UUID{Bytes: promptID, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			notFoundErr := domain.NewNotFoundError("prompt version", promptID.String())
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetPromptByID", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", promptID.String()), zap.Error(err))
+		return nil, fmt.Errorf("could not get prompt version: %w", err)
+	}
+	return toModelPrompt(prompt), nil
+}
+
+// GetActivePrompt implements interfaces.PromptRepository.
+// GetActivePrompt retrieves the active version of a prompt. Returns a domain.NotFoundError if none is active.
+func (r *PromptRepository) GetActivePrompt(ctx context.Context, name string) (*models.Prompt, error) {
+	const operation = "postgres.PromptRepository.GetActivePrompt"
+	requestID := utils.GetRequestID(ctx)
+
+	prompt, err := r.queries.GetActivePrompt(ctx, r.db, pgtype.Text{String: name, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Debug("No active prompt version", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name)) // Expected until a version is activated
+			notFoundErr := domain.NewNotFoundError("active prompt", name)
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetActivePrompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not get active prompt: %w", err)
+	}
+	return toModelPrompt(prompt), nil
+}
+
+// ListPromptVersions implements interfaces.PromptRepository.
+// ListPromptVersions retrieves every version of a prompt, newest first.
+func (r *PromptRepository) ListPromptVersions(ctx context.Context, name string) ([]*models.Prompt, error) {
+	const operation = "postgres.PromptRepository.ListPromptVersions"
+	requestID := utils.GetRequestID(ctx)
+
+	rows, err := r.queries.ListPromptVersions(ctx, r.db, pgtype.Text{String: name, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in ListPromptVersions", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("could not list prompt versions: %w", err)
+	}
+	prompts := make([]*models.Prompt, len(rows))
+	for i, row := range rows {
+		prompts[i] = toModelPrompt(row)
+	}
+	return prompts, nil
+}
+
+// NextPromptVersion implements interfaces.PromptRepository.
+// NextPromptVersion returns the version number of the next version of a prompt.
+func (r *PromptRepository) NextPromptVersion(ctx context.Context, name string) (int, error) {
+	const operation = "postgres.PromptRepository.NextPromptVersion"
+
+	next, err := r.queries.GetNextPromptVersion(ctx, r.db, pgtype.Text{String: name, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in NextPromptVersion", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Error(err))
+		return 0, fmt.Errorf("could not get next prompt version: %w", err)
+	}
+	return int(next), nil
+}
+
+// UpdatePrompt implements interfaces.PromptRepository.
+// UpdatePrompt stores a prompt version and appends its event in one transaction, first deactivating the previously
+// active version when the prompt becomes active.
+func (r *PromptRepository) UpdatePrompt(ctx context.Context, prompt *models.Prompt, event *models.PromptEvent) error {
+	const operation = "postgres.PromptRepository.UpdatePrompt"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", prompt.ID.String()), zap.String("status", prompt.Status))
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		// 1. Deactivate the current version before activating another (at most one may be active)
+		if prompt.Status == models.PromptStatusActive {
+			deactivated, err := r.queries.DeactivatePrompt(ctx, tx, pgtype.Text{String: prompt.Name, Valid: true})
+			if err != nil {
+				return err
+			}
+			for _, previous := range deactivated {
+				err := r.createEvent(ctx, tx, &models.PromptEvent{
+					PromptID:   uuid.UUID(previous.PromptID.Bytes),
+					Action:     "deactivate",
+					Actor:      event.Actor,
+					FromStatus: models.PromptStatusActive,
+					ToStatus:   models.PromptStatusInactive,
+					Comment:    fmt.Sprintf("replaced by version %d", prompt.Version),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// 2. Store the version; the database rejects content changes to versions that are no longer drafts
+		updated, err := r.queries.UpdatePrompt(ctx, tx, &postgres.UpdatePromptParams{
+			PromptID:       pgtype.UUID{Bytes: prompt.ID, Valid: true},
+			Description:    pgtype.Text{String: prompt.Name, Valid: true},
+			Template:       prompt.Template,
+			InputVariables: optionalText(string(prompt.InputVariables)),
+			OutputFormat:   optionalText(string(prompt.OutputFormat)),
+			Version:        strconv.Itoa(prompt.Version),
+			Author:         pgtype.Text{String: prompt.Author, Valid: true},
+			Status:         prompt.Status,
+			ApprovalStatus: optionalText(prompt.ApprovalStatus),
+		})
+		if err != nil {
+			return err
+		}
+		*prompt = *toModelPrompt(updated)
+
+		// 3. Append the event
+		event.PromptID = prompt.ID
+		return r.createEvent(ctx, tx, event)
+	})
+	if err != nil {
+		r.logger.Error("Database error in UpdatePrompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", prompt.ID.String()), zap.Error(err))
+		return fmt.Errorf("could not update prompt version: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt_id", prompt.ID.String()))
+	return nil
+}
+
+// ListPromptEvents implements interfaces.PromptRepository.
+// ListPromptEvents retrieves the lifecycle history of a prompt version, oldest first.
+func (r *PromptRepository) ListPromptEvents(ctx context.Context, promptID uuid.UUID) ([]*models.PromptEvent, error) {
+	const operation = "postgres.PromptRepository.ListPromptEvents"
+
+	rows, err := r.queries.ListPromptEvents(ctx, r.db, pgtype.UUID{Bytes: promptID, Valid: true})
+	if err != nil {
+		r.logger.Error("Database error in ListPromptEvents", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt_id", promptID.String()), zap.Error(err))
+		return nil, fmt.Errorf("could not list prompt events: %w", err)
+	}
+	events := make([]*models.PromptEvent, len(rows))
+	for i, row := range rows {
+		events[i] = &models.PromptEvent{
+			ID:         uuid.UUID(row.EventID.Bytes),
+			PromptID:   uuid.UUID(row.PromptID.Bytes),
+			Action:     row.Action,
+			Actor:      row.Actor,
+			FromStatus: row.FromStatus.String,
+			ToStatus:   row.ToStatus,
+			Comment:    row.Comment.String,
+			CreatedAt:  row.CreatedAt.Time,
+		}
+	}
+	return events, nil
+}
+
+// createEvent appends an event within tx.
+func (r *PromptRepository) createEvent(ctx context.Context, tx pgx.Tx, event *models.PromptEvent) error {
+	created, err := r.queries.CreatePromptEvent(ctx, tx, &postgres.CreatePromptEventParams{
+		PromptID:   pgtype.UUID{Bytes: event.PromptID, Valid: true},
+		Action:     event.Action,
+		Actor:      event.Actor,
+		FromStatus: optionalText(event.FromStatus),
+		ToStatus:   event.ToStatus,
+		Comment:    optionalText(event.Comment),
+	})
+	if err != nil {
+		return fmt.Errorf("appending %s event: %w", event.Action, err)
+	}
+	event.ID = uuid.UUID(created.EventID.Bytes)
+	event.CreatedAt = created.CreatedAt.Time
+	return nil
+}
+
+// inTx runs fn in a transaction, committing if it succeeds.
+func (r *PromptRepository) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+			r.logger.Error("Failed to roll back transaction", zap.String("operation", "postgres.PromptRepository.inTx"), zap.Error(rollbackErr))
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// toModelPrompt converts a prompts row. Versions are stored as text; non-numeric legacy versions read as 0.
+func toModelPrompt(p *postgres.Prompt) *models.Prompt {
+	version, _ := strconv.Atoi(p.Version)
+	prompt := &models.Prompt{
+		ID:             uuid.UUID(p.PromptID.Bytes),
+		Name:           p.Description.String,
+		Template:       p.Template,
+		Version:        version,
+		Author:         p.Author.String,
+		Status:         p.Status,
+		ApprovalStatus: p.ApprovalStatus.String,
+		CreatedAt:      p.CreatedAt.Time,
+		UpdatedAt:      p.UpdatedAt.Time,
+	}
+	if p.InputVariables.Valid {
+		prompt.InputVariables = []byte(p.InputVariables.String)
+	}
+	if p.OutputFormat.Valid {
+		prompt.OutputFormat = []byte(p.OutputFormat.String)
+	}
+	return prompt
+}
+
+// optionalText maps an empty string to NULL.
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *PromptRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.PromptRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *PromptRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.PromptRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *PromptRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.PromptRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
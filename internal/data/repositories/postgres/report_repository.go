@@ -101,7 +101,7 @@ func (r *ReportRepository) GetReportByID(ctx context.Context, reportID uuid.UUID
 // GetReportByPatientID implements interfaces.ReportRepository.
 func (r *ReportRepository) GetReportByPatientID(ctx context.Context, patientID uuid.UUID) ([]*models.Report, error) {
 	const operation = "postgres.ReportRepository.GetReportByPatientID"
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 	r.logger.Debug("Starting DB operation", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID))
 
 	reports, err := r.queries.GetReportByPatientID(ctx, r.db, pgtype.UUID{Bytes: uuid.UUID(patientID), Valid: true})
@@ -160,6 +160,28 @@ func (r *ReportRepository) DeleteAllReportsByPatientID(ctx context.Context, pati
 	return nil
 }
 
+// CreateReportPage implements interfaces.ReportRepository.
+func (r *ReportRepository) CreateReportPage(ctx context.Context, page *models.ReportPage) error {
+	const operation = "postgres.ReportRepository.CreateReportPage"
+	requestID := utils.GetRequestID(ctx)
+
+	params := &postgres.CreateReportPageParams{
+		ReportID:         pgtype.UUID{Bytes: page.ReportID, Valid: true},
+		PageNumber:       int32(page.PageNumber),
+		ExtractionMethod: page.ExtractionMethod,
+		Confidence:       pgtype.Float8{Float64: page.Confidence, Valid: page.ExtractionMethod == models.ExtractionMethodOCR},
+		CharCount:        int32(page.CharCount),
+	}
+
+	if err := r.queries.CreateReportPage(ctx, r.db, params); err != nil {
+		r.logger.Error("DB error in CreateReportPage", zap.String("operation", operation), zap.String("report_id", page.ReportID.String()), zap.Int("page_number", page.PageNumber), zap.String("request_id", requestID), zap.Error(err))
+		return utils.NewErrDBQuery("CreateReportPage failed", operation, "CreateReportPage", params, err)
+	}
+
+	r.logger.Debug("Successfully completed DB operation", zap.String("operation", operation), zap.String("report_id", page.ReportID.String()), zap.Int("page_number", page.PageNumber), zap.String("extraction_method", page.ExtractionMethod), zap.String("request_id", requestID))
+	return nil
+}
+
 // CreateFinding implements interfaces.ReportRepository.
 func (r *ReportRepository) CreateFinding(ctx context.Context, finding *models.Finding) error {
 	const operation = "postgres.ReportRepository.CreateFinding"
@@ -174,6 +196,7 @@ func (r *ReportRepository) CreateFinding(ctx context.Context, finding *models.Fi
 		Description:      finding.Description,
 		ImageCoordinates: finding.ImageCoordinates,
 		Source:           finding.Source,
+		LowConfidence:    finding.LowConfidence,
 	}
 
 	if r.logger.Core().Enabled(zapcore.DebugLevel) {
@@ -190,6 +213,35 @@ func (r *ReportRepository) CreateFinding(ctx context.Context, finding *models.Fi
 	return nil
 }
 
+// GetFindingsByPatientID implements interfaces.ReportRepository.
+func (r *ReportRepository) GetFindingsByPatientID(ctx context.Context, patientID uuid.UUID) ([]*models.Finding, error) {
+	const operation = "postgres.ReportRepository.GetFindingsByPatientID"
+	requestID := utils.GetRequestID(ctx)
+	r.logger.Debug("Starting DB operation", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID))
+
+	rows, err := r.queries.GetFindingsByPatientID(ctx, r.db, pgtype.UUID{Bytes: patientID, Valid: true})
+	if err != nil {
+		r.logger.Error("DB error in GetFindingsByPatientID", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID), zap.Error(err))
+		return nil, utils.NewErrDBQuery("GetFindingsByPatientID failed", operation, "GetFindingsByPatientID", patientID.String(), err)
+	}
+
+	findings := make([]*models.Finding, len(rows))
+	for i, row := range rows {
+		findings[i] = &models.Finding{
+			FindingID:        uuid.UUID(row.FindingID.Bytes),
+			FileID:           uuid.UUID(row.FileID.Bytes),
+			FindingType:      row.FindingType,
+			Description:      row.Description,
+			ImageCoordinates: row.ImageCoordinates,
+			Source:           row.Source,
+			LowConfidence:    row.LowConfidence,
+		}
+	}
+
+	r.logger.Debug("Successfully completed DB operation", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.Int("findings", len(findings)), zap.String("request_id", requestID))
+	return findings, nil
+}
+
 // CreateDiagnosis implements interfaces.ReportRepository.
 func (r *ReportRepository) CreateDiagnosis(ctx context.Context, diagnosis *models.Diagnosis) error {
 	const operation = "postgres.ReportRepository.CreateDiagnosis"
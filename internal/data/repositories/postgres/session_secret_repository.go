@@ -0,0 +1,121 @@
+// internal/data/repositories/postgres/session_secret_repository.go
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	postgres "github.com/stackvity/lung-server/internal/data/repositories/sqlc" // Alias to avoid naming conflict
+	"github.com/stackvity/lung-server/internal/domain"
+	"go.uber.org/zap"
+)
+
+var _ interfaces.SessionSecretRepository = (*SessionSecretRepository)(nil)
+
+// SessionSecretRepository implements the interfaces.SessionSecretRepository for PostgreSQL.
+type SessionSecretRepository struct {
+	db      *pgxpool.Pool
+	queries *postgres.Queries // Use the generated Queries struct
+	logger  *zap.Logger
+}
+
+// NewSessionSecretRepository creates a new SessionSecretRepository instance.
+// It requires a pgxpool.Pool for database access and a zap.Logger for logging.
+func NewSessionSecretRepository(db *pgxpool.Pool, logger *zap.Logger) *SessionSecretRepository {
+	return &SessionSecretRepository{
+		db:      db,
+		queries: postgres.New(), // Initialize sqlc Queries
+		logger:  logger,
+	}
+}
+
+// CreateSessionSecret implements interfaces.SessionSecretRepository.
+// CreateSessionSecret stores an encrypted session secret. An existing secret for the same session and purpose is kept.
+func (r *SessionSecretRepository) CreateSessionSecret(ctx context.Context, sessionID uuid.UUID, purpose string, encryptedSecret []byte) error {
+	const operation = "postgres.SessionSecretRepository.CreateSessionSecret"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose))
+
+	params := &postgres.CreateSessionSecretParams{
+		SessionID:       pgtype.UUID{Bytes: uuid.UUID(sessionID), Valid: true},
+		Purpose:         purpose,
+		EncryptedSecret: encryptedSecret,
+	}
+	if err := r.queries.CreateSessionSecret(ctx, r.db, params); err != nil {
+		r.logger.Error("Database error in CreateSessionSecret", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return fmt.Errorf("could not create session secret: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+	return nil
+}
+
+// GetSessionSecret implements interfaces.SessionSecretRepository.
+// GetSessionSecret retrieves the encrypted secret of a session for one purpose.
+// Returns a domain.NotFoundError if the secret is not found.
+func (r *SessionSecretRepository) GetSessionSecret(ctx context.Context, sessionID uuid.UUID, purpose string) ([]byte, error) {
+	const operation = "postgres.SessionSecretRepository.GetSessionSecret"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose))
+
+	secret, err := r.queries.GetSessionSecret(ctx, r.db, &postgres.GetSessionSecretParams{
+		SessionID: pgtype.UUID{Bytes: uuid.UUID(sessionID), Valid: true},
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Debug("Session secret not found in database", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose)) // Expected on a session's first upload
+			notFoundErr := domain.NewNotFoundError("session secret", sessionID.String())
+			notFoundErr.SetLogger(r.logger)
+			return nil, notFoundErr
+		}
+		r.logger.Error("Database error in GetSessionSecret", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return nil, fmt.Errorf("could not get session secret: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+	return secret.EncryptedSecret, nil
+}
+
+// DeleteSessionSecrets implements interfaces.SessionSecretRepository.
+// DeleteSessionSecrets deletes every secret of a session. This is used when deleting patient data.
+func (r *SessionSecretRepository) DeleteSessionSecrets(ctx context.Context, sessionID uuid.UUID) error {
+	const operation = "postgres.SessionSecretRepository.DeleteSessionSecrets"
+	r.logger.Debug("Starting database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+
+	if err := r.queries.DeleteSessionSecrets(ctx, r.db, pgtype.UUID{Bytes: uuid.UUID(sessionID), Valid: true}); err != nil {
+		r.logger.Error("Database error in DeleteSessionSecrets", zap.String("operation", operation), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return fmt.Errorf("could not delete session secrets: %w", err)
+	}
+
+	r.logger.Debug("Successfully completed database operation", zap.String("operation", operation), zap.String("session_id", sessionID.String()))
+	return nil
+}
+
+// BeginTx implements interfaces.Repository.
+// BeginTx starts a new database transaction.
+func (r *SessionSecretRepository) BeginTx(ctx context.Context, opts ...pgx.TxOptions) (pgx.Tx, error) {
+	r.logger.Debug("Starting transaction", zap.String("operation", "postgres.SessionSecretRepository.BeginTx"))
+	if len(opts) > 0 {
+		return r.db.BeginTx(ctx, opts[0])
+	}
+	return r.db.Begin(ctx)
+}
+
+// CommitTx implements interfaces.Repository.
+// CommitTx commits the database transaction.
+func (r *SessionSecretRepository) CommitTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Committing transaction", zap.String("operation", "postgres.SessionSecretRepository.CommitTx"))
+	return tx.Commit(ctx)
+}
+
+// RollbackTx implements interfaces.Repository.
+// RollbackTx rolls back the database transaction.
+func (r *SessionSecretRepository) RollbackTx(ctx context.Context, tx pgx.Tx) error {
+	r.logger.Debug("Rolling back transaction", zap.String("operation", "postgres.SessionSecretRepository.RollbackTx"))
+	return tx.Rollback(ctx)
+}
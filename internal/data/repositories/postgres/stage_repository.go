@@ -57,6 +57,8 @@ func (r *StageRepository) CreateStaging(ctx context.Context, stage *models.Stage
 		N:          pgtype.Text{String: stage.N, Valid: true},
 		M:          pgtype.Text{String: stage.M, Valid: true},
 		Confidence: pgtype.Text{String: stage.Confidence, Valid: true},
+
+		PromptVersionID: optionalUUID(stage.PromptVersionID),
 	}
 
 	if r.logger.Core().Enabled(zapcore.DebugLevel) {
@@ -105,6 +107,8 @@ func (r *StageRepository) GetStageByID(ctx context.Context, stageID uuid.UUID) (
 		Confidence: stage.Confidence.String,
 		CreatedAt:  stage.CreatedAt.Time,
 		UpdatedAt:  stage.UpdatedAt.Time,
+
+		PromptVersionID: uuid.UUID(stage.PromptVersionID.Bytes), // uuid.Nil when NULL
 	}
 
 	r.logger.Debug("Successfully completed DB operation", zap.String("operation", operation), zap.String("stage_id", stageID.String()), zap.String("request_id", requestID))
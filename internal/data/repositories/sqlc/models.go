@@ -125,14 +125,15 @@ type Auditlog struct {
 }
 
 type Diagnosis struct {
-	ID            pgtype.UUID        `json:"id"`
-	ResultID      pgtype.UUID        `json:"result_id"`
-	SessionID     pgtype.UUID        `json:"session_id"`
-	DiagnosisText pgtype.Text        `json:"diagnosis_text"`
-	Confidence    pgtype.Text        `json:"confidence"`
-	Justification pgtype.Text        `json:"justification"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	ID              pgtype.UUID        `json:"id"`
+	ResultID        pgtype.UUID        `json:"result_id"`
+	SessionID       pgtype.UUID        `json:"session_id"`
+	DiagnosisText   pgtype.Text        `json:"diagnosis_text"`
+	Confidence      pgtype.Text        `json:"confidence"`
+	Justification   pgtype.Text        `json:"justification"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	PromptVersionID pgtype.UUID        `json:"prompt_version_id"`
 }
 
 type Externalresource struct {
@@ -151,6 +152,8 @@ type Finding struct {
 	Source           string             `json:"source"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	PromptVersionID  pgtype.UUID        `json:"prompt_version_id"`
+	LowConfidence    bool               `json:"low_confidence"`
 }
 
 type Image struct {
@@ -165,6 +168,46 @@ type Image struct {
 	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 }
 
+type Labobservation struct {
+	ObservationID  pgtype.UUID        `json:"observation_id"`
+	SessionID      pgtype.UUID        `json:"session_id"`
+	Source         string             `json:"source"`
+	Analyte        string             `json:"analyte"`
+	Code           pgtype.Text        `json:"code"`
+	Value          string             `json:"value"`
+	NumericValue   pgtype.Float8      `json:"numeric_value"`
+	Unit           pgtype.Text        `json:"unit"`
+	ReferenceRange pgtype.Text        `json:"reference_range"`
+	ReferenceLow   pgtype.Float8      `json:"reference_low"`
+	ReferenceHigh  pgtype.Float8      `json:"reference_high"`
+	Flag           pgtype.Text        `json:"flag"`
+	ObservedAt     pgtype.Timestamptz `json:"observed_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type Llmcache struct {
+	CacheKey  string             `json:"cache_key"`
+	Method    string             `json:"method"`
+	Body      []byte             `json:"body"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	SessionID pgtype.UUID        `json:"session_id"`
+}
+
+type Llmusage struct {
+	UsageID         pgtype.UUID        `json:"usage_id"`
+	SessionID       pgtype.UUID        `json:"session_id"`
+	Method          string             `json:"method"`
+	PromptName      string             `json:"prompt_name"`
+	PromptVersion   int32              `json:"prompt_version"`
+	PromptVersionID pgtype.UUID        `json:"prompt_version_id"`
+	PromptTokens    int32              `json:"prompt_tokens"`
+	OutputTokens    int32              `json:"output_tokens"`
+	LatencyMs       int32              `json:"latency_ms"`
+	Outcome         string             `json:"outcome"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
 type Patientsession struct {
 	SessionID           pgtype.UUID        `json:"session_id"`
 	AccessLink          string             `json:"access_link"`
@@ -189,6 +232,53 @@ type Prompt struct {
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
 }
 
+type Promptcall struct {
+	CallID          pgtype.UUID        `json:"call_id"`
+	PromptName      string             `json:"prompt_name"`
+	PromptVersionID pgtype.UUID        `json:"prompt_version_id"`
+	ExperimentID    pgtype.UUID        `json:"experiment_id"`
+	Arm             pgtype.Text        `json:"arm"`
+	Outcome         string             `json:"outcome"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
+type Promptevent struct {
+	EventID    pgtype.UUID        `json:"event_id"`
+	PromptID   pgtype.UUID        `json:"prompt_id"`
+	Action     string             `json:"action"`
+	Actor      string             `json:"actor"`
+	FromStatus pgtype.Text        `json:"from_status"`
+	ToStatus   string             `json:"to_status"`
+	Comment    pgtype.Text        `json:"comment"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Promptexperiment struct {
+	ExperimentID      pgtype.UUID        `json:"experiment_id"`
+	PromptName        string             `json:"prompt_name"`
+	ControlPromptID   pgtype.UUID        `json:"control_prompt_id"`
+	TreatmentPromptID pgtype.UUID        `json:"treatment_prompt_id"`
+	TreatmentPercent  int32              `json:"treatment_percent"`
+	Status            string             `json:"status"`
+	StartedBy         string             `json:"started_by"`
+	StoppedBy         pgtype.Text        `json:"stopped_by"`
+	StopReason        pgtype.Text        `json:"stop_reason"`
+	StartedAt         pgtype.Timestamptz `json:"started_at"`
+	StoppedAt         pgtype.Timestamptz `json:"stopped_at"`
+}
+
+type Promptfeedback struct {
+	FeedbackID      pgtype.UUID        `json:"feedback_id"`
+	TargetType      string             `json:"target_type"`
+	TargetID        pgtype.UUID        `json:"target_id"`
+	PromptName      string             `json:"prompt_name"`
+	PromptVersionID pgtype.UUID        `json:"prompt_version_id"`
+	Reviewer        string             `json:"reviewer"`
+	Rating          int16              `json:"rating"`
+	Comment         pgtype.Text        `json:"comment"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
 type Report struct {
 	ID         pgtype.UUID        `json:"id"`
 	PatientID  pgtype.UUID        `json:"patient_id"`
@@ -200,16 +290,33 @@ type Report struct {
 	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
 }
 
+type Reportpage struct {
+	ReportID         pgtype.UUID        `json:"report_id"`
+	PageNumber       int32              `json:"page_number"`
+	ExtractionMethod string             `json:"extraction_method"`
+	Confidence       pgtype.Float8      `json:"confidence"`
+	CharCount        int32              `json:"char_count"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+}
+
+type Sessionsecret struct {
+	SessionID       pgtype.UUID        `json:"session_id"`
+	Purpose         string             `json:"purpose"`
+	EncryptedSecret []byte             `json:"encrypted_secret"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
 type Stage struct {
-	ID         pgtype.UUID        `json:"id"`
-	ResultID   pgtype.UUID        `json:"result_id"`
-	SessionID  pgtype.UUID        `json:"session_id"`
-	T          pgtype.Text        `json:"t"`
-	N          pgtype.Text        `json:"n"`
-	M          pgtype.Text        `json:"m"`
-	Confidence pgtype.Text        `json:"confidence"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	ID              pgtype.UUID        `json:"id"`
+	ResultID        pgtype.UUID        `json:"result_id"`
+	SessionID       pgtype.UUID        `json:"session_id"`
+	T               pgtype.Text        `json:"t"`
+	N               pgtype.Text        `json:"n"`
+	M               pgtype.Text        `json:"m"`
+	Confidence      pgtype.Text        `json:"confidence"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	PromptVersionID pgtype.UUID        `json:"prompt_version_id"`
 }
 
 type Study struct {
@@ -31,15 +31,36 @@ type Querier interface {
 	// ------------- Image Queries -------------
 	// CreateImage creates a new image
 	CreateImage(ctx context.Context, db DBTX, arg *CreateImageParams) (*Image, error)
+	// ------------- LLMUsage Queries -------------
+	// CreateLLMUsage: Records the tokens and latency of a model call.
+	CreateLLMUsage(ctx context.Context, db DBTX, arg *CreateLLMUsageParams) error
+	// ------------- LabObservation Queries -------------
+	// CreateLabObservation: Stores a normalized lab result of a session.
+	CreateLabObservation(ctx context.Context, db DBTX, arg *CreateLabObservationParams) error
 	// ------------- PatientSession (and Link) Queries -------------
 	// CreatePatientSession: Creates a new patient session (with associated link).
 	CreatePatientSession(ctx context.Context, db DBTX, arg *CreatePatientSessionParams) (*Patientsession, error)
 	// ------------- Prompt Queries -------------
 	// CreatePrompt: Inserts a new prompt.
 	CreatePrompt(ctx context.Context, db DBTX, arg *CreatePromptParams) (*Prompt, error)
+	// CreatePromptCall: Records the outcome of a model call made with a managed prompt.
+	CreatePromptCall(ctx context.Context, db DBTX, arg *CreatePromptCallParams) error
+	// ------------- PromptEvent Queries -------------
+	// CreatePromptEvent: Appends a lifecycle event to a prompt version's history.
+	CreatePromptEvent(ctx context.Context, db DBTX, arg *CreatePromptEventParams) (*Promptevent, error)
+	// ------------- PromptExperiment Queries -------------
+	// CreatePromptExperiment: Starts an A/B experiment between the active version of a prompt and a candidate version.
+	CreatePromptExperiment(ctx context.Context, db DBTX, arg *CreatePromptExperimentParams) (*Promptexperiment, error)
+	// CreatePromptFeedback: Stores a clinician's rating of a model output.
+	CreatePromptFeedback(ctx context.Context, db DBTX, arg *CreatePromptFeedbackParams) (*Promptfeedback, error)
 	// ------------- Report Queries -------------
 	// CreateReport inserts a new report record.
 	CreateReport(ctx context.Context, db DBTX, arg *CreateReportParams) (*Report, error)
+	// CreateReportPage records how the text of one page of a report was extracted.
+	CreateReportPage(ctx context.Context, db DBTX, arg *CreateReportPageParams) error
+	// ------------- SessionSecret Queries -------------
+	// CreateSessionSecret: Stores an encrypted per-session secret. An existing secret is kept, so concurrent uploads agree on one secret.
+	CreateSessionSecret(ctx context.Context, db DBTX, arg *CreateSessionSecretParams) error
 	// ------------- Stage Queries -------------
 	// CreateStaging inserts a new staging record.
 	CreateStaging(ctx context.Context, db DBTX, arg *CreateStagingParams) (*Stage, error)
@@ -52,6 +73,8 @@ type Querier interface {
 	// ------------- UploadedContent Queries -------------
 	// CreateUploadedContent: Inserts a new uploaded content record.
 	CreateUploadedContent(ctx context.Context, db DBTX, arg *CreateUploadedContentParams) (*Uploadedcontent, error)
+	// DeactivatePrompt: Makes the active version of a prompt inactive, returning it (no rows if none was active).
+	DeactivatePrompt(ctx context.Context, db DBTX, description pgtype.Text) ([]*Prompt, error)
 	// DeleteAllImagesByPatientID deletes all image records associated with a given patient ID.
 	DeleteAllImagesByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) error
 	// DeleteAllReportsByPatientID deletes all reports for a patient
@@ -64,18 +87,24 @@ type Querier interface {
 	DeleteAnalysisResultBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) error
 	// DeleteAnalysisResultExternalResource: Removes a link between an analysis result and an external resource.
 	DeleteAnalysisResultExternalResource(ctx context.Context, db DBTX, arg *DeleteAnalysisResultExternalResourceParams) error
+	// DeleteExpiredLLMCacheEntries: Deletes expired cached model responses.
+	DeleteExpiredLLMCacheEntries(ctx context.Context, db DBTX) error
 	// DeleteExpiredSessions: Deletes expired patient sessions.
 	DeleteExpiredSessions(ctx context.Context, db DBTX) error
 	// DeleteExternalResource: Deletes an external resource by its ID.
 	DeleteExternalResource(ctx context.Context, db DBTX, resourceID int32) error
 	// DeleteImage deletes a image by ID
 	DeleteImage(ctx context.Context, db DBTX, id pgtype.UUID) error
+	// DeleteLLMCacheEntriesBySessionID: Deletes the cached model responses generated for a session.
+	DeleteLLMCacheEntriesBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) error
 	// DeletePatientSession: Deletes a patient session by session ID.
 	DeletePatientSession(ctx context.Context, db DBTX, sessionID pgtype.UUID) error
 	// DeletePrompt: Deletes a prompt by its ID.
 	DeletePrompt(ctx context.Context, db DBTX, promptID pgtype.UUID) error
 	// DeleteReport deletes a report by ID
 	DeleteReport(ctx context.Context, db DBTX, id pgtype.UUID) error
+	// DeleteSessionSecrets: Deletes every secret of a session, making its pseudonyms unlinkable.
+	DeleteSessionSecrets(ctx context.Context, db DBTX, sessionID pgtype.UUID) error
 	// DeleteStudy deletes a study by ID
 	DeleteStudy(ctx context.Context, db DBTX, id pgtype.UUID) error
 	// DeleteUploadedContent: Deletes uploaded content by its ID.
@@ -92,24 +121,55 @@ type Querier interface {
 	GetAuditLogByID(ctx context.Context, db DBTX, logID int64) (*Auditlog, error)
 	// GetDiagnosisByID retrieves a diagnosis by its ID.
 	GetDiagnosisByID(ctx context.Context, db DBTX, id pgtype.UUID) (*Diagnosis, error)
+	// GetDiagnosisPromptVersion: Returns the prompt version a stored diagnosis was generated with.
+	GetDiagnosisPromptVersion(ctx context.Context, db DBTX, id pgtype.UUID) (pgtype.UUID, error)
 	// GetExternalResourceByID: Retrieves an external resource by its ID.
 	GetExternalResourceByID(ctx context.Context, db DBTX, resourceID int32) (*Externalresource, error)
+	// GetFindingsByPatientID retrieves the findings of every report of a patient, oldest first.
+	GetFindingsByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) ([]*Finding, error)
 	// GetImageByID retrieves a image by its ID
 	GetImageByID(ctx context.Context, db DBTX, id pgtype.UUID) (*Image, error)
 	// GetImageByStudyID retrieves all images for a study
 	GetImageByStudyID(ctx context.Context, db DBTX, studyID pgtype.UUID) ([]*Image, error)
+	// ------------- LLMCache Queries -------------
+	// GetLLMCacheEntry: Retrieves the encrypted cached model response of a key, unless it has expired.
+	GetLLMCacheEntry(ctx context.Context, db DBTX, cacheKey string) (*Llmcache, error)
+	// GetLLMTokensSince: Sums the prompt and output tokens of every model call made since a time.
+	GetLLMTokensSince(ctx context.Context, db DBTX, createdAt pgtype.Timestamptz) (int64, error)
+	// GetLLMUsageSummary: Aggregates model calls per UTC day, method and prompt version between two times.
+	GetLLMUsageSummary(ctx context.Context, db DBTX, arg *GetLLMUsageSummaryParams) ([]*GetLLMUsageSummaryRow, error)
+	// GetLabObservationsBySessionID: Retrieves the lab results of a session by analyte, oldest first.
+	GetLabObservationsBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) ([]*Labobservation, error)
+	// GetNextPromptVersion: Returns the version number following the latest numeric version of a prompt (1 for a new prompt).
+	GetNextPromptVersion(ctx context.Context, db DBTX, description pgtype.Text) (int32, error)
 	// GetNoduleByID retrieves a nodule by its ID.
 	GetNoduleByID(ctx context.Context, db DBTX, findingID pgtype.UUID) (*GetNoduleByIDRow, error)
+	// GetNodulePromptVersion: Returns the prompt version a stored nodule was detected with.
+	GetNodulePromptVersion(ctx context.Context, db DBTX, findingID pgtype.UUID) (pgtype.UUID, error)
 	// GetPatientSessionByLink: Retrieves a patient session by its access link.
 	GetPatientSessionByLink(ctx context.Context, db DBTX, accessLink string) (*Patientsession, error)
 	// GetPromptByID: Retrieves a prompt by its ID.
 	GetPromptByID(ctx context.Context, db DBTX, promptID pgtype.UUID) (*Prompt, error)
+	// GetPromptCallStats: Counts the calls of a prompt per version and outcome between two times, optionally within one experiment.
+	GetPromptCallStats(ctx context.Context, db DBTX, arg *GetPromptCallStatsParams) ([]*GetPromptCallStatsRow, error)
+	// GetPromptExperimentByID: Retrieves an experiment by its ID.
+	GetPromptExperimentByID(ctx context.Context, db DBTX, experimentID pgtype.UUID) (*Promptexperiment, error)
+	// GetPromptFeedbackStats: Aggregates the ratings of a prompt's outputs per version, for feedback given between two times.
+	GetPromptFeedbackStats(ctx context.Context, db DBTX, arg *GetPromptFeedbackStatsParams) ([]*GetPromptFeedbackStatsRow, error)
 	// GetReportByID retrieves a report by its ID.
 	GetReportByID(ctx context.Context, db DBTX, id pgtype.UUID) (*Report, error)
 	// GetReportByPatientID retrieves all reports for a patient
 	GetReportByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) ([]*Report, error)
+	// GetRunningPromptExperiment: Retrieves the running experiment of a prompt, if any.
+	GetRunningPromptExperiment(ctx context.Context, db DBTX, promptName string) (*Promptexperiment, error)
+	// GetSessionLLMTokens: Sums the prompt and output tokens of every model call made for a session.
+	GetSessionLLMTokens(ctx context.Context, db DBTX, sessionID pgtype.UUID) (int64, error)
+	// GetSessionSecret: Retrieves the encrypted secret of a session for one purpose.
+	GetSessionSecret(ctx context.Context, db DBTX, arg *GetSessionSecretParams) (*Sessionsecret, error)
 	// GetStageByID retrieves a staging record by its ID.
 	GetStageByID(ctx context.Context, db DBTX, id pgtype.UUID) (*Stage, error)
+	// GetStagePromptVersion: Returns the prompt version a stored staging record was generated with.
+	GetStagePromptVersion(ctx context.Context, db DBTX, id pgtype.UUID) (pgtype.UUID, error)
 	// GetStudyByID retrieves a study by its ID
 	GetStudyByID(ctx context.Context, db DBTX, id pgtype.UUID) (*Study, error)
 	// GetTreatmentRecommendationByID retrieves a treatment recommendation record by its ID.
@@ -132,18 +192,28 @@ type Querier interface {
 	ListExternalResourcesByResultID(ctx context.Context, db DBTX, resultID pgtype.UUID) ([]*Externalresource, error)
 	// ListImagesByPatientID retrieves all images for a patient
 	ListImagesByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) ([]*Image, error)
+	// ListPromptEvents: Retrieves the lifecycle history of a prompt version, oldest first.
+	ListPromptEvents(ctx context.Context, db DBTX, promptID pgtype.UUID) ([]*Promptevent, error)
+	// ListPromptExperiments: Retrieves every experiment of a prompt, newest first.
+	ListPromptExperiments(ctx context.Context, db DBTX, promptName string) ([]*Promptexperiment, error)
+	// ListPromptVersions: Retrieves every version of a prompt, newest first (non-numeric versions last).
+	ListPromptVersions(ctx context.Context, db DBTX, description pgtype.Text) ([]*Prompt, error)
 	// ListPrompts: Retrieves all prompts.
 	ListPrompts(ctx context.Context, db DBTX) ([]*Prompt, error)
 	// ListStudiesByPatientID retrieves all studies for a patient
 	ListStudiesByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) ([]*Study, error)
 	// ListUploadedContentBySessionID: Retrieves all uploaded content for a given session.
 	ListUploadedContentBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) ([]*Uploadedcontent, error)
+	// StopPromptExperiment: Stops a running experiment, returning it (no rows if it was not running).
+	StopPromptExperiment(ctx context.Context, db DBTX, arg *StopPromptExperimentParams) (*Promptexperiment, error)
 	// UpdateExternalResource: Updates an existing external resource.
 	UpdateExternalResource(ctx context.Context, db DBTX, arg *UpdateExternalResourceParams) (*Externalresource, error)
 	// UpdatePatientSessionUsed: Marks a patient session as used.
 	UpdatePatientSessionUsed(ctx context.Context, db DBTX, sessionID pgtype.UUID) error
 	// UpdatePrompt: Updates an existing prompt.
 	UpdatePrompt(ctx context.Context, db DBTX, arg *UpdatePromptParams) (*Prompt, error)
+	// UpsertLLMCacheEntry: Stores an encrypted model response, replacing an earlier one for the same key.
+	UpsertLLMCacheEntry(ctx context.Context, db DBTX, arg *UpsertLLMCacheEntryParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -105,17 +105,18 @@ func (q *Queries) CreateAuditLog(ctx context.Context, db DBTX, arg *CreateAuditL
 
 const createDiagnosis = `-- name: CreateDiagnosis :one
 
-INSERT INTO diagnosis (result_id, session_id, diagnosis_text, confidence, justification)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at
+INSERT INTO diagnosis (result_id, session_id, diagnosis_text, confidence, justification, prompt_version_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at, prompt_version_id
 `
 
 type CreateDiagnosisParams struct {
-	ResultID      pgtype.UUID `json:"result_id"`
-	SessionID     pgtype.UUID `json:"session_id"`
-	DiagnosisText pgtype.Text `json:"diagnosis_text"`
-	Confidence    pgtype.Text `json:"confidence"`
-	Justification pgtype.Text `json:"justification"`
+	ResultID        pgtype.UUID `json:"result_id"`
+	SessionID       pgtype.UUID `json:"session_id"`
+	DiagnosisText   pgtype.Text `json:"diagnosis_text"`
+	Confidence      pgtype.Text `json:"confidence"`
+	Justification   pgtype.Text `json:"justification"`
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
 }
 
 // ------------- Diagnosis Queries -------------
@@ -127,6 +128,7 @@ func (q *Queries) CreateDiagnosis(ctx context.Context, db DBTX, arg *CreateDiagn
 		arg.DiagnosisText,
 		arg.Confidence,
 		arg.Justification,
+		arg.PromptVersionID,
 	)
 	var i Diagnosis
 	err := row.Scan(
@@ -138,6 +140,7 @@ func (q *Queries) CreateDiagnosis(ctx context.Context, db DBTX, arg *CreateDiagn
 		&i.Justification,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromptVersionID,
 	)
 	return &i, err
 }
@@ -171,9 +174,9 @@ func (q *Queries) CreateExternalResource(ctx context.Context, db DBTX, arg *Crea
 }
 
 const createFinding = `-- name: CreateFinding :one
-INSERT INTO findings (finding_id, file_id, finding_type, description, image_coordinates, source)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING finding_id, file_id, finding_type, description, image_coordinates, source, created_at, updated_at
+INSERT INTO findings (finding_id, file_id, finding_type, description, image_coordinates, source, prompt_version_id, low_confidence)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING finding_id, file_id, finding_type, description, image_coordinates, source, created_at, updated_at, prompt_version_id, low_confidence
 `
 
 type CreateFindingParams struct {
@@ -183,6 +186,8 @@ type CreateFindingParams struct {
 	Description      string      `json:"description"`
 	ImageCoordinates []float64   `json:"image_coordinates"`
 	Source           string      `json:"source"`
+	PromptVersionID  pgtype.UUID `json:"prompt_version_id"`
+	LowConfidence    bool        `json:"low_confidence"`
 }
 
 // CreateFinding inserts a new finding record.
@@ -194,6 +199,8 @@ func (q *Queries) CreateFinding(ctx context.Context, db DBTX, arg *CreateFinding
 		arg.Description,
 		arg.ImageCoordinates,
 		arg.Source,
+		arg.PromptVersionID,
+		arg.LowConfidence,
 	)
 	var i Finding
 	err := row.Scan(
@@ -205,6 +212,8 @@ func (q *Queries) CreateFinding(ctx context.Context, db DBTX, arg *CreateFinding
 		&i.Source,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromptVersionID,
+		&i.LowConfidence,
 	)
 	return &i, err
 }
@@ -253,6 +262,82 @@ func (q *Queries) CreateImage(ctx context.Context, db DBTX, arg *CreateImagePara
 	return &i, err
 }
 
+const createLLMUsage = `-- name: CreateLLMUsage :exec
+
+INSERT INTO llmusage (session_id, method, prompt_name, prompt_version, prompt_version_id, prompt_tokens, output_tokens, latency_ms, outcome)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateLLMUsageParams struct {
+	SessionID       pgtype.UUID `json:"session_id"`
+	Method          string      `json:"method"`
+	PromptName      string      `json:"prompt_name"`
+	PromptVersion   int32       `json:"prompt_version"`
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
+	PromptTokens    int32       `json:"prompt_tokens"`
+	OutputTokens    int32       `json:"output_tokens"`
+	LatencyMs       int32       `json:"latency_ms"`
+	Outcome         string      `json:"outcome"`
+}
+
+// ------------- LLMUsage Queries -------------
+// CreateLLMUsage: Records the tokens and latency of a model call.
+func (q *Queries) CreateLLMUsage(ctx context.Context, db DBTX, arg *CreateLLMUsageParams) error {
+	_, err := db.Exec(ctx, createLLMUsage,
+		arg.SessionID,
+		arg.Method,
+		arg.PromptName,
+		arg.PromptVersion,
+		arg.PromptVersionID,
+		arg.PromptTokens,
+		arg.OutputTokens,
+		arg.LatencyMs,
+		arg.Outcome,
+	)
+	return err
+}
+
+const createLabObservation = `-- name: CreateLabObservation :exec
+
+INSERT INTO labobservations (session_id, source, analyte, code, value, numeric_value, unit, reference_range, reference_low, reference_high, flag, observed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
+type CreateLabObservationParams struct {
+	SessionID      pgtype.UUID        `json:"session_id"`
+	Source         string             `json:"source"`
+	Analyte        string             `json:"analyte"`
+	Code           pgtype.Text        `json:"code"`
+	Value          string             `json:"value"`
+	NumericValue   pgtype.Float8      `json:"numeric_value"`
+	Unit           pgtype.Text        `json:"unit"`
+	ReferenceRange pgtype.Text        `json:"reference_range"`
+	ReferenceLow   pgtype.Float8      `json:"reference_low"`
+	ReferenceHigh  pgtype.Float8      `json:"reference_high"`
+	Flag           pgtype.Text        `json:"flag"`
+	ObservedAt     pgtype.Timestamptz `json:"observed_at"`
+}
+
+// ------------- LabObservation Queries -------------
+// CreateLabObservation: Stores a normalized lab result of a session.
+func (q *Queries) CreateLabObservation(ctx context.Context, db DBTX, arg *CreateLabObservationParams) error {
+	_, err := db.Exec(ctx, createLabObservation,
+		arg.SessionID,
+		arg.Source,
+		arg.Analyte,
+		arg.Code,
+		arg.Value,
+		arg.NumericValue,
+		arg.Unit,
+		arg.ReferenceRange,
+		arg.ReferenceLow,
+		arg.ReferenceHigh,
+		arg.Flag,
+		arg.ObservedAt,
+	)
+	return err
+}
+
 const createPatientSession = `-- name: CreatePatientSession :one
 
 INSERT INTO patientsession (access_link, expiration_timestamp, used, patient_data)
@@ -337,6 +422,154 @@ func (q *Queries) CreatePrompt(ctx context.Context, db DBTX, arg *CreatePromptPa
 	return &i, err
 }
 
+const createPromptCall = `-- name: CreatePromptCall :exec
+INSERT INTO promptcall (prompt_name, prompt_version_id, experiment_id, arm, outcome)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreatePromptCallParams struct {
+	PromptName      string      `json:"prompt_name"`
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
+	ExperimentID    pgtype.UUID `json:"experiment_id"`
+	Arm             pgtype.Text `json:"arm"`
+	Outcome         string      `json:"outcome"`
+}
+
+// CreatePromptCall: Records the outcome of a model call made with a managed prompt.
+func (q *Queries) CreatePromptCall(ctx context.Context, db DBTX, arg *CreatePromptCallParams) error {
+	_, err := db.Exec(ctx, createPromptCall,
+		arg.PromptName,
+		arg.PromptVersionID,
+		arg.ExperimentID,
+		arg.Arm,
+		arg.Outcome,
+	)
+	return err
+}
+
+const createPromptEvent = `-- name: CreatePromptEvent :one
+INSERT INTO promptevent (prompt_id, action, actor, from_status, to_status, comment)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING event_id, prompt_id, action, actor, from_status, to_status, comment, created_at
+`
+
+type CreatePromptEventParams struct {
+	PromptID   pgtype.UUID `json:"prompt_id"`
+	Action     string      `json:"action"`
+	Actor      string      `json:"actor"`
+	FromStatus pgtype.Text `json:"from_status"`
+	ToStatus   string      `json:"to_status"`
+	Comment    pgtype.Text `json:"comment"`
+}
+
+// ------------- PromptEvent Queries -------------
+// CreatePromptEvent: Appends a lifecycle event to a prompt version's history.
+func (q *Queries) CreatePromptEvent(ctx context.Context, db DBTX, arg *CreatePromptEventParams) (*Promptevent, error) {
+	row := db.QueryRow(ctx, createPromptEvent,
+		arg.PromptID,
+		arg.Action,
+		arg.Actor,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.Comment,
+	)
+	var i Promptevent
+	err := row.Scan(
+		&i.EventID,
+		&i.PromptID,
+		&i.Action,
+		&i.Actor,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.Comment,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
+const createPromptExperiment = `-- name: CreatePromptExperiment :one
+INSERT INTO promptexperiment (prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, started_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+`
+
+type CreatePromptExperimentParams struct {
+	PromptName        string      `json:"prompt_name"`
+	ControlPromptID   pgtype.UUID `json:"control_prompt_id"`
+	TreatmentPromptID pgtype.UUID `json:"treatment_prompt_id"`
+	TreatmentPercent  int32       `json:"treatment_percent"`
+	StartedBy         string      `json:"started_by"`
+}
+
+// ------------- PromptExperiment Queries -------------
+// CreatePromptExperiment: Starts an A/B experiment between the active version of a prompt and a candidate version.
+func (q *Queries) CreatePromptExperiment(ctx context.Context, db DBTX, arg *CreatePromptExperimentParams) (*Promptexperiment, error) {
+	row := db.QueryRow(ctx, createPromptExperiment,
+		arg.PromptName,
+		arg.ControlPromptID,
+		arg.TreatmentPromptID,
+		arg.TreatmentPercent,
+		arg.StartedBy,
+	)
+	var i Promptexperiment
+	err := row.Scan(
+		&i.ExperimentID,
+		&i.PromptName,
+		&i.ControlPromptID,
+		&i.TreatmentPromptID,
+		&i.TreatmentPercent,
+		&i.Status,
+		&i.StartedBy,
+		&i.StoppedBy,
+		&i.StopReason,
+		&i.StartedAt,
+		&i.StoppedAt,
+	)
+	return &i, err
+}
+
+const createPromptFeedback = `-- name: CreatePromptFeedback :one
+INSERT INTO promptfeedback (target_type, target_id, prompt_name, prompt_version_id, reviewer, rating, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING feedback_id, target_type, target_id, prompt_name, prompt_version_id, reviewer, rating, comment, created_at
+`
+
+type CreatePromptFeedbackParams struct {
+	TargetType      string      `json:"target_type"`
+	TargetID        pgtype.UUID `json:"target_id"`
+	PromptName      string      `json:"prompt_name"`
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
+	Reviewer        string      `json:"reviewer"`
+	Rating          int16       `json:"rating"`
+	Comment         pgtype.Text `json:"comment"`
+}
+
+// CreatePromptFeedback: Stores a clinician's rating of a model output.
+func (q *Queries) CreatePromptFeedback(ctx context.Context, db DBTX, arg *CreatePromptFeedbackParams) (*Promptfeedback, error) {
+	row := db.QueryRow(ctx, createPromptFeedback,
+		arg.TargetType,
+		arg.TargetID,
+		arg.PromptName,
+		arg.PromptVersionID,
+		arg.Reviewer,
+		arg.Rating,
+		arg.Comment,
+	)
+	var i Promptfeedback
+	err := row.Scan(
+		&i.FeedbackID,
+		&i.TargetType,
+		&i.TargetID,
+		&i.PromptName,
+		&i.PromptVersionID,
+		&i.Reviewer,
+		&i.Rating,
+		&i.Comment,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
 const createReport = `-- name: CreateReport :one
 
 INSERT INTO reports (id, patient_id, filename, report_type, report_text, filepath)
@@ -378,20 +611,66 @@ func (q *Queries) CreateReport(ctx context.Context, db DBTX, arg *CreateReportPa
 	return &i, err
 }
 
+const createReportPage = `-- name: CreateReportPage :exec
+INSERT INTO reportpages (report_id, page_number, extraction_method, confidence, char_count)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateReportPageParams struct {
+	ReportID         pgtype.UUID   `json:"report_id"`
+	PageNumber       int32         `json:"page_number"`
+	ExtractionMethod string        `json:"extraction_method"`
+	Confidence       pgtype.Float8 `json:"confidence"`
+	CharCount        int32         `json:"char_count"`
+}
+
+// CreateReportPage records how the text of one page of a report was extracted.
+func (q *Queries) CreateReportPage(ctx context.Context, db DBTX, arg *CreateReportPageParams) error {
+	_, err := db.Exec(ctx, createReportPage,
+		arg.ReportID,
+		arg.PageNumber,
+		arg.ExtractionMethod,
+		arg.Confidence,
+		arg.CharCount,
+	)
+	return err
+}
+
+const createSessionSecret = `-- name: CreateSessionSecret :exec
+
+INSERT INTO sessionsecret (session_id, purpose, encrypted_secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (session_id, purpose) DO NOTHING
+`
+
+type CreateSessionSecretParams struct {
+	SessionID       pgtype.UUID `json:"session_id"`
+	Purpose         string      `json:"purpose"`
+	EncryptedSecret []byte      `json:"encrypted_secret"`
+}
+
+// ------------- SessionSecret Queries -------------
+// CreateSessionSecret: Stores an encrypted per-session secret. An existing secret is kept, so concurrent uploads agree on one secret.
+func (q *Queries) CreateSessionSecret(ctx context.Context, db DBTX, arg *CreateSessionSecretParams) error {
+	_, err := db.Exec(ctx, createSessionSecret, arg.SessionID, arg.Purpose, arg.EncryptedSecret)
+	return err
+}
+
 const createStaging = `-- name: CreateStaging :one
 
-INSERT INTO stages (result_id, session_id, t, n, m, confidence)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, result_id, session_id, t, n, m, confidence, created_at, updated_at
+INSERT INTO stages (result_id, session_id, t, n, m, confidence, prompt_version_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, result_id, session_id, t, n, m, confidence, created_at, updated_at, prompt_version_id
 `
 
 type CreateStagingParams struct {
-	ResultID   pgtype.UUID `json:"result_id"`
-	SessionID  pgtype.UUID `json:"session_id"`
-	T          pgtype.Text `json:"t"`
-	N          pgtype.Text `json:"n"`
-	M          pgtype.Text `json:"m"`
-	Confidence pgtype.Text `json:"confidence"`
+	ResultID        pgtype.UUID `json:"result_id"`
+	SessionID       pgtype.UUID `json:"session_id"`
+	T               pgtype.Text `json:"t"`
+	N               pgtype.Text `json:"n"`
+	M               pgtype.Text `json:"m"`
+	Confidence      pgtype.Text `json:"confidence"`
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
 }
 
 // ------------- Stage Queries -------------
@@ -404,6 +683,7 @@ func (q *Queries) CreateStaging(ctx context.Context, db DBTX, arg *CreateStaging
 		arg.N,
 		arg.M,
 		arg.Confidence,
+		arg.PromptVersionID,
 	)
 	var i Stage
 	err := row.Scan(
@@ -416,6 +696,7 @@ func (q *Queries) CreateStaging(ctx context.Context, db DBTX, arg *CreateStaging
 		&i.Confidence,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromptVersionID,
 	)
 	return &i, err
 }
@@ -551,6 +832,47 @@ func (q *Queries) CreateUploadedContent(ctx context.Context, db DBTX, arg *Creat
 	return &i, err
 }
 
+const deactivatePrompt = `-- name: DeactivatePrompt :many
+UPDATE prompts
+SET status = 'inactive'
+WHERE description = $1
+AND status = 'active'
+RETURNING prompt_id, description, template, input_variables, output_format, version, author, status, approval_status, created_at, updated_at
+`
+
+// DeactivatePrompt: Makes the active version of a prompt inactive, returning it (no rows if none was active).
+func (q *Queries) DeactivatePrompt(ctx context.Context, db DBTX, description pgtype.Text) ([]*Prompt, error) {
+	rows, err := db.Query(ctx, deactivatePrompt, description)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Prompt
+	for rows.Next() {
+		var i Prompt
+		if err := rows.Scan(
+			&i.PromptID,
+			&i.Description,
+			&i.Template,
+			&i.InputVariables,
+			&i.OutputFormat,
+			&i.Version,
+			&i.Author,
+			&i.Status,
+			&i.ApprovalStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteAllImagesByPatientID = `-- name: DeleteAllImagesByPatientID :exec
 DELETE FROM images
 WHERE study_id IN (SELECT id FROM studies WHERE patient_id = $1)
@@ -621,6 +943,17 @@ func (q *Queries) DeleteAnalysisResultExternalResource(ctx context.Context, db D
 	return err
 }
 
+const deleteExpiredLLMCacheEntries = `-- name: DeleteExpiredLLMCacheEntries :exec
+DELETE FROM llmcache
+WHERE expires_at <= NOW()
+`
+
+// DeleteExpiredLLMCacheEntries: Deletes expired cached model responses.
+func (q *Queries) DeleteExpiredLLMCacheEntries(ctx context.Context, db DBTX) error {
+	_, err := db.Exec(ctx, deleteExpiredLLMCacheEntries)
+	return err
+}
+
 const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
 DELETE FROM patientsession
 WHERE expiration_timestamp < NOW()
@@ -654,6 +987,17 @@ func (q *Queries) DeleteImage(ctx context.Context, db DBTX, id pgtype.UUID) erro
 	return err
 }
 
+const deleteLLMCacheEntriesBySessionID = `-- name: DeleteLLMCacheEntriesBySessionID :exec
+DELETE FROM llmcache
+WHERE session_id = $1
+`
+
+// DeleteLLMCacheEntriesBySessionID: Deletes the cached model responses generated for a session.
+func (q *Queries) DeleteLLMCacheEntriesBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteLLMCacheEntriesBySessionID, sessionID)
+	return err
+}
+
 const deletePatientSession = `-- name: DeletePatientSession :exec
 DELETE from patientsession
 WHERE session_id = $1
@@ -688,6 +1032,17 @@ func (q *Queries) DeleteReport(ctx context.Context, db DBTX, id pgtype.UUID) err
 	return err
 }
 
+const deleteSessionSecrets = `-- name: DeleteSessionSecrets :exec
+DELETE FROM sessionsecret
+WHERE session_id = $1
+`
+
+// DeleteSessionSecrets: Deletes every secret of a session, making its pseudonyms unlinkable.
+func (q *Queries) DeleteSessionSecrets(ctx context.Context, db DBTX, sessionID pgtype.UUID) error {
+	_, err := db.Exec(ctx, deleteSessionSecrets, sessionID)
+	return err
+}
+
 const deleteStudy = `-- name: DeleteStudy :exec
 DELETE FROM studies
 WHERE id = $1
@@ -816,7 +1171,7 @@ func (q *Queries) GetAuditLogByID(ctx context.Context, db DBTX, logID int64) (*A
 }
 
 const getDiagnosisByID = `-- name: GetDiagnosisByID :one
-SELECT id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at FROM diagnosis
+SELECT id, result_id, session_id, diagnosis_text, confidence, justification, created_at, updated_at, prompt_version_id FROM diagnosis
 WHERE id = $1
 `
 
@@ -833,10 +1188,23 @@ func (q *Queries) GetDiagnosisByID(ctx context.Context, db DBTX, id pgtype.UUID)
 		&i.Justification,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromptVersionID,
 	)
 	return &i, err
 }
 
+const getDiagnosisPromptVersion = `-- name: GetDiagnosisPromptVersion :one
+SELECT prompt_version_id FROM diagnosis WHERE id = $1
+`
+
+// GetDiagnosisPromptVersion: Returns the prompt version a stored diagnosis was generated with.
+func (q *Queries) GetDiagnosisPromptVersion(ctx context.Context, db DBTX, id pgtype.UUID) (pgtype.UUID, error) {
+	row := db.QueryRow(ctx, getDiagnosisPromptVersion, id)
+	var prompt_version_id pgtype.UUID
+	err := row.Scan(&prompt_version_id)
+	return prompt_version_id, err
+}
+
 const getExternalResourceByID = `-- name: GetExternalResourceByID :one
 SELECT resource_id, name, url, description FROM externalresource
 WHERE resource_id = $1
@@ -855,6 +1223,46 @@ func (q *Queries) GetExternalResourceByID(ctx context.Context, db DBTX, resource
 	return &i, err
 }
 
+const getFindingsByPatientID = `-- name: GetFindingsByPatientID :many
+SELECT findings.finding_id, findings.file_id, findings.finding_type, findings.description, findings.image_coordinates, findings.source, findings.created_at, findings.updated_at, findings.prompt_version_id, findings.low_confidence
+FROM findings
+JOIN reports ON reports.id = findings.file_id
+WHERE reports.patient_id = $1
+ORDER BY findings.created_at
+`
+
+// GetFindingsByPatientID retrieves the findings of every report of a patient, oldest first.
+func (q *Queries) GetFindingsByPatientID(ctx context.Context, db DBTX, patientID pgtype.UUID) ([]*Finding, error) {
+	rows, err := db.Query(ctx, getFindingsByPatientID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Finding
+	for rows.Next() {
+		var i Finding
+		if err := rows.Scan(
+			&i.FindingID,
+			&i.FileID,
+			&i.FindingType,
+			&i.Description,
+			&i.ImageCoordinates,
+			&i.Source,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PromptVersionID,
+			&i.LowConfidence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getImageByID = `-- name: GetImageByID :one
 SELECT id, study_id, file_path, series_instance_uid, sop_instance_uid, image_type, content_data, created_at, updated_at FROM images
 WHERE id = $1
@@ -914,8 +1322,169 @@ func (q *Queries) GetImageByStudyID(ctx context.Context, db DBTX, studyID pgtype
 	return items, nil
 }
 
+const getLLMCacheEntry = `-- name: GetLLMCacheEntry :one
+
+SELECT cache_key, method, body, created_at, expires_at, session_id FROM llmcache
+WHERE cache_key = $1 AND expires_at > NOW()
+`
+
+// ------------- LLMCache Queries -------------
+// GetLLMCacheEntry: Retrieves the encrypted cached model response of a key, unless it has expired.
+func (q *Queries) GetLLMCacheEntry(ctx context.Context, db DBTX, cacheKey string) (*Llmcache, error) {
+	row := db.QueryRow(ctx, getLLMCacheEntry, cacheKey)
+	var i Llmcache
+	err := row.Scan(
+		&i.CacheKey,
+		&i.Method,
+		&i.Body,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.SessionID,
+	)
+	return &i, err
+}
+
+const getLLMTokensSince = `-- name: GetLLMTokensSince :one
+SELECT COALESCE(SUM(prompt_tokens + output_tokens), 0)::bigint AS tokens
+FROM llmusage
+WHERE created_at >= $1
+`
+
+// GetLLMTokensSince: Sums the prompt and output tokens of every model call made since a time.
+func (q *Queries) GetLLMTokensSince(ctx context.Context, db DBTX, createdAt pgtype.Timestamptz) (int64, error) {
+	row := db.QueryRow(ctx, getLLMTokensSince, createdAt)
+	var tokens int64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const getLLMUsageSummary = `-- name: GetLLMUsageSummary :many
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+    method,
+    prompt_name,
+    prompt_version,
+    COUNT(*)::integer AS calls,
+    (COUNT(*) FILTER (WHERE outcome <> 'success'))::integer AS failures,
+    SUM(prompt_tokens)::bigint AS prompt_tokens,
+    SUM(output_tokens)::bigint AS output_tokens,
+    AVG(latency_ms)::float8 AS average_latency_ms,
+    (percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms))::float8 AS p95_latency_ms
+FROM llmusage
+WHERE created_at >= $1
+AND created_at < $2
+GROUP BY day, method, prompt_name, prompt_version
+ORDER BY day, method, prompt_name, prompt_version
+`
+
+type GetLLMUsageSummaryParams struct {
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetLLMUsageSummaryRow struct {
+	Day              pgtype.Date `json:"day"`
+	Method           string      `json:"method"`
+	PromptName       string      `json:"prompt_name"`
+	PromptVersion    int32       `json:"prompt_version"`
+	Calls            int32       `json:"calls"`
+	Failures         int32       `json:"failures"`
+	PromptTokens     int64       `json:"prompt_tokens"`
+	OutputTokens     int64       `json:"output_tokens"`
+	AverageLatencyMs float64     `json:"average_latency_ms"`
+	P95LatencyMs     float64     `json:"p95_latency_ms"`
+}
+
+// GetLLMUsageSummary: Aggregates model calls per UTC day, method and prompt version between two times.
+func (q *Queries) GetLLMUsageSummary(ctx context.Context, db DBTX, arg *GetLLMUsageSummaryParams) ([]*GetLLMUsageSummaryRow, error) {
+	rows, err := db.Query(ctx, getLLMUsageSummary, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetLLMUsageSummaryRow
+	for rows.Next() {
+		var i GetLLMUsageSummaryRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Method,
+			&i.PromptName,
+			&i.PromptVersion,
+			&i.Calls,
+			&i.Failures,
+			&i.PromptTokens,
+			&i.OutputTokens,
+			&i.AverageLatencyMs,
+			&i.P95LatencyMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLabObservationsBySessionID = `-- name: GetLabObservationsBySessionID :many
+SELECT observation_id, session_id, source, analyte, code, value, numeric_value, unit, reference_range, reference_low, reference_high, flag, observed_at, created_at
+FROM labobservations
+WHERE session_id = $1
+ORDER BY analyte, observed_at NULLS LAST, created_at
+`
+
+// GetLabObservationsBySessionID: Retrieves the lab results of a session by analyte, oldest first.
+func (q *Queries) GetLabObservationsBySessionID(ctx context.Context, db DBTX, sessionID pgtype.UUID) ([]*Labobservation, error) {
+	rows, err := db.Query(ctx, getLabObservationsBySessionID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Labobservation
+	for rows.Next() {
+		var i Labobservation
+		if err := rows.Scan(
+			&i.ObservationID,
+			&i.SessionID,
+			&i.Source,
+			&i.Analyte,
+			&i.Code,
+			&i.Value,
+			&i.NumericValue,
+			&i.Unit,
+			&i.ReferenceRange,
+			&i.ReferenceLow,
+			&i.ReferenceHigh,
+			&i.Flag,
+			&i.ObservedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNextPromptVersion = `-- name: GetNextPromptVersion :one
+SELECT (COALESCE(MAX(CASE WHEN version ~ '^[0-9]+$' THEN version::integer ELSE 0 END), 0) + 1)::integer AS next_version
+FROM prompts
+WHERE description = $1
+`
+
+// GetNextPromptVersion: Returns the version number following the latest numeric version of a prompt (1 for a new prompt).
+func (q *Queries) GetNextPromptVersion(ctx context.Context, db DBTX, description pgtype.Text) (int32, error) {
+	row := db.QueryRow(ctx, getNextPromptVersion, description)
+	var next_version int32
+	err := row.Scan(&next_version)
+	return next_version, err
+}
+
 const getNoduleByID = `-- name: GetNoduleByID :one
-SELECT finding_id, file_id, description, image_coordinates, source FROM findings WHERE finding_id = $1
+SELECT finding_id, file_id, description, image_coordinates, source, prompt_version_id FROM findings WHERE finding_id = $1
 `
 
 type GetNoduleByIDRow struct {
@@ -924,6 +1493,7 @@ type GetNoduleByIDRow struct {
 	Description      string      `json:"description"`
 	ImageCoordinates []float64   `json:"image_coordinates"`
 	Source           string      `json:"source"`
+	PromptVersionID  pgtype.UUID `json:"prompt_version_id"`
 }
 
 // GetNoduleByID retrieves a nodule by its ID.
@@ -936,10 +1506,23 @@ func (q *Queries) GetNoduleByID(ctx context.Context, db DBTX, findingID pgtype.U
 		&i.Description,
 		&i.ImageCoordinates,
 		&i.Source,
+		&i.PromptVersionID,
 	)
 	return &i, err
 }
 
+const getNodulePromptVersion = `-- name: GetNodulePromptVersion :one
+SELECT prompt_version_id FROM findings WHERE finding_id = $1 AND finding_type = 'nodule'
+`
+
+// GetNodulePromptVersion: Returns the prompt version a stored nodule was detected with.
+func (q *Queries) GetNodulePromptVersion(ctx context.Context, db DBTX, findingID pgtype.UUID) (pgtype.UUID, error) {
+	row := db.QueryRow(ctx, getNodulePromptVersion, findingID)
+	var prompt_version_id pgtype.UUID
+	err := row.Scan(&prompt_version_id)
+	return prompt_version_id, err
+}
+
 const getPatientSessionByLink = `-- name: GetPatientSessionByLink :one
 SELECT session_id, access_link, expiration_timestamp, used, patient_data, created_at, updated_at FROM patientsession
 WHERE access_link = $1
@@ -987,6 +1570,134 @@ func (q *Queries) GetPromptByID(ctx context.Context, db DBTX, promptID pgtype.UU
 	return &i, err
 }
 
+const getPromptCallStats = `-- name: GetPromptCallStats :many
+SELECT prompt_version_id,
+    COUNT(*)::integer AS calls,
+    (COUNT(*) FILTER (WHERE outcome = 'parse_failure'))::integer AS parse_failures,
+    (COUNT(*) FILTER (WHERE outcome = 'error'))::integer AS errors
+FROM promptcall
+WHERE prompt_name = $1
+AND created_at >= $2
+AND created_at < $3
+AND ($4::uuid IS NULL OR experiment_id = $4::uuid)
+GROUP BY prompt_version_id
+`
+
+type GetPromptCallStatsParams struct {
+	PromptName   string             `json:"prompt_name"`
+	CreatedFrom  pgtype.Timestamptz `json:"created_from"`
+	CreatedTo    pgtype.Timestamptz `json:"created_to"`
+	ExperimentID pgtype.UUID        `json:"experiment_id"`
+}
+
+type GetPromptCallStatsRow struct {
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
+	Calls           int32       `json:"calls"`
+	ParseFailures   int32       `json:"parse_failures"`
+	Errors          int32       `json:"errors"`
+}
+
+// GetPromptCallStats: Counts the calls of a prompt per version and outcome between two times, optionally within one experiment.
+func (q *Queries) GetPromptCallStats(ctx context.Context, db DBTX, arg *GetPromptCallStatsParams) ([]*GetPromptCallStatsRow, error) {
+	rows, err := db.Query(ctx, getPromptCallStats,
+		arg.PromptName,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.ExperimentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetPromptCallStatsRow
+	for rows.Next() {
+		var i GetPromptCallStatsRow
+		if err := rows.Scan(
+			&i.PromptVersionID,
+			&i.Calls,
+			&i.ParseFailures,
+			&i.Errors,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromptExperimentByID = `-- name: GetPromptExperimentByID :one
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE experiment_id = $1
+`
+
+// GetPromptExperimentByID: Retrieves an experiment by its ID.
+func (q *Queries) GetPromptExperimentByID(ctx context.Context, db DBTX, experimentID pgtype.UUID) (*Promptexperiment, error) {
+	row := db.QueryRow(ctx, getPromptExperimentByID, experimentID)
+	var i Promptexperiment
+	err := row.Scan(
+		&i.ExperimentID,
+		&i.PromptName,
+		&i.ControlPromptID,
+		&i.TreatmentPromptID,
+		&i.TreatmentPercent,
+		&i.Status,
+		&i.StartedBy,
+		&i.StoppedBy,
+		&i.StopReason,
+		&i.StartedAt,
+		&i.StoppedAt,
+	)
+	return &i, err
+}
+
+const getPromptFeedbackStats = `-- name: GetPromptFeedbackStats :many
+SELECT prompt_version_id,
+    COUNT(*)::integer AS ratings,
+    AVG(rating)::float8 AS average_rating
+FROM promptfeedback
+WHERE prompt_name = $1
+AND created_at >= $2
+AND created_at < $3
+GROUP BY prompt_version_id
+`
+
+type GetPromptFeedbackStatsParams struct {
+	PromptName  string             `json:"prompt_name"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+type GetPromptFeedbackStatsRow struct {
+	PromptVersionID pgtype.UUID `json:"prompt_version_id"`
+	Ratings         int32       `json:"ratings"`
+	AverageRating   float64     `json:"average_rating"`
+}
+
+// GetPromptFeedbackStats: Aggregates the ratings of a prompt's outputs per version, for feedback given between two times.
+func (q *Queries) GetPromptFeedbackStats(ctx context.Context, db DBTX, arg *GetPromptFeedbackStatsParams) ([]*GetPromptFeedbackStatsRow, error) {
+	rows, err := db.Query(ctx, getPromptFeedbackStats, arg.PromptName, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetPromptFeedbackStatsRow
+	for rows.Next() {
+		var i GetPromptFeedbackStatsRow
+		if err := rows.Scan(&i.PromptVersionID, &i.Ratings, &i.AverageRating); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getReportByID = `-- name: GetReportByID :one
 SELECT id, patient_id, filename, report_type, report_text, filepath, created_at, updated_at
 FROM reports
@@ -1046,8 +1757,72 @@ func (q *Queries) GetReportByPatientID(ctx context.Context, db DBTX, patientID p
 	return items, nil
 }
 
+const getRunningPromptExperiment = `-- name: GetRunningPromptExperiment :one
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE prompt_name = $1
+AND status = 'running'
+`
+
+// GetRunningPromptExperiment: Retrieves the running experiment of a prompt, if any.
+func (q *Queries) GetRunningPromptExperiment(ctx context.Context, db DBTX, promptName string) (*Promptexperiment, error) {
+	row := db.QueryRow(ctx, getRunningPromptExperiment, promptName)
+	var i Promptexperiment
+	err := row.Scan(
+		&i.ExperimentID,
+		&i.PromptName,
+		&i.ControlPromptID,
+		&i.TreatmentPromptID,
+		&i.TreatmentPercent,
+		&i.Status,
+		&i.StartedBy,
+		&i.StoppedBy,
+		&i.StopReason,
+		&i.StartedAt,
+		&i.StoppedAt,
+	)
+	return &i, err
+}
+
+const getSessionLLMTokens = `-- name: GetSessionLLMTokens :one
+SELECT COALESCE(SUM(prompt_tokens + output_tokens), 0)::bigint AS tokens
+FROM llmusage
+WHERE session_id = $1
+`
+
+// GetSessionLLMTokens: Sums the prompt and output tokens of every model call made for a session.
+func (q *Queries) GetSessionLLMTokens(ctx context.Context, db DBTX, sessionID pgtype.UUID) (int64, error) {
+	row := db.QueryRow(ctx, getSessionLLMTokens, sessionID)
+	var tokens int64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const getSessionSecret = `-- name: GetSessionSecret :one
+SELECT session_id, purpose, encrypted_secret, created_at FROM sessionsecret
+WHERE session_id = $1 AND purpose = $2
+`
+
+type GetSessionSecretParams struct {
+	SessionID pgtype.UUID `json:"session_id"`
+	Purpose   string      `json:"purpose"`
+}
+
+// GetSessionSecret: Retrieves the encrypted secret of a session for one purpose.
+func (q *Queries) GetSessionSecret(ctx context.Context, db DBTX, arg *GetSessionSecretParams) (*Sessionsecret, error) {
+	row := db.QueryRow(ctx, getSessionSecret, arg.SessionID, arg.Purpose)
+	var i Sessionsecret
+	err := row.Scan(
+		&i.SessionID,
+		&i.Purpose,
+		&i.EncryptedSecret,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
 const getStageByID = `-- name: GetStageByID :one
-SELECT id, result_id, session_id, t, n, m, confidence, created_at, updated_at
+SELECT id, result_id, session_id, t, n, m, confidence, created_at, updated_at, prompt_version_id
 FROM stages
 WHERE id = $1
 `
@@ -1066,10 +1841,23 @@ func (q *Queries) GetStageByID(ctx context.Context, db DBTX, id pgtype.UUID) (*S
 		&i.Confidence,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PromptVersionID,
 	)
 	return &i, err
 }
 
+const getStagePromptVersion = `-- name: GetStagePromptVersion :one
+SELECT prompt_version_id FROM stages WHERE id = $1
+`
+
+// GetStagePromptVersion: Returns the prompt version a stored staging record was generated with.
+func (q *Queries) GetStagePromptVersion(ctx context.Context, db DBTX, id pgtype.UUID) (pgtype.UUID, error) {
+	row := db.QueryRow(ctx, getStagePromptVersion, id)
+	var prompt_version_id pgtype.UUID
+	err := row.Scan(&prompt_version_id)
+	return prompt_version_id, err
+}
+
 const getStudyByID = `-- name: GetStudyByID :one
 SELECT id, patient_id, study_instance_uid, study_data, created_at, updated_at FROM studies
 WHERE id = $1
@@ -1403,6 +2191,123 @@ func (q *Queries) ListImagesByPatientID(ctx context.Context, db DBTX, patientID
 	return items, nil
 }
 
+const listPromptEvents = `-- name: ListPromptEvents :many
+SELECT event_id, prompt_id, action, actor, from_status, to_status, comment, created_at
+FROM promptevent
+WHERE prompt_id = $1
+ORDER BY created_at, event_id
+`
+
+// ListPromptEvents: Retrieves the lifecycle history of a prompt version, oldest first.
+func (q *Queries) ListPromptEvents(ctx context.Context, db DBTX, promptID pgtype.UUID) ([]*Promptevent, error) {
+	rows, err := db.Query(ctx, listPromptEvents, promptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Promptevent
+	for rows.Next() {
+		var i Promptevent
+		if err := rows.Scan(
+			&i.EventID,
+			&i.PromptID,
+			&i.Action,
+			&i.Actor,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Comment,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPromptExperiments = `-- name: ListPromptExperiments :many
+SELECT experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+FROM promptexperiment
+WHERE prompt_name = $1
+ORDER BY started_at DESC
+`
+
+// ListPromptExperiments: Retrieves every experiment of a prompt, newest first.
+func (q *Queries) ListPromptExperiments(ctx context.Context, db DBTX, promptName string) ([]*Promptexperiment, error) {
+	rows, err := db.Query(ctx, listPromptExperiments, promptName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Promptexperiment
+	for rows.Next() {
+		var i Promptexperiment
+		if err := rows.Scan(
+			&i.ExperimentID,
+			&i.PromptName,
+			&i.ControlPromptID,
+			&i.TreatmentPromptID,
+			&i.TreatmentPercent,
+			&i.Status,
+			&i.StartedBy,
+			&i.StoppedBy,
+			&i.StopReason,
+			&i.StartedAt,
+			&i.StoppedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPromptVersions = `-- name: ListPromptVersions :many
+SELECT prompt_id, description, template, input_variables, output_format, version, author, status, approval_status, created_at, updated_at
+FROM prompts
+WHERE description = $1
+ORDER BY CASE WHEN version ~ '^[0-9]+$' THEN version::integer ELSE 0 END DESC, created_at DESC
+`
+
+// ListPromptVersions: Retrieves every version of a prompt, newest first (non-numeric versions last).
+func (q *Queries) ListPromptVersions(ctx context.Context, db DBTX, description pgtype.Text) ([]*Prompt, error) {
+	rows, err := db.Query(ctx, listPromptVersions, description)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Prompt
+	for rows.Next() {
+		var i Prompt
+		if err := rows.Scan(
+			&i.PromptID,
+			&i.Description,
+			&i.Template,
+			&i.InputVariables,
+			&i.OutputFormat,
+			&i.Version,
+			&i.Author,
+			&i.Status,
+			&i.ApprovalStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPrompts = `-- name: ListPrompts :many
 SELECT prompt_id, description, template, input_variables, output_format, version, author, status, approval_status, created_at, updated_at
 FROM prompts
@@ -1513,6 +2418,40 @@ func (q *Queries) ListUploadedContentBySessionID(ctx context.Context, db DBTX, s
 	return items, nil
 }
 
+const stopPromptExperiment = `-- name: StopPromptExperiment :one
+UPDATE promptexperiment
+SET status = 'stopped', stopped_by = $2, stop_reason = $3, stopped_at = now()
+WHERE experiment_id = $1
+AND status = 'running'
+RETURNING experiment_id, prompt_name, control_prompt_id, treatment_prompt_id, treatment_percent, status, started_by, stopped_by, stop_reason, started_at, stopped_at
+`
+
+type StopPromptExperimentParams struct {
+	ExperimentID pgtype.UUID `json:"experiment_id"`
+	StoppedBy    pgtype.Text `json:"stopped_by"`
+	StopReason   pgtype.Text `json:"stop_reason"`
+}
+
+// StopPromptExperiment: Stops a running experiment, returning it (no rows if it was not running).
+func (q *Queries) StopPromptExperiment(ctx context.Context, db DBTX, arg *StopPromptExperimentParams) (*Promptexperiment, error) {
+	row := db.QueryRow(ctx, stopPromptExperiment, arg.ExperimentID, arg.StoppedBy, arg.StopReason)
+	var i Promptexperiment
+	err := row.Scan(
+		&i.ExperimentID,
+		&i.PromptName,
+		&i.ControlPromptID,
+		&i.TreatmentPromptID,
+		&i.TreatmentPercent,
+		&i.Status,
+		&i.StartedBy,
+		&i.StoppedBy,
+		&i.StopReason,
+		&i.StartedAt,
+		&i.StoppedAt,
+	)
+	return &i, err
+}
+
 const updateExternalResource = `-- name: UpdateExternalResource :one
 UPDATE externalresource
 SET name = $2, url = $3, description = $4
@@ -1612,3 +2551,29 @@ func (q *Queries) UpdatePrompt(ctx context.Context, db DBTX, arg *UpdatePromptPa
 	)
 	return &i, err
 }
+
+const upsertLLMCacheEntry = `-- name: UpsertLLMCacheEntry :exec
+INSERT INTO llmcache (cache_key, method, body, expires_at, session_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (cache_key) DO UPDATE SET method = EXCLUDED.method, body = EXCLUDED.body, created_at = NOW(), expires_at = EXCLUDED.expires_at, session_id = EXCLUDED.session_id
+`
+
+type UpsertLLMCacheEntryParams struct {
+	CacheKey  string             `json:"cache_key"`
+	Method    string             `json:"method"`
+	Body      []byte             `json:"body"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	SessionID pgtype.UUID        `json:"session_id"`
+}
+
+// UpsertLLMCacheEntry: Stores an encrypted model response, replacing an earlier one for the same key.
+func (q *Queries) UpsertLLMCacheEntry(ctx context.Context, db DBTX, arg *UpsertLLMCacheEntryParams) error {
+	_, err := db.Exec(ctx, upsertLLMCacheEntry,
+		arg.CacheKey,
+		arg.Method,
+		arg.Body,
+		arg.ExpiresAt,
+		arg.SessionID,
+	)
+	return err
+}
@@ -152,6 +152,31 @@ func (e *DataAccessError) SetLogger(logger *zap.Logger) {
 	e.logger = logger
 }
 
+// InvalidTransitionError is returned when an action is not allowed in a resource's current lifecycle state
+// (e.g., approving a prompt version that was never submitted for review).
+type InvalidTransitionError struct {
+	Resource string
+	State    string // Current state of the resource
+	Action   string // Rejected action
+	logger   *zap.Logger
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if e.logger != nil {
+		e.logger.Debug("invalid transition error", zap.String("resource", e.Resource), zap.String("state", e.State), zap.String("action", e.Action))
+	}
+	return fmt.Sprintf("cannot %s %s in state %s", e.Action, e.Resource, e.State)
+}
+
+// NewInvalidTransitionError creates a new InvalidTransitionError.
+func NewInvalidTransitionError(resource, state, action string) *InvalidTransitionError {
+	return &InvalidTransitionError{Resource: resource, State: state, Action: action, logger: nil}
+}
+
+func (e *InvalidTransitionError) SetLogger(logger *zap.Logger) {
+	e.logger = logger
+}
+
 // ErrDICOMParsingFailed represents an error during DICOM file parsing.  // ADDED: DICOM Parsing Error
 type ErrDICOMParsingFailed struct {
 	Filename string
@@ -0,0 +1,338 @@
+// internal/domain/services/archive.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/utils"
+	"github.com/stackvity/lung-server/pkg/dicom"
+	"github.com/stackvity/lung-server/pkg/hl7"
+	"go.uber.org/zap"
+)
+
+// Zip-bomb limits applied to uploaded archives (BE-012). An archive breaking any of them is rejected as a whole
+// before any entry is processed, except for the uncompressed size which is also enforced while reading.
+const (
+	maxArchiveSize             int64 = 1024 * 1024 * 1024     // Compressed size of the uploaded archive (1 GiB)
+	maxArchiveEntries                = 5000                   // Files in the archive (a chest CT CD holds a few hundred to a few thousand)
+	maxArchiveUncompressedSize int64 = 4 * 1024 * 1024 * 1024 // Sum of all entries once decompressed (4 GiB)
+	maxArchiveCompressionRatio       = 100                    // Uncompressed/compressed ratio allowed for a single entry
+	maxDICOMDIRSize            int64 = 32 * 1024 * 1024       // DICOMDIR files grow with the number of referenced instances
+	archiveRatioCheckThreshold int64 = 1024 * 1024            // Entries smaller than this are not ratio-checked (tiny files compress very well)
+)
+
+// Archive entry outcomes reported in ArchiveEntryResult.Status.
+const (
+	ArchiveEntryProcessed = "processed"
+	ArchiveEntrySkipped   = "skipped"
+	ArchiveEntryFailed    = "failed"
+)
+
+// ArchiveEntryResult reports what happened to one file of an uploaded archive.
+type ArchiveEntryResult struct {
+	Name        string `json:"name"`                   // Path inside the archive
+	ContentType string `json:"content_type,omitempty"` // Detected content type ("" when skipped as unsupported)
+	Status      string `json:"status"`                 // processed, skipped or failed
+	Reason      string `json:"reason,omitempty"`       // Why the entry was skipped or failed; never contains file contents
+}
+
+// ArchiveResult summarizes the ingestion of an uploaded archive.
+type ArchiveResult struct {
+	UsedDICOMDIR bool                 `json:"used_dicomdir"` // True when entries were selected from a DICOMDIR index
+	Processed    int                  `json:"processed"`
+	Skipped      int                  `json:"skipped"`
+	Failed       int                  `json:"failed"`
+	Entries      []ArchiveEntryResult `json:"entries"`
+	Series       []SeriesAnalysis     `json:"series,omitempty"` // Series analysis run once all DICOM entries were ingested
+}
+
+// IsArchiveUpload reports whether an upload should be handled as a ZIP archive, based on its declared
+// content type or, for clients that send application/octet-stream, its file extension.
+func IsArchiveUpload(filename string, contentType string) bool {
+	switch contentType {
+	case "application/zip", "application/x-zip-compressed", "application/x-zip":
+		return true
+	}
+	return strings.EqualFold(path.Ext(filename), ".zip")
+}
+
+// ProcessArchive ingests a ZIP upload, typically the contents of a patient's imaging CD. If the archive holds a
+// DICOMDIR, the files it references are processed; otherwise every entry is sniffed (DICOM magic number, then
+// net/http content detection) and supported documents are processed. Each entry goes through ProcessDocument,
+// so validation, storage, anonymization and secure deletion are identical to single-file uploads. Series analysis
+// runs once at the end, so nodule detection sees whole series.
+//
+// Failures of individual entries are recorded in the result and do not abort the archive; violations of the
+// zip-bomb limits do, with a *utils.ErrArchiveLimitExceeded.
+func (s *ProcessingService) ProcessArchive(ctx context.Context, patientID uuid.UUID, filename string, archive io.ReaderAt, size int64) (*ArchiveResult, error) {
+	const operation = "ProcessArchive"
+	requestID := utils.GetRequestID(ctx)
+
+	s.logger.Info("Processing archive",
+		zap.String("operation", operation),
+		zap.String("request_id", requestID),
+		zap.String("patient_id", patientID.String()),
+		zap.String("filename", filename),
+		zap.Int64("size", size),
+	)
+
+	if size > maxArchiveSize {
+		return nil, utils.NewErrFileSizeExceeded(filename, size, maxArchiveSize)
+	}
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		s.logger.Warn("Invalid ZIP archive", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return nil, fmt.Errorf("opening archive: %w", err)
+	}
+
+	entries, err := s.checkArchiveEntries(filename, reader.File)
+	if err != nil {
+		s.logger.Warn("Archive rejected", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return nil, err
+	}
+
+	result := &ArchiveResult{}
+	budget := maxArchiveUncompressedSize // Remaining uncompressed bytes; headers can lie, so the budget is charged with bytes actually read
+
+	// 1. Select entries: the DICOMDIR index when present and readable, every entry otherwise
+	selected, usedDICOMDIR := s.selectFromDICOMDIR(ctx, entries, &budget)
+	if !usedDICOMDIR {
+		selected = entries
+	}
+	result.UsedDICOMDIR = usedDICOMDIR
+
+	// 2. Feed each entry to ProcessDocument
+	for _, entry := range selected {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		entryResult := ArchiveEntryResult{Name: entry.Name}
+		if strings.EqualFold(path.Base(entry.Name), "DICOMDIR") {
+			entryResult.Status, entryResult.Reason = ArchiveEntrySkipped, "DICOMDIR index"
+			result.add(entryResult)
+			continue
+		}
+		data, err := readArchiveEntry(entry, &budget)
+		if err != nil {
+			if errors.Is(err, errArchiveBudgetExceeded) {
+				limitErr := utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("uncompressed size exceeds %d bytes", maxArchiveUncompressedSize))
+				s.logger.Warn("Archive rejected", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(limitErr))
+				return result, limitErr
+			}
+			entryResult.Status, entryResult.Reason = ArchiveEntryFailed, "could not read entry"
+			s.logger.Warn("Failed to read archive entry", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("entry", entry.Name), zap.Error(err))
+			result.add(entryResult)
+			continue
+		}
+
+		contentType := sniffArchiveEntry(data, usedDICOMDIR)
+		if contentType == "" {
+			entryResult.Status, entryResult.Reason = ArchiveEntrySkipped, "unsupported file type"
+			result.add(entryResult)
+			continue
+		}
+		entryResult.ContentType = contentType
+
+		if err := s.ProcessDocument(ctx, patientID, entry.Name, contentType, bytes.NewReader(data)); err != nil {
+			entryResult.Status, entryResult.Reason = ArchiveEntryFailed, "processing failed"
+			s.logger.Warn("Failed to process archive entry", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("entry", entry.Name), zap.String("content_type", contentType), zap.Error(err))
+		} else {
+			entryResult.Status = ArchiveEntryProcessed
+		}
+		result.add(entryResult)
+	}
+
+	// 3. Series-level analysis over every DICOM instance of the archive - BE-030
+	series, err := s.AnalyzeSeries(ctx, patientID)
+	result.Series = series
+	if err != nil {
+		s.logger.Error("Series analysis after archive ingestion failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return result, fmt.Errorf("analyzing series: %w", err)
+	}
+
+	s.logger.Info("Archive processed",
+		zap.String("operation", operation),
+		zap.String("request_id", requestID),
+		zap.String("filename", filename),
+		zap.Bool("used_dicomdir", result.UsedDICOMDIR),
+		zap.Int("processed", result.Processed),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+		zap.Int("series", len(result.Series)),
+	)
+	return result, nil
+}
+
+// add records an entry result and updates the counters.
+func (r *ArchiveResult) add(entry ArchiveEntryResult) {
+	switch entry.Status {
+	case ArchiveEntryProcessed:
+		r.Processed++
+	case ArchiveEntrySkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// checkArchiveEntries enforces the entry count, declared size, compression ratio and path limits, and returns
+// the regular files of the archive sorted by name. Directory entries and OS metadata (__MACOSX, dot files) are dropped.
+func (s *ProcessingService) checkArchiveEntries(filename string, files []*zip.File) ([]*zip.File, error) {
+	if len(files) > maxArchiveEntries {
+		return nil, utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("%d entries, limit is %d", len(files), maxArchiveEntries))
+	}
+
+	var declared uint64
+	entries := make([]*zip.File, 0, len(files))
+	for _, f := range files {
+		// Path traversal: names must be relative, slash-separated and free of ".." (Zip Slip)
+		if name := strings.TrimSuffix(f.Name, "/"); !fs.ValidPath(name) || name == "." || strings.Contains(name, `\`) {
+			return nil, utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("entry %q has an unsafe path", f.Name))
+		}
+		if f.FileInfo().IsDir() || isArchiveMetadata(f.Name) {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return nil, utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("entry %q is not a regular file", f.Name))
+		}
+
+		declared += f.UncompressedSize64
+		if declared > uint64(maxArchiveUncompressedSize) {
+			return nil, utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("uncompressed size exceeds %d bytes", maxArchiveUncompressedSize))
+		}
+		if f.UncompressedSize64 > uint64(archiveRatioCheckThreshold) && f.UncompressedSize64 > f.CompressedSize64*maxArchiveCompressionRatio {
+			return nil, utils.NewErrArchiveLimitExceeded(filename, fmt.Sprintf("entry %q has a compression ratio above %d", f.Name, maxArchiveCompressionRatio))
+		}
+		entries = append(entries, f)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// selectFromDICOMDIR looks for a DICOMDIR in the archive and returns the entries it references, matched
+// case-insensitively (CD file systems are upper case but archivers may change case). It returns false when
+// there is no usable DICOMDIR, in which case the caller falls back to sniffing every entry.
+func (s *ProcessingService) selectFromDICOMDIR(ctx context.Context, entries []*zip.File, budget *int64) ([]*zip.File, bool) {
+	const operation = "selectFromDICOMDIR"
+	requestID := utils.GetRequestID(ctx)
+
+	var dicomdir *zip.File
+	for _, entry := range entries {
+		if strings.EqualFold(path.Base(entry.Name), "DICOMDIR") && (dicomdir == nil || len(entry.Name) < len(dicomdir.Name)) {
+			dicomdir = entry // Prefer the shallowest DICOMDIR
+		}
+	}
+	if dicomdir == nil {
+		return nil, false
+	}
+
+	data, err := readArchiveEntry(dicomdir, budget)
+	if err == nil && int64(len(data)) > maxDICOMDIRSize {
+		err = fmt.Errorf("DICOMDIR exceeds %d bytes", maxDICOMDIRSize)
+	}
+	var ds *dicom.DataSet
+	if err == nil {
+		ds, err = dicom.Parse(bytes.NewReader(data), maxDICOMDIRSize)
+	}
+	if err == nil && !ds.IsDirectory() {
+		err = errors.New("not a Media Storage Directory")
+	}
+	if err != nil {
+		s.logger.Warn("Ignoring unreadable DICOMDIR; sniffing every entry instead", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("entry", dicomdir.Name), zap.Error(err))
+		return nil, false
+	}
+
+	byName := make(map[string]*zip.File, len(entries))
+	for _, entry := range entries {
+		byName[strings.ToUpper(entry.Name)] = entry
+	}
+	root := path.Dir(dicomdir.Name)
+
+	var selected []*zip.File
+	seen := make(map[*zip.File]bool)
+	for _, record := range ds.DirectoryRecords() {
+		entry, ok := byName[strings.ToUpper(path.Join(root, record.FileID))]
+		if !ok {
+			s.logger.Warn("DICOMDIR references a file missing from the archive", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("file_id", record.FileID))
+			continue
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			selected = append(selected, entry)
+		}
+	}
+	if len(selected) == 0 {
+		s.logger.Warn("DICOMDIR references no files in the archive; sniffing every entry instead", zap.String("operation", operation), zap.String("request_id", requestID))
+		return nil, false
+	}
+	return selected, true
+}
+
+// errArchiveBudgetExceeded signals that an archive expanded beyond maxArchiveUncompressedSize while being read.
+var errArchiveBudgetExceeded = errors.New("archive uncompressed size budget exceeded")
+
+// readArchiveEntry decompresses one entry, charging the bytes actually produced to the remaining budget.
+// Entries larger than maxDocumentSize are truncated to maxDocumentSize+1 bytes so ProcessDocument rejects them.
+func readArchiveEntry(entry *zip.File, budget *int64) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	limit := min(maxDocumentSize+1, *budget+1)
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return nil, err
+	}
+	*budget -= int64(len(data))
+	if *budget < 0 {
+		return nil, errArchiveBudgetExceeded
+	}
+	return data, nil
+}
+
+// sniffArchiveEntry returns the content type ProcessDocument should use for an entry, or "" if the entry is not
+// a supported document. DICOM is recognized by its magic number; files referenced by a DICOMDIR are always DICOM.
+// HL7 messages are recognized by their MSH segment.
+func sniffArchiveEntry(data []byte, fromDICOMDIR bool) string {
+	if len(data) >= 132 && string(data[128:132]) == "DICM" {
+		return "application/dicom"
+	}
+	if fromDICOMDIR {
+		return "application/dicom" // Let DICOM validation report the problem
+	}
+	if hl7.IsMessage(data) {
+		return hl7ContentType
+	}
+	detected := http.DetectContentType(data)
+	for _, supported := range []string{"application/pdf", "image/jpeg", "image/png"} {
+		if strings.HasPrefix(detected, supported) {
+			return supported
+		}
+	}
+	return ""
+}
+
+// isArchiveMetadata reports whether an entry is OS metadata rather than patient content.
+func isArchiveMetadata(name string) bool {
+	if strings.HasPrefix(name, "__MACOSX/") {
+		return true
+	}
+	base := path.Base(name)
+	return strings.HasPrefix(base, ".") || strings.EqualFold(base, "Thumbs.db") || strings.EqualFold(base, "desktop.ini")
+}
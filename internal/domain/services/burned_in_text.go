@@ -0,0 +1,251 @@
+// internal/domain/services/burned_in_text.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/ocr"
+	"github.com/stackvity/lung-server/internal/security"
+	"github.com/stackvity/lung-server/internal/utils"
+	"github.com/stackvity/lung-server/pkg/dicom"
+	"go.uber.org/zap"
+)
+
+// AuditActionBurnedInTextRedacted is the audit log action recorded for every region blacked out by redactBurnedInText.
+const AuditActionBurnedInTextRedacted = "burned_in_text_redacted"
+
+// Limits of the burned-in text search.
+const (
+	burnedInMinRegionSide        = 24 // Regions are not split below this many pixels per side; OCR is unreliable on smaller crops
+	burnedInMaxOCRCalls          = 16 // OCR calls per image; once spent, any region still matching is blacked out whole
+	burnedInMaxOCRCallsPerSeries = 64 // OCR calls for all the rendered slices of a series together
+)
+
+// burnedInSOPClassPrefixes are the SOP classes of captures and photographs, whose pixels often carry the patient's
+// identifiers: secondary captures (including the multi-frame ones) and visible-light images.
+var burnedInSOPClassPrefixes = []string{"1.2.840.10008.5.1.4.1.1.7", "1.2.840.10008.5.1.4.1.1.77.1"}
+
+// TextRedaction describes one blacked-out region. It carries no text: only why and where.
+type TextRedaction struct {
+	Category security.PHICategory
+	Bounds   image.Rectangle
+}
+
+// redactableImage is a drawable image whose regions can be cropped for OCR (*image.Gray and *image.RGBA).
+type redactableImage interface {
+	draw.Image
+	SubImage(r image.Rectangle) image.Image
+}
+
+// burnedInTextRedactor locates PHI burned into an image's pixels using only ocr.OCRService.ExtractText,
+// which returns text without positions. It OCRs the whole image first; if the text matches a PHI pattern
+// it bisects the region and recurses into the halves, so clean images cost a single call and text is pinned
+// down to small rectangles. Found regions are painted black on the working copy as they are found; a region
+// that still matches after its halves and its central band have been searched, or whose search ran out of OCR
+// calls, is blacked out whole.
+type burnedInTextRedactor struct {
+	ctx        context.Context
+	ocrService ocr.OCRService
+	matcher    *security.PHIMatcher
+	img        redactableImage // Working copy; redactions are painted into it
+	calls      int
+	budget     *int // OCR calls left for every image sharing the budget
+}
+
+// redactBurnedInText returns a copy of img with every region whose OCR text matches the matcher blacked out,
+// and the list of redacted regions. The input image is not modified. OCR errors are returned: callers must
+// not pass on an image whose check failed. Every OCR call is taken from budget; the caller checks that some are left.
+func redactBurnedInText(ctx context.Context, ocrService ocr.OCRService, matcher *security.PHIMatcher, img image.Image, budget *int) (image.Image, []TextRedaction, error) {
+	r := &burnedInTextRedactor{ctx: ctx, ocrService: ocrService, matcher: matcher, img: cloneImage(img), budget: budget}
+	redactions, err := r.locate(r.img.Bounds())
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.img, redactions, nil
+}
+
+// locate checks one region and returns the redactions made inside it.
+func (r *burnedInTextRedactor) locate(rect image.Rectangle) ([]TextRedaction, error) {
+	category, found, err := r.check(rect)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	halves, middle, ok := splitRegion(rect)
+	if !ok || r.exhausted() {
+		r.paint(rect)
+		return []TextRedaction{{Category: category, Bounds: rect}}, nil
+	}
+
+	// Search both halves, then, for text straddling the split and therefore unreadable in either half, the band
+	// centred on the split. The region is re-checked with the redactions applied after each step. Once the OCR calls
+	// are spent the region, known to match, is blacked out whole.
+	var redactions []TextRedaction
+search:
+	for _, candidates := range [][]image.Rectangle{halves[:], {middle}} {
+		for _, candidate := range candidates {
+			if r.exhausted() {
+				break search
+			}
+			found, err := r.locate(candidate)
+			if err != nil {
+				return nil, err
+			}
+			redactions = append(redactions, found...)
+		}
+		if len(redactions) == 0 {
+			continue
+		}
+		if r.exhausted() {
+			break
+		}
+		if category, found, err = r.check(rect); err != nil || !found {
+			return redactions, err
+		}
+	}
+	r.paint(rect)
+	return []TextRedaction{{Category: category, Bounds: rect}}, nil
+}
+
+// exhausted reports whether the OCR calls of the image or of the shared budget are spent.
+func (r *burnedInTextRedactor) exhausted() bool {
+	return r.calls >= burnedInMaxOCRCalls || *r.budget <= 0
+}
+
+// check runs OCR on one region of the working copy and matches the text against the PHI patterns.
+func (r *burnedInTextRedactor) check(rect image.Rectangle) (security.PHICategory, bool, error) {
+	r.calls++
+	*r.budget--
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, r.img.SubImage(rect)); err != nil {
+		return "", false, fmt.Errorf("encoding region for OCR: %w", err)
+	}
+	text, _, err := r.ocrService.ExtractText(r.ctx, buf.Bytes(), "image/png")
+	if err != nil {
+		return "", false, fmt.Errorf("running OCR on image region: %w", err)
+	}
+	category, found := r.matcher.Match(text)
+	return category, found, nil
+}
+
+// paint blacks out a region of the working copy.
+func (r *burnedInTextRedactor) paint(rect image.Rectangle) {
+	draw.Draw(r.img, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+}
+
+// splitRegion bisects rect across its longer side, returning the two halves and the band of half the size centred
+// on the split. It reports false once both sides are below twice the minimum.
+func splitRegion(rect image.Rectangle) ([2]image.Rectangle, image.Rectangle, bool) {
+	w, h := rect.Dx(), rect.Dy()
+	switch {
+	case w >= 2*burnedInMinRegionSide && (w >= h || h < 2*burnedInMinRegionSide):
+		mid := rect.Min.X + w/2
+		halves := [2]image.Rectangle{image.Rect(rect.Min.X, rect.Min.Y, mid, rect.Max.Y), image.Rect(mid, rect.Min.Y, rect.Max.X, rect.Max.Y)}
+		return halves, image.Rect(mid-w/4, rect.Min.Y, mid+w/4, rect.Max.Y), true
+	case h >= 2*burnedInMinRegionSide:
+		mid := rect.Min.Y + h/2
+		halves := [2]image.Rectangle{image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, mid), image.Rect(rect.Min.X, mid, rect.Max.X, rect.Max.Y)}
+		return halves, image.Rect(rect.Min.X, mid-h/4, rect.Max.X, mid+h/4), true
+	}
+	return [2]image.Rectangle{}, image.Rectangle{}, false
+}
+
+// cloneImage copies img into a drawable image, keeping greyscale images greyscale so re-encoded PNGs stay small.
+func cloneImage(img image.Image) redactableImage {
+	var dst redactableImage
+	if _, ok := img.(*image.Gray); ok {
+		dst = image.NewGray(img.Bounds())
+	} else {
+		dst = image.NewRGBA(img.Bounds())
+	}
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return dst
+}
+
+// redactImage runs the burned-in text check on one image and records each redaction in the audit log.
+// source and details describe the image in the audit entry (e.g. the upload's content type or the rendered slice index);
+// they must not contain PHI, so filenames are never recorded.
+func (s *ProcessingService) redactImage(ctx context.Context, patientID uuid.UUID, img image.Image, matcher *security.PHIMatcher, budget *int, source string, details map[string]any) (image.Image, []TextRedaction, error) {
+	const operation = "redactImage"
+	requestID := utils.GetRequestID(ctx)
+
+	redacted, redactions, err := redactBurnedInText(ctx, s.ocrService, matcher, img, budget)
+	if err != nil {
+		s.logger.Error("Burned-in text check failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("source", source), zap.Error(err))
+		return nil, nil, fmt.Errorf("checking for burned-in text: %w", err)
+	}
+
+	bounds := img.Bounds()
+	for _, redaction := range redactions {
+		entryDetails := map[string]any{
+			"source":       source,
+			"category":     redaction.Category,
+			"region":       [4]int{redaction.Bounds.Min.X, redaction.Bounds.Min.Y, redaction.Bounds.Max.X, redaction.Bounds.Max.Y},
+			"image_width":  bounds.Dx(),
+			"image_height": bounds.Dy(),
+		}
+		for k, v := range details {
+			entryDetails[k] = v
+		}
+		detailsJSON, err := json.Marshal(entryDetails)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding redaction audit details: %w", err)
+		}
+		if err := s.auditLogRepository.CreateAuditLog(ctx, &models.AuditLog{Action: AuditActionBurnedInTextRedacted, SessionID: patientID, Details: detailsJSON}); err != nil {
+			return nil, nil, fmt.Errorf("recording redaction in audit log: %w", err)
+		}
+	}
+	if len(redactions) > 0 {
+		s.logger.Info("Burned-in text redacted", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("source", source), zap.Int("regions", len(redactions)))
+	}
+	return redacted, redactions, nil
+}
+
+// burnedInIdentifiers returns the identifying values of an original (not yet anonymized) DICOM header that are burned
+// into the pixels of captures and photographs: the patient's name, IDs and birth date. It returns nil for images not
+// expected to carry burned-in text, such as CT slices, whose pixels are then not checked.
+func burnedInIdentifiers(data *dicom.DataSet) []string {
+	if !mayHaveBurnedInText(data) {
+		return nil
+	}
+	var identifiers []string
+	for _, tag := range []dicom.Tag{dicom.TagPatientName, dicom.TagPatientID, dicom.TagOtherPatientIDs, dicom.TagPatientBirthDate} {
+		if el, ok := data.FindElement(tag); ok {
+			identifiers = append(identifiers, el.Strings()...)
+		}
+	}
+	return identifiers
+}
+
+// mayHaveBurnedInText reports whether an image declares burned-in annotations or is a capture or photograph.
+func mayHaveBurnedInText(data *dicom.DataSet) bool {
+	if strings.EqualFold(strings.TrimSpace(data.GetString(dicom.TagBurnedInAnnotation)), "YES") {
+		return true
+	}
+	instance := data.Instance()
+	for _, prefix := range burnedInSOPClassPrefixes {
+		if instance.SOPClassUID == prefix || strings.HasPrefix(instance.SOPClassUID, prefix+".") {
+			return true
+		}
+	}
+	for _, value := range instance.ImageType {
+		if strings.EqualFold(strings.TrimSpace(value), "SECONDARY") {
+			return true
+		}
+	}
+	switch data.Series().Modality {
+	case "OT", "XC":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// internal/domain/services/burned_in_text_test.go
+package services
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/stackvity/lung-server/internal/ocr"
+	"github.com/stackvity/lung-server/internal/security"
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// fixedOCR reads the same text in every region and counts its calls.
+type fixedOCR struct {
+	ocr.OCRService
+	text  string
+	calls int
+}
+
+func (o *fixedOCR) ExtractText(context.Context, []byte, string) (string, float64, error) {
+	o.calls++
+	return o.text, 0.9, nil
+}
+
+func TestRedactBurnedInTextKnownIdentifiersOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		redacted  bool
+		wantCalls int
+	}{
+		{"clinical annotation", "2024-03-12 ID: 1.25 mm L 12 mm 120 kVp", false, 1},
+		{"patient name", "DOE, JANE  2024-03-12", true, burnedInMaxOCRCalls},
+		{"birth date without separators", "DOB 1957 03 02", true, burnedInMaxOCRCalls},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fixedOCR{text: tt.text}
+			budget := burnedInMaxOCRCallsPerSeries
+			matcher := security.NewKnownIdentifierMatcher("DOE^JANE", "MRN0042", "19570302")
+			img := image.NewGray(image.Rect(0, 0, 256, 256))
+			_, redactions, err := redactBurnedInText(context.Background(), service, matcher, img, &budget)
+			if err != nil {
+				t.Fatalf("redactBurnedInText: %v", err)
+			}
+			if (len(redactions) > 0) != tt.redacted {
+				t.Errorf("redactions %v, want redacted = %v", redactions, tt.redacted)
+			}
+			if service.calls > tt.wantCalls {
+				t.Errorf("%d OCR calls, want at most %d", service.calls, tt.wantCalls)
+			}
+			if budget != burnedInMaxOCRCallsPerSeries-service.calls {
+				t.Errorf("budget %d after %d calls, want %d", budget, service.calls, burnedInMaxOCRCallsPerSeries-service.calls)
+			}
+		})
+	}
+}
+
+func TestRedactBurnedInTextSharedBudget(t *testing.T) {
+	service := &fixedOCR{text: "DOE JANE"}
+	budget := 3
+	img := image.NewGray(image.Rect(0, 0, 256, 256))
+	if _, _, err := redactBurnedInText(context.Background(), service, security.NewKnownIdentifierMatcher("DOE^JANE"), img, &budget); err != nil {
+		t.Fatalf("redactBurnedInText: %v", err)
+	}
+	if service.calls != 3 || budget != 0 {
+		t.Errorf("%d OCR calls leaving a budget of %d, want 3 calls and none left", service.calls, budget)
+	}
+}
+
+func TestBurnedInIdentifiers(t *testing.T) {
+	withHeader := func(sopClass, modality, burnedIn string) *dicom.DataSet {
+		ds := &dicom.DataSet{}
+		ds.Put(dicom.NewStringElement(dicom.TagSOPClassUID, dicom.VRUI, sopClass))
+		ds.Put(dicom.NewStringElement(dicom.TagModality, dicom.VRCS, modality))
+		if burnedIn != "" {
+			ds.Put(dicom.NewStringElement(dicom.TagBurnedInAnnotation, dicom.VRCS, burnedIn))
+		}
+		ds.Put(dicom.NewStringElement(dicom.TagPatientName, dicom.VRPN, "DOE^JANE"))
+		ds.Put(dicom.NewStringElement(dicom.TagPatientID, dicom.VRLO, "MRN0042"))
+		ds.Put(dicom.NewStringElement(dicom.TagPatientBirthDate, dicom.VRDA, "19570302"))
+		ds.Put(dicom.NewStringElement(dicom.TagInstitutionName, dicom.VRLO, "General Hospital"))
+		return ds
+	}
+	tests := []struct {
+		name string
+		data *dicom.DataSet
+		want int
+	}{
+		{"CT slice", withHeader("1.2.840.10008.5.1.4.1.1.2", "CT", ""), 0},
+		{"CT slice declaring no burned-in text", withHeader("1.2.840.10008.5.1.4.1.1.2", "CT", "NO"), 0},
+		{"CT slice declaring burned-in text", withHeader("1.2.840.10008.5.1.4.1.1.2", "CT", "YES"), 3},
+		{"secondary capture", withHeader("1.2.840.10008.5.1.4.1.1.7", "CT", ""), 3},
+		{"multi-frame secondary capture", withHeader("1.2.840.10008.5.1.4.1.1.7.4", "OT", ""), 3},
+		{"photograph", withHeader("1.2.840.10008.5.1.4.1.1.77.1.4", "XC", ""), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := burnedInIdentifiers(tt.data); len(got) != tt.want {
+				t.Errorf("burnedInIdentifiers = %q, want %d values", got, tt.want)
+			}
+		})
+	}
+}
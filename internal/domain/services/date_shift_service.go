@@ -0,0 +1,50 @@
+// internal/domain/services/date_shift_service.go
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// maxDateShiftDays bounds the date offset. Offsets are always negative (dates move into the past) and at most
+// about two years, so shifted dates stay plausible for the scanner generations they come from.
+const maxDateShiftDays = 730
+
+// DateShiftService provides the per-session date offset used to de-identify dates while preserving the intervals
+// between them: every date of a session, in DICOM headers and in report text, is moved by the same number of days.
+// The offset is derived from a random session secret (SecretPurposeDateShift), so it is stored encrypted with the
+// session and destroyed together with the other session secrets by ProcessingService.DeleteAllPatientData.
+type DateShiftService struct {
+	sessionSecrets *SessionSecretService
+	logger         *zap.Logger
+}
+
+// NewDateShiftService creates a new DateShiftService with dependencies injected.
+func NewDateShiftService(sessionSecrets *SessionSecretService, logger *zap.Logger) *DateShiftService {
+	return &DateShiftService{
+		sessionSecrets: sessionSecrets,
+		logger:         logger.Named("DateShiftService"),
+	}
+}
+
+// Offset returns the session's date offset in days, between -maxDateShiftDays and -1. It is generated on first
+// use and the same for every later call of the session.
+func (s *DateShiftService) Offset(ctx context.Context, sessionID uuid.UUID) (int, error) {
+	const operation = "DateShiftService.Offset"
+	requestID := utils.GetRequestID(ctx)
+
+	secret, err := s.sessionSecrets.Secret(ctx, sessionID, SecretPurposeDateShift)
+	if err != nil {
+		s.logger.Error("Failed to get date shift secret", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()), zap.Error(err))
+		return 0, fmt.Errorf("getting date shift secret: %w", err)
+	}
+	if len(secret) < 4 {
+		return 0, fmt.Errorf("date shift secret too short: %d bytes", len(secret))
+	}
+	return -1 - int(binary.BigEndian.Uint32(secret[:4])%maxDateShiftDays), nil
+}
@@ -3,8 +3,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
-	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stackvity/lung-server/internal/data/models"
 	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
@@ -20,7 +21,10 @@ import (
 // staging information, and treatment recommendations.
 type DiagnosisService struct {
 	reportRepository interfaces.ReportRepository
+	labRepository    interfaces.LabObservationRepository // Lab results imported from lab exports, sent as structured input
 	geminiClient     gemini.GeminiClient
+	prompts          gemini.PromptManager     // Managed prompts of the Gemini calls
+	experiments      *PromptExperimentService // Records the outcome of every Gemini call per prompt version
 	knowledgeBase    knowledge.KnowledgeBase
 	logger           *zap.Logger
 }
@@ -28,40 +32,65 @@ type DiagnosisService struct {
 // NewDiagnosisService creates a new DiagnosisService instance.
 func NewDiagnosisService(
 	reportRepository interfaces.ReportRepository,
+	labRepository interfaces.LabObservationRepository,
 	geminiClient gemini.GeminiClient,
+	prompts gemini.PromptManager,
+	experiments *PromptExperimentService,
 	knowledgeBase knowledge.KnowledgeBase,
 	logger *zap.Logger,
 ) *DiagnosisService {
 	return &DiagnosisService{
 		reportRepository: reportRepository,
+		labRepository:    labRepository,
 		geminiClient:     geminiClient,
+		prompts:          prompts,
+		experiments:      experiments,
 		knowledgeBase:    knowledgeBase,
 		logger:           logger.Named("DiagnosisService"),
 	}
 }
 
-// GeneratePreliminaryDiagnosis orchestrates the generation of a preliminary diagnosis using the Gemini API.
+// GeneratePreliminaryDiagnosis orchestrates the generation of a preliminary diagnosis using the Gemini API, based on
+// the reports and findings stored for the session.
 func (s *DiagnosisService) GeneratePreliminaryDiagnosis(ctx context.Context, patientID uuid.UUID) (*models.Diagnosis, error) {
+	evidence, err := s.sessionEvidence(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GeneratePreliminaryDiagnosisFromEvidence(ctx, patientID, evidence)
+}
+
+// GeneratePreliminaryDiagnosisFromEvidence generates a preliminary diagnosis from the given reports and findings
+// instead of the stored ones, without storing anything. It is used by the offline evaluation harness.
+func (s *DiagnosisService) GeneratePreliminaryDiagnosisFromEvidence(ctx context.Context, patientID uuid.UUID, evidence *CaseEvidence) (*models.Diagnosis, error) {
 	const operation = "DiagnosisService.GeneratePreliminaryDiagnosis" // Corrected operation name for clarity
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 
 	s.logger.Info("Starting preliminary diagnosis generation", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID))
 
 	// 1. Prepare Input for Gemini API - BE-039, BE-048a
+	prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptPreliminaryDiagnosis, patientID, nil)
+	if err != nil {
+		s.logger.Error("Failed to render prompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return nil, fmt.Errorf("rendering preliminary diagnosis prompt: %w", err)
+	}
+	labResults, err := s.labResults(ctx, patientID)
+	if err != nil {
+		s.logger.Error("Failed to load lab results", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return nil, err
+	}
 	geminiInput := &geminiModels.DiagnosisInput{
-		PatientID: patientID,
-		Prompt:    "Generate a preliminary diagnosis for lung cancer based on the available medical information.", // Define prompt - consider refining prompt for better results
-		// In real implementation, populate input with relevant patient data if needed from database
-		// Example:
-		//   reportData, err := s.reportRepository.GetReportByPatientID(ctx, patientID)
-		//   if err != nil { return nil, fmt.Errorf("failed to retrieve report data: %w", err) }
-		//   geminiInput.ReportText = reportData.ReportText // Assuming ReportText field in DiagnosisInput
-		//   geminiInput.FindingsSummary = ... // Populate with findings summary if available
-		//   geminiInput.PatientHistory = ... // Populate with patient history if available
+		PatientID:       patientID,
+		Prompt:          prompt.Text,
+		FindingsSummary: evidence.findingsSummary(),
+		ReportText:      evidence.reportText(),
+		LabResults:      labResults,
+		// PatientHistory and Symptoms are not collected yet
 	}
 
 	// 2. Call Gemini API Client - BE-039, BE-048a
-	geminiOutput, err := s.geminiClient.GeneratePreliminaryDiagnosis(ctx, geminiInput)
+	geminiOutput, err := s.geminiClient.GeneratePreliminaryDiagnosis(gemini.WithPrompt(ctx, prompt), geminiInput)
+	s.experiments.RecordCall(ctx, prompt, err)
 	if err != nil {
 		geminiErr := domain.NewErrGeminiDiagnosisFailed("Gemini Preliminary Diagnosis API call failed", err) // BE-039 - Custom error type
 		geminiErr.SetLogger(s.logger)                                                                        // Set logger for custom error for better context
@@ -72,10 +101,11 @@ func (s *DiagnosisService) GeneratePreliminaryDiagnosis(ctx context.Context, pat
 	// 3. Process Gemini API Output and Create Diagnosis Model - BE-039, BE-044, BE-048a
 	diagnosis := &models.Diagnosis{ // BE-044 - Create Diagnosis model
 		// ID:        uuid.New(), // Removed: ID is not set here, database will generate it. - Fixed issue: #2
-		DiagnosisText: geminiOutput.Diagnosis,     // Extract diagnosis text from Gemini output
-		Confidence:    geminiOutput.Confidence,    // Extract confidence level
-		Justification: geminiOutput.Justification, // Extract justification
-		SessionID:     patientID,                  // Assuming SessionID is the same as PatientID for this context - Corrected: SessionID is now correctly set. - Fixed issue: #1
+		DiagnosisText:   geminiOutput.Diagnosis,     // Extract diagnosis text from Gemini output
+		Confidence:      geminiOutput.Confidence,    // Extract confidence level
+		Justification:   geminiOutput.Justification, // Extract justification
+		SessionID:       patientID,                  // Assuming SessionID is the same as PatientID for this context - Corrected: SessionID is now correctly set. - Fixed issue: #1
+		PromptVersionID: prompt.VersionID,           // Prompt version the diagnosis was generated with
 	}
 
 	// 4. (Optional) Integrate with Knowledge Base/Rules - BE-048a - Placeholder
@@ -93,27 +123,118 @@ func (s *DiagnosisService) GeneratePreliminaryDiagnosis(ctx context.Context, pat
 	return diagnosis, nil
 }
 
-// GetStagingInformation retrieves preliminary staging information using the Gemini API.
+// labResults returns the lab observations of a session as structured Gemini input.
+func (s *DiagnosisService) labResults(ctx context.Context, patientID uuid.UUID) ([]geminiModels.LabResult, error) {
+	observations, err := s.labRepository.GetLabObservationsBySessionID(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("loading lab observations: %w", err)
+	}
+	results := make([]geminiModels.LabResult, len(observations))
+	for i, o := range observations {
+		results[i] = geminiModels.LabResult{
+			Analyte:        o.Analyte,
+			Code:           o.Code,
+			Value:          o.Value,
+			NumericValue:   o.NumericValue,
+			Unit:           o.Unit,
+			ReferenceRange: o.ReferenceRange,
+			Flag:           o.Flag,
+		}
+		if o.ObservedAt != nil {
+			results[i].Date = o.ObservedAt.Format("2006-01-02")
+		}
+	}
+	return results, nil
+}
+
+// CaseEvidence is the de-identified case data staging and diagnosis are based on: the text of the case's reports
+// and the findings extracted from them.
+type CaseEvidence struct {
+	Reports  []*models.Report  // ReportType and ReportText are used
+	Findings []*models.Finding // Description and LowConfidence are used
+}
+
+// sessionEvidence loads the reports stored for a session and the findings extracted from them.
+func (s *DiagnosisService) sessionEvidence(ctx context.Context, patientID uuid.UUID) (*CaseEvidence, error) {
+	reports, err := s.reportRepository.GetReportByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("loading reports: %w", err)
+	}
+	findings, err := s.reportRepository.GetFindingsByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("loading findings: %w", err)
+	}
+	return &CaseEvidence{Reports: reports, Findings: findings}, nil
+}
+
+// reportText joins the text of the reports, each headed by its type.
+func (e *CaseEvidence) reportText() string {
+	if e == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, report := range e.Reports {
+		if strings.TrimSpace(report.ReportText) == "" {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteString("\n\n")
+		}
+		fmt.Fprintf(&text, "[%s report]\n%s", report.ReportType, strings.TrimSpace(report.ReportText))
+	}
+	return text.String()
+}
+
+// findingsSummary lists the findings, one per line; those read from low-confidence OCR text are marked.
+func (e *CaseEvidence) findingsSummary() string {
+	if e == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		line := "- " + finding.Description
+		if finding.LowConfidence {
+			line += " (read from low-confidence OCR text)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetStagingInformation retrieves preliminary staging information using the Gemini API, based on the reports and
+// findings stored for the session.
 func (s *DiagnosisService) GetStagingInformation(ctx context.Context, patientID uuid.UUID) (*models.Stage, error) {
+	evidence, err := s.sessionEvidence(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStagingInformationFromEvidence(ctx, patientID, evidence)
+}
+
+// GetStagingInformationFromEvidence retrieves preliminary staging information from the given reports and findings
+// instead of the stored ones, without storing anything. It is used by the offline evaluation harness.
+func (s *DiagnosisService) GetStagingInformationFromEvidence(ctx context.Context, patientID uuid.UUID, evidence *CaseEvidence) (*models.Stage, error) {
 	const operation = "DiagnosisService.GetStagingInformation" // Corrected operation name
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 
 	s.logger.Info("Starting staging information retrieval", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID))
 
 	// 1. Prepare Input for Gemini API - BE-041, BE-048a
+	prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptStagingInformation, patientID, nil)
+	if err != nil {
+		s.logger.Error("Failed to render prompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return nil, fmt.Errorf("rendering staging prompt: %w", err)
+	}
 	geminiInput := &geminiModels.StagingInput{
-		PatientID: patientID,
-		Prompt:    "Get TNM staging information for lung cancer based on the available medical information.", // Define prompt - consider refining prompt
-		// In real implementation, populate input with relevant patient data if needed
-		// Example:
-		//   reportData, err := s.reportRepository.GetReportByPatientID(ctx, patientID)
-		//   if err != nil {  return nil, fmt.Errorf("failed to get report data: %w", err) }
-		//   geminiInput.ReportText = reportData.ReportText // Assuming ReportText field in StagingInput
-		//   geminiInput.FindingsSummary = ... // Populate with findings summary if available
+		PatientID:       patientID,
+		Prompt:          prompt.Text,
+		FindingsSummary: evidence.findingsSummary(),
+		ReportText:      evidence.reportText(),
 	}
 
 	// 2. Call Gemini API Client - BE-041, BE-048a
-	geminiOutput, err := s.geminiClient.GetStagingInformation(ctx, geminiInput)
+	geminiOutput, err := s.geminiClient.GetStagingInformation(gemini.WithPrompt(ctx, prompt), geminiInput)
+	s.experiments.RecordCall(ctx, prompt, err)
 	if err != nil {
 		geminiErr := domain.NewErrGeminiStagingFailed("Gemini Staging API call failed", err) // BE-041 - Custom error type
 		geminiErr.SetLogger(s.logger)
@@ -124,11 +245,12 @@ func (s *DiagnosisService) GetStagingInformation(ctx context.Context, patientID
 	// 3. Process Gemini API Output and Create Stage Model - BE-041, BE-044, BE-048a
 	stage := &models.Stage{ // BE-044 - Create Stage model
 		// ID:        uuid.New(),  // Removed: ID is not set here, database will generate it. - Fixed issue: #2
-		T:          geminiOutput.T,          // Extract T staging from Gemini Output
-		N:          geminiOutput.N,          // Extract N staging
-		M:          geminiOutput.M,          // Extract M staging
-		Confidence: geminiOutput.Confidence, // Extract confidence
-		SessionID:  patientID,               // Corrected: SessionID is now correctly set. - Fixed issue: #1
+		T:               geminiOutput.T,          // Extract T staging from Gemini Output
+		N:               geminiOutput.N,          // Extract N staging
+		M:               geminiOutput.M,          // Extract M staging
+		Confidence:      geminiOutput.Confidence, // Extract confidence
+		SessionID:       patientID,               // Corrected: SessionID is now correctly set. - Fixed issue: #1
+		PromptVersionID: prompt.VersionID,        // Prompt version the staging was generated with
 	}
 
 	// 4. (Optional) Integrate with Knowledge Base/Rules - BE-048a - Placeholder
@@ -149,14 +271,19 @@ func (s *DiagnosisService) GetStagingInformation(ctx context.Context, patientID
 // SuggestTreatmentOptions retrieves potential treatment options using the Gemini API.
 func (s *DiagnosisService) SuggestTreatmentOptions(ctx context.Context, patientID uuid.UUID) ([]*models.TreatmentRecommendation, error) {
 	const operation = "DiagnosisService.SuggestTreatmentOptions" // Corrected operation name
-	requestID := utils.GetRequestID(ctx.(*gin.Context))
+	requestID := utils.GetRequestID(ctx)
 
 	s.logger.Info("Starting treatment options suggestion", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.String("request_id", requestID))
 
 	// 1. Prepare Input for Gemini API - BE-043, BE-048a
+	prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptTreatmentOptions, patientID, nil)
+	if err != nil {
+		s.logger.Error("Failed to render prompt", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return nil, fmt.Errorf("rendering treatment options prompt: %w", err)
+	}
 	geminiInput := &geminiModels.TreatmentRecommendationInput{
 		PatientID: patientID,
-		Prompt:    "Suggest potential treatment options for lung cancer based on the preliminary diagnosis and staging.", // Define prompt - consider refine prompt
+		Prompt:    prompt.Text,
 		// In real implementation, populate input with preliminary diagnosis and staging info
 		// Example:
 		//   diagnosis, err := s.GeneratePreliminaryDiagnosis(ctx, patientID)
@@ -169,7 +296,8 @@ func (s *DiagnosisService) SuggestTreatmentOptions(ctx context.Context, patientI
 	}
 
 	// 2. Call Gemini API Client - BE-043, BE-048a
-	geminiOutput, err := s.geminiClient.SuggestTreatmentOptions(ctx, geminiInput)
+	geminiOutput, err := s.geminiClient.SuggestTreatmentOptions(gemini.WithPrompt(ctx, prompt), geminiInput)
+	s.experiments.RecordCall(ctx, prompt, err)
 	if err != nil {
 		geminiErr := domain.NewErrGeminiTreatmentSuggestFailed("Gemini Treatment Suggestion API call failed", err) // BE-043 - Custom error type
 		geminiErr.SetLogger(s.logger)
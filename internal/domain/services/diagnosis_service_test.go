@@ -0,0 +1,82 @@
+// internal/domain/services/diagnosis_service_test.go
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/gemini"
+	geminiModels "github.com/stackvity/lung-server/internal/gemini/models"
+)
+
+// inputRecorder records the inputs of the staging and diagnosis calls and answers them with fixed outputs.
+type inputRecorder struct {
+	gemini.GeminiClient
+	staging   *geminiModels.StagingInput
+	diagnosis *geminiModels.DiagnosisInput
+}
+
+func (c *inputRecorder) GetStagingInformation(_ context.Context, input *geminiModels.StagingInput) (*geminiModels.StagingOutput, error) {
+	c.staging = input
+	return &geminiModels.StagingOutput{T: "T1c", N: "N0", M: "M0"}, nil
+}
+
+func (c *inputRecorder) GeneratePreliminaryDiagnosis(_ context.Context, input *geminiModels.DiagnosisInput) (*geminiModels.DiagnosisOutput, error) {
+	c.diagnosis = input
+	return &geminiModels.DiagnosisOutput{Diagnosis: "Adenocarcinoma of the right upper lobe"}, nil
+}
+
+// noLabs is a session without lab results.
+type noLabs struct {
+	interfaces.LabObservationRepository
+}
+
+func (noLabs) GetLabObservationsBySessionID(context.Context, uuid.UUID) ([]*models.LabObservation, error) {
+	return nil, nil
+}
+
+func TestEvidenceReachesStagingAndDiagnosis(t *testing.T) {
+	client := &inputRecorder{}
+	logger := zap.NewNop()
+	s := NewDiagnosisService(nil, noLabs{}, client, registryPrompts{versionID: uuid.New()}, NewPromptExperimentService(&promptCallLog{}, nil, nil, logger), nil, logger)
+	evidence := &CaseEvidence{
+		Reports: []*models.Report{
+			{ReportType: "radiology", ReportText: "18 mm spiculated nodule in the right upper lobe."},
+			{ReportType: "pathology", ReportText: "Invasive adenocarcinoma, acinar predominant."},
+		},
+		Findings: []*models.Finding{
+			{Description: "Spiculated nodule, 18 mm, right upper lobe"},
+			{Description: "No mediastinal lymphadenopathy", LowConfidence: true},
+		},
+	}
+
+	if _, err := s.GetStagingInformationFromEvidence(context.Background(), uuid.New(), evidence); err != nil {
+		t.Fatalf("GetStagingInformationFromEvidence: %v", err)
+	}
+	if _, err := s.GeneratePreliminaryDiagnosisFromEvidence(context.Background(), uuid.New(), evidence); err != nil {
+		t.Fatalf("GeneratePreliminaryDiagnosisFromEvidence: %v", err)
+	}
+
+	for _, input := range []struct {
+		name, reportText, findings string
+	}{
+		{"staging", client.staging.ReportText, client.staging.FindingsSummary},
+		{"diagnosis", client.diagnosis.ReportText, client.diagnosis.FindingsSummary},
+	} {
+		for _, want := range []string{"[radiology report]\n18 mm spiculated nodule", "[pathology report]\nInvasive adenocarcinoma"} {
+			if !strings.Contains(input.reportText, want) {
+				t.Errorf("%s report text %q does not contain %q", input.name, input.reportText, want)
+			}
+		}
+		want := "- Spiculated nodule, 18 mm, right upper lobe\n- No mediastinal lymphadenopathy (read from low-confidence OCR text)"
+		if input.findings != want {
+			t.Errorf("%s findings = %q, want %q", input.name, input.findings, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// internal/domain/services/lab_import.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/labs"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// hl7ContentType is the content type HL7 v2 uploads are processed as. They are recognized by their content
+// (hl7.IsMessage), whatever they were declared as.
+const hl7ContentType = "application/hl7-v2"
+
+// hl7IdentifyingSegments are dropped from HL7 uploads before they are stored: the patient, next of kin, visit,
+// guarantor, insurance and merge segments, none of which the import reads.
+var hl7IdentifyingSegments = []string{"PID", "PD1", "NK1", "PV1", "PV2", "GT1", "IN1", "IN2", "IN3", "MRG"}
+
+// isLabCSVUpload reports whether an upload is a CSV lab export: declared as CSV, or, since browsers declare .csv
+// files as spreadsheets or plain text depending on the platform, with a .csv extension and such a content type.
+func isLabCSVUpload(filename string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	switch strings.ToLower(mediaType) {
+	case "text/csv", "application/csv", "text/comma-separated-values":
+		return true
+	case "application/vnd.ms-excel", "text/plain", "application/octet-stream", "":
+		return strings.EqualFold(path.Ext(filename), ".csv")
+	}
+	return false
+}
+
+// processLabCSVFile imports the lab results of a CSV export (see labs.ParseCSV) as lab observations of the session.
+// Only the lab columns of the export are read. Dates are moved by the session's date offset, like every other date
+// of the session, and text values are de-identified; numbers, units and ranges carry no PHI and are kept as read.
+func (s *ProcessingService) processLabCSVFile(ctx context.Context, patientID uuid.UUID, filename string, fileData []byte) error {
+	const operation = "processLabCSVFile"
+	requestID := utils.GetRequestID(ctx)
+
+	// 1. Parse and normalize the results
+	parsed, err := labs.ParseCSV(fileData)
+	if err != nil {
+		s.logger.Warn("Failed to read lab results", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return fmt.Errorf("reading lab results: %w", err)
+	}
+	s.logger.Info("Lab results read",
+		zap.String("operation", operation),
+		zap.String("request_id", requestID),
+		zap.String("filename", filename),
+		zap.Int("observations", len(parsed.Observations)),
+		zap.Int("skipped_rows", parsed.Skipped),
+		zap.Int("header_row", parsed.Columns.HeaderRow),
+	)
+
+	// 2. De-identify dates and text values
+	observations, err := s.labObservations(ctx, patientID, models.LabSourceCSV, parsed.Observations)
+	if err != nil {
+		return err
+	}
+
+	// 3. Store them
+	if err := s.ensurePatient(ctx, patientID); err != nil {
+		return fmt.Errorf("create patient when processing lab results: %w", err)
+	}
+	if err := s.labRepository.CreateLabObservations(ctx, observations); err != nil {
+		return fmt.Errorf("storing lab observations: %w", err)
+	}
+	return nil
+}
+
+// labObservations turns parsed lab results into lab observations of a session, shifting their dates by the
+// session's date offset and de-identifying their text values.
+func (s *ProcessingService) labObservations(ctx context.Context, patientID uuid.UUID, source string, parsed []labs.Observation) ([]*models.LabObservation, error) {
+	dateShiftDays, err := s.dateShift.Offset(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("getting date offset: %w", err)
+	}
+	deidentifier, err := s.textDeidentifier(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make([]*models.LabObservation, len(parsed))
+	for i, o := range parsed {
+		observation := &models.LabObservation{
+			SessionID:      patientID,
+			Source:         source,
+			Analyte:        o.Analyte,
+			Code:           o.Code,
+			Value:          o.Value,
+			NumericValue:   o.Numeric,
+			Unit:           o.Unit,
+			ReferenceRange: o.ReferenceRange,
+			ReferenceLow:   o.ReferenceLow,
+			ReferenceHigh:  o.ReferenceHigh,
+			Flag:           o.Flag,
+		}
+		if o.Numeric == nil {
+			observation.Value = deidentifier.Deidentify(o.Value).Text // Free text may name people ("called to Dr. ...")
+		}
+		if !o.ObservedAt.IsZero() {
+			observedAt := o.ObservedAt.AddDate(0, 0, dateShiftDays)
+			observation.ObservedAt = &observedAt
+		}
+		observations[i] = observation
+	}
+	return observations, nil
+}
+
+// processHL7File imports the ORU^R01 result messages of an HL7 v2 file (see labs.ParseHL7): numeric and coded
+// results as lab observations of the session, and the text results of each order as a report, which is analyzed
+// like an uploaded report. ProcessDocument has already dropped the patient segments (hl7IdentifyingSegments).
+func (s *ProcessingService) processHL7File(ctx context.Context, patientID uuid.UUID, filename, filePath string, fileData []byte) error {
+	const operation = "processHL7File"
+	requestID := utils.GetRequestID(ctx)
+
+	// 1. Parse the result messages
+	parsed, err := labs.ParseHL7(fileData)
+	if err != nil {
+		s.logger.Warn("Failed to read HL7 results", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return fmt.Errorf("reading HL7 results: %w", err)
+	}
+	s.logger.Info("HL7 results read",
+		zap.String("operation", operation),
+		zap.String("request_id", requestID),
+		zap.String("filename", filename),
+		zap.Int("messages", parsed.Messages),
+		zap.Int("observations", len(parsed.Observations)),
+		zap.Int("reports", len(parsed.Reports)),
+		zap.Int("skipped_results", parsed.Skipped),
+	)
+
+	// 2. De-identify dates and text values, and store the observations
+	observations, err := s.labObservations(ctx, patientID, models.LabSourceHL7, parsed.Observations)
+	if err != nil {
+		return err
+	}
+	if err := s.ensurePatient(ctx, patientID); err != nil {
+		return fmt.Errorf("create patient when processing HL7 results: %w", err)
+	}
+	if len(observations) > 0 {
+		if err := s.labRepository.CreateLabObservations(ctx, observations); err != nil {
+			return fmt.Errorf("storing lab observations: %w", err)
+		}
+	}
+
+	// 3. Store the text of each order as a de-identified report, and its findings - BE-024, BE-055
+	for _, parsedReport := range parsed.Reports {
+		text := hl7ReportText(parsedReport)
+		deidentified, err := s.deidentifyText(ctx, patientID, text) // Shifts the order date with the session's offset
+		if err != nil {
+			return fmt.Errorf("de-identifying HL7 report text: %w", err)
+		}
+		report := &models.Report{
+			ID:         uuid.New(),
+			PatientID:  patientID,
+			Filename:   filename,
+			ReportType: s.DetermineReportType(filename, text),
+			ReportText: deidentified.Text,
+			Filepath:   filePath,
+		}
+		if err := s.reportRepository.CreateReport(ctx, report); err != nil {
+			return fmt.Errorf("creating report in db: %w", err)
+		}
+		findings, err := s.AnalyzeReportText(ctx, patientID, report.ReportType, report.ReportText)
+		if err != nil {
+			return err
+		}
+		for _, finding := range findings {
+			finding.FileID = report.ID
+			if err := s.reportRepository.CreateFinding(ctx, finding); err != nil {
+				return fmt.Errorf("creating %s finding: %w", finding.FindingType, err)
+			}
+		}
+	}
+	return nil
+}
+
+// hl7ReportText writes the text of an HL7 order as a report: the ordered service and its date, then the text.
+func hl7ReportText(report labs.Report) string {
+	title := report.Title
+	if !report.ObservedAt.IsZero() {
+		title = strings.TrimSpace(title + " " + report.ObservedAt.Format("2006-01-02"))
+	}
+	if title == "" {
+		return report.Text
+	}
+	return title + "\n\n" + report.Text
+}
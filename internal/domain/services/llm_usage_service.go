@@ -0,0 +1,113 @@
+// internal/domain/services/llm_usage_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// tokensPerPriceUnit is the number of tokens LLM_PROMPT_TOKEN_PRICE and LLM_OUTPUT_TOKEN_PRICE are quoted for.
+const tokensPerPriceUnit = 1_000_000
+
+// UsageReportRow is the usage of one method and prompt version on one UTC day.
+type UsageReportRow struct {
+	*models.LLMUsageSummary
+	EstimatedCost float64 `json:"estimated_cost"` // From the configured token prices; 0 if none are set
+}
+
+// UsageTotals sums the rows of a usage report.
+type UsageTotals struct {
+	Calls         int     `json:"calls"`
+	Failures      int     `json:"failures"`
+	PromptTokens  int64   `json:"prompt_tokens"`
+	OutputTokens  int64   `json:"output_tokens"`
+	EstimatedCost float64 `json:"estimated_cost"`
+}
+
+// UsageReport aggregates the token usage of model calls by day, method and prompt version, with the state of the
+// daily budget.
+type UsageReport struct {
+	From               time.Time         `json:"from"`
+	To                 time.Time         `json:"to"`
+	Rows               []*UsageReportRow `json:"rows"`
+	Totals             UsageTotals       `json:"totals"`
+	DailyTokenBudget   int64             `json:"daily_token_budget"`   // 0: unlimited
+	SessionTokenBudget int64             `json:"session_token_budget"` // 0: unlimited
+	TokensUsedToday    int64             `json:"tokens_used_today"`    // Since midnight UTC
+}
+
+// LLMUsageService reports on the token usage recorded by gemini.MeteredClient.
+type LLMUsageService struct {
+	repository interfaces.LLMUsageRepository
+	config     *config.Config
+	logger     *zap.Logger
+}
+
+// NewLLMUsageService creates a new LLMUsageService with dependencies injected.
+func NewLLMUsageService(repository interfaces.LLMUsageRepository, cfg *config.Config, logger *zap.Logger) *LLMUsageService {
+	return &LLMUsageService{
+		repository: repository,
+		config:     cfg,
+		logger:     logger.Named("LLMUsageService"),
+	}
+}
+
+// Report aggregates the model calls made in [from, to). A zero to is now, a zero from is defaultReportWindow before
+// to.
+func (s *LLMUsageService) Report(ctx context.Context, from, to time.Time) (*UsageReport, error) {
+	const operation = "LLMUsageService.Report"
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultReportWindow)
+	}
+	if !from.Before(to) {
+		return nil, domain.NewValidationError("from must be before to")
+	}
+
+	// 1. Aggregated usage, priced per row
+	summary, err := s.repository.GetUsageSummary(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("getting model usage: %w", err)
+	}
+	report := &UsageReport{
+		From:               from,
+		To:                 to,
+		Rows:               make([]*UsageReportRow, len(summary)),
+		DailyTokenBudget:   s.config.LLMDailyTokenBudget,
+		SessionTokenBudget: s.config.LLMSessionTokenBudget,
+	}
+	for i, row := range summary {
+		cost := s.cost(row.PromptTokens, row.OutputTokens)
+		report.Rows[i] = &UsageReportRow{LLMUsageSummary: row, EstimatedCost: cost}
+		report.Totals.Calls += row.Calls
+		report.Totals.Failures += row.Failures
+		report.Totals.PromptTokens += row.PromptTokens
+		report.Totals.OutputTokens += row.OutputTokens
+		report.Totals.EstimatedCost += cost
+	}
+
+	// 2. Consumption of today's budget
+	midnight := time.Now().UTC().Truncate(24 * time.Hour)
+	if report.TokensUsedToday, err = s.repository.GetTokensUsedSince(ctx, midnight); err != nil {
+		return nil, fmt.Errorf("getting today's model usage: %w", err)
+	}
+
+	s.logger.Debug("Model usage report generated", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.Int("rows", len(report.Rows)))
+	return report, nil
+}
+
+// cost estimates the price of a number of prompt and output tokens.
+func (s *LLMUsageService) cost(promptTokens, outputTokens int64) float64 {
+	return (float64(promptTokens)*s.config.LLMPromptTokenPrice + float64(outputTokens)*s.config.LLMOutputTokenPrice) / tokensPerPriceUnit
+}
@@ -2,15 +2,21 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"image/png"
 	"io"
-	"os"
+	"math"
+	"net/http"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/config"
 	"github.com/stackvity/lung-server/internal/data/models"
 	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
 	"github.com/stackvity/lung-server/internal/domain" // Corrected import: Use "internal/domain" not "internal/domain/entities"
@@ -22,20 +28,52 @@ import (
 	"github.com/stackvity/lung-server/internal/storage"
 	"github.com/stackvity/lung-server/internal/utils"
 	"github.com/stackvity/lung-server/pkg/dicom"
+	"github.com/stackvity/lung-server/pkg/hl7"
+	"github.com/stackvity/lung-server/pkg/pdftext"
 	"go.uber.org/zap"
 )
 
+// maxDICOMFileSize is the hard limit passed to the DICOM parser (BE-029).
+const maxDICOMFileSize int64 = 1024 * 1024 * 2
+
+// maxSlicesPerSeries is the number of evenly spaced slices rendered per series for nodule detection (BE-030).
+const maxSlicesPerSeries = 16
+
+// maxDocumentSize bounds how much of an upload is buffered in memory for validation and processing.
+const maxDocumentSize int64 = 50 * 1024 * 1024
+
 type ProcessingService struct {
-	fileStorage       storage.FileStorage
-	ocrService        ocr.OCRService
-	geminiClient      gemini.GeminiClient
-	validator         *validator.Validate
-	patientRepository interfaces.PatientRepository
-	studyRepository   interfaces.StudyRepository
-	imageRepository   interfaces.ImageRepository
-	reportRepository  interfaces.ReportRepository
-	knowledgeBase     knowledge.KnowledgeBase
-	logger            *zap.Logger
+	fileStorage        storage.FileStorage
+	ocrService         ocr.OCRService
+	geminiClient       gemini.GeminiClient
+	responseCache      gemini.ResponseCache     // Cached model responses, deleted with a session's data; nil if caching is disabled
+	prompts            gemini.PromptManager     // Managed prompts of the Gemini calls
+	experiments        *PromptExperimentService // Records the outcome of every Gemini call per prompt version
+	validator          *validator.Validate
+	patientRepository  interfaces.PatientRepository
+	studyRepository    interfaces.StudyRepository
+	imageRepository    interfaces.ImageRepository
+	noduleRepository   interfaces.NoduleRepository
+	reportRepository   interfaces.ReportRepository
+	labRepository      interfaces.LabObservationRepository
+	auditLogRepository interfaces.AuditLogRepository
+	sessionSecrets     *SessionSecretService // Per-session pseudonymization secrets (UID remapping, text surrogates)
+	dateShift          *DateShiftService     // Per-session date offset
+	knowledgeBase      knowledge.KnowledgeBase
+	config             *config.Config
+	pendingSeries      *seriesBuffer // Instances awaiting series-level analysis, keyed by patient session
+	logger             *zap.Logger
+}
+
+// SeriesAnalysis summarizes the nodule analysis of one assembled DICOM series.
+type SeriesAnalysis struct {
+	StudyInstanceUID  string              `json:"study_instance_uid"`  // Anonymized StudyInstanceUID
+	SeriesInstanceUID string              `json:"series_instance_uid"` // Anonymized SeriesInstanceUID
+	Modality          string              `json:"modality"`
+	SliceCount        int                 `json:"slice_count"`      // Slices after duplicate removal
+	SliceSpacing      float64             `json:"slice_spacing_mm"` // Typical spacing between slices
+	NoduleCount       int                 `json:"nodule_count"`     // Nodules stored for this series
+	Issues            []dicom.SeriesIssue `json:"issues,omitempty"` // Missing/duplicate slices, inconsistent spacing, etc.
 }
 
 // NewProcessingService creates a new ProcessingService with dependencies injected.
@@ -43,25 +81,44 @@ func NewProcessingService(
 	fileStorage storage.FileStorage,
 	ocrService ocr.OCRService,
 	geminiClient gemini.GeminiClient,
+	responseCache gemini.ResponseCache,
+	prompts gemini.PromptManager,
+	experiments *PromptExperimentService,
 	validator *validator.Validate,
 	patientRepository interfaces.PatientRepository,
 	studyRepository interfaces.StudyRepository,
 	imageRepository interfaces.ImageRepository,
+	noduleRepository interfaces.NoduleRepository,
 	reportRepository interfaces.ReportRepository,
+	labRepository interfaces.LabObservationRepository,
+	auditLogRepository interfaces.AuditLogRepository,
+	sessionSecrets *SessionSecretService,
+	dateShift *DateShiftService,
 	knowledgeBase knowledge.KnowledgeBase,
+	config *config.Config,
 	logger *zap.Logger,
 ) *ProcessingService {
 	return &ProcessingService{
-		fileStorage:       fileStorage,
-		ocrService:        ocrService,
-		geminiClient:      geminiClient,
-		validator:         validator,
-		patientRepository: patientRepository,
-		studyRepository:   studyRepository,
-		imageRepository:   imageRepository,
-		reportRepository:  reportRepository,
-		knowledgeBase:     knowledgeBase,
-		logger:            logger.Named("processing"),
+		fileStorage:        fileStorage,
+		ocrService:         ocrService,
+		geminiClient:       geminiClient,
+		responseCache:      responseCache,
+		prompts:            prompts,
+		experiments:        experiments,
+		validator:          validator,
+		patientRepository:  patientRepository,
+		studyRepository:    studyRepository,
+		imageRepository:    imageRepository,
+		noduleRepository:   noduleRepository,
+		reportRepository:   reportRepository,
+		labRepository:      labRepository,
+		auditLogRepository: auditLogRepository,
+		sessionSecrets:     sessionSecrets,
+		dateShift:          dateShift,
+		knowledgeBase:      knowledgeBase,
+		config:             config,
+		pendingSeries:      newSeriesBuffer(),
+		logger:             logger.Named("processing"),
 	}
 }
 
@@ -83,14 +140,36 @@ func (s *ProcessingService) ProcessDocument(ctx context.Context, patientID uuid.
 		return fmt.Errorf("invalid patient ID: %w", err)
 	}
 
-	// 1. Input Validation (File Type, Size, etc.) - BE-010, BE-011, BE-012, BE-055 (Enhanced Validation)
-	if err := s.validateFile(filename, contentType); err != nil { // Enhanced validation in validateFile
+	// 1. Buffer the upload once so that validation, storage and parsing all see the same bytes
+	fileData, err := io.ReadAll(io.LimitReader(file, maxDocumentSize+1))
+	if err != nil {
+		s.logger.Error("Failed to read uploaded file", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
+		return fmt.Errorf("reading uploaded file: %w", err)
+	}
+	if int64(len(fileData)) > maxDocumentSize {
+		return utils.NewErrFileSizeExceeded(filename, int64(len(fileData)), maxDocumentSize)
+	}
+	switch {
+	case hl7.IsMessage(fileData):
+		contentType = hl7ContentType // Senders declare HL7 files as plain text, binary data or one of several HL7 types
+	case isLabCSVUpload(filename, contentType):
+		contentType = "text/csv" // Browsers declare CSV files in several ways
+	}
+
+	// 2. Input Validation (File Type, Size, etc.) - BE-010, BE-011, BE-012, BE-055 (Enhanced Validation)
+	if err := s.validateFile(filename, contentType, fileData); err != nil { // Enhanced validation in validateFile
 		s.logger.Warn("File validation failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.String("content_type", contentType), zap.Error(err))
 		return fmt.Errorf("validating file: %w", err)
 	}
 
-	// 2. Save File (Temporarily) - BE-014
-	filePath, err := s.fileStorage.Save(ctx, filename, contentType, file)
+	// 3. Drop the patient segments of HL7 messages before they are stored or analyzed. Scanned reports (JPEG, PNG)
+	// keep their pixels: their OCR text goes through the text de-identifier, which leaves the clinical text readable
+	if contentType == hl7ContentType {
+		fileData = hl7.RemoveSegments(fileData, hl7IdentifyingSegments...)
+	}
+
+	// 4. Save File (Temporarily) - BE-014
+	filePath, err := s.fileStorage.Save(ctx, filename, contentType, bytes.NewReader(fileData))
 	if err != nil {
 		s.logger.Error("Failed to save file", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Error(err))
 		return fmt.Errorf("saving file: %w", err)
@@ -105,12 +184,16 @@ func (s *ProcessingService) ProcessDocument(ctx context.Context, patientID uuid.
 		}
 	}()
 
-	// 3.  File Type Handling
+	// 5.  File Type Handling
 	switch contentType {
 	case "application/dicom":
-		return s.processDICOMFile(ctx, patientID, filename, filePath) // BE-029, BE-030
+		return s.processDICOMFile(ctx, patientID, filename, filePath, fileData) // BE-029, BE-030
 	case "application/pdf", "image/jpeg", "image/png":
-		return s.processPDFImageFile(ctx, patientID, filename, filePath, contentType)
+		return s.processPDFImageFile(ctx, patientID, filename, filePath, contentType, fileData)
+	case "text/csv":
+		return s.processLabCSVFile(ctx, patientID, filename, fileData)
+	case hl7ContentType:
+		return s.processHL7File(ctx, patientID, filename, filePath, fileData)
 	default:
 		s.logger.Error("Unsupported content type", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.String("content_type", contentType))
 		return fmt.Errorf("unsupported content type: %s", contentType)
@@ -122,27 +205,38 @@ func (s *ProcessingService) GetPatientRepository() interfaces.PatientRepository
 	return s.patientRepository
 }
 
-func (s *ProcessingService) validateFile(filename string, contentType string) error {
-	// 1. Basic Content Type Validation (using net/http):
-	detectedContentType, err := utils.DetectContentTypeFromFile(filename)
-	if err != nil {
-		return utils.Wrapf(err, "detecting content type for file: %s", filename) // Enhanced error wrapping with Wrapf
-	}
-	if !strings.HasPrefix(detectedContentType, contentType) {
-		return fmt.Errorf("mismatched content type: expected %s, detected %s for file: %s", contentType, detectedContentType, filename)
-	}
-
-	// 2.  DICOM-Specific Validation (Magic Number Check) - BE-011 (Magic Number Implementation)
+func (s *ProcessingService) validateFile(filename string, contentType string, fileData []byte) error {
+	// 1.  DICOM-Specific Validation (Magic Number Check) - BE-011 (Magic Number Implementation)
+	// net/http does not sniff DICOM (it reports application/octet-stream), so DICOM is checked by its own magic number.
 	if contentType == "application/dicom" {
-		if err := s.validateDICOMMagicNumber(filename); err != nil { // Added DICOM magic number validation
+		if err := s.validateDICOMMagicNumber(fileData); err != nil { // Added DICOM magic number validation
 			return utils.Wrapf(err, "DICOM magic number validation failed for file: %s", filename) // Enhanced error wrapping
 		}
 
-		// Basic DICOM Header Validation (as before)
-		_, err := dicom.ParseFile(filename, 1024*1024*2)
-		if err != nil {
+		// Basic DICOM Header Validation - the full parse rejects truncated or malformed objects early
+		if _, err := dicom.Parse(bytes.NewReader(fileData), maxDICOMFileSize); err != nil {
 			return utils.Wrapf(err, "basic DICOM header validation failed for file: %s", filename) // Enhanced error wrapping
 		}
+		return nil
+	}
+
+	// HL7 messages were recognized by their MSH segment (net/http has no type for them); check that they parse.
+	if contentType == hl7ContentType {
+		if _, err := hl7.Parse(fileData); err != nil {
+			return utils.Wrapf(err, "HL7 message validation failed for file: %s", filename)
+		}
+		return nil
+	}
+
+	// 2. Basic Content Type Validation (using net/http):
+	// net/http sniffs CSV (and any other text) as text/plain.
+	detectedContentType := http.DetectContentType(fileData)
+	expectedContentType := contentType
+	if contentType == "text/csv" {
+		expectedContentType = "text/plain"
+	}
+	if !strings.HasPrefix(detectedContentType, expectedContentType) {
+		return fmt.Errorf("mismatched content type: expected %s, detected %s for file: %s", contentType, detectedContentType, filename)
 	}
 
 	// 3.  Further checks can be added here (e.g., using go-playground/validator for file metadata).
@@ -150,28 +244,22 @@ func (s *ProcessingService) validateFile(filename string, contentType string) er
 	return nil
 }
 
-func (s *ProcessingService) validateDICOMMagicNumber(filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("opening file: %w", err)
-	}
-	defer file.Close()
-
-	header := make([]byte, 132) // DICOM preamble + magic number is 132 bytes
-	_, err = file.Read(header)
-	if err != nil {
-		return fmt.Errorf("reading file header: %w", err)
+func (s *ProcessingService) validateDICOMMagicNumber(fileData []byte) error {
+	if len(fileData) < 132 { // DICOM preamble + magic number is 132 bytes
+		return errors.New("file too short to contain a DICOM header")
 	}
 
 	// DICOM magic number is bytes 128-131 (0-indexed) and should be "DICM"
-	magicNumber := string(header[128:132])
+	magicNumber := string(fileData[128:132])
 	if magicNumber != "DICM" {
 		return errors.New("DICOM magic number not found")
 	}
 	return nil
 }
 
-func (s *ProcessingService) determineReportType(filename string, text string) string {
+// DetermineReportType classifies a report as pathology, radiology or labtest from keywords in its filename and text
+// (BE-021); reports without a match are classified as radiology.
+func (s *ProcessingService) DetermineReportType(filename string, text string) string {
 	lowerFilename := strings.ToLower(filename)
 	lowerText := strings.ToLower(text)
 
@@ -194,71 +282,130 @@ func (s *ProcessingService) determineReportType(filename string, text string) st
 	return "radiology" // Default - should log a warning/error in real implementation
 }
 
-func (s *ProcessingService) preprocessImage(_ *dicom.DataSet) ([]byte, error) {
-	// Placeholder implementation for image preprocessing (BE-029 - Placeholder with Comments)
-	// In a real implementation, this function would perform steps like:
-
-	// 1. Image Resizing:
-	//    - Resize the image to a standard input size (e.g., 256x256, 512x512)
-	//    - Use Lanczos resampling for high-quality resizing
-	//    - Example using gocv (or similar library):
-	//      resizedImage := gocv.NewMat()
-	//      gocv.Resize(inputImage, resizedImage, image.Size{X: 256, Y: 256}, 0, 0, gocv.InterpolationLanczos4)
-	//      defer resizedImage.Close()
-
-	// 2. Intensity Normalization:
-	//    - Normalize pixel intensities to a standard range (e.g., 0-1 or -1 to 1)
-	//    - Common methods: Min-Max scaling, Z-score normalization
-	//    - Example (Min-Max scaling - conceptual):
-	//      minVal, maxVal := gocv.MinMaxLoc(resizedImage)
-	//      normalizedImage := gocv.NewMat()
-	//      gocv.SubtractScalar(resizedImage, scalar.NewScalar(minVal, minVal, minVal, 0), normalizedImage)
-	//      gocv.DivideScalar(normalizedImage, scalar.NewScalar(maxVal-minVal, maxVal-minVal, maxVal-minVal, 0), normalizedImage)
-	//      defer normalizedImage.Close()
-
-	// 3. (Optional) Lung Segmentation:
-	//    - Use a pre-trained lung segmentation model (if available and performant)
-	//    - Apply segmentation mask to focus analysis on lung regions
-	//    - Example (conceptual):
-	//      mask := loadLungSegmentationMask(resizedImage) // Load or generate mask
-	//      maskedImage := gocv.NewMat()
-	//      gocv.BitwiseAnd(resizedImage, resizedImage, maskedImage, mask, mask) // Apply mask
-	//      defer maskedImage.Close()
-
-	// 4. Encoding and Output:
-	//    - Encode the preprocessed image to JPEG or PNG format for Gemini API.
-	//    - Return the encoded image data as bytes.
-	//    - Example (JPEG encoding using gocv - conceptual):
-	//      encodedImageData, _ := gocv.IMEncode(".jpg", normalizedImage)
-	//      return encodedImageData, nil
-
-	s.logger.Warn("preprocessImage is a placeholder - basic image preprocessing steps (resizing, normalization) not yet implemented") // Updated log message
-	return []byte{}, nil                                                                                                              // Placeholder!
+// renderOptions selects how slices of a series are rendered for Gemini (BE-029).
+// CT slices are windowed with the configured preset (IMAGE_WINDOW_PRESET) so that Hounsfield units map onto
+// the same grey levels for every scanner; other modalities use their own VOI window, or the full value range.
+// Images are downscaled to IMAGE_MAX_EDGE.
+func (s *ProcessingService) renderOptions(data *dicom.DataSet) (dicom.RenderOptions, error) {
+	opts := dicom.RenderOptions{MaxEdge: s.config.ImageMaxEdge}
+	pixel := data.Pixel()
+	switch {
+	case data.Series().Modality == "CT":
+		window, ok := dicom.WindowPreset(s.config.ImageWindowPreset)
+		if !ok {
+			return opts, fmt.Errorf("unknown image window preset %q", s.config.ImageWindowPreset)
+		}
+		opts.Window = window
+	case len(pixel.WindowCenter) > 0 && len(pixel.WindowWidth) > 0:
+		opts.Window = dicom.Window{Name: "dataset", Center: pixel.WindowCenter[0], Width: pixel.WindowWidth[0]}
+	}
+	return opts, nil
+}
+
+// preprocessSeries renders up to maxSlicesPerSeries evenly spaced slices of the series as normalized 8-bit PNGs
+// (BE-029). Only those slices are decoded, one at a time, and a series larger than SERIES_MAX_VOXELS is refused before
+// any of them is. The patient's identifiers are blacked out of slices that may carry burned-in
+// text (captures and photographs, see burnedInIdentifiers) before encoding; a slice that cannot be checked once the
+// series' OCR budget is spent is left out. It returns the rendered slices and the index of the central one.
+func (s *ProcessingService) preprocessSeries(ctx context.Context, patientID uuid.UUID, series *dicom.Series, instances map[string]pendingInstance) ([]geminiModels.SliceImage, int, error) {
+	if voxels := series.Voxels(); voxels > s.config.SeriesMaxVoxels {
+		return nil, 0, fmt.Errorf("%w: series has %d voxels, at most %d are analyzed", dicom.ErrSeriesTooLarge, voxels, s.config.SeriesMaxVoxels)
+	}
+	opts, err := s.renderOptions(series.Slices[0].DataSet)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	depth := len(series.Slices)
+	count := min(depth, maxSlicesPerSeries)
+	slices := make([]geminiModels.SliceImage, 0, count)
+	ocrBudget := burnedInMaxOCRCallsPerSeries
+	for k := 0; k < count; k++ {
+		z := 0
+		if count > 1 {
+			z = int(math.Round(float64(k) * float64(depth-1) / float64(count-1)))
+		}
+		frames, err := series.DecodeSlice(z)
+		if err != nil {
+			return nil, 0, err
+		}
+		img, err := frames.RenderFrame(0, opts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("rendering slice %d: %w", z, err)
+		}
+		if instance := instances[series.Slices[z].DataSet.SOPInstanceUID]; len(instance.identifiers) > 0 {
+			if ocrBudget <= 0 {
+				s.logger.Warn("Burned-in text check budget spent, leaving slice out", zap.String("request_id", utils.GetRequestID(ctx)), zap.String("image_id", instance.imageID.String()), zap.Int("slice_index", z))
+				continue
+			}
+			img, _, err = s.redactImage(ctx, patientID, img, security.NewKnownIdentifierMatcher(instance.identifiers...), &ocrBudget, "dicom_slice", map[string]any{"image_id": instance.imageID, "slice_index": z})
+			if err != nil {
+				return nil, 0, fmt.Errorf("redacting slice %d: %w", z, err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, 0, fmt.Errorf("encoding slice %d: %w", z, err)
+		}
+		slices = append(slices, geminiModels.SliceImage{SliceIndex: z, Position: series.Slices[z].Position, ImageData: buf.Bytes()})
+	}
+
+	s.logger.Debug("Rendered DICOM series for nodule detection",
+		zap.String("series_instance_uid", series.SeriesInstanceUID),
+		zap.Int("depth", depth),
+		zap.Int("rendered_slices", len(slices)),
+		zap.Float64("slice_spacing_mm", series.SliceSpacing),
+		zap.String("window", opts.Window.Name),
+	)
+	return slices, len(slices) / 2, nil
 }
 
-func (s *ProcessingService) processDICOMFile(ctx context.Context, patientID uuid.UUID, filename, filePath string) error {
+func (s *ProcessingService) processDICOMFile(ctx context.Context, patientID uuid.UUID, filename, filePath string, fileData []byte) error {
 	const operation = "processDICOMFile"
 
 	// 1. Parse DICOM file - BE-029
-	dicomData, err := dicom.ParseFile(filePath, 1024*1024*2)
+	dicomData, err := dicom.Parse(bytes.NewReader(fileData), maxDICOMFileSize)
 	if err != nil {
 		utils.Logger.Warn("Failed to parse DICOM", zap.String("filename", filename), zap.Error(err), zap.String("operation", operation))
 		parseErr := domain.NewErrDICOMParsingFailed(filename, err) // BE-021 (OCR Error Handling) - Custom Error
 		parseErr.SetLogger(s.logger)                               //Set logger for domain error
 		return parseErr
 	}
-	utils.Logger.Info("DICOM Parsed")
+	utils.Logger.Info("DICOM Parsed", zap.String("operation", operation), zap.String("transfer_syntax", dicomData.TransferSyntaxUID), zap.String("modality", dicomData.Series().Modality))
+	identifiers := burnedInIdentifiers(dicomData) // Captured before anonymization removes them, to find them burned into the pixels
 
-	// 2. Extract relevant metadata (Study, Series, Instance) - BE-029
-	studyInstanceUID := dicomData.StudyInstanceUID
-	seriesInstanceUID := dicomData.SeriesInstanceUID
-	sopInstanceUID := dicomData.SOPInstanceUID
-
-	// 3. Data Anonymization/De-identification (before storing or further processing) - BE-055
-	anonymizedDicomData, err := security.AnonymizeDICOMData(dicomData) // Implement this function
+	// 2. Data Anonymization/De-identification (before storing or further processing) - BE-055
+	// UIDs are remapped with the session's secret so that instances uploaded separately keep grouping into the
+	// same study and series, and references between instances still resolve. Dates are shifted by the session's
+	// offset, so intervals between studies survive; cleaned descriptors use the session's text de-identifier.
+	uidSecret, err := s.sessionSecrets.Secret(ctx, patientID, SecretPurposeUIDRemap)
+	if err != nil {
+		return fmt.Errorf("getting UID remapping secret: %w", err)
+	}
+	dateShiftDays, err := s.dateShift.Offset(ctx, patientID)
+	if err != nil {
+		return fmt.Errorf("getting date offset: %w", err)
+	}
+	textDeidentifier, err := s.textDeidentifier(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	anonymizedDicomData, deidRecord, err := security.AnonymizeDICOMData(dicomData, security.DICOMAnonymizationOptions{
+		RetainLongitudinalDates: s.config.DeidRetainLongitudinalDates, // PS3.15 profile options are deployment policy, set in config
+		CleanDescriptors:        s.config.DeidCleanDescriptors,
+		DateShiftDays:           dateShiftDays,
+		UIDMapper:               security.NewUIDMapper(uidSecret),
+		TextCleaner:             func(text string) string { return textDeidentifier.Deidentify(text).Text },
+	})
 	if err != nil {
 		return fmt.Errorf("anonymizing DICOM data: %w", err) // BE-055 - Data Anonymization
 	}
+	s.logDeidentification(ctx, filename, deidRecord)
+
+	// 3. Extract relevant metadata (Study, Series, Instance) from the anonymized dataset - BE-029
+	studyInstanceUID := anonymizedDicomData.StudyInstanceUID
+	seriesInstanceUID := anonymizedDicomData.SeriesInstanceUID
+	sopInstanceUID := anonymizedDicomData.SOPInstanceUID
 
 	// 4. Create or Update Database Records (Patient, Study, Image) - BE-024
 	// (Use repositories to interact with the database)
@@ -278,14 +425,10 @@ func (s *ProcessingService) processDICOMFile(ctx context.Context, patientID uuid
 		}
 	}
 
-	study := &models.Study{ // BE-024
-		ID:               uuid.New(),       // Or generate based on StudyInstanceUID
-		PatientID:        patientID,        // Foreign key
-		StudyInstanceUID: studyInstanceUID, // Store anonymized UID
-		// ... other relevant fields ...
-	}
-	if err := s.studyRepository.CreateStudy(ctx, study); err != nil {
-		return fmt.Errorf("creating study in db: %w", err) // BE-024 - DB Interaction
+	// Instances of the same study share one Study record, however many uploads they arrive in
+	study, err := s.findOrCreateStudy(ctx, patientID, studyInstanceUID)
+	if err != nil {
+		return err
 	}
 
 	image := &models.Image{ // BE-024
@@ -299,56 +442,275 @@ func (s *ProcessingService) processDICOMFile(ctx context.Context, patientID uuid
 	if err := s.imageRepository.CreateImage(ctx, image); err != nil {
 		return fmt.Errorf("creating image in db: %w", err) // BE-024 - DB Interaction
 	}
-	// 5. Image Preprocessing (Resizing, Normalization, etc.) - if needed - BE-029
-	preprocessedImageData, err := s.preprocessImage(anonymizedDicomData)
+
+	// 5. Queue the instance for series-level analysis - nodule detection runs once per assembled series
+	// (see AnalyzeSeries) rather than once per uploaded file.
+	if err := s.pendingSeries.add(patientID, newPendingInstance(anonymizedDicomData, image.ID, identifiers)); err != nil {
+		return fmt.Errorf("queueing instance for series analysis: %w", err)
+	}
+	s.logger.Info("DICOM instance queued for series analysis", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("series_instance_uid", seriesInstanceUID))
+
+	return nil
+}
+
+// logDeidentification writes the de-identification record of an instance to the log for auditing. The summary
+// (profile, options, number of attributes per action) is logged at Info; the per-attribute list at Debug.
+// Records hold attribute paths and action codes only, never values.
+func (s *ProcessingService) logDeidentification(ctx context.Context, filename string, record *security.AnonymizationRecord) {
+	const operation = "logDeidentification"
+	requestID := utils.GetRequestID(ctx)
+
+	counts := record.Counts()
+	s.logger.Info("DICOM instance de-identified",
+		zap.String("operation", operation),
+		zap.String("request_id", requestID),
+		zap.String("filename", filename),
+		zap.String("profile", record.Profile),
+		zap.Strings("options", record.Options),
+		zap.Int("removed", counts[security.ActionRemove]),
+		zap.Int("zeroed", counts[security.ActionZero]),
+		zap.Int("dummied", counts[security.ActionDummy]),
+		zap.Int("cleaned", counts[security.ActionClean]),
+		zap.Int("uids_replaced", counts[security.ActionUID]),
+		zap.Int("dates_shifted", counts[security.ActionShift]),
+		zap.Int("kept", counts[security.ActionKeep]),
+	)
+	s.logger.Debug("DICOM de-identification actions", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("filename", filename), zap.Any("actions", record.Actions))
+}
+
+// textDeidentifier returns the text de-identifier of a session. Surrogates are keyed with the session's secret, so
+// the same name or number gets the same surrogate in every document of the session, and dates are shifted by the
+// session's offset (the one applied to DICOM dates) unless DEID_TEXT_METHODS selects another method for them.
+func (s *ProcessingService) textDeidentifier(ctx context.Context, patientID uuid.UUID) (*security.TextDeidentifier, error) {
+	methods, err := security.ParseTextDeidMethods(s.config.DeidTextMethods)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEID_TEXT_METHODS: %w", err)
+	}
+	if _, ok := methods[security.PHIDate]; !ok {
+		methods[security.PHIDate] = security.MethodDateShift
+	}
+	surrogateKey, err := s.sessionSecrets.Secret(ctx, patientID, SecretPurposeTextSurrogate)
+	if err != nil {
+		return nil, fmt.Errorf("getting text surrogate secret: %w", err)
+	}
+	dateShiftDays, err := s.dateShift.Offset(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("getting date offset: %w", err)
+	}
+	return security.NewTextDeidentifier(security.TextDeidentifierOptions{Methods: methods, SurrogateKey: surrogateKey, DateShiftDays: dateShiftDays}), nil
+}
+
+// deidentifyText removes PHI from free text before it is stored or sent to Gemini (BE-055), using the session's
+// text de-identifier. The replaced spans are logged by category and offset only.
+func (s *ProcessingService) deidentifyText(ctx context.Context, patientID uuid.UUID, text string) (*security.DeidentifiedText, error) {
+	const operation = "deidentifyText"
+	requestID := utils.GetRequestID(ctx)
+
+	deidentifier, err := s.textDeidentifier(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	result := deidentifier.Deidentify(text)
+
+	counts := map[string]int{}
+	for _, span := range result.Spans {
+		counts[string(span.Category)]++
+	}
+	s.logger.Info("Text de-identified", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("replacements", len(result.Spans)), zap.Any("categories", counts))
+	s.logger.Debug("Text de-identification spans", zap.String("operation", operation), zap.String("request_id", requestID), zap.Any("spans", result.Spans))
+	return result, nil
+}
+
+// findOrCreateStudy returns the patient's Study record for studyInstanceUID, creating it on first sight.
+func (s *ProcessingService) findOrCreateStudy(ctx context.Context, patientID uuid.UUID, studyInstanceUID string) (*models.Study, error) {
+	studies, err := s.studyRepository.GetStudiesByPatientID(ctx, patientID)
 	if err != nil {
-		return fmt.Errorf("preprocessing image: %w", err) // BE-029 - Image Preprocessing
+		if _, ok := err.(*domain.NotFoundError); !ok {
+			return nil, fmt.Errorf("getting studies for patient: %w", err) // BE-024 - DB Interaction
+		}
+	}
+	for _, study := range studies {
+		if study.StudyInstanceUID == studyInstanceUID {
+			return study, nil
+		}
+	}
+
+	study := &models.Study{ // BE-024
+		ID:               uuid.New(),
+		PatientID:        patientID,        // Foreign key
+		StudyInstanceUID: studyInstanceUID, // Store anonymized UID
+	}
+	if err := s.studyRepository.CreateStudy(ctx, study); err != nil {
+		return nil, fmt.Errorf("creating study in db: %w", err) // BE-024 - DB Interaction
+	}
+	return study, nil
+}
+
+// AnalyzeSeries assembles every DICOM instance uploaded for the patient since the last analysis into series,
+// checks their geometry (missing/duplicate slices, inconsistent spacing), and runs nodule detection once per
+// series (BE-030). A failure in one series does not prevent the others from being analyzed; all errors are returned joined,
+// and the instances of the failed series stay queued for the next call.
+func (s *ProcessingService) AnalyzeSeries(ctx context.Context, patientID uuid.UUID) ([]SeriesAnalysis, error) {
+	const operation = "AnalyzeSeries"
+	requestID := utils.GetRequestID(ctx)
+
+	pending, session := s.pendingSeries.take(patientID)
+	if len(pending) == 0 {
+		s.logger.Info("No pending DICOM instances to analyze", zap.String("operation", operation), zap.String("request_id", requestID))
+		return nil, nil
 	}
 
-	// 6.  Call Gemini API for Nodule Detection - BE-030
+	instances := make(map[string]pendingInstance, len(pending)) // Anonymized SOPInstanceUID -> pending instance
+	datasets := make([]*dicom.DataSet, 0, len(pending))
+	for _, instance := range pending {
+		instances[instance.dataSet.SOPInstanceUID] = instance
+		datasets = append(datasets, instance.dataSet)
+	}
+
+	var results []SeriesAnalysis
+	var errs []error
+	failedSeries := map[[2]string]bool{} // Study and series UIDs of the series to analyze again
+	for _, series := range dicom.AssembleSeries(datasets) {
+		for _, issue := range series.Issues {
+			s.logger.Warn("DICOM series geometry issue", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("series_instance_uid", series.SeriesInstanceUID), zap.String("issue", string(issue.Kind)), zap.String("detail", issue.Detail))
+		}
+
+		result, err := s.analyzeOneSeries(ctx, patientID, series, instances)
+		if err != nil {
+			s.logger.Error("Series analysis failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("series_instance_uid", series.SeriesInstanceUID), zap.Error(err))
+			errs = append(errs, fmt.Errorf("series %s: %w", series.SeriesInstanceUID, err))
+			failedSeries[[2]string{series.StudyInstanceUID, series.SeriesInstanceUID}] = true
+			continue
+		}
+		results = append(results, result)
+	}
+
+	// Instances of failed series are queued again, so that they are analyzed by the next call
+	var analyzed, failed []pendingInstance
+	for _, instance := range pending {
+		if failedSeries[[2]string{instance.dataSet.StudyInstanceUID, instance.dataSet.SeriesInstanceUID}] {
+			failed = append(failed, instance)
+		} else {
+			analyzed = append(analyzed, instance)
+		}
+	}
+	s.pendingSeries.finish(patientID, session, analyzed, failed)
+	return results, errors.Join(errs...)
+}
+
+// analyzeOneSeries renders one series, calls Gemini and stores the detected nodules.
+func (s *ProcessingService) analyzeOneSeries(ctx context.Context, patientID uuid.UUID, series *dicom.Series, instances map[string]pendingInstance) (SeriesAnalysis, error) {
+	result := SeriesAnalysis{
+		StudyInstanceUID:  series.StudyInstanceUID,
+		SeriesInstanceUID: series.SeriesInstanceUID,
+		Modality:          series.Modality,
+		SliceCount:        len(series.Slices),
+		SliceSpacing:      series.SliceSpacing,
+	}
+
+	// 1. Nodule Detection - BE-029, BE-030
+	nodules, err := s.detectNodules(ctx, patientID, series, instances)
+	result.Issues = series.Issues // Volume reconstruction may add dimension issues
+	if err != nil {
+		return result, err
+	}
+
+	// 2. Store Nodule Information
+	for _, nodule := range nodules {
+		if err := s.noduleRepository.CreateNodule(ctx, nodule); err != nil {
+			return result, fmt.Errorf("saving nodule: %w", err) // BE-048a - Store Nodule Information
+		}
+		result.NoduleCount++
+	}
+
+	return result, nil
+}
+
+// DetectNodules runs nodule detection on DICOM instances that are already de-identified, without storing anything:
+// the instances are assembled into series, and every series is rendered and sent to Gemini as by AnalyzeSeries.
+// It is used by the offline evaluation harness. A failure in one series does not prevent the others from being
+// analyzed; all errors are returned joined.
+func (s *ProcessingService) DetectNodules(ctx context.Context, sessionID uuid.UUID, files [][]byte) ([]*models.Nodule, error) {
+	instances := make(map[string]pendingInstance, len(files)) // SOPInstanceUID -> instance
+	datasets := make([]*dicom.DataSet, 0, len(files))
+	for i, data := range files {
+		dataSet, err := dicom.Parse(bytes.NewReader(data), maxDICOMFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DICOM instance %d: %w", i, err)
+		}
+		instances[dataSet.SOPInstanceUID] = pendingInstance{dataSet: dataSet, imageID: uuid.New()}
+		datasets = append(datasets, dataSet)
+	}
+
+	var nodules []*models.Nodule
+	var errs []error
+	for _, series := range dicom.AssembleSeries(datasets) {
+		found, err := s.detectNodules(ctx, sessionID, series, instances)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("series %s: %w", series.SeriesInstanceUID, err))
+			continue
+		}
+		nodules = append(nodules, found...)
+	}
+	return nodules, errors.Join(errs...)
+}
+
+// detectNodules renders one series and calls Gemini, returning the detected nodules linked to the central rendered
+// slice.
+func (s *ProcessingService) detectNodules(ctx context.Context, patientID uuid.UUID, series *dicom.Series, instances map[string]pendingInstance) ([]*models.Nodule, error) {
+	// 1. Image Preprocessing (volume reconstruction, windowing, resizing) - BE-029
+	slices, central, err := s.preprocessSeries(ctx, patientID, series, instances)
+	if err != nil {
+		return nil, fmt.Errorf("preprocessing series: %w", err) // BE-029 - Image Preprocessing
+	}
+
+	// 2.  Call Gemini API for Nodule Detection - BE-030
+	prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptNoduleDetection, patientID, map[string]any{"SliceCount": len(slices), "Modality": series.Modality}) // Managed prompt - BE-028
+	if err != nil {
+		return nil, fmt.Errorf("rendering nodule detection prompt: %w", err)
+	}
 	geminiInput := &geminiModels.NoduleDetectionInput{
-		ImageData: preprocessedImageData,
-		ImageType: "dicom",                                                                                             // Or determine from DICOM metadata
-		Prompt:    "Identify potential lung nodules in this DICOM image.  Report location, size, and characteristics.", // Use a managed prompt - BE-028
+		ImageData: slices[central].ImageData,
+		ImageType: "image/png", // preprocessSeries always renders to PNG
+		Prompt:    prompt.Text,
+		Slices:    slices,
 	}
-	geminiOutput, err := s.geminiClient.DetectNodules(ctx, geminiInput) // BE-030 - Gemini API Call
+	geminiOutput, err := s.geminiClient.DetectNodules(gemini.WithPrompt(ctx, prompt), geminiInput) // BE-030 - Gemini API Call
+	s.experiments.RecordCall(ctx, prompt, err)
 	if err != nil {
 		geminiErr := domain.NewErrGeminiNoduleDetectionFailed("Gemini Nodule Detection API call failed", err) // BE-030 - Gemini API Error Handling - Custom Error
 		geminiErr.SetLogger(s.logger)                                                                         // Set logger for domain error
-		return geminiErr
-
+		return nil, geminiErr
 	}
 
-	// 7. Process Gemini Output (Store Nodule Information)
+	// 3. Process Gemini Output, linked to the central rendered slice
+	imageID := instances[series.Slices[slices[central].SliceIndex].DataSet.SOPInstanceUID].imageID
+	nodules := make([]*models.Nodule, 0, len(geminiOutput.Nodules))
 	for _, noduleInfo := range geminiOutput.Nodules {
-		nodule := &models.Nodule{ // BE-030 - Process Gemini Output
+		nodules = append(nodules, &models.Nodule{ // BE-030 - Process Gemini Output
 			ID:       uuid.New(),
-			ImageID:  image.ID, // Link to the Image
+			ImageID:  imageID, // Link to the Image
 			Location: noduleInfo.Location,
 			Size:     noduleInfo.Size,
 			Shape:    noduleInfo.Shape,
+
+			PromptVersionID: prompt.VersionID, // Prompt version the nodule was detected with
 			// ... other characteristics ...
-		}
-		if err := s.imageRepository.CreateNodule(ctx, nodule); err != nil {
-			return fmt.Errorf("saving nodule: %w", err) // BE-048a - Store Nodule Information
-		}
+		})
 	}
-
-	// 8.  (Optional) If other analyses are needed (e.g., staging from image), call other Gemini methods.
-
-	return nil
+	return nodules, nil
 }
 
-func (s *ProcessingService) processPDFImageFile(ctx context.Context, patientID uuid.UUID, filename, filePath string, contentType string) error {
-	const operation = "processPDFImageFile"
-
-	// 1. Read File Data - BE-010
-	fileData, err := os.ReadFile(filePath) // Read the file from temporary storage
-	if err != nil {
-		return fmt.Errorf("reading file data: %w", err) // BE-010 - File Handling
+func (s *ProcessingService) processPDFImageFile(ctx context.Context, patientID uuid.UUID, filename, filePath string, contentType string, fileData []byte) error {
+	// 1. File Data - BE-010: the upload was buffered by ProcessDocument (the stored copy is encrypted)
+	// 2. Text extraction - BE-021: PDFs are read from their own text layer, and only pages without one are OCRed
+	var extraction *reportExtraction
+	var err error
+	if contentType == "application/pdf" {
+		extraction, err = s.extractPDFText(ctx, fileData)
+	} else {
+		extraction, err = s.ocrReportImage(ctx, fileData, contentType) // contentType helps determine image type - BE-021
 	}
-	// 2. OCR (if it's a scanned document or PDF) - BE-021
-	extractedText, _, err := s.ocrService.ExtractText(ctx, fileData, contentType) // contentType helps determine image type - BE-021 // Corrected line
 	if err != nil {
 		ocrErr := domain.NewErrOCRExtractionFailed(filename, err) // BE-021 - OCR Error Handling - Custom Error
 		ocrErr.SetLogger(s.logger)                                // Set logger for domain error
@@ -357,98 +719,285 @@ func (s *ProcessingService) processPDFImageFile(ctx context.Context, patientID u
 	}
 
 	// 3. Data Anonymization (of extracted text) - BE-055
-	anonymizedText := security.AnonymizeText(extractedText) // Implement this function (mask PII) - BE-055
+	deidentified, err := s.deidentifyText(ctx, patientID, extraction.text)
+	if err != nil {
+		return fmt.Errorf("de-identifying extracted text: %w", err)
+	}
+	anonymizedText := deidentified.Text
 
 	// 4. Create or Update Database Records - BE-024
-	_, err = s.patientRepository.GetPatient(ctx, patientID)
-	if err != nil {
-		if _, ok := err.(*domain.NotFoundError); ok {
-			newPatient := &models.Patient{
-				SessionID: patientID, // Using the pseudonym - BE-024
-			}
-			if err := s.patientRepository.CreatePatient(ctx, newPatient); err != nil {
-				return fmt.Errorf("create patient when processing pdf: %w", err) // BE-024 - DB Interaction
-			}
-		} else {
-			return fmt.Errorf("getting patient: %w", err) // BE-024 - DB Interaction
-		}
+	if err := s.ensurePatient(ctx, patientID); err != nil {
+		return fmt.Errorf("create patient when processing pdf: %w", err) // BE-024 - DB Interaction
 	}
 
 	report := &models.Report{ // BE-024
 		ID:         uuid.New(),
 		PatientID:  patientID,
 		Filename:   filename,
-		ReportType: s.determineReportType(filename, extractedText), // Implement a function to guess report type - BE-021
-		ReportText: anonymizedText,                                 // Store anonymized text *temporarily* - BE-055
-		Filepath:   filePath,                                       //Store file path to connect to the file. - BE-014
+		ReportType: s.DetermineReportType(filename, extraction.text), // Implement a function to guess report type - BE-021
+		ReportText: anonymizedText,                                   // Store anonymized text *temporarily* - BE-055
+		Filepath:   filePath,                                         //Store file path to connect to the file. - BE-014
 	}
 	if err := s.reportRepository.CreateReport(ctx, report); err != nil {
 		return fmt.Errorf("creating report in db: %w", err) // BE-024 - DB Interaction
 	}
+	for _, page := range extraction.pages {
+		page.ReportID = report.ID
+		if err := s.reportRepository.CreateReportPage(ctx, page); err != nil {
+			return fmt.Errorf("recording extraction of page %d: %w", page.PageNumber, err)
+		}
+	}
+
+	// 5. Call Gemini API for Analysis (Pathology, Information Extraction, etc.) and store the findings - BE-030, BE-048a
+	findings, err := s.AnalyzeReportText(ctx, patientID, report.ReportType, anonymizedText)
+	if err != nil {
+		return err
+	}
+	if flagged := flagLowConfidenceFindings(findings, extraction.lowConfidenceLines); flagged > 0 {
+		s.logger.Info("Findings taken from low-confidence OCR lines", zap.String("request_id", utils.GetRequestID(ctx)), zap.String("report_id", report.ID.String()), zap.Int("low_confidence_lines", len(extraction.lowConfidenceLines)), zap.Int("flagged_findings", flagged))
+	}
+	for _, finding := range findings {
+		finding.FileID = report.ID // Link to the Report
+		if err := s.reportRepository.CreateFinding(ctx, finding); err != nil {
+			return fmt.Errorf("creating %s finding: %w", finding.FindingType, err) // BE-048a - Store Findings
+		}
+	}
 
-	// 5. Call Gemini API for Analysis (Pathology, Information Extraction, etc.) - BE-030, BE-048a
-	if report.ReportType == "pathology" {
+	return nil
+}
+
+// ensurePatient creates the patient record of a session on its first upload.
+func (s *ProcessingService) ensurePatient(ctx context.Context, patientID uuid.UUID) error {
+	_, err := s.patientRepository.GetPatient(ctx, patientID)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*domain.NotFoundError); !ok {
+		return fmt.Errorf("getting patient: %w", err) // BE-024 - DB Interaction
+	}
+	newPatient := &models.Patient{
+		SessionID: patientID, // Using the pseudonym - BE-024
+	}
+	return s.patientRepository.CreatePatient(ctx, newPatient)
+}
+
+// reportExtraction is the text extracted from an uploaded report.
+type reportExtraction struct {
+	text               string
+	pages              []*models.ReportPage // PDFs: how the text of each page was obtained
+	lowConfidenceLines []string             // OCRed lines with a confidence below OCRLowConfidenceThreshold
+}
+
+// ocrReportImage OCRs a scanned report image.
+func (s *ProcessingService) ocrReportImage(ctx context.Context, fileData []byte, contentType string) (*reportExtraction, error) {
+	document, err := s.ocrService.ExtractDocument(ctx, fileData, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return &reportExtraction{text: document.Text(), lowConfidenceLines: s.lowConfidenceLines(document.Pages...)}, nil
+}
+
+// extractPDFText returns the text of a PDF and how each page's text was obtained. A page's own text layer is used when
+// it has enough text (PDFTextLayerMinChars), or when the page has no images and every character could be read, as
+// OCR would find nothing more; the other pages (scans, unreadable fonts) are OCRed one by one. Documents whose text
+// layer cannot be read at all, such as encrypted ones, are OCRed whole.
+func (s *ProcessingService) extractPDFText(ctx context.Context, fileData []byte) (*reportExtraction, error) {
+	const operation = "ProcessingService.extractPDFText"
+	requestID := utils.GetRequestID(ctx)
+
+	pdfPages, err := pdftext.Extract(fileData)
+	pageOCR, canOCRPages := s.ocrService.(ocr.PageExtractor)
+	if err != nil {
+		s.logger.Warn("PDF text layer unreadable, falling back to OCR of the whole document", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return s.ocrWholePDF(ctx, fileData)
+	}
+
+	extraction := &reportExtraction{pages: make([]*models.ReportPage, 0, len(pdfPages))}
+	texts := make([]string, 0, len(pdfPages))
+	methodCounts := map[string]int{}
+	for i := range pdfPages {
+		page := &pdfPages[i]
+		record := &models.ReportPage{PageNumber: page.Number}
+		var text string
+		switch {
+		case page.HasText(s.config.PDFTextLayerMinChars),
+			page.Err == nil && page.Images == 0 && page.Unmapped == 0 && page.Text != "":
+			record.ExtractionMethod, text = models.ExtractionMethodTextLayer, page.Text
+		case page.Err == nil && page.Images == 0 && page.Unmapped == 0:
+			record.ExtractionMethod = models.ExtractionMethodNone
+		default:
+			if !canOCRPages {
+				return s.ocrWholePDF(ctx, fileData)
+			}
+			if page.Err != nil {
+				s.logger.Warn("PDF page content unreadable, OCRing the page", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page.Number), zap.Error(page.Err))
+			}
+			recognized, err := pageOCR.ExtractPDFPage(ctx, fileData, page.Number)
+			if err != nil {
+				return nil, err
+			}
+			record.ExtractionMethod, record.Confidence, text = models.ExtractionMethodOCR, recognized.Confidence, recognized.Text()
+			extraction.lowConfidenceLines = append(extraction.lowConfidenceLines, s.lowConfidenceLines(*recognized)...)
+		}
+		record.CharCount = utf8.RuneCountInString(text)
+		extraction.pages = append(extraction.pages, record)
+		methodCounts[record.ExtractionMethod]++
+		if text = strings.TrimSpace(text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	extraction.text = strings.Join(texts, "\n\n")
+
+	s.logger.Info("Extracted PDF text", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("pages", len(extraction.pages)), zap.Int("text_layer_pages", methodCounts[models.ExtractionMethodTextLayer]), zap.Int("ocr_pages", methodCounts[models.ExtractionMethodOCR]), zap.Int("blank_pages", methodCounts[models.ExtractionMethodNone]), zap.Int("low_confidence_lines", len(extraction.lowConfidenceLines)))
+	return extraction, nil
+}
+
+// ocrWholePDF OCRs every page of a PDF in one call.
+func (s *ProcessingService) ocrWholePDF(ctx context.Context, fileData []byte) (*reportExtraction, error) {
+	document, err := s.ocrService.ExtractDocument(ctx, fileData, "application/pdf")
+	if err != nil {
+		return nil, err
+	}
+	extraction := &reportExtraction{text: document.Text(), lowConfidenceLines: s.lowConfidenceLines(document.Pages...)}
+	for i := range document.Pages {
+		page := &document.Pages[i]
+		extraction.pages = append(extraction.pages, &models.ReportPage{
+			PageNumber:       page.Number,
+			ExtractionMethod: models.ExtractionMethodOCR,
+			Confidence:       page.Confidence,
+			CharCount:        utf8.RuneCountInString(page.Text()),
+		})
+	}
+	return extraction, nil
+}
+
+// lowConfidenceLines returns the text of the OCRed lines whose confidence is below OCRLowConfidenceThreshold.
+func (s *ProcessingService) lowConfidenceLines(pages ...ocr.Page) []string {
+	document := ocr.Document{Pages: pages}
+	var lines []string
+	for _, line := range document.LowConfidenceLines(s.config.OCRLowConfidenceThreshold) {
+		lines = append(lines, line.Text)
+	}
+	return lines
+}
+
+// flagLowConfidenceFindings marks the findings taken from a low-confidence OCR line and returns how many it marked. A
+// finding is taken from a line if its quote of the report (or, without one, its description) contains at least half
+// of the line's words; lines of one word must match it.
+func flagLowConfidenceFindings(findings []*models.Finding, lines []string) int {
+	lineWords := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		if words := matchWords(line); len(words) > 0 {
+			lineWords = append(lineWords, words)
+		}
+	}
+	flagged := 0
+	for _, finding := range findings {
+		source := finding.Quote
+		if source == "" {
+			source = finding.Description
+		}
+		sourceWords := map[string]bool{}
+		for _, word := range matchWords(source) {
+			sourceWords[word] = true
+		}
+		for _, words := range lineWords {
+			shared := 0
+			for _, word := range words {
+				if sourceWords[word] {
+					shared++
+				}
+			}
+			if shared*2 >= len(words) && shared >= min(2, len(words)) {
+				finding.LowConfidence = true
+				flagged++
+				break
+			}
+		}
+	}
+	return flagged
+}
+
+// matchWords splits text into lower-case words for matching findings to lines, dropping single letters.
+func matchWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	words := fields[:0]
+	for _, field := range fields {
+		if utf8.RuneCountInString(field) > 1 || unicode.IsDigit([]rune(field)[0]) {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// AnalyzeReportText sends the de-identified text of a report to Gemini and returns the findings it extracted, without
+// storing them: pathology reports are analyzed with the pathology prompt, every other type with the information
+// extraction prompt (BE-030, BE-048a). The findings are not linked to a report yet.
+func (s *ProcessingService) AnalyzeReportText(ctx context.Context, sessionID uuid.UUID, reportType, text string) ([]*models.Finding, error) {
+	var findings []*models.Finding
+	if reportType == "pathology" {
+		prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptPathologyReportAnalysis, sessionID, nil) // Managed prompt - BE-028
+		if err != nil {
+			return nil, fmt.Errorf("rendering pathology report prompt: %w", err)
+		}
 		geminiInput := &geminiModels.PathologyReportAnalysisInput{
-			ReportText: anonymizedText,
-			Prompt:     "Extract key findings from this pathology report...", // Use a managed prompt - BE-028
+			ReportText: text,
+			Prompt:     prompt.Text,
 		}
-		geminiOutput, err := s.geminiClient.AnalyzePathologyReport(ctx, geminiInput) // BE-030 - Gemini API Call
+		geminiOutput, err := s.geminiClient.AnalyzePathologyReport(gemini.WithPrompt(ctx, prompt), geminiInput) // BE-030 - Gemini API Call
+		s.experiments.RecordCall(ctx, prompt, err)
 		if err != nil {
-			return fmt.Errorf("calling Gemini API for pathology report analysis: %w", err) // BE-030 - Gemini API Error Handling
+			return nil, fmt.Errorf("calling Gemini API for pathology report analysis: %w", err) // BE-030 - Gemini API Error Handling
 		}
-		// Process Gemini Output (store key findings) - BE-048a
+		// Process Gemini Output (key findings) - BE-048a
 		for _, finding := range geminiOutput.Findings {
-			dbFinding := &models.Finding{
+			findings = append(findings, &models.Finding{
 				FindingID:   uuid.New(),
-				FileID:      report.ID, // Link to the Report
 				FindingType: "pathology",
 				Description: finding.Description,
+				Quote:       finding.Quote,
 				// ... other details ...
-			}
-			if err := s.reportRepository.CreateFinding(ctx, dbFinding); err != nil {
-				return fmt.Errorf("creating pathology finding: %w", err) // BE-048a - Store Findings
-			}
+			})
 		}
 
 	} else { // Assume radiology report (or other) - BE-030, BE-048a
+		prompt, err := s.prompts.RenderPrompt(ctx, gemini.PromptInformationExtraction, sessionID, map[string]any{"ReportType": reportType}) // Managed prompt - BE-028
+		if err != nil {
+			return nil, fmt.Errorf("rendering information extraction prompt: %w", err)
+		}
 		geminiInput := &geminiModels.InformationExtractionInput{ // You'll need to define this model
-			ReportText: anonymizedText,
-			Prompt:     "Extract symptoms, signs, and investigations mentioned in this report...", // Use managed prompt - BE-028
+			ReportText: text,
+			Prompt:     prompt.Text,
 		}
-		geminiOutput, err := s.geminiClient.ExtractInformation(ctx, geminiInput) // and this method - BE-030 - Gemini API Call
+		geminiOutput, err := s.geminiClient.ExtractInformation(gemini.WithPrompt(ctx, prompt), geminiInput) // and this method - BE-030 - Gemini API Call
+		s.experiments.RecordCall(ctx, prompt, err)
 		if err != nil {
-			return fmt.Errorf("calling Gemini API for information extraction: %w", err) // BE-030 - Gemini API Error Handling
+			return nil, fmt.Errorf("calling Gemini API for information extraction: %w", err) // BE-030 - Gemini API Error Handling
 		}
 
-		// Process Gemini Output (store findings) - BE-048a
+		// Process Gemini Output (extracted findings) - BE-048a
 		for _, finding := range geminiOutput.Findings { // Assuming a Findings field in the output
-			dbFinding := &models.Finding{
+			findings = append(findings, &models.Finding{
 				FindingID:   uuid.New(),
-				FileID:      report.ID, // Link to Report
 				FindingType: finding.Type,
 				Description: finding.Description,
+				Quote:       finding.Quote,
 				//... other details...
-			}
-			if err := s.reportRepository.CreateFinding(ctx, dbFinding); err != nil {
-				return fmt.Errorf("creating extracted finding %w", err) // BE-048a - Store Extracted Info
-			}
+			})
 		}
 	}
 
-	return nil
+	return findings, nil
 }
 
 func (s *ProcessingService) DeleteAllPatientData(ctx context.Context, patientID uuid.UUID) error {
 	//  1. Get all file paths associated with the patient (from the database).
 	//  2. Delete files from storage (using s.fileStorage.Delete).
-	//  3. Delete database records (Patient, Study, Image, Nodule, Report, AuditLog entries).
-	//    * Use transactions to ensure all deletions are successful or rolled back.
+	//  3. Delete database records (Patient, Study, Image, Nodule, Report, AuditLog entries) and cached model responses.
+	//    * The repositories each run their own statements, so the deletions are not one transaction: every step
+	//      tolerates data already deleted, and a failed deletion is completed by calling this again.
 	//   * Use the repository interfaces for all database interactions.
-	tx, err := s.reportRepository.BeginTx(ctx)
-	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
+	s.pendingSeries.discard(patientID) // Drop any parsed instances still awaiting series analysis
 	images, err := s.imageRepository.GetImageByPatientID(ctx, patientID)
 	if err != nil {
 		return fmt.Errorf("getting image filepaths: %w", err)
@@ -486,9 +1035,17 @@ func (s *ProcessingService) DeleteAllPatientData(ctx context.Context, patientID
 	if err := s.patientRepository.DeletePatient(ctx, patientID); err != nil {
 		return fmt.Errorf("deleting patient from db: %w", err)
 	}
-	err = tx.Commit(ctx)
-	if err != nil {
-		return fmt.Errorf("committing transaction: %w", err)
+
+	// Model responses cached for the session are derived from its documents
+	if s.responseCache != nil {
+		if err := s.responseCache.DeleteSessionResponses(ctx, patientID); err != nil {
+			return fmt.Errorf("deleting cached model responses: %w", err)
+		}
+	}
+
+	// Destroying the session secrets makes the pseudonymous UIDs unlinkable to the originals and discards the date offset
+	if err := s.sessionSecrets.Delete(ctx, patientID); err != nil {
+		return fmt.Errorf("deleting session secrets: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,255 @@
+// internal/domain/services/processing_service_test.go
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/gemini"
+	"github.com/stackvity/lung-server/internal/ocr"
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// noduleStore records the nodules stored through it; the other NoduleRepository methods are not used.
+type noduleStore struct {
+	interfaces.NoduleRepository
+	mu      sync.Mutex
+	nodules []*models.Nodule
+}
+
+func (r *noduleStore) CreateNodule(_ context.Context, nodule *models.Nodule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nodules = append(r.nodules, nodule)
+	return nil
+}
+
+// promptCallLog records the prompt calls of a PromptExperimentService.
+type promptCallLog struct {
+	interfaces.PromptExperimentRepository
+	calls []*models.PromptCall
+}
+
+func (r *promptCallLog) RecordPromptCall(_ context.Context, call *models.PromptCall) error {
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+// blankOCR finds no text in any image, so rendered slices are never redacted.
+type blankOCR struct{ ocr.OCRService }
+
+func (blankOCR) ExtractText(context.Context, []byte, string) (string, float64, error) {
+	return "", 0, nil
+}
+
+// registryPrompts renders every prompt as one version of the prompt registry.
+type registryPrompts struct{ versionID uuid.UUID }
+
+func (p registryPrompts) GetPrompt(_ context.Context, promptID string) (string, error) {
+	return "Find the nodules in these slices.", nil
+}
+
+func (p registryPrompts) RenderPrompt(_ context.Context, promptID string, sessionID uuid.UUID, _ map[string]any) (*gemini.RenderedPrompt, error) {
+	return &gemini.RenderedPrompt{ID: promptID, Version: 2, VersionID: p.versionID, Text: "Find the nodules in these slices.", SessionID: sessionID}, nil
+}
+
+// ctInstance is slice z of a 16×16 axial CT series, 2.5 mm apart.
+func ctInstance(z int) *dicom.DataSet {
+	ds := &dicom.DataSet{
+		StudyInstanceUID:  "2.25.100",
+		SeriesInstanceUID: "2.25.100.1",
+		SOPInstanceUID:    fmt.Sprintf("2.25.100.1.%d", z+1),
+		TransferSyntaxUID: dicom.ExplicitVRLittleEndian,
+	}
+	us := func(tag dicom.Tag, v int) *dicom.Element {
+		value := make([]byte, 2)
+		binary.LittleEndian.PutUint16(value, uint16(v))
+		return &dicom.Element{Tag: tag, VR: dicom.VRUS, Value: value}
+	}
+	ds.Put(dicom.NewStringElement(dicom.TagStudyInstanceUID, dicom.VRUI, ds.StudyInstanceUID))
+	ds.Put(dicom.NewStringElement(dicom.TagSeriesInstanceUID, dicom.VRUI, ds.SeriesInstanceUID))
+	ds.Put(dicom.NewStringElement(dicom.TagSOPInstanceUID, dicom.VRUI, ds.SOPInstanceUID))
+	ds.Put(dicom.NewStringElement(dicom.TagModality, dicom.VRCS, "CT"))
+	ds.Put(dicom.NewStringElement(dicom.TagInstanceNumber, dicom.VRIS, fmt.Sprint(z+1)))
+	ds.Put(dicom.NewStringElement(dicom.TagImagePositionPatient, dicom.VRDS, "0", "0", fmt.Sprintf("%.1f", float64(z)*2.5)))
+	ds.Put(dicom.NewStringElement(dicom.TagImageOrientationPatient, dicom.VRDS, "1", "0", "0", "0", "1", "0"))
+	ds.Put(dicom.NewStringElement(dicom.TagPixelSpacing, dicom.VRDS, "0.7", "0.7"))
+	ds.Put(dicom.NewStringElement(dicom.TagSliceThickness, dicom.VRDS, "2.5"))
+	ds.Put(us(dicom.TagSamplesPerPixel, 1))
+	ds.Put(dicom.NewStringElement(dicom.TagPhotometricInterpretation, dicom.VRCS, "MONOCHROME2"))
+	ds.Put(us(dicom.TagRows, 16))
+	ds.Put(us(dicom.TagColumns, 16))
+	ds.Put(us(dicom.TagBitsAllocated, 16))
+	ds.Put(us(dicom.TagBitsStored, 12))
+	ds.Put(us(dicom.TagHighBit, 11))
+	ds.Put(us(dicom.TagPixelRepresentation, 0))
+	ds.Put(dicom.NewStringElement(dicom.TagRescaleSlope, dicom.VRDS, "1"))
+	ds.Put(dicom.NewStringElement(dicom.TagRescaleIntercept, dicom.VRDS, "-1024"))
+	pixels := make([]byte, 16*16*2)
+	for i := 0; i < 16*16; i++ {
+		binary.LittleEndian.PutUint16(pixels[i*2:], uint16((i*8+z*40)%4096))
+	}
+	ds.Put(&dicom.Element{Tag: dicom.TagPixelData, VR: dicom.VROW, Value: pixels})
+	return ds
+}
+
+// newSeriesTestService returns a ProcessingService answering nodule detection from fixture with the fake model client.
+func newSeriesTestService(t *testing.T, fixture string, nodules *noduleStore, calls *promptCallLog, versionID uuid.UUID) *ProcessingService {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "DetectNodules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "DetectNodules", "default.json"), []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{FakeLLMFixtures: dir, FakeLLMMode: gemini.FakeModeReplay, ImageWindowPreset: "lung", SeriesMaxVoxels: 16 * 16 * 5}
+	logger := zap.NewNop()
+	client, err := gemini.NewFakeClient(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewFakeClient: %v", err)
+	}
+	experiments := NewPromptExperimentService(calls, nil, nil, logger)
+	return NewProcessingService(nil, blankOCR{}, client, nil, registryPrompts{versionID: versionID}, experiments, nil,
+		nil, nil, nil, nodules, nil, nil, nil, nil, nil, nil, cfg, logger)
+}
+
+func TestAnalyzeSeriesStoresNodules(t *testing.T) {
+	const fixture = `{"output": {"nodules": [
+		{"location": "Right Upper Lobe", "size": 12.5, "shape": "irregular", "confidence": 0.9},
+		{"location": "Left Lower Lobe", "size": 6, "shape": "round", "confidence": 0.7}
+	]}}`
+	nodules, calls := &noduleStore{}, &promptCallLog{}
+	versionID := uuid.New()
+	s := newSeriesTestService(t, fixture, nodules, calls, versionID)
+
+	patientID := uuid.New()
+	imageIDs := map[uuid.UUID]bool{}
+	for z := 0; z < 5; z++ {
+		imageID := uuid.New()
+		imageIDs[imageID] = true
+		if err := s.pendingSeries.add(patientID, newPendingInstance(ctInstance(z), imageID, nil)); err != nil {
+			t.Fatalf("queueing slice %d: %v", z, err)
+		}
+	}
+
+	results, err := s.AnalyzeSeries(context.Background(), patientID)
+	if err != nil {
+		t.Fatalf("AnalyzeSeries: %v", err)
+	}
+	if len(results) != 1 || results[0].SliceCount != 5 || results[0].NoduleCount != 2 {
+		t.Fatalf("AnalyzeSeries = %+v, want one series of 5 slices with 2 nodules", results)
+	}
+
+	if len(nodules.nodules) != 2 {
+		t.Fatalf("stored %d nodules, want 2", len(nodules.nodules))
+	}
+	for i, want := range []struct {
+		location string
+		size     float64
+	}{{"Right Upper Lobe", 12.5}, {"Left Lower Lobe", 6}} {
+		nodule := nodules.nodules[i]
+		if nodule.Location != want.location || nodule.Size != want.size {
+			t.Errorf("nodule %d is %s, %v mm; want %s, %v mm", i, nodule.Location, nodule.Size, want.location, want.size)
+		}
+		if !imageIDs[nodule.ImageID] {
+			t.Errorf("nodule %d is linked to image %s, which is not a slice of the series", i, nodule.ImageID)
+		}
+		if nodule.PromptVersionID != versionID {
+			t.Errorf("nodule %d has prompt version %s, want %s", i, nodule.PromptVersionID, versionID)
+		}
+	}
+
+	if len(calls.calls) != 1 || calls.calls[0].PromptVersionID != versionID || calls.calls[0].Outcome != models.PromptCallSuccess {
+		t.Errorf("recorded prompt calls %+v, want one successful call of version %s", calls.calls, versionID)
+	}
+	if pending, _ := s.pendingSeries.take(patientID); len(pending) != 0 {
+		t.Errorf("%d instances still pending after a successful analysis", len(pending))
+	}
+	if s.pendingSeries.bytes != 0 {
+		t.Errorf("%d bytes still held after a successful analysis", s.pendingSeries.bytes)
+	}
+}
+
+func TestAnalyzeSeriesRequeuesFailedSeries(t *testing.T) {
+	const fixture = `{"error": {"status": 400, "message": "invalid request"}, "fail_times": 1, "output": {"nodules": [
+		{"location": "Right Upper Lobe", "size": 12.5, "shape": "irregular", "confidence": 0.9}
+	]}}`
+	nodules := &noduleStore{}
+	s := newSeriesTestService(t, fixture, nodules, &promptCallLog{}, uuid.New())
+
+	patientID := uuid.New()
+	for z := 0; z < 3; z++ {
+		if err := s.pendingSeries.add(patientID, newPendingInstance(ctInstance(z), uuid.New(), nil)); err != nil {
+			t.Fatalf("queueing slice %d: %v", z, err)
+		}
+	}
+
+	if _, err := s.AnalyzeSeries(context.Background(), patientID); err == nil {
+		t.Fatal("AnalyzeSeries succeeded although the model call failed")
+	}
+	if len(nodules.nodules) != 0 {
+		t.Fatalf("stored %d nodules from a failed analysis", len(nodules.nodules))
+	}
+
+	results, err := s.AnalyzeSeries(context.Background(), patientID)
+	if err != nil {
+		t.Fatalf("AnalyzeSeries after the failure: %v", err)
+	}
+	if len(results) != 1 || results[0].SliceCount != 3 || len(nodules.nodules) != 1 {
+		t.Fatalf("AnalyzeSeries = %+v with %d nodules stored, want the 3 requeued slices analyzed", results, len(nodules.nodules))
+	}
+}
+
+func TestAnalyzeSeriesRefusesLargeSeries(t *testing.T) {
+	nodules := &noduleStore{}
+	s := newSeriesTestService(t, `{"output": {"nodules": []}}`, nodules, &promptCallLog{}, uuid.New())
+
+	patientID := uuid.New()
+	for z := 0; z < 6; z++ {
+		instance := newPendingInstance(ctInstance(z), uuid.New(), nil)
+		if z == 3 {
+			instance.dataSet.Put(&dicom.Element{Tag: dicom.TagPixelData, VR: dicom.VROW, Value: []byte{0}}) // Would fail to decode
+		}
+		if err := s.pendingSeries.add(patientID, instance); err != nil {
+			t.Fatalf("queueing slice %d: %v", z, err)
+		}
+	}
+
+	_, err := s.AnalyzeSeries(context.Background(), patientID)
+	if !errors.Is(err, dicom.ErrSeriesTooLarge) {
+		t.Fatalf("AnalyzeSeries error %v, want %v before any slice is decoded", err, dicom.ErrSeriesTooLarge)
+	}
+}
+
+func TestValidateFileDICOMSizeLimit(t *testing.T) {
+	// An Explicit VR object whose Pixel Data fills it up to size bytes.
+	object := func(size int) []byte {
+		data := append(make([]byte, 128), "DICM"...)
+		data = append(data, 0x02, 0x00, 0x10, 0x00, 'U', 'I', 20, 0)
+		data = append(data, dicom.ExplicitVRLittleEndian+"\x00"...)
+		data = append(data, 0xE0, 0x7F, 0x10, 0x00, 'O', 'B', 0, 0)
+		length := size - len(data) - 4
+		data = binary.LittleEndian.AppendUint32(data, uint32(length))
+		return append(data, make([]byte, length)...)
+	}
+	s := &ProcessingService{}
+	if err := s.validateFile("ct.dcm", "application/dicom", object(int(maxDICOMFileSize))); err != nil {
+		t.Errorf("validateFile of a %d-byte object: %v", maxDICOMFileSize, err)
+	}
+	if err := s.validateFile("ct.dcm", "application/dicom", object(int(maxDICOMFileSize)+2)); !errors.Is(err, dicom.ErrFileTooLarge) {
+		t.Errorf("validateFile of a %d-byte object: error %v, want %v", maxDICOMFileSize+2, err, dicom.ErrFileTooLarge)
+	}
+}
@@ -0,0 +1,316 @@
+// internal/domain/services/prompt_experiment_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/gemini"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// defaultTreatmentPercent is the share of sessions assigned to the treatment when an experiment does not set one.
+const defaultTreatmentPercent = 50
+
+// defaultReportWindow is the period a prompt report covers when no start time is given.
+const defaultReportWindow = 30 * 24 * time.Hour
+
+// feedbackPrompts maps each kind of rated output to the prompt it is generated with.
+var feedbackPrompts = map[string]string{
+	models.FeedbackTargetDiagnosis: gemini.PromptPreliminaryDiagnosis,
+	models.FeedbackTargetStage:     gemini.PromptStagingInformation,
+	models.FeedbackTargetNodule:    gemini.PromptNoduleDetection,
+}
+
+// PromptVersionMetrics are the metrics of one prompt version over a report's period.
+type PromptVersionMetrics struct {
+	PromptVersionID  uuid.UUID `json:"prompt_version_id"` // uuid.Nil for the prompt file
+	Version          int       `json:"version"`           // 0 for the prompt file
+	Arm              string    `json:"arm,omitempty"`     // Experiment reports only
+	Calls            int       `json:"calls"`
+	ParseFailures    int       `json:"parse_failures"`
+	ParseFailureRate float64   `json:"parse_failure_rate"` // ParseFailures / Calls; 0 without calls
+	Errors           int       `json:"errors"`
+	Ratings          int       `json:"ratings"`        // Clinician ratings of the version's outputs
+	AverageRating    float64   `json:"average_rating"` // 1 to 5; 0 without ratings
+}
+
+// PromptReport compares the versions of a prompt on parse failures, errors and clinician feedback.
+type PromptReport struct {
+	Name       string                   `json:"name"`
+	Experiment *models.PromptExperiment `json:"experiment,omitempty"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Versions   []*PromptVersionMetrics  `json:"versions"`
+}
+
+// PromptExperimentService runs A/B experiments between the active version of a prompt and an approved candidate,
+// records the outcome of every model call made with a managed prompt and the clinician feedback on stored outputs,
+// and reports on both per version.
+type PromptExperimentService struct {
+	repository interfaces.PromptExperimentRepository
+	prompts    interfaces.PromptRepository
+	cache      *gemini.DBPromptManager // Its cached experiments are invalidated when they start or stop
+	logger     *zap.Logger
+}
+
+// NewPromptExperimentService creates a new PromptExperimentService with dependencies injected.
+func NewPromptExperimentService(repository interfaces.PromptExperimentRepository, prompts interfaces.PromptRepository, cache *gemini.DBPromptManager, logger *zap.Logger) *PromptExperimentService {
+	return &PromptExperimentService{
+		repository: repository,
+		prompts:    prompts,
+		cache:      cache,
+		logger:     logger.Named("PromptExperimentService"),
+	}
+}
+
+// StartExperiment starts an experiment between the active version of a prompt (control) and an approved version
+// (treatment), assigning treatmentPercent of the sessions (defaultTreatmentPercent when 0) to the treatment.
+func (s *PromptExperimentService) StartExperiment(ctx context.Context, name string, treatmentVersion, treatmentPercent int, actor string) (*models.PromptExperiment, error) {
+	const operation = "PromptExperimentService.StartExperiment"
+	requestID := utils.GetRequestID(ctx)
+
+	// 1. Validate the arms
+	if !gemini.IsManagedPrompt(name) {
+		return nil, domain.NewNotFoundError("managed prompt", name)
+	}
+	if treatmentPercent == 0 {
+		treatmentPercent = defaultTreatmentPercent
+	}
+	if treatmentPercent < 1 || treatmentPercent > 99 {
+		return nil, domain.NewValidationError("treatment_percent must be between 1 and 99")
+	}
+	control, err := s.prompts.GetActivePrompt(ctx, name)
+	if err != nil {
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, domain.NewValidationError(fmt.Sprintf("prompt %s has no active version to compare against", name))
+		}
+		return nil, fmt.Errorf("getting active prompt version: %w", err)
+	}
+	versions, err := s.prompts.ListPromptVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("listing prompt versions: %w", err)
+	}
+	var treatment *models.Prompt
+	for _, v := range versions {
+		if v.Version == treatmentVersion {
+			treatment = v
+		}
+	}
+	if treatment == nil {
+		return nil, domain.NewNotFoundError("prompt "+name+" version", fmt.Sprint(treatmentVersion))
+	}
+	if treatment.Status != models.PromptStatusApproved {
+		return nil, domain.NewInvalidTransitionError(promptResource(treatment), treatment.Status, "start an experiment with")
+	}
+
+	// 2. Store; the repository rejects a second running experiment of the prompt
+	experiment := &models.PromptExperiment{
+		PromptName:        name,
+		ControlPromptID:   control.ID,
+		TreatmentPromptID: treatment.ID,
+		TreatmentPercent:  treatmentPercent,
+		StartedBy:         actor,
+	}
+	if err := s.repository.CreateExperiment(ctx, experiment); err != nil {
+		return nil, err
+	}
+	s.cache.Invalidate(name)
+
+	s.logger.Info("Prompt experiment started", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name), zap.String("experiment_id", experiment.ID.String()), zap.Int("control_version", control.Version), zap.Int("treatment_version", treatment.Version), zap.Int("treatment_percent", treatmentPercent), zap.String("actor", actor))
+	return experiment, nil
+}
+
+// StopExperiment stops a running experiment of a prompt; every session gets the active version again.
+func (s *PromptExperimentService) StopExperiment(ctx context.Context, name string, experimentID uuid.UUID, actor, reason string) (*models.PromptExperiment, error) {
+	const operation = "PromptExperimentService.StopExperiment"
+
+	if _, err := s.experiment(ctx, name, experimentID); err != nil {
+		return nil, err
+	}
+	experiment, err := s.repository.StopExperiment(ctx, experimentID, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Invalidate(name)
+
+	s.logger.Info("Prompt experiment stopped", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.String("experiment_id", experimentID.String()), zap.String("actor", actor))
+	return experiment, nil
+}
+
+// StopRunningExperiment stops the running experiment of a prompt, if any. It is called when the prompt's active
+// version changes, as the experiment's control would no longer be the version other sessions get.
+func (s *PromptExperimentService) StopRunningExperiment(ctx context.Context, name, actor, reason string) error {
+	running, err := s.repository.GetRunningPromptExperiment(ctx, name)
+	if err != nil {
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return err
+	}
+	_, err = s.StopExperiment(ctx, name, running.ID, actor, reason)
+	return err
+}
+
+// ListExperiments returns every experiment of a prompt, newest first.
+func (s *PromptExperimentService) ListExperiments(ctx context.Context, name string) ([]*models.PromptExperiment, error) {
+	return s.repository.ListExperiments(ctx, name)
+}
+
+// RecordCall records the outcome of a model call made with prompt: a parse failure when the model's answer did not
+// match the prompt's output format, an error when the call failed otherwise. Recording is best effort; a failure is
+// logged and does not fail the call.
+func (s *PromptExperimentService) RecordCall(ctx context.Context, prompt *gemini.RenderedPrompt, callErr error) {
+	const operation = "PromptExperimentService.RecordCall"
+
+	outcome := models.PromptCallSuccess
+	var parseErr *gemini.ErrGeminiResponseParsingFailed
+	switch {
+	case errors.As(callErr, &parseErr):
+		outcome = models.PromptCallParseFailure
+	case callErr != nil:
+		outcome = models.PromptCallError
+	}
+	call := &models.PromptCall{
+		PromptName:      prompt.ID,
+		PromptVersionID: prompt.VersionID,
+		ExperimentID:    prompt.ExperimentID,
+		Arm:             prompt.Arm,
+		Outcome:         outcome,
+	}
+	if err := s.repository.RecordPromptCall(ctx, call); err != nil {
+		s.logger.Warn("Failed to record prompt call", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", prompt.ID), zap.String("outcome", outcome), zap.Error(err))
+	}
+}
+
+// SubmitFeedback stores a clinician's rating of a stored diagnosis, stage or nodule, attributed to the prompt version
+// the output was generated with. The feedback's prompt fields, ID and timestamp are set.
+func (s *PromptExperimentService) SubmitFeedback(ctx context.Context, feedback *models.PromptFeedback) error {
+	const operation = "PromptExperimentService.SubmitFeedback"
+
+	name, ok := feedbackPrompts[feedback.TargetType]
+	if !ok {
+		return domain.NewValidationError("target_type must be one of diagnosis, stage, nodule")
+	}
+	if feedback.Rating < 1 || feedback.Rating > 5 {
+		return domain.NewValidationError("rating must be between 1 and 5")
+	}
+	versionID, err := s.repository.GetOutputPromptVersion(ctx, feedback.TargetType, feedback.TargetID)
+	if err != nil {
+		return err
+	}
+	feedback.PromptName, feedback.PromptVersionID = name, versionID
+	if err := s.repository.CreatePromptFeedback(ctx, feedback); err != nil {
+		return err
+	}
+
+	s.logger.Info("Prompt feedback recorded", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.String("target_type", feedback.TargetType), zap.String("reviewer", feedback.Reviewer), zap.Int("rating", feedback.Rating))
+	return nil
+}
+
+// Report compares every version of a prompt used in [from, to). A zero to is now, a zero from is defaultReportWindow
+// before to.
+func (s *PromptExperimentService) Report(ctx context.Context, name string, from, to time.Time) (*PromptReport, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultReportWindow)
+	}
+	if !from.Before(to) {
+		return nil, domain.NewValidationError("from must be before to")
+	}
+	return s.report(ctx, &PromptReport{Name: name, From: from, To: to}, uuid.Nil)
+}
+
+// ExperimentReport compares the two arms of an experiment: calls made within the experiment, and feedback given since
+// it started.
+func (s *PromptExperimentService) ExperimentReport(ctx context.Context, name string, experimentID uuid.UUID) (*PromptReport, error) {
+	experiment, err := s.experiment(ctx, name, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	report := &PromptReport{Name: name, Experiment: experiment, From: experiment.StartedAt, To: time.Now()}
+	return s.report(ctx, report, experimentID)
+}
+
+// report fills in the version metrics of report, from calls within experimentID unless it is uuid.Nil.
+func (s *PromptExperimentService) report(ctx context.Context, report *PromptReport, experimentID uuid.UUID) (*PromptReport, error) {
+	// 1. Version numbers and, for experiments, arms
+	versions, err := s.prompts.ListPromptVersions(ctx, report.Name)
+	if err != nil {
+		return nil, fmt.Errorf("listing prompt versions: %w", err)
+	}
+	numbers := make(map[uuid.UUID]int, len(versions))
+	for _, v := range versions {
+		numbers[v.ID] = v.Version
+	}
+	metrics := make(map[uuid.UUID]*PromptVersionMetrics)
+	metricsOf := func(versionID uuid.UUID) *PromptVersionMetrics {
+		m, ok := metrics[versionID]
+		if !ok {
+			m = &PromptVersionMetrics{PromptVersionID: versionID, Version: numbers[versionID]}
+			metrics[versionID] = m
+		}
+		return m
+	}
+	if report.Experiment != nil {
+		metricsOf(report.Experiment.ControlPromptID).Arm = models.PromptArmControl
+		metricsOf(report.Experiment.TreatmentPromptID).Arm = models.PromptArmTreatment
+	}
+
+	// 2. Call outcomes
+	calls, err := s.repository.GetCallStats(ctx, report.Name, report.From, report.To, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range calls {
+		m := metricsOf(c.PromptVersionID)
+		m.Calls, m.ParseFailures, m.Errors = c.Calls, c.ParseFailures, c.Errors
+		if c.Calls > 0 {
+			m.ParseFailureRate = float64(c.ParseFailures) / float64(c.Calls)
+		}
+	}
+
+	// 3. Clinician feedback; experiments only compare their arms
+	feedback, err := s.repository.GetFeedbackStats(ctx, report.Name, report.From, report.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range feedback {
+		if _, isArm := metrics[f.PromptVersionID]; report.Experiment != nil && !isArm {
+			continue
+		}
+		m := metricsOf(f.PromptVersionID)
+		m.Ratings, m.AverageRating = f.Ratings, f.AverageRating
+	}
+
+	report.Versions = make([]*PromptVersionMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		report.Versions = append(report.Versions, m)
+	}
+	sort.Slice(report.Versions, func(i, j int) bool { return report.Versions[i].Version < report.Versions[j].Version })
+	return report, nil
+}
+
+// experiment returns an experiment of a prompt by its ID.
+func (s *PromptExperimentService) experiment(ctx context.Context, name string, experimentID uuid.UUID) (*models.PromptExperiment, error) {
+	experiment, err := s.repository.GetExperiment(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.PromptName != name {
+		return nil, domain.NewNotFoundError("prompt "+name+" experiment", experimentID.String())
+	}
+	return experiment, nil
+}
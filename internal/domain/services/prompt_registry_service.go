@@ -0,0 +1,392 @@
+// internal/domain/services/prompt_registry_service.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/gemini"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// PromptDraft is the editable content of a prompt version.
+type PromptDraft struct {
+	Template     string                  `json:"template"`
+	Variables    []gemini.PromptVariable `json:"variables"`
+	OutputFormat gemini.OutputFormat     `json:"output_format"`
+}
+
+// PromptVersionHistory is a prompt version with its lifecycle events.
+type PromptVersionHistory struct {
+	*models.Prompt
+	Events []*models.PromptEvent `json:"events"`
+}
+
+// PromptDiff is a line diff between two versions of a prompt. Each line starts with "  " (unchanged), "- " (only in
+// From) or "+ " (only in To); the template is followed by the variables and output format as indented JSON.
+type PromptDiff struct {
+	Name    string   `json:"name"`
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Changed bool     `json:"changed"`
+	Lines   []string `json:"lines"`
+}
+
+// PromptRegistryService manages versions of the managed prompts stored in the prompt registry.
+// A version is written as a draft by its author, submitted for review, approved (or sent back) by another admin and
+// then activated, which makes the previously active version inactive; a rollback reactivates an inactive version.
+// Only drafts can be edited, and every action is recorded as an event of the version it applies to.
+type PromptRegistryService struct {
+	repository  interfaces.PromptRepository
+	prompts     *gemini.DBPromptManager  // Its cached active versions are invalidated when they change
+	experiments *PromptExperimentService // Running experiments are stopped when the active version changes
+	logger      *zap.Logger
+}
+
+// NewPromptRegistryService creates a new PromptRegistryService with dependencies injected.
+func NewPromptRegistryService(repository interfaces.PromptRepository, prompts *gemini.DBPromptManager, experiments *PromptExperimentService, logger *zap.Logger) *PromptRegistryService {
+	return &PromptRegistryService{
+		repository:  repository,
+		prompts:     prompts,
+		experiments: experiments,
+		logger:      logger.Named("PromptRegistryService"),
+	}
+}
+
+// CreateDraft stores a new draft version of a prompt, numbered after its latest version.
+func (s *PromptRegistryService) CreateDraft(ctx context.Context, name, author string, draft PromptDraft) (*models.Prompt, error) {
+	const operation = "PromptRegistryService.CreateDraft"
+	requestID := utils.GetRequestID(ctx)
+
+	if !gemini.IsManagedPrompt(name) {
+		return nil, domain.NewNotFoundError("managed prompt", name)
+	}
+	prompt := &models.Prompt{Name: name, Author: author, Status: models.PromptStatusDraft}
+	if err := applyDraft(prompt, draft); err != nil {
+		return nil, err
+	}
+	version, err := s.repository.NextPromptVersion(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("numbering prompt version: %w", err)
+	}
+	prompt.Version = version
+
+	event := &models.PromptEvent{Action: "create", Actor: author, ToStatus: models.PromptStatusDraft}
+	if err := s.repository.CreatePrompt(ctx, prompt, event); err != nil {
+		s.logger.Error("Failed to create prompt draft", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name), zap.Error(err))
+		return nil, fmt.Errorf("creating prompt draft: %w", err)
+	}
+	s.logger.Info("Prompt draft created", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", name), zap.Int("version", prompt.Version), zap.String("author", author))
+	return prompt, nil
+}
+
+// UpdateDraft replaces the content of a draft. Only its author may edit it.
+func (s *PromptRegistryService) UpdateDraft(ctx context.Context, name string, version int, actor string, draft PromptDraft) (*models.Prompt, error) {
+	prompt, err := s.version(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if prompt.Status != models.PromptStatusDraft {
+		return nil, domain.NewInvalidTransitionError(promptResource(prompt), prompt.Status, "edit")
+	}
+	if actor != prompt.Author {
+		return nil, domain.NewForbiddenError(fmt.Sprintf("only the author (%s) can edit %s", prompt.Author, promptResource(prompt)))
+	}
+	if err := applyDraft(prompt, draft); err != nil {
+		return nil, err
+	}
+	if err := s.transition(ctx, prompt, "edit", actor, models.PromptStatusDraft, ""); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Submit submits a draft for review. Only its author may submit it.
+func (s *PromptRegistryService) Submit(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error) {
+	prompt, err := s.version(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if prompt.Status != models.PromptStatusDraft {
+		return nil, domain.NewInvalidTransitionError(promptResource(prompt), prompt.Status, "submit")
+	}
+	if actor != prompt.Author {
+		return nil, domain.NewForbiddenError(fmt.Sprintf("only the author (%s) can submit %s", prompt.Author, promptResource(prompt)))
+	}
+	prompt.ApprovalStatus = models.PromptApprovalPending
+	if err := s.transition(ctx, prompt, "submit", actor, models.PromptStatusPendingReview, comment); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Approve approves a version pending review. A version cannot be approved by its author.
+func (s *PromptRegistryService) Approve(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error) {
+	prompt, err := s.reviewable(ctx, name, version, actor, "approve")
+	if err != nil {
+		return nil, err
+	}
+	prompt.ApprovalStatus = models.PromptApprovalApproved
+	if err := s.transition(ctx, prompt, "approve", actor, models.PromptStatusApproved, comment); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Reject sends a version pending review back to its author as a draft. A version cannot be rejected by its author.
+func (s *PromptRegistryService) Reject(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error) {
+	prompt, err := s.reviewable(ctx, name, version, actor, "reject")
+	if err != nil {
+		return nil, err
+	}
+	prompt.ApprovalStatus = models.PromptApprovalRejected
+	if err := s.transition(ctx, prompt, "reject", actor, models.PromptStatusDraft, comment); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Activate makes an approved version the active version of its prompt, replacing the previously active one and
+// stopping the prompt's running experiment.
+func (s *PromptRegistryService) Activate(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error) {
+	prompt, err := s.version(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if prompt.Status != models.PromptStatusApproved {
+		return nil, domain.NewInvalidTransitionError(promptResource(prompt), prompt.Status, "activate")
+	}
+	if err := s.transition(ctx, prompt, "activate", actor, models.PromptStatusActive, comment); err != nil {
+		return nil, err
+	}
+	s.stopExperiment(ctx, prompt, actor)
+	s.prompts.Invalidate(name)
+	return prompt, nil
+}
+
+// Rollback reactivates a previously active version of a prompt: the given version, or the most recently deactivated
+// one when version is 0. The prompt's running experiment is stopped.
+func (s *PromptRegistryService) Rollback(ctx context.Context, name string, version int, actor, comment string) (*models.Prompt, error) {
+	const operation = "PromptRegistryService.Rollback"
+
+	var prompt *models.Prompt
+	if version != 0 {
+		var err error
+		if prompt, err = s.version(ctx, name, version); err != nil {
+			return nil, err
+		}
+	} else {
+		versions, err := s.repository.ListPromptVersions(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("listing prompt versions: %w", err)
+		}
+		// Deactivation is the last change made to an inactive version, so its updated_at is the deactivation time
+		for _, v := range versions {
+			if v.Status == models.PromptStatusInactive && (prompt == nil || v.UpdatedAt.After(prompt.UpdatedAt)) {
+				prompt = v
+			}
+		}
+		if prompt == nil {
+			return nil, domain.NewNotFoundError("previously active version of prompt", name)
+		}
+	}
+	if prompt.Status != models.PromptStatusInactive {
+		return nil, domain.NewInvalidTransitionError(promptResource(prompt), prompt.Status, "roll back to")
+	}
+	if err := s.transition(ctx, prompt, "rollback", actor, models.PromptStatusActive, comment); err != nil {
+		return nil, err
+	}
+	s.stopExperiment(ctx, prompt, actor)
+	s.prompts.Invalidate(name)
+	s.logger.Warn("Prompt rolled back", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", name), zap.Int("version", prompt.Version), zap.String("actor", actor))
+	return prompt, nil
+}
+
+// History returns every version of a prompt, newest first, with its lifecycle events.
+func (s *PromptRegistryService) History(ctx context.Context, name string) ([]*PromptVersionHistory, error) {
+	versions, err := s.repository.ListPromptVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("listing prompt versions: %w", err)
+	}
+	history := make([]*PromptVersionHistory, len(versions))
+	for i, v := range versions {
+		events, err := s.repository.ListPromptEvents(ctx, v.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing events of prompt version %d: %w", v.Version, err)
+		}
+		history[i] = &PromptVersionHistory{Prompt: v, Events: events}
+	}
+	return history, nil
+}
+
+// Diff compares two versions of a prompt line by line.
+func (s *PromptRegistryService) Diff(ctx context.Context, name string, from, to int) (*PromptDiff, error) {
+	fromPrompt, err := s.version(ctx, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toPrompt, err := s.version(ctx, name, to)
+	if err != nil {
+		return nil, err
+	}
+	diff := &PromptDiff{Name: name, From: from, To: to, Lines: diffLines(promptLines(fromPrompt), promptLines(toPrompt))}
+	for _, line := range diff.Lines {
+		if !strings.HasPrefix(line, "  ") {
+			diff.Changed = true
+			break
+		}
+	}
+	return diff, nil
+}
+
+// reviewable returns a version pending review that actor may review.
+func (s *PromptRegistryService) reviewable(ctx context.Context, name string, version int, actor, action string) (*models.Prompt, error) {
+	prompt, err := s.version(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if prompt.Status != models.PromptStatusPendingReview {
+		return nil, domain.NewInvalidTransitionError(promptResource(prompt), prompt.Status, action)
+	}
+	if actor == prompt.Author {
+		return nil, domain.NewForbiddenError(fmt.Sprintf("%s cannot be reviewed by its author", promptResource(prompt)))
+	}
+	return prompt, nil
+}
+
+// transition moves a version to status and records the action.
+func (s *PromptRegistryService) transition(ctx context.Context, prompt *models.Prompt, action, actor, status, comment string) error {
+	const operation = "PromptRegistryService.transition"
+	requestID := utils.GetRequestID(ctx)
+
+	event := &models.PromptEvent{Action: action, Actor: actor, FromStatus: prompt.Status, ToStatus: status, Comment: comment}
+	prompt.Status = status
+	if err := s.repository.UpdatePrompt(ctx, prompt, event); err != nil {
+		s.logger.Error("Prompt lifecycle action failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", prompt.Name), zap.Int("version", prompt.Version), zap.String("action", action), zap.Error(err))
+		return fmt.Errorf("%s %s: %w", action, promptResource(prompt), err)
+	}
+	s.logger.Info("Prompt lifecycle action recorded", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("prompt", prompt.Name), zap.Int("version", prompt.Version), zap.String("action", action), zap.String("actor", actor), zap.String("status", status))
+	return nil
+}
+
+// stopExperiment stops the running experiment of a prompt whose active version became prompt. The version change is
+// already stored, so a failure is logged rather than returned.
+func (s *PromptRegistryService) stopExperiment(ctx context.Context, prompt *models.Prompt, actor string) {
+	reason := fmt.Sprintf("version %d activated", prompt.Version)
+	if err := s.experiments.StopRunningExperiment(ctx, prompt.Name, actor, reason); err != nil {
+		s.logger.Error("Failed to stop the running prompt experiment", zap.String("operation", "PromptRegistryService.stopExperiment"), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("prompt", prompt.Name), zap.Error(err))
+	}
+}
+
+// version returns a version of a prompt by its number.
+func (s *PromptRegistryService) version(ctx context.Context, name string, number int) (*models.Prompt, error) {
+	versions, err := s.repository.ListPromptVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("listing prompt versions: %w", err)
+	}
+	for _, v := range versions {
+		if v.Version == number {
+			return v, nil
+		}
+	}
+	return nil, domain.NewNotFoundError("prompt "+name+" version", fmt.Sprint(number))
+}
+
+// applyDraft validates draft as a template of prompt and stores it in prompt's content columns.
+func applyDraft(prompt *models.Prompt, draft PromptDraft) error {
+	if strings.TrimSpace(draft.Template) == "" {
+		return domain.NewValidationError("prompt template is required")
+	}
+	template := &gemini.PromptTemplate{ID: prompt.Name, Template: draft.Template, Variables: draft.Variables, OutputFormat: draft.OutputFormat}
+	if err := template.Compile(); err != nil {
+		return domain.NewValidationError(err.Error())
+	}
+	if template.Variables == nil {
+		template.Variables = []gemini.PromptVariable{}
+	}
+	variables, err := json.Marshal(template.Variables)
+	if err != nil {
+		return fmt.Errorf("encoding prompt variables: %w", err)
+	}
+	outputFormat, err := json.Marshal(template.OutputFormat) // Includes the defaults filled in by Compile
+	if err != nil {
+		return fmt.Errorf("encoding prompt output format: %w", err)
+	}
+	prompt.Template = draft.Template
+	prompt.InputVariables = variables
+	prompt.OutputFormat = outputFormat
+	return nil
+}
+
+// promptResource names a prompt version in errors.
+func promptResource(prompt *models.Prompt) string {
+	return fmt.Sprintf("prompt %s version %d", prompt.Name, prompt.Version)
+}
+
+// promptLines renders a version's content for diffing.
+func promptLines(prompt *models.Prompt) []string {
+	lines := []string{"template:"}
+	lines = append(lines, strings.Split(strings.TrimRight(prompt.Template, "\n"), "\n")...)
+	for _, section := range []struct {
+		name  string
+		value json.RawMessage
+	}{{"variables:", prompt.InputVariables}, {"output_format:", prompt.OutputFormat}} {
+		lines = append(lines, section.name)
+		var value any
+		if err := json.Unmarshal(section.value, &value); err != nil {
+			lines = append(lines, string(section.value)) // Not JSON: compare as stored
+			continue
+		}
+		indented, _ := json.MarshalIndent(value, "", "  ") // Re-encoding sorts object keys
+		lines = append(lines, strings.Split(string(indented), "\n")...)
+	}
+	return lines
+}
+
+// diffLines returns a line diff of a and b built from their longest common subsequence.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]string, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+	return lines
+}
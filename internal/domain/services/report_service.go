@@ -49,8 +49,8 @@ func (s *ReportService) GenerateReport(ctx context.Context, patientID uuid.UUID)
 	// 1. Data Retrieval (using ReportRepository):
 	//    - Retrieve all necessary data for the report using the injected ReportRepository.
 	//    - This might include patient data, analysis results, findings, etc.
-	//    - Placeholder for now - actual data retrieval logic will be added in subsequent tasks (BE-045, BE-052).
-	reportData, err := s.retrieveReportData(ctx, patientID) // Placeholder function call for data retrieval - to be implemented
+	//    - Findings only for now - the other sections will be added in subsequent tasks (BE-045, BE-052).
+	reportData, err := s.retrieveReportData(ctx, patientID)
 	if err != nil {
 		s.logger.Error("Failed to retrieve report data", zap.String("operation", operation), zap.String("patient_id", patientID.String()), zap.Error(err)) // Log error if data retrieval fails
 		return "", fmt.Errorf("generating report: failed to retrieve data: %w", err)                                                                       // Return error with context
@@ -70,41 +70,52 @@ func (s *ReportService) GenerateReport(ctx context.Context, patientID uuid.UUID)
 	return filePath, nil                                                                                                                                                    // Return the file path to the generated PDF and nil error for success
 }
 
-// retrieveReportData is a placeholder function for retrieving data needed for the report.
-// In a real implementation, this function would orchestrate calls to various repositories
-// (e.g., PatientRepository, AnalysisResultRepository, UploadedContentRepository) to gather
-// all the necessary data for the report, based on the patientID.
+// LowConfidenceNote is shown in the patient report next to findings taken from text OCR read with low confidence.
+const LowConfidenceNote = "please verify with the original"
+
+// PatientReportData is the content of a patient report passed to the PDFGenerator.
+type PatientReportData struct {
+	PatientID uuid.UUID              `json:"patient_id"`
+	Findings  []PatientReportFinding `json:"findings"`
+}
+
+// PatientReportFinding is a finding as shown in the patient report.
+type PatientReportFinding struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Note        string `json:"note,omitempty"` // LowConfidenceNote for low-confidence findings
+}
+
+// retrieveReportData retrieves the data needed for the report. It currently collects the findings extracted from the
+// patient's reports, noting those to verify against the original document.
 //
-// In a complete implementation, this function should retrieve:
+// In a complete implementation, this function should also retrieve:
 // - Patient demographic information (from PatientRepository - currently minimal).
 // - Analysis results (diagnosis, staging, treatment recommendations from AnalysisResultRepository).
-// - Findings summaries (from UploadedContentRepository and potentially FindingsRepository).
 // - Disclaimers and legal text (from a configuration or content management system).
 // - Any other data required by the report template.
 //
-// This function is a placeholder and needs to be implemented in subsequent tasks (BE-045, BE-052).
-func (s *ReportService) retrieveReportData(ctx context.Context, patientID uuid.UUID) (interface{}, error) { //  Return type and parameters - adjust as needed
+// These are to be added in subsequent tasks (BE-045, BE-052).
+func (s *ReportService) retrieveReportData(ctx context.Context, patientID uuid.UUID) (*PatientReportData, error) {
 	const operation = "retrieveReportData" // Define operation name for structured logging
 	requestID := utils.GetRequestID(ctx)
 
-	s.logger.Debug("Placeholder function called", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("patient_id", patientID.String())) // Debug log
-
-	// Placeholder implementation - Replace with actual data retrieval logic in future tasks (BE-045, BE-052)
-	// Example of data retrieval from repositories (conceptual - adapt to actual data needs):
-	// patient, err := s.patientRepository.GetPatient(ctx, patientID)
-	// if err != nil { return nil, fmt.Errorf("failed to get patient data: %w", err) }
-	// analysisResult, err := s.analysisResultRepository.GetAnalysisResultBySessionID(ctx, patientID)
-	// if err != nil { return nil, fmt.Errorf("failed to get analysis result: %w", err) }
-	// uploadedContentList, err := s.uploadedContentRepository.ListUploadedContentBySessionID(ctx, patientID)
-	// if err != nil { return nil, fmt.Errorf("failed to get uploaded content: %w", err) }
-
-	// In a complete implementation, this function would return a struct or map containing all necessary data
-	// for the report template (e.g., patient info, findings summaries, diagnosis, staging, treatment options, disclaimers, etc.).
-	// For now, it returns nil and no error as a placeholder.
+	findings, err := s.reportRepository.GetFindingsByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get findings: %w", err)
+	}
 
-	s.logger.Warn("retrieveReportData is a placeholder - actual data retrieval logic not yet implemented", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("patient_id", patientID.String())) // Warning log
+	data := &PatientReportData{PatientID: patientID, Findings: make([]PatientReportFinding, 0, len(findings))}
+	lowConfidence := 0
+	for _, finding := range findings {
+		reportFinding := PatientReportFinding{Type: finding.FindingType, Description: finding.Description}
+		if finding.LowConfidence {
+			reportFinding.Note = LowConfidenceNote
+			lowConfidence++
+		}
+		data.Findings = append(data.Findings, reportFinding)
+	}
 
-	// Example of returning an error for demonstration purposes in the placeholder:
-	return nil, fmt.Errorf("retrieveReportData placeholder error: not yet implemented") // Example error return - now explicitly returning an error as part of the placeholder
-	// return nil, nil // Placeholder return - replace with actual data and error handling -  (original placeholder return)
+	s.logger.Debug("Retrieved report data", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("patient_id", patientID.String()), zap.Int("findings", len(data.Findings)), zap.Int("low_confidence_findings", lowConfidence))
+	return data, nil
 }
@@ -0,0 +1,163 @@
+// internal/domain/services/series_buffer.go
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// maxPendingInstancesPerPatient caps how many parsed instances are held in memory for one session
+// before series analysis runs (a typical chest CT is 100-600 slices).
+const maxPendingInstancesPerPatient = 2000
+
+// maxPendingBytes caps the pixel data and attributes held for all sessions together (a 512×512 CT slice is 512 KiB).
+const maxPendingBytes int64 = 2 << 30
+
+// pendingSeriesIdleTTL is how long a session's instances are kept without an upload or analysis before they are
+// dropped.
+const pendingSeriesIdleTTL = 2 * time.Hour
+
+// analysisTags are the attributes series analysis reads: the identifiers and geometry that assemble instances into
+// series and order their slices, and the pixel module that renders them. Everything else is dropped from an
+// instance before it is queued.
+var analysisTags = []dicom.Tag{
+	dicom.TagStudyInstanceUID, dicom.TagSeriesInstanceUID, dicom.TagSOPInstanceUID, dicom.TagModality,
+	dicom.TagInstanceNumber, dicom.TagImagePositionPatient, dicom.TagImageOrientationPatient, dicom.TagPixelSpacing,
+	dicom.TagSliceThickness, dicom.TagSliceLocation,
+	dicom.TagSamplesPerPixel, dicom.TagPhotometricInterpretation, dicom.TagRows, dicom.TagColumns,
+	dicom.TagPlanarConfiguration, dicom.TagBitsAllocated, dicom.TagBitsStored, dicom.TagHighBit,
+	dicom.TagPixelRepresentation, dicom.TagNumberOfFrames, dicom.TagRescaleSlope, dicom.TagRescaleIntercept,
+	dicom.TagWindowCenter, dicom.TagWindowWidth, dicom.TagPixelData,
+}
+
+// pendingInstance is an anonymized DICOM instance, already stored as a models.Image, waiting for series analysis.
+type pendingInstance struct {
+	dataSet     *dicom.DataSet // Only the analysisTags of the instance
+	imageID     uuid.UUID
+	identifiers []string // Identifying values of the original header, used to find them burned into the pixels; held in memory only
+	size        int64    // Bytes held for the instance, counted against maxPendingBytes
+}
+
+// newPendingInstance keeps what series analysis needs of an anonymized instance.
+func newPendingInstance(dataSet *dicom.DataSet, imageID uuid.UUID, identifiers []string) pendingInstance {
+	kept := dataSet.Subset(analysisTags...)
+	return pendingInstance{dataSet: kept, imageID: imageID, identifiers: identifiers, size: kept.Size()}
+}
+
+// pendingSession is the queue of one patient session.
+type pendingSession struct {
+	instances    []pendingInstance // Waiting for the next analysis
+	bytes        int64             // Held for the session, instances being analyzed included
+	lastActivity time.Time
+}
+
+// seriesBuffer collects instances per patient session between upload and series-level analysis,
+// so that slices uploaded in separate requests can still be assembled into one series. Sessions idle for
+// pendingSeriesIdleTTL are dropped, and uploads are refused once maxPendingBytes are held.
+//
+// The buffer lives in the memory of one process: instances queued on one replica are only analyzed by an
+// AnalyzeSeries call that reaches the same replica, and a restart drops them. Their images stay stored, so
+// re-uploading the series queues them again.
+type seriesBuffer struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*pendingSession
+	bytes    int64
+	now      func() time.Time
+}
+
+func newSeriesBuffer() *seriesBuffer {
+	return &seriesBuffer{sessions: make(map[uuid.UUID]*pendingSession), now: time.Now}
+}
+
+// add queues an instance for the patient, enforcing the per-session and global limits.
+func (b *seriesBuffer) add(patientID uuid.UUID, instance pendingInstance) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.evictIdle(now)
+	session := b.sessions[patientID]
+	if session == nil {
+		session = &pendingSession{}
+		b.sessions[patientID] = session
+	}
+	if len(session.instances) >= maxPendingInstancesPerPatient {
+		return fmt.Errorf("too many pending DICOM instances for session (limit %d); run series analysis first", maxPendingInstancesPerPatient)
+	}
+	if b.bytes+instance.size > maxPendingBytes {
+		return fmt.Errorf("too much DICOM data pending analysis (limit %d MiB); run series analysis first", maxPendingBytes>>20)
+	}
+	session.instances = append(session.instances, instance)
+	session.bytes += instance.size
+	session.lastActivity = now
+	b.bytes += instance.size
+	return nil
+}
+
+// take hands every pending instance of the patient over to analysis. The instances stay counted against the
+// limits until finish is called with the returned session.
+func (b *seriesBuffer) take(patientID uuid.UUID) ([]pendingInstance, *pendingSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.evictIdle(now)
+	session := b.sessions[patientID]
+	if session == nil {
+		return nil, nil
+	}
+	instances := session.instances
+	session.instances = nil
+	session.lastActivity = now
+	return instances, session
+}
+
+// finish ends the analysis of instances taken from session: the analyzed ones are released, and those of series
+// whose analysis failed are queued again for the next analysis. Nothing is queued again if the session's data was
+// discarded (or the session expired) meanwhile.
+func (b *seriesBuffer) finish(patientID uuid.UUID, session *pendingSession, analyzed, failed []pendingInstance) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if session == nil || b.sessions[patientID] != session {
+		return
+	}
+	for _, instance := range analyzed {
+		session.bytes -= instance.size
+		b.bytes -= instance.size
+	}
+	session.instances = append(failed, session.instances...)
+	session.lastActivity = b.now()
+	if len(session.instances) == 0 && session.bytes == 0 {
+		delete(b.sessions, patientID)
+	}
+}
+
+// discard drops any pending instances for the patient (used when all patient data is deleted).
+func (b *seriesBuffer) discard(patientID uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(patientID)
+}
+
+// evictIdle drops the sessions without activity for pendingSeriesIdleTTL. The caller holds b.mu.
+func (b *seriesBuffer) evictIdle(now time.Time) {
+	for patientID, session := range b.sessions {
+		if now.Sub(session.lastActivity) > pendingSeriesIdleTTL {
+			b.remove(patientID)
+		}
+	}
+}
+
+// remove drops the patient's session. The caller holds b.mu.
+func (b *seriesBuffer) remove(patientID uuid.UUID) {
+	if session, ok := b.sessions[patientID]; ok {
+		b.bytes -= session.bytes
+		delete(b.sessions, patientID)
+	}
+}
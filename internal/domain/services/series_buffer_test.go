@@ -0,0 +1,106 @@
+// internal/domain/services/series_buffer_test.go
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+func TestSeriesBufferKeepsOnlyAnalysisAttributes(t *testing.T) {
+	ds := ctInstance(0)
+	ds.Put(dicom.NewStringElement(dicom.TagImageComments, dicom.VRLT, "scanned after contrast"))
+	instance := newPendingInstance(ds, uuid.New(), nil)
+
+	if _, ok := instance.dataSet.FindElement(dicom.TagImageComments); ok {
+		t.Error("queued instance kept an attribute series analysis does not read")
+	}
+	if instance.dataSet.SOPInstanceUID != ds.SOPInstanceUID {
+		t.Errorf("queued instance has SOP Instance UID %q, want %q", instance.dataSet.SOPInstanceUID, ds.SOPInstanceUID)
+	}
+	if _, err := instance.dataSet.DecodePixelData(); err != nil {
+		t.Errorf("queued instance cannot be decoded: %v", err)
+	}
+	if instance.size < 16*16*2 || instance.size >= ds.Size() {
+		t.Errorf("queued instance size %d, want at least the pixel data and less than the whole dataset (%d)", instance.size, ds.Size())
+	}
+}
+
+func TestSeriesBufferByteLimit(t *testing.T) {
+	b := newSeriesBuffer()
+	patientID := uuid.New()
+	if err := b.add(patientID, pendingInstance{size: maxPendingBytes - 10}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := b.add(uuid.New(), pendingInstance{size: 11}); err == nil {
+		t.Fatal("add succeeded beyond the limit shared by all sessions")
+	}
+
+	pending, session := b.take(patientID)
+	if err := b.add(uuid.New(), pendingInstance{size: 11}); err == nil {
+		t.Fatal("add succeeded while the taken instances are still being analyzed")
+	}
+	b.finish(patientID, session, pending, nil)
+	if err := b.add(uuid.New(), pendingInstance{size: 11}); err != nil {
+		t.Fatalf("add after the analysis released its instances: %v", err)
+	}
+}
+
+func TestSeriesBufferRequeue(t *testing.T) {
+	b := newSeriesBuffer()
+	patientID := uuid.New()
+	for i := 0; i < 3; i++ {
+		if err := b.add(patientID, pendingInstance{imageID: uuid.New(), size: 100}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	pending, session := b.take(patientID)
+	late := pendingInstance{imageID: uuid.New(), size: 100} // Uploaded while the analysis runs
+	if err := b.add(patientID, late); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	b.finish(patientID, session, pending[:1], pending[1:])
+
+	requeued, _ := b.take(patientID)
+	if len(requeued) != 3 || requeued[0].imageID != pending[1].imageID || requeued[2].imageID != late.imageID {
+		t.Fatalf("took %d instances after a partial failure, want the 2 failed ones then the late upload", len(requeued))
+	}
+	if b.bytes != 300 {
+		t.Errorf("buffer holds %d bytes, want 300", b.bytes)
+	}
+
+	b.discard(patientID)
+	b.finish(patientID, session, nil, requeued)
+	if again, _ := b.take(patientID); len(again) != 0 || b.bytes != 0 {
+		t.Errorf("%d instances (%d bytes) queued again after the session's data was discarded", len(again), b.bytes)
+	}
+}
+
+func TestSeriesBufferIdleEviction(t *testing.T) {
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	b := newSeriesBuffer()
+	b.now = func() time.Time { return now }
+
+	idle, active := uuid.New(), uuid.New()
+	if err := b.add(idle, pendingInstance{size: 100}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	now = now.Add(pendingSeriesIdleTTL / 2)
+	if err := b.add(active, pendingInstance{size: 100}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	now = now.Add(pendingSeriesIdleTTL/2 + time.Minute)
+
+	if pending, _ := b.take(idle); len(pending) != 0 {
+		t.Errorf("took %d instances of a session idle for longer than %v", len(pending), pendingSeriesIdleTTL)
+	}
+	if pending, _ := b.take(active); len(pending) != 1 {
+		t.Errorf("took %d instances of an active session, want 1", len(pending))
+	}
+	if b.bytes != 100 {
+		t.Errorf("buffer holds %d bytes, want the 100 of the active session", b.bytes)
+	}
+}
@@ -0,0 +1,91 @@
+// internal/domain/services/session_secret_service.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/data/repositories/interfaces"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/security"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// Purposes of per-session secrets. Each purpose gets its own independent secret.
+const (
+	SecretPurposeUIDRemap      = "uid_remap"      // Key for deriving pseudonymous DICOM UIDs (security.NewUIDMapper)
+	SecretPurposeTextSurrogate = "text_surrogate" // Key for consistent surrogates in report text (security.TextDeidentifier)
+	SecretPurposeDateShift     = "date_shift"     // Source of the session's date offset (DateShiftService)
+)
+
+// sessionSecretSize is the length of a generated secret in bytes (256 bits, matching the HMAC-SHA256 output).
+const sessionSecretSize = 32
+
+// SessionSecretService manages the random per-session secrets from which pseudonyms are derived.
+// Pseudonyms are recomputed from the secret whenever needed, so no original-to-pseudonym map is ever stored.
+// Secrets are stored encrypted with the file encryption key and destroyed with the rest of the session's data.
+type SessionSecretService struct {
+	repository interfaces.SessionSecretRepository
+	config     *config.Config
+	logger     *zap.Logger
+}
+
+// NewSessionSecretService creates a new SessionSecretService with dependencies injected.
+func NewSessionSecretService(repository interfaces.SessionSecretRepository, config *config.Config, logger *zap.Logger) *SessionSecretService {
+	return &SessionSecretService{
+		repository: repository,
+		config:     config,
+		logger:     logger.Named("SessionSecretService"),
+	}
+}
+
+// Secret returns the session's secret for purpose, generating and storing one on first use.
+// Concurrent first uses agree on a single secret: the first one stored wins and every caller reads it back.
+func (s *SessionSecretService) Secret(ctx context.Context, sessionID uuid.UUID, purpose string) ([]byte, error) {
+	const operation = "SessionSecretService.Secret"
+	requestID := utils.GetRequestID(ctx)
+
+	encrypted, err := s.repository.GetSessionSecret(ctx, sessionID, purpose)
+	if domain.IsNotFoundError(err) {
+		// 1. First use in this session: generate a secret and store it encrypted
+		secret := make([]byte, sessionSecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generating session secret: %w", err)
+		}
+		ciphertext, err := security.Encrypt([]byte(s.config.FileEncryptionKey), secret)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting session secret: %w", err)
+		}
+		if err := s.repository.CreateSessionSecret(ctx, sessionID, purpose, ciphertext); err != nil {
+			return nil, fmt.Errorf("storing session secret: %w", err)
+		}
+		s.logger.Info("Session secret created", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose))
+
+		// 2. Read back whichever secret was stored first
+		encrypted, err = s.repository.GetSessionSecret(ctx, sessionID, purpose)
+	}
+	if err != nil {
+		s.logger.Error("Failed to get session secret", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose), zap.Error(err))
+		return nil, fmt.Errorf("getting session secret: %w", err)
+	}
+
+	secret, err := security.Decrypt([]byte(s.config.FileEncryptionKey), encrypted)
+	if err != nil {
+		s.logger.Error("Failed to decrypt session secret", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", sessionID.String()), zap.String("purpose", purpose), zap.Error(err))
+		return nil, fmt.Errorf("decrypting session secret: %w", err)
+	}
+	return secret, nil
+}
+
+// Delete destroys every secret of a session. Pseudonyms derived from them can no longer be linked to the originals.
+func (s *SessionSecretService) Delete(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.repository.DeleteSessionSecrets(ctx, sessionID); err != nil {
+		return fmt.Errorf("deleting session secrets: %w", err)
+	}
+	s.logger.Info("Session secrets deleted", zap.String("operation", "SessionSecretService.Delete"), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("session_id", sessionID.String()))
+	return nil
+}
@@ -0,0 +1,140 @@
+// internal/evaluation/corpus.go
+// Package evaluation runs a labelled corpus of anonymized cases through the processing and diagnosis pipeline and
+// scores the results against ground truth, so that prompt and model changes can be gated on regressions.
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the name of the corpus manifest in the corpus directory.
+const ManifestFile = "manifest.json"
+
+// Corpus is a labelled set of cases, loaded from <dir>/manifest.json:
+//
+//	{
+//	  "name": "nsclc-v1",
+//	  "cases": [{
+//	    "id": "case-001",
+//	    "reports": [{"file": "case-001/pathology.txt", "report_type": "pathology", "findings": ["adenocarcinoma"]}],
+//	    "images": "case-001/ct",
+//	    "nodules": [{"location": "Right Upper Lobe", "size_mm": 18}],
+//	    "tnm": {"t": "T1c", "n": "N0", "m": "M0"},
+//	    "diagnosis": "adenocarcinoma"
+//	  }]
+//	}
+//
+// Paths are relative to the corpus directory. Report files hold the de-identified report text; the images directory
+// holds the de-identified DICOM instances of the case (all files below it are read). Every label is optional: a case
+// is only scored on what it declares.
+type Corpus struct {
+	Name  string `json:"name"`
+	Cases []Case `json:"cases"`
+
+	dir string // Directory the manifest was loaded from
+}
+
+// Case is one labelled patient case of a corpus.
+type Case struct {
+	ID        string        `json:"id"`
+	Reports   []ReportLabel `json:"reports,omitempty"`
+	Images    string        `json:"images,omitempty"`    // Directory of DICOM instances, relative to the corpus
+	Nodules   []NoduleLabel `json:"nodules,omitempty"`   // Expected nodules of the images (none if empty); requires Images
+	TNM       *TNMLabel     `json:"tnm,omitempty"`       // Expected staging
+	Diagnosis string        `json:"diagnosis,omitempty"` // Text the preliminary diagnosis must contain (case-insensitive)
+}
+
+// ReportLabel is a report of a case with its expected type and findings.
+type ReportLabel struct {
+	File       string   `json:"file"`
+	ReportType string   `json:"report_type,omitempty"` // Expected result of ProcessingService.DetermineReportType
+	Findings   []string `json:"findings,omitempty"`    // Texts that must each appear in one extracted finding (case-insensitive)
+}
+
+// NoduleLabel is an expected nodule of a case.
+type NoduleLabel struct {
+	Location string  `json:"location"`
+	SizeMM   float64 `json:"size_mm"`
+}
+
+// TNMLabel is the expected TNM staging of a case.
+type TNMLabel struct {
+	T string `json:"t"`
+	N string `json:"n"`
+	M string `json:"m"`
+}
+
+// LoadCorpus reads and validates the manifest of the corpus in dir.
+func LoadCorpus(dir string) (*Corpus, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading corpus manifest: %w", err)
+	}
+	corpus := &Corpus{dir: dir}
+	if err := json.Unmarshal(data, corpus); err != nil {
+		return nil, fmt.Errorf("parsing corpus manifest: %w", err)
+	}
+
+	seen := make(map[string]bool, len(corpus.Cases))
+	for i, c := range corpus.Cases {
+		switch {
+		case c.ID == "":
+			return nil, fmt.Errorf("corpus case %d: id is required", i)
+		case seen[c.ID]:
+			return nil, fmt.Errorf("corpus case %q: duplicate id", c.ID)
+		case len(c.Nodules) > 0 && c.Images == "":
+			return nil, fmt.Errorf("corpus case %q: nodules are labelled but no images are given", c.ID)
+		}
+		seen[c.ID] = true
+		for _, report := range c.Reports {
+			if report.File == "" {
+				return nil, fmt.Errorf("corpus case %q: report file is required", c.ID)
+			}
+		}
+	}
+	if corpus.Name == "" {
+		corpus.Name = filepath.Base(filepath.Clean(dir))
+	}
+	return corpus, nil
+}
+
+// readReport returns the text of a report of the corpus.
+func (c *Corpus) readReport(report ReportLabel) (string, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, report.File))
+	if err != nil {
+		return "", fmt.Errorf("reading report %s: %w", report.File, err)
+	}
+	return string(data), nil
+}
+
+// readImages returns the contents of every file below a case's images directory, in path order (WalkDir walks in lexical order).
+func (c *Corpus) readImages(images string) ([][]byte, error) {
+	root := filepath.Join(c.dir, images)
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing images in %s: %w", images, err)
+	}
+
+	files := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading image %s: %w", path, err)
+		}
+		files = append(files, data)
+	}
+	return files, nil
+}
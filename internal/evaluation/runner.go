@@ -0,0 +1,143 @@
+// internal/evaluation/runner.go
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/domain/services"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// sessionNamespace derives the session ID of a case, so that a case gets the same session (and thus the same prompt
+// experiment arm) in every run.
+var sessionNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("lung-server/evaluation"))
+
+// Runner runs the cases of a corpus through ProcessingService and DiagnosisService with the configured model client
+// and scores the results. Nothing is stored: reports and images are analyzed through the services' non-persisting
+// entry points, and staging and diagnosis are given the case's reports and extracted findings as the stored ones
+// would be in a session. Model calls are recorded in the prompt call metrics like any other.
+type Runner struct {
+	processing *services.ProcessingService
+	diagnosis  *services.DiagnosisService
+	logger     *zap.Logger
+}
+
+// NewRunner creates a new Runner instance.
+func NewRunner(processing *services.ProcessingService, diagnosis *services.DiagnosisService, logger *zap.Logger) *Runner {
+	return &Runner{
+		processing: processing,
+		diagnosis:  diagnosis,
+		logger:     logger.Named("EvaluationRunner"),
+	}
+}
+
+// Run scores every case of the corpus. A failing step is recorded in the case's errors and does not stop the run;
+// only the cancellation of ctx does.
+func (r *Runner) Run(ctx context.Context, corpus *Corpus) (*Scorecard, error) {
+	const operation = "Runner.Run"
+
+	results := make([]CaseResult, 0, len(corpus.Cases))
+	for _, c := range corpus.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("evaluation cancelled: %w", err)
+		}
+		result := r.runCase(ctx, corpus, c)
+		if len(result.Errors) > 0 {
+			r.logger.Warn("Case evaluated with errors", zap.String("operation", operation), zap.String("case", c.ID), zap.Strings("errors", result.Errors))
+		} else {
+			r.logger.Info("Case evaluated", zap.String("operation", operation), zap.String("case", c.ID))
+		}
+		results = append(results, result)
+	}
+	return newScorecard(corpus.Name, results), nil
+}
+
+// runCase runs and scores the steps a case has labels for.
+func (r *Runner) runCase(ctx context.Context, corpus *Corpus, c Case) CaseResult {
+	ctx = context.WithValue(ctx, utils.RequestIDKey, "evaluation-"+c.ID) // Correlates the services' logs with the case
+	sessionID := uuid.NewSHA1(sessionNamespace, []byte(corpus.Name+"/"+c.ID))
+	result := CaseResult{ID: c.ID}
+	fail := func(step string, err error) {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", step, err))
+	}
+
+	// 1. Reports: report type and extracted findings, which are also the evidence staging and diagnosis are based on
+	var findings *FindingScore
+	evidence := &services.CaseEvidence{}
+	needEvidence := c.TNM != nil || c.Diagnosis != ""
+	for _, report := range c.Reports {
+		text, err := corpus.readReport(report)
+		if err != nil {
+			fail("report", err)
+			continue
+		}
+		reportType := r.processing.DetermineReportType(filepath.Base(report.File), text)
+		result.ReportTypes = append(result.ReportTypes, ReportResult{File: report.File, Expected: report.ReportType, Predicted: reportType})
+		evidence.Reports = append(evidence.Reports, &models.Report{Filename: filepath.Base(report.File), ReportType: reportType, ReportText: text})
+		if len(report.Findings) == 0 && !needEvidence {
+			continue
+		}
+		extracted, err := r.processing.AnalyzeReportText(ctx, sessionID, reportType, text)
+		if err != nil {
+			fail("findings of "+report.File, err)
+			continue
+		}
+		evidence.Findings = append(evidence.Findings, extracted...)
+		if len(report.Findings) == 0 {
+			continue
+		}
+		score := scoreFindings(report.Findings, extracted)
+		if findings == nil {
+			findings = &FindingScore{}
+		}
+		findings.Expected += score.Expected
+		findings.Found += score.Found
+		findings.Missing = append(findings.Missing, score.Missing...)
+	}
+	result.Findings = findings
+
+	// 2. Images: nodule detection
+	if c.Images != "" {
+		if files, err := corpus.readImages(c.Images); err != nil {
+			fail("images", err)
+		} else if nodules, err := r.processing.DetectNodules(ctx, sessionID, files); err != nil {
+			fail("nodules", err)
+		} else {
+			score := scoreNodules(c.Nodules, nodules)
+			result.Nodules = &score
+		}
+	}
+
+	// 3. Staging
+	if c.TNM != nil {
+		if stage, err := r.diagnosis.GetStagingInformationFromEvidence(ctx, sessionID, evidence); err != nil {
+			fail("staging", err)
+		} else {
+			score := scoreTNM(*c.TNM, stage)
+			result.TNM = &score
+		}
+	}
+
+	// 4. Preliminary diagnosis
+	if c.Diagnosis != "" {
+		if diagnosis, err := r.diagnosis.GeneratePreliminaryDiagnosisFromEvidence(ctx, sessionID, evidence); err != nil {
+			fail("diagnosis", err)
+		} else {
+			match := diagnosisMatches(c.Diagnosis, diagnosis)
+			result.Diagnosis = &match
+		}
+	}
+
+	return result
+}
+
+// diagnosisMatches reports whether the diagnosis text contains the expected text (case-insensitive).
+func diagnosisMatches(expected string, diagnosis *models.Diagnosis) bool {
+	return strings.Contains(strings.ToLower(diagnosis.DiagnosisText), strings.ToLower(strings.TrimSpace(expected)))
+}
@@ -0,0 +1,321 @@
+// internal/evaluation/scorecard.go
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// CaseResult holds the scores of one case. Scores are nil for what the case does not label or when the step failed;
+// failures are listed in Errors.
+type CaseResult struct {
+	ID          string         `json:"id"`
+	ReportTypes []ReportResult `json:"report_types,omitempty"`
+	Findings    *FindingScore  `json:"findings,omitempty"`
+	Nodules     *NoduleScore   `json:"nodules,omitempty"`
+	TNM         *TNMScore      `json:"tnm,omitempty"`
+	Diagnosis   *bool          `json:"diagnosis,omitempty"` // Whether the preliminary diagnosis contains the expected text
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// ReportResult is the report type determined for one report of a case.
+type ReportResult struct {
+	File      string `json:"file"`
+	Expected  string `json:"expected,omitempty"`
+	Predicted string `json:"predicted"`
+}
+
+// ConfusionMatrix counts reports per expected (outer key) and determined (inner key) report type.
+type ConfusionMatrix map[string]map[string]int
+
+// Labels returns every report type of the matrix, expected or determined, sorted.
+func (m ConfusionMatrix) Labels() []string {
+	seen := make(map[string]bool)
+	for expected, row := range m {
+		seen[expected] = true
+		for predicted := range row {
+			seen[predicted] = true
+		}
+	}
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// add counts one report.
+func (m ConfusionMatrix) add(expected, predicted string) {
+	if m[expected] == nil {
+		m[expected] = make(map[string]int)
+	}
+	m[expected][predicted]++
+}
+
+// Summary aggregates the scores of every case. Rates are between 0 and 1 and are 0 when nothing was scored; the
+// counts tell how many cases or items each rate is based on.
+type Summary struct {
+	Cases      int `json:"cases"`
+	ErrorCases int `json:"error_cases"` // Cases where at least one step failed
+
+	TNMCases   int     `json:"tnm_cases"`
+	TNMExact   float64 `json:"tnm_exact"`   // Share of cases with all three components right
+	TNMPartial float64 `json:"tnm_partial"` // Share of components right at least in their category
+	TAccuracy  float64 `json:"t_accuracy"`  // Share of cases with the T component right
+	NAccuracy  float64 `json:"n_accuracy"`  // Share of cases with the N component right
+	MAccuracy  float64 `json:"m_accuracy"`  // Share of cases with the M component right
+
+	NoduleCases      int     `json:"nodule_cases"` // Cases whose images were analyzed
+	NodulesExpected  int     `json:"nodules_expected"`
+	NodulesPredicted int     `json:"nodules_predicted"`
+	NodulesMatched   int     `json:"nodules_matched"`
+	NoduleRecall     float64 `json:"nodule_recall"`
+	NodulePrecision  float64 `json:"nodule_precision"`
+	NoduleSizeMAEMM  float64 `json:"nodule_size_mae_mm"` // Mean absolute size error of the matched nodules
+
+	FindingsExpected int     `json:"findings_expected"`
+	FindingsFound    int     `json:"findings_found"`
+	FindingRecall    float64 `json:"finding_recall"`
+
+	Reports            int     `json:"reports"` // Reports with an expected type
+	ReportTypeAccuracy float64 `json:"report_type_accuracy"`
+
+	DiagnosisCases int     `json:"diagnosis_cases"`
+	DiagnosisMatch float64 `json:"diagnosis_match"`
+}
+
+// Regression is a metric that got worse than in the baseline scorecard by more than the tolerance.
+type Regression struct {
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+// Scorecard is the result of a corpus run.
+type Scorecard struct {
+	Corpus      string          `json:"corpus"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Summary     Summary         `json:"summary"`
+	ReportTypes ConfusionMatrix `json:"report_types"`
+	Cases       []CaseResult    `json:"cases"`
+	Regressions []Regression    `json:"regressions,omitempty"` // Set by Compare
+}
+
+// newScorecard aggregates the results of a corpus run.
+func newScorecard(corpus string, results []CaseResult) *Scorecard {
+	card := &Scorecard{Corpus: corpus, GeneratedAt: time.Now().UTC(), ReportTypes: ConfusionMatrix{}, Cases: results}
+	sum := &card.Summary
+	sum.Cases = len(results)
+
+	var tnmComponents, tnmPartial, tCorrect, nCorrect, mCorrect, exact, reportsCorrect, diagnosisMatches int
+	var sizeError float64
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			sum.ErrorCases++
+		}
+		for _, report := range result.ReportTypes {
+			if report.Expected == "" {
+				continue
+			}
+			card.ReportTypes.add(report.Expected, report.Predicted)
+			sum.Reports++
+			if report.Expected == report.Predicted {
+				reportsCorrect++
+			}
+		}
+		if f := result.Findings; f != nil {
+			sum.FindingsExpected += f.Expected
+			sum.FindingsFound += f.Found
+		}
+		if n := result.Nodules; n != nil {
+			sum.NoduleCases++
+			sum.NodulesExpected += n.Expected
+			sum.NodulesPredicted += n.Predicted
+			sum.NodulesMatched += n.Matched
+			sizeError += n.SizeErrorMM
+		}
+		if t := result.TNM; t != nil {
+			sum.TNMCases++
+			tnmComponents += 3
+			tnmPartial += t.Partial
+			exact += count(t.Exact)
+			tCorrect += count(t.T)
+			nCorrect += count(t.N)
+			mCorrect += count(t.M)
+		}
+		if result.Diagnosis != nil {
+			sum.DiagnosisCases++
+			diagnosisMatches += count(*result.Diagnosis)
+		}
+	}
+
+	sum.TNMExact = rate(exact, sum.TNMCases)
+	sum.TNMPartial = rate(tnmPartial, tnmComponents)
+	sum.TAccuracy = rate(tCorrect, sum.TNMCases)
+	sum.NAccuracy = rate(nCorrect, sum.TNMCases)
+	sum.MAccuracy = rate(mCorrect, sum.TNMCases)
+	sum.NoduleRecall = rate(sum.NodulesMatched, sum.NodulesExpected)
+	sum.NodulePrecision = rate(sum.NodulesMatched, sum.NodulesPredicted)
+	if sum.NodulesMatched > 0 {
+		sum.NoduleSizeMAEMM = sizeError / float64(sum.NodulesMatched)
+	}
+	sum.FindingRecall = rate(sum.FindingsFound, sum.FindingsExpected)
+	sum.ReportTypeAccuracy = rate(reportsCorrect, sum.Reports)
+	sum.DiagnosisMatch = rate(diagnosisMatches, sum.DiagnosisCases)
+	return card
+}
+
+// count returns 1 for true and 0 for false.
+func count(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// rate returns n/total, or 0 if total is 0.
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// Compare records in the scorecard every metric that got worse than in baseline: rates that dropped by more than
+// tolerance (e.g. 0.02 for two percentage points), a nodule size error that grew by more than sizeToleranceMM, and
+// any increase of failed cases. Metrics that were not scored in either scorecard are skipped. It returns the
+// regressions.
+func (s *Scorecard) Compare(baseline *Scorecard, tolerance, sizeToleranceMM float64) []Regression {
+	cur, base := s.Summary, baseline.Summary
+	s.Regressions = nil
+	higher := func(metric string, current, previous float64, scored bool) {
+		if scored && previous-current > tolerance {
+			s.Regressions = append(s.Regressions, Regression{Metric: metric, Baseline: previous, Current: current})
+		}
+	}
+
+	tnm := cur.TNMCases > 0 && base.TNMCases > 0
+	higher("tnm_exact", cur.TNMExact, base.TNMExact, tnm)
+	higher("tnm_partial", cur.TNMPartial, base.TNMPartial, tnm)
+	higher("t_accuracy", cur.TAccuracy, base.TAccuracy, tnm)
+	higher("n_accuracy", cur.NAccuracy, base.NAccuracy, tnm)
+	higher("m_accuracy", cur.MAccuracy, base.MAccuracy, tnm)
+	higher("nodule_recall", cur.NoduleRecall, base.NoduleRecall, cur.NodulesExpected > 0 && base.NodulesExpected > 0)
+	higher("nodule_precision", cur.NodulePrecision, base.NodulePrecision, cur.NodulesPredicted > 0 && base.NodulesPredicted > 0)
+	higher("finding_recall", cur.FindingRecall, base.FindingRecall, cur.FindingsExpected > 0 && base.FindingsExpected > 0)
+	higher("report_type_accuracy", cur.ReportTypeAccuracy, base.ReportTypeAccuracy, cur.Reports > 0 && base.Reports > 0)
+	higher("diagnosis_match", cur.DiagnosisMatch, base.DiagnosisMatch, cur.DiagnosisCases > 0 && base.DiagnosisCases > 0)
+
+	if cur.NodulesMatched > 0 && base.NodulesMatched > 0 && cur.NoduleSizeMAEMM-base.NoduleSizeMAEMM > sizeToleranceMM {
+		s.Regressions = append(s.Regressions, Regression{Metric: "nodule_size_mae_mm", Baseline: base.NoduleSizeMAEMM, Current: cur.NoduleSizeMAEMM})
+	}
+	if cur.ErrorCases > base.ErrorCases {
+		s.Regressions = append(s.Regressions, Regression{Metric: "error_cases", Baseline: float64(base.ErrorCases), Current: float64(cur.ErrorCases)})
+	}
+	return s.Regressions
+}
+
+// LoadScorecard reads a scorecard written by WriteJSON, e.g. the baseline of a regression check.
+func LoadScorecard(path string) (*Scorecard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading scorecard: %w", err)
+	}
+	var card Scorecard
+	if err := json.Unmarshal(data, &card); err != nil {
+		return nil, fmt.Errorf("parsing scorecard %s: %w", path, err)
+	}
+	return &card, nil
+}
+
+// WriteJSON writes the scorecard as indented JSON.
+func (s *Scorecard) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Errorf("encoding scorecard: %w", err)
+	}
+	return nil
+}
+
+// WriteHTML writes the scorecard as a standalone HTML page.
+func (s *Scorecard) WriteHTML(w io.Writer) error {
+	if err := scorecardTemplate.Execute(w, s); err != nil {
+		return fmt.Errorf("rendering scorecard: %w", err)
+	}
+	return nil
+}
+
+// scorecardTemplate renders a Scorecard as HTML.
+var scorecardTemplate = template.Must(template.New("scorecard").Funcs(template.FuncMap{
+	"pct": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"mm":  func(v float64) string { return fmt.Sprintf("%.1f mm", v) },
+	"cell": func(m ConfusionMatrix, expected, predicted string) int {
+		return m[expected][predicted]
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scorecard {{.Corpus}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.bad { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Scorecard {{.Corpus}}</h1>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04:05 UTC"}}, {{.Summary.Cases}} cases, {{.Summary.ErrorCases}} with errors.</p>
+{{with .Regressions}}
+<h2 class="bad">Regressions</h2>
+<table>
+<tr><th>Metric</th><th>Baseline</th><th>Current</th></tr>
+{{range .}}<tr class="bad"><td>{{.Metric}}</td><td>{{printf "%.4f" .Baseline}}</td><td>{{printf "%.4f" .Current}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Summary}}
+<h2>Summary</h2>
+<table>
+<tr><th>Metric</th><th>Value</th><th>Based on</th></tr>
+<tr><td>TNM exact match</td><td>{{pct .TNMExact}}</td><td>{{.TNMCases}} cases</td></tr>
+<tr><td>TNM partial match</td><td>{{pct .TNMPartial}}</td><td>{{.TNMCases}} cases</td></tr>
+<tr><td>T / N / M accuracy</td><td>{{pct .TAccuracy}} / {{pct .NAccuracy}} / {{pct .MAccuracy}}</td><td>{{.TNMCases}} cases</td></tr>
+<tr><td>Nodule recall</td><td>{{pct .NoduleRecall}}</td><td>{{.NodulesMatched}} of {{.NodulesExpected}} expected</td></tr>
+<tr><td>Nodule precision</td><td>{{pct .NodulePrecision}}</td><td>{{.NodulesMatched}} of {{.NodulesPredicted}} detected</td></tr>
+<tr><td>Nodule size error (MAE)</td><td>{{mm .NoduleSizeMAEMM}}</td><td>{{.NodulesMatched}} matched nodules</td></tr>
+<tr><td>Finding recall</td><td>{{pct .FindingRecall}}</td><td>{{.FindingsFound}} of {{.FindingsExpected}} findings</td></tr>
+<tr><td>Report type accuracy</td><td>{{pct .ReportTypeAccuracy}}</td><td>{{.Reports}} reports</td></tr>
+<tr><td>Diagnosis match</td><td>{{pct .DiagnosisMatch}}</td><td>{{.DiagnosisCases}} cases</td></tr>
+</table>
+{{end}}
+{{$matrix := .ReportTypes}}{{$labels := .ReportTypes.Labels}}
+{{if $labels}}
+<h2>Report type confusion matrix</h2>
+<table>
+<tr><th>Expected \ Determined</th>{{range $labels}}<th>{{.}}</th>{{end}}</tr>
+{{range $expected := $labels}}<tr><th>{{$expected}}</th>{{range $predicted := $labels}}<td>{{cell $matrix $expected $predicted}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+<h2>Cases</h2>
+<table>
+<tr><th>Case</th><th>TNM (expected / predicted)</th><th>Nodules</th><th>Findings</th><th>Diagnosis</th><th>Errors</th></tr>
+{{range .Cases}}<tr>
+<td>{{.ID}}</td>
+<td>{{with .TNM}}<span{{if not .Exact}} class="bad"{{end}}>{{.Expected.T}}{{.Expected.N}}{{.Expected.M}} / {{.Predicted.T}}{{.Predicted.N}}{{.Predicted.M}}</span>{{end}}</td>
+<td>{{with .Nodules}}{{.Matched}} matched, {{.Expected}} expected, {{.Predicted}} detected{{end}}</td>
+<td>{{with .Findings}}{{.Found}}/{{.Expected}}{{with .Missing}} <span class="bad">missing: {{range $i, $m := .}}{{if $i}}, {{end}}{{$m}}{{end}}</span>{{end}}{{end}}</td>
+<td>{{with .Diagnosis}}{{if .}}yes{{else}}<span class="bad">no</span>{{end}}{{end}}</td>
+<td class="bad">{{range .Errors}}{{.}}<br>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
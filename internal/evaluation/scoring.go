@@ -0,0 +1,161 @@
+// internal/evaluation/scoring.go
+package evaluation
+
+import (
+	"math"
+	"strings"
+
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+// TNMScore compares a predicted TNM staging with the expected one. Components are compared after normalization
+// (case, spaces and the c/p/y/r/a prefixes are ignored, "2a" equals "T2a").
+type TNMScore struct {
+	Expected  TNMLabel `json:"expected"`
+	Predicted TNMLabel `json:"predicted"`
+	Exact     bool     `json:"exact"`   // All three components match
+	T         bool     `json:"t"`       // The T component matches
+	N         bool     `json:"n"`       // The N component matches
+	M         bool     `json:"m"`       // The M component matches
+	Partial   int      `json:"partial"` // Components matching at least in their category (T2a and T2b are both T2)
+}
+
+// scoreTNM compares a predicted staging with the expected one.
+func scoreTNM(expected TNMLabel, stage *models.Stage) TNMScore {
+	score := TNMScore{Expected: expected, Predicted: TNMLabel{T: stage.T, N: stage.N, M: stage.M}}
+	components := []struct {
+		prefix              byte
+		expected, predicted string
+		match               *bool
+	}{
+		{'T', expected.T, stage.T, &score.T},
+		{'N', expected.N, stage.N, &score.N},
+		{'M', expected.M, stage.M, &score.M},
+	}
+	for _, c := range components {
+		want, got := normalizeTNM(c.expected, c.prefix), normalizeTNM(c.predicted, c.prefix)
+		*c.match = want == got
+		if want != "" && tnmCategory(want) == tnmCategory(got) {
+			score.Partial++
+		}
+	}
+	score.Exact = score.T && score.N && score.M
+	return score
+}
+
+// normalizeTNM returns a TNM component in upper case, without spaces or c/p/y/r/a prefixes and with its T, N or M
+// prefix, so that "cT2a", "t2a" and "2A" all become "T2A".
+func normalizeTNM(component string, prefix byte) string {
+	s := strings.ToUpper(strings.Join(strings.Fields(component), ""))
+	for len(s) > 1 && strings.IndexByte("CPYRA", s[0]) >= 0 && s[0] != prefix {
+		s = s[1:]
+	}
+	if s != "" && s[0] != prefix {
+		s = string(prefix) + s
+	}
+	return s
+}
+
+// tnmCategory returns the main category of a normalized TNM component: "T2" for "T2A", "TIS" for "TIS".
+func tnmCategory(component string) string {
+	switch {
+	case strings.HasPrefix(component[min(1, len(component)):], "IS"):
+		return component[:3]
+	case len(component) > 2:
+		return component[:2]
+	}
+	return component
+}
+
+// Size tolerance of nodule matching: a detected nodule matches an expected one only if their sizes differ by at most
+// noduleSizeToleranceRatio of the expected size, or noduleSizeToleranceMM for nodules too small for the ratio to allow
+// for measurement differences.
+const (
+	noduleSizeToleranceRatio = 0.5
+	noduleSizeToleranceMM    = 3.0
+)
+
+// NoduleScore compares the nodules detected in a case's images with the expected ones. Each expected nodule is matched
+// with the unmatched detected nodule at the same location (case and spacing ignored) closest in size, provided the
+// sizes are within the tolerance (see noduleSizeToleranceRatio).
+type NoduleScore struct {
+	Expected    int          `json:"expected"`
+	Predicted   int          `json:"predicted"`
+	Matched     int          `json:"matched"`
+	SizeErrorMM float64      `json:"size_error_mm"` // Sum of the absolute size errors of the matched nodules
+	Pairs       []NodulePair `json:"pairs,omitempty"`
+}
+
+// NodulePair is an expected nodule and the detected nodule matched with it.
+type NodulePair struct {
+	Location    string  `json:"location"`
+	ExpectedMM  float64 `json:"expected_mm"`
+	PredictedMM float64 `json:"predicted_mm"`
+}
+
+// scoreNodules matches the detected nodules with the expected ones.
+func scoreNodules(expected []NoduleLabel, predicted []*models.Nodule) NoduleScore {
+	score := NoduleScore{Expected: len(expected), Predicted: len(predicted)}
+	used := make([]bool, len(predicted))
+	for _, want := range expected {
+		best := -1
+		for i, got := range predicted {
+			if used[i] || normalizeLocation(got.Location) != normalizeLocation(want.Location) || !withinSizeTolerance(want.SizeMM, got.Size) {
+				continue
+			}
+			if best < 0 || math.Abs(got.Size-want.SizeMM) < math.Abs(predicted[best].Size-want.SizeMM) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[best] = true
+		score.Matched++
+		score.SizeErrorMM += math.Abs(predicted[best].Size - want.SizeMM)
+		score.Pairs = append(score.Pairs, NodulePair{Location: want.Location, ExpectedMM: want.SizeMM, PredictedMM: predicted[best].Size})
+	}
+	return score
+}
+
+// withinSizeTolerance reports whether a detected size is close enough to the expected one for the nodules to match.
+func withinSizeTolerance(expectedMM, predictedMM float64) bool {
+	return math.Abs(predictedMM-expectedMM) <= math.Max(noduleSizeToleranceRatio*expectedMM, noduleSizeToleranceMM)
+}
+
+// normalizeLocation returns an anatomical location in lower case with single spaces.
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.Join(strings.Fields(location), " "))
+}
+
+// FindingScore compares the findings extracted from a report with the expected ones. An expected finding is found if
+// its text appears in the description or type of an extracted finding (case-insensitive).
+type FindingScore struct {
+	Expected int      `json:"expected"`
+	Found    int      `json:"found"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// scoreFindings looks up every expected finding in the extracted ones.
+func scoreFindings(expected []string, findings []*models.Finding) FindingScore {
+	score := FindingScore{Expected: len(expected)}
+	for _, want := range expected {
+		if findingFound(want, findings) {
+			score.Found++
+		} else {
+			score.Missing = append(score.Missing, want)
+		}
+	}
+	return score
+}
+
+// findingFound reports whether text appears in the description or type of one of the findings.
+func findingFound(text string, findings []*models.Finding) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, finding := range findings {
+		if strings.Contains(strings.ToLower(finding.Description), text) || strings.Contains(strings.ToLower(finding.FindingType), text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// internal/evaluation/scoring_test.go
+package evaluation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stackvity/lung-server/internal/data/models"
+)
+
+func TestNormalizeTNM(t *testing.T) {
+	tests := []struct {
+		component string
+		prefix    byte
+		want      string
+	}{
+		{"T2a", 'T', "T2A"},
+		{"t2a", 'T', "T2A"},
+		{"2A", 'T', "T2A"},
+		{" T 2 a ", 'T', "T2A"},
+		{"cT2a", 'T', "T2A"},
+		{"pT1b", 'T', "T1B"},
+		{"ypT0", 'T', "T0"},
+		{"rT3", 'T', "T3"},
+		{"Tis", 'T', "TIS"},
+		{"is", 'T', "TIS"},
+		{"TX", 'T', "TX"},
+		{"pN1", 'N', "N1"},
+		{"N2b", 'N', "N2B"},
+		{"cM1c", 'M', "M1C"},
+		{"0", 'M', "M0"},
+		{"", 'T', ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTNM(tt.component, tt.prefix); got != tt.want {
+			t.Errorf("normalizeTNM(%q, %c) = %q, want %q", tt.component, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestTNMCategory(t *testing.T) {
+	tests := map[string]string{"T2A": "T2", "T2": "T2", "TIS": "TIS", "N3": "N3", "M1C": "M1", "TX": "TX", "T": "T", "": ""}
+	for component, want := range tests {
+		if got := tnmCategory(component); got != want {
+			t.Errorf("tnmCategory(%q) = %q, want %q", component, got, want)
+		}
+	}
+}
+
+func TestScoreTNM(t *testing.T) {
+	tests := []struct {
+		name      string
+		expected  TNMLabel
+		predicted models.Stage
+		exact     bool
+		t, n, m   bool
+		partial   int
+	}{
+		{"exact", TNMLabel{"T2a", "N1", "M0"}, models.Stage{T: "T2a", N: "N1", M: "M0"}, true, true, true, true, 3},
+		{"exact after normalization", TNMLabel{"T2a", "N1", "M0"}, models.Stage{T: "cT2A", N: "pn1", M: "0"}, true, true, true, true, 3},
+		{"subcategory differs", TNMLabel{"T2a", "N1", "M0"}, models.Stage{T: "T2b", N: "N1", M: "M0"}, false, false, true, true, 3},
+		{"category differs", TNMLabel{"T2a", "N1", "M0"}, models.Stage{T: "T3", N: "N2", M: "M0"}, false, false, false, true, 1},
+		{"metastasis subcategory", TNMLabel{"T4", "N3", "M1c"}, models.Stage{T: "T4", N: "N3", M: "M1a"}, false, true, true, false, 3},
+		{"in situ", TNMLabel{"Tis", "N0", "M0"}, models.Stage{T: "pTis", N: "N0", M: "M0"}, true, true, true, true, 3},
+		{"in situ against T1", TNMLabel{"Tis", "N0", "M0"}, models.Stage{T: "T1", N: "N0", M: "M0"}, false, false, true, true, 2},
+		{"nothing predicted", TNMLabel{"T1", "N0", "M0"}, models.Stage{}, false, false, false, false, 0},
+		{"nothing expected", TNMLabel{}, models.Stage{T: "T1", N: "N0", M: "M0"}, false, false, false, false, 0},
+		{"nothing expected nor predicted", TNMLabel{}, models.Stage{}, true, true, true, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := scoreTNM(tt.expected, &tt.predicted)
+			if score.Exact != tt.exact || score.T != tt.t || score.N != tt.n || score.M != tt.m || score.Partial != tt.partial {
+				t.Errorf("scoreTNM = exact %v, T/N/M %v/%v/%v, partial %d; want %v, %v/%v/%v, %d",
+					score.Exact, score.T, score.N, score.M, score.Partial, tt.exact, tt.t, tt.n, tt.m, tt.partial)
+			}
+			if score.Predicted != (TNMLabel{tt.predicted.T, tt.predicted.N, tt.predicted.M}) {
+				t.Errorf("Predicted = %+v, want the staging as returned", score.Predicted)
+			}
+		})
+	}
+}
+
+func TestScoreNodules(t *testing.T) {
+	nodule := func(location string, size float64) *models.Nodule {
+		return &models.Nodule{Location: location, Size: size}
+	}
+	tests := []struct {
+		name      string
+		expected  []NoduleLabel
+		predicted []*models.Nodule
+		matched   int
+		sizeError float64
+		pairs     []NodulePair
+	}{
+		{"same location and size", []NoduleLabel{{"right upper lobe", 12}}, []*models.Nodule{nodule("right upper lobe", 12)}, 1, 0,
+			[]NodulePair{{"right upper lobe", 12, 12}}},
+		{"location case and spacing ignored", []NoduleLabel{{"Right Upper Lobe", 12}}, []*models.Nodule{nodule(" right  upper lobe", 14)}, 1, 2,
+			[]NodulePair{{"Right Upper Lobe", 12, 14}}},
+		{"other location", []NoduleLabel{{"right upper lobe", 12}}, []*models.Nodule{nodule("left upper lobe", 12)}, 0, 0, nil},
+		{"within half the expected size", []NoduleLabel{{"right upper lobe", 20}}, []*models.Nodule{nodule("right upper lobe", 30)}, 1, 10,
+			[]NodulePair{{"right upper lobe", 20, 30}}},
+		{"beyond half the expected size", []NoduleLabel{{"right upper lobe", 20}}, []*models.Nodule{nodule("right upper lobe", 31)}, 0, 0, nil},
+		{"small nodule within 3 mm", []NoduleLabel{{"left lower lobe", 4}}, []*models.Nodule{nodule("left lower lobe", 7)}, 1, 3,
+			[]NodulePair{{"left lower lobe", 4, 7}}},
+		{"small nodule beyond 3 mm", []NoduleLabel{{"left lower lobe", 4}}, []*models.Nodule{nodule("left lower lobe", 7.5)}, 0, 0, nil},
+		{"closest size wins", []NoduleLabel{{"right upper lobe", 10}}, []*models.Nodule{nodule("right upper lobe", 14), nodule("right upper lobe", 9)}, 1, 1,
+			[]NodulePair{{"right upper lobe", 10, 9}}},
+		{"each detected nodule matched once", []NoduleLabel{{"right upper lobe", 10}, {"right upper lobe", 11}}, []*models.Nodule{nodule("right upper lobe", 10)}, 1, 0,
+			[]NodulePair{{"right upper lobe", 10, 10}}},
+		{"two nodules in one lobe", []NoduleLabel{{"right upper lobe", 10}, {"right upper lobe", 20}}, []*models.Nodule{nodule("right upper lobe", 21), nodule("right upper lobe", 9)}, 2, 2,
+			[]NodulePair{{"right upper lobe", 10, 9}, {"right upper lobe", 20, 21}}},
+		{"nothing expected", nil, []*models.Nodule{nodule("right upper lobe", 10)}, 0, 0, nil},
+		{"nothing detected", []NoduleLabel{{"right upper lobe", 10}}, nil, 0, 0, nil},
+		{"nothing expected nor detected", nil, nil, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := scoreNodules(tt.expected, tt.predicted)
+			if score.Expected != len(tt.expected) || score.Predicted != len(tt.predicted) {
+				t.Errorf("counted %d expected and %d detected nodules, want %d and %d", score.Expected, score.Predicted, len(tt.expected), len(tt.predicted))
+			}
+			if score.Matched != tt.matched || score.SizeErrorMM != tt.sizeError {
+				t.Errorf("%d matched with a size error of %v mm, want %d and %v mm", score.Matched, score.SizeErrorMM, tt.matched, tt.sizeError)
+			}
+			if !reflect.DeepEqual(score.Pairs, tt.pairs) {
+				t.Errorf("pairs %+v, want %+v", score.Pairs, tt.pairs)
+			}
+		})
+	}
+}
+
+func TestScoreFindings(t *testing.T) {
+	findings := []*models.Finding{
+		{FindingType: "potential nodule", Description: "Spiculated nodule in the right upper lobe, 18 mm"},
+		{FindingType: "Pleural Effusion", Description: "Small effusion on the left"},
+	}
+	tests := []struct {
+		name     string
+		expected []string
+		findings []*models.Finding
+		found    int
+		missing  []string
+	}{
+		{"in a description", []string{"spiculated nodule"}, findings, 1, nil},
+		{"in a type, case ignored", []string{"pleural effusion"}, findings, 1, nil},
+		{"surrounding spaces ignored", []string{"  Right Upper Lobe "}, findings, 1, nil},
+		{"missing", []string{"lymphadenopathy", "18 mm"}, findings, 1, []string{"lymphadenopathy"}},
+		{"nothing extracted", []string{"nodule"}, nil, 0, []string{"nodule"}},
+		{"nothing expected", nil, findings, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := scoreFindings(tt.expected, tt.findings)
+			if score.Expected != len(tt.expected) || score.Found != tt.found || !reflect.DeepEqual(score.Missing, tt.missing) {
+				t.Errorf("scoreFindings = %+v, want %d of %d found, missing %q", score, tt.found, len(tt.expected), tt.missing)
+			}
+		})
+	}
+}
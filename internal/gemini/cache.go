@@ -0,0 +1,426 @@
+// internal/gemini/cache.go
+package gemini
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+	"github.com/stackvity/lung-server/internal/security"
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// Cache backends selectable with config.LLMCacheBackend.
+const (
+	CacheBackendNone     = "none"     // No caching: every call reaches the provider
+	CacheBackendMemory   = "memory"   // LRUResponseCache, per process
+	CacheBackendPostgres = "postgres" // llmcache table, shared by every instance
+)
+
+// Cache modes of one request, set with WithCacheMode (the X-LLM-Cache header of admin requests).
+const (
+	CacheModeDefault = ""        // Answer from the cache when possible and store fresh responses
+	CacheModeRefresh = "refresh" // Skip the lookup, but store the fresh response, replacing the cached one
+	CacheModeBypass  = "bypass"  // Neither read nor write the cache
+)
+
+// cachePurgeInterval is how often CachingClient deletes expired responses from its backend.
+const cachePurgeInterval = time.Hour
+
+type cacheModeKey struct{}
+
+type promptKey struct{}
+
+// WithCacheMode returns a copy of ctx in which model calls use the given cache mode (one of the CacheMode constants).
+func WithCacheMode(ctx context.Context, mode string) context.Context {
+	return context.WithValue(ctx, cacheModeKey{}, mode)
+}
+
+// cacheMode returns the cache mode of ctx, CacheModeDefault if none was set.
+func cacheMode(ctx context.Context) string {
+	mode, _ := ctx.Value(cacheModeKey{}).(string)
+	return mode
+}
+
+// WithPrompt returns a copy of ctx carrying the managed prompt a model call is made with, so that decorators such as
+// CachingClient can tell prompt versions apart. Services wrap the context of every GeminiClient call with it.
+func WithPrompt(ctx context.Context, prompt *RenderedPrompt) context.Context {
+	return context.WithValue(ctx, promptKey{}, prompt)
+}
+
+// promptFromContext returns the prompt set with WithPrompt, or nil.
+func promptFromContext(ctx context.Context) *RenderedPrompt {
+	prompt, _ := ctx.Value(promptKey{}).(*RenderedPrompt)
+	return prompt
+}
+
+// ResponseCache is a backend of CachingClient. Bodies are opaque (encrypted) bytes.
+type ResponseCache interface {
+	// GetCachedResponse returns the body cached under key; found is false if none is cached or it has expired.
+	GetCachedResponse(ctx context.Context, key string) (body []byte, found bool, err error)
+	// PutCachedResponse caches the body of a method's response, generated for a session (uuid.Nil if none), under key
+	// until expiresAt, replacing an earlier one.
+	PutCachedResponse(ctx context.Context, key, method string, sessionID uuid.UUID, body []byte, expiresAt time.Time) error
+	// DeleteExpiredResponses drops every expired body.
+	DeleteExpiredResponses(ctx context.Context) error
+	// DeleteSessionResponses drops every body generated for a session, when the session's data is deleted.
+	DeleteSessionResponses(ctx context.Context, sessionID uuid.UUID) error
+}
+
+// ResponseCacheStore is the persistent ResponseCache (the llmcache table), bound by Wire to the repository.
+type ResponseCacheStore interface {
+	ResponseCache
+}
+
+// NewResponseCache is the Wire provider of the backend selected by cfg.LLMCacheBackend. It returns nil for
+// CacheBackendNone, which disables caching.
+func NewResponseCache(cfg *config.Config, store ResponseCacheStore) (ResponseCache, error) {
+	switch cfg.LLMCacheBackend {
+	case CacheBackendNone:
+		return nil, nil
+	case CacheBackendMemory:
+		return NewLRUResponseCache(cfg.LLMCacheSize), nil
+	case CacheBackendPostgres:
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_CACHE_BACKEND %q: expected %q, %q or %q", cfg.LLMCacheBackend, CacheBackendMemory, CacheBackendPostgres, CacheBackendNone)
+	}
+}
+
+// CachingClient decorates a GeminiClient with a cache of successful responses, so that reprocessing the same
+// document (retries, re-uploads) does not call the model again.
+//
+// Responses are keyed by a SHA-256 of the method, the provider and model, the managed prompt and its version (see
+// WithPrompt) and the JSON of the input, and are reused for LLMCacheTTL. Bodies are encrypted with
+// FILE_ENCRYPTION_KEY before they reach the backend. Failed calls are never cached. Cache failures are logged and
+// fall back to calling the model, so the cache can never break a call.
+//
+// Every response is stored with the session of its prompt (the last one to store it, if sessions share an input), so
+// that deleting a patient's data deletes the responses derived from it (ResponseCache.DeleteSessionResponses).
+type CachingClient struct {
+	inner  GeminiClient
+	cache  ResponseCache // nil disables caching
+	key    []byte        // Encryption key of cached bodies
+	model  string        // Provider and model, part of every cache key
+	ttl    time.Duration
+	logger *zap.Logger
+
+	purgeMu   sync.Mutex
+	nextPurge time.Time // Next time expired responses are deleted from the backend
+}
+
+var _ GeminiClient = (*CachingClient)(nil)
+
+// NewCachingClient wraps inner with the given cache backend (nil disables caching). Responses served from the cache
+// never reach inner, so they use no tokens and count against no budget.
+func NewCachingClient(inner *MeteredClient, cache ResponseCache, cfg *config.Config, logger *zap.Logger) (*CachingClient, error) {
+	c := &CachingClient{
+		inner:     inner,
+		cache:     cache,
+		key:       []byte(cfg.FileEncryptionKey),
+		model:     providerModel(cfg),
+		ttl:       cfg.LLMCacheTTL,
+		logger:    logger.Named("CachingGeminiClient"),
+		nextPurge: time.Now().Add(cachePurgeInterval),
+	}
+	if cache == nil {
+		logger.Info("Model response cache disabled", zap.String("operation", "gemini.NewCachingClient"))
+		return c, nil
+	}
+	switch len(c.key) {
+	case 16, 24, 32: // AES-128, AES-192 or AES-256
+	default:
+		return nil, fmt.Errorf("FILE_ENCRYPTION_KEY must be 16, 24 or 32 bytes long to encrypt cached model responses, got %d", len(c.key))
+	}
+	logger.Info("Model response cache enabled", zap.String("operation", "gemini.NewCachingClient"), zap.String("backend", cfg.LLMCacheBackend), zap.Duration("ttl", c.ttl))
+	return c, nil
+}
+
+// providerModel names the provider and model answering calls, so that switching either invalidates the cache.
+func providerModel(cfg *config.Config) string {
+	switch cfg.LLMProvider {
+	case ProviderOpenAI:
+		return ProviderOpenAI + "/" + cfg.OpenAIModel
+	case ProviderFake:
+		return ProviderFake + "/" + cfg.FakeLLMFixtures
+	default:
+		return ProviderGemini + "/" + cfg.GeminiModel
+	}
+}
+
+// DetectNodules answers from the cache or calls the wrapped client.
+func (c *CachingClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return callCached(ctx, c, "DetectNodules", input, func(ctx context.Context) (*models.NoduleDetectionOutput, error) {
+		return c.inner.DetectNodules(ctx, input)
+	})
+}
+
+// AnalyzePathologyReport answers from the cache or calls the wrapped client.
+func (c *CachingClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	return callCached(ctx, c, "AnalyzePathologyReport", input, func(ctx context.Context) (*models.PathologyReportAnalysisOutput, error) {
+		return c.inner.AnalyzePathologyReport(ctx, input)
+	})
+}
+
+// ExtractInformation answers from the cache or calls the wrapped client.
+func (c *CachingClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	return callCached(ctx, c, "ExtractInformation", input, func(ctx context.Context) (*models.InformationExtractionOutput, error) {
+		return c.inner.ExtractInformation(ctx, input)
+	})
+}
+
+// GeneratePreliminaryDiagnosis answers from the cache or calls the wrapped client.
+func (c *CachingClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	return callCached(ctx, c, "GeneratePreliminaryDiagnosis", input, func(ctx context.Context) (*models.DiagnosisOutput, error) {
+		return c.inner.GeneratePreliminaryDiagnosis(ctx, input)
+	})
+}
+
+// GetStagingInformation answers from the cache or calls the wrapped client.
+func (c *CachingClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	return callCached(ctx, c, "GetStagingInformation", input, func(ctx context.Context) (*models.StagingOutput, error) {
+		return c.inner.GetStagingInformation(ctx, input)
+	})
+}
+
+// SuggestTreatmentOptions answers from the cache or calls the wrapped client.
+func (c *CachingClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	return callCached(ctx, c, "SuggestTreatmentOptions", input, func(ctx context.Context) (*models.TreatmentRecommendationOutput, error) {
+		return c.inner.SuggestTreatmentOptions(ctx, input)
+	})
+}
+
+// callCached answers a call of method from the cache, or makes it with fn and caches the response.
+func callCached[T any](ctx context.Context, c *CachingClient, method string, input any, fn func(context.Context) (*T, error)) (*T, error) {
+	operation := "CachingGeminiClient." + method
+	requestID := utils.GetRequestID(ctx)
+
+	mode := cacheMode(ctx)
+	if c.cache == nil || mode == CacheModeBypass {
+		return fn(ctx)
+	}
+	key, err := c.cacheKey(ctx, method, input)
+	if err != nil {
+		c.logger.Warn("Failed to compute cache key, calling the model", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+		return fn(ctx)
+	}
+
+	// 1. Answer from the cache, unless the request asks for a fresh response
+	if mode != CacheModeRefresh {
+		if output, ok := lookupCached[T](ctx, c, key); ok {
+			c.logger.Debug("Model response served from cache", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("cache_key", key))
+			return output, nil
+		}
+	}
+
+	// 2. Call the model; failures are returned as they are and never cached
+	output, err := fn(ctx)
+	if err != nil {
+		return output, err
+	}
+
+	// 3. Cache the response
+	if err := c.store(ctx, key, method, output); err != nil {
+		c.logger.Warn("Failed to cache model response", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("cache_key", key), zap.Error(err))
+	}
+	c.purgeExpired(ctx)
+	return output, nil
+}
+
+// cacheKey hashes the method, the provider and model, the prompt version and the JSON of the input. Every part is
+// length-prefixed, so that different parts can never produce the same hash input.
+func (c *CachingClient) cacheKey(ctx context.Context, method string, input any) (string, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("encoding input: %w", err)
+	}
+	promptVersion := ""
+	if prompt := promptFromContext(ctx); prompt != nil {
+		promptVersion = prompt.ID + "@" + prompt.VersionID.String()
+	}
+
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(method), []byte(c.model), []byte(promptVersion), payload} {
+		writeKeyPart(h, part)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeKeyPart writes a length-prefixed part of a cache key.
+func writeKeyPart(h hash.Hash, part []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+	h.Write(length[:])
+	h.Write(part)
+}
+
+// lookupCached returns the response cached under key. Backend, decryption and decoding failures count as misses.
+func lookupCached[T any](ctx context.Context, c *CachingClient, key string) (*T, bool) {
+	const operation = "CachingGeminiClient.lookup"
+
+	body, found, err := c.cache.GetCachedResponse(ctx, key)
+	if err != nil {
+		c.logger.Warn("Failed to read model response cache", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.Error(err))
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	plaintext, err := security.Decrypt(c.key, body)
+	if err != nil {
+		c.logger.Warn("Failed to decrypt cached model response", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("cache_key", key), zap.Error(err))
+		return nil, false
+	}
+	output := new(T)
+	if err := json.Unmarshal(plaintext, output); err != nil {
+		c.logger.Warn("Failed to decode cached model response", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("cache_key", key), zap.Error(err))
+		return nil, false
+	}
+	return output, true
+}
+
+// store encrypts a response and caches it under key for the TTL.
+func (c *CachingClient) store(ctx context.Context, key, method string, output any) error {
+	plaintext, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("encoding response: %w", err)
+	}
+	body, err := security.Encrypt(c.key, plaintext)
+	if err != nil {
+		return fmt.Errorf("encrypting response: %w", err)
+	}
+	sessionID := uuid.Nil
+	if prompt := promptFromContext(ctx); prompt != nil {
+		sessionID = prompt.SessionID
+	}
+	return c.cache.PutCachedResponse(ctx, key, method, sessionID, body, time.Now().Add(c.ttl))
+}
+
+// purgeExpired deletes expired responses from the backend, at most once per cachePurgeInterval.
+func (c *CachingClient) purgeExpired(ctx context.Context) {
+	c.purgeMu.Lock()
+	now := time.Now()
+	if now.Before(c.nextPurge) {
+		c.purgeMu.Unlock()
+		return
+	}
+	c.nextPurge = now.Add(cachePurgeInterval)
+	c.purgeMu.Unlock()
+
+	if err := c.cache.DeleteExpiredResponses(ctx); err != nil {
+		c.logger.Warn("Failed to delete expired model responses", zap.String("operation", "CachingGeminiClient.purgeExpired"), zap.String("request_id", utils.GetRequestID(ctx)), zap.Error(err))
+	}
+}
+
+// LRUResponseCache is an in-memory ResponseCache holding at most size bodies, evicting the least recently used one.
+// It is safe for concurrent use.
+type LRUResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List               // Front: most recently used
+	entries map[string]*list.Element // Key -> element of order holding an *lruEntry
+}
+
+// lruEntry is a cached body of LRUResponseCache.
+type lruEntry struct {
+	key       string
+	sessionID uuid.UUID
+	body      []byte
+	expiresAt time.Time
+}
+
+var _ ResponseCache = (*LRUResponseCache)(nil)
+
+// NewLRUResponseCache creates an LRUResponseCache holding at most size bodies (at least one).
+func NewLRUResponseCache(size int) *LRUResponseCache {
+	return &LRUResponseCache{
+		size:    max(size, 1),
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// GetCachedResponse implements ResponseCache.
+func (l *LRUResponseCache) GetCachedResponse(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		l.remove(element)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(element)
+	return entry.body, true, nil
+}
+
+// PutCachedResponse implements ResponseCache.
+func (l *LRUResponseCache) PutCachedResponse(_ context.Context, key, _ string, sessionID uuid.UUID, body []byte, expiresAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.sessionID, entry.body, entry.expiresAt = sessionID, body, expiresAt
+		l.order.MoveToFront(element)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, sessionID: sessionID, body: body, expiresAt: expiresAt})
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+// DeleteExpiredResponses implements ResponseCache.
+func (l *LRUResponseCache) DeleteExpiredResponses(_ context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for element := l.order.Front(); element != nil; {
+		next := element.Next()
+		if !now.Before(element.Value.(*lruEntry).expiresAt) {
+			l.remove(element)
+		}
+		element = next
+	}
+	return nil
+}
+
+// DeleteSessionResponses implements ResponseCache.
+func (l *LRUResponseCache) DeleteSessionResponses(_ context.Context, sessionID uuid.UUID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for element := l.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*lruEntry).sessionID == sessionID {
+			l.remove(element)
+		}
+		element = next
+	}
+	return nil
+}
+
+// remove drops an element; l.mu must be held.
+func (l *LRUResponseCache) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,70 @@
+// internal/gemini/cache_test.go
+package gemini
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/gemini/models"
+)
+
+// countingClient answers every staging call and counts the calls that reach it.
+type countingClient struct {
+	GeminiClient
+	calls int
+}
+
+func (c *countingClient) GetStagingInformation(_ context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	c.calls++
+	return &models.StagingOutput{T: "T1c", N: "N0", M: "M0", StageValue: "Stage IA3", Confidence: "High"}, nil
+}
+
+func TestCachingClientDeletesSessionResponses(t *testing.T) {
+	inner := &countingClient{}
+	cache := NewLRUResponseCache(16)
+	client := &CachingClient{
+		inner:     inner,
+		cache:     cache,
+		key:       []byte("0123456789abcdef0123456789abcdef"),
+		model:     "fake/test",
+		ttl:       time.Hour,
+		logger:    zap.NewNop(),
+		nextPurge: time.Now().Add(cachePurgeInterval),
+	}
+
+	deleted, kept := uuid.New(), uuid.New()
+	stage := func(sessionID uuid.UUID, findings string) {
+		t.Helper()
+		ctx := WithPrompt(context.Background(), &RenderedPrompt{ID: PromptStagingInformation, Version: 1, SessionID: sessionID})
+		if _, err := client.GetStagingInformation(ctx, &models.StagingInput{PatientID: sessionID, Prompt: "Stage this case.", FindingsSummary: findings}); err != nil {
+			t.Fatalf("GetStagingInformation: %v", err)
+		}
+	}
+
+	stage(deleted, "- Spiculated nodule, 18 mm")
+	stage(kept, "- Ground-glass nodule, 7 mm")
+	stage(deleted, "- Spiculated nodule, 18 mm")
+	stage(kept, "- Ground-glass nodule, 7 mm")
+	if inner.calls != 2 {
+		t.Fatalf("%d calls reached the model, want 2 (one per session, then cached)", inner.calls)
+	}
+
+	if err := cache.DeleteSessionResponses(context.Background(), deleted); err != nil {
+		t.Fatalf("DeleteSessionResponses: %v", err)
+	}
+	if len(cache.entries) != 1 {
+		t.Errorf("%d responses cached after deleting a session, want 1", len(cache.entries))
+	}
+	stage(kept, "- Ground-glass nodule, 7 mm")
+	if inner.calls != 2 {
+		t.Errorf("the response of the other session was not served from the cache")
+	}
+	stage(deleted, "- Spiculated nodule, 18 mm")
+	if inner.calls != 3 {
+		t.Errorf("the response of the deleted session was served from the cache")
+	}
+}
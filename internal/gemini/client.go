@@ -3,14 +3,12 @@ package gemini
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 
 	"go.uber.org/zap"
 
 	"github.com/stackvity/lung-server/internal/config"
-	"github.com/stackvity/lung-server/internal/domain"        // Import domain for custom errors
 	"github.com/stackvity/lung-server/internal/gemini/models" // Import the models package
-	"github.com/stackvity/lung-server/internal/utils"         // Import utils for error handling
 )
 
 // GeminiClient defines the interface for interacting with the Gemini API.
@@ -25,148 +23,55 @@ type GeminiClient interface {
 	SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) // ADDED: SuggestTreatmentOptions
 }
 
-// GeminiProClient implements the GeminiClient interface, providing a concrete implementation
-// for interacting with the Google AI Gemini Pro API.
-// It encapsulates the API client, API key, and any specific logic required for interacting with the Gemini Pro API.
+// GeminiProClient implements the GeminiClient interface on the Gemini REST API (models/{model}:generateContent).
+// Every call asks for JSON output constrained by a response schema derived from the method's output struct
+// (see schemaFor), sends images as inline parts and is bounded by GeminiAPITimeout.
+// Failures are returned as *ErrGeminiRequestFailed (transport errors, non-2xx statuses) or
+// *ErrGeminiResponseParsingFailed (blocked prompts, missing or malformed structured output).
 type GeminiProClient struct {
-	config *config.Config // Configuration to access API key and other settings
-	logger *zap.Logger    // Logger for structured logging
+	config     *config.Config // Configuration to access API key and other settings
+	httpClient *http.Client   // Shared client; connections are kept alive between calls
+	logger     *zap.Logger    // Logger for structured logging
 }
 
 // NewGeminiProClient creates a new GeminiProClient, injecting the configuration and logger.
 // It takes a Config and Logger as dependencies, promoting dependency injection for testability and configurability.
 func NewGeminiProClient(cfg *config.Config, logger *zap.Logger) *GeminiProClient {
 	return &GeminiProClient{
-		config: cfg,
-		logger: logger.Named("GeminiProClient"), // Creates a logger specific to GeminiProClient for contextual logging
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.GeminiAPITimeout}, // Also bounds reading the body; each call additionally carries a context deadline
+		logger:     logger.Named("GeminiProClient"),             // Creates a logger specific to GeminiProClient for contextual logging
 	}
 }
 
-// DetectNodules calls the Gemini API to detect lung nodules in a DICOM image.
-func (c *GeminiProClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
-	const operation = "GeminiProClient.DetectNodules"
-	requestID := utils.GetRequestID(ctx)
-	//
-	// Detailed steps for implementation (Pseudocode-like Guidance):
-	// 1. **API Request Payload Construction:**
-	//    ```go
-	//    requestPayload := &geminiapi.NoduleDetectionRequest{ // Assuming a struct for request
-	//        ImageData:    input.ImageData,
-	//        ImageType:   input.ImageType,
-	//        UserPrompt:    input.Prompt,
-	//        ApiKey:        c.config.GeminiAPIKey, // *Security Critical: Securely retrieve API key - DO NOT HARDCODE!*
-	//        Options:       /* ... API request options (timeouts, regions, etc.) ... */,
-	//    }
-	//    ```
-	//    - Construct the JSON request payload for the Gemini API's nodule detection endpoint.
-	//    - Use the data from the `input` (*models.NoduleDetectionInput) parameter to populate the request payload. This includes: `ImageData`, `ImageType`, and `Prompt`.
-	//    - Retrieve prompts from `internal/gemini/prompts.go` or a database (BE-028, BE-037a, BE-038), ensuring prompt versioning and management are considered.
-	//    - Refer to the Google AI Gemini API documentation for the precise request format. *Adhere strictly to the API contract.*
-	//
-	// 2. **Authentication:** Retrieve the Gemini API key from the application configuration (`c.config.GeminiAPIKey`).
-	//    - *Security Best Practice (Critical):*  *NEVER* hardcode API keys directly in the code. Use environment variables or a dedicated secrets management service for production deployments (e.g., HashiCorp Vault, AWS Secrets Manager, Google Cloud Secret Manager). Ensure proper access control and rotation policies for API keys.
-	//    - Include the API key in the request headers (recommended for security) or as a query parameter, strictly following the Gemini API authentication guidelines.
-	//
-	// 3. **HTTP API Call:** Use Go's `net/http` package or a suitable HTTP client library (e.g., `resty`, `go-resty`) to make a POST request to the Gemini API endpoint.
-	//    ```go
-	//    client := http.Client{Timeout: time.Duration(c.config.GeminiAPITimeout)} // Configure HTTP client with timeouts
-	//    resp, err := client.Post(geminiAPIEndpoint, "application/json", bytes.NewBuffer(payloadJson)) // Make POST request
-	//    if err != nil { /* ... Handle network errors ... */ }
-	//    defer resp.Body.Close() // Ensure body closure
-	//    ```
-	//    - Configure the HTTP client with appropriate settings, such as timeouts (`http.Client{Timeout: ...}`, configurable via `c.config`), to prevent indefinite delays and ensure responsiveness.
-	//    - Enable HTTP Keep-Alive to reuse connections and improve performance. Consider using a connection pool for the HTTP client for efficient connection management.
-	//    - Set the correct HTTP headers: `Content-Type: application/json`, `Authorization: Bearer <API_KEY>` (or as required by Gemini API).
-	//
-	// 4. **Error Handling and Retries (BE-036):** Implement robust error handling for all potential failure scenarios during the API call.
-	//     ```go
-	//     if err != nil {
-	//         // Network errors (DNS resolution, TLS handshake, connection refused)
-	//         if netErr, ok := err.(net.Error); ok && netErr.Timeout() {  /* Handle timeout specifically */ }
-	//         return nil, domain.NewErrGeminiNoduleDetectionFailed("Network error calling Gemini API", err) // Use custom error
-	//     }
-	//     if resp.StatusCode != http.StatusOK {
-	//         // API-specific errors (check resp.StatusCode and response body for details)
-	//         if resp.StatusCode == http.StatusTooManyRequests { /* Handle rate limiting */ }
-	//         if resp.StatusCode == http.StatusUnauthorized {  /* Handle invalid API key */ }
-	//         return nil, domain.NewErrGeminiNoduleDetectionFailed(fmt.Sprintf("Gemini API returned error status: %d", resp.StatusCode), errors.New(resp.Status)) // Include status code in error
-	//     }
-	//     ```
-	//    - Handle network errors (DNS resolution failures, timeouts, connection refused) using Go's `net` package error types. Implement retry mechanisms with exponential backoff and jitter to handle transient network glitches and server-side issues.
-	//    - Implement specific error handling for Gemini API-specific errors based on HTTP status codes (4xx and 5xx) and response bodies. Pay special attention to rate limiting errors (429 Too Many Requests) and authentication errors (401 Unauthorized).
-	//    - Use context-aware HTTP requests (`http.NewRequestWithContext`) to ensure that API calls respect deadlines and cancellations from the incoming request context.
-	//
-	// 5. **Request and Response Logging (BE-037):** Implement detailed logging for all Gemini API interactions.
-	//     ```go
-	//     c.logger.Debug("Gemini API Request", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("endpoint", geminiAPIEndpoint), zap.String("method", "POST"), zap.ByteString("request_body", scrubbedPayload)) // Scrub sensitive data from logs!
-	//     c.logger.Info("Gemini API Response", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("status_code", resp.StatusCode), zap.String("response_headers", fmt.Sprintf("%v", resp.Header)) /* , zap.ByteString("response_body", scrubbedResponse) */) // Scrub response body if it contains PII
-	//     if resp.StatusCode != http.StatusOK {
-	//         c.logger.Warn("Gemini API Error Response", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("status_code", resp.StatusCode), zap.String("error_message", errorMessage), zap.Error(apiError)) // Log API errors at Warn or Error level
-	//     }
-	//     ```
-	//    - Log API request details *before* sending the request: endpoint URL, HTTP method, request headers (excluding sensitive API keys), and a scrubbed/truncated version of the request body (to avoid logging PII). Use `c.logger.Debug` or `c.logger.Info` level. *Security Best Practice: Scrub or mask any potentially sensitive patient data (PHI) from the request and response logs to comply with data privacy regulations.*
-	//    - Log API response details *after* receiving the response: HTTP status code, response headers (including rate limiting headers like `Retry-After`), and a scrubbed/truncated version of the response body. Use `c.logger.Debug` or `c.logger.Info` level.
-	//    - In case of errors, log the error details using `c.logger.Warn` or `c.logger.Error` level, including: the error type, error message, request ID, operation name, API endpoint URL, HTTP status code, and relevant parts of the request and response for comprehensive debugging context.
-	//
-	// 6. **Response Parsing:** Parse the JSON response body from the Gemini API, carefully handling potential variations in the response structure and data types robustly.
-	//     ```go
-	//     var geminiOutput models.NoduleDetectionOutput // Struct to unmarshal Gemini response
-	//     decoder := json.NewDecoder(resp.Body)
-	//     err = decoder.Decode(&geminiOutput)
-	//     if err != nil { /* ... Handle JSON parsing errors ... */ }
-	//     ```
-	//    - Unmarshal the JSON response into a `models.NoduleDetectionOutput` struct using `encoding/json.NewDecoder` for efficient decoding and stream processing.
-	//    - Implement robust error handling for JSON parsing failures (e.g., invalid JSON format, unexpected data types, missing fields in the response). Use custom error types (e.g., `domain.ErrGeminiResponseParsingFailed`) to represent JSON parsing errors.
-	//
-	// 7. **Error Handling and Custom Errors:** Implement comprehensive error handling throughout the API call and response processing pipeline.
-	//    - Wrap any errors that occur during API calls, response parsing, or data processing using `fmt.Errorf` with `%w` to preserve the original error context and enable error unwrapping in the upper layers of the application.
-	//    - For Gemini API-specific errors or business logic errors (e.g., invalid API key, rate limit exceeded, nodule detection failure, unexpected response format), return appropriate custom error types defined in `internal/domain/errors.go` (e.g., `domain.ErrGeminiNoduleDetectionFailed`). This ensures consistent and type-safe error handling and allows for specific error handling logic in the service layer.
-
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID)) // Warning log for placeholder
+var _ GeminiClient = (*GeminiProClient)(nil)
 
-	// Placeholder response - Replace with actual Gemini API call and response processing in BE-027, BE-030, BE-036, BE-037.
-	// In a real implementation, ensure to return a populated models.NoduleDetectionOutput and nil error on success.
-	// For failure scenarios, return nil for *models.NoduleDetectionOutput and a specific error type (e.g., domain.ErrGeminiNoduleDetectionFailed).
-	return nil, domain.NewErrGeminiNoduleDetectionFailed("Gemini API integration is not fully implemented yet (placeholder)", fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation)) // Placeholder error - using custom error type - Recommendation 1
+// DetectNodules calls the Gemini API to detect lung nodules in a DICOM series or image (see detectNodules).
+func (c *GeminiProClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return detectNodules(ctx, c, "GeminiProClient.DetectNodules", input)
 }
 
-// AnalyzePathologyReport - Placeholder implementation
+// AnalyzePathologyReport calls the Gemini API to extract findings from a (de-identified) pathology report.
 func (c *GeminiProClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
-	const operation = "GeminiProClient.AnalyzePathologyReport"
-	requestID := utils.GetRequestID(ctx)
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("operation", operation)) // Warning log
-
-	return nil, fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation) // Placeholder error
+	return analyzePathologyReport(ctx, c, "GeminiProClient.AnalyzePathologyReport", input)
 }
 
-// ExtractInformation - Placeholder implementation
+// ExtractInformation calls the Gemini API to extract findings (symptoms, signs, investigations) from a report.
 func (c *GeminiProClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
-	const operation = "GeminiProClient.ExtractInformation"
-	requestID := utils.GetRequestID(ctx)
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("operation", operation)) // Warning log
-	return nil, fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation)                                                                                                 // Placeholder error
+	return extractInformation(ctx, c, "GeminiProClient.ExtractInformation", input)
 }
 
-// GeneratePreliminaryDiagnosis - Placeholder implementation  // ADDED: GeneratePreliminaryDiagnosis Mock
+// GeneratePreliminaryDiagnosis calls the Gemini API for a preliminary diagnosis.
 func (c *GeminiProClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
-	const operation = "GeminiProClient.GeneratePreliminaryDiagnosis"
-	requestID := utils.GetRequestID(ctx)
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("operation", operation)) // Warning log
-	return nil, fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation)                                                                                                 // Placeholder error
+	return generatePreliminaryDiagnosis(ctx, c, "GeminiProClient.GeneratePreliminaryDiagnosis", input)
 }
 
-// GetStagingInformation - Placeholder implementation // ADDED: GetStagingInformation Mock
+// GetStagingInformation calls the Gemini API for TNM staging.
 func (c *GeminiProClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
-	const operation = "GeminiProClient.GetStagingInformation"
-	requestID := utils.GetRequestID(ctx)
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("operation", operation)) // Warning log
-	return nil, fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation)                                                                                                 // Placeholder error
+	return getStagingInformation(ctx, c, "GeminiProClient.GetStagingInformation", input)
 }
 
-// SuggestTreatmentOptions - Placeholder implementation // ADDED: SuggestTreatmentOptions Mock
+// SuggestTreatmentOptions calls the Gemini API for treatment options to discuss with the care team.
 func (c *GeminiProClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
-	const operation = "GeminiProClient.SuggestTreatmentOptions"
-	requestID := utils.GetRequestID(ctx)
-	c.logger.Warn("Gemini API integration not fully implemented - placeholder response returned", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("operation", operation)) // Warning log
-	return nil, fmt.Errorf("%s: Gemini API integration is not fully implemented yet (placeholder)", operation)                                                                                                 // Placeholder error
+	return suggestTreatmentOptions(ctx, c, "GeminiProClient.SuggestTreatmentOptions", input)
 }
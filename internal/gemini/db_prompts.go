@@ -0,0 +1,237 @@
+// internal/gemini/db_prompts.go
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackvity/lung-server/internal/config"
+	dataModels "github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ActivePromptSource is the part of the prompt registry DBPromptManager reads from
+// (implemented by postgres.PromptRepository).
+type ActivePromptSource interface {
+	// GetActivePrompt returns the active version of a prompt, or a *domain.NotFoundError when none is active.
+	GetActivePrompt(ctx context.Context, name string) (*dataModels.Prompt, error)
+	// GetPromptByID returns a prompt version by its ID.
+	GetPromptByID(ctx context.Context, promptID uuid.UUID) (*dataModels.Prompt, error)
+}
+
+// PromptExperimentSource is the part of the prompt experiments DBPromptManager reads from
+// (implemented by postgres.PromptExperimentRepository).
+type PromptExperimentSource interface {
+	// GetRunningPromptExperiment returns the running experiment of a prompt, or a *domain.NotFoundError when none runs.
+	GetRunningPromptExperiment(ctx context.Context, name string) (*dataModels.PromptExperiment, error)
+}
+
+// IsManagedPrompt reports whether promptID is the ID of a prompt used by one of the GeminiClient call sites.
+func IsManagedPrompt(promptID string) bool {
+	_, ok := promptOutputs[promptID]
+	return ok
+}
+
+// PromptTemplateFromVersion builds and compiles the template of a prompt registry version.
+func PromptTemplateFromVersion(version *dataModels.Prompt) (*PromptTemplate, error) {
+	prompt := &PromptTemplate{ID: version.Name, Template: version.Template, version: version.Version, versionID: version.ID}
+	if len(version.InputVariables) > 0 {
+		if err := json.Unmarshal(version.InputVariables, &prompt.Variables); err != nil {
+			return nil, fmt.Errorf("prompt %s: invalid input variables: %w", version.Name, err)
+		}
+	}
+	if len(version.OutputFormat) > 0 {
+		if err := json.Unmarshal(version.OutputFormat, &prompt.OutputFormat); err != nil {
+			return nil, fmt.Errorf("prompt %s: invalid output format: %w", version.Name, err)
+		}
+	}
+	if err := prompt.Compile(); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// cachedPrompt is a DBPromptManager cache entry. A nil prompt records that no version is active, so the prompt file
+// is used without asking the database again until the entry expires.
+type cachedPrompt struct {
+	prompt     *PromptTemplate
+	experiment *dataModels.PromptExperiment // Running experiment of the prompt, if any
+	treatment  *PromptTemplate              // Treatment version of the experiment
+	expires    time.Time
+}
+
+// assignArm assigns a session to an arm of an experiment. The assignment hashes the experiment and session IDs, so a
+// session keeps its arm for every call and across instances, while sessions are spread independently per experiment.
+// Calls without a session always use the control version.
+func assignArm(experiment *dataModels.PromptExperiment, sessionID uuid.UUID) string {
+	if sessionID == uuid.Nil {
+		return dataModels.PromptArmControl
+	}
+	sum := sha256.Sum256(append(experiment.ID[:], sessionID[:]...))
+	if binary.BigEndian.Uint64(sum[:8])%100 < uint64(experiment.TreatmentPercent) {
+		return dataModels.PromptArmTreatment
+	}
+	return dataModels.PromptArmControl
+}
+
+// DBPromptManager implements PromptManager with the prompt registry: a prompt's active version is used when there is
+// one, and its prompt file otherwise. While a prompt experiment runs, sessions assigned to its treatment arm get the
+// treatment version instead. Active versions and experiments are cached for PromptCacheTTL; the registry calls
+// Invalidate when they change, so changes made on this instance apply immediately.
+type DBPromptManager struct {
+	source      ActivePromptSource
+	experiments PromptExperimentSource
+	files       *FilePromptManager // Fallback for prompts without an active version
+	ttl         time.Duration
+	logger      *zap.Logger
+
+	mu    sync.RWMutex
+	cache map[string]cachedPrompt // Key is prompt ID
+}
+
+// NewDBPromptManager creates a new DBPromptManager reading active versions from source and running experiments from
+// experiments, falling back to files.
+func NewDBPromptManager(cfg *config.Config, source ActivePromptSource, experiments PromptExperimentSource, files *FilePromptManager, logger *zap.Logger) *DBPromptManager {
+	return &DBPromptManager{
+		source:      source,
+		experiments: experiments,
+		files:       files,
+		ttl:         cfg.PromptCacheTTL,
+		logger:      logger.Named("DBPromptManager"),
+		cache:       make(map[string]cachedPrompt),
+	}
+}
+
+// Invalidate drops the cached active version and experiment of a prompt, so the next call reads them again.
+func (m *DBPromptManager) Invalidate(promptID string) {
+	m.mu.Lock()
+	delete(m.cache, promptID)
+	m.mu.Unlock()
+	m.logger.Info("Prompt cache invalidated", zap.String("operation", "DBPromptManager.Invalidate"), zap.String("prompt_id", promptID))
+}
+
+// lookup returns the cache entry of a prompt, with its prompt file as the template if no version is active.
+func (m *DBPromptManager) lookup(ctx context.Context, promptID string) (cachedPrompt, error) {
+	entry := m.entry(ctx, promptID)
+	if entry.prompt == nil {
+		prompt, err := m.files.lookup(promptID)
+		if err != nil {
+			return cachedPrompt{}, err
+		}
+		entry.prompt = prompt
+	}
+	return entry, nil
+}
+
+// entry returns the cache entry of a prompt, reading its active version and running experiment on a cache miss.
+func (m *DBPromptManager) entry(ctx context.Context, promptID string) cachedPrompt {
+	const operation = "DBPromptManager.entry"
+
+	// 1. Cache Lookup
+	m.mu.RLock()
+	entry, cached := m.cache[promptID]
+	m.mu.RUnlock()
+	if cached && time.Now().Before(entry.expires) {
+		return entry
+	}
+
+	// 2. Cache Miss: read the active version from the registry
+	version, err := m.source.GetActivePrompt(ctx, promptID)
+	var notFoundErr *domain.NotFoundError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return m.store(promptID, cachedPrompt{})
+	case err != nil:
+		// Serve the last known entry (or the file) rather than failing the model call while the database is unavailable
+		m.logger.Warn("Reading the active prompt version failed, using the last known one", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Error(err))
+		return entry
+	}
+
+	// 3. Compile; an invalid version is not used (it was validated when it was written, so this is unexpected)
+	prompt, err := PromptTemplateFromVersion(version)
+	if err != nil {
+		m.logger.Error("Active prompt version is invalid, using the prompt file", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Int("version", version.Version), zap.Error(err))
+		return m.store(promptID, cachedPrompt{})
+	}
+	m.logger.Debug("Active prompt version loaded", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Int("version", version.Version))
+
+	// 4. Running experiment; when it cannot be read, every session gets the active version until the entry expires
+	entry = cachedPrompt{prompt: prompt}
+	experiment, err := m.experiments.GetRunningPromptExperiment(ctx, promptID)
+	switch {
+	case errors.As(err, &notFoundErr):
+	case err != nil:
+		m.logger.Warn("Reading the running prompt experiment failed, using the active version", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Error(err))
+	default:
+		treatment, err := m.treatment(ctx, experiment)
+		if err != nil {
+			m.logger.Error("Treatment version of the prompt experiment is unusable, using the active version", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.String("experiment_id", experiment.ID.String()), zap.Error(err))
+			break
+		}
+		entry.experiment, entry.treatment = experiment, treatment
+	}
+	return m.store(promptID, entry)
+}
+
+// treatment reads and compiles the treatment version of an experiment.
+func (m *DBPromptManager) treatment(ctx context.Context, experiment *dataModels.PromptExperiment) (*PromptTemplate, error) {
+	version, err := m.source.GetPromptByID(ctx, experiment.TreatmentPromptID)
+	if err != nil {
+		return nil, err
+	}
+	return PromptTemplateFromVersion(version)
+}
+
+// store caches the entry of a prompt (a nil prompt for its prompt file) for the configured TTL and returns it.
+func (m *DBPromptManager) store(promptID string, entry cachedPrompt) cachedPrompt {
+	entry.expires = time.Now().Add(m.ttl)
+	m.mu.Lock()
+	m.cache[promptID] = entry
+	m.mu.Unlock()
+	return entry
+}
+
+// GetPrompt retrieves the unrendered template text of a prompt's active version (or prompt file).
+func (m *DBPromptManager) GetPrompt(ctx context.Context, promptID string) (string, error) {
+	entry, err := m.lookup(ctx, promptID)
+	if err != nil {
+		return "", err
+	}
+	return entry.prompt.Template, nil
+}
+
+// RenderPrompt renders a prompt's active version (or prompt file) with vars, or its treatment version when a running
+// experiment assigns the session to the treatment arm.
+func (m *DBPromptManager) RenderPrompt(ctx context.Context, promptID string, sessionID uuid.UUID, vars map[string]any) (*RenderedPrompt, error) {
+	const operation = "DBPromptManager.RenderPrompt"
+
+	entry, err := m.lookup(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+	prompt, arm := entry.prompt, ""
+	if entry.experiment != nil {
+		arm = assignArm(entry.experiment, sessionID)
+		if arm == dataModels.PromptArmTreatment {
+			prompt = entry.treatment
+		}
+	}
+	rendered, err := prompt.render(vars)
+	if err != nil {
+		m.logger.Warn("Prompt rendering failed", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Int("version", prompt.version), zap.String("arm", arm), zap.Error(err))
+		return nil, err
+	}
+	rendered.SessionID = sessionID
+	if entry.experiment != nil {
+		rendered.ExperimentID, rendered.Arm = entry.experiment.ID, arm
+	}
+	return rendered, nil
+}
@@ -11,6 +11,8 @@ import (
 const (
 	ErrCodeGeminiAPIRequestFailed      = "GEMINI_API_REQUEST_FAILED"          // Grouped with "GEMINI_API" prefix - Recommendation: Error Grouping/Categorization
 	ErrCodeGeminiResponseParsingFailed = "GEMINI_API_RESPONSE_PARSING_FAILED" // Grouped with "GEMINI_API" prefix
+	ErrCodeGeminiCircuitOpen           = "GEMINI_API_CIRCUIT_OPEN"            // Grouped with "GEMINI_API" prefix - call rejected by an open circuit breaker (see ResilientClient)
+	ErrCodeGeminiBudgetExceeded        = "GEMINI_API_BUDGET_EXCEEDED"         // Grouped with "GEMINI_API" prefix - call rejected by an exhausted token budget (see MeteredClient)
 
 	ErrCodeGeminiNoduleDetectionFailed       = "GEMINI_NODULE_DETECTION_FAILED"   // Grouped with "GEMINI_NODULE_DETECTION" prefix - Recommendation: More Granular Error Types & Grouping
 	ErrCodeGeminiPathologyAnalysisFailed     = "GEMINI_PATHOLOGY_ANALYSIS_FAILED" // Grouped with "GEMINI_PATHOLOGY_ANALYSIS" prefix - Recommendation: More Granular Error Types & Grouping
@@ -154,6 +156,44 @@ func (e *ErrGeminiRequestFailed) SetLogger(logger *zap.Logger) {
 	e.logger = logger
 }
 
+// Token budgets enforced by MeteredClient, reported in ErrGeminiBudgetExceeded.Budget.
+const (
+	BudgetDaily   = "daily"   // LLM_DAILY_TOKEN_BUDGET, tokens of every call since midnight UTC
+	BudgetSession = "session" // LLM_SESSION_TOKEN_BUDGET, tokens of every call made for one session
+)
+
+// ErrGeminiBudgetExceeded is returned, without calling the model, once a token budget is used up.
+//
+// Error Code: ErrCodeGeminiBudgetExceeded
+// Severity Level: Warning
+// Possible Causes: Unusually heavy use, reprocessing loops, a session with very many or very large documents.
+// Actionable Recommendations: Review the usage report of the admin API, raise LLM_DAILY_TOKEN_BUDGET or LLM_SESSION_TOKEN_BUDGET if the use is legitimate.
+type ErrGeminiBudgetExceeded struct {
+	Code     string
+	Severity string
+	Method   string // GeminiClient method that was rejected
+	Budget   string // BudgetDaily or BudgetSession
+	Limit    int64  // Tokens allowed
+	Used     int64  // Tokens already used
+}
+
+// Error method for ErrGeminiBudgetExceeded. Includes severity and error code in message.
+func (e *ErrGeminiBudgetExceeded) Error() string {
+	return fmt.Sprintf("[%s] Gemini API call rejected [%s]: %s token budget exceeded (%d of %d tokens used) - %s", e.Severity, e.Code, e.Budget, e.Used, e.Limit, e.Method)
+}
+
+// NewErrGeminiBudgetExceeded creates a new ErrGeminiBudgetExceeded. Defaults to Warning severity.
+func NewErrGeminiBudgetExceeded(method, budget string, limit, used int64) *ErrGeminiBudgetExceeded {
+	return &ErrGeminiBudgetExceeded{
+		Code:     ErrCodeGeminiBudgetExceeded,
+		Severity: SeverityWarning,
+		Method:   method,
+		Budget:   budget,
+		Limit:    limit,
+		Used:     used,
+	}
+}
+
 // ErrGeminiNoduleDetectionFailed represents an error during Gemini Nodule Detection API call. // ADDED: Gemini Nodule Detection Error - Recommendation 1
 //
 // Error Code: ErrCodeGeminiNoduleDetectionFailed - Recommendation: More Granular Error Types & Grouping
@@ -0,0 +1,287 @@
+// internal/gemini/fake.go
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// Modes of the fake provider (config.FakeLLMMode).
+const (
+	FakeModeReplay = "replay" // Answer from fixtures only; a call without a fixture fails
+	FakeModeRecord = "record" // Answer from fixtures, calling the real provider and saving its answer for calls without one
+)
+
+// fakeDefaultFixture is the fixture used for a method when none matches the call's key. Inputs that change from run
+// to run (shifted dates, pseudonymous UIDs) can then still be answered.
+const fakeDefaultFixture = "default"
+
+// FakeClient is a deterministic GeminiClient for development and end-to-end tests of ProcessingService and
+// DiagnosisService without a live model. Each call is answered from a fixture file
+//
+//	<FAKE_LLM_FIXTURES>/<Method>/<key>.json
+//
+// where key is a hash of the rendered request (prompt, input fields and images, model and output schema), falling
+// back to <Method>/default.json. The answer goes through the same validation as a real provider's.
+//
+// A fixture may inject latency and errors:
+//
+//	{"latency": "300ms", "error": {"kind": "request", "status": 503, "message": "overloaded"}, "fail_times": 2, "output": {...}}
+//
+// fails the first two matching calls with a 503 (retried by ResilientClient like a real one) and answers later
+// calls with output. Without fail_times the error is returned on every call. "answer" may replace "output" to give
+// the raw text, e.g. to test malformed answers. "usage": {"prompt_tokens": 1200, "output_tokens": 300} reports the
+// token usage of answered calls like a real provider, to exercise the token budgets of MeteredClient. In record mode,
+// calls without a fixture (default excluded) are sent to the real provider and its answer is saved, so fixtures are
+// captured once and replayed from then on.
+type FakeClient struct {
+	fixtureDir string
+	mode       string
+	latency    time.Duration // Added to every call, before the fixture's own latency
+	upstream   completer     // Real provider used in record mode
+	logger     *zap.Logger
+
+	mu    sync.Mutex
+	calls map[string]int // Calls per fixture path, for fail_times
+}
+
+var _ GeminiClient = (*FakeClient)(nil)
+
+// fakeFixture is the content of a fixture file.
+type fakeFixture struct {
+	Method    string          `json:"method,omitempty"` // Informational; the directory decides
+	Latency   string          `json:"latency,omitempty"`
+	Error     *fakeError      `json:"error,omitempty"`
+	FailTimes int             `json:"fail_times,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Answer    string          `json:"answer,omitempty"`
+	Usage     *fakeUsage      `json:"usage,omitempty"`
+}
+
+// fakeUsage is the token usage a fixture reports for every answered call.
+type fakeUsage struct {
+	PromptTokens int32 `json:"prompt_tokens"`
+	OutputTokens int32 `json:"output_tokens"`
+}
+
+// fakeError is an injected failure.
+type fakeError struct {
+	Kind    string `json:"kind"`             // "request" (the default) or "parsing"
+	Status  int    `json:"status,omitempty"` // HTTP status of a request error; 0 simulates a timeout
+	Message string `json:"message,omitempty"`
+}
+
+// NewFakeClient creates a FakeClient reading fixtures from cfg.FakeLLMFixtures. In record mode, answers are taken
+// from the provider named by cfg.FakeLLMRecordFrom.
+func NewFakeClient(cfg *config.Config, logger *zap.Logger) (*FakeClient, error) {
+	c := &FakeClient{
+		fixtureDir: cfg.FakeLLMFixtures,
+		mode:       cfg.FakeLLMMode,
+		latency:    cfg.FakeLLMLatency,
+		logger:     logger.Named("FakeClient"),
+		calls:      make(map[string]int),
+	}
+	switch c.mode {
+	case FakeModeReplay:
+	case FakeModeRecord:
+		switch cfg.FakeLLMRecordFrom {
+		case ProviderGemini:
+			c.upstream = NewGeminiProClient(cfg, logger)
+		case ProviderOpenAI:
+			c.upstream = NewOpenAIClient(cfg, logger)
+		default:
+			return nil, fmt.Errorf("unknown FAKE_LLM_RECORD_FROM %q: expected %q or %q", cfg.FakeLLMRecordFrom, ProviderGemini, ProviderOpenAI)
+		}
+	default:
+		return nil, fmt.Errorf("unknown FAKE_LLM_MODE %q: expected %q or %q", c.mode, FakeModeReplay, FakeModeRecord)
+	}
+	if info, err := os.Stat(c.fixtureDir); err != nil || !info.IsDir() {
+		if c.mode != FakeModeRecord {
+			return nil, fmt.Errorf("fake LLM fixture directory %q not found", c.fixtureDir)
+		}
+	}
+	return c, nil
+}
+
+// fixtureKey returns the key of a call: a hash of everything that is sent to the model. Session IDs are not part of
+// the rendered request, so the same documents give the same key in every session.
+func fixtureKey(call completionRequest) (string, error) {
+	h := sha256.New()
+	schema, err := json.Marshal(call.schema)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "model=%s\nmax_tokens=%d\nschema=%s\n", call.model, call.maxOutputTokens, schema)
+	for _, p := range call.parts {
+		if p.InlineData != nil {
+			fmt.Fprintf(h, "inline %s %d\n", p.InlineData.MimeType, len(p.InlineData.Data))
+			h.Write(p.InlineData.Data)
+		} else {
+			fmt.Fprintf(h, "text %d\n%s", len(p.Text), p.Text)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// complete implements completer from the fixtures.
+func (c *FakeClient) complete(ctx context.Context, call completionRequest) (string, error) {
+	requestID := utils.GetRequestID(ctx)
+	_, method, _ := strings.Cut(call.operation, ".")
+	key, err := fixtureKey(call)
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("hashing request", "fake://"+method, "", err)
+	}
+
+	// 1. Find the fixture: exact key, recording it if allowed, then the method's default
+	path := filepath.Join(c.fixtureDir, method, key+".json")
+	fixture, err := readFixture(path)
+	if errors.Is(err, fs.ErrNotExist) && c.mode == FakeModeRecord {
+		return c.record(ctx, call, method, path)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		path = filepath.Join(c.fixtureDir, method, fakeDefaultFixture+".json")
+		fixture, err = readFixture(path)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		c.logger.Warn("No fixture for fake model call", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.String("key", key))
+		return "", NewErrGeminiRequestFailed("no fixture for key "+key, "fake://"+method, "", err)
+	}
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("reading fixture", "fake://"+method, "", err)
+	}
+	c.logger.Debug("Fake model call answered from fixture", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.String("fixture", path))
+
+	// 2. Injected latency
+	delay := c.latency
+	if fixture.Latency != "" {
+		fixtureDelay, err := time.ParseDuration(fixture.Latency)
+		if err != nil {
+			return "", NewErrGeminiRequestFailed("invalid latency in fixture "+path, "fake://"+method, "", err)
+		}
+		delay += fixtureDelay
+	}
+	if delay > 0 {
+		if err := sleepContext(ctx, delay); err != nil {
+			return "", NewErrGeminiRequestFailed("waiting for injected latency", "fake://"+method, "", err)
+		}
+	}
+
+	// 3. Injected error, for every call or the first fail_times calls
+	if fixture.Error != nil {
+		c.mu.Lock()
+		c.calls[path]++
+		calls := c.calls[path]
+		c.mu.Unlock()
+		if fixture.FailTimes == 0 || calls <= fixture.FailTimes {
+			return "", fixture.Error.err(method)
+		}
+	}
+
+	if fixture.Usage != nil {
+		reportUsage(ctx, fixture.Usage.PromptTokens, fixture.Usage.OutputTokens)
+	}
+	if fixture.Answer != "" {
+		return fixture.Answer, nil
+	}
+	return string(fixture.Output), nil
+}
+
+// record answers call from the real provider and saves the answer as the call's fixture.
+func (c *FakeClient) record(ctx context.Context, call completionRequest, method, path string) (string, error) {
+	answer, err := c.upstream.complete(ctx, call)
+	if err != nil {
+		return "", err // Failures are not recorded; inject them by editing a fixture instead
+	}
+	fixture := fakeFixture{Method: method}
+	if trimmed := stripCodeFence(answer); json.Valid([]byte(trimmed)) {
+		fixture.Output = json.RawMessage(trimmed)
+	} else {
+		fixture.Answer = answer
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("creating fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil { // Answers derive from patient documents; keep them private
+		return "", fmt.Errorf("writing fixture: %w", err)
+	}
+	c.logger.Info("Fake model fixture recorded", zap.String("operation", call.operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.String("fixture", path))
+	return answer, nil
+}
+
+// readFixture reads and decodes a fixture file.
+func readFixture(path string) (*fakeFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture fakeFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("decoding fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// err returns the injected error, of the same types the real providers return.
+func (e *fakeError) err(method string) error {
+	message := e.Message
+	if message == "" {
+		message = "injected failure"
+	}
+	switch {
+	case e.Kind == "parsing":
+		return NewErrGeminiResponseParsingFailed(message, "", nil)
+	case e.Status == 0:
+		return NewErrGeminiRequestFailed(message, "fake://"+method, "", context.DeadlineExceeded)
+	}
+	return NewErrGeminiRequestFailed(fmt.Sprintf("status %d", e.Status), "fake://"+method, "", &StatusError{StatusCode: e.Status, Message: message})
+}
+
+// DetectNodules answers from the DetectNodules fixtures (see detectNodules).
+func (c *FakeClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return detectNodules(ctx, c, "FakeClient.DetectNodules", input)
+}
+
+// AnalyzePathologyReport answers from the AnalyzePathologyReport fixtures.
+func (c *FakeClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	return analyzePathologyReport(ctx, c, "FakeClient.AnalyzePathologyReport", input)
+}
+
+// ExtractInformation answers from the ExtractInformation fixtures.
+func (c *FakeClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	return extractInformation(ctx, c, "FakeClient.ExtractInformation", input)
+}
+
+// GeneratePreliminaryDiagnosis answers from the GeneratePreliminaryDiagnosis fixtures.
+func (c *FakeClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	return generatePreliminaryDiagnosis(ctx, c, "FakeClient.GeneratePreliminaryDiagnosis", input)
+}
+
+// GetStagingInformation answers from the GetStagingInformation fixtures.
+func (c *FakeClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	return getStagingInformation(ctx, c, "FakeClient.GetStagingInformation", input)
+}
+
+// SuggestTreatmentOptions answers from the SuggestTreatmentOptions fixtures.
+func (c *FakeClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	return suggestTreatmentOptions(ctx, c, "FakeClient.SuggestTreatmentOptions", input)
+}
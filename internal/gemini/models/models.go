@@ -6,11 +6,20 @@ import "github.com/google/uuid"
 // NoduleDetectionInput represents the input for nodule detection for the Gemini API.
 // @Description Input data structure for the Nodule Detection endpoint of the Gemini API.
 type NoduleDetectionInput struct {
-	ImageData           []byte  `json:"imageData" validate:"required" example:"base64 encoded image data" format:"byte" description:"Raw image data as bytes, required for the API call. Expected format depends on the Gemini API (e.g., JPEG, PNG, DICOM)."`                                                                 // ImageData (bytes):  Raw image data as bytes, required for the API call. Expected format depends on the Gemini API (e.g., JPEG, PNG, DICOM).
-	ImageType           string  `json:"imageType" validate:"required" example:"image/jpeg" description:"ImageType (string):  MIME type or format of the image data (e.g., 'image/jpeg', 'application/dicom'), required for API to interpret the data correctly."`                                                              // ImageType (string):  MIME type or format of the image data (e.g., "image/jpeg", "application/dicom"), required for API to interpret the data correctly.
-	Prompt              string  `json:"prompt" validate:"required" example:"Identify potential lung nodules in this image." description:"Prompt (string): User-defined or system-generated prompt to guide Gemini API's nodule detection. Should be carefully engineered for optimal results, required for task instruction."` // Prompt (string): User-defined or system-generated prompt to guide Gemini API's nodule detection.  Should be carefully engineered for optimal results, required for task instruction.
-	MaxResults          int32   `json:"maxResults,omitempty" example:"5" description:"MaxResults (int32, optional): Maximum number of nodules to return in the response. Optional parameter to control API response size."`                                                                                                    // MaxResults (int32, optional): Maximum number of nodules to return in the response. Optional parameter to control API response size.
-	ConfidenceThreshold float32 `json:"confidenceThreshold,omitempty" example:"0.8" description:"ConfidenceThreshold (float32, optional): Minimum confidence score for a nodule to be included in the output. Filters out low-confidence detections. Range 0.0-1.0."`                                                          // ConfidenceThreshold (float32, optional): Minimum confidence score for a nodule to be included in the output.  Filters out low-confidence detections. Range 0.0-1.0.
+	ImageData           []byte       `json:"imageData" validate:"required" example:"base64 encoded image data" format:"byte" description:"Raw image data as bytes, required for the API call. Expected format depends on the Gemini API (e.g., JPEG, PNG, DICOM)."`                                                                 // ImageData (bytes):  Raw image data as bytes, required for the API call. Expected format depends on the Gemini API (e.g., JPEG, PNG, DICOM).
+	ImageType           string       `json:"imageType" validate:"required" example:"image/jpeg" description:"ImageType (string):  MIME type or format of the image data (e.g., 'image/jpeg', 'application/dicom'), required for API to interpret the data correctly."`                                                              // ImageType (string):  MIME type or format of the image data (e.g., "image/jpeg", "application/dicom"), required for API to interpret the data correctly.
+	Prompt              string       `json:"prompt" validate:"required" example:"Identify potential lung nodules in this image." description:"Prompt (string): User-defined or system-generated prompt to guide Gemini API's nodule detection. Should be carefully engineered for optimal results, required for task instruction."` // Prompt (string): User-defined or system-generated prompt to guide Gemini API's nodule detection.  Should be carefully engineered for optimal results, required for task instruction.
+	MaxResults          int32        `json:"maxResults,omitempty" example:"5" description:"MaxResults (int32, optional): Maximum number of nodules to return in the response. Optional parameter to control API response size."`                                                                                                    // MaxResults (int32, optional): Maximum number of nodules to return in the response. Optional parameter to control API response size.
+	ConfidenceThreshold float32      `json:"confidenceThreshold,omitempty" example:"0.8" description:"ConfidenceThreshold (float32, optional): Minimum confidence score for a nodule to be included in the output. Filters out low-confidence detections. Range 0.0-1.0."`                                                          // ConfidenceThreshold (float32, optional): Minimum confidence score for a nodule to be included in the output.  Filters out low-confidence detections. Range 0.0-1.0.
+	Slices              []SliceImage `json:"slices,omitempty" description:"Slices ([]SliceImage, optional): Additional slices of the same series, ordered along the slice normal, so the model can assess the series as a whole rather than a single image."`                                                                       // Slices ([]SliceImage, optional): Additional slices of the same series, ordered along the slice normal, so the model can assess the series as a whole.
+}
+
+// SliceImage is one rendered slice of a DICOM series sent alongside a nodule detection request.
+// @Description A single rendered slice of a series, with its position so the model can report where findings are.
+type SliceImage struct {
+	SliceIndex int     `json:"sliceIndex" example:"42" description:"SliceIndex (int): Zero-based index of the slice within the assembled series."`                                       // SliceIndex (int): Zero-based index of the slice within the assembled series.
+	Position   float64 `json:"position" example:"-125.5" description:"Position (float64): Position of the slice along the series normal, in millimeters."`                               // Position (float64): Position of the slice along the series normal, in millimeters.
+	ImageData  []byte  `json:"imageData" validate:"required" format:"byte" description:"ImageData (bytes): The rendered slice, encoded as described by NoduleDetectionInput.ImageType."` // ImageData (bytes): The rendered slice, encoded as described by NoduleDetectionInput.ImageType.
 }
 
 // NoduleInfo represents information about a detected nodule.
@@ -50,6 +59,7 @@ type Finding struct {
 	Type        string  `json:"type" example:"tumor type" description:"Type (string): Type or category of the finding (e.g., 'tumor type', 'biomarker', 'grade'). Helps categorize findings."`                             // Type (string): Type or category of the finding (e.g., "tumor type", "biomarker", "grade").  Helps categorize findings.
 	Confidence  float64 `json:"confidence,omitempty" example:"0.98" description:"Confidence (float64, optional): Confidence score for the finding extraction (0.0-1.0)."`                                                  // Confidence (float64, optional):  Confidence score for the finding extraction (0.0-1.0).
 	Relevance   string  `json:"relevance,omitempty" example:"high" description:"Relevance (string, optional): Relevance of the finding to lung cancer diagnosis/staging (e.g., 'high', 'medium', 'low')."`                 // Relevance (string, optional):  Relevance of the finding to lung cancer diagnosis/staging (e.g., "high", "medium", "low").
+	Quote       string  `json:"quote,omitempty" example:"Invasive adenocarcinoma, acinar predominant." description:"Quote (string, optional): The sentence of the report the finding was taken from, copied verbatim."`    // Quote (string, optional): The sentence of the report the finding was taken from, copied verbatim.
 }
 
 // PathologyReportAnalysisOutput is a placeholder for the output.
@@ -80,12 +90,26 @@ type InformationExtractionOutput struct {
 // DiagnosisInput represents the input for preliminary diagnosis generation.
 // @Description Input data structure for the Preliminary Diagnosis generation endpoint of the Gemini API.
 type DiagnosisInput struct {
-	PatientID       uuid.UUID `json:"patientId" validate:"required,uuid4" example:"a1b2c3d4-e5f6-4789-9012-34567890abcd" description:"PatientID (UUID): Unique identifier for the patient session, UUID format, required and validated as UUIDv4."`          // PatientID (UUID):  Unique identifier for the patient session, UUID format, required and validated as UUIDv4.
-	Prompt          string    `json:"prompt" validate:"required" example:"Generate a preliminary lung cancer diagnosis..." description:"Prompt (string): Prompt for guiding Gemini API's diagnosis generation, required."`                                   // Prompt (string): Prompt for guiding Gemini API's diagnosis generation, required.
-	MedicalHistory  string    `json:"medicalHistory,omitempty" example:"Patient is a 58-year-old former smoker..." description:"MedicalHistory (string): Patient's medical history as text. Optional, but improves diagnostic accuracy."`                    // MedicalHistory (string): Patient's medical history as text.  Optional, but improves diagnostic accuracy.
-	Symptoms        string    `json:"symptoms,omitempty" example:"Persistent cough, shortness of breath" description:"Symptoms (string): Patient's reported symptoms as text. Optional, but improves diagnostic accuracy."`                                  // Symptoms (string):  Patient's reported symptoms as text. Optional, but improves diagnostic accuracy.
-	FindingsSummary string    `json:"findingsSummary,omitempty" example:"CT scan shows a 2cm nodule in the right upper lobe..." description:"FindingsSummary (string): Summary of findings extracted from reports. Optional, but provides crucial context."` // FindingsSummary (string): Summary of findings extracted from reports. Optional, but provides crucial context.
-	ReportText      string    `json:"reportText,omitempty" example:"..." description:"ReportText (string): Full report text for context. Optional, but can improve diagnostic accuracy."`                                                                    // ReportText (string): Full report text for context.  Optional, but can improve diagnostic accuracy.
+	PatientID       uuid.UUID   `json:"patientId" validate:"required,uuid4" example:"a1b2c3d4-e5f6-4789-9012-34567890abcd" description:"PatientID (UUID): Unique identifier for the patient session, UUID format, required and validated as UUIDv4."`          // PatientID (UUID):  Unique identifier for the patient session, UUID format, required and validated as UUIDv4.
+	Prompt          string      `json:"prompt" validate:"required" example:"Generate a preliminary lung cancer diagnosis..." description:"Prompt (string): Prompt for guiding Gemini API's diagnosis generation, required."`                                   // Prompt (string): Prompt for guiding Gemini API's diagnosis generation, required.
+	MedicalHistory  string      `json:"medicalHistory,omitempty" example:"Patient is a 58-year-old former smoker..." description:"MedicalHistory (string): Patient's medical history as text. Optional, but improves diagnostic accuracy."`                    // MedicalHistory (string): Patient's medical history as text.  Optional, but improves diagnostic accuracy.
+	Symptoms        string      `json:"symptoms,omitempty" example:"Persistent cough, shortness of breath" description:"Symptoms (string): Patient's reported symptoms as text. Optional, but improves diagnostic accuracy."`                                  // Symptoms (string):  Patient's reported symptoms as text. Optional, but improves diagnostic accuracy.
+	FindingsSummary string      `json:"findingsSummary,omitempty" example:"CT scan shows a 2cm nodule in the right upper lobe..." description:"FindingsSummary (string): Summary of findings extracted from reports. Optional, but provides crucial context."` // FindingsSummary (string): Summary of findings extracted from reports. Optional, but provides crucial context.
+	ReportText      string      `json:"reportText,omitempty" example:"..." description:"ReportText (string): Full report text for context. Optional, but can improve diagnostic accuracy."`                                                                    // ReportText (string): Full report text for context.  Optional, but can improve diagnostic accuracy.
+	LabResults      []LabResult `json:"labResults,omitempty" description:"LabResults ([]LabResult): Normalized lab results of the session, sent as structured data. Optional."`                                                                                // LabResults ([]LabResult): Normalized lab results of the session, sent as structured data. Optional.
+}
+
+// LabResult is a normalized lab result sent as structured input.
+// @Description Data structure representing a single lab result of the patient, as imported from lab exports.
+type LabResult struct {
+	Analyte        string   `json:"analyte" example:"Hemoglobin" description:"Analyte (string): Test name."`                                                                 // Analyte (string): Test name.
+	Code           string   `json:"code,omitempty" example:"718-7" description:"Code (string, optional): Test code, e.g. LOINC."`                                            // Code (string, optional): Test code, e.g. LOINC.
+	Value          string   `json:"value" example:"11.2" description:"Value (string): Result as reported, e.g. '11.2', '<0.5', 'Positive'."`                                 // Value (string): Result as reported, e.g. "11.2", "<0.5", "Positive".
+	NumericValue   *float64 `json:"numericValue,omitempty" example:"11.2" description:"NumericValue (number, optional): Result as a number, for numeric results."`           // NumericValue (number, optional): Result as a number, for numeric results.
+	Unit           string   `json:"unit,omitempty" example:"g/dL" description:"Unit (string, optional): Unit, in a normalized spelling."`                                    // Unit (string, optional): Unit, in a normalized spelling.
+	ReferenceRange string   `json:"referenceRange,omitempty" example:"13.5-17.5" description:"ReferenceRange (string, optional): Reference range, as reported."`             // ReferenceRange (string, optional): Reference range, as reported.
+	Flag           string   `json:"flag,omitempty" example:"low" description:"Flag (string, optional): 'low', 'high', 'normal' or 'abnormal', reported or derived."`         // Flag (string, optional): "low", "high", "normal" or "abnormal", reported or derived from the range.
+	Date           string   `json:"date,omitempty" example:"2024-03-14" description:"Date (string, optional): Collection date (YYYY-MM-DD), shifted for de-identification."` // Date (string, optional): Collection date (YYYY-MM-DD), shifted for de-identification.
 }
 
 // DiagnosisOutput represents the output of preliminary diagnosis generation.
@@ -111,6 +135,7 @@ type StagingInput struct {
 	LymphNodeInvolvement string `json:"lymphNodeInvolvement,omitempty" example:"Mediastinal lymph nodes enlarged" description:"LymphNodeInvolvement (string): Information on lymph node involvement from reports/images, for N stage."` // LymphNodeInvolvement (string): Information on lymph node involvement from reports/images, for N stage.
 	MetastasisPresent    string `json:"metastasisPresent,omitempty" example:"No distant metastasis" description:"MetastasisPresent (string): Information on distant metastasis, for M stage."`                                          // MetastasisPresent (string):  Information on distant metastasis, for M stage.
 	FindingsSummary      string `json:"findingsSummary,omitempty" example:"Findings are suggestive of early-stage lung cancer." description:"FindingsSummary (string): Summary of findings, to provide context for staging."`           // FindingsSummary (string): Summary of findings, to provide context for staging.
+	ReportText           string `json:"reportText,omitempty" example:"..." description:"ReportText (string): De-identified text of the case's reports, for context."`                                                                   // ReportText (string): De-identified text of the case's reports, for context.
 }
 
 // StagingOutput represents the output for staging information.
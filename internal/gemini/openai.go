@@ -0,0 +1,232 @@
+// internal/gemini/openai.go
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// OpenAIClient implements the GeminiClient interface on the OpenAI chat-completions protocol
+// (POST {OPENAI_BASE_URL}/chat/completions), as served by self-hosted model servers such as vLLM or llama.cpp.
+// It lets the pipeline run against local models, for privacy or offline testing.
+//
+// JSON mode (response_format json_object) only guarantees syntactically valid JSON, so the output struct's schema
+// is given to the model in the system message and the answer is validated like every other provider's.
+// Images are sent as base64 data URLs; the model must be multimodal for DetectNodules.
+type OpenAIClient struct {
+	config     *config.Config
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+var _ GeminiClient = (*OpenAIClient)(nil)
+
+// NewOpenAIClient creates a new OpenAIClient. Calls are bounded by GeminiAPITimeout, like the Gemini client's.
+func NewOpenAIClient(cfg *config.Config, logger *zap.Logger) *OpenAIClient {
+	return &OpenAIClient{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.GeminiAPITimeout},
+		logger:     logger.Named("OpenAIClient"),
+	}
+}
+
+// chatCompletionRequest is the body of a chat/completions call.
+type chatCompletionRequest struct {
+	Model          string         `json:"model"`
+	Messages       []chatMessage  `json:"messages"`
+	ResponseFormat responseFormat `json:"response_format"`
+	MaxTokens      int32          `json:"max_tokens,omitempty"`
+	Temperature    float64        `json:"temperature"`
+}
+
+// chatMessage is a message whose content is either a string or a list of content parts.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+// chatContentPart is a text or image_url part of a user message.
+type chatContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatCompletionResponse is the subset of the chat/completions response the client reads.
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal,omitempty"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int32 `json:"prompt_tokens"`
+		CompletionTokens int32 `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIErrorResponse is the error body returned with non-2xx statuses.
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// complete implements completer on chat/completions in JSON mode.
+// Request bodies are never logged or put into errors: they hold patient images and text.
+func (c *OpenAIClient) complete(ctx context.Context, call completionRequest) (string, error) {
+	requestID := utils.GetRequestID(ctx)
+	model := call.model
+	if model == "" {
+		model = c.config.OpenAIModel
+	}
+	endpoint := strings.TrimSuffix(c.config.OpenAIBaseURL, "/") + "/chat/completions"
+
+	// 1. Build the request: the schema as system message, the parts as one user message
+	schema, err := json.Marshal(call.schema.jsonSchema())
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("encoding response schema", endpoint, "", err)
+	}
+	userParts := make([]chatContentPart, 0, len(call.parts))
+	for _, p := range call.parts {
+		if p.InlineData != nil {
+			url := "data:" + p.InlineData.MimeType + ";base64," + base64.StdEncoding.EncodeToString(p.InlineData.Data)
+			userParts = append(userParts, chatContentPart{Type: "image_url", ImageURL: &chatImageURL{URL: url}})
+		} else {
+			userParts = append(userParts, chatContentPart{Type: "text", Text: p.Text})
+		}
+	}
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: model,
+		Messages: []chatMessage{
+			{Role: "system", Content: "Respond with a single JSON object, without Markdown, that conforms to this JSON Schema:\n" + string(schema)},
+			{Role: "user", Content: userParts},
+		},
+		ResponseFormat: responseFormat{Type: "json_object"},
+		MaxTokens:      call.maxOutputTokens,
+		Temperature:    0, // Deterministic answers; the same document should get the same analysis
+	})
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("encoding request", endpoint, "", err)
+	}
+
+	// 2. Call the server within GeminiAPITimeout
+	ctx, cancel := context.WithTimeout(ctx, c.config.GeminiAPITimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("building request", endpoint, "", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.OpenAIAPIKey != "" { // Local servers usually run without authentication
+		req.Header.Set("Authorization", "Bearer "+c.config.OpenAIAPIKey)
+	}
+
+	c.logger.Debug("OpenAI-compatible API request", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.String("model", model), zap.Int("parts", len(call.parts)), zap.Int("request_bytes", len(body)))
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Warn("OpenAI-compatible API request failed", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.Duration("elapsed", time.Since(start)), zap.Error(err))
+		return "", NewErrGeminiRequestFailed("sending request", endpoint, "", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("reading response", endpoint, "", err)
+	}
+
+	// 3. Map error statuses onto the same errors as the Gemini client, so retries classify them alike
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr openAIErrorResponse
+		message := http.StatusText(resp.StatusCode)
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			message = apiErr.Error.Message
+		}
+		c.logger.Warn("OpenAI-compatible API error response", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.Int("status_code", resp.StatusCode), zap.String("error_message", message), zap.Duration("elapsed", time.Since(start)))
+		return "", NewErrGeminiRequestFailed(fmt.Sprintf("status %d", resp.StatusCode), endpoint, "", &StatusError{StatusCode: resp.StatusCode, Message: message, RetryAfter: resp.Header.Get("Retry-After")})
+	}
+
+	// 4. Extract the answer
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return "", NewErrGeminiResponseParsingFailed("decoding chat completion response", bodySummary(resp.StatusCode, string(respBody)), err)
+	}
+	c.logger.Info("OpenAI-compatible API response",
+		zap.String("operation", call.operation),
+		zap.String("request_id", requestID),
+		zap.Int("status_code", resp.StatusCode),
+		zap.Duration("elapsed", time.Since(start)),
+		zap.Int32("prompt_tokens", completion.Usage.PromptTokens),
+		zap.Int32("output_tokens", completion.Usage.CompletionTokens),
+	)
+	reportUsage(ctx, completion.Usage.PromptTokens, completion.Usage.CompletionTokens)
+	if len(completion.Choices) == 0 {
+		return "", NewErrGeminiResponseParsingFailed("response has no choices", bodySummary(resp.StatusCode, string(respBody)), nil)
+	}
+	choice := completion.Choices[0]
+	switch {
+	case choice.Message.Refusal != "":
+		return "", NewErrGeminiResponseParsingFailed("model refused: "+choice.Message.Refusal, "", nil)
+	case choice.FinishReason == "content_filter":
+		return "", NewErrGeminiResponseParsingFailed("answer blocked by content filter", "", nil)
+	case choice.FinishReason == "length":
+		return "", NewErrGeminiResponseParsingFailed("output truncated at the token limit", bodySummary(resp.StatusCode, choice.Message.Content), nil)
+	case choice.Message.Content == "":
+		return "", NewErrGeminiResponseParsingFailed("choice has no content (finish reason "+choice.FinishReason+")", "", nil)
+	}
+	return choice.Message.Content, nil
+}
+
+// DetectNodules asks the model to detect lung nodules in a DICOM series or image (see detectNodules).
+func (c *OpenAIClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return detectNodules(ctx, c, "OpenAIClient.DetectNodules", input)
+}
+
+// AnalyzePathologyReport asks the model to extract findings from a (de-identified) pathology report.
+func (c *OpenAIClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	return analyzePathologyReport(ctx, c, "OpenAIClient.AnalyzePathologyReport", input)
+}
+
+// ExtractInformation asks the model to extract findings (symptoms, signs, investigations) from a report.
+func (c *OpenAIClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	return extractInformation(ctx, c, "OpenAIClient.ExtractInformation", input)
+}
+
+// GeneratePreliminaryDiagnosis asks the model for a preliminary diagnosis.
+func (c *OpenAIClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	return generatePreliminaryDiagnosis(ctx, c, "OpenAIClient.GeneratePreliminaryDiagnosis", input)
+}
+
+// GetStagingInformation asks the model for TNM staging.
+func (c *OpenAIClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	return getStagingInformation(ctx, c, "OpenAIClient.GetStagingInformation", input)
+}
+
+// SuggestTreatmentOptions asks the model for treatment options to discuss with the care team.
+func (c *OpenAIClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	return suggestTreatmentOptions(ctx, c, "OpenAIClient.SuggestTreatmentOptions", input)
+}
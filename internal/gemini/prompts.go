@@ -2,44 +2,131 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"sync" // Import sync for using sync.Map
+	"text/template"
 
+	"github.com/google/uuid"
 	"github.com/pelletier/go-toml"                     // Import for TOML support
+	"github.com/stackvity/lung-server/internal/config" // Import config for the prompt template path
 	"github.com/stackvity/lung-server/internal/domain" // Import domain for custom errors
+	"github.com/stackvity/lung-server/internal/gemini/models"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3" // Import for YAML support
 )
 
+// IDs of the managed prompts used by the services, one per GeminiClient call site.
+const (
+	PromptNoduleDetection         = "nodule_detection"          // DetectNodules on a rendered DICOM series
+	PromptPathologyReportAnalysis = "pathology_report_analysis" // AnalyzePathologyReport on a pathology report
+	PromptInformationExtraction   = "information_extraction"    // ExtractInformation on other reports
+	PromptPreliminaryDiagnosis    = "preliminary_diagnosis"     // GeneratePreliminaryDiagnosis
+	PromptStagingInformation      = "staging_information"       // GetStagingInformation
+	PromptTreatmentOptions        = "treatment_options"         // SuggestTreatmentOptions
+)
+
+// promptOutputs maps each managed prompt to the output struct of the method it is sent with. A prompt file declaring
+// another output schema is rejected at load time, so a prompt cannot be bound to the wrong call by mistake.
+var promptOutputs = map[string]any{
+	PromptNoduleDetection:         models.NoduleDetectionOutput{},
+	PromptPathologyReportAnalysis: models.PathologyReportAnalysisOutput{},
+	PromptInformationExtraction:   models.InformationExtractionOutput{},
+	PromptPreliminaryDiagnosis:    models.DiagnosisOutput{},
+	PromptStagingInformation:      models.StagingOutput{},
+	PromptTreatmentOptions:        models.TreatmentRecommendationOutput{},
+}
+
 // PromptManager defines the interface for managing Gemini API prompts.
 // This interface abstracts prompt retrieval for various storage mechanisms.
 type PromptManager interface {
+	// GetPrompt returns the unrendered template text of a prompt.
 	GetPrompt(ctx context.Context, promptID string) (string, error)
+	// RenderPrompt renders a prompt with the given variables for a session; while a prompt experiment runs, the
+	// session ID decides which version is used. It fails with a *domain.ValidationError when a required variable is
+	// missing, and with a *domain.NotFoundError for unknown prompt IDs.
+	RenderPrompt(ctx context.Context, promptID string, sessionID uuid.UUID, vars map[string]any) (*RenderedPrompt, error)
+}
+
+// PromptTemplate is one prompt file. Files may be JSON, YAML or TOML:
+//
+//	id: staging_information        # Optional, defaults to the file name without extension
+//	description: TNM staging of a lung cancer case
+//	template: |
+//	  Determine TNM staging according to the AJCC {{.Edition}} edition ...
+//	variables:
+//	  - name: Edition
+//	    default: 8th
+//	output_format:
+//	  type: json
+//	  schema: StagingOutput
+//	  instructions: Give each category with the evidence it is based on.
+//
+// Files holding a single "name: text" pair, the earlier format, are loaded as a template without variables.
+type PromptTemplate struct {
+	ID           string           `json:"id" yaml:"id" toml:"id"`
+	Description  string           `json:"description" yaml:"description" toml:"description"`
+	Template     string           `json:"template" yaml:"template" toml:"template"`                // text/template source; variables are referenced as {{.Name}}
+	Variables    []PromptVariable `json:"variables" yaml:"variables" toml:"variables"`             // Declared input variables
+	OutputFormat OutputFormat     `json:"output_format" yaml:"output_format" toml:"output_format"` // Expected answer format
+
+	parsed    *template.Template // Parsed once at load time
+	version   int                // Prompt registry version; 0 for prompt files
+	versionID uuid.UUID          // Prompt registry version ID; uuid.Nil for prompt files
+}
+
+// PromptVariable is a declared input variable of a prompt.
+type PromptVariable struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	Description string `json:"description" yaml:"description" toml:"description"`
+	Required    bool   `json:"required" yaml:"required" toml:"required"` // Rendering fails when a required variable is not supplied
+	Default     string `json:"default" yaml:"default" toml:"default"`    // Used for an optional variable that is not supplied
+}
+
+// OutputFormat describes the answer a prompt asks for.
+type OutputFormat struct {
+	Type         string `json:"type" yaml:"type" toml:"type"`                         // Answer format; only "json" is supported. Default: "json"
+	Schema       string `json:"schema" yaml:"schema" toml:"schema"`                   // Name of the output struct in internal/gemini/models, e.g. "StagingOutput"
+	Instructions string `json:"instructions" yaml:"instructions" toml:"instructions"` // Appended to the rendered prompt
+}
+
+// RenderedPrompt is a prompt ready to be sent.
+type RenderedPrompt struct {
+	ID           string
+	Version      int       // Prompt registry version the text was rendered from; 0 for prompt files
+	VersionID    uuid.UUID // ID of that version, recorded on the stored outputs; uuid.Nil for prompt files
+	Text         string
+	OutputFormat OutputFormat
+
+	SessionID    uuid.UUID // Session the prompt was rendered for; model usage is accounted to it
+	ExperimentID uuid.UUID // Prompt experiment the session took part in; uuid.Nil outside experiments
+	Arm          string    // Experiment arm the session was assigned to ("control" or "treatment"); empty outside experiments
 }
 
 // FilePromptManager implements PromptManager, loading prompts from JSON, YAML, and TOML files.
 // It utilizes an in-memory cache (sync.Map) for efficient prompt retrieval.
 type FilePromptManager struct {
-	promptTemplates map[string]string // In-memory map to store prompt templates, key is prompt ID, value is the prompt template string.
-	templatePath    string            // Path to the directory containing prompt template files.
-	logger          *zap.Logger       // Logger for structured logging.
-	promptCache     sync.Map          // ADDED: In-memory cache for prompts, using sync.Map for concurrent access safety.
+	promptTemplates map[string]*PromptTemplate // In-memory map to store prompt templates, key is prompt ID.
+	templatePath    string                     // Path to the directory containing prompt template files.
+	logger          *zap.Logger                // Logger for structured logging.
+	promptCache     sync.Map                   // ADDED: In-memory cache for prompts, using sync.Map for concurrent access safety.
 }
 
-// NewFilePromptManager creates a new FilePromptManager, loads prompts from files, and initializes the cache.
-// It takes a path to the directory containing prompt files as input.
-// Returns a FilePromptManager instance and an error if prompt loading fails.
-func NewFilePromptManager(templatePath string, logger *zap.Logger) (*FilePromptManager, error) {
+// NewFilePromptManager creates a new FilePromptManager, loads prompts from the PromptTemplatePath directory, and
+// initializes the cache. Returns an error if a prompt file cannot be parsed or a managed prompt is missing.
+func NewFilePromptManager(cfg *config.Config, logger *zap.Logger) (*FilePromptManager, error) {
 	const operation = "NewFilePromptManager"
 
 	fpm := &FilePromptManager{
-		promptTemplates: make(map[string]string),
-		templatePath:    templatePath,
+		promptTemplates: make(map[string]*PromptTemplate),
+		templatePath:    cfg.PromptTemplatePath,
 		logger:          logger.Named("FilePromptManager"),
 		promptCache:     sync.Map{}, // Initialize the cache - Recommendation 1
 	}
@@ -50,7 +137,14 @@ func NewFilePromptManager(templatePath string, logger *zap.Logger) (*FilePromptM
 		return nil, fmt.Errorf("failed to initialize FilePromptManager: %w", err)
 	}
 
-	fpm.logger.Info("FilePromptManager initialized successfully", zap.String("operation", operation), zap.String("template_path", templatePath), zap.Int("prompt_count", len(fpm.promptTemplates)))
+	// Every call site's prompt must exist, so a missing file fails at startup rather than on the first upload
+	for promptID := range promptOutputs {
+		if _, ok := fpm.promptTemplates[promptID]; !ok {
+			return nil, fmt.Errorf("failed to initialize FilePromptManager: prompt %q not found in %s", promptID, fpm.templatePath)
+		}
+	}
+
+	fpm.logger.Info("FilePromptManager initialized successfully", zap.String("operation", operation), zap.String("template_path", fpm.templatePath), zap.Int("prompt_count", len(fpm.promptTemplates)))
 	return fpm, nil
 }
 
@@ -69,56 +163,141 @@ func (fpm *FilePromptManager) loadPromptsFromFiles() error {
 			return nil
 		}
 
-		var promptMap map[string]string
-		file, err := os.ReadFile(path)
-		if err != nil {
-			fpm.logger.Error("Error reading prompt file", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
-			return fmt.Errorf("error reading prompt file %s: %w", path, err)
-		}
-
+		var unmarshal func([]byte, any) error
 		// Determine file type and unmarshal accordingly - Recommendation 2
 		switch ext := filepath.Ext(path); ext {
 		case ".json":
-			if err := json.Unmarshal(file, &promptMap); err != nil {
-				fpm.logger.Error("Error parsing JSON", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
-				return fmt.Errorf("error parsing JSON file %s: %w", path, err)
-			}
+			unmarshal = json.Unmarshal
 		case ".yaml", ".yml": // Support YAML format - Recommendation 2
-			if err := yaml.Unmarshal(file, &promptMap); err != nil {
-				fpm.logger.Error("Error parsing YAML", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
-				return fmt.Errorf("error parsing YAML file %s: %w", path, err)
-			}
+			unmarshal = yaml.Unmarshal
 		case ".toml": // Support TOML format - Recommendation 2
-			if err := toml.Unmarshal(file, &promptMap); err != nil {
-				fpm.logger.Error("Error parsing TOML", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
-				return fmt.Errorf("error parsing TOML file %s: %w", path, err)
-			}
+			unmarshal = toml.Unmarshal
 		default:
 			fpm.logger.Debug("Skipping unsupported file type", zap.String("operation", operation), zap.String("path", path), zap.String("extension", ext))
 			return nil // Skip unsupported file types
 		}
 
-		promptID := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
-		for _, prompt := range promptMap {
-			fpm.promptTemplates[promptID] = prompt
-			fpm.promptCache.Store(promptID, prompt) // Store prompt in cache - Recommendation 1
+		file, err := os.ReadFile(path)
+		if err != nil {
+			fpm.logger.Error("Error reading prompt file", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
+			return fmt.Errorf("error reading prompt file %s: %w", path, err)
 		}
-		fpm.logger.Debug("Loaded prompt from file", zap.String("operation", operation), zap.String("path", path), zap.String("prompt_id", promptID))
+		prompt, err := parsePromptFile(file, unmarshal)
+		if err != nil {
+			fpm.logger.Error("Error parsing prompt file", zap.String("operation", operation), zap.String("path", path), zap.Error(err))
+			return fmt.Errorf("error parsing prompt file %s: %w", path, err)
+		}
+		if prompt.ID == "" {
+			prompt.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if err := prompt.Compile(); err != nil {
+			return fmt.Errorf("invalid prompt file %s: %w", path, err)
+		}
+		if _, exists := fpm.promptTemplates[prompt.ID]; exists {
+			return fmt.Errorf("duplicate prompt ID %q in %s", prompt.ID, path)
+		}
+
+		fpm.promptTemplates[prompt.ID] = prompt
+		fpm.promptCache.Store(prompt.ID, prompt) // Store prompt in cache - Recommendation 1
+		fpm.logger.Debug("Loaded prompt from file", zap.String("operation", operation), zap.String("path", path), zap.String("prompt_id", prompt.ID), zap.Int("variables", len(prompt.Variables)))
 		return nil
 	})
 }
 
-// GetPrompt retrieves a prompt template by its ID, first checking the cache and then loading from files if cache miss.
-func (fpm *FilePromptManager) GetPrompt(ctx context.Context, promptID string) (string, error) {
-	const operation = "FilePromptManager.GetPrompt"
+// parsePromptFile decodes a prompt file, accepting the earlier single "name: text" format as well.
+func parsePromptFile(file []byte, unmarshal func([]byte, any) error) (*PromptTemplate, error) {
+	var prompt PromptTemplate
+	if err := unmarshal(file, &prompt); err == nil && prompt.Template != "" {
+		return &prompt, nil
+	}
+	var legacy map[string]string
+	if err := unmarshal(file, &legacy); err != nil {
+		return nil, err
+	}
+	if len(legacy) != 1 {
+		return nil, fmt.Errorf("no template field, and %d name/text pairs instead of one", len(legacy))
+	}
+	for _, text := range legacy {
+		prompt = PromptTemplate{Template: text}
+	}
+	return &prompt, nil
+}
+
+// Compile validates the prompt's metadata and parses its template. It must be called before the prompt is rendered.
+func (p *PromptTemplate) Compile() error {
+	if p.OutputFormat.Type == "" {
+		p.OutputFormat.Type = "json"
+	}
+	if p.OutputFormat.Type != "json" {
+		return fmt.Errorf("prompt %s: unsupported output format type %q", p.ID, p.OutputFormat.Type)
+	}
+	if output, managed := promptOutputs[p.ID]; managed {
+		expected := reflect.TypeOf(output).Name()
+		if p.OutputFormat.Schema == "" {
+			p.OutputFormat.Schema = expected
+		} else if p.OutputFormat.Schema != expected {
+			return fmt.Errorf("prompt %s: output schema %s does not match the %s its call returns", p.ID, p.OutputFormat.Schema, expected)
+		}
+	}
+	seen := make(map[string]bool, len(p.Variables))
+	for _, v := range p.Variables {
+		if v.Name == "" || seen[v.Name] {
+			return fmt.Errorf("prompt %s: variable names must be set and unique (%q)", p.ID, v.Name)
+		}
+		seen[v.Name] = true
+	}
+	parsed, err := template.New(p.ID).Option("missingkey=error").Parse(p.Template)
+	if err != nil {
+		return fmt.Errorf("prompt %s: %w", p.ID, err)
+	}
+	p.parsed = parsed
+	return nil
+}
+
+// render checks vars against the declared variables and executes the template.
+// Supplied variables that are not declared are ignored by the check; the template may still use them.
+func (p *PromptTemplate) render(vars map[string]any) (*RenderedPrompt, error) {
+	data := make(map[string]any, len(p.Variables)+len(vars))
+	for name, value := range vars {
+		data[name] = value
+	}
+	var missing []string
+	for _, v := range p.Variables {
+		value, ok := data[v.Name]
+		supplied := ok && value != nil && value != ""
+		switch {
+		case supplied:
+		case v.Required:
+			missing = append(missing, v.Name)
+		default:
+			data[v.Name] = v.Default
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, domain.NewValidationError(fmt.Sprintf("prompt %s: missing required variables: %s", p.ID, strings.Join(missing, ", ")))
+	}
+
+	var text bytes.Buffer
+	if err := p.parsed.Execute(&text, data); err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("prompt %s: rendering template: %v", p.ID, err))
+	}
+	rendered := strings.TrimSpace(text.String())
+	if instructions := strings.TrimSpace(p.OutputFormat.Instructions); instructions != "" {
+		rendered += "\n\n" + instructions
+	}
+	return &RenderedPrompt{ID: p.ID, Version: p.version, VersionID: p.versionID, Text: rendered, OutputFormat: p.OutputFormat}, nil
+}
 
-	fpm.logger.Debug("Retrieving prompt", zap.String("operation", operation), zap.String("prompt_id", promptID))
+// lookup returns a prompt template by its ID, first checking the cache and then the loaded templates.
+func (fpm *FilePromptManager) lookup(promptID string) (*PromptTemplate, error) {
+	const operation = "FilePromptManager.lookup"
 
 	// 1. Cache Lookup - Recommendation 1
 	if cachedPrompt, ok := fpm.promptCache.Load(promptID); ok {
-		prompt, ok := cachedPrompt.(string) // Type assertion
+		prompt, ok := cachedPrompt.(*PromptTemplate) // Type assertion
 		if !ok {
-			return "", fmt.Errorf("%s: invalid data type in cache for prompt ID: %s", operation, promptID) // Error for unexpected cache value type
+			return nil, fmt.Errorf("%s: invalid data type in cache for prompt ID: %s", operation, promptID) // Error for unexpected cache value type
 		}
 		fpm.logger.Debug("Prompt retrieved from cache", zap.String("operation", operation), zap.String("prompt_id", promptID)) // Debug log for cache hit
 		return prompt, nil                                                                                                     // Return cached prompt
@@ -130,12 +309,38 @@ func (fpm *FilePromptManager) GetPrompt(ctx context.Context, promptID string) (s
 		notFoundErr := domain.NewNotFoundError("Gemini prompt template", promptID)
 		notFoundErr.SetLogger(fpm.logger)
 		fpm.logger.Warn("Prompt template not found", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Error(notFoundErr)) // Warn log for not found prompt
-		return "", notFoundErr
+		return nil, notFoundErr
 	}
 
 	// 3. Store in Cache after successful retrieval from files - Recommendation 1 (Cache population on cache miss)
 	fpm.promptCache.Store(promptID, prompt)
 	fpm.logger.Debug("Prompt retrieved from files and stored in cache", zap.String("operation", operation), zap.String("prompt_id", promptID)) // Debug log for cache population
-
 	return prompt, nil
 }
+
+// GetPrompt retrieves the unrendered template text of a prompt by its ID.
+func (fpm *FilePromptManager) GetPrompt(ctx context.Context, promptID string) (string, error) {
+	prompt, err := fpm.lookup(promptID)
+	if err != nil {
+		return "", err
+	}
+	return prompt.Template, nil
+}
+
+// RenderPrompt renders a prompt by its ID with vars, after checking that every required variable is supplied.
+// Prompt files take no part in experiments, so the session ID is only recorded on the rendered prompt.
+func (fpm *FilePromptManager) RenderPrompt(ctx context.Context, promptID string, sessionID uuid.UUID, vars map[string]any) (*RenderedPrompt, error) {
+	const operation = "FilePromptManager.RenderPrompt"
+
+	prompt, err := fpm.lookup(promptID)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := prompt.render(vars)
+	if err != nil {
+		fpm.logger.Warn("Prompt rendering failed", zap.String("operation", operation), zap.String("prompt_id", promptID), zap.Error(err))
+		return nil, err
+	}
+	rendered.SessionID = sessionID
+	return rendered, nil
+}
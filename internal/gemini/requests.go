@@ -0,0 +1,309 @@
+// internal/gemini/requests.go
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stackvity/lung-server/internal/gemini/models"
+)
+
+// The GeminiClient methods are implemented once, on top of a provider's completer: this file renders each method's
+// input into prompt parts and validates the provider's answer against the method's output struct. Providers
+// (GeminiProClient, OpenAIClient) only translate a completionRequest into their wire protocol.
+
+// completer is a model provider. complete sends one request asking for JSON matching req.schema and returns the
+// JSON text of the answer. Transport failures and error statuses are returned as *ErrGeminiRequestFailed (with a
+// *StatusError cause for error statuses); refusals and answers without text as *ErrGeminiResponseParsingFailed.
+type completer interface {
+	complete(ctx context.Context, req completionRequest) (string, error)
+}
+
+// completionRequest is one structured call, independent of the provider's protocol.
+type completionRequest struct {
+	operation       string
+	model           string // Overrides the provider's configured model when set
+	parts           []part // Text and inline images, in order
+	schema          *responseSchema
+	maxOutputTokens int32
+}
+
+// part is a text or an inline binary part of a prompt.
+type part struct {
+	Text       string      `json:"text,omitempty"`
+	InlineData *inlineData `json:"inlineData,omitempty"`
+}
+
+// inlineData carries an image in the request body. Data is base64-encoded by encoding/json.
+type inlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+// generate sends a call for output type T and decodes the answer into a T, returning the raw answer alongside.
+// Answers that are not a JSON object of the expected shape fail with *ErrGeminiResponseParsingFailed.
+func generate[T any](ctx context.Context, provider completer, call completionRequest) (*T, string, error) {
+	call.schema = schemaFor[T]()
+	raw, err := provider.complete(ctx, call)
+	if err != nil {
+		return nil, "", err
+	}
+	raw = stripCodeFence(raw)
+	output := new(T)
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	if err := decoder.Decode(output); err != nil {
+		return nil, raw, NewErrGeminiResponseParsingFailed("decoding structured output", bodySummary(0, raw), err)
+	}
+	if missing := missingRequired(call.schema, raw); len(missing) > 0 {
+		return nil, raw, NewErrGeminiResponseParsingFailed("structured output lacks required fields: "+strings.Join(missing, ", "), bodySummary(0, raw), nil)
+	}
+	return output, raw, nil
+}
+
+// stripCodeFence removes a Markdown code fence around a JSON answer. Models without schema-constrained decoding
+// (most JSON-mode servers) sometimes add one despite being asked for bare JSON.
+func stripCodeFence(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.HasPrefix(trimmed, "```") {
+		return raw
+	}
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	trimmed = strings.TrimPrefix(trimmed, "json")
+	return strings.TrimSpace(strings.TrimSuffix(trimmed, "```"))
+}
+
+// missingRequired returns the required top-level fields of schema absent from the JSON object raw.
+// Nested objects are not checked: a missing top-level field is what shows a model answered another question.
+func missingRequired(schema *responseSchema, raw string) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil // Already decoded into the output struct; a non-object would have failed there
+	}
+	var missing []string
+	for _, name := range schema.Required {
+		if _, ok := fields[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// StatusError is the cause of an *ErrGeminiRequestFailed for a non-2xx response, whatever the provider.
+type StatusError struct {
+	StatusCode int
+	Message    string
+	RetryAfter string // Retry-After header, if the API sent one (429, 503)
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("model API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the status code of a failed call, or 0 if err was not caused by an error response.
+func HTTPStatus(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// bodySummary describes a response body for inclusion in an error, which is logged: answers are derived from patient
+// documents, so only the HTTP status (if non-zero), the body's length and a hash of it are given. The hash matches
+// the body against what a debugging session captures.
+func bodySummary(status int, body string) string {
+	sum := sha256.Sum256([]byte(body))
+	summary := fmt.Sprintf("%d bytes, sha256 %s", len(body), hex.EncodeToString(sum[:8]))
+	if status != 0 {
+		summary = fmt.Sprintf("status %d, %s", status, summary)
+	}
+	return summary
+}
+
+// promptField is one labelled input value appended to a prompt.
+type promptField struct {
+	label string
+	value string
+}
+
+// promptParts returns the text part of a call: the prompt followed by the non-empty input fields, each under its label.
+func promptParts(prompt string, fields ...promptField) []part {
+	var text strings.Builder
+	text.WriteString(prompt)
+	for _, f := range fields {
+		if strings.TrimSpace(f.value) == "" {
+			continue
+		}
+		fmt.Fprintf(&text, "\n\n%s:\n%s", f.label, f.value)
+	}
+	return []part{{Text: text.String()}}
+}
+
+// detectNodules implements GeminiClient.DetectNodules.
+// When Slices are given, every slice is sent as an inline PNG preceded by its index and position, and ImageData
+// (the representative slice) is not sent again; otherwise ImageData is sent with ImageType.
+// Nodules below ConfidenceThreshold are dropped and at most MaxResults are returned, most confident first.
+func detectNodules(ctx context.Context, provider completer, operation string, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	// 1. Prompt and image parts
+	parts := promptParts(input.Prompt)
+	if len(input.Slices) > 0 {
+		for _, slice := range input.Slices {
+			parts = append(parts,
+				part{Text: fmt.Sprintf("Slice %d (position %.2f mm):", slice.SliceIndex, slice.Position)},
+				part{InlineData: &inlineData{MimeType: "image/png", Data: slice.ImageData}}, // Rendered slices are always PNG
+			)
+		}
+	} else if len(input.ImageData) > 0 {
+		parts = append(parts, part{InlineData: &inlineData{MimeType: input.ImageType, Data: input.ImageData}})
+	} else {
+		return nil, NewErrGeminiRequestFailed("no image data", "", "", fmt.Errorf("%s: input has neither ImageData nor Slices", operation))
+	}
+
+	// 2. Structured call
+	output, raw, err := generate[models.NoduleDetectionOutput](ctx, provider, completionRequest{operation: operation, parts: parts})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+
+	// 3. Apply the caller's threshold and result limit
+	if input.ConfidenceThreshold > 0 {
+		kept := output.Nodules[:0]
+		for _, nodule := range output.Nodules {
+			if nodule.Confidence >= float64(input.ConfidenceThreshold) {
+				kept = append(kept, nodule)
+			}
+		}
+		output.Nodules = kept
+	}
+	if input.MaxResults > 0 && len(output.Nodules) > int(input.MaxResults) {
+		sortNodulesByConfidence(output.Nodules)
+		output.Nodules = output.Nodules[:input.MaxResults]
+	}
+	return output, nil
+}
+
+// sortNodulesByConfidence orders nodules by decreasing confidence, keeping the model's order among equals.
+func sortNodulesByConfidence(nodules []models.NoduleInfo) {
+	for i := 1; i < len(nodules); i++ {
+		for j := i; j > 0 && nodules[j].Confidence > nodules[j-1].Confidence; j-- {
+			nodules[j], nodules[j-1] = nodules[j-1], nodules[j]
+		}
+	}
+}
+
+// analyzePathologyReport implements GeminiClient.AnalyzePathologyReport.
+// ModelVersion, when set, overrides the configured model; MaxTokens bounds the output.
+func analyzePathologyReport(ctx context.Context, provider completer, operation string, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	output, raw, err := generate[models.PathologyReportAnalysisOutput](ctx, provider, completionRequest{
+		operation:       operation,
+		model:           input.ModelVersion,
+		parts:           promptParts(input.Prompt, promptField{"Pathology report", input.ReportText}),
+		maxOutputTokens: input.MaxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+	return output, nil
+}
+
+// extractInformation implements GeminiClient.ExtractInformation.
+func extractInformation(ctx context.Context, provider completer, operation string, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	output, raw, err := generate[models.InformationExtractionOutput](ctx, provider, completionRequest{
+		operation: operation,
+		parts: promptParts(input.Prompt,
+			promptField{"Task", input.TaskType},
+			promptField{"Context", input.Context},
+			promptField{"Report", input.ReportText},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+	return output, nil
+}
+
+// generatePreliminaryDiagnosis implements GeminiClient.GeneratePreliminaryDiagnosis. The session ID is not sent.
+func generatePreliminaryDiagnosis(ctx context.Context, provider completer, operation string, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	output, raw, err := generate[models.DiagnosisOutput](ctx, provider, completionRequest{
+		operation: operation,
+		parts: promptParts(input.Prompt,
+			promptField{"Medical history", input.MedicalHistory},
+			promptField{"Symptoms", input.Symptoms},
+			promptField{"Findings", input.FindingsSummary},
+			promptField{"Reports", input.ReportText},
+			promptField{"Lab results (JSON)", labResultsJSON(input.LabResults)},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+	return output, nil
+}
+
+// labResultsJSON renders lab results as a JSON array, so that values, units, ranges and flags reach the model as
+// structured data rather than prose. It returns "" when there are none.
+func labResultsJSON(results []models.LabResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// getStagingInformation implements GeminiClient.GetStagingInformation. The session ID is not sent.
+func getStagingInformation(ctx context.Context, provider completer, operation string, input *models.StagingInput) (*models.StagingOutput, error) {
+	output, raw, err := generate[models.StagingOutput](ctx, provider, completionRequest{
+		operation: operation,
+		parts: promptParts(input.Prompt,
+			promptField{"T", input.T},
+			promptField{"N", input.N},
+			promptField{"M", input.M},
+			promptField{"Nodule size", input.NoduleSize},
+			promptField{"Lymph node involvement", input.LymphNodeInvolvement},
+			promptField{"Metastasis", input.MetastasisPresent},
+			promptField{"Findings", input.FindingsSummary},
+			promptField{"Reports", input.ReportText},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+	return output, nil
+}
+
+// suggestTreatmentOptions implements GeminiClient.SuggestTreatmentOptions. The session ID is not sent.
+func suggestTreatmentOptions(ctx context.Context, provider completer, operation string, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	age := ""
+	if input.PatientAge > 0 {
+		age = fmt.Sprint(input.PatientAge)
+	}
+	output, raw, err := generate[models.TreatmentRecommendationOutput](ctx, provider, completionRequest{
+		operation: operation,
+		parts: promptParts(input.Prompt,
+			promptField{"Diagnosis", input.Diagnosis},
+			promptField{"Stage", input.Stage},
+			promptField{"Age", age},
+			promptField{"Comorbidities", input.Comorbidities},
+			promptField{"Biomarkers", input.Biomarkers},
+			promptField{"Patient preferences", input.PatientPreferences},
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	output.RawResponse = raw
+	return output, nil
+}
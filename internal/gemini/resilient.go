@@ -0,0 +1,335 @@
+// internal/gemini/resilient.go
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// maxRetryDelay caps the exponential backoff. A longer Retry-After from the API is still honored.
+const maxRetryDelay = 10 * time.Second
+
+// ErrCircuitOpen is the cause of the *ErrGeminiAPIError returned while a method's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"    // Calls go through
+	BreakerOpen     = "open"      // Calls are rejected until the cooldown has passed
+	BreakerHalfOpen = "half_open" // One probe call is let through; its outcome closes or re-opens the breaker
+)
+
+// BreakerStatus is the state of one method's circuit breaker, as reported to the health endpoint.
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"` // Set while open or half-open
+}
+
+// BreakerReporter exposes the circuit breakers of a GeminiClient, keyed by method name.
+type BreakerReporter interface {
+	BreakerStates() map[string]BreakerStatus
+}
+
+// ResilientClient decorates a GeminiClient with classified retries and a circuit breaker per method.
+//
+// Calls failing with 429, a 5xx status or a timeout are retried up to GeminiMaxRetries times with exponential
+// backoff and jitter, waiting at least as long as the API's Retry-After. Other failures (400 and other 4xx statuses,
+// blocked prompts and unparseable output) are returned at once: repeating the same request cannot fix them.
+// A method whose calls fail GeminiBreakerThreshold times in a row, retries included, has its breaker opened; for
+// GeminiBreakerCooldown its calls then fail immediately with an *ErrGeminiAPIError wrapping ErrCircuitOpen.
+type ResilientClient struct {
+	inner    GeminiClient
+	config   *config.Config
+	breakers map[string]*circuitBreaker // Per method; created up front, so the map itself is never written after construction
+	logger   *zap.Logger
+}
+
+var (
+	_ GeminiClient    = (*ResilientClient)(nil)
+	_ BreakerReporter = (*ResilientClient)(nil)
+)
+
+// resilientMethods are the GeminiClient methods, each with its own breaker.
+var resilientMethods = []string{"DetectNodules", "AnalyzePathologyReport", "ExtractInformation", "GeneratePreliminaryDiagnosis", "GetStagingInformation", "SuggestTreatmentOptions"}
+
+// NewResilientClient wraps inner with retries and circuit breakers configured from cfg.
+func NewResilientClient(inner GeminiClient, cfg *config.Config, logger *zap.Logger) *ResilientClient {
+	c := &ResilientClient{
+		inner:    inner,
+		config:   cfg,
+		breakers: make(map[string]*circuitBreaker, len(resilientMethods)),
+		logger:   logger.Named("ResilientGeminiClient"),
+	}
+	for _, method := range resilientMethods {
+		c.breakers[method] = &circuitBreaker{threshold: cfg.GeminiBreakerThreshold, cooldown: cfg.GeminiBreakerCooldown, state: BreakerClosed}
+	}
+	return c
+}
+
+// Providers selectable with config.LLMProvider.
+const (
+	ProviderGemini = "gemini" // Gemini REST API (GeminiProClient)
+	ProviderOpenAI = "openai" // OpenAI-compatible chat-completions server (OpenAIClient)
+	ProviderFake   = "fake"   // Fixtures, for development and end-to-end tests (FakeClient)
+)
+
+// NewProviderClient is the Wire provider of the GeminiClient: it creates the client of the provider selected by
+// cfg.LLMProvider and decorates it with retries and circuit breakers. The fake provider is decorated too, so injected
+// errors exercise the retry and breaker paths.
+func NewProviderClient(cfg *config.Config, logger *zap.Logger) (*ResilientClient, error) {
+	var inner GeminiClient
+	switch cfg.LLMProvider {
+	case ProviderGemini:
+		inner = NewGeminiProClient(cfg, logger)
+	case ProviderOpenAI:
+		inner = NewOpenAIClient(cfg, logger)
+	case ProviderFake:
+		fake, err := NewFakeClient(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		logger.Warn("Fake model provider in use: answers come from fixtures", zap.String("operation", "gemini.NewProviderClient"), zap.String("fixtures", cfg.FakeLLMFixtures), zap.String("mode", cfg.FakeLLMMode))
+		inner = fake
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q: expected %q, %q or %q", cfg.LLMProvider, ProviderGemini, ProviderOpenAI, ProviderFake)
+	}
+	logger.Info("Model provider selected", zap.String("operation", "gemini.NewProviderClient"), zap.String("provider", cfg.LLMProvider))
+	return NewResilientClient(inner, cfg, logger), nil
+}
+
+// BreakerStates returns a snapshot of every method's breaker.
+func (c *ResilientClient) BreakerStates() map[string]BreakerStatus {
+	states := make(map[string]BreakerStatus, len(c.breakers))
+	for method, breaker := range c.breakers {
+		states[method] = breaker.status()
+	}
+	return states
+}
+
+// DetectNodules calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return callResilient(ctx, c, "DetectNodules", func(ctx context.Context) (*models.NoduleDetectionOutput, error) {
+		return c.inner.DetectNodules(ctx, input)
+	})
+}
+
+// AnalyzePathologyReport calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	return callResilient(ctx, c, "AnalyzePathologyReport", func(ctx context.Context) (*models.PathologyReportAnalysisOutput, error) {
+		return c.inner.AnalyzePathologyReport(ctx, input)
+	})
+}
+
+// ExtractInformation calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	return callResilient(ctx, c, "ExtractInformation", func(ctx context.Context) (*models.InformationExtractionOutput, error) {
+		return c.inner.ExtractInformation(ctx, input)
+	})
+}
+
+// GeneratePreliminaryDiagnosis calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	return callResilient(ctx, c, "GeneratePreliminaryDiagnosis", func(ctx context.Context) (*models.DiagnosisOutput, error) {
+		return c.inner.GeneratePreliminaryDiagnosis(ctx, input)
+	})
+}
+
+// GetStagingInformation calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	return callResilient(ctx, c, "GetStagingInformation", func(ctx context.Context) (*models.StagingOutput, error) {
+		return c.inner.GetStagingInformation(ctx, input)
+	})
+}
+
+// SuggestTreatmentOptions calls the wrapped client with retries and the method's circuit breaker.
+func (c *ResilientClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	return callResilient(ctx, c, "SuggestTreatmentOptions", func(ctx context.Context) (*models.TreatmentRecommendationOutput, error) {
+		return c.inner.SuggestTreatmentOptions(ctx, input)
+	})
+}
+
+// callResilient runs fn under method's breaker, retrying retryable failures.
+func callResilient[T any](ctx context.Context, c *ResilientClient, method string, fn func(context.Context) (T, error)) (T, error) {
+	operation := "ResilientGeminiClient." + method
+	requestID := utils.GetRequestID(ctx)
+	breaker := c.breakers[method]
+
+	// 1. Short-circuit while the breaker is open
+	if !breaker.allow() {
+		var zero T
+		c.logger.Warn("Gemini call rejected by open circuit breaker", zap.String("operation", operation), zap.String("request_id", requestID))
+		apiErr := NewErrGeminiAPIError(fmt.Sprintf("%s unavailable: too many consecutive failures", method), ErrCircuitOpen)
+		apiErr.Code = ErrCodeGeminiCircuitOpen
+		apiErr.SetLogger(c.logger)
+		return zero, apiErr
+	}
+
+	// 2. Call with classified retries
+	for attempt := 0; ; attempt++ {
+		output, err := fn(ctx)
+		if err == nil {
+			breaker.record(true)
+			return output, nil
+		}
+		retryable, retryAfter := classifyGeminiError(ctx, err)
+		if !retryable {
+			// Client-side failures say nothing about the API's health and do not move the breaker, except for a
+			// half-open probe, which must release its slot either way.
+			breaker.release()
+			return output, err
+		}
+		if attempt >= c.config.GeminiMaxRetries || ctx.Err() != nil {
+			breaker.record(false)
+			c.logger.Warn("Gemini call failed after retries", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("attempts", attempt+1), zap.String("breaker", breaker.status().State), zap.Error(err))
+			return output, err
+		}
+
+		delay := max(backoff(c.config.GeminiRetryBaseDelay, attempt), retryAfter)
+		c.logger.Info("Retrying Gemini call", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Int("status_code", HTTPStatus(err)))
+		if err := sleepContext(ctx, delay); err != nil {
+			breaker.release()
+			return output, fmt.Errorf("%s: waiting to retry: %w", operation, err)
+		}
+	}
+}
+
+// classifyGeminiError reports whether a failed call may succeed when repeated, and the delay the API asked for.
+// Cancellation of the caller's own context is never retried.
+func classifyGeminiError(ctx context.Context, err error) (bool, time.Duration) {
+	if ctx.Err() != nil {
+		return false, 0
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests, statusErr.StatusCode >= 500:
+			return true, parseRetryAfter(statusErr.RetryAfter)
+		}
+		return false, 0
+	}
+	var requestErr *ErrGeminiRequestFailed
+	if !errors.As(err, &requestErr) {
+		return false, 0 // Parsing failures (including blocked prompts) and anything unclassified
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true, 0
+	}
+	return false, 0
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// backoff returns the delay before retry attempt+1: base doubled per attempt, capped at maxRetryDelay, with jitter
+// spreading it over [delay/2, delay] so that clients failing together do not retry together.
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 30 && base<<attempt < maxRetryDelay {
+		delay = base << attempt
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// circuitBreaker counts consecutive failed calls of one method.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // A half-open probe is in flight
+}
+
+// allow reports whether a call may proceed, moving an open breaker to half-open once the cooldown has passed.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// record registers the outcome of an allowed call.
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.state, b.failures, b.openedAt = BreakerClosed, 0, time.Time{}
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt = BreakerOpen, time.Now()
+	}
+}
+
+// release ends an allowed call whose outcome says nothing about the API's health.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// status returns a snapshot of the breaker.
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
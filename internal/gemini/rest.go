@@ -0,0 +1,159 @@
+// internal/gemini/rest.go
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// maxResponseBytes is the largest response body read; structured outputs are far smaller.
+const maxResponseBytes = 16 << 20
+
+// generateContentRequest is the body of a models/{model}:generateContent call.
+type generateContentRequest struct {
+	Contents         []content        `json:"contents"`
+	GenerationConfig generationConfig `json:"generationConfig"`
+}
+
+// content is one conversational turn; the client only sends a single user turn.
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// generationConfig requests JSON output matching ResponseSchema.
+type generationConfig struct {
+	ResponseMimeType string          `json:"responseMimeType"`
+	ResponseSchema   *responseSchema `json:"responseSchema"`
+	MaxOutputTokens  int32           `json:"maxOutputTokens,omitempty"`
+}
+
+// generateContentResponse is the subset of the generateContent response the client reads.
+type generateContentResponse struct {
+	Candidates []struct {
+		Content      content `json:"content"`
+		FinishReason string  `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+	UsageMetadata UsageMetadata `json:"usageMetadata"`
+}
+
+// UsageMetadata reports the tokens consumed by one generateContent call.
+type UsageMetadata struct {
+	PromptTokenCount     int32 `json:"promptTokenCount"`
+	CandidatesTokenCount int32 `json:"candidatesTokenCount"`
+	TotalTokenCount      int32 `json:"totalTokenCount"`
+}
+
+// apiErrorResponse is the error body returned with non-2xx statuses.
+type apiErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// complete implements completer on models/{model}:generateContent, constraining the output with call.schema.
+// Request bodies are never logged or put into errors: they hold patient images and text.
+func (c *GeminiProClient) complete(ctx context.Context, call completionRequest) (string, error) {
+	requestID := utils.GetRequestID(ctx)
+	model := call.model
+	if model == "" {
+		model = c.config.GeminiModel
+	}
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(c.config.GeminiBaseURL, "/"), url.PathEscape(model))
+
+	// 1. Build the request with the output type's schema
+	body, err := json.Marshal(generateContentRequest{
+		Contents: []content{{Role: "user", Parts: call.parts}},
+		GenerationConfig: generationConfig{
+			ResponseMimeType: "application/json",
+			ResponseSchema:   call.schema,
+			MaxOutputTokens:  call.maxOutputTokens,
+		},
+	})
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("encoding request", endpoint, "", err)
+	}
+
+	// 2. Call the API within GeminiAPITimeout. The key goes in a header so it never appears in URLs or logs.
+	ctx, cancel := context.WithTimeout(ctx, c.config.GeminiAPITimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("building request", endpoint, "", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.config.GeminiAPIKey)
+
+	c.logger.Debug("Gemini API request", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.String("model", model), zap.Int("parts", len(call.parts)), zap.Int("request_bytes", len(body)))
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Warn("Gemini API request failed", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.Duration("elapsed", time.Since(start)), zap.Error(err))
+		return "", NewErrGeminiRequestFailed("sending request", endpoint, "", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", NewErrGeminiRequestFailed("reading response", endpoint, "", err)
+	}
+
+	// 3. Map error statuses
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr apiErrorResponse
+		message := http.StatusText(resp.StatusCode)
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			message = apiErr.Error.Status + ": " + apiErr.Error.Message
+		}
+		c.logger.Warn("Gemini API error response", zap.String("operation", call.operation), zap.String("request_id", requestID), zap.Int("status_code", resp.StatusCode), zap.String("error_message", message), zap.Duration("elapsed", time.Since(start)))
+		return "", NewErrGeminiRequestFailed(fmt.Sprintf("status %d", resp.StatusCode), endpoint, "", &StatusError{StatusCode: resp.StatusCode, Message: message, RetryAfter: resp.Header.Get("Retry-After")})
+	}
+
+	// 4. Extract the candidate's JSON text and decode it into the output struct
+	var generated generateContentResponse
+	if err := json.Unmarshal(respBody, &generated); err != nil {
+		return "", NewErrGeminiResponseParsingFailed("decoding generateContent response", bodySummary(resp.StatusCode, string(respBody)), err)
+	}
+	c.logger.Info("Gemini API response",
+		zap.String("operation", call.operation),
+		zap.String("request_id", requestID),
+		zap.Int("status_code", resp.StatusCode),
+		zap.Duration("elapsed", time.Since(start)),
+		zap.Int32("prompt_tokens", generated.UsageMetadata.PromptTokenCount),
+		zap.Int32("output_tokens", generated.UsageMetadata.CandidatesTokenCount),
+	)
+	reportUsage(ctx, generated.UsageMetadata.PromptTokenCount, generated.UsageMetadata.CandidatesTokenCount)
+	if generated.PromptFeedback != nil && generated.PromptFeedback.BlockReason != "" {
+		return "", NewErrGeminiResponseParsingFailed("prompt blocked: "+generated.PromptFeedback.BlockReason, "", nil)
+	}
+	if len(generated.Candidates) == 0 {
+		return "", NewErrGeminiResponseParsingFailed("response has no candidates", bodySummary(resp.StatusCode, string(respBody)), nil)
+	}
+	candidate := generated.Candidates[0]
+	var text strings.Builder
+	for _, p := range candidate.Content.Parts {
+		text.WriteString(p.Text)
+	}
+	if text.Len() == 0 {
+		return "", NewErrGeminiResponseParsingFailed("candidate has no text (finish reason "+candidate.FinishReason+")", "", nil)
+	}
+	if candidate.FinishReason == "MAX_TOKENS" {
+		return "", NewErrGeminiResponseParsingFailed("output truncated at the token limit", bodySummary(resp.StatusCode, text.String()), nil)
+	}
+	return text.String(), nil
+}
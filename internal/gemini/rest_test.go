@@ -0,0 +1,219 @@
+// internal/gemini/rest_test.go
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+)
+
+// newTestRESTClient returns a GeminiProClient calling the API served by handler.
+func newTestRESTClient(t *testing.T, handler http.HandlerFunc) *GeminiProClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewGeminiProClient(&config.Config{
+		GeminiBaseURL:    server.URL + "/v1beta",
+		GeminiAPIKey:     "test-key",
+		GeminiModel:      "gemini-test",
+		GeminiAPITimeout: 5 * time.Second,
+	}, zap.NewNop())
+}
+
+// answer writes a generateContent response whose single candidate holds text.
+func answer(w http.ResponseWriter, text, finishReason string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"candidates": []map[string]any{{
+			"content":      map[string]any{"role": "model", "parts": []map[string]any{{"text": text}}},
+			"finishReason": finishReason,
+		}},
+		"usageMetadata": map[string]any{"promptTokenCount": 120, "candidatesTokenCount": 30, "totalTokenCount": 150},
+	})
+}
+
+func stagingInput() *models.StagingInput {
+	return &models.StagingInput{PatientID: uuid.New(), Prompt: "Stage this case.", FindingsSummary: "- Spiculated nodule, 18 mm"}
+}
+
+func TestRESTCompleteSuccess(t *testing.T) {
+	client := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1beta/models/gemini-test:generateContent" {
+			t.Errorf("request %s %s, want POST /v1beta/models/gemini-test:generateContent", r.Method, r.URL.Path)
+		}
+		if key := r.Header.Get("x-goog-api-key"); key != "test-key" {
+			t.Errorf("API key header %q, want test-key", key)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("query %q: the key must not be sent in the URL", r.URL.RawQuery)
+		}
+		var request struct {
+			Contents []struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"contents"`
+			GenerationConfig struct {
+				ResponseMimeType string `json:"responseMimeType"`
+				ResponseSchema   struct {
+					Type       string                     `json:"type"`
+					Properties map[string]json.RawMessage `json:"properties"`
+					Required   []string                   `json:"required"`
+				} `json:"responseSchema"`
+			} `json:"generationConfig"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if len(request.Contents) != 1 || len(request.Contents[0].Parts) != 1 || !strings.Contains(request.Contents[0].Parts[0].Text, "Findings:\n- Spiculated nodule, 18 mm") {
+			t.Errorf("request contents %+v do not carry the prompt and findings", request.Contents)
+		}
+		schema := request.GenerationConfig.ResponseSchema
+		if request.GenerationConfig.ResponseMimeType != "application/json" || schema.Type != "OBJECT" {
+			t.Errorf("generation config %+v, want a JSON object schema", request.GenerationConfig)
+		}
+		for _, field := range []string{"T", "N", "M", "stageValue", "confidence"} {
+			if _, ok := schema.Properties[field]; !ok {
+				t.Errorf("response schema lacks property %q", field)
+			}
+		}
+		answer(w, `{"T": "T1c", "N": "N0", "M": "M0", "stageValue": "Stage IA3", "confidence": "High", "rawResponse": ""}`, "STOP")
+	})
+
+	output, err := client.GetStagingInformation(context.Background(), stagingInput())
+	if err != nil {
+		t.Fatalf("GetStagingInformation: %v", err)
+	}
+	if output.T != "T1c" || output.N != "N0" || output.M != "M0" || output.StageValue != "Stage IA3" || output.Confidence != "High" {
+		t.Errorf("output %+v, want T1c N0 M0, Stage IA3, High", output)
+	}
+	if !strings.Contains(output.RawResponse, `"stageValue": "Stage IA3"`) {
+		t.Errorf("raw response %q is not the model's answer", output.RawResponse)
+	}
+}
+
+func TestRESTCompleteErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wantMsg    string
+		retryable  bool
+		wantDelay  time.Duration
+	}{
+		{"rate limited", http.StatusTooManyRequests, "7", `{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED"}}`, "RESOURCE_EXHAUSTED: Quota exceeded", true, 7 * time.Second},
+		{"server error", http.StatusServiceUnavailable, "", `{"error": {"code": 503, "message": "The model is overloaded", "status": "UNAVAILABLE"}}`, "UNAVAILABLE: The model is overloaded", true, 0},
+		{"server error without a JSON body", http.StatusBadGateway, "", "<html>Bad Gateway</html>", "Bad Gateway", true, 0},
+		{"bad request", http.StatusBadRequest, "", `{"error": {"code": 400, "message": "Invalid JSON payload", "status": "INVALID_ARGUMENT"}}`, "INVALID_ARGUMENT: Invalid JSON payload", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := client.GetStagingInformation(context.Background(), stagingInput())
+			var requestErr *ErrGeminiRequestFailed
+			if !errors.As(err, &requestErr) {
+				t.Fatalf("error %v (%T), want *ErrGeminiRequestFailed", err, err)
+			}
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("error %v does not wrap a *StatusError", err)
+			}
+			if statusErr.StatusCode != tt.status || statusErr.Message != tt.wantMsg || statusErr.RetryAfter != tt.retryAfter {
+				t.Errorf("StatusError %+v, want status %d, message %q, Retry-After %q", statusErr, tt.status, tt.wantMsg, tt.retryAfter)
+			}
+			if HTTPStatus(err) != tt.status {
+				t.Errorf("HTTPStatus = %d, want %d", HTTPStatus(err), tt.status)
+			}
+			retryable, delay := classifyGeminiError(context.Background(), err)
+			if retryable != tt.retryable || delay != tt.wantDelay {
+				t.Errorf("classifyGeminiError = %v, %v; want %v, %v", retryable, delay, tt.retryable, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestRESTCompleteUnusableAnswers(t *testing.T) {
+	const patientText = "Jane Doe, born 1957-03-02"
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter)
+		wantMsg string
+	}{
+		{"blocked prompt", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"promptFeedback": {"blockReason": "SAFETY"}, "usageMetadata": {"promptTokenCount": 80}}`))
+		}, "prompt blocked: SAFETY"},
+		{"answer withheld for safety", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"candidates": [{"content": {"role": "model", "parts": []}, "finishReason": "SAFETY"}]}`))
+		}, "finish reason SAFETY"},
+		{"no candidates", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"candidates": [], "modelVersion": "` + patientText + `"}`))
+		}, "response has no candidates"},
+		{"malformed body", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "` + patientText))
+		}, "decoding generateContent response"},
+		{"answer truncated at the token limit", func(w http.ResponseWriter) {
+			answer(w, `{"T": "T1c", "N": "`+patientText, "MAX_TOKENS")
+		}, "output truncated at the token limit"},
+		{"answer that is not the output object", func(w http.ResponseWriter) {
+			answer(w, `["`+patientText+`"]`, "STOP")
+		}, "decoding structured output"},
+		{"answer missing required fields", func(w http.ResponseWriter) {
+			answer(w, `{"T": "T1c", "stageValue": "`+patientText+`"}`, "STOP")
+		}, "structured output lacks required fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				tt.respond(w)
+			})
+
+			_, err := client.GetStagingInformation(context.Background(), stagingInput())
+			var parseErr *ErrGeminiResponseParsingFailed
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v (%T), want *ErrGeminiResponseParsingFailed", err, err)
+			}
+			if !strings.Contains(parseErr.Message, tt.wantMsg) {
+				t.Errorf("message %q, want it to contain %q", parseErr.Message, tt.wantMsg)
+			}
+			if strings.Contains(err.Error(), patientText) {
+				t.Errorf("error %q contains the response body", err)
+			}
+			if retryable, _ := classifyGeminiError(context.Background(), err); retryable {
+				t.Error("an unusable answer was classified as retryable")
+			}
+		})
+	}
+}
+
+func TestBodySummary(t *testing.T) {
+	got := bodySummary(200, `{"candidates": []}`)
+	if !strings.HasPrefix(got, "status 200, 18 bytes, sha256 ") || len(got) != len("status 200, 18 bytes, sha256 ")+16 {
+		t.Errorf("bodySummary = %q, want the status, length and a 16-digit hash", got)
+	}
+	if bodySummary(0, "a") == bodySummary(0, "b") {
+		t.Error("different bodies have the same summary")
+	}
+	if strings.HasPrefix(bodySummary(0, "a"), "status") {
+		t.Error("bodySummary gave a status of 0")
+	}
+}
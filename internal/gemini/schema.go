@@ -0,0 +1,102 @@
+// internal/gemini/schema.go
+package gemini
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// responseSchema is the subset of the OpenAPI schema object accepted by generateContent's
+// generationConfig.responseSchema. It constrains the model to return JSON that decodes into an output struct.
+type responseSchema struct {
+	Type             string                     `json:"type"`
+	Description      string                     `json:"description,omitempty"`
+	Properties       map[string]*responseSchema `json:"properties,omitempty"`
+	PropertyOrdering []string                   `json:"propertyOrdering,omitempty"` // Keeps fields in struct order, so reasoning fields come out in a sensible sequence
+	Required         []string                   `json:"required,omitempty"`
+	Items            *responseSchema            `json:"items,omitempty"`
+}
+
+// clientFields are output fields filled in by the client, not by the model; they are left out of the schema.
+var clientFields = map[string]bool{"rawResponse": true, "error": true}
+
+// schemaCache holds the schema of each output type; schemas are derived once per type.
+var schemaCache sync.Map // reflect.Type -> *responseSchema
+
+// schemaFor returns the response schema of the output struct T (e.g. models.NoduleDetectionOutput), derived from
+// its json tags: fields without omitempty are required, and the "description" tag becomes the field description.
+func schemaFor[T any]() *responseSchema {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*responseSchema)
+	}
+	s := deriveSchema(t)
+	schemaCache.Store(t, s)
+	return s
+}
+
+// deriveSchema maps a Go type onto a schema. It panics on types that have no JSON schema equivalent (maps, interfaces,
+// channels), which only a change to the models package can introduce.
+func deriveSchema(t reflect.Type) *responseSchema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return deriveSchema(t.Elem())
+	case reflect.String:
+		return &responseSchema{Type: "STRING"}
+	case reflect.Bool:
+		return &responseSchema{Type: "BOOLEAN"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &responseSchema{Type: "INTEGER"}
+	case reflect.Float32, reflect.Float64:
+		return &responseSchema{Type: "NUMBER"}
+	case reflect.Slice, reflect.Array:
+		return &responseSchema{Type: "ARRAY", Items: deriveSchema(t.Elem())}
+	case reflect.Struct:
+		s := &responseSchema{Type: "OBJECT", Properties: map[string]*responseSchema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || clientFields[name] {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			property := deriveSchema(field.Type)
+			property.Description = field.Tag.Get("description")
+			s.Properties[name] = property
+			s.PropertyOrdering = append(s.PropertyOrdering, name)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	panic(fmt.Sprintf("gemini: no response schema for Go type %s", t))
+}
+
+// jsonSchema returns s as a standard JSON Schema document (lower-case types, no Gemini-specific keywords), for
+// providers that take the schema as part of the prompt.
+func (s *responseSchema) jsonSchema() map[string]any {
+	doc := map[string]any{"type": strings.ToLower(s.Type)}
+	if s.Description != "" {
+		doc["description"] = s.Description
+	}
+	if s.Items != nil {
+		doc["items"] = s.Items.jsonSchema()
+	}
+	if s.Properties != nil {
+		properties := make(map[string]any, len(s.Properties))
+		for name, property := range s.Properties {
+			properties[name] = property.jsonSchema()
+		}
+		doc["properties"] = properties
+		doc["required"] = s.Required
+	}
+	return doc
+}
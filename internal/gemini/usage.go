@@ -0,0 +1,193 @@
+// internal/gemini/usage.go
+package gemini
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stackvity/lung-server/internal/config"
+	dataModels "github.com/stackvity/lung-server/internal/data/models"
+	"github.com/stackvity/lung-server/internal/gemini/models"
+	"github.com/stackvity/lung-server/internal/utils"
+)
+
+// UsageStore persists the token usage of model calls (implemented by postgres.LLMUsageRepository).
+type UsageStore interface {
+	// RecordLLMUsage stores the token usage and latency of a model call.
+	RecordLLMUsage(ctx context.Context, usage *dataModels.LLMUsage) error
+	// GetTokensUsedSince sums the tokens of every model call made since a time.
+	GetTokensUsedSince(ctx context.Context, since time.Time) (int64, error)
+	// GetSessionTokensUsed sums the tokens of every model call made for a session.
+	GetSessionTokensUsed(ctx context.Context, sessionID uuid.UUID) (int64, error)
+}
+
+type usageMeterKey struct{}
+
+// usageMeter collects the tokens providers report during one metered call. Retries report again, so the tokens of
+// every attempt add up.
+type usageMeter struct {
+	promptTokens atomic.Int64
+	outputTokens atomic.Int64
+}
+
+// withUsageMeter returns a copy of ctx in which providers report their token usage to a new meter.
+func withUsageMeter(ctx context.Context) (context.Context, *usageMeter) {
+	meter := &usageMeter{}
+	return context.WithValue(ctx, usageMeterKey{}, meter), meter
+}
+
+// reportUsage adds the tokens of one provider response to the meter of ctx, if any.
+func reportUsage(ctx context.Context, promptTokens, outputTokens int32) {
+	if meter, ok := ctx.Value(usageMeterKey{}).(*usageMeter); ok {
+		meter.promptTokens.Add(int64(promptTokens))
+		meter.outputTokens.Add(int64(outputTokens))
+	}
+}
+
+// MeteredClient decorates a GeminiClient with token accounting and budgets.
+//
+// Every call is recorded with its method, session and prompt version (see WithPrompt), the prompt and output tokens
+// the provider reported (summed over retries) and its latency. Before a call, the tokens used since midnight UTC and
+// by the call's session are checked against LLM_DAILY_TOKEN_BUDGET and LLM_SESSION_TOKEN_BUDGET; once a budget is
+// used up, calls fail at once with an *ErrGeminiBudgetExceeded. Budgets are checked before a call and not reserved,
+// so concurrent calls can overshoot a budget by the tokens of the calls in flight. Store failures are logged and
+// never fail a call.
+type MeteredClient struct {
+	inner         GeminiClient
+	store         UsageStore
+	dailyBudget   int64 // 0: unlimited
+	sessionBudget int64 // 0: unlimited
+	logger        *zap.Logger
+}
+
+var _ GeminiClient = (*MeteredClient)(nil)
+
+// NewMeteredClient wraps inner with the token accounting and budgets configured in cfg.
+func NewMeteredClient(inner *ResilientClient, store UsageStore, cfg *config.Config, logger *zap.Logger) *MeteredClient {
+	logger.Info("Model token budgets", zap.String("operation", "gemini.NewMeteredClient"), zap.Int64("daily_budget", cfg.LLMDailyTokenBudget), zap.Int64("session_budget", cfg.LLMSessionTokenBudget))
+	return &MeteredClient{
+		inner:         inner,
+		store:         store,
+		dailyBudget:   cfg.LLMDailyTokenBudget,
+		sessionBudget: cfg.LLMSessionTokenBudget,
+		logger:        logger.Named("MeteredGeminiClient"),
+	}
+}
+
+// DetectNodules checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) DetectNodules(ctx context.Context, input *models.NoduleDetectionInput) (*models.NoduleDetectionOutput, error) {
+	return callMetered(ctx, c, "DetectNodules", func(ctx context.Context) (*models.NoduleDetectionOutput, error) {
+		return c.inner.DetectNodules(ctx, input)
+	})
+}
+
+// AnalyzePathologyReport checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) AnalyzePathologyReport(ctx context.Context, input *models.PathologyReportAnalysisInput) (*models.PathologyReportAnalysisOutput, error) {
+	return callMetered(ctx, c, "AnalyzePathologyReport", func(ctx context.Context) (*models.PathologyReportAnalysisOutput, error) {
+		return c.inner.AnalyzePathologyReport(ctx, input)
+	})
+}
+
+// ExtractInformation checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) ExtractInformation(ctx context.Context, input *models.InformationExtractionInput) (*models.InformationExtractionOutput, error) {
+	return callMetered(ctx, c, "ExtractInformation", func(ctx context.Context) (*models.InformationExtractionOutput, error) {
+		return c.inner.ExtractInformation(ctx, input)
+	})
+}
+
+// GeneratePreliminaryDiagnosis checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) GeneratePreliminaryDiagnosis(ctx context.Context, input *models.DiagnosisInput) (*models.DiagnosisOutput, error) {
+	return callMetered(ctx, c, "GeneratePreliminaryDiagnosis", func(ctx context.Context) (*models.DiagnosisOutput, error) {
+		return c.inner.GeneratePreliminaryDiagnosis(ctx, input)
+	})
+}
+
+// GetStagingInformation checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) GetStagingInformation(ctx context.Context, input *models.StagingInput) (*models.StagingOutput, error) {
+	return callMetered(ctx, c, "GetStagingInformation", func(ctx context.Context) (*models.StagingOutput, error) {
+		return c.inner.GetStagingInformation(ctx, input)
+	})
+}
+
+// SuggestTreatmentOptions checks the budgets, calls the wrapped client and records the usage.
+func (c *MeteredClient) SuggestTreatmentOptions(ctx context.Context, input *models.TreatmentRecommendationInput) (*models.TreatmentRecommendationOutput, error) {
+	return callMetered(ctx, c, "SuggestTreatmentOptions", func(ctx context.Context) (*models.TreatmentRecommendationOutput, error) {
+		return c.inner.SuggestTreatmentOptions(ctx, input)
+	})
+}
+
+// callMetered runs fn for method if the budgets allow it and records its usage.
+func callMetered[T any](ctx context.Context, c *MeteredClient, method string, fn func(context.Context) (*T, error)) (*T, error) {
+	operation := "MeteredGeminiClient." + method
+	requestID := utils.GetRequestID(ctx)
+
+	usage := &dataModels.LLMUsage{Method: method}
+	if prompt := promptFromContext(ctx); prompt != nil {
+		usage.SessionID = prompt.SessionID
+		usage.PromptName, usage.PromptVersion, usage.PromptVersionID = prompt.ID, prompt.Version, prompt.VersionID
+	}
+
+	// 1. Fail fast once a budget is used up
+	if err := c.checkBudgets(ctx, method, usage.SessionID); err != nil {
+		c.logger.Warn("Model call rejected by token budget", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("session_id", usage.SessionID.String()), zap.Error(err))
+		return nil, err
+	}
+
+	// 2. Call the model, collecting the tokens the provider reports
+	meteredCtx, meter := withUsageMeter(ctx)
+	start := time.Now()
+	output, err := fn(meteredCtx)
+	usage.Latency = time.Since(start)
+	usage.PromptTokens = int(meter.promptTokens.Load())
+	usage.OutputTokens = int(meter.outputTokens.Load())
+
+	// 3. Record the usage, failed calls included: they may have consumed tokens too
+	var parseErr *ErrGeminiResponseParsingFailed
+	switch {
+	case err == nil:
+		usage.Outcome = dataModels.PromptCallSuccess
+	case errors.As(err, &parseErr):
+		usage.Outcome = dataModels.PromptCallParseFailure
+	default:
+		usage.Outcome = dataModels.PromptCallError
+	}
+	if recordErr := c.store.RecordLLMUsage(ctx, usage); recordErr != nil {
+		c.logger.Warn("Failed to record model usage", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(recordErr))
+	}
+	c.logger.Debug("Model call metered", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("prompt_tokens", usage.PromptTokens), zap.Int("output_tokens", usage.OutputTokens), zap.Duration("latency", usage.Latency), zap.String("outcome", usage.Outcome))
+	return output, err
+}
+
+// checkBudgets returns an *ErrGeminiBudgetExceeded if the daily or the session's budget is used up. Budgets that
+// cannot be read are logged and not enforced.
+func (c *MeteredClient) checkBudgets(ctx context.Context, method string, sessionID uuid.UUID) error {
+	const operation = "MeteredGeminiClient.checkBudgets"
+
+	if c.dailyBudget > 0 {
+		used, err := c.store.GetTokensUsedSince(ctx, startOfDay(time.Now()))
+		if err != nil {
+			c.logger.Warn("Failed to read daily token usage, budget not enforced", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.Error(err))
+		} else if used >= c.dailyBudget {
+			return NewErrGeminiBudgetExceeded(method, BudgetDaily, c.dailyBudget, used)
+		}
+	}
+	if c.sessionBudget > 0 && sessionID != uuid.Nil {
+		used, err := c.store.GetSessionTokensUsed(ctx, sessionID)
+		if err != nil {
+			c.logger.Warn("Failed to read session token usage, budget not enforced", zap.String("operation", operation), zap.String("request_id", utils.GetRequestID(ctx)), zap.Error(err))
+		} else if used >= c.sessionBudget {
+			return NewErrGeminiBudgetExceeded(method, BudgetSession, c.sessionBudget, used)
+		}
+	}
+	return nil
+}
+
+// startOfDay returns midnight UTC of t's day, when the daily budget resets.
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
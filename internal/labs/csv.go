@@ -0,0 +1,394 @@
+// internal/labs/csv.go
+package labs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// headerSearchRows is how many rows may precede the header row (export titles, report dates).
+	headerSearchRows = 10
+	// sampleRows is how many rows are inspected to detect the delimiter and the columns of exports without a header.
+	sampleRows = 200
+	// minColumnShare is the share of a column's non-empty cells that must look alike for it to be detected by content.
+	minColumnShare = 0.6
+)
+
+// Columns are the indexes of the detected columns of a CSV export; -1 for columns it does not have.
+type Columns struct {
+	Analyte        int
+	Code           int
+	Value          int
+	Unit           int
+	ReferenceRange int
+	Flag           int
+	Date           int
+	HeaderRow      int // 0-based row of the header; -1 if the columns were detected by their content
+}
+
+// CSVImport is the result of reading a CSV export.
+type CSVImport struct {
+	Observations []Observation
+	Columns      Columns
+	Delimiter    rune
+	Skipped      int // Data rows without an analyte or a value
+}
+
+// ParseCSV reads the lab results of a CSV export with one result per row. The delimiter (comma, semicolon, tab or
+// pipe) is detected, and so are the columns: by the names in a header row when one of the first rows has them, by
+// their content otherwise. Rows before the header and columns that are not detected are ignored. Exports that are
+// not valid UTF-8 are read as Windows-1252, as spreadsheet programs write them. Semicolon-delimited exports may use
+// decimal commas.
+func ParseCSV(data []byte) (*CSVImport, error) {
+	text := decodeText(data)
+	delimiter := sniffDelimiter(text)
+	decimalComma := delimiter == ';'
+
+	// 1. Records
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("labs: reading CSV: %w", err)
+		}
+		if isBlank(record) {
+			continue
+		}
+		if len(records) >= MaxRows+headerSearchRows {
+			return nil, ErrTooManyRows
+		}
+		records = append(records, record)
+	}
+
+	// 2. Columns: from a header row, or from the content of the rows
+	columns, found := Columns{}, false
+	for i := 0; i < len(records) && i < headerSearchRows; i++ {
+		if columns, found = columnsFromHeader(records[i]); found {
+			columns.HeaderRow = i
+			break
+		}
+	}
+	if !found {
+		if columns, found = columnsFromContent(records, decimalComma); !found {
+			return nil, ErrNoLabColumns
+		}
+	}
+	rows := records[columns.HeaderRow+1:]
+	if len(rows) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+
+	// 3. Observations
+	dayFirst := decimalComma // Semicolon exports come from locales that write the day first
+	if columns.Date >= 0 {
+		dates := make([]string, 0, len(rows))
+		for _, row := range rows {
+			dates = append(dates, cell(row, columns.Date))
+		}
+		dayFirst = numericDateOrder(dates, dayFirst)
+	}
+	result := &CSVImport{Columns: columns, Delimiter: delimiter}
+	for _, row := range rows {
+		o := Observation{
+			Analyte:        cell(row, columns.Analyte),
+			Code:           cell(row, columns.Code),
+			Value:          cell(row, columns.Value),
+			Unit:           cell(row, columns.Unit),
+			ReferenceRange: cell(row, columns.ReferenceRange),
+			Flag:           cell(row, columns.Flag),
+		}
+		if strings.TrimSpace(o.Analyte) == "" || strings.TrimSpace(o.Value) == "" {
+			result.Skipped++
+			continue
+		}
+		o.normalize(decimalComma)
+		if date := cell(row, columns.Date); date != "" {
+			o.ObservedAt, _ = parseDate(date, dayFirst)
+		}
+		result.Observations = append(result.Observations, o)
+	}
+	if len(result.Observations) == 0 {
+		return nil, ErrNoObservations
+	}
+	return result, nil
+}
+
+// cell returns a trimmed cell of a row, or "" if the column is missing (-1) or the row is short.
+func cell(row []string, column int) string {
+	if column < 0 || column >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[column])
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// windows1252 maps the bytes 0x80-0x9F of Windows-1252; the other bytes are the Latin-1 code points.
+var windows1252 = []rune("€�‚ƒ„…†‡ˆ‰Š‹Œ�Ž��‘’“”•–—˜™š›œ�žŸ")
+
+// decodeText returns data as a string without a byte order mark, decoding it as Windows-1252 if it is not UTF-8.
+func decodeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var text strings.Builder
+	for _, b := range data {
+		switch {
+		case b >= 0x80 && b < 0xA0:
+			text.WriteRune(windows1252[b-0x80])
+		default:
+			text.WriteRune(rune(b))
+		}
+	}
+	return text.String()
+}
+
+// sniffDelimiter picks the candidate delimiter found the same number of times, outside quotes, on the most of the
+// first lines, preferring the one found most often on them. It defaults to a comma.
+func sniffDelimiter(text string) rune {
+	lines := strings.Split(text, "\n")
+	if len(lines) > sampleRows {
+		lines = lines[:sampleRows]
+	}
+	best, bestScore := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		counts := map[int]int{} // Delimiters per line -> lines
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if n := countOutsideQuotes(line, candidate); n > 0 {
+				counts[n]++
+			}
+		}
+		for perLine, lineCount := range counts {
+			if score := lineCount*1000 + perLine; score > bestScore {
+				best, bestScore = candidate, score
+			}
+		}
+	}
+	return best
+}
+
+func countOutsideQuotes(line string, delimiter rune) int {
+	count, quoted := 0, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delimiter && !quoted:
+			count++
+		}
+	}
+	return count
+}
+
+// column kinds, in the order header words are checked (see headerWords).
+type columnKind int
+
+const (
+	kindIgnored columnKind = iota
+	kindDate
+	kindReferenceRange
+	kindUnit
+	kindFlag
+	kindCode
+	kindValue
+	kindAnalyte
+)
+
+// headerWords are the words identifying the kind of a column in a header cell. Kinds are checked in order and the
+// first with a word in the cell wins, so that "Result date" is a date, "Result unit" a unit and "Test result" a
+// value. Columns naming the patient or other people, and notes, are ignored first: they are never read.
+var headerWords = []struct {
+	kind  columnKind
+	words []string
+}{
+	{kindIgnored, []string{"patient", "mrn", "dob", "birth", "ssn", "address", "phone", "email",
+		"physician", "doctor", "provider", "ordering", "ordered", "performing", "accession", "status", "comment",
+		"comments", "note", "notes", "method", "specimen", "sex", "gender", "age"}},
+	{kindDate, []string{"date", "time", "datetime", "collected", "collection", "drawn", "resulted", "reported"}},
+	{kindReferenceRange, []string{"range", "reference", "ref", "normal", "interval", "limits"}},
+	{kindUnit, []string{"unit", "units", "uom"}},
+	{kindFlag, []string{"flag", "flags", "abnormal", "interpretation"}},
+	{kindCode, []string{"code", "loinc"}},
+	{kindValue, []string{"value", "result", "results", "reading", "measurement"}},
+	{kindAnalyte, []string{"test", "tests", "analyte", "component", "parameter", "name", "observation",
+		"description", "item", "assay", "exam", "investigation", "marker"}},
+}
+
+// headerKind returns the kind of a header cell.
+func headerKind(header string) (columnKind, bool) {
+	words := strings.FieldsFunc(strings.ToLower(header), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, entry := range headerWords {
+		for _, word := range words {
+			for _, candidate := range entry.words {
+				if word == candidate {
+					return entry.kind, true
+				}
+			}
+		}
+	}
+	return kindIgnored, false
+}
+
+// columnsFromHeader detects the columns of a header row. found is false unless it names an analyte and a value
+// column. When several cells name the same kind, the first is used.
+func columnsFromHeader(header []string) (Columns, bool) {
+	columns := noColumns()
+	for i, name := range header {
+		kind, ok := headerKind(name)
+		if !ok || kind == kindIgnored {
+			continue
+		}
+		if index := columns.of(kind); *index < 0 {
+			*index = i
+		}
+	}
+	return columns, columns.Analyte >= 0 && columns.Value >= 0
+}
+
+// columnsFromContent detects the columns of an export without a header from its first rows: the column of dates,
+// the column of reference ranges, the column of numbers (the values), the column of known units, the column of
+// flags, and the first column of text left (the analytes). found is false unless analytes and values are found.
+func columnsFromContent(records [][]string, decimalComma bool) (Columns, bool) {
+	if len(records) > sampleRows {
+		records = records[:sampleRows]
+	}
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+
+	// 1. Share of each column's non-empty cells that look like each kind
+	type profile struct {
+		filled                                        int
+		dates, ranges, twoSided, values, units, flags int
+		texts                                         int
+	}
+	profiles := make([]profile, width)
+	for _, record := range records {
+		for i, raw := range record {
+			value := strings.TrimSpace(raw)
+			if value == "" {
+				continue
+			}
+			p := &profiles[i]
+			p.filled++
+			_, isDate := parseDate(value, false)
+			if !isDate {
+				_, isDate = parseDate(value, true)
+			}
+			_, _, _, isValue := parseQuantity(value, decimalComma)
+			low, high := parseReferenceRange(value, decimalComma)
+			switch {
+			case isDate:
+				p.dates++
+			case low != nil && high != nil:
+				p.ranges++
+				p.twoSided++
+			case low != nil || high != nil:
+				p.ranges++
+			}
+			if isValue && !isDate {
+				p.values++
+			}
+			if isKnownUnit(value) {
+				p.units++
+			}
+			if parseFlag(value) != "" {
+				p.flags++
+			}
+			if !isDate && !isValue && strings.ContainsFunc(value, unicode.IsLetter) {
+				p.texts++
+			}
+		}
+	}
+	share := func(count, filled int) float64 {
+		if filled == 0 {
+			return 0
+		}
+		return float64(count) / float64(filled)
+	}
+
+	// 2. Assign each kind the unassigned column where it is most common
+	columns := noColumns()
+	assigned := make([]bool, width)
+	pick := func(target *int, count func(p profile) int, accept func(p profile) bool) {
+		best, bestShare := -1, minColumnShare
+		for i, p := range profiles {
+			if assigned[i] || p.filled == 0 || (accept != nil && !accept(p)) {
+				continue
+			}
+			if s := share(count(p), p.filled); s >= bestShare && (best < 0 || s > bestShare) {
+				best, bestShare = i, s
+			}
+		}
+		if best >= 0 {
+			*target = best
+			assigned[best] = true
+		}
+	}
+	pick(&columns.Date, func(p profile) int { return p.dates }, nil)
+	pick(&columns.ReferenceRange, func(p profile) int { return p.ranges }, func(p profile) bool { return p.twoSided > 0 })
+	pick(&columns.Value, func(p profile) int { return p.values }, nil)
+	pick(&columns.Unit, func(p profile) int { return p.units }, nil)
+	pick(&columns.Flag, func(p profile) int { return p.flags }, nil)
+	for i, p := range profiles {
+		if !assigned[i] && share(p.texts, p.filled) >= minColumnShare {
+			columns.Analyte = i
+			break
+		}
+	}
+	return columns, columns.Analyte >= 0 && columns.Value >= 0
+}
+
+// noColumns returns Columns with no column detected.
+func noColumns() Columns {
+	return Columns{Analyte: -1, Code: -1, Value: -1, Unit: -1, ReferenceRange: -1, Flag: -1, Date: -1, HeaderRow: -1}
+}
+
+// of returns the field holding the index of a kind's column.
+func (c *Columns) of(kind columnKind) *int {
+	switch kind {
+	case kindDate:
+		return &c.Date
+	case kindReferenceRange:
+		return &c.ReferenceRange
+	case kindUnit:
+		return &c.Unit
+	case kindFlag:
+		return &c.Flag
+	case kindCode:
+		return &c.Code
+	case kindValue:
+		return &c.Value
+	default:
+		return &c.Analyte
+	}
+}
@@ -0,0 +1,230 @@
+// internal/labs/hl7.go
+package labs
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/stackvity/lung-server/pkg/hl7"
+)
+
+// ErrNoResultMessages is returned for HL7 files without an ORU^R01 (unsolicited observation result) message.
+var ErrNoResultMessages = errors.New("labs: no ORU^R01 result messages found")
+
+// Report is the narrative text of one order of a result message: a pathology or imaging report, or the comments
+// on a panel.
+type Report struct {
+	Title      string    // Ordered service (OBR-4), e.g. "CT CHEST W CONTRAST"
+	ObservedAt time.Time // Observation date of the order (OBR-7); zero if unknown
+	Text       string    // Text results and notes of the order, one per line
+}
+
+// HL7Import is the result of reading HL7 v2 result messages.
+type HL7Import struct {
+	Observations []Observation
+	Reports      []Report
+	Messages     int // ORU^R01 messages read; other messages are ignored
+	Skipped      int // OBX segments that are neither results nor text: embedded documents, deleted results, ...
+}
+
+// ParseHL7 reads the results of the ORU^R01 messages of an HL7 v2 file. Each OBX segment with a numeric (NM, SN)
+// or coded (CE, CWE, CNE) value becomes an observation, with the unit, reference range, abnormal flag and date of
+// the segment (or of its order); OBX segments with text (TX, FT) and the notes (NTE) on an order become the text
+// of a report per order. String (ST) values are observations when they read as results (a number, or with a unit,
+// range or flag) and text otherwise. Only the order (OBR), result (OBX) and note (NTE) segments are read: patient
+// (PID) and visit segments and the notes on them are never looked at.
+func ParseHL7(data []byte) (*HL7Import, error) {
+	messages, err := hl7.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &HL7Import{}
+	for _, message := range messages {
+		if messageType, trigger := message.Type(); messageType != "ORU" || (trigger != "R01" && trigger != "") {
+			continue
+		}
+		result.Messages++
+		readResultMessage(message, result)
+	}
+	if result.Messages == 0 {
+		return nil, ErrNoResultMessages
+	}
+	if len(result.Observations) == 0 && len(result.Reports) == 0 {
+		return nil, ErrNoObservations
+	}
+	if len(result.Observations) > MaxRows {
+		return nil, ErrTooManyRows
+	}
+	return result, nil
+}
+
+// order collects the text of one OBR segment while its OBX and NTE segments are read.
+type order struct {
+	report  Report
+	lines   []string
+	heading string // Analyte of the last text written, written once above consecutive text of that analyte
+	analyte string // Analyte of the last result, which the notes after it are about
+	open    bool   // Notes belong to this order rather than to the patient or visit
+}
+
+func (o *order) addText(heading string, lines ...string) {
+	if heading != "" && heading != o.heading && heading != o.report.Title {
+		o.lines = append(o.lines, heading+":")
+	}
+	o.heading = heading
+	o.lines = append(o.lines, lines...)
+}
+
+// readResultMessage adds the observations and reports of one ORU^R01 message to result.
+func readResultMessage(message *hl7.Message, result *HL7Import) {
+	d := message.Delimiters
+	current := &order{}
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current.lines, "\n")); text != "" {
+			current.report.Text = text
+			result.Reports = append(result.Reports, current.report)
+		}
+	}
+
+	for _, segment := range message.Segments {
+		switch segment.Name {
+		case "OBR":
+			flush()
+			current = &order{open: true}
+			current.report.Title = codedText(segment.Field(4))
+			current.report.ObservedAt, _ = hl7.ParseTime(segment.Field(7).String())
+		case "OBX":
+			current.open = true // Results without an order are still results
+			readResult(segment, d, current, result)
+		case "NTE":
+			if current.open {
+				current.addText(current.analyte, textLines(segment.Field(3), d)...)
+			}
+		}
+	}
+	flush()
+}
+
+// readResult reads one OBX segment into an observation or into the text of its order.
+func readResult(segment *hl7.Segment, d hl7.Delimiters, current *order, result *HL7Import) {
+	switch segment.Field(11).String() { // Result status
+	case "D", "W", "X": // Deleted, wrong (replaced by a later result), cannot be obtained
+		result.Skipped++
+		return
+	}
+
+	analyte := codedText(segment.Field(3))
+	current.analyte = analyte
+	observation := Observation{
+		Analyte:        analyte,
+		Code:           segment.Field(3).Component(1),
+		Unit:           unitOf(segment.Field(6)),
+		ReferenceRange: joinRepetitions(segment.Field(7), d, " "),
+		Flag:           firstFlag(segment.Field(8)),
+	}
+	value := segment.Field(5)
+	switch valueType := strings.ToUpper(segment.Field(2).String()); valueType {
+	case "NM":
+		observation.Value = value.String()
+	case "SN":
+		observation.Value = structuredNumeric(value)
+	case "CE", "CWE", "CNE":
+		observation.Value = codedText(value)
+	case "ST":
+		observation.Value = joinRepetitions(value, d, " ")
+		if !readsAsResult(observation) {
+			current.addText(analyte, textLines(value, d)...)
+			return
+		}
+	case "TX", "FT":
+		current.addText(analyte, textLines(value, d)...)
+		return
+	default: // Embedded data (ED), references (RP), dates and the other types
+		result.Skipped++
+		return
+	}
+
+	if observedAt, ok := hl7.ParseTime(segment.Field(14).String()); ok {
+		observation.ObservedAt = observedAt
+	} else {
+		observation.ObservedAt = current.report.ObservedAt
+	}
+	observation.normalize(false)
+	if observation.Analyte == "" || observation.Value == "" {
+		result.Skipped++
+		return
+	}
+	result.Observations = append(result.Observations, observation)
+}
+
+// readsAsResult reports whether a string value is a result rather than a line of text: a quantity, or a value
+// given with a unit, reference range or flag.
+func readsAsResult(o Observation) bool {
+	if _, _, _, ok := parseQuantity(o.Value, false); ok {
+		return true
+	}
+	return o.Value != "" && (o.Unit != "" || o.ReferenceRange != "" || o.Flag != "")
+}
+
+// codedText returns the text of a coded field (CE, CWE): its text, else its alternate text, else its code.
+func codedText(field hl7.Field) string {
+	for _, component := range []int{2, 5, 1} {
+		if text := strings.TrimSpace(field.Component(component)); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// unitOf returns the unit of an OBX-6 field: the code (UCUM for most senders) if it is a known unit, else the
+// text if that one is, else whichever is given.
+func unitOf(field hl7.Field) string {
+	code, text := field.Component(1), field.Component(2)
+	switch {
+	case isKnownUnit(code):
+		return code
+	case isKnownUnit(text):
+		return text
+	case code != "":
+		return code
+	}
+	return text
+}
+
+// structuredNumeric writes a structured numeric value (SN: comparator, number, separator or suffix, number) the
+// way it reads: "<0.5", "1:128", "10-20", "2+".
+func structuredNumeric(field hl7.Field) string {
+	var value strings.Builder
+	for i := 1; i <= 4; i++ {
+		value.WriteString(field.Component(i))
+	}
+	return strings.TrimSpace(value.String())
+}
+
+// firstFlag returns the first abnormal flag of an OBX-8 field, which may repeat.
+func firstFlag(field hl7.Field) string {
+	for _, repetition := range field {
+		if flag := repetition.Component(1); parseFlag(flag) != "" {
+			return flag
+		}
+	}
+	return ""
+}
+
+// textLines returns the lines of a text field: each repetition starts a line, as do line breaks within them.
+func textLines(field hl7.Field, d hl7.Delimiters) []string {
+	var lines []string
+	for _, repetition := range field {
+		lines = append(lines, strings.Split(strings.TrimRight(repetition.Join(d), " "), "\n")...)
+	}
+	return lines
+}
+
+func joinRepetitions(field hl7.Field, d hl7.Delimiters, separator string) string {
+	values := make([]string, len(field))
+	for i, repetition := range field {
+		values[i] = repetition.Join(d)
+	}
+	return strings.TrimSpace(strings.Join(values, separator))
+}
@@ -0,0 +1,100 @@
+// internal/labs/labs.go
+
+// Package labs reads laboratory results out of uploaded exports (the CSV downloads of patient portals and lab
+// systems, and the HL7 v2 result messages clinics hand out) into normalized observations: analyte, value, unit,
+// reference range, flag and date. Only the columns and segments holding these are read; every other column of an
+// export (patient name, record number, ordering physician) and every patient or visit segment is ignored.
+package labs
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoLabColumns is returned when no analyte and value columns can be found.
+	ErrNoLabColumns = errors.New("labs: no analyte and value columns found")
+	// ErrNoObservations is returned when the columns were found but no row holds an analyte with a value.
+	ErrNoObservations = errors.New("labs: no lab results found")
+	// ErrTooManyRows is returned for exports longer than MaxRows.
+	ErrTooManyRows = errors.New("labs: too many rows")
+)
+
+// MaxRows bounds the rows read from one export.
+const MaxRows = 10000
+
+// Flags of an observation (Observation.Flag).
+const (
+	FlagLow      = "low"
+	FlagHigh     = "high"
+	FlagNormal   = "normal"
+	FlagAbnormal = "abnormal" // Outside the reference, direction unknown (e.g. a positive qualitative result)
+)
+
+// Observation is one lab result.
+type Observation struct {
+	Analyte        string    // Test name, with whitespace collapsed
+	Code           string    // Test code (e.g. LOINC), if the export gives one
+	Value          string    // As reported, less a trailing unit or flag: "5.2", "<0.5", "Positive"
+	Numeric        *float64  // Value as a number; nil for text and censored ("<0.5") values
+	Unit           string    // Normalized spelling (see NormalizeUnit)
+	ReferenceRange string    // As reported: "3.5-5.0", "<200"
+	ReferenceLow   *float64  // Lower bound of the reference range, if any
+	ReferenceHigh  *float64  // Upper bound of the reference range, if any
+	Flag           string    // One of the Flag constants; "" if neither reported nor derivable from the range
+	ObservedAt     time.Time // Collection or result date; zero if unknown
+}
+
+// normalize tidies the reported fields of an observation and fills the derived ones: the numeric value and the
+// bounds of the reference range are parsed, a unit written after the value is moved to Unit, and the flag is
+// derived from the range when none was reported. decimalComma accepts "5,2" as a number.
+func (o *Observation) normalize(decimalComma bool) {
+	o.Analyte = collapseSpaces(o.Analyte)
+	o.Code = strings.TrimSpace(o.Code)
+	o.Value = collapseSpaces(o.Value)
+	o.ReferenceRange = collapseSpaces(o.ReferenceRange)
+	o.Flag = parseFlag(o.Flag)
+
+	// 1. Value, with a trailing flag ("5.2 H") and a trailing unit ("5.2 mg/dL") split off
+	value := o.Value
+	if rest, flag, ok := splitTrailingFlag(value); ok {
+		if _, _, _, isQuantity := parseQuantity(rest, decimalComma); isQuantity {
+			value = rest
+			if o.Flag == "" {
+				o.Flag = flag
+			}
+		}
+	}
+	if comparator, number, unit, ok := parseQuantity(value, decimalComma); ok {
+		if unit != "" {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit))
+			if strings.TrimSpace(o.Unit) == "" {
+				o.Unit = unit
+			}
+		}
+		if comparator == "" {
+			o.Numeric = &number
+		}
+	}
+	o.Value = value
+	o.Unit = NormalizeUnit(o.Unit)
+
+	// 2. Reference range, and the flag it implies
+	o.ReferenceLow, o.ReferenceHigh = parseReferenceRange(o.ReferenceRange, decimalComma)
+	if o.Flag == "" && o.Numeric != nil {
+		switch {
+		case o.ReferenceLow != nil && *o.Numeric < *o.ReferenceLow:
+			o.Flag = FlagLow
+		case o.ReferenceHigh != nil && *o.Numeric > *o.ReferenceHigh:
+			o.Flag = FlagHigh
+		case o.ReferenceLow != nil || o.ReferenceHigh != nil:
+			o.Flag = FlagNormal
+		}
+	}
+}
+
+// collapseSpaces trims s and replaces runs of whitespace with single spaces.
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,265 @@
+// internal/labs/normalize.go
+package labs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// number matches a decimal number, with thousands separators or a decimal comma resolved by parseNumber.
+	number = regexp.MustCompile(`^[+-]?(?:\d[\d,]*(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?$`)
+	// thousands matches numbers written with comma thousands separators ("1,234.5").
+	thousands = regexp.MustCompile(`^[+-]?\d{1,3}(?:,\d{3})+(?:\.\d*)?$`)
+	// quantity matches a value: an optional comparator, a number and an optional unit.
+	quantity = regexp.MustCompile(`^(<=|>=|<|>|≤|≥)?\s*([+-]?[\d.,]*\d[\d.,]*)\s*(.*)$`)
+	// twoSidedRange matches "3.5-5.0", "3.5 – 5.0", "3.5 to 5.0" and "-2 - 2", with anything (a unit) after.
+	twoSidedRange = regexp.MustCompile(`^(-?\d[\d.,]*)\s*(?:-|–|—|to|\.\.)\s*(-?\d[\d.,]*)`)
+	// oneSidedRange matches "<200", "≤ 5", ">= 60", "up to 40" and "above 1.5", with anything (a unit) after.
+	oneSidedRange = regexp.MustCompile(`(?i)^(<=|>=|<|>|≤|≥|up to|below|under|above|over)\s*(-?\d[\d.,]*)`)
+	// numericDate matches dates written with day and month as numbers, in an order decided by the caller.
+	numericDate = regexp.MustCompile(`^(\d{1,2})[/.\-](\d{1,2})[/.\-](\d{4}|\d{2})\b(.*)$`)
+)
+
+// parseNumber parses a decimal number. Commas are thousands separators ("1,234.5"), or the decimal separator
+// when decimalComma is set and the number has no point ("5,2").
+func parseNumber(s string, decimalComma bool) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if !number.MatchString(s) {
+		return 0, false
+	}
+	switch {
+	case !strings.Contains(s, ","):
+	case decimalComma && strings.Count(s, ",") == 1 && !strings.Contains(s, "."):
+		s = strings.Replace(s, ",", ".", 1)
+	case thousands.MatchString(s):
+		s = strings.ReplaceAll(s, ",", "")
+	default:
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// parseQuantity splits a value into its comparator ("<", "<=", ">", ">=" or ""), number and unit. ok is false for
+// values that are not a number, or whose remainder does not look like a unit ("2 of 3", "1.2-3.4").
+func parseQuantity(s string, decimalComma bool) (comparator string, value float64, unit string, ok bool) {
+	m := quantity.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", 0, "", false
+	}
+	if value, ok = parseNumber(m[2], decimalComma); !ok {
+		return "", 0, "", false
+	}
+	if m[3] != "" && !looksLikeUnit(m[3]) {
+		return "", 0, "", false
+	}
+	comparator = strings.NewReplacer("≤", "<=", "≥", ">=").Replace(m[1])
+	return comparator, value, m[3], true
+}
+
+// looksLikeUnit reports whether s can be a unit: a short token with a letter, a percent or a slash.
+func looksLikeUnit(s string) bool {
+	if len(s) > 20 || strings.ContainsFunc(s, unicode.IsSpace) {
+		return false
+	}
+	return strings.ContainsFunc(s, func(r rune) bool { return unicode.IsLetter(r) || r == '%' || r == '/' }) &&
+		!strings.HasPrefix(s, "-")
+}
+
+// parseReferenceRange returns the bounds of a reference range; either is nil when the range does not give it.
+func parseReferenceRange(s string, decimalComma bool) (low, high *float64) {
+	s = strings.Trim(strings.TrimSpace(s), "()[]")
+	if m := twoSidedRange.FindStringSubmatch(s); m != nil {
+		l, okLow := parseNumber(m[1], decimalComma)
+		h, okHigh := parseNumber(m[2], decimalComma)
+		if okLow && okHigh && l <= h {
+			return &l, &h
+		}
+		return nil, nil
+	}
+	if m := oneSidedRange.FindStringSubmatch(s); m != nil {
+		bound, ok := parseNumber(m[2], decimalComma)
+		if !ok {
+			return nil, nil
+		}
+		switch strings.ToLower(m[1]) {
+		case "<", "<=", "≤", "up to", "below", "under":
+			return nil, &bound
+		default:
+			return &bound, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseFlag maps a reported abnormal flag (H, L, HH, LL, A, N, as in lab exports and HL7 OBX-8, or spelled out) to
+// a Flag constant, or "" for flags it does not know.
+func parseFlag(s string) string {
+	switch strings.ToUpper(strings.Trim(collapseSpaces(s), "()[]")) {
+	case "L", "LO", "LOW", "LL", "<", "↓", "CRITICAL LOW", "PANIC LOW":
+		return FlagLow
+	case "H", "HI", "HIGH", "HH", ">", "↑", "CRITICAL HIGH", "PANIC HIGH":
+		return FlagHigh
+	case "N", "NORMAL", "WNL", "WITHIN RANGE":
+		return FlagNormal
+	case "A", "AA", "ABN", "ABNORMAL", "*", "!":
+		return FlagAbnormal
+	}
+	return ""
+}
+
+// splitTrailingFlag splits a flag written after a value ("5.2 H", "5.2 (L)") off it. Only upper-case flags are
+// recognized, so that units such as "h" are left alone.
+func splitTrailingFlag(value string) (rest, flag string, ok bool) {
+	i := strings.LastIndexByte(value, ' ')
+	if i < 0 {
+		return value, "", false
+	}
+	token := value[i+1:]
+	if token != strings.ToUpper(token) {
+		return value, "", false
+	}
+	if flag = parseFlag(token); flag == "" {
+		return value, "", false
+	}
+	return strings.TrimSpace(value[:i]), flag, true
+}
+
+// canonicalUnits are the spellings units are normalized to.
+var canonicalUnits = []string{
+	"g/L", "g/dL", "mg/L", "mg/dL", "µg/L", "µg/dL", "µg/mL", "ng/L", "ng/mL", "ng/dL", "pg/mL",
+	"mol/L", "mmol/L", "µmol/L", "nmol/L", "pmol/L", "mEq/L", "mOsm/kg",
+	"U/L", "IU/L", "U/mL", "IU/mL", "kU/L", "mU/L", "mIU/L", "mIU/mL", "µIU/mL",
+	"10^3/µL", "10^6/µL", "10^9/L", "10^12/L", "/µL", "fL", "pg", "%",
+	"mm/h", "mmHg", "s", "min", "mL/min", "mL/min/1.73m²", "ratio",
+}
+
+// unitAliases maps the lower-cased, space-free spellings of units to their canonical spelling.
+var unitAliases = func() map[string]string {
+	aliases := map[string]string{}
+	for _, unit := range canonicalUnits {
+		aliases[strings.ToLower(unit)] = unit
+	}
+	micro := map[string]string{"ug": "µg", "mcg": "µg", "umol": "µmol", "mcmol": "µmol", "uiu": "µIU"}
+	for prefix, canonical := range micro {
+		for _, volume := range []string{"l", "dl", "ml"} {
+			if unit, ok := aliases[strings.ToLower(canonical+"/"+volume)]; ok {
+				aliases[prefix+"/"+volume] = unit
+			}
+		}
+	}
+	// Cell counts: "x10^9/L", "10*9/L", "10e9/L", "K/uL", ...
+	for _, count := range []struct{ exponent, volume, canonical string }{
+		{"3", "ul", "10^3/µL"}, {"3", "µl", "10^3/µL"}, {"3", "mm3", "10^3/µL"},
+		{"6", "ul", "10^6/µL"}, {"6", "µl", "10^6/µL"}, {"6", "mm3", "10^6/µL"},
+		{"9", "l", "10^9/L"}, {"12", "l", "10^12/L"},
+	} {
+		for _, prefix := range []string{"10^", "x10^", "×10^", "10*", "x10*", "10e", "x10e", "×10e"} {
+			aliases[prefix+count.exponent+"/"+count.volume] = count.canonical
+		}
+	}
+	for alias, unit := range map[string]string{
+		"k/ul": "10^3/µL", "k/µl": "10^3/µL", "thou/ul": "10^3/µL", "thou/µl": "10^3/µL", "m/ul": "10^6/µL",
+		"m/µl": "10^6/µL", "mill/ul": "10^6/µL", "mill/µl": "10^6/µL", "/ul": "/µL", "cells/ul": "/µL",
+		"cells/µl": "/µL", "ui/l": "IU/L", "u/l": "U/L", "iu/l": "IU/L", "mmhg": "mmHg", "mm/hr": "mm/h",
+		"sec": "s", "secs": "s", "seconds": "s", "percent": "%", "ml/min/1.73m2": "mL/min/1.73m²",
+		"ml/min/1,73m2": "mL/min/1.73m²", "ml/min/1.73m^2": "mL/min/1.73m²",
+		// UCUM codes, as HL7 messages carry them
+		"[iu]/l": "IU/L", "[iu]/ml": "IU/mL", "m[iu]/l": "mIU/L", "m[iu]/ml": "mIU/mL", "u[iu]/ml": "µIU/mL",
+		"mm[hg]": "mmHg", "meq/l": "mEq/L", "ml/min/{1.73_m2}": "mL/min/1.73m²", "{ratio}": "ratio",
+	} {
+		aliases[alias] = unit
+	}
+	return aliases
+}()
+
+// NormalizeUnit returns the canonical spelling of a unit ("mg/dl" is "mg/dL", "x10E9/L" and "G/L" are "10^9/L",
+// "mcg/L" is "µg/L"), or the unit trimmed if it is not a known one.
+func NormalizeUnit(unit string) string {
+	unit = strings.ReplaceAll(collapseSpaces(unit), "μ", "µ") // Greek mu to the micro sign
+	switch unit {
+	case "G/L": // Giga per litre, not grams
+		return "10^9/L"
+	case "T/L":
+		return "10^12/L"
+	}
+	if canonical, ok := unitAliases[strings.ToLower(strings.ReplaceAll(unit, " ", ""))]; ok {
+		return canonical
+	}
+	return unit
+}
+
+// isKnownUnit reports whether a unit has a canonical spelling.
+func isKnownUnit(unit string) bool {
+	normalized := NormalizeUnit(unit)
+	for _, canonical := range canonicalUnits {
+		if normalized == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// dateLayouts are the layouts of dates with the year first or the month spelled out. Dates with numeric day and
+// month ("01/02/2024") are rewritten year first by parseDate.
+var dateLayouts = []string{
+	"2006-01-02", "2006-1-2", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05",
+	time.RFC3339, "2006-01-02 3:04 PM", "2006-01-02 3:04:05 PM", "2006-01-02 3:04PM", "2006/01/02", "2006/1/2",
+	"2006/01/02 15:04", "2006/01/02 15:04:05", "20060102", "200601021504", "20060102150405",
+	"2 Jan 2006", "2 January 2006", "2-Jan-2006", "2-Jan-06", "Jan 2, 2006", "January 2, 2006", "Jan 2 2006",
+	"2 Jan 2006 15:04", "Jan 2, 2006 15:04", "Jan 2, 2006 3:04 PM",
+}
+
+// parseDate parses a collection or result date. For numeric dates such as "03/04/2024", dayFirst tells whether the
+// day (4 March) or the month (3 April) comes first; two-digit years are placed in the last hundred years. Dates
+// without a time zone are read as UTC.
+func parseDate(s string, dayFirst bool) (time.Time, bool) {
+	s = collapseSpaces(s)
+	if m := numericDate.FindStringSubmatch(s); m != nil {
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		year, _ := strconv.Atoi(m[3])
+		month, day := first, second
+		if dayFirst {
+			month, day = second, first
+		}
+		if len(m[3]) == 2 {
+			year += 2000
+			if year > time.Now().Year() {
+				year -= 100
+			}
+		}
+		s = fmt.Sprintf("%04d-%02d-%02d%s", year, month, day, m[4])
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// numericDateOrder tells whether the numeric dates of a column put the day first: a first part above 12 can only
+// be a day, a second part above 12 only a day after the month. Columns that settle neither way use fallback.
+func numericDateOrder(dates []string, fallback bool) bool {
+	for _, date := range dates {
+		m := numericDate.FindStringSubmatch(collapseSpaces(date))
+		if m == nil {
+			continue
+		}
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		switch {
+		case first > 12:
+			return true
+		case second > 12:
+			return false
+		}
+	}
+	return fallback
+}
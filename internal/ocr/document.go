@@ -0,0 +1,158 @@
+// internal/ocr/document.go
+package ocr
+
+import (
+	"image"
+	"strings"
+)
+
+// Document is the layout of recognized text: pages, their blocks of text, the lines of each block and the words of
+// each line, in reading order. Confidences are 0.0-1.0; boxes are in pixels of the page image the text was read from.
+type Document struct {
+	Pages []Page `json:"pages"`
+}
+
+// Page is a recognized page.
+type Page struct {
+	Number     int     `json:"number"` // 1-based; 1 for single images
+	Width      int     `json:"width"`  // Pixels; 0 if unknown
+	Height     int     `json:"height"` // Pixels; 0 if unknown
+	Confidence float64 `json:"confidence"`
+	Blocks     []Block `json:"blocks"`
+}
+
+// Block is a block of text: a paragraph for Tesseract, a text block for Cloud Vision.
+type Block struct {
+	Box        image.Rectangle `json:"box"`
+	Confidence float64         `json:"confidence"`
+	Lines      []Line          `json:"lines"`
+}
+
+// Line is a line of text, with the mean confidence of its words.
+type Line struct {
+	Text       string          `json:"text"` // Words joined with spaces
+	Box        image.Rectangle `json:"box"`
+	Confidence float64         `json:"confidence"`
+	Words      []Word          `json:"words"`
+}
+
+// Word is a word recognized on a page, with its confidence (0.0-1.0) and its bounding box in pixels of the page
+// image it was read from.
+type Word struct {
+	Text       string          `json:"text"`
+	Confidence float64         `json:"confidence"`
+	Page       int             `json:"page"` // 1-based; 1 for single images
+	Box        image.Rectangle `json:"box"`
+}
+
+// newLine builds a line from its words.
+func newLine(words []Word) Line {
+	line := Line{Words: words}
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.Text
+		line.Box = line.Box.Union(word.Box)
+		line.Confidence += word.Confidence
+	}
+	line.Text = strings.Join(texts, " ")
+	if len(words) > 0 {
+		line.Confidence /= float64(len(words))
+	}
+	return line
+}
+
+// newBlock builds a block from its lines; its confidence is the mean confidence of their words.
+func newBlock(lines []Line) Block {
+	block := Block{Lines: lines}
+	words := 0
+	for _, line := range lines {
+		block.Box = block.Box.Union(line.Box)
+		block.Confidence += line.Confidence * float64(len(line.Words))
+		words += len(line.Words)
+	}
+	if words > 0 {
+		block.Confidence /= float64(words)
+	}
+	return block
+}
+
+// setConfidence sets the page's confidence to the mean confidence of its words.
+func (p *Page) setConfidence() {
+	total, words := 0.0, 0
+	for _, block := range p.Blocks {
+		for _, line := range block.Lines {
+			total += line.Confidence * float64(len(line.Words))
+			words += len(line.Words)
+		}
+	}
+	p.Confidence = 0
+	if words > 0 {
+		p.Confidence = total / float64(words)
+	}
+}
+
+// Text lays the page out as text: words on a line are separated by spaces, lines by newlines and blocks by blank
+// lines.
+func (p *Page) Text() string {
+	blocks := make([]string, 0, len(p.Blocks))
+	for _, block := range p.Blocks {
+		lines := make([]string, 0, len(block.Lines))
+		for _, line := range block.Lines {
+			lines = append(lines, line.Text)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Text lays the document out as text, with blank lines between pages (see Page.Text).
+func (d *Document) Text() string {
+	pages := make([]string, 0, len(d.Pages))
+	for i := range d.Pages {
+		if text := d.Pages[i].Text(); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	return strings.Join(pages, "\n\n")
+}
+
+// Confidence returns the mean confidence of the document's words, or 0.0 if no word was recognized.
+func (d *Document) Confidence() float64 {
+	total, words := 0.0, 0
+	for _, word := range d.Words() {
+		total += word.Confidence
+		words++
+	}
+	if words == 0 {
+		return 0.0
+	}
+	return total / float64(words)
+}
+
+// Words returns the document's words in reading order.
+func (d *Document) Words() []Word {
+	var words []Word
+	for _, page := range d.Pages {
+		for _, block := range page.Blocks {
+			for _, line := range block.Lines {
+				words = append(words, line.Words...)
+			}
+		}
+	}
+	return words
+}
+
+// LowConfidenceLines returns the lines whose confidence is below threshold, in reading order.
+func (d *Document) LowConfidenceLines(threshold float64) []Line {
+	var lines []Line
+	for _, page := range d.Pages {
+		for _, block := range page.Blocks {
+			for _, line := range block.Lines {
+				if line.Confidence < threshold {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	return lines
+}
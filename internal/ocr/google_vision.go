@@ -3,8 +3,9 @@ package ocr
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"image"
+	"strings"
 	"time"
 
 	vision "cloud.google.com/go/vision/apiv1"
@@ -51,6 +52,40 @@ func (s *GoogleVisionService) ExtractText(ctx context.Context, imageData []byte,
 	const operation = "GoogleVisionService.ExtractText"
 	requestID := utils.GetRequestID(ctx)
 
+	annotation, err := s.annotateImage(ctx, operation, imageData, imageType)
+	if err != nil {
+		return "", 0.0, err
+	}
+	extractedText := annotation.GetText()
+	confidence := symbolConfidence(annotation)
+
+	s.logger.Debug("Google Vision API Response Parsed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("response_pages", len(annotation.GetPages())), zap.Float64("confidence_score", confidence), zap.Int("text_length", len(extractedText)))
+
+	s.logger.Info("Successfully extracted text with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Float64("confidence_score", confidence), zap.Int("text_length", len(extractedText)))
+
+	return extractedText, confidence, nil
+}
+
+// ExtractDocument implements the OCRService interface. Lines are split at the line breaks Cloud Vision detects.
+func (s *GoogleVisionService) ExtractDocument(ctx context.Context, imageData []byte, imageType string) (*Document, error) {
+	const operation = "GoogleVisionService.ExtractDocument"
+	requestID := utils.GetRequestID(ctx)
+
+	annotation, err := s.annotateImage(ctx, operation, imageData, imageType)
+	if err != nil {
+		return nil, err
+	}
+	document := documentFromAnnotation(annotation, 1)
+
+	s.logger.Info("Successfully extracted document layout with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Float64("confidence_score", document.Confidence()), zap.Int("pages", len(document.Pages)))
+	return document, nil
+}
+
+// annotateImage runs document text detection on an image and returns the full text annotation (nil if no text was
+// found). Responses and failures are logged under the caller's operation, without the image or its text.
+func (s *GoogleVisionService) annotateImage(ctx context.Context, operation string, imageData []byte, imageType string) (*visionpb.TextAnnotation, error) {
+	requestID := utils.GetRequestID(ctx)
+
 	s.logger.Info("Starting OCR extraction with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType))
 
 	client := s.visionClient
@@ -69,61 +104,171 @@ func (s *GoogleVisionService) ExtractText(ctx context.Context, imageData []byte,
 		Requests: []*visionpb.AnnotateImageRequest{request},
 	}
 
-	payload, err := json.Marshal(batchRequest)
-	if err != nil {
-		s.logger.Error("Failed to marshal request payload for logging", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
-	}
-
 	apiStartTime := time.Now()
 	resp, err := client.BatchAnnotateImages(ctx, batchRequest)
 	apiLatency := time.Since(apiStartTime)
-	responseBytes, respErr := json.Marshal(resp)
-	if respErr != nil {
-		s.logger.Warn("Failed to marshal response for logging", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(respErr))
-		responseBytes = []byte("response marshaling failed")
+	if err != nil {
+		apiErr := fmt.Errorf("Google Vision API call failed: %w", err)
+		s.logger.Error("Google Vision API error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("image_bytes", len(imageData)), zap.Duration("api_latency", apiLatency), zap.Error(apiErr))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API call failed", apiErr))
 	}
+	if len(resp.GetResponses()) == 0 {
+		apiErr := fmt.Errorf("Google Vision API returned no response")
+		s.logger.Warn("Google Vision API returned no response", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("image_bytes", len(imageData)), zap.Duration("api_latency", apiLatency))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API returned no response", apiErr))
+	}
+	response := resp.GetResponses()[0]
 
-	s.logger.Debug("Google Vision API Request", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.ByteString("request_payload", payload))
-	s.logger.Debug("Google Vision API Response", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("status_code", int(resp.Responses[0].Error.GetCode())), zap.ByteString("response_payload", responseBytes), zap.Duration("api_latency", apiLatency))
+	// The image and the text read from it are PHI, so only their sizes are logged.
+	s.logger.Debug("Google Vision API Response", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Int("image_bytes", len(imageData)), zap.Int("status_code", int(response.GetError().GetCode())), zap.Int("text_length", len(response.GetFullTextAnnotation().GetText())), zap.Duration("api_latency", apiLatency))
 
+	if apiError := response.GetError(); apiError != nil {
+		apiErr := fmt.Errorf("Google Vision API returned error: %s (code: %d)", apiError.GetMessage(), apiError.GetCode())
+		s.logger.Warn("Google Vision API returned error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("status_code", int(apiError.GetCode())), zap.String("error_message", apiError.GetMessage()), zap.Error(apiErr))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API returned an error", apiErr))
+	}
+
+	return response.GetFullTextAnnotation(), nil
+}
+
+// ExtractPDFPage implements the PageExtractor interface, sending the PDF to the files endpoint with only the requested
+// page selected.
+func (s *GoogleVisionService) ExtractPDFPage(ctx context.Context, pdfData []byte, page int) (*Page, error) {
+	const operation = "GoogleVisionService.ExtractPDFPage"
+	requestID := utils.GetRequestID(ctx)
+
+	s.logger.Info("Starting PDF page OCR extraction with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page))
+
+	request := &visionpb.BatchAnnotateFilesRequest{
+		Requests: []*visionpb.AnnotateFileRequest{{
+			InputConfig: &visionpb.InputConfig{Content: pdfData, MimeType: "application/pdf"},
+			Features:    []*visionpb.Feature{{Type: visionpb.Feature_DOCUMENT_TEXT_DETECTION}},
+			Pages:       []int32{int32(page)},
+		}},
+	}
+
+	apiStartTime := time.Now()
+	resp, err := s.visionClient.BatchAnnotateFiles(ctx, request)
+	apiLatency := time.Since(apiStartTime)
 	if err != nil {
 		apiErr := fmt.Errorf("Google Vision API call failed: %w", err)
-		s.logger.Error("Google Vision API error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(apiErr))
-		return "", 0.0, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API call failed", apiErr))
+		s.logger.Error("Google Vision API error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Duration("api_latency", apiLatency), zap.Error(apiErr))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API call failed", apiErr))
+	}
+	if len(resp.GetResponses()) == 0 || len(resp.GetResponses()[0].GetResponses()) == 0 {
+		apiErr := fmt.Errorf("Google Vision API returned no response for page %d", page)
+		s.logger.Warn("Google Vision API returned no response", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API returned no response", apiErr))
+	}
+	fileResponse := resp.GetResponses()[0]
+	pageResponse := fileResponse.GetResponses()[0]
+	apiError := fileResponse.GetError()
+	if apiError == nil {
+		apiError = pageResponse.GetError()
 	}
-	if apiError := resp.Responses[0].Error; apiError != nil {
+	if apiError != nil {
 		apiErr := fmt.Errorf("Google Vision API returned error: %s (code: %d)", apiError.GetMessage(), apiError.GetCode())
-		s.logger.Warn("Google Vision API returned error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("status_code", int(apiError.GetCode())), zap.String("error_message", apiError.GetMessage()), zap.Error(apiErr))
-		return "", 0.0, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API returned an error", apiErr))
-	}
-
-	var extractedText string
-	var confidence float64 = 0.0
-
-	if resp != nil && len(resp.Responses) > 0 && resp.Responses[0] != nil && resp.Responses[0].FullTextAnnotation != nil {
-		extractedText = resp.Responses[0].FullTextAnnotation.GetText()
-		totalConfidence := 0.0
-		wordCount := 0
-		for _, page := range resp.Responses[0].FullTextAnnotation.Pages {
-			for _, block := range page.Blocks {
-				for _, paragraph := range block.Paragraphs {
-					for _, word := range paragraph.Words {
-						for _, symbol := range word.Symbols {
-							totalConfidence += float64(symbol.GetConfidence())
-							wordCount++
-						}
+		s.logger.Warn("Google Vision API returned error", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Int("status_code", int(apiError.GetCode())), zap.String("error_message", apiError.GetMessage()), zap.Error(apiErr))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Google Vision API returned an error", apiErr))
+	}
+
+	document := documentFromAnnotation(pageResponse.GetFullTextAnnotation(), page)
+	recognized := Page{Number: page}
+	if len(document.Pages) > 0 {
+		recognized = document.Pages[0]
+	}
+
+	s.logger.Info("Successfully extracted PDF page text with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Float64("confidence_score", recognized.Confidence), zap.Int("blocks", len(recognized.Blocks)), zap.Duration("api_latency", apiLatency))
+	return &recognized, nil
+}
+
+// symbolConfidence returns the mean confidence of the symbols of an annotation, or 0.0 if it has none.
+func symbolConfidence(annotation *visionpb.TextAnnotation) float64 {
+	totalConfidence := 0.0
+	symbolCount := 0
+	for _, page := range annotation.GetPages() {
+		for _, block := range page.GetBlocks() {
+			for _, paragraph := range block.GetParagraphs() {
+				for _, word := range paragraph.GetWords() {
+					for _, symbol := range word.GetSymbols() {
+						totalConfidence += float64(symbol.GetConfidence())
+						symbolCount++
 					}
 				}
 			}
 		}
-		if wordCount > 0 {
-			confidence = totalConfidence / float64(wordCount)
-		}
 	}
+	if symbolCount == 0 {
+		return 0.0
+	}
+	return totalConfidence / float64(symbolCount)
+}
 
-	s.logger.Debug("Google Vision API Response Parsed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("response_pages", len(resp.Responses[0].FullTextAnnotation.Pages)), zap.Float64("confidence_score", confidence), zap.Int("text_length", len(extractedText)))
-
-	s.logger.Info("Successfully extracted text with Google Vision API", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Float64("confidence_score", confidence), zap.Int("text_length", len(extractedText)))
+// documentFromAnnotation converts a Cloud Vision text annotation to a Document, numbering its pages from firstPage.
+// Words are joined from their symbols and lines end at the symbols followed by a line break. Boxes given as normalized
+// vertices (PDF and TIFF files) are scaled to the page size.
+func documentFromAnnotation(annotation *visionpb.TextAnnotation, firstPage int) *Document {
+	document := &Document{}
+	for i, annotatedPage := range annotation.GetPages() {
+		page := Page{Number: firstPage + i, Width: int(annotatedPage.GetWidth()), Height: int(annotatedPage.GetHeight())}
+		for _, annotatedBlock := range annotatedPage.GetBlocks() {
+			var lines []Line
+			var words []Word
+			for _, paragraph := range annotatedBlock.GetParagraphs() {
+				for _, annotatedWord := range paragraph.GetWords() {
+					var text strings.Builder
+					lineBreak := false
+					for _, symbol := range annotatedWord.GetSymbols() {
+						text.WriteString(symbol.GetText())
+						switch symbol.GetProperty().GetDetectedBreak().GetType() {
+						case visionpb.TextAnnotation_DetectedBreak_EOL_SURE_SPACE, visionpb.TextAnnotation_DetectedBreak_LINE_BREAK, visionpb.TextAnnotation_DetectedBreak_HYPHEN:
+							lineBreak = true
+						}
+					}
+					words = append(words, Word{
+						Text:       text.String(),
+						Confidence: float64(annotatedWord.GetConfidence()),
+						Page:       page.Number,
+						Box:        boundingBox(annotatedWord.GetBoundingBox(), page.Width, page.Height),
+					})
+					if lineBreak {
+						lines = append(lines, newLine(words))
+						words = nil
+					}
+				}
+				if len(words) > 0 { // A paragraph ends its last line
+					lines = append(lines, newLine(words))
+					words = nil
+				}
+			}
+			if len(lines) > 0 {
+				page.Blocks = append(page.Blocks, newBlock(lines))
+			}
+		}
+		page.setConfidence()
+		document.Pages = append(document.Pages, page)
+	}
+	return document
+}
 
-	return extractedText, confidence, nil
+// boundingBox returns the rectangle enclosing a bounding polygon, in pixels.
+func boundingBox(poly *visionpb.BoundingPoly, width, height int) image.Rectangle {
+	var points []image.Point
+	for _, vertex := range poly.GetVertices() {
+		points = append(points, image.Pt(int(vertex.GetX()), int(vertex.GetY())))
+	}
+	if len(points) == 0 {
+		for _, vertex := range poly.GetNormalizedVertices() {
+			points = append(points, image.Pt(int(vertex.GetX()*float32(width)), int(vertex.GetY()*float32(height))))
+		}
+	}
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+	box := image.Rectangle{Min: points[0], Max: points[0]}
+	for _, point := range points[1:] {
+		box.Min.X, box.Min.Y = min(box.Min.X, point.X), min(box.Min.Y, point.Y)
+		box.Max.X, box.Max.Y = max(box.Max.X, point.X), max(box.Max.Y, point.Y)
+	}
+	return box
 }
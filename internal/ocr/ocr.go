@@ -1,7 +1,13 @@
 // internal/ocr/ocr.go
 package ocr
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"go.uber.org/zap"
+)
 
 // OCRService defines the interface for OCR functionality.
 //
@@ -78,4 +84,44 @@ type OCRService interface {
 	//       - Accuracy (Optional and Hard to Measure): While difficult to measure directly in code, consider logging confidence scores and tracking user feedback related to OCR accuracy.  Establish baselines and track trends over time.
 	//     - Use MONITORING TOOLS (e.g., Prometheus, Grafana, cloud provider's monitoring services) to VISUALIZE metrics and set up ALERTS for performance degradation or errors. Proactive monitoring and alerting are CRITICAL for maintaining service uptime and quality.
 	ExtractText(ctx context.Context, imageData []byte, imageType string) (string, float64, error) // Returns text, confidence, error
+
+	// ExtractDocument performs OCR like ExtractText but keeps the layout: the pages recognized (every page of a PDF),
+	// their blocks, lines and words, each with its confidence (0.0-1.0) and bounding box. It fails as ExtractText.
+	ExtractDocument(ctx context.Context, imageData []byte, imageType string) (*Document, error)
+}
+
+// OCR backends selectable with config.OCRBackend.
+const (
+	BackendGoogleVision = "google"    // Google Cloud Vision API (GoogleVisionService)
+	BackendTesseract    = "tesseract" // Local Tesseract CLI (TesseractService), no network access
+)
+
+// PageExtractor is implemented by OCR backends that can recognize a single page of a PDF, so that callers can OCR
+// only the pages a native text layer does not cover.
+type PageExtractor interface {
+	// ExtractPDFPage performs OCR on one page (1-based) of a PDF and returns its layout, as ExtractDocument.
+	ExtractPDFPage(ctx context.Context, pdfData []byte, page int) (*Page, error)
+}
+
+// NewOCRService returns the OCR backend selected by cfg.OCRBackend.
+func NewOCRService(ctx context.Context, cfg *config.Config, logger *zap.Logger) (OCRService, error) {
+	var service OCRService
+	switch cfg.OCRBackend {
+	case BackendGoogleVision:
+		vision, err := NewGoogleVisionService(ctx, cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		service = vision
+	case BackendTesseract:
+		tesseract, err := NewTesseractService(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		service = tesseract
+	default:
+		return nil, fmt.Errorf("unknown OCR_BACKEND %q: expected %q or %q", cfg.OCRBackend, BackendGoogleVision, BackendTesseract)
+	}
+	logger.Info("OCR backend selected", zap.String("operation", "ocr.NewOCRService"), zap.String("backend", cfg.OCRBackend))
+	return service, nil
 }
@@ -0,0 +1,302 @@
+// internal/ocr/tesseract.go
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Registers the JPEG decoder used to validate uploads
+	_ "image/png"  // Registers the PNG decoder used to validate uploads
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackvity/lung-server/internal/config"
+	"github.com/stackvity/lung-server/internal/domain"
+	"github.com/stackvity/lung-server/internal/utils"
+	"go.uber.org/zap"
+)
+
+// TesseractService implements the OCRService interface with a local Tesseract installation, so that documents never
+// leave the host. PNG and JPEG images are recognized by the tesseract CLI directly; PDFs are first rendered to one PNG
+// per page with pdftoppm (poppler-utils). Confidences are Tesseract's word confidences scaled to 0.0-1.0, and the
+// confidence returned by ExtractText is their mean (0.0 if no word was recognized).
+type TesseractService struct {
+	binary      string // tesseract executable
+	pdfRenderer string // pdftoppm executable
+	languages   string // Tesseract language codes joined with "+"
+	pdfDPI      int    // Resolution PDF pages are rendered at
+	maxPages    int    // Pages of a PDF rendered by recognize; later pages are ignored
+	logger      *zap.Logger
+}
+
+var (
+	_ OCRService    = (*TesseractService)(nil)
+	_ PageExtractor = (*TesseractService)(nil)
+)
+
+// NewTesseractService creates a new TesseractService instance. It fails if the tesseract executable cannot be found;
+// a missing PDF renderer is only logged, as images can still be recognized.
+func NewTesseractService(cfg *config.Config, logger *zap.Logger) (*TesseractService, error) {
+	const operation = "NewTesseractService"
+	tsLogger := logger.Named("TesseractService")
+
+	binary, err := exec.LookPath(cfg.TesseractPath)
+	if err != nil {
+		tsLogger.Error("Tesseract executable not found", zap.String("operation", operation), zap.String("path", cfg.TesseractPath), zap.Error(err))
+		return nil, fmt.Errorf("tesseract executable %q not found: %w", cfg.TesseractPath, err)
+	}
+	pdfRenderer, err := exec.LookPath(cfg.OCRPDFRendererPath)
+	if err != nil {
+		tsLogger.Warn("PDF renderer not found, PDFs cannot be recognized", zap.String("operation", operation), zap.String("path", cfg.OCRPDFRendererPath), zap.Error(err))
+		pdfRenderer = cfg.OCRPDFRendererPath
+	}
+
+	tsLogger.Info("Tesseract Service initialized successfully", zap.String("operation", operation), zap.String("tesseract", binary), zap.String("pdf_renderer", pdfRenderer), zap.String("languages", cfg.TesseractLanguages))
+	return &TesseractService{
+		binary:      binary,
+		pdfRenderer: pdfRenderer,
+		languages:   cfg.TesseractLanguages,
+		pdfDPI:      cfg.OCRPDFDPI,
+		maxPages:    cfg.OCRPDFMaxPages,
+		logger:      tsLogger,
+	}, nil
+}
+
+// ExtractText implements the OCRService interface. Words on a line are separated by spaces, lines by newlines and
+// paragraphs and pages by blank lines.
+func (s *TesseractService) ExtractText(ctx context.Context, imageData []byte, imageType string) (string, float64, error) {
+	const operation = "TesseractService.ExtractText"
+	requestID := utils.GetRequestID(ctx)
+
+	document, err := s.ExtractDocument(ctx, imageData, imageType)
+	if err != nil {
+		return "", 0.0, err
+	}
+	text, confidence := document.Text(), document.Confidence()
+
+	s.logger.Info("Successfully extracted text with Tesseract", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Float64("confidence_score", confidence), zap.Int("pages", len(document.Pages)), zap.Int("text_length", len(text)))
+	return text, confidence, nil
+}
+
+// ExtractDocument implements the OCRService interface. Each Tesseract paragraph is a block.
+func (s *TesseractService) ExtractDocument(ctx context.Context, imageData []byte, imageType string) (*Document, error) {
+	pages, err := s.recognize(ctx, imageData, imageType)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Pages: pages}, nil
+}
+
+// ExtractPDFPage implements the PageExtractor interface: only the requested page is rendered and recognized.
+func (s *TesseractService) ExtractPDFPage(ctx context.Context, pdfData []byte, page int) (*Page, error) {
+	const operation = "TesseractService.ExtractPDFPage"
+	requestID := utils.GetRequestID(ctx)
+
+	if page < 1 {
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("invalid page number", fmt.Errorf("page %d", page)))
+	}
+	dir, err := os.MkdirTemp("", "ocr-pdf-")
+	if err != nil {
+		return nil, fmt.Errorf("%s: creating temporary directory: %w", operation, err)
+	}
+	defer os.RemoveAll(dir)
+	paths, err := s.renderPDF(ctx, dir, pdfData, page, page)
+	if err != nil || len(paths) != 1 {
+		if err == nil {
+			err = fmt.Errorf("page %d not rendered", page)
+		}
+		s.logger.Error("Failed to render PDF page", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Error(err))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("rendering PDF page failed", err))
+	}
+	recognized, err := s.runTesseract(ctx, paths[0], nil, s.pdfDPI, page)
+	if err != nil {
+		s.logger.Error("Tesseract failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Error(err))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed(fmt.Sprintf("Tesseract failed on page %d", page), err))
+	}
+
+	s.logger.Info("Successfully extracted PDF page text with Tesseract", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", page), zap.Float64("confidence_score", recognized.Confidence), zap.Int("blocks", len(recognized.Blocks)))
+	return &recognized, nil
+}
+
+// recognize runs Tesseract on an image, or on the first maxPages pages of a PDF, and returns the recognized pages.
+func (s *TesseractService) recognize(ctx context.Context, imageData []byte, imageType string) ([]Page, error) {
+	const operation = "TesseractService.recognize"
+	requestID := utils.GetRequestID(ctx)
+	start := time.Now()
+
+	var pages []Page
+	switch imageType {
+	case "image/png", "image/jpeg":
+		// 1. Images: validate the header, then pipe the image to tesseract
+		if _, format, err := image.DecodeConfig(bytes.NewReader(imageData)); err != nil {
+			s.logger.Warn("Invalid image data", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Error(err))
+			return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("invalid image data", err))
+		} else if "image/"+format != imageType {
+			s.logger.Warn("Image data does not match its type", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.String("format", format))
+		}
+		page, err := s.runTesseract(ctx, "stdin", bytes.NewReader(imageData), 0, 1)
+		if err != nil {
+			s.logger.Error("Tesseract failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Error(err))
+			return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("Tesseract failed", err))
+		}
+		pages = []Page{page}
+	case "application/pdf":
+		// 2. PDFs: render the pages to a PNG each in a private directory, removed with the PHI it holds. Only the first
+		// maxPages pages are rendered, so that a PDF of thousands of pages cannot fill the disk or occupy Tesseract.
+		dir, err := os.MkdirTemp("", "ocr-pdf-")
+		if err != nil {
+			return nil, fmt.Errorf("%s: creating temporary directory: %w", operation, err)
+		}
+		defer os.RemoveAll(dir)
+		paths, err := s.renderPDF(ctx, dir, imageData, 1, s.maxPages)
+		if err != nil {
+			s.logger.Error("Failed to render PDF pages", zap.String("operation", operation), zap.String("request_id", requestID), zap.Error(err))
+			return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("rendering PDF pages failed", err))
+		}
+		if s.maxPages > 0 && len(paths) == s.maxPages {
+			s.logger.Warn("PDF page limit reached, later pages are not recognized", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("max_pages", s.maxPages))
+		}
+		for i, path := range paths {
+			page, err := s.runTesseract(ctx, path, nil, s.pdfDPI, i+1)
+			if err != nil {
+				s.logger.Error("Tesseract failed", zap.String("operation", operation), zap.String("request_id", requestID), zap.Int("page", i+1), zap.Error(err))
+				return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed(fmt.Sprintf("Tesseract failed on page %d", i+1), err))
+			}
+			pages = append(pages, page)
+		}
+	default:
+		s.logger.Warn("Unsupported image type", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType))
+		return nil, fmt.Errorf("%s: %w", operation, domain.NewErrOCRExtractionFailed("unsupported image type", fmt.Errorf("tesseract cannot read %q", imageType)))
+	}
+
+	s.logger.Debug("Tesseract recognition finished", zap.String("operation", operation), zap.String("request_id", requestID), zap.String("image_type", imageType), zap.Int("pages", len(pages)), zap.Duration("latency", time.Since(start)))
+	return pages, nil
+}
+
+// renderPDF renders the pages first to last (1-based; 0 renders every page) of a PDF to a PNG each in dir with
+// pdftoppm and returns their paths in page order.
+func (s *TesseractService) renderPDF(ctx context.Context, dir string, pdf []byte, first, last int) ([]string, error) {
+	input := filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(input, pdf, 0o600); err != nil {
+		return nil, fmt.Errorf("writing PDF: %w", err)
+	}
+	args := []string{"-r", strconv.Itoa(s.pdfDPI), "-png"}
+	if first > 0 && last >= first {
+		args = append(args, "-f", strconv.Itoa(first), "-l", strconv.Itoa(last))
+	}
+	args = append(args, input, filepath.Join(dir, "page"))
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.pdfRenderer, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(s.pdfRenderer), err, strings.TrimSpace(stderr.String()))
+	}
+	pages, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(pages) // pdftoppm pads page numbers to the width of the page count
+	return pages, nil
+}
+
+// runTesseract recognizes one page, read from input (a path, or "stdin" to read it from stdin), and parses the layout
+// of its TSV output. dpi is passed to Tesseract when known (0 lets it estimate the resolution).
+func (s *TesseractService) runTesseract(ctx context.Context, input string, stdin io.Reader, dpi, page int) (Page, error) {
+	args := []string{input, "stdout", "-l", s.languages}
+	if dpi > 0 {
+		args = append(args, "--dpi", strconv.Itoa(dpi))
+	}
+	args = append(args, "tsv")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return Page{}, fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(stdout.String(), page)
+}
+
+// parseTesseractTSV builds a page from Tesseract's TSV output: its size from the page row (level 1), and its
+// paragraphs, lines and words from the word rows (level 5) that have a confidence, skipping blank ones.
+// Columns: level, page_num, block_num, par_num, line_num, word_num, left, top, width, height, conf, text.
+func parseTesseractTSV(tsv string, pageNumber int) (Page, error) {
+	page := Page{Number: pageNumber}
+	rows := strings.Split(strings.TrimRight(tsv, "\r\n"), "\n")
+	if len(rows) == 0 || !strings.HasPrefix(rows[0], "level\t") {
+		return page, fmt.Errorf("unexpected tesseract output: missing TSV header")
+	}
+
+	type lineKey struct{ block, paragraph, line int }
+	var words []Word
+	var current, previous lineKey
+	var lines []Line
+	flushLine := func() {
+		if len(words) > 0 {
+			lines = append(lines, newLine(words))
+			words = nil
+		}
+	}
+	flushBlock := func() {
+		flushLine()
+		if len(lines) > 0 {
+			page.Blocks = append(page.Blocks, newBlock(lines))
+			lines = nil
+		}
+	}
+	for _, row := range rows[1:] {
+		fields := strings.SplitN(strings.TrimRight(row, "\r"), "\t", 12)
+		if len(fields) < 11 || (fields[0] != "1" && fields[0] != "5") {
+			continue
+		}
+
+		var numbers [8]int // block, paragraph, line, word, left, top, width, height
+		for i := range numbers {
+			var err error
+			if numbers[i], err = strconv.Atoi(fields[i+2]); err != nil {
+				return page, fmt.Errorf("unexpected tesseract output %q: %w", row, err)
+			}
+		}
+		left, top, width, height := numbers[4], numbers[5], numbers[6], numbers[7]
+		if fields[0] == "1" {
+			page.Width, page.Height = width, height
+			continue
+		}
+		if len(fields) < 12 {
+			continue
+		}
+		text := strings.TrimSpace(fields[11])
+		confidence, err := strconv.ParseFloat(fields[10], 64)
+		if text == "" || err != nil || confidence < 0 {
+			continue
+		}
+
+		current = lineKey{block: numbers[0], paragraph: numbers[1], line: numbers[2]}
+		switch {
+		case len(words) == 0 && len(lines) == 0:
+		case current.block != previous.block || current.paragraph != previous.paragraph:
+			flushBlock()
+		case current.line != previous.line:
+			flushLine()
+		}
+		previous = current
+		words = append(words, Word{
+			Text:       text,
+			Confidence: confidence / 100,
+			Page:       pageNumber,
+			Box:        image.Rect(left, top, left+width, top+height),
+		})
+	}
+	flushBlock()
+	page.setConfidence()
+	return page, nil
+}
@@ -2,50 +2,303 @@
 package security
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
 	"github.com/stackvity/lung-server/pkg/dicom"
+	"go.uber.org/zap"
+)
+
+// DeidentificationAction is an action code of PS3.15 Table E.1-1.
+type DeidentificationAction string
+
+const (
+	ActionDummy  DeidentificationAction = "D" // Replace with a non-zero length dummy value consistent with the VR
+	ActionZero   DeidentificationAction = "Z" // Replace with a zero length value
+	ActionRemove DeidentificationAction = "X" // Remove the attribute
+	ActionKeep   DeidentificationAction = "K" // Keep (sequences are still processed recursively)
+	ActionClean  DeidentificationAction = "C" // Clean: replace identifying text with values of similar meaning
+	ActionUID    DeidentificationAction = "U" // Replace UIDs with consistent non-identifying UIDs
+	ActionShift  DeidentificationAction = "S" // Not a Table E.1-1 code: date moved by DateShiftDays (Retain Longitudinal Temporal Information with Modified Dates)
 )
 
-// AnonymizeDICOMData is a PLACEHOLDER for DICOM data anonymization.
-// In a real implementation, this would remove or replace all
-// patient-identifiable information from the DICOM dataset,
-// according to HIPAA and other relevant regulations.
-// THIS IS A CRITICAL SECURITY FUNCTION AND MUST BE IMPLEMENTED PROPERLY.
-func AnonymizeDICOMData(data *dicom.DataSet) (*dicom.DataSet, error) {
-	logger.Warn("AnonymizeDICOMData is a placeholder - DICOM anonymization not yet implemented")
-	// TODO: Implement DICOM anonymization.
-	//  This is a *critical* security requirement and must be done correctly.
-	//  This placeholder simply returns the original data, which is NOT secure.
-
-	//  For a *very* basic example (NOT production-ready!), you could *remove*
-	//  some obviously identifiable tags.  But you MUST consult with a DICOM
-	//  and security expert to do this correctly.  This is NOT comprehensive.
-	//  See: https://dicom.nema.org/medical/dicom/current/output/chtml/part15/chapter_E.html
-
-	// Example (INSUFFICIENT - DO NOT USE AS IS):
-	// data.Elements = removeTag(data.Elements, dicom.TagPatientName) //REMOVE
-	// data.Elements = removeTag(data.Elements, dicom.TagPatientID)   //REMOVE
-
-	return data, nil // Return the original (unmodified) data.  THIS IS NOT SECURE.
-}
-
-// AnonymizeText is a PLACEHOLDER for text anonymization.  In a real
-// implementation, this would identify and remove/replace/mask any
-// personally identifiable information (PII) from the text.
-// THIS IS A CRITICAL SECURITY FUNCTION AND MUST BE IMPLEMENTED PROPERLY.
-func AnonymizeText(text string) string {
-	logger.Warn("AnonymizeText is a placeholder - text anonymization not yet implemented")
-	// TODO: Implement text anonymization. This is a *critical* security requirement.
-	// This is a very, very basic placeholder.  It is NOT sufficient for real use.
-
-	// A real implementation would need to handle:
-	// - Names
-	// - Dates (especially dates of birth, admission, discharge)
-	// - Locations (hospital names, addresses)
-	// - Phone numbers
-	// - Email addresses
-	// - Medical record numbers
-	// - Other identifiers
-
-	// For now, just return the input text.
-	return text // THIS IS NOT SECURE.
+// basicProfileName identifies the profile in (0012,0063) De-identification Method and in AnonymizationRecord.
+const basicProfileName = "PS3.15 Basic Application Level Confidentiality Profile"
+
+// UIDMapper replaces a UID with its de-identified counterpart (action U). It must be deterministic within a
+// patient session, so that instances uploaded separately still share study, series and frame of reference UIDs.
+type UIDMapper interface {
+	MapUID(uid string) string
+}
+
+// DICOMAnonymizationOptions selects the PS3.15 profile options applied on top of the Basic Profile.
+type DICOMAnonymizationOptions struct {
+	RetainLongitudinalDates bool                // Retain Longitudinal Temporal Information with Full Dates Option (E.3.6): dates and times are kept
+	DateShiftDays           int                 // Non-zero selects the Modified Dates variant of E.3.6: dates move by this many days, times are kept; ignored with RetainLongitudinalDates
+	CleanDescriptors        bool                // Clean Descriptors Option (E.3.5): descriptions are cleaned with TextCleaner instead of removed
+	UIDMapper               UIDMapper           // Mapper for action U; nil uses a mapper keyed with a random per-process secret
+	TextCleaner             func(string) string // Cleaner for action C; nil uses AnonymizeText
+}
+
+// AppliedAction records one de-identification action for auditing. It never contains attribute values.
+type AppliedAction struct {
+	Path    string                 `json:"path"`    // Location in the dataset, e.g. "ReferencedImageSequence[0].ReferencedSOPInstanceUID"
+	Tag     string                 `json:"tag"`     // "(gggg,eeee)"
+	Keyword string                 `json:"keyword"` // Dictionary keyword, or a rule name such as "PrivateTag"
+	Action  DeidentificationAction `json:"action"`
+}
+
+// AnonymizationRecord describes how a dataset was de-identified, so that the choice can be audited.
+type AnonymizationRecord struct {
+	Profile string          `json:"profile"`
+	Options []string        `json:"options,omitempty"`
+	Actions []AppliedAction `json:"actions"`
+}
+
+// Counts returns the number of attributes each action was applied to.
+func (r *AnonymizationRecord) Counts() map[DeidentificationAction]int {
+	counts := make(map[DeidentificationAction]int)
+	for _, a := range r.Actions {
+		counts[a.Action]++
+	}
+	return counts
+}
+
+// AnonymizeDICOMData de-identifies a DICOM dataset according to the PS3.15 Basic Application Level
+// Confidentiality Profile (Annex E), with the options selected in opts. The input is not modified; the
+// de-identified copy is returned together with a record of every action applied.
+//
+// With opts.DateShiftDays set, dates (DA) and date times (DT) are moved by the same number of days instead of being
+// removed, so intervals between studies of the same patient are preserved; values that cannot be parsed are emptied.
+// Times (TM) are kept as they are: a shift by whole days never changes the time of day, and the date part of a DT
+// is shifted on its own, so there is no rollover of the time into the next or previous day to carry over.
+//
+// Every attribute is processed, including those nested in sequences at any depth:
+//   - attributes listed in Table E.1-1 get their D, Z, X, K, C or U action (see basicProfile);
+//   - private attributes, curve data, overlay data/comments and group lengths are removed;
+//   - Person Name (PN) attributes not listed in the table are removed as well, as names are always identifying;
+//   - all other attributes are kept.
+//
+// Patient Identity Removed (0012,0062), De-identification Method (0012,0063) and Longitudinal Temporal
+// Information Modified (0028,0303) are set as Annex E requires. Burned-in annotations in the pixel data are
+// NOT handled here.
+func AnonymizeDICOMData(data *dicom.DataSet, opts DICOMAnonymizationOptions) (*dicom.DataSet, *AnonymizationRecord, error) {
+	const operation = "security.AnonymizeDICOMData"
+
+	if data == nil {
+		return nil, nil, fmt.Errorf("anonymizing DICOM data: dataset is nil")
+	}
+	if opts.UIDMapper == nil {
+		opts.UIDMapper = defaultUIDMapper
+	}
+	if opts.TextCleaner == nil {
+		opts.TextCleaner = AnonymizeText
+	}
+
+	a := &dicomAnonymizer{opts: opts, record: &AnonymizationRecord{Profile: basicProfileName}}
+	methods := []string{"Basic Profile"}
+	if opts.RetainLongitudinalDates {
+		a.record.Options = append(a.record.Options, "Retain Longitudinal Temporal Information with Full Dates")
+		methods = append(methods, "Retain Longitudinal Full Dates")
+	}
+	if a.shiftDates() {
+		a.record.Options = append(a.record.Options, "Retain Longitudinal Temporal Information with Modified Dates")
+		methods = append(methods, "Retain Longitudinal Modified Dates")
+	}
+	if opts.CleanDescriptors {
+		a.record.Options = append(a.record.Options, "Clean Descriptors")
+		methods = append(methods, "Clean Descriptors")
+	}
+
+	out := data.Clone()
+	out.Meta = a.process(out.Meta, "")
+	out.Elements = a.process(out.Elements, "")
+
+	out.Put(dicom.NewStringElement(dicom.TagPatientIdentityRemoved, dicom.VRCS, "YES"))
+	out.Put(dicom.NewStringElement(dicom.TagDeidentificationMethod, dicom.VRLO, methods...))
+	longitudinal := "REMOVED"
+	switch {
+	case opts.RetainLongitudinalDates:
+		longitudinal = "UNMODIFIED"
+	case a.shiftDates():
+		longitudinal = "MODIFIED"
+	}
+	out.Put(dicom.NewStringElement(dicom.TagLongitudinalTemporalInfoModified, dicom.VRCS, longitudinal))
+	out.RefreshIdentifiers()
+
+	logger.Debug("DICOM dataset de-identified", zap.String("operation", operation), zap.Int("actions", len(a.record.Actions)), zap.Strings("options", a.record.Options))
+	return out, a.record, nil
+}
+
+// dicomAnonymizer walks a dataset applying the profile and recording each action.
+type dicomAnonymizer struct {
+	opts   DICOMAnonymizationOptions
+	record *AnonymizationRecord
+}
+
+// shiftDates reports whether dates are shifted rather than kept or removed.
+func (a *dicomAnonymizer) shiftDates() bool {
+	return a.opts.DateShiftDays != 0 && !a.opts.RetainLongitudinalDates
+}
+
+// process applies the profile to a list of elements and returns the elements that remain.
+func (a *dicomAnonymizer) process(elements []*dicom.Element, parent string) []*dicom.Element {
+	out := make([]*dicom.Element, 0, len(elements))
+	for _, e := range elements {
+		action, keyword, listed := a.actionFor(e)
+		path := keyword
+		if parent != "" {
+			path = parent + "." + keyword
+		}
+		if action == ActionShift && !shiftDICOMElement(e, a.opts.DateShiftDays) {
+			action = ActionZero // Unparseable dates cannot be shifted and must not leak unshifted
+		}
+		if listed {
+			a.record.Actions = append(a.record.Actions, AppliedAction{Path: path, Tag: e.Tag.String(), Keyword: keyword, Action: action})
+		}
+
+		switch action {
+		case ActionRemove:
+			continue
+		case ActionZero:
+			e = &dicom.Element{Tag: e.Tag, VR: e.VR}
+		case ActionDummy:
+			e = dummyElement(e)
+		case ActionUID:
+			if e.VR == dicom.VRSQ {
+				a.processItems(e, path) // X/Z/U* sequences: keep the references, replace the UIDs inside
+			} else {
+				if e.VR == dicom.VRUN {
+					e.VR = dicom.VRUI // Unknown to the dictionary in Implicit VR data; the profile only lists UI attributes for U
+				}
+				uids := e.Strings()
+				for i, uid := range uids {
+					uids[i] = a.opts.UIDMapper.MapUID(uid)
+				}
+				e.SetStrings(uids...)
+			}
+		case ActionClean:
+			if e.VR == dicom.VRSQ {
+				a.processItems(e, path)
+			} else if e.VR.IsString() {
+				e.SetStrings(a.opts.TextCleaner(e.StringValue()))
+			} else {
+				e = &dicom.Element{Tag: e.Tag, VR: e.VR}
+			}
+		default: // ActionKeep, and attributes not listed in the profile
+			if e.VR == dicom.VRSQ {
+				a.processItems(e, path)
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// processItems applies the profile recursively to every item of a sequence.
+func (a *dicomAnonymizer) processItems(e *dicom.Element, path string) {
+	for i, item := range e.Items {
+		item.Elements = a.process(item.Elements, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+// actionFor returns the action for an element, the keyword used in the record, and whether the action should
+// be recorded (attributes that are simply kept because the profile does not mention them are not recorded).
+func (a *dicomAnonymizer) actionFor(e *dicom.Element) (DeidentificationAction, string, bool) {
+	tag := e.Tag
+	switch {
+	case tag.IsPrivate():
+		return ActionRemove, "PrivateTag", true
+	case isCurveOrOverlayData(tag):
+		return ActionRemove, tag.Name(), true
+	case tag.IsGroupLength() && tag.Group() != 0x0002:
+		return ActionRemove, "GroupLength", true // Stale once attributes are removed; group lengths are optional
+	}
+
+	entry, ok := basicProfile[tag]
+	if !ok {
+		switch {
+		case e.VR == dicom.VRPN:
+			return ActionRemove, tag.Name(), true
+		case e.VR == dicom.VRUI && isInstanceUID(e):
+			return ActionUID, tag.Name(), true // Unlisted references (e.g. in newer IODs) must stay consistent with the remapped UIDs they point to
+		case (e.VR == dicom.VRDA || e.VR == dicom.VRDT) && a.shiftDates():
+			return ActionShift, tag.Name(), true // Unlisted dates are kept by the profile; shift them so they stay consistent with the listed ones
+		}
+		return ActionKeep, tag.Name(), false
+	}
+
+	switch {
+	case entry.temporal && a.opts.RetainLongitudinalDates:
+		return ActionKeep, entry.keyword, true
+	case entry.temporal && a.shiftDates():
+		if e.VR == dicom.VRDA || e.VR == dicom.VRDT {
+			return ActionShift, entry.keyword, true
+		}
+		return ActionKeep, entry.keyword, true // Times and UTC offsets: shifting by whole days leaves them unchanged
+	case entry.descriptor && a.opts.CleanDescriptors:
+		return ActionClean, entry.keyword, true
+	}
+	return resolveActionCode(entry.code), entry.keyword, true
+}
+
+// dummyElement returns a replacement for e holding a non-identifying value valid for its VR.
+func dummyElement(e *dicom.Element) *dicom.Element {
+	switch e.VR {
+	case dicom.VRSQ, dicom.VRUN, dicom.VRUI, dicom.VRUR:
+		return &dicom.Element{Tag: e.Tag, VR: e.VR} // No meaningful dummy; an empty value keeps the attribute present
+	case dicom.VRDA:
+		return dicom.NewStringElement(e.Tag, e.VR, "19000101")
+	case dicom.VRTM:
+		return dicom.NewStringElement(e.Tag, e.VR, "000000.000000")
+	case dicom.VRDT:
+		return dicom.NewStringElement(e.Tag, e.VR, "19000101000000.000000")
+	case dicom.VRAS:
+		return dicom.NewStringElement(e.Tag, e.VR, "000D")
+	case dicom.VRDS, dicom.VRIS:
+		return dicom.NewStringElement(e.Tag, e.VR, "0")
+	}
+	if e.VR.IsString() {
+		return dicom.NewStringElement(e.Tag, e.VR, "ANONYMIZED")
+	}
+	return &dicom.Element{Tag: e.Tag, VR: e.VR, Value: make([]byte, len(e.Value))} // Binary VRs: zeros of the same length
+}
+
+// hashUIDMapper derives "2.25." UIDs (PS3.5 B.2) from an HMAC-SHA256 of the original UID, so the same input
+// always yields the same output for a given key but cannot be reversed or recomputed without the key.
+type hashUIDMapper struct {
+	key []byte
+}
+
+// MapUID implements UIDMapper.
+func (m *hashUIDMapper) MapUID(uid string) string {
+	if uid == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(uid))
+	sum := mac.Sum(nil)
+	return "2.25." + new(big.Int).SetBytes(sum[:16]).String() // 128 bits: at most 39 digits, well within the 64-character UI limit
+}
+
+// NewUIDMapper returns a UIDMapper that derives "2.25." UIDs from an HMAC-SHA256 of the original UID keyed with
+// secret. With a per-session secret, the same original UID maps to the same pseudonym for every upload of the
+// session, while nothing but the secret needs to be kept: the mapping is recomputed, never stored.
+func NewUIDMapper(secret []byte) UIDMapper {
+	return &hashUIDMapper{key: append([]byte(nil), secret...)}
+}
+
+// defaultUIDMapper is used when no UIDMapper is configured. Its key is random per process, so mapped UIDs are
+// consistent for the lifetime of the server but unlinkable across restarts.
+var defaultUIDMapper UIDMapper = newRandomUIDMapper()
+
+func newRandomUIDMapper() *hashUIDMapper {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("security: generating UID mapping key: %v", err))
+	}
+	return &hashUIDMapper{key: key}
 }
@@ -0,0 +1,129 @@
+// internal/security/anonymization_test.go
+package security
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// derivedUID is a "2.25." UID (PS3.5 B.2): a decimal integer without leading zeros.
+var derivedUID = regexp.MustCompile(`^2\.25\.(?:0|[1-9][0-9]*)$`)
+
+func TestUIDMapper(t *testing.T) {
+	uids := []string{
+		"1.2.826.0.1.3680043.2.1143.42",
+		"1.2.826.0.1.3680043.2.1143.43",
+		"1.3.6.1.4.1.14519.5.2.1.6279.6001.298806137288633453246975630178",
+		"2.25.0",
+		"1",
+	}
+	first, again, other := NewUIDMapper([]byte("secret A")), NewUIDMapper([]byte("secret A")), NewUIDMapper([]byte("secret B"))
+
+	seen := map[string]string{}
+	for _, uid := range uids {
+		mapped := first.MapUID(uid)
+		if len(mapped) > 64 || !derivedUID.MatchString(mapped) {
+			t.Errorf("MapUID(%q) = %q, want a 2.25 UID of at most 64 characters", uid, mapped)
+		}
+		if mapped == uid {
+			t.Errorf("MapUID(%q) returned the UID unchanged", uid)
+		}
+		if got := first.MapUID(uid); got != mapped {
+			t.Errorf("MapUID(%q) = %q, then %q", uid, mapped, got)
+		}
+		if got := again.MapUID(uid); got != mapped {
+			t.Errorf("MapUID(%q) = %q with one mapper and %q with another of the same secret", uid, mapped, got)
+		}
+		if got := other.MapUID(uid); got == mapped {
+			t.Errorf("MapUID(%q) = %q for two different secrets", uid, got)
+		}
+		if previous, ok := seen[mapped]; ok {
+			t.Errorf("MapUID(%q) and MapUID(%q) are both %q", previous, uid, mapped)
+		}
+		seen[mapped] = uid
+	}
+	if got := first.MapUID(""); got != "" {
+		t.Errorf("MapUID(\"\") = %q, want an empty UID", got)
+	}
+}
+
+func TestUIDMapperCopiesSecret(t *testing.T) {
+	secret := []byte("secret A")
+	mapper := NewUIDMapper(secret)
+	before := mapper.MapUID("1.2.3.4")
+	secret[0] = 'S'
+	if got := mapper.MapUID("1.2.3.4"); got != before {
+		t.Errorf("changing the secret after NewUIDMapper changed the mapping from %q to %q", before, got)
+	}
+}
+
+func TestAnonymizeDICOMDataRemapsReferencedUIDs(t *testing.T) {
+	const (
+		ctImage    = "1.2.840.10008.5.1.4.1.1.2"
+		study      = "1.2.826.0.1.3680043.2.1143.1"
+		series     = "1.2.826.0.1.3680043.2.1143.1.2"
+		instance   = "1.2.826.0.1.3680043.2.1143.1.2.3"
+		source     = "1.2.826.0.1.3680043.2.1143.1.2.4"
+		frameOfRef = "1.2.826.0.1.3680043.2.1143.1.9"
+		referenced = "1.2.826.0.1.3680043.2.1143.1.7" // Referenced in a sequence not listed in the profile
+	)
+	reference := func(classUID, instanceUID string) *dicom.Item {
+		item := &dicom.Item{}
+		item.Put(dicom.NewStringElement(dicom.TagReferencedSOPClassUID, dicom.VRUI, classUID))
+		item.Put(dicom.NewStringElement(dicom.TagReferencedSOPInstanceUID, dicom.VRUI, instanceUID))
+		return item
+	}
+	ds := &dicom.DataSet{}
+	ds.Put(dicom.NewStringElement(dicom.TagSOPClassUID, dicom.VRUI, ctImage))
+	ds.Put(dicom.NewStringElement(dicom.TagSOPInstanceUID, dicom.VRUI, instance))
+	ds.Put(dicom.NewStringElement(dicom.TagStudyInstanceUID, dicom.VRUI, study))
+	ds.Put(dicom.NewStringElement(dicom.TagSeriesInstanceUID, dicom.VRUI, series))
+	ds.Put(dicom.NewStringElement(dicom.TagFrameOfReferenceUID, dicom.VRUI, frameOfRef))
+	ds.Put(&dicom.Element{Tag: dicom.TagSourceImageSequence, VR: dicom.VRSQ, Items: []*dicom.Item{reference(ctImage, source)}})
+	// Referenced Series Sequence > Referenced Instance Sequence, neither listed in the profile
+	seriesItem := &dicom.Item{}
+	seriesItem.Put(dicom.NewStringElement(dicom.TagSeriesInstanceUID, dicom.VRUI, series))
+	seriesItem.Put(&dicom.Element{Tag: 0x0008114A, VR: dicom.VRSQ, Items: []*dicom.Item{reference(ctImage, referenced)}})
+	ds.Put(&dicom.Element{Tag: 0x00081115, VR: dicom.VRSQ, Items: []*dicom.Item{seriesItem}})
+
+	mapper := NewUIDMapper([]byte("session secret"))
+	out, _, err := AnonymizeDICOMData(ds, DICOMAnonymizationOptions{UIDMapper: mapper})
+	if err != nil {
+		t.Fatalf("AnonymizeDICOMData: %v", err)
+	}
+
+	if out.SOPInstanceUID != mapper.MapUID(instance) || out.StudyInstanceUID != mapper.MapUID(study) || out.SeriesInstanceUID != mapper.MapUID(series) {
+		t.Errorf("identifiers %q, %q, %q were not mapped", out.StudyInstanceUID, out.SeriesInstanceUID, out.SOPInstanceUID)
+	}
+	if got := out.GetString(dicom.TagFrameOfReferenceUID); got != mapper.MapUID(frameOfRef) {
+		t.Errorf("Frame of Reference UID = %q, want the mapped UID", got)
+	}
+	if got := out.GetString(dicom.TagSOPClassUID); got != ctImage {
+		t.Errorf("SOP Class UID = %q, want it kept", got)
+	}
+
+	sourceImages, ok := out.FindElement(dicom.TagSourceImageSequence)
+	if !ok || len(sourceImages.Items) != 1 {
+		t.Fatal("Source Image Sequence was not kept")
+	}
+	if uid, _ := sourceImages.Items[0].FindElement(dicom.TagReferencedSOPInstanceUID); uid.StringValue() != mapper.MapUID(source) {
+		t.Errorf("referenced source image %q, want the mapped UID", uid.StringValue())
+	}
+	if uid, _ := sourceImages.Items[0].FindElement(dicom.TagReferencedSOPClassUID); uid.StringValue() != ctImage {
+		t.Errorf("referenced SOP class %q, want it kept", uid.StringValue())
+	}
+
+	referencedSeries, ok := out.FindElement(0x00081115)
+	if !ok || len(referencedSeries.Items) != 1 {
+		t.Fatal("Referenced Series Sequence was not kept")
+	}
+	if uid, _ := referencedSeries.Items[0].FindElement(dicom.TagSeriesInstanceUID); uid.StringValue() != out.SeriesInstanceUID {
+		t.Errorf("referenced series %q, want the series' own mapped UID %q", uid.StringValue(), out.SeriesInstanceUID)
+	}
+	instances, _ := referencedSeries.Items[0].FindElement(0x0008114A)
+	if uid, _ := instances.Items[0].FindElement(dicom.TagReferencedSOPInstanceUID); uid.StringValue() != mapper.MapUID(referenced) {
+		t.Errorf("instance referenced two sequences deep %q, want the mapped UID", uid.StringValue())
+	}
+}
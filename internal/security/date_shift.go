@@ -0,0 +1,90 @@
+// internal/security/date_shift.go
+package security
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// Date shifting moves every date of a patient by the same number of days, so intervals between studies survive
+// de-identification while the real dates do not. The offset comes from the caller (one per patient session).
+
+// dateLayouts are the formats shiftDate can parse and re-emit. Numeric slash dates are read month first (US order)
+// unless the first field cannot be a month.
+var dateLayouts = []string{
+	"2006-01-02", "2006-1-2",
+	"01/02/2006", "1/2/2006", "01/02/06", "1/2/06", "01-02-2006", "1-2-2006",
+	"02/01/2006", "2/1/2006", "02/01/06", "2/1/06",
+	"January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006", "Jan. 2, 2006",
+	"2 January 2006", "2 Jan 2006", "2 January, 2006",
+	"January 2006", "Jan 2006", "January, 2006",
+}
+
+// ordinalSuffix matches "1st", "2nd", ... so they can be stripped before parsing.
+var ordinalSuffix = regexp.MustCompile(`(\d)(?:st|nd|rd|th)\b`)
+
+// shiftDate moves a date by days, re-emitting it in the layout it was written in.
+func shiftDate(s string, days int) (string, bool) {
+	cleaned := ordinalSuffix.ReplaceAllString(strings.Join(strings.Fields(s), " "), "$1")
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, cleaned)
+		if err != nil {
+			continue
+		}
+		return t.AddDate(0, 0, days).Format(layout), true
+	}
+	return "", false
+}
+
+// dicomDateLayouts are the precisions a DT value's date part may have (PS3.5 6.2), longest first.
+var dicomDateLayouts = []string{"20060102", "200601", "2006"}
+
+// shiftDICOMDate shifts a DA value, or the date part of a DT value, by days and keeps everything after the date
+// part (time, fraction, UTC offset) as it is: shifting by whole days never changes the time of day. Reduced
+// precision DT dates (YYYY, YYYYMM) are shifted from their first day and re-emitted at the same precision.
+func shiftDICOMDate(value string, vr dicom.VR, days int) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", true
+	}
+	layouts := dicomDateLayouts
+	if vr == dicom.VRDA {
+		layouts = layouts[:1] // DA is always YYYYMMDD
+	}
+	for _, layout := range layouts {
+		if len(value) < len(layout) {
+			continue
+		}
+		t, err := time.Parse(layout, value[:len(layout)])
+		if err != nil {
+			continue
+		}
+		rest := value[len(layout):]
+		if vr == dicom.VRDA && rest != "" {
+			return "", false
+		}
+		if layout != "20060102" && rest != "" && !strings.HasPrefix(rest, "+") && !strings.HasPrefix(rest, "-") {
+			continue // A longer date part that failed to parse above; do not misread it at lower precision
+		}
+		return t.AddDate(0, 0, days).Format(layout) + rest, true
+	}
+	return "", false
+}
+
+// shiftDICOMElement shifts every value of a DA or DT element in place. It reports false if a value could not be
+// parsed, in which case the element is left unchanged and the caller must remove or empty it.
+func shiftDICOMElement(e *dicom.Element, days int) bool {
+	values := e.Strings()
+	for i, v := range values {
+		shifted, ok := shiftDICOMDate(v, e.VR, days)
+		if !ok {
+			return false
+		}
+		values[i] = shifted
+	}
+	e.SetStrings(values...)
+	return true
+}
@@ -0,0 +1,118 @@
+// internal/security/date_shift_test.go
+package security
+
+import (
+	"testing"
+
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+func TestShiftDICOMDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		vr    dicom.VR
+		days  int
+		want  string
+		ok    bool
+	}{
+		{"within a month", "20240312", dicom.VRDA, -7, "20240305", true},
+		{"back across a month", "20240305", dicom.VRDA, -7, "20240227", true},
+		{"forward across a year", "20231228", dicom.VRDA, 7, "20240104", true},
+		{"onto a leap day", "20240301", dicom.VRDA, -1, "20240229", true},
+		{"over a missing leap day", "20230301", dicom.VRDA, -1, "20230228", true},
+		{"from a leap day", "20240229", dicom.VRDA, 365, "20250228", true},
+		{"across leap days of several years", "20200229", dicom.VRDA, -1461, "20160229", true},
+		{"empty", " ", dicom.VRDA, -7, "", true},
+		{"DA with a time", "20240312101530", dicom.VRDA, -7, "", false},
+		{"DA of reduced precision", "202403", dicom.VRDA, -7, "", false},
+		{"DA with separators", "2024-03-12", dicom.VRDA, -7, "", false},
+		{"DA that is no date", "20240230", dicom.VRDA, -7, "", false},
+		{"DT keeps its time", "20240301101530.123456", dicom.VRDT, -1, "20240229101530.123456", true},
+		{"DT keeps a time just before midnight", "20240229235959.999999", dicom.VRDT, 1, "20240301235959.999999", true},
+		{"DT keeps its UTC offset", "20231231230000+0100", dicom.VRDT, 1, "20240101230000+0100", true},
+		{"DT of a day", "20240312", dicom.VRDT, 30, "20240411", true},
+		{"DT of a month, shifted from its first day", "202402", dicom.VRDT, 30, "202403", true},
+		{"DT of a month, shifted less than a month", "202402", dicom.VRDT, 20, "202402", true},
+		{"DT of a year", "2024", dicom.VRDT, -1, "2023", true},
+		{"DT of a year with a UTC offset", "2024+0100", dicom.VRDT, 366, "2025+0100", true},
+		{"DT that is no date", "2024131", dicom.VRDT, -7, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := shiftDICOMDate(tt.value, tt.vr, tt.days)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("shiftDICOMDate(%q, %s, %d) = %q, %v, want %q, %v", tt.value, tt.vr, tt.days, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestShiftDICOMElement(t *testing.T) {
+	e := dicom.NewStringElement(dicom.TagStudyDate, dicom.VRDA, "20240229", "20240301")
+	if !shiftDICOMElement(e, 1) {
+		t.Fatal("shiftDICOMElement failed")
+	}
+	if got := e.Strings(); len(got) != 2 || got[0] != "20240301" || got[1] != "20240302" {
+		t.Errorf("shifted values %q, want [20240301 20240302]", got)
+	}
+
+	e = dicom.NewStringElement(dicom.TagStudyDate, dicom.VRDA, "20240229", "unknown")
+	if shiftDICOMElement(e, 1) || e.Strings()[0] != "20240229" {
+		t.Errorf("an element with an unparseable value was changed to %q", e.Strings())
+	}
+}
+
+func TestAnonymizeDICOMDataKeepsTimesWhenShifting(t *testing.T) {
+	ds := &dicom.DataSet{}
+	ds.Put(dicom.NewStringElement(dicom.TagStudyDate, dicom.VRDA, "20240301"))
+	ds.Put(dicom.NewStringElement(dicom.TagStudyTime, dicom.VRTM, "000512.5"))
+	ds.Put(dicom.NewStringElement(dicom.TagAcquisitionDateTime, dicom.VRDT, "20240301000512.5"))
+	ds.Put(dicom.NewStringElement(dicom.TagContentDate, dicom.VRDA, "20240301"))
+	ds.Put(dicom.NewStringElement(dicom.TagContentTime, dicom.VRTM, "235959"))
+
+	out, _, err := AnonymizeDICOMData(ds, DICOMAnonymizationOptions{DateShiftDays: -1})
+	if err != nil {
+		t.Fatalf("AnonymizeDICOMData: %v", err)
+	}
+	for tag, want := range map[dicom.Tag]string{
+		dicom.TagStudyDate:           "20240229",
+		dicom.TagStudyTime:           "000512.5",
+		dicom.TagAcquisitionDateTime: "20240229000512.5",
+		dicom.TagContentDate:         "20240229",
+		dicom.TagContentTime:         "235959",
+	} {
+		if got := out.GetString(tag); got != want {
+			t.Errorf("%s = %q, want %q", tag.Name(), got, want)
+		}
+	}
+	if got := out.GetString(dicom.TagLongitudinalTemporalInfoModified); got != "MODIFIED" {
+		t.Errorf("Longitudinal Temporal Information Modified = %q, want MODIFIED", got)
+	}
+}
+
+func TestShiftDate(t *testing.T) {
+	tests := []struct {
+		value string
+		days  int
+		want  string
+		ok    bool
+	}{
+		{"2024-03-01", -1, "2024-02-29", true},
+		{"03/01/2024", -1, "02/29/2024", true},
+		{"3/1/24", -1, "2/29/24", true},
+		{"March 1, 2024", -1, "February 29, 2024", true},
+		{"1 Mar 2024", -1, "29 Feb 2024", true},
+		{"Mar 1st, 2024", -1, "Feb 29, 2024", true},
+		{"December 31, 2023", 1, "January 1, 2024", true},
+		{"March 2024", -1, "February 2024", true},
+		{"25/12/2023", 7, "01/01/2024", true}, // Day first: 25 cannot be a month
+		{"02/30/2024", 1, "", false},
+		{"next spring", 1, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := shiftDate(tt.value, tt.days); ok != tt.ok || got != tt.want {
+			t.Errorf("shiftDate(%q, %d) = %q, %v, want %q, %v", tt.value, tt.days, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// internal/security/deid_dictionaries.go
+package security
+
+import "strings"
+
+// givenNames is the dictionary of common given names used to find names without a header cue. Names that are
+// also ordinary English or clinical words (e.g. "Will", "Grace", "June", "Mark", "Hope") are left out on purpose:
+// they would redact report text far more often than they would catch a name.
+var givenNames = wordSet(`
+AARON ABIGAIL ADAM ADRIAN AHMED ALAN ALBERT ALEXANDER ALEXANDRA ALICE ALICIA ALLISON AMANDA AMY ANA ANDREA ANDREW
+ANGELA ANN ANNA ANNE ANTHONY ANTONIO ARTHUR ASHLEY BARBARA BENJAMIN BETTY BEVERLY BRANDON BRENDA BRIAN BRITTANY
+BRUCE BRYAN CARL CAROL CAROLYN CATHERINE CHARLES CHERYL CHRISTINA CHRISTINE CHRISTOPHER CYNTHIA DANIEL DANIELLE
+DAVID DEBORAH DEBRA DENISE DENNIS DIANA DIANE DONALD DONNA DOROTHY DOUGLAS DYLAN EDWARD ELIZABETH EMILY EMMA ERIC
+ETHAN EUGENE EVELYN FRANCES FRANK GARY GEORGE GERALD GLORIA GREGORY HANNAH HAROLD HEATHER HELEN HENRY ISABELLA
+JACK JACOB JACQUELINE JAMES JANET JANICE JASON JEAN JEFFREY JENNIFER JEREMY JERRY JESSICA JOAN JOHN JONATHAN
+JORGE JOSE JOSEPH JOSHUA JUAN JUDITH JULIA JULIE JUSTIN KAREN KATHERINE KATHLEEN KATHRYN KAYLA KEITH KELLY
+KENNETH KEVIN KIMBERLY KYLE LARRY LAURA LAUREN LAWRENCE LINDA LISA LOUIS LUIS MARGARET MARIA MARIE MARILYN
+MARTHA MARY MATTHEW MEGAN MELISSA MICHAEL MICHELLE MOHAMMED MUHAMMAD NANCY NATALIE NATHAN NICHOLAS NICOLE NOAH
+OLIVIA PAMELA PATRICIA PATRICK PAUL PETER PHILIP RACHEL RALPH RANDY RAYMOND REBECCA RICHARD ROBERT ROGER RONALD
+ROY RUSSELL RUTH RYAN SAMANTHA SAMUEL SANDRA SARA SARAH SCOTT SEAN SHARON SHIRLEY SOPHIA STEPHANIE STEPHEN
+STEVEN SUSAN TERESA TERRY THERESA THOMAS TIMOTHY TYLER VICTORIA VINCENT VIRGINIA WALTER WAYNE WILLIAM ZACHARY
+`)
+
+// nameStopWords end a cued name: capitalized words that follow a name in report text but are not part of it.
+var nameStopWords = wordSet(`
+A AN AND ARE AS AT BE BUT BY FOR FROM HAS HAD HE HER HIS HISTORY IN IS IT MALE FEMALE NO NOT OF ON OR PRESENTS
+REPORTS SHE THE THIS TO UNKNOWN WAS WITH WHO DENIES DATE DOB AGE SEX MRN ID ACCESSION EXAM STUDY CLINICAL
+INDICATION FINDINGS IMPRESSION COMPARISON TECHNIQUE REPORT PATIENT
+DR MR MRS MS MISS PROF MD DO PHD RN NP PA FRCR FRCP
+`)
+
+// Surrogate dictionaries. They are deliberately short and bland; their purpose is readable text, not realism.
+var (
+	surrogateGivenNames = []string{"Alex", "Jordan", "Taylor", "Morgan", "Casey", "Riley", "Jamie", "Avery", "Quinn", "Drew", "Robin", "Sam"}
+	surrogateSurnames   = []string{"Smith", "Johnson", "Brown", "Miller", "Davis", "Wilson", "Moore", "Clark", "Lewis", "Walker", "Hall", "Young"}
+	surrogateStreets    = []string{"Main Street", "Oak Avenue", "Maple Road", "Cedar Lane", "Pine Drive", "Elm Court", "Park Place", "Lake Way"}
+)
+
+// wordSet builds a set from whitespace-separated upper-case words.
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
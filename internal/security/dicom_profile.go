@@ -0,0 +1,375 @@
+// internal/security/dicom_profile.go
+package security
+
+import (
+	"strings"
+
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// profileEntry is one row of PS3.15 Table E.1-1: the Basic Profile action code for an attribute, and whether the
+// attribute is affected by the Retain Longitudinal Temporal Information with Full Dates option (dates and times,
+// which the option keeps) or by the Clean Descriptors option (free-text descriptors, which the option cleans).
+type profileEntry struct {
+	keyword    string
+	code       string // Action code as printed in the standard; composite codes such as "X/Z/D" are resolved by resolveActionCode
+	temporal   bool   // Kept (K) under Retain Longitudinal Temporal Information with Full Dates; shifted (dates) or kept (times) with Modified Dates
+	descriptor bool   // Cleaned (C) under Clean Descriptors
+}
+
+// basicProfile lists the attributes of PS3.15 Table E.1-1 (Basic Application Level Confidentiality Profile)
+// that occur in the imaging objects this service accepts (CT, MR, CR/DX, PET, SC, SR, encapsulated PDF and RT).
+// Attributes absent from the table are kept, except for the rules in basicProfileRules (private tags, curves,
+// overlays, group lengths and any Person Name).
+var basicProfile = map[dicom.Tag]profileEntry{
+	// File meta information
+	0x00020003: {keyword: "MediaStorageSOPInstanceUID", code: "U"},
+	0x00020016: {keyword: "SourceApplicationEntityTitle", code: "X"},
+	0x00020100: {keyword: "PrivateInformationCreatorUID", code: "X"},
+	0x00020102: {keyword: "PrivateInformation", code: "X"},
+
+	// SOP common, general study/series/equipment
+	0x00080012: {keyword: "InstanceCreationDate", code: "X/D", temporal: true},
+	0x00080013: {keyword: "InstanceCreationTime", code: "X/Z/D", temporal: true},
+	0x00080014: {keyword: "InstanceCreatorUID", code: "U"},
+	0x00080015: {keyword: "InstanceCoercionDateTime", code: "X", temporal: true},
+	0x00080018: {keyword: "SOPInstanceUID", code: "U"},
+	0x00080020: {keyword: "StudyDate", code: "Z", temporal: true},
+	0x00080021: {keyword: "SeriesDate", code: "X/D", temporal: true},
+	0x00080022: {keyword: "AcquisitionDate", code: "X/Z", temporal: true},
+	0x00080023: {keyword: "ContentDate", code: "Z/D", temporal: true},
+	0x00080024: {keyword: "OverlayDate", code: "X", temporal: true},
+	0x00080025: {keyword: "CurveDate", code: "X", temporal: true},
+	0x0008002A: {keyword: "AcquisitionDateTime", code: "X/Z/D", temporal: true},
+	0x00080030: {keyword: "StudyTime", code: "Z", temporal: true},
+	0x00080031: {keyword: "SeriesTime", code: "X/D", temporal: true},
+	0x00080032: {keyword: "AcquisitionTime", code: "X/Z", temporal: true},
+	0x00080033: {keyword: "ContentTime", code: "Z/D", temporal: true},
+	0x00080034: {keyword: "OverlayTime", code: "X", temporal: true},
+	0x00080035: {keyword: "CurveTime", code: "X", temporal: true},
+	0x00080050: {keyword: "AccessionNumber", code: "Z"},
+	0x00080054: {keyword: "RetrieveAETitle", code: "X"},
+	0x00080080: {keyword: "InstitutionName", code: "X/Z/D"},
+	0x00080081: {keyword: "InstitutionAddress", code: "X"},
+	0x00080082: {keyword: "InstitutionCodeSequence", code: "X/Z/D"},
+	0x00080090: {keyword: "ReferringPhysicianName", code: "Z"},
+	0x00080092: {keyword: "ReferringPhysicianAddress", code: "X"},
+	0x00080094: {keyword: "ReferringPhysicianTelephoneNumbers", code: "X"},
+	0x00080096: {keyword: "ReferringPhysicianIdentificationSequence", code: "X"},
+	0x0008009C: {keyword: "ConsultingPhysicianName", code: "X"},
+	0x0008009D: {keyword: "ConsultingPhysicianIdentificationSequence", code: "X"},
+	0x00080116: {keyword: "ResponsibleOrganization", code: "X"},
+	0x00080201: {keyword: "TimezoneOffsetFromUTC", code: "X", temporal: true},
+	0x00081010: {keyword: "StationName", code: "X/Z/D"},
+	0x00081030: {keyword: "StudyDescription", code: "X", descriptor: true},
+	0x0008103E: {keyword: "SeriesDescription", code: "X", descriptor: true},
+	0x00081040: {keyword: "InstitutionalDepartmentName", code: "X"},
+	0x00081048: {keyword: "PhysiciansOfRecord", code: "X"},
+	0x00081049: {keyword: "PhysiciansOfRecordIdentificationSequence", code: "X"},
+	0x00081050: {keyword: "PerformingPhysicianName", code: "X"},
+	0x00081052: {keyword: "PerformingPhysicianIdentificationSequence", code: "X"},
+	0x00081060: {keyword: "NameOfPhysiciansReadingStudy", code: "X"},
+	0x00081062: {keyword: "PhysiciansReadingStudyIdentificationSequence", code: "X"},
+	0x00081070: {keyword: "OperatorsName", code: "X/Z/D"},
+	0x00081072: {keyword: "OperatorIdentificationSequence", code: "X/D"},
+	0x00081080: {keyword: "AdmittingDiagnosesDescription", code: "X", descriptor: true},
+	0x00081084: {keyword: "AdmittingDiagnosesCodeSequence", code: "X"},
+	0x00081110: {keyword: "ReferencedStudySequence", code: "X/Z"},
+	0x00081111: {keyword: "ReferencedPerformedProcedureStepSequence", code: "X/Z/D"},
+	0x00081120: {keyword: "ReferencedPatientSequence", code: "X"},
+	0x00081140: {keyword: "ReferencedImageSequence", code: "X/Z/U*"},
+	0x00081155: {keyword: "ReferencedSOPInstanceUID", code: "U"},
+	0x00081195: {keyword: "TransactionUID", code: "U"},
+	0x00082111: {keyword: "DerivationDescription", code: "X", descriptor: true},
+	0x00082112: {keyword: "SourceImageSequence", code: "X/Z/U*"},
+	0x00083010: {keyword: "IrradiationEventUID", code: "U"},
+	0x00084000: {keyword: "IdentifyingComments", code: "X"},
+
+	// Patient
+	0x00100010: {keyword: "PatientName", code: "Z"},
+	0x00100020: {keyword: "PatientID", code: "Z"},
+	0x00100021: {keyword: "IssuerOfPatientID", code: "X"},
+	0x00100030: {keyword: "PatientBirthDate", code: "Z"},
+	0x00100032: {keyword: "PatientBirthTime", code: "X"},
+	0x00100040: {keyword: "PatientSex", code: "Z"},
+	0x00100050: {keyword: "PatientInsurancePlanCodeSequence", code: "X"},
+	0x00100101: {keyword: "PatientPrimaryLanguageCodeSequence", code: "X"},
+	0x00100102: {keyword: "PatientPrimaryLanguageModifierCodeSequence", code: "X"},
+	0x00101000: {keyword: "OtherPatientIDs", code: "X"},
+	0x00101001: {keyword: "OtherPatientNames", code: "X"},
+	0x00101002: {keyword: "OtherPatientIDsSequence", code: "X"},
+	0x00101005: {keyword: "PatientBirthName", code: "X"},
+	0x00101010: {keyword: "PatientAge", code: "X"},
+	0x00101020: {keyword: "PatientSize", code: "X"},
+	0x00101030: {keyword: "PatientWeight", code: "X"},
+	0x00101040: {keyword: "PatientAddress", code: "X"},
+	0x00101050: {keyword: "InsurancePlanIdentification", code: "X"},
+	0x00101060: {keyword: "PatientMotherBirthName", code: "X"},
+	0x00101080: {keyword: "MilitaryRank", code: "X"},
+	0x00101081: {keyword: "BranchOfService", code: "X"},
+	0x00101090: {keyword: "MedicalRecordLocator", code: "X"},
+	0x00101100: {keyword: "ReferencedPatientPhotoSequence", code: "X"},
+	0x00102000: {keyword: "MedicalAlerts", code: "X"},
+	0x00102110: {keyword: "Allergies", code: "X"},
+	0x00102150: {keyword: "CountryOfResidence", code: "X"},
+	0x00102152: {keyword: "RegionOfResidence", code: "X"},
+	0x00102154: {keyword: "PatientTelephoneNumbers", code: "X"},
+	0x00102155: {keyword: "PatientTelecomInformation", code: "X"},
+	0x00102160: {keyword: "EthnicGroup", code: "X"},
+	0x00102180: {keyword: "Occupation", code: "X"},
+	0x001021A0: {keyword: "SmokingStatus", code: "X"},
+	0x001021B0: {keyword: "AdditionalPatientHistory", code: "X"},
+	0x001021C0: {keyword: "PregnancyStatus", code: "X"},
+	0x001021D0: {keyword: "LastMenstrualDate", code: "X"},
+	0x001021F0: {keyword: "PatientReligiousPreference", code: "X"},
+	0x00102203: {keyword: "PatientSexNeutered", code: "X/Z"},
+	0x00102297: {keyword: "ResponsiblePerson", code: "X"},
+	0x00102299: {keyword: "ResponsibleOrganization", code: "X"},
+	0x00104000: {keyword: "PatientComments", code: "X"},
+
+	// Acquisition and equipment
+	0x00180010: {keyword: "ContrastBolusAgent", code: "Z/D", descriptor: true},
+	0x00181000: {keyword: "DeviceSerialNumber", code: "X/Z/D"},
+	0x00181002: {keyword: "DeviceUID", code: "U"},
+	0x00181004: {keyword: "PlateID", code: "X"},
+	0x00181005: {keyword: "GeneratorID", code: "X"},
+	0x00181007: {keyword: "CassetteID", code: "X"},
+	0x00181008: {keyword: "GantryID", code: "X"},
+	0x00181012: {keyword: "DateOfSecondaryCapture", code: "X", temporal: true},
+	0x00181014: {keyword: "TimeOfSecondaryCapture", code: "X", temporal: true},
+	0x00181030: {keyword: "ProtocolName", code: "X/D", descriptor: true},
+	0x00181200: {keyword: "DateOfLastCalibration", code: "X", temporal: true},
+	0x00181201: {keyword: "TimeOfLastCalibration", code: "X", temporal: true},
+	0x00181400: {keyword: "AcquisitionDeviceProcessingDescription", code: "X/D", descriptor: true},
+	0x00184000: {keyword: "AcquisitionComments", code: "X", descriptor: true},
+	0x0018700A: {keyword: "DetectorID", code: "X/D"},
+	0x0018700C: {keyword: "DateOfLastDetectorCalibration", code: "X/D", temporal: true},
+	0x00189074: {keyword: "FrameAcquisitionDateTime", code: "X", temporal: true},
+	0x00189151: {keyword: "FrameReferenceDateTime", code: "X", temporal: true},
+	0x00189424: {keyword: "AcquisitionProtocolDescription", code: "X", descriptor: true},
+	0x0018A003: {keyword: "ContributionDescription", code: "X", descriptor: true},
+
+	// Study, series, frame of reference and image
+	0x0020000D: {keyword: "StudyInstanceUID", code: "U"},
+	0x0020000E: {keyword: "SeriesInstanceUID", code: "U"},
+	0x00200010: {keyword: "StudyID", code: "Z"},
+	0x00200052: {keyword: "FrameOfReferenceUID", code: "U"},
+	0x00200200: {keyword: "SynchronizationFrameOfReferenceUID", code: "U"},
+	0x00204000: {keyword: "ImageComments", code: "X", descriptor: true},
+	0x00209161: {keyword: "ConcatenationUID", code: "U"},
+	0x00209164: {keyword: "DimensionOrganizationUID", code: "U"},
+	0x00281199: {keyword: "PaletteColorLookupTableUID", code: "U"},
+	0x00281214: {keyword: "LargePaletteColorLookupTableUID", code: "U"},
+	0x00284000: {keyword: "ImagePresentationComments", code: "X"},
+
+	// Study scheduling, visit and procedure steps
+	0x00320012: {keyword: "StudyIDIssuer", code: "X"},
+	0x00320032: {keyword: "StudyVerifiedDate", code: "X", temporal: true},
+	0x00320033: {keyword: "StudyVerifiedTime", code: "X", temporal: true},
+	0x00320034: {keyword: "StudyReadDate", code: "X", temporal: true},
+	0x00320035: {keyword: "StudyReadTime", code: "X", temporal: true},
+	0x00321000: {keyword: "ScheduledStudyStartDate", code: "X", temporal: true},
+	0x00321001: {keyword: "ScheduledStudyStartTime", code: "X", temporal: true},
+	0x00321010: {keyword: "ScheduledStudyStopDate", code: "X", temporal: true},
+	0x00321011: {keyword: "ScheduledStudyStopTime", code: "X", temporal: true},
+	0x00321020: {keyword: "ScheduledStudyLocation", code: "X"},
+	0x00321021: {keyword: "ScheduledStudyLocationAETitle", code: "X"},
+	0x00321030: {keyword: "ReasonForStudy", code: "X"},
+	0x00321032: {keyword: "RequestingPhysician", code: "X"},
+	0x00321033: {keyword: "RequestingService", code: "X"},
+	0x00321040: {keyword: "StudyArrivalDate", code: "X", temporal: true},
+	0x00321041: {keyword: "StudyArrivalTime", code: "X", temporal: true},
+	0x00321050: {keyword: "StudyCompletionDate", code: "X", temporal: true},
+	0x00321051: {keyword: "StudyCompletionTime", code: "X", temporal: true},
+	0x00321060: {keyword: "RequestedProcedureDescription", code: "X/Z", descriptor: true},
+	0x00321070: {keyword: "RequestedContrastAgent", code: "X"},
+	0x00324000: {keyword: "StudyComments", code: "X"},
+	0x00380004: {keyword: "ReferencedPatientAliasSequence", code: "X"},
+	0x00380010: {keyword: "AdmissionID", code: "X"},
+	0x00380011: {keyword: "IssuerOfAdmissionID", code: "X"},
+	0x00380020: {keyword: "AdmittingDate", code: "X", temporal: true},
+	0x00380021: {keyword: "AdmittingTime", code: "X", temporal: true},
+	0x00380030: {keyword: "DischargeDate", code: "X", temporal: true},
+	0x00380032: {keyword: "DischargeTime", code: "X", temporal: true},
+	0x00380060: {keyword: "ServiceEpisodeID", code: "X"},
+	0x00380062: {keyword: "ServiceEpisodeDescription", code: "X"},
+	0x00380300: {keyword: "CurrentPatientLocation", code: "X"},
+	0x00380400: {keyword: "PatientInstitutionResidence", code: "X"},
+	0x00380500: {keyword: "PatientState", code: "X"},
+	0x00384000: {keyword: "VisitComments", code: "X"},
+	0x00400001: {keyword: "ScheduledStationAETitle", code: "X"},
+	0x00400002: {keyword: "ScheduledProcedureStepStartDate", code: "X", temporal: true},
+	0x00400003: {keyword: "ScheduledProcedureStepStartTime", code: "X", temporal: true},
+	0x00400004: {keyword: "ScheduledProcedureStepEndDate", code: "X", temporal: true},
+	0x00400005: {keyword: "ScheduledProcedureStepEndTime", code: "X", temporal: true},
+	0x00400006: {keyword: "ScheduledPerformingPhysicianName", code: "X"},
+	0x00400007: {keyword: "ScheduledProcedureStepDescription", code: "X", descriptor: true},
+	0x0040000B: {keyword: "ScheduledPerformingPhysicianIdentificationSequence", code: "X"},
+	0x00400010: {keyword: "ScheduledStationName", code: "X"},
+	0x00400011: {keyword: "ScheduledProcedureStepLocation", code: "X"},
+	0x00400012: {keyword: "PreMedication", code: "X"},
+	0x00400241: {keyword: "PerformedStationAETitle", code: "X"},
+	0x00400242: {keyword: "PerformedStationName", code: "X"},
+	0x00400243: {keyword: "PerformedLocation", code: "X"},
+	0x00400244: {keyword: "PerformedProcedureStepStartDate", code: "X", temporal: true},
+	0x00400245: {keyword: "PerformedProcedureStepStartTime", code: "X", temporal: true},
+	0x00400250: {keyword: "PerformedProcedureStepEndDate", code: "X", temporal: true},
+	0x00400251: {keyword: "PerformedProcedureStepEndTime", code: "X", temporal: true},
+	0x00400253: {keyword: "PerformedProcedureStepID", code: "X"},
+	0x00400254: {keyword: "PerformedProcedureStepDescription", code: "X", descriptor: true},
+	0x00400275: {keyword: "RequestAttributesSequence", code: "X"},
+	0x00400280: {keyword: "CommentsOnThePerformedProcedureStep", code: "X"},
+	0x00400555: {keyword: "AcquisitionContextSequence", code: "X"},
+	0x00401001: {keyword: "RequestedProcedureID", code: "X"},
+	0x00401004: {keyword: "PatientTransportArrangements", code: "X"},
+	0x00401005: {keyword: "RequestedProcedureLocation", code: "X"},
+	0x00401010: {keyword: "NamesOfIntendedRecipientsOfResults", code: "X"},
+	0x00401011: {keyword: "IntendedRecipientsOfResultsIdentificationSequence", code: "X"},
+	0x00401101: {keyword: "PersonIdentificationCodeSequence", code: "D"},
+	0x00401102: {keyword: "PersonAddress", code: "X"},
+	0x00401103: {keyword: "PersonTelephoneNumbers", code: "X"},
+	0x00401400: {keyword: "RequestedProcedureComments", code: "X"},
+	0x00402001: {keyword: "ReasonForTheImagingServiceRequest", code: "X"},
+	0x00402008: {keyword: "OrderEnteredBy", code: "X"},
+	0x00402009: {keyword: "OrderEntererLocation", code: "X"},
+	0x00402010: {keyword: "OrderCallbackPhoneNumber", code: "X"},
+	0x00402016: {keyword: "PlacerOrderNumberImagingServiceRequest", code: "Z"},
+	0x00402017: {keyword: "FillerOrderNumberImagingServiceRequest", code: "Z"},
+	0x00402400: {keyword: "ImagingServiceRequestComments", code: "X"},
+	0x00403001: {keyword: "ConfidentialityConstraintOnPatientDataDescription", code: "X"},
+
+	// Structured reporting
+	0x0040A027: {keyword: "VerifyingOrganization", code: "X"},
+	0x0040A030: {keyword: "VerificationDateTime", code: "D", temporal: true},
+	0x0040A032: {keyword: "ObservationDateTime", code: "X/D", temporal: true},
+	0x0040A073: {keyword: "VerifyingObserverSequence", code: "D"},
+	0x0040A075: {keyword: "VerifyingObserverName", code: "D"},
+	0x0040A078: {keyword: "AuthorObserverSequence", code: "X"},
+	0x0040A07A: {keyword: "ParticipantSequence", code: "X"},
+	0x0040A07C: {keyword: "CustodialOrganizationSequence", code: "X"},
+	0x0040A088: {keyword: "VerifyingObserverIdentificationCodeSequence", code: "Z"},
+	0x0040A120: {keyword: "DateTime", code: "X", temporal: true},
+	0x0040A121: {keyword: "Date", code: "X", temporal: true},
+	0x0040A122: {keyword: "Time", code: "X", temporal: true},
+	0x0040A123: {keyword: "PersonName", code: "D"},
+	0x0040A124: {keyword: "UID", code: "U"},
+	0x0040A160: {keyword: "TextValue", code: "X"},
+	0x0040A730: {keyword: "ContentSequence", code: "X"},
+	0x0040DB0C: {keyword: "TemplateExtensionOrganizationUID", code: "U"},
+	0x0040DB0D: {keyword: "TemplateExtensionCreatorUID", code: "U"},
+	0x00700084: {keyword: "ContentCreatorName", code: "Z"},
+	0x00700086: {keyword: "ContentCreatorIdentificationCodeSequence", code: "X"},
+
+	// Media, icons, signatures and miscellaneous text
+	0x00880140: {keyword: "StorageMediaFileSetUID", code: "U"},
+	0x00880200: {keyword: "IconImageSequence", code: "X"},
+	0x00880904: {keyword: "TopicTitle", code: "X"},
+	0x00880906: {keyword: "TopicSubject", code: "X"},
+	0x00880910: {keyword: "TopicAuthor", code: "X"},
+	0x00880912: {keyword: "TopicKeywords", code: "X"},
+	0x04000100: {keyword: "DigitalSignatureUID", code: "X"},
+	0x04000402: {keyword: "ReferencedDigitalSignatureSequence", code: "X"},
+	0x04000403: {keyword: "ReferencedSOPInstanceMACSequence", code: "X"},
+	0x04000404: {keyword: "MAC", code: "X"},
+	0x04000550: {keyword: "ModifiedAttributesSequence", code: "X"},
+	0x04000561: {keyword: "OriginalAttributesSequence", code: "X"},
+	0x20300020: {keyword: "TextString", code: "X"},
+	0x40000010: {keyword: "Arbitrary", code: "X"},
+	0x40004000: {keyword: "TextComments", code: "X"},
+	0xFFFAFFFA: {keyword: "DigitalSignaturesSequence", code: "X"},
+	0xFFFCFFFC: {keyword: "DataSetTrailingPadding", code: "X"},
+
+	// Radiotherapy
+	0x30060002: {keyword: "StructureSetLabel", code: "D"},
+	0x30060004: {keyword: "StructureSetName", code: "Z"},
+	0x30060006: {keyword: "StructureSetDescription", code: "X", descriptor: true},
+	0x30060008: {keyword: "StructureSetDate", code: "Z", temporal: true},
+	0x30060009: {keyword: "StructureSetTime", code: "Z", temporal: true},
+	0x30060024: {keyword: "ReferencedFrameOfReferenceUID", code: "U"},
+	0x30060026: {keyword: "ROIName", code: "Z"},
+	0x30060028: {keyword: "ROIDescription", code: "X", descriptor: true},
+	0x30060085: {keyword: "ROIObservationLabel", code: "X"},
+	0x300600A6: {keyword: "ROIInterpreter", code: "Z"},
+	0x300600C2: {keyword: "RelatedFrameOfReferenceUID", code: "U"},
+	0x300A0002: {keyword: "RTPlanLabel", code: "D"},
+	0x300A0003: {keyword: "RTPlanName", code: "X"},
+	0x300A0004: {keyword: "RTPlanDescription", code: "X", descriptor: true},
+	0x300A000E: {keyword: "PrescriptionDescription", code: "X", descriptor: true},
+	0x300A0013: {keyword: "DoseReferenceUID", code: "U"},
+	0x300A0016: {keyword: "DoseReferenceDescription", code: "X", descriptor: true},
+	0x300A0072: {keyword: "FractionGroupDescription", code: "X", descriptor: true},
+	0x300E0008: {keyword: "ReviewerName", code: "X/Z"},
+
+	// Results and interpretation (retired, still found on older media)
+	0x40080042: {keyword: "ResultsIDIssuer", code: "X"},
+	0x40080102: {keyword: "InterpretationRecorder", code: "X"},
+	0x4008010A: {keyword: "InterpretationTranscriber", code: "X"},
+	0x4008010B: {keyword: "InterpretationText", code: "X"},
+	0x4008010C: {keyword: "InterpretationAuthor", code: "X"},
+	0x40080111: {keyword: "InterpretationApproverSequence", code: "X"},
+	0x40080114: {keyword: "PhysicianApprovingInterpretation", code: "X"},
+	0x40080115: {keyword: "InterpretationDiagnosisDescription", code: "X"},
+	0x40080118: {keyword: "ResultsDistributionListSequence", code: "X"},
+	0x40080119: {keyword: "DistributionName", code: "X"},
+	0x4008011A: {keyword: "DistributionAddress", code: "X"},
+	0x40080202: {keyword: "InterpretationIDIssuer", code: "X"},
+	0x40080300: {keyword: "Impressions", code: "X"},
+	0x40084000: {keyword: "ResultsComments", code: "X"},
+}
+
+// resolveActionCode reduces a (possibly composite) Table E.1-1 code to a single action. The standard leaves the
+// choice to the implementation depending on the attribute's Type in the IOD; since this service handles many
+// IODs, it takes the least destructive choice that still removes the information and keeps Type 1 and Type 2
+// attributes present: X/Z -> Z, X/D, Z/D and X/Z/D -> D, X/Z/U* -> U (sequences are kept and their UIDs replaced).
+func resolveActionCode(code string) DeidentificationAction {
+	switch code {
+	case "D", "X/D", "Z/D", "X/Z/D":
+		return ActionDummy
+	case "Z", "X/Z":
+		return ActionZero
+	case "U", "X/Z/U*":
+		return ActionUID
+	case "K":
+		return ActionKeep
+	case "C":
+		return ActionClean
+	default:
+		return ActionRemove
+	}
+}
+
+// isCurveOrOverlayData reports whether tag belongs to the retired Curve groups (50xx,xxxx) or is Overlay Data or
+// Overlay Comments (60xx,3000 / 60xx,4000), which the Basic Profile removes because overlays may carry burned-in text.
+func isCurveOrOverlayData(tag dicom.Tag) bool {
+	group := tag.Group()
+	if group&0xFF00 == 0x5000 {
+		return true
+	}
+	return group&0xFF00 == 0x6000 && group%2 == 0 && (tag.Element() == 0x3000 || tag.Element() == 0x4000)
+}
+
+// wellKnownUIDTags hold UIDs that identify a class, syntax or coding scheme rather than an instance, and are
+// never remapped.
+var wellKnownUIDTags = map[dicom.Tag]bool{
+	dicom.TagSOPClassUID:                       true,
+	dicom.TagReferencedSOPClassUID:             true,
+	0x0008010C:                                 true, // CodingSchemeUID
+	dicom.TagReferencedSOPClassUIDInFile:       true,
+	dicom.TagReferencedTransferSyntaxUIDInFile: true,
+	0x0008001A: true, // RelatedGeneralSOPClassUID
+	0x0008001B: true, // OriginalSpecializedSOPClassUID
+}
+
+// isInstanceUID reports whether a UI element not listed in the profile identifies an instance, i.e. is neither a
+// well-known class/syntax attribute nor a value under the DICOM UID root (1.2.840.10008).
+func isInstanceUID(e *dicom.Element) bool {
+	if wellKnownUIDTags[e.Tag] || e.Tag.Group() == 0x0002 {
+		return false
+	}
+	for _, uid := range e.Strings() {
+		if uid != "" && !strings.HasPrefix(uid, "1.2.840.10008.") {
+			return true
+		}
+	}
+	return false
+}
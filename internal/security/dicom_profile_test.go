@@ -0,0 +1,238 @@
+// internal/security/dicom_profile_test.go
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackvity/lung-server/pkg/dicom"
+)
+
+// Original values given to every attribute of the synthetic dataset, by VR.
+const (
+	originalDate     = "20240312"
+	originalTime     = "101530"
+	originalDateTime = "20240312101530"
+	originalUID      = "1.2.826.0.1.3680043.2.1143.42"
+	shiftedDate      = "20240211" // originalDate moved by testDateShift days
+	shiftedDateTime  = "20240211101530"
+	testDateShift    = -30
+)
+
+// profileVR guesses the VR of a profile attribute from its keyword; the dataset only needs values the actions can
+// tell apart (dates, times, UIDs and sequences are handled differently, all other text alike).
+func profileVR(keyword string) dicom.VR {
+	switch {
+	case strings.HasSuffix(keyword, "Sequence"):
+		return dicom.VRSQ
+	case strings.HasSuffix(keyword, "UID"):
+		return dicom.VRUI
+	case strings.HasSuffix(keyword, "DateTime"):
+		return dicom.VRDT
+	case strings.HasSuffix(keyword, "Date") || strings.HasPrefix(keyword, "DateOf"):
+		return dicom.VRDA
+	case strings.HasSuffix(keyword, "Time") || strings.HasPrefix(keyword, "TimeOf"):
+		return dicom.VRTM
+	case strings.HasSuffix(keyword, "Name") || strings.HasPrefix(keyword, "NameOf") || strings.HasPrefix(keyword, "NamesOf"):
+		return dicom.VRPN
+	}
+	return dicom.VRLO
+}
+
+// profileElement returns an element for a profile attribute holding an identifying value.
+func profileElement(tag dicom.Tag, keyword string) *dicom.Element {
+	vr := profileVR(keyword)
+	switch vr {
+	case dicom.VRSQ:
+		item := &dicom.Item{}
+		item.Put(dicom.NewStringElement(0x00080100, dicom.VRSH, "CODE42")) // CodeValue
+		return &dicom.Element{Tag: tag, VR: vr, Items: []*dicom.Item{item}}
+	case dicom.VRUI:
+		return dicom.NewStringElement(tag, vr, originalUID)
+	case dicom.VRDA:
+		return dicom.NewStringElement(tag, vr, originalDate)
+	case dicom.VRTM:
+		return dicom.NewStringElement(tag, vr, originalTime)
+	case dicom.VRDT:
+		return dicom.NewStringElement(tag, vr, originalDateTime)
+	}
+	return dicom.NewStringElement(tag, vr, "IDENTIFYING "+keyword)
+}
+
+// syntheticProfileDataset holds every attribute of basicProfile, plus attributes the profile rules remove.
+func syntheticProfileDataset() *dicom.DataSet {
+	ds := &dicom.DataSet{}
+	for tag, entry := range basicProfile {
+		ds.Put(profileElement(tag, entry.keyword))
+	}
+	ds.Put(dicom.NewStringElement(dicom.TagSOPClassUID, dicom.VRUI, "1.2.840.10008.5.1.4.1.1.2"))
+	ds.Put(dicom.NewStringElement(dicom.TagModality, dicom.VRCS, "CT"))
+	ds.Put(&dicom.Element{Tag: 0x00080000, VR: dicom.VRUL, Value: []byte{0, 1, 0, 0}}) // Group length
+	ds.Put(dicom.NewStringElement(0x00090010, dicom.VRLO, "ACME 1.0"))                 // Private creator
+	ds.Put(dicom.NewStringElement(0x00091010, dicom.VRLO, "DOE^JANE"))                 // Private attribute
+	ds.Put(&dicom.Element{Tag: 0x50003000, VR: dicom.VROW, Value: []byte{1, 2, 3, 4}}) // Curve Data
+	ds.Put(dicom.NewStringElement(0x50020022, dicom.VRLO, "Curve description"))        // Curve Description
+	ds.Put(&dicom.Element{Tag: 0x60000010, VR: dicom.VRUS, Value: []byte{16, 0}})      // Overlay Rows, kept
+	ds.Put(&dicom.Element{Tag: 0x60003000, VR: dicom.VROW, Value: []byte{0xFF, 0xFF}}) // Overlay Data
+	ds.Put(dicom.NewStringElement(0x60024000, dicom.VRLT, "Operator DOE"))             // Overlay Comments
+	ds.Put(dicom.NewStringElement(0x00321034, dicom.VRPN, "DOE^JOHN"))                 // Person Name absent from the table
+	ds.Put(dicom.NewStringElement(0x00181020, dicom.VRLO, "VE11C"))                    // Software Versions, kept
+	nested := &dicom.Item{}
+	nested.Put(dicom.NewStringElement(dicom.TagPatientName, dicom.VRPN, "DOE^JANE"))
+	nested.Put(dicom.NewStringElement(0x00191001, dicom.VRLO, "private"))
+	ds.Put(&dicom.Element{Tag: 0x00400260, VR: dicom.VRSQ, Items: []*dicom.Item{nested}}) // Performed Protocol Code Sequence, not listed
+	return ds
+}
+
+// isEmpty reports whether an element holds no value.
+func isEmpty(e *dicom.Element) bool {
+	return len(e.Value) == 0 && len(e.Items) == 0 && len(e.Fragments) == 0
+}
+
+func TestAnonymizeDICOMDataProfile(t *testing.T) {
+	mapper := NewUIDMapper([]byte("session secret"))
+	tests := []struct {
+		name string
+		opts DICOMAnonymizationOptions
+	}{
+		{"basic profile", DICOMAnonymizationOptions{}},
+		{"retain longitudinal full dates", DICOMAnonymizationOptions{RetainLongitudinalDates: true}},
+		{"retain longitudinal modified dates", DICOMAnonymizationOptions{DateShiftDays: testDateShift}},
+		{"full dates win over modified dates", DICOMAnonymizationOptions{RetainLongitudinalDates: true, DateShiftDays: testDateShift}},
+		{"clean descriptors", DICOMAnonymizationOptions{CleanDescriptors: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.UIDMapper = mapper
+			opts.TextCleaner = func(string) string { return "CLEANED" }
+			original := syntheticProfileDataset()
+			out, record, err := AnonymizeDICOMData(original, opts)
+			if err != nil {
+				t.Fatalf("AnonymizeDICOMData: %v", err)
+			}
+
+			actions := map[string]DeidentificationAction{}
+			for _, a := range record.Actions {
+				if !strings.Contains(a.Path, ".") {
+					actions[a.Tag] = a.Action
+				}
+			}
+			shifting := opts.DateShiftDays != 0 && !opts.RetainLongitudinalDates
+			for tag, entry := range basicProfile {
+				action, recorded := actions[tag.String()]
+				if !recorded {
+					t.Errorf("%s %s: no action recorded", tag, entry.keyword)
+					continue
+				}
+				vr := profileVR(entry.keyword)
+				want := resolveActionCode(entry.code)
+				switch {
+				case entry.temporal && opts.RetainLongitudinalDates:
+					want = ActionKeep
+				case entry.temporal && shifting && (vr == dicom.VRDA || vr == dicom.VRDT):
+					want = ActionShift
+				case entry.temporal && shifting:
+					want = ActionKeep
+				case entry.descriptor && opts.CleanDescriptors:
+					want = ActionClean
+				}
+				if action != want {
+					t.Errorf("%s %s: action %s, want %s", tag, entry.keyword, action, want)
+				}
+
+				before, _ := original.FindElement(tag)
+				after, present := out.FindElement(tag)
+				switch action {
+				case ActionRemove:
+					if present {
+						t.Errorf("%s %s: kept, want removed", tag, entry.keyword)
+					}
+				case ActionZero:
+					if !present || !isEmpty(after) {
+						t.Errorf("%s %s: want present and empty", tag, entry.keyword)
+					}
+				case ActionDummy:
+					switch {
+					case !present:
+						t.Errorf("%s %s: removed, want a dummy value", tag, entry.keyword)
+					case vr == dicom.VRSQ || vr == dicom.VRUI:
+						if !isEmpty(after) {
+							t.Errorf("%s %s: want an empty dummy", tag, entry.keyword)
+						}
+					case isEmpty(after) || after.StringValue() == before.StringValue():
+						t.Errorf("%s %s: dummy %q, want a non-empty value replacing %q", tag, entry.keyword, after.StringValue(), before.StringValue())
+					}
+				case ActionUID:
+					switch {
+					case !present:
+						t.Errorf("%s %s: removed, want its UIDs replaced", tag, entry.keyword)
+					case vr == dicom.VRUI && after.StringValue() != mapper.MapUID(originalUID):
+						t.Errorf("%s %s: UID %q, want the mapped UID", tag, entry.keyword, after.StringValue())
+					case vr == dicom.VRSQ && len(after.Items) != len(before.Items):
+						t.Errorf("%s %s: %d items, want the %d references kept", tag, entry.keyword, len(after.Items), len(before.Items))
+					}
+				case ActionKeep:
+					if !present || after.StringValue() != before.StringValue() {
+						t.Errorf("%s %s: want kept unchanged", tag, entry.keyword)
+					}
+				case ActionClean:
+					if !present || (vr != dicom.VRSQ && after.StringValue() != "CLEANED") {
+						t.Errorf("%s %s: want cleaned", tag, entry.keyword)
+					}
+				case ActionShift:
+					want := shiftedDate
+					if vr == dicom.VRDT {
+						want = shiftedDateTime
+					}
+					if !present || after.StringValue() != want {
+						t.Errorf("%s %s: want shifted to %q", tag, entry.keyword, want)
+					}
+				}
+			}
+
+			for _, tag := range []dicom.Tag{0x00080000, 0x00090010, 0x00091010, 0x50003000, 0x50020022, 0x60003000, 0x60024000, 0x00321034} {
+				if _, ok := out.FindElement(tag); ok {
+					t.Errorf("%s kept, want removed", tag)
+				}
+			}
+			for _, tag := range []dicom.Tag{dicom.TagSOPClassUID, dicom.TagModality, 0x60000010, 0x00181020} {
+				if _, ok := out.FindElement(tag); !ok {
+					t.Errorf("%s removed, want kept", tag)
+				}
+			}
+			sequence, ok := out.FindElement(0x00400260)
+			if !ok || len(sequence.Items) != 1 {
+				t.Fatal("unlisted sequence removed, want it kept and its items processed")
+			}
+			if name, ok := sequence.Items[0].FindElement(dicom.TagPatientName); !ok || !isEmpty(name) {
+				t.Error("Patient Name inside a sequence was not emptied")
+			}
+			if _, ok := sequence.Items[0].FindElement(0x00191001); ok {
+				t.Error("private attribute inside a sequence was kept")
+			}
+
+			if got := out.GetString(dicom.TagPatientIdentityRemoved); got != "YES" {
+				t.Errorf("Patient Identity Removed = %q, want YES", got)
+			}
+			if got, _ := original.FindElement(dicom.TagPatientName); got.StringValue() == "" {
+				t.Error("the input dataset was modified")
+			}
+		})
+	}
+}
+
+func TestAnonymizeDICOMDataUnparseableDate(t *testing.T) {
+	ds := &dicom.DataSet{}
+	ds.Put(dicom.NewStringElement(dicom.TagStudyDate, dicom.VRDA, "2024-03-12"))
+	out, record, err := AnonymizeDICOMData(ds, DICOMAnonymizationOptions{DateShiftDays: testDateShift})
+	if err != nil {
+		t.Fatalf("AnonymizeDICOMData: %v", err)
+	}
+	if e, ok := out.FindElement(dicom.TagStudyDate); !ok || !isEmpty(e) {
+		t.Error("an unparseable Study Date was not emptied")
+	}
+	if got := record.Counts()[ActionZero]; got != 1 {
+		t.Errorf("%d attributes recorded as emptied, want 1", got)
+	}
+}
@@ -0,0 +1,123 @@
+// internal/security/phi_patterns.go
+package security
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PHICategory names the kind of protected health information a piece of text was matched as.
+// Categories are safe to log and audit; the matched text itself never is.
+type PHICategory string
+
+const (
+	PHIKnownIdentifier PHICategory = "known_identifier" // A value taken from the source's own identifying attributes (e.g. the DICOM Patient Name)
+	PHILabelledField   PHICategory = "labelled_field"   // A caption such as "Name:", "MRN" or "DOB:" that introduces an identifier
+	PHIDate            PHICategory = "date"
+	PHISSN             PHICategory = "ssn"
+	PHIPhone           PHICategory = "phone"
+	PHIEmail           PHICategory = "email"
+	PHIIdentifier      PHICategory = "identifier" // Long digit runs such as medical record or accession numbers
+)
+
+// minKnownTokenLength is the shortest identifier token matched on its own; shorter tokens (initials, "JR")
+// would match ordinary annotation text far too often.
+const minKnownTokenLength = 3
+
+// phiPatterns are checked in order; the first match determines the category.
+var phiPatterns = []struct {
+	category PHICategory
+	pattern  *regexp.Regexp
+}{
+	{PHISSN, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{PHIEmail, regexp.MustCompile(`(?i)\b[A-Z0-9._%+-]+@[A-Z0-9.-]+\.[A-Z]{2,}\b`)},
+	{PHIPhone, regexp.MustCompile(`(?:\(\d{3}\)\s*|\b\d{3}[-. ])\d{3}[-. ]\d{4}\b`)},
+	{PHILabelledField, regexp.MustCompile(`(?i)\b(?:patient|pt|name|mrn|dob|d\.o\.b|birth(?:\s*date)?|accession|acc|ssn|address|id)\s*(?:no\.?|number|#)?\s*[:#]`)},
+	{PHIDate, regexp.MustCompile(`(?i)\b\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}\b|\b(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\b|\b\d{1,2}[- ]?(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*[- ,]*\d{2,4}\b`)},
+	{PHIIdentifier, regexp.MustCompile(`(?i)\b[A-Z]{0,4}\d{6,}\b`)},
+}
+
+// PHIMatcher recognizes text that likely contains PHI: generic patterns (dates, phone numbers, long identifiers,
+// captions such as "Name:") plus, optionally, the identifying values known from the source itself.
+// It is used to find burned-in annotations in OCR output, where text arrives without any structure.
+type PHIMatcher struct {
+	knownTokens []string // Upper-case alphanumeric tokens of known names, compared token by token
+	knownIDs    []string // Upper-case alphanumeric identifiers, also found inside longer runs (OCR often drops separators)
+	knownOnly   bool     // Match the known values only, not the generic patterns
+}
+
+// NewPHIMatcher creates a matcher. knownValues are identifying values of the source (patient name, patient ID,
+// accession number, ...); person names in DICOM "FAMILY^GIVEN" form are split into their components.
+func NewPHIMatcher(knownValues ...string) *PHIMatcher {
+	m := &PHIMatcher{}
+	for _, value := range knownValues {
+		for _, token := range phiTokens(value) {
+			if len(token) < minKnownTokenLength {
+				continue
+			}
+			if strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+				m.knownIDs = append(m.knownIDs, token)
+			} else {
+				m.knownTokens = append(m.knownTokens, token)
+			}
+		}
+	}
+	return m
+}
+
+// NewKnownIdentifierMatcher creates a matcher of the known identifying values only. The generic patterns would also
+// match the dates, captions and numbers of clinical annotations, which must stay readable.
+func NewKnownIdentifierMatcher(knownValues ...string) *PHIMatcher {
+	m := NewPHIMatcher(knownValues...)
+	m.knownOnly = true
+	return m
+}
+
+// Match reports whether text contains likely PHI, and which category matched first.
+func (m *PHIMatcher) Match(text string) (PHICategory, bool) {
+	if strings.TrimSpace(text) == "" {
+		return "", false
+	}
+	if m.matchesKnown(text) {
+		return PHIKnownIdentifier, true
+	}
+	if m.knownOnly {
+		return "", false
+	}
+	for _, p := range phiPatterns {
+		if p.pattern.MatchString(text) {
+			return p.category, true
+		}
+	}
+	return "", false
+}
+
+// matchesKnown reports whether text contains one of the known identifying values.
+func (m *PHIMatcher) matchesKnown(text string) bool {
+	if len(m.knownTokens) == 0 && len(m.knownIDs) == 0 {
+		return false
+	}
+	tokens := phiTokens(text)
+	for _, token := range tokens {
+		for _, known := range m.knownTokens {
+			if token == known {
+				return true
+			}
+		}
+	}
+	joined := strings.Join(tokens, "")
+	for _, known := range m.knownIDs {
+		if strings.Contains(joined, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// phiTokens splits text into upper-case runs of letters and digits.
+func phiTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
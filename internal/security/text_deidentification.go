@@ -0,0 +1,359 @@
+// internal/security/text_deidentification.go
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Categories detected by the text de-identifier, in addition to those shared with PHIMatcher.
+const (
+	PHIName      PHICategory = "name"
+	PHIMRN       PHICategory = "mrn"
+	PHIAccession PHICategory = "accession"
+	PHIAddress   PHICategory = "address"
+	PHIAge       PHICategory = "age" // Ages over 89 (HIPAA Safe Harbor aggregates them into "90 or older")
+)
+
+// TextDeidMethod is how a detected category is replaced.
+type TextDeidMethod string
+
+const (
+	MethodRedact    TextDeidMethod = "redact"     // Replace with a category placeholder such as "[NAME]"
+	MethodSurrogate TextDeidMethod = "surrogate"  // Replace with a realistic fake value, the same for the same original under one key
+	MethodDateShift TextDeidMethod = "date_shift" // Move dates by a fixed number of days, keeping their format and intervals; dates only
+)
+
+// TextDeidentifierOptions configures a TextDeidentifier.
+type TextDeidentifierOptions struct {
+	Methods       map[PHICategory]TextDeidMethod // Method per category; categories not listed are redacted
+	DateShiftDays int                            // Offset for MethodDateShift; 0 derives an offset from SurrogateKey
+	SurrogateKey  []byte                         // Key making surrogates consistent (e.g. per session); nil uses a random per-process key
+	KnownNames    []string                       // Names known to belong to the patient (e.g. from a DICOM header), matched anywhere
+}
+
+// TextSpan records one replacement. Offsets count characters (runes), not bytes; the original text is never kept.
+type TextSpan struct {
+	Start       int            `json:"start"`        // Offset of the replaced text in the input
+	End         int            `json:"end"`          // End offset (exclusive) in the input
+	OutputStart int            `json:"output_start"` // Offset of the replacement in the output
+	OutputEnd   int            `json:"output_end"`   // End offset (exclusive) of the replacement in the output
+	Category    PHICategory    `json:"category"`
+	Method      TextDeidMethod `json:"method"`
+	Replacement string         `json:"replacement"`
+}
+
+// DeidentifiedText is the result of TextDeidentifier.Deidentify.
+type DeidentifiedText struct {
+	Text  string
+	Spans []TextSpan // Ordered by Start
+}
+
+// TextDeidentifier detects PHI in free text (reports, OCR output) with rules: header cues and a given-name
+// dictionary for names, labelled MRNs and accession numbers, and patterns for dates, phone and fax numbers,
+// e-mail and street addresses, SSNs and ages over 89. It is deterministic for a given configuration.
+type TextDeidentifier struct {
+	opts       TextDeidentifierOptions
+	knownNames map[string]bool
+}
+
+// NewTextDeidentifier creates a TextDeidentifier.
+func NewTextDeidentifier(opts TextDeidentifierOptions) *TextDeidentifier {
+	if opts.SurrogateKey == nil {
+		opts.SurrogateKey = defaultSurrogateKey
+	}
+	if opts.DateShiftDays == 0 {
+		opts.DateShiftDays = derivedDateShift(opts.SurrogateKey)
+	}
+	d := &TextDeidentifier{opts: opts, knownNames: map[string]bool{}}
+	for _, name := range opts.KnownNames {
+		for _, token := range phiTokens(name) {
+			if len(token) >= minKnownTokenLength && strings.IndexFunc(token, unicode.IsDigit) < 0 {
+				d.knownNames[token] = true
+			}
+		}
+	}
+	return d
+}
+
+// ParseTextDeidMethods parses a comma-separated "category=method" list (e.g. "name=surrogate,date=date_shift"),
+// the format of the DEID_TEXT_METHODS setting. An empty string yields an empty map (redact everything).
+func ParseTextDeidMethods(s string) (map[PHICategory]TextDeidMethod, error) {
+	methods := map[PHICategory]TextDeidMethod{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		category, method, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid de-identification method %q: expected category=method", pair)
+		}
+		c, m := PHICategory(strings.TrimSpace(category)), TextDeidMethod(strings.TrimSpace(method))
+		if !textCategories[c] {
+			return nil, fmt.Errorf("unknown PHI category %q", c)
+		}
+		switch m {
+		case MethodRedact, MethodSurrogate:
+		case MethodDateShift:
+			if c != PHIDate {
+				return nil, fmt.Errorf("method %q only applies to %q, not %q", m, PHIDate, c)
+			}
+		default:
+			return nil, fmt.Errorf("unknown de-identification method %q", m)
+		}
+		methods[c] = m
+	}
+	return methods, nil
+}
+
+// textMatch is a detected PHI occurrence, in byte offsets.
+type textMatch struct {
+	start, end int
+	category   PHICategory
+}
+
+// textRule detects one category. If group is non-zero only that submatch is replaced (the cue is kept).
+type textRule struct {
+	category PHICategory
+	pattern  *regexp.Regexp
+	group    int
+}
+
+const (
+	monthNames   = `(?:Jan(?:uary)?|Feb(?:ruary)?|Mar(?:ch)?|Apr(?:il)?|May|June?|July?|Aug(?:ust)?|Sep(?:t(?:ember)?)?|Oct(?:ober)?|Nov(?:ember)?|Dec(?:ember)?)`
+	nameToken    = `[A-Z][A-Za-z'\-]+`
+	nameSequence = `(?:[A-Z]\.[ \t]*)*` + nameToken + `(?:,?[ \t]+(?:[A-Z]\.|` + nameToken + `)){0,3}`
+)
+
+// textRules are applied in order; a match overlapping an earlier one is dropped, so more specific rules come first.
+var textRules = []textRule{
+	{PHISSN, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), 0},
+	{PHIEmail, regexp.MustCompile(`(?i)\b[A-Z0-9._%+-]+@[A-Z0-9.-]+\.[A-Z]{2,}\b`), 0},
+	{PHIPhone, regexp.MustCompile(`(?:\+?1[-. ]?)?(?:\(\d{3}\)\s*|\b\d{3}[-. ])\d{3}[-. ]\d{4}\b(?:\s*(?:x|ext\.?)\s*\d{1,5})?`), 0},
+	{PHIMRN, regexp.MustCompile(`(?i)\b(?:MRN|MR\s*#|medical\s+record(?:\s+(?:number|no\.?))?|patient\s+ID|hospital\s+(?:number|no\.?))\s*[:#]?\s*([A-Z0-9][A-Z0-9-]{3,})`), 1},
+	{PHIAccession, regexp.MustCompile(`(?i)\b(?:accession|acc)\s*(?:number|no\.?|#)?\s*[:#]?\s*([A-Z0-9][A-Z0-9-]{3,})`), 1},
+	{PHIAddress, regexp.MustCompile(`\b\d{1,6}\s+(?:[NSEW]\.?\s+)?(?:[A-Z][a-z]+\.?\s+){1,4}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl|Terrace|Ter|Parkway|Pkwy|Circle|Cir|Highway|Hwy)\b\.?(?:,?\s*(?:Apt|Suite|Ste|Unit|#)\.?\s*[A-Za-z0-9-]+)?`), 0},
+	{PHIAddress, regexp.MustCompile(`(?i)\bP\.?\s*O\.?\s+Box\s+\d+\b`), 0},
+	{PHIAddress, regexp.MustCompile(`\b[A-Z][a-z]+(?:\s[A-Z][a-z]+){0,2},\s*[A-Z]{2}\s+\d{5}(?:-\d{4})?\b`), 0},
+	{PHIDate, regexp.MustCompile(`\b\d{4}-\d{1,2}-\d{1,2}\b|\b\d{1,2}[/-]\d{1,2}[/-](?:\d{4}|\d{2})\b`), 0},
+	{PHIDate, regexp.MustCompile(`(?i)\b` + monthNames + `\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}\b|\b\d{1,2}(?:st|nd|rd|th)?\s+` + monthNames + `\.?,?\s+\d{4}\b|\b` + monthNames + `\.?,?\s+\d{4}\b`), 0},
+	{PHIAge, regexp.MustCompile(`(?i)\b(9\d|1[0-4]\d)(?:\s*|-)(?:years?|yrs?|y/?o|year-old)\b`), 1},
+	{PHIAge, regexp.MustCompile(`(?i)\bage(?:d|:)?\s*(9\d|1[0-4]\d)\b`), 1},
+	{PHIName, regexp.MustCompile(`(?i:\b(?:patient(?:\s+name)?|name|pt|attending|referring(?:\s+physician)?|physician|radiologist|pathologist|(?:electronically\s+)?signed\s+by|dictated\s+by|cc)\s*:\s*)(` + nameSequence + `)`), 1},
+	{PHIName, regexp.MustCompile(`\b(?:Dr|Mr|Mrs|Ms|Miss|Prof)\.?\s+(` + nameSequence + `)`), 1},
+	{PHIIdentifier, regexp.MustCompile(`\b\d{7,}\b`), 0},
+}
+
+// textCategories is the set of categories the de-identifier can emit (used to validate configuration).
+var textCategories = func() map[PHICategory]bool {
+	m := map[PHICategory]bool{}
+	for _, rule := range textRules {
+		m[rule.category] = true
+	}
+	return m
+}()
+
+// Deidentify replaces every detected PHI occurrence in text.
+func (d *TextDeidentifier) Deidentify(text string) *DeidentifiedText {
+	var accepted []textMatch
+	overlaps := func(m textMatch) bool {
+		for _, a := range accepted {
+			if m.start < a.end && a.start < m.end {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(m textMatch) {
+		if m.end > m.start && !overlaps(m) {
+			accepted = append(accepted, m)
+		}
+	}
+
+	for _, rule := range textRules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if rule.group > 0 {
+				start, end = loc[2*rule.group], loc[2*rule.group+1]
+			}
+			if rule.category == PHIName {
+				end = start + trimNameStopWords(text[start:end])
+			}
+			add(textMatch{start: start, end: end, category: rule.category})
+		}
+	}
+	for _, m := range d.findDictionaryNames(text) {
+		add(m)
+	}
+
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i].start < accepted[j].start })
+
+	var out strings.Builder
+	result := &DeidentifiedText{Spans: make([]TextSpan, 0, len(accepted))}
+	last, lastRune, outRunes := 0, 0, 0
+	for _, m := range accepted {
+		before := text[last:m.start]
+		out.WriteString(before)
+		startRune := lastRune + utf8.RuneCountInString(before)
+		outRunes += utf8.RuneCountInString(before)
+
+		method, replacement := d.replace(m.category, text[m.start:m.end])
+		out.WriteString(replacement)
+		span := TextSpan{
+			Start:       startRune,
+			End:         startRune + utf8.RuneCountInString(text[m.start:m.end]),
+			OutputStart: outRunes,
+			OutputEnd:   outRunes + utf8.RuneCountInString(replacement),
+			Category:    m.category,
+			Method:      method,
+			Replacement: replacement,
+		}
+		result.Spans = append(result.Spans, span)
+		last, lastRune, outRunes = m.end, span.End, span.OutputEnd
+	}
+	out.WriteString(text[last:])
+	result.Text = out.String()
+	return result
+}
+
+// findDictionaryNames finds names without a header cue: a dictionary given name followed by a capitalized
+// surname ("Mary Smith", "Mary A. Smith"), a surname followed by a comma and a given name ("Smith, Mary"),
+// and any token of the configured known names.
+func (d *TextDeidentifier) findDictionaryNames(text string) []textMatch {
+	var matches []textMatch
+	for _, loc := range capitalizedWordPattern.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		upper := strings.ToUpper(word)
+		if d.knownNames[upper] {
+			matches = append(matches, textMatch{start: loc[0], end: loc[1], category: PHIName})
+			continue
+		}
+		if !givenNames[upper] {
+			continue
+		}
+		if next := givenNameFollower.FindStringSubmatchIndex(text[loc[1]:]); next != nil && !nameStopWords[strings.ToUpper(text[loc[1]+next[2]:loc[1]+next[3]])] {
+			matches = append(matches, textMatch{start: loc[0], end: loc[1] + next[1], category: PHIName})
+			continue
+		}
+		if prev := surnameBeforeComma.FindStringSubmatchIndex(text[:loc[0]]); prev != nil && !nameStopWords[strings.ToUpper(text[prev[2]:prev[3]])] {
+			matches = append(matches, textMatch{start: prev[2], end: loc[1], category: PHIName})
+		}
+	}
+	return matches
+}
+
+var (
+	capitalizedWordPattern = regexp.MustCompile(`\b[A-Z][A-Za-z'\-]+\b`)
+	givenNameFollower      = regexp.MustCompile(`^(?:[ \t]+[A-Z]\.)?[ \t]+([A-Z][A-Za-z'\-]+)`)
+	surnameBeforeComma     = regexp.MustCompile(`\b([A-Z][A-Za-z'\-]+),[ \t]+$`)
+	nameTokenPattern       = regexp.MustCompile(`\b[A-Z][A-Za-z'\-]*\.?`)
+)
+
+// trimNameStopWords returns the length of the leading part of a cued name that consists of name-like tokens,
+// cutting at the first ordinary word or title (e.g. "John Smith Presents" and "John Smith MD" keep "John Smith").
+func trimNameStopWords(s string) int {
+	end := 0
+	for _, loc := range nameTokenPattern.FindAllStringIndex(s, -1) {
+		token := s[loc[0]:loc[1]]
+		isInitial := len(token) == 2 && token[1] == '.'
+		if !isInitial && nameStopWords[strings.ToUpper(strings.TrimSuffix(token, "."))] {
+			break
+		}
+		end = loc[0] + len(strings.TrimSuffix(token, ".")) // A trailing period ends a sentence unless it marks an initial
+		if isInitial {
+			end = loc[1]
+		}
+	}
+	return end
+}
+
+// replace returns the method applied and the replacement for one detected occurrence.
+func (d *TextDeidentifier) replace(category PHICategory, original string) (TextDeidMethod, string) {
+	method := d.opts.Methods[category]
+	switch {
+	case method == MethodDateShift && category == PHIDate:
+		if shifted, ok := shiftDate(original, d.opts.DateShiftDays); ok {
+			return MethodDateShift, shifted
+		}
+	case method == MethodSurrogate:
+		if surrogate, ok := d.surrogate(category, original); ok {
+			return MethodSurrogate, surrogate
+		}
+	}
+	return MethodRedact, "[" + strings.ToUpper(string(category)) + "]"
+}
+
+// surrogate returns a fake value of the same kind, chosen by a keyed hash of the original so that repeated
+// occurrences get the same surrogate. Surrogates use reserved or never-issued ranges where those exist.
+func (d *TextDeidentifier) surrogate(category PHICategory, original string) (string, bool) {
+	mac := hmac.New(sha256.New, d.opts.SurrogateKey)
+	mac.Write([]byte(category))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToUpper(strings.TrimSpace(original))))
+	sum := mac.Sum(nil)
+	n := binary.BigEndian.Uint64(sum[:8])
+
+	switch category {
+	case PHIName:
+		return surrogateGivenNames[n%uint64(len(surrogateGivenNames))] + " " + surrogateSurnames[(n>>16)%uint64(len(surrogateSurnames))], true
+	case PHIMRN, PHIAccession, PHIIdentifier:
+		return surrogateDigits(sum[8:], len(original)), true
+	case PHIPhone:
+		return fmt.Sprintf("555-01%02d", n%100), true // 555-0100 to 555-0199 are reserved for fictional use
+	case PHISSN:
+		return fmt.Sprintf("000-00-%04d", n%10000), true // Area number 000 is never issued
+	case PHIEmail:
+		return fmt.Sprintf("patient%04d@example.org", n%10000), true
+	case PHIAddress:
+		return fmt.Sprintf("%d %s", 100+n%900, surrogateStreets[(n>>16)%uint64(len(surrogateStreets))]), true
+	case PHIAge:
+		return "90+", true
+	case PHIDate:
+		return shiftDate(original, d.opts.DateShiftDays)
+	}
+	return "", false
+}
+
+// surrogateDigits returns length pseudo-random digits drawn from seed.
+func surrogateDigits(seed []byte, length int) string {
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		b.WriteByte('0' + seed[i%len(seed)]%10)
+		if i%len(seed) == len(seed)-1 {
+			seed = sha256Sum(seed)
+		}
+	}
+	return b.String()
+}
+
+func sha256Sum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+// derivedDateShift returns a date offset between -365 and -1 days derived from key.
+func derivedDateShift(key []byte) int {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("date_shift"))
+	return -1 - int(binary.BigEndian.Uint32(mac.Sum(nil)[:4])%365)
+}
+
+// defaultSurrogateKey keys surrogates when no key is configured; random per process.
+var defaultSurrogateKey = newRandomUIDMapper().key
+
+// defaultTextDeidentifier backs AnonymizeText: every category is redacted.
+var defaultTextDeidentifier = NewTextDeidentifier(TextDeidentifierOptions{})
+
+// AnonymizeText redacts PHI from free text with the default rules (every category replaced by a placeholder
+// such as "[NAME]"). Use a TextDeidentifier for surrogates, date shifting or the replaced spans.
+func AnonymizeText(text string) string {
+	return defaultTextDeidentifier.Deidentify(text).Text
+}
@@ -0,0 +1,126 @@
+// internal/security/text_deidentification_test.go
+package security
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeTextDetects(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"cued name", "Patient: John Smith", "Patient: [NAME]"},
+		{"cued name, family name first", "Name: SMITH, JOHN A.", "Name: [NAME]"},
+		{"dictionary given name", "Mary Johnson presented with cough.", "[NAME] presented with cough."},
+		{"surname, given name", "Smith, Mary was seen.", "[NAME] was seen."},
+		{"title", "Dr. Alan Turing reviewed.", "Dr. [NAME] reviewed."},
+		{"signature with initial and degree", "Signed by: Jane Q. Doe MD", "Signed by: [NAME] MD"},
+		{"MRN", "MRN: 12345678", "MRN: [MRN]"},
+		{"alphanumeric MRN", "MRN A1234567", "MRN [MRN]"},
+		{"medical record number", "Medical record number: 00123456", "Medical record number: [MRN]"},
+		{"patient ID", "Patient ID: X9Y8Z7", "Patient ID: [MRN]"},
+		{"accession number", "Accession #: ACC20240312", "Accession #: [ACCESSION]"},
+		{"phone, area code in parentheses", "Call (555) 123-4567", "Call [PHONE]"},
+		{"phone with extension", "Phone 555-123-4567 ext. 12", "Phone [PHONE]"},
+		{"phone with country code", "+1 555.123.4567", "[PHONE]"},
+		{"e-mail", "Write to jane.doe@example.com today", "Write to [EMAIL] today"},
+		{"SSN", "SSN 123-45-6789", "SSN [SSN]"},
+		{"street address", "Lives at 1234 Elm Street, Apt 5", "Lives at [ADDRESS]"},
+		{"abbreviated street", "Address: 42 N Main St.", "Address: [ADDRESS]"},
+		{"post office box", "P.O. Box 1234", "[ADDRESS]"},
+		{"city, state and ZIP code", "Springfield, IL 62704", "[ADDRESS]"},
+		{"ISO date", "Seen 2024-03-12.", "Seen [DATE]."},
+		{"US date", "Seen 03/12/2024.", "Seen [DATE]."},
+		{"short US date", "Seen 3/12/24.", "Seen [DATE]."},
+		{"dashed date", "Seen 12-03-2024.", "Seen [DATE]."},
+		{"month name first", "Seen March 12, 2024.", "Seen [DATE]."},
+		{"day first", "Seen 12 March 2024.", "Seen [DATE]."},
+		{"abbreviated month with ordinal", "Seen Mar. 12th, 2024", "Seen [DATE]"},
+		{"month and year", "Seen March 2024", "Seen [DATE]"},
+		{"age over 89", "Aged 92 years", "Aged [AGE] years"},
+		{"long identifier", "Ref 98765432", "Ref [IDENTIFIER]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnonymizeText(tt.text); got != tt.want {
+				t.Errorf("AnonymizeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizeTextKeepsClinicalText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"TNM stage", "Stage T2N1M0, pT1aN0M0, cT1c N2 M1b (Stage IIIA)"},
+		{"measurements", "A 12 mm nodule, a 1.2 cm mass and a 12mm lymph node; 8 x 6 mm"},
+		{"lab values", "Hemoglobin 13.5 g/dL, Creatinine 1.1 mg/dL, WBC 7.2 x10^9/L, Platelets 250 x10^3/uL, CEA 5.1 ng/mL"},
+		{"PET and biomarkers", "SUVmax 4.2, PD-L1 TPS 60%, Ki-67 30%, EGFR exon 19 deletion, ALK negative"},
+		{"acquisition details", "Series 3 Image 45, slice 1.25 mm, 120 kVp, Lung-RADS 4B"},
+		{"age under 90", "Patient is 45 years old"},
+		{"follow-up interval", "Follow-up CT in 3 months; compared with prior study"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnonymizeText(tt.text); got != tt.text {
+				t.Errorf("AnonymizeText(%q) = %q, want it unchanged", tt.text, got)
+			}
+		})
+	}
+}
+
+func TestTextDeidentifierMethods(t *testing.T) {
+	d := NewTextDeidentifier(TextDeidentifierOptions{
+		Methods:       map[PHICategory]TextDeidMethod{PHIName: MethodSurrogate, PHIDate: MethodDateShift, PHIPhone: MethodSurrogate},
+		DateShiftDays: -30,
+		SurrogateKey:  []byte("session secret"),
+		KnownNames:    []string{"OKONKWO^ADAEZE"},
+	})
+	text := "Patient: John Smith, seen 2024-03-12 by Okonkwo; call 555-123-4567. John Smith returns March 12, 2024."
+	result := d.Deidentify(text)
+
+	for _, original := range []string{"John Smith", "2024-03-12", "Okonkwo", "555-123-4567", "March 12, 2024"} {
+		if strings.Contains(result.Text, original) {
+			t.Errorf("%q survived in %q", original, result.Text)
+		}
+	}
+	for _, want := range []string{"2024-02-11", "February 11, 2024", "555-01"} {
+		if !strings.Contains(result.Text, want) {
+			t.Errorf("%q missing from %q", want, result.Text)
+		}
+	}
+
+	var names []string
+	runes, output := []rune(text), []rune(result.Text)
+	for _, span := range result.Spans {
+		if got := string(output[span.OutputStart:span.OutputEnd]); got != span.Replacement {
+			t.Errorf("span %+v points at %q in the output", span, got)
+		}
+		if span.Category == PHIName && string(runes[span.Start:span.End]) == "John Smith" {
+			names = append(names, span.Replacement)
+		}
+	}
+	if len(names) != 2 || names[0] != names[1] {
+		t.Errorf("surrogates for the two occurrences of one name: %q, want the same one twice", names)
+	}
+}
+
+func TestParseTextDeidMethods(t *testing.T) {
+	methods, err := ParseTextDeidMethods(" name=surrogate, date=date_shift ,")
+	if err != nil {
+		t.Fatalf("ParseTextDeidMethods: %v", err)
+	}
+	if len(methods) != 2 || methods[PHIName] != MethodSurrogate || methods[PHIDate] != MethodDateShift {
+		t.Errorf("ParseTextDeidMethods = %v", methods)
+	}
+	for _, invalid := range []string{"name", "colour=redact", "name=blur", "name=date_shift"} {
+		if _, err := ParseTextDeidMethods(invalid); err == nil {
+			t.Errorf("ParseTextDeidMethods(%q) succeeded", invalid)
+		}
+	}
+}
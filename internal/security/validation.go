@@ -101,7 +101,9 @@ func isValidContentType(contentType string) bool {
 		"application/pdf":         true,
 		"image/jpeg":              true,
 		"image/png":               true,
+		"text/csv":                true, // Lab result exports
 		"text/csv; charset=utf-8": true, // Explicitly handle CSV with charset for broader compatibility
+		"application/hl7-v2":      true, // HL7 v2 result messages
 	}
 	return allowedTypes[contentType]
 }
@@ -314,6 +314,27 @@ func (e *ErrFileSizeExceeded) SetLogger(logger *zap.Logger) {
 	e.logger = logger
 }
 
+// ErrArchiveLimitExceeded represents an uploaded archive that breaks one of the extraction limits
+// (entry count, uncompressed size, compression ratio or entry path) and is rejected as a whole.
+type ErrArchiveLimitExceeded struct {
+	Filename string
+	Reason   string
+	logger   *zap.Logger
+}
+
+func (e *ErrArchiveLimitExceeded) Error() string {
+	return fmt.Sprintf("archive '%s' rejected: %s", e.Filename, e.Reason)
+}
+func (e *ErrArchiveLimitExceeded) Unwrap() error {
+	return nil
+}
+func NewErrArchiveLimitExceeded(filename string, reason string) error {
+	return &ErrArchiveLimitExceeded{Filename: filename, Reason: reason, logger: nil}
+}
+func (e *ErrArchiveLimitExceeded) SetLogger(logger *zap.Logger) {
+	e.logger = logger
+}
+
 // Is function for Data Access Errors
 func (e *ErrDBConnection) Is(target error) bool {
 	_, ok := target.(*ErrDBConnection)
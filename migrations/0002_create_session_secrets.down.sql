@@ -0,0 +1,3 @@
+-- 0002_create_session_secrets.down.sql
+
+DROP TABLE IF EXISTS sessionsecret;
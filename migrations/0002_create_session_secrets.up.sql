@@ -0,0 +1,13 @@
+-- 0002_create_session_secrets.up.sql
+
+-- Create the 'sessionsecret' table (per-session pseudonymization secrets)
+-- Holds the random secrets from which a session's pseudonyms are derived (e.g. remapped DICOM UIDs).
+-- No original-to-pseudonym map is ever stored: pseudonyms are recomputed from the secret, and the secret
+-- itself is stored encrypted (AES-GCM with FILE_ENCRYPTION_KEY). Deleting the row makes the pseudonyms unlinkable.
+CREATE TABLE sessionsecret (
+    session_id UUID NOT NULL,                -- Patient session the secret belongs to
+    purpose VARCHAR(64) NOT NULL,            -- What the secret is used for (e.g., "uid_remap")
+    encrypted_secret BYTEA NOT NULL,         -- Nonce-prefixed AES-GCM ciphertext of the secret
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (session_id, purpose)        -- One secret per session and purpose
+);
@@ -0,0 +1,11 @@
+-- 0003_create_prompt_registry.down.sql
+
+DROP TRIGGER IF EXISTS promptevent_guard ON promptevent;
+DROP FUNCTION IF EXISTS promptevent_guard();
+DROP TABLE IF EXISTS promptevent;
+DROP TRIGGER IF EXISTS prompts_guard_history ON prompts;
+DROP FUNCTION IF EXISTS prompts_guard_history();
+DROP INDEX IF EXISTS idx_prompts_one_active;
+ALTER TABLE prompts DROP CONSTRAINT IF EXISTS prompts_description_version_unique;
+ALTER TABLE prompts DROP CONSTRAINT IF EXISTS prompts_status_valid;
+ALTER TABLE prompts DROP CONSTRAINT IF EXISTS prompts_version_numeric;
@@ -0,0 +1,66 @@
+-- 0003_create_prompt_registry.up.sql
+
+-- Prompt registry (services.PromptRegistryService, gemini.DBPromptManager)
+-- Each row of 'prompts' is one version of a managed prompt: 'description' holds the prompt ID (e.g. "staging_information"),
+-- 'version' a sequence number per prompt, 'input_variables' and 'output_format' the JSON of gemini.PromptVariable and
+-- gemini.OutputFormat. Versions move draft -> pending_review -> approved -> active; activating a version makes the
+-- previously active one inactive. Only drafts may change or be deleted: reviewed versions form an immutable history.
+-- The constraints are NOT VALID so that rows written before the registry existed are not rejected.
+ALTER TABLE prompts ADD CONSTRAINT prompts_version_numeric CHECK (version ~ '^[1-9][0-9]*$') NOT VALID;
+ALTER TABLE prompts ADD CONSTRAINT prompts_status_valid CHECK (status IN ('draft', 'pending_review', 'approved', 'active', 'inactive')) NOT VALID;
+ALTER TABLE prompts ADD CONSTRAINT prompts_description_version_unique UNIQUE (description, version);
+CREATE UNIQUE INDEX idx_prompts_one_active ON prompts(description) WHERE status = 'active'; -- At most one active version per prompt
+
+-- Create the 'promptevent' table (append-only lifecycle history of prompt versions)
+CREATE TABLE promptevent (
+    event_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_id UUID NOT NULL REFERENCES prompts(prompt_id), -- Version the event applies to
+    action VARCHAR(32) NOT NULL,             -- create, edit, submit, approve, reject, activate, deactivate, rollback
+    actor VARCHAR(255) NOT NULL,             -- Admin user who performed the action
+    from_status VARCHAR(32),                 -- Status before the action (NULL on create)
+    to_status VARCHAR(32) NOT NULL,          -- Status after the action
+    comment TEXT,                            -- Reviewer or rollback comment
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_promptevent_prompt ON promptevent(prompt_id);
+
+-- Reviewed versions are immutable: only the lifecycle columns of a non-draft version may change, and only drafts may be deleted
+CREATE FUNCTION prompts_guard_history() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.status <> 'draft' THEN
+            RAISE EXCEPTION 'prompt % version % is %, only drafts can be deleted', OLD.description, OLD.version, OLD.status;
+        END IF;
+        RETURN OLD;
+    END IF;
+    IF OLD.status <> 'draft' AND (NEW.description IS DISTINCT FROM OLD.description
+        OR NEW.template IS DISTINCT FROM OLD.template
+        OR NEW.input_variables IS DISTINCT FROM OLD.input_variables
+        OR NEW.output_format IS DISTINCT FROM OLD.output_format
+        OR NEW.version IS DISTINCT FROM OLD.version
+        OR NEW.author IS DISTINCT FROM OLD.author) THEN
+        RAISE EXCEPTION 'prompt % version % is %, only drafts can be edited', OLD.description, OLD.version, OLD.status;
+    END IF;
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prompts_guard_history BEFORE UPDATE OR DELETE ON prompts
+    FOR EACH ROW EXECUTE FUNCTION prompts_guard_history();
+
+-- Events are append-only, and a version cannot be approved by its author
+CREATE FUNCTION promptevent_guard() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        RAISE EXCEPTION 'prompt events are append-only';
+    END IF;
+    IF NEW.action = 'approve' AND EXISTS (SELECT 1 FROM prompts WHERE prompt_id = NEW.prompt_id AND author = NEW.actor) THEN
+        RAISE EXCEPTION 'prompt versions cannot be approved by their author';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER promptevent_guard BEFORE INSERT OR UPDATE OR DELETE ON promptevent
+    FOR EACH ROW EXECUTE FUNCTION promptevent_guard();
@@ -0,0 +1,8 @@
+-- 0004_create_prompt_experiments.down.sql
+
+DROP TABLE IF EXISTS promptfeedback;
+DROP TABLE IF EXISTS promptcall;
+DROP TABLE IF EXISTS promptexperiment;
+ALTER TABLE findings DROP COLUMN IF EXISTS prompt_version_id;
+ALTER TABLE stages DROP COLUMN IF EXISTS prompt_version_id;
+ALTER TABLE diagnosis DROP COLUMN IF EXISTS prompt_version_id;
@@ -0,0 +1,51 @@
+-- 0004_create_prompt_experiments.up.sql
+
+-- Prompt version of every stored model output (NULL: rendered from a prompt file, or stored before versions were recorded)
+ALTER TABLE diagnosis ADD COLUMN prompt_version_id UUID REFERENCES prompts(prompt_id);
+ALTER TABLE stages ADD COLUMN prompt_version_id UUID REFERENCES prompts(prompt_id);
+ALTER TABLE findings ADD COLUMN prompt_version_id UUID REFERENCES prompts(prompt_id);
+
+-- Create the 'promptexperiment' table (A/B experiments between the active version of a prompt and an approved candidate)
+-- While an experiment runs, each session is assigned to the control or treatment version by a hash of its session ID.
+CREATE TABLE promptexperiment (
+    experiment_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_name TEXT NOT NULL,                                           -- Prompt ID, as in prompts.description
+    control_prompt_id UUID NOT NULL REFERENCES prompts(prompt_id),      -- Active version when the experiment started
+    treatment_prompt_id UUID NOT NULL REFERENCES prompts(prompt_id),    -- Approved candidate version
+    treatment_percent INTEGER NOT NULL CHECK (treatment_percent BETWEEN 1 AND 99), -- Share of sessions assigned to the treatment
+    status VARCHAR(32) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'stopped')),
+    started_by VARCHAR(255) NOT NULL,
+    stopped_by VARCHAR(255),
+    stop_reason TEXT,
+    started_at timestamp with time zone NOT NULL DEFAULT now(),
+    stopped_at timestamp with time zone
+);
+CREATE UNIQUE INDEX idx_promptexperiment_one_running ON promptexperiment(prompt_name) WHERE status = 'running'; -- At most one running experiment per prompt
+
+-- Create the 'promptcall' table (outcome of every model call made with a managed prompt)
+-- Calls are not linked to sessions, so the metrics outlive the session data they were computed from.
+CREATE TABLE promptcall (
+    call_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    prompt_name TEXT NOT NULL,
+    prompt_version_id UUID REFERENCES prompts(prompt_id),              -- NULL for prompt files
+    experiment_id UUID REFERENCES promptexperiment(experiment_id),     -- NULL outside experiments
+    arm VARCHAR(32),                                                   -- control, treatment (NULL outside experiments)
+    outcome VARCHAR(32) NOT NULL CHECK (outcome IN ('success', 'parse_failure', 'error')),
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_promptcall_prompt_created ON promptcall(prompt_name, created_at);
+CREATE INDEX idx_promptcall_experiment ON promptcall(experiment_id);
+
+-- Create the 'promptfeedback' table (clinician ratings of stored model outputs)
+CREATE TABLE promptfeedback (
+    feedback_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    target_type VARCHAR(32) NOT NULL CHECK (target_type IN ('diagnosis', 'stage', 'nodule')),
+    target_id UUID NOT NULL,                                           -- diagnosis.id, stages.id or findings.finding_id (not a FK: outputs are deleted with their session)
+    prompt_name TEXT NOT NULL,
+    prompt_version_id UUID REFERENCES prompts(prompt_id),              -- Version the rated output was generated with (NULL for prompt files)
+    reviewer VARCHAR(255) NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),           -- 1 (wrong) to 5 (fully agree)
+    comment TEXT,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_promptfeedback_prompt_created ON promptfeedback(prompt_name, created_at);
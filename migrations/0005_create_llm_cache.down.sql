@@ -0,0 +1,3 @@
+-- 0005_create_llm_cache.down.sql
+
+DROP TABLE IF EXISTS llmcache;
@@ -0,0 +1,12 @@
+-- 0005_create_llm_cache.up.sql
+
+-- Create the 'llmcache' table (model responses cached by gemini.CachingClient when LLM_CACHE_BACKEND is 'postgres')
+-- Bodies are encrypted with FILE_ENCRYPTION_KEY; keys are SHA-256 hashes of the request and reveal nothing about it.
+CREATE TABLE llmcache (
+    cache_key CHAR(64) PRIMARY KEY,                                    -- Hex SHA-256 of method, model, prompt version and input
+    method VARCHAR(64) NOT NULL,                                       -- GeminiClient method, for inspection and bulk invalidation
+    body BYTEA NOT NULL,                                               -- AES-GCM encrypted JSON response
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    expires_at timestamp with time zone NOT NULL
+);
+CREATE INDEX idx_llmcache_expires ON llmcache(expires_at);
@@ -0,0 +1,3 @@
+-- 0006_create_llm_usage.down.sql
+
+DROP TABLE IF EXISTS llmusage;
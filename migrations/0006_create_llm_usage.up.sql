@@ -0,0 +1,19 @@
+-- 0006_create_llm_usage.up.sql
+
+-- Create the 'llmusage' table (tokens and latency of every model call, recorded by gemini.MeteredClient)
+-- Usage is kept when a session's data is deleted: it carries no patient data and the daily budget counts it.
+CREATE TABLE llmusage (
+    usage_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID,                                                   -- Session the call was made for (not a FK: sessions are deleted); NULL if unknown
+    method VARCHAR(64) NOT NULL,                                       -- GeminiClient method
+    prompt_name TEXT NOT NULL,                                         -- Prompt ID, as in prompts.description; empty for calls without a managed prompt
+    prompt_version INTEGER NOT NULL DEFAULT 0,                         -- Prompt registry version; 0 for prompt files
+    prompt_version_id UUID REFERENCES prompts(prompt_id),              -- NULL for prompt files
+    prompt_tokens INTEGER NOT NULL,                                    -- Summed over retries
+    output_tokens INTEGER NOT NULL,                                    -- Summed over retries
+    latency_ms INTEGER NOT NULL,                                       -- Retries and backoff included
+    outcome VARCHAR(32) NOT NULL CHECK (outcome IN ('success', 'parse_failure', 'error')),
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_llmusage_created ON llmusage(created_at);
+CREATE INDEX idx_llmusage_session ON llmusage(session_id);
@@ -0,0 +1,3 @@
+-- 0007_create_report_pages.down.sql
+
+DROP TABLE IF EXISTS reportpages;
@@ -0,0 +1,12 @@
+-- 0007_create_report_pages.up.sql
+
+-- Create the 'reportpages' table (how the text of each page of a PDF report was extracted)
+CREATE TABLE reportpages (
+    report_id UUID NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    page_number INTEGER NOT NULL,                                      -- 1-based
+    extraction_method VARCHAR(16) NOT NULL CHECK (extraction_method IN ('text_layer', 'ocr', 'none')),
+    confidence DOUBLE PRECISION,                                       -- OCR confidence (0.0-1.0); NULL for text layers and empty pages
+    char_count INTEGER NOT NULL,                                       -- Characters of extracted text, before de-identification
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (report_id, page_number)
+);
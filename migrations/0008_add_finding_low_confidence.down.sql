@@ -0,0 +1,3 @@
+-- 0008_add_finding_low_confidence.down.sql
+
+ALTER TABLE findings DROP COLUMN IF EXISTS low_confidence;
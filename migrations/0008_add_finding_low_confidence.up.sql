@@ -0,0 +1,4 @@
+-- 0008_add_finding_low_confidence.up.sql
+
+-- Findings taken from text OCR read with low confidence, shown in the patient report as "please verify with the original"
+ALTER TABLE findings ADD COLUMN low_confidence BOOLEAN NOT NULL DEFAULT false;
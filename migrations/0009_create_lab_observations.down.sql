@@ -0,0 +1,3 @@
+-- 0009_create_lab_observations.down.sql
+
+DROP TABLE IF EXISTS labobservations;
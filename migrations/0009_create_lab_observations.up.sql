@@ -0,0 +1,20 @@
+-- 0009_create_lab_observations.up.sql
+
+-- Create the 'labobservations' table (normalized lab results read from uploaded lab exports)
+CREATE TABLE labobservations (
+    observation_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES patientsession(session_id) ON DELETE CASCADE,
+    source VARCHAR(16) NOT NULL,                                       -- Format of the upload (models.LabSource constants)
+    analyte TEXT NOT NULL,                                             -- Test name, as reported
+    code VARCHAR(64),                                                  -- Test code (e.g. LOINC), if reported
+    value TEXT NOT NULL,                                               -- As reported: "5.2", "<0.5", "Positive" (text values de-identified)
+    numeric_value DOUBLE PRECISION,                                    -- NULL for text and censored values
+    unit VARCHAR(32),                                                  -- Normalized spelling
+    reference_range TEXT,                                              -- As reported
+    reference_low DOUBLE PRECISION,
+    reference_high DOUBLE PRECISION,
+    flag VARCHAR(16) CHECK (flag IN ('low', 'high', 'normal', 'abnormal')), -- NULL if unknown
+    observed_at timestamp with time zone,                              -- Collection or result date, shifted by the session's date offset
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_labobservations_session ON labobservations(session_id);
@@ -0,0 +1,3 @@
+-- 0010_add_llm_cache_session.down.sql
+
+ALTER TABLE llmcache DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,6 @@
+-- 0010_add_llm_cache_session.up.sql
+
+-- Link every cached model response to the patient session it was generated for, so that deleting a session's data
+-- also deletes the responses derived from its documents. Responses of calls made outside a session have none.
+ALTER TABLE llmcache ADD COLUMN session_id UUID;
+CREATE INDEX idx_llmcache_session ON llmcache(session_id);
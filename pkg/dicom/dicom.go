@@ -1,33 +1,178 @@
 // pkg/dicom/dicom.go
 package dicom
 
-import "fmt"
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+)
 
-// DataSet is a placeholder for the DICOM data.  In a real implementation,
-// this would be a struct representing the parsed DICOM data (from a library
-// like github.com/suyashkumar/dicom).
+// DataSet is a parsed DICOM Part 10 object: the file meta information group plus the main dataset.
+// The Study/Series/SOP Instance UIDs are lifted into dedicated fields because every caller needs them;
+// everything else is reachable through FindElement and the typed module accessors (Patient, Study, Series,
+// Instance, Pixel, Geometry).
 type DataSet struct {
 	StudyInstanceUID  string
 	SeriesInstanceUID string
 	SOPInstanceUID    string
-	// Add other relevant fields from your DICOM parsing library
+
+	TransferSyntaxUID string     // Transfer syntax of the main dataset, from (0002,0010)
+	Meta              []*Element // File meta information (group 0002)
+	Elements          []*Element // Main dataset, in file order
 }
 
-// ParseFile is a placeholder for DICOM parsing.
+// ParseFile parses the DICOM Part 10 file at filePath. maxSize is a hard limit on the file size in bytes:
+// larger files are rejected with ErrFileTooLarge before any of their content is decoded.
 func ParseFile(filePath string, maxSize int64) (*DataSet, error) {
-	// TODO: Implement DICOM parsing using a library like github.com/suyashkumar/dicom
-	// For now, we just return a dummy DataSet and no error.
-	fmt.Println("Warning : pkg/dicom/dicom.go is using a placeholder.")
-	return &DataSet{}, nil // Return a dummy DataSet
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("dicom: opening file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("dicom: reading file info: %w", err)
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrFileTooLarge, info.Size(), maxSize)
+	}
+
+	return Parse(file, maxSize)
 }
 
-// ParseDicom is a function to parse DICOM files.
-// It should accept a file path as input and return a DataSet struct and an error.
-// It also should handle cases where parsing fails.
+// ParseDicom parses the DICOM Part 10 file at filePath. It is kept for existing callers and is equivalent to ParseFile.
 func ParseDicom(filePath string, maxSize int64) (*DataSet, error) {
-	// Placeholder implementation: return an empty DataSet and nil error.
-	// TODO: Replace with a real implementation using a DICOM parsing library.
-	fmt.Println("Warning : pkg/dicom/dicom.go is using a placeholder.")
-	return &DataSet{}, nil // Return a dummy DataSet
+	return ParseFile(filePath, maxSize)
+}
+
+// Parse reads a DICOM Part 10 stream from r. At most maxSize bytes are read; a stream that is
+// longer is rejected with ErrFileTooLarge. For the Deflated transfer syntax the inflated dataset
+// is held to the same limit, which protects against decompression bombs. maxSize must be positive.
+func Parse(r io.Reader, maxSize int64) (*DataSet, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("dicom: maxSize must be positive, got %d", maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1)) // Read one extra byte to detect oversize input
+	if err != nil {
+		return nil, fmt.Errorf("dicom: reading input: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%w: input exceeds limit of %d bytes", ErrFileTooLarge, maxSize)
+	}
+	return parseBytes(data, maxSize)
+}
+
+// parseBytes decodes a complete in-memory Part 10 object.
+func parseBytes(data []byte, maxSize int64) (*DataSet, error) {
+	if len(data) < preambleLength+len(magicPrefix) || string(data[preambleLength:preambleLength+len(magicPrefix)]) != magicPrefix {
+		return nil, ErrNotDICOM
+	}
+
+	p := &parser{buf: data, pos: preambleLength + len(magicPrefix), explicit: true}
+	meta, err := p.readMetaGroup()
+	if err != nil {
+		return nil, fmt.Errorf("dicom: reading file meta information: %w", err)
+	}
+
+	ds := &DataSet{Meta: meta}
+	if el, ok := findElement(meta, TagTransferSyntaxUID); ok {
+		ds.TransferSyntaxUID = el.StringValue()
+	}
+
+	switch ds.TransferSyntaxUID {
+	case ImplicitVRLittleEndian:
+		p.explicit = false
+	case DeflatedExplicitVRLittleEndian:
+		// Everything after the meta group is a raw deflate stream of an Explicit VR Little Endian dataset
+		inflated, err := inflate(data[p.pos:], maxSize)
+		if err != nil {
+			return nil, err
+		}
+		p = &parser{buf: inflated, explicit: true}
+	case ExplicitVRBigEndian:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTransferSyntax, ds.TransferSyntaxUID)
+	case "":
+		return nil, fmt.Errorf("%w: file meta information has no transfer syntax", ErrUnsupportedTransferSyntax)
+	default:
+		// Explicit VR Little Endian, and every encapsulated (compressed) transfer syntax,
+		// which encode the dataset itself as Explicit VR Little Endian.
+		p.explicit = true
+	}
+
+	elements, _, err := p.readElements(len(p.buf), false)
+	if err != nil {
+		return nil, err
+	}
+	ds.Elements = elements
+	ds.RefreshIdentifiers()
+	return ds, nil
+}
+
+// inflate decompresses a Deflated Explicit VR Little Endian dataset, refusing to produce more than maxSize bytes.
+func inflate(compressed []byte, maxSize int64) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+
+	inflated, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("dicom: inflating deflated dataset: %w", err)
+	}
+	if int64(len(inflated)) > maxSize {
+		return nil, fmt.Errorf("%w: inflated dataset exceeds limit of %d bytes", ErrFileTooLarge, maxSize)
+	}
+	return inflated, nil
+}
+
+// RefreshIdentifiers copies the Study/Series/SOP Instance UIDs from the dataset into the
+// convenience fields. Call it after modifying those elements.
+func (ds *DataSet) RefreshIdentifiers() {
+	ds.StudyInstanceUID = ds.GetString(TagStudyInstanceUID)
+	ds.SeriesInstanceUID = ds.GetString(TagSeriesInstanceUID)
+	ds.SOPInstanceUID = ds.GetString(TagSOPInstanceUID)
+}
+
+// FindElement returns the top-level element with the given tag. File meta tags (group 0002)
+// are looked up in the meta group.
+func (ds *DataSet) FindElement(tag Tag) (*Element, bool) {
+	if tag.Group() == 0x0002 {
+		return findElement(ds.Meta, tag)
+	}
+	return findElement(ds.Elements, tag)
+}
+
+// GetString returns the trimmed string value of a top-level element, or "" if it is absent.
+func (ds *DataSet) GetString(tag Tag) string {
+	if el, ok := ds.FindElement(tag); ok {
+		return el.StringValue()
+	}
+	return ""
+}
+
+// GetStrings returns all values of a multi-valued top-level string element.
+func (ds *DataSet) GetStrings(tag Tag) []string {
+	if el, ok := ds.FindElement(tag); ok {
+		return el.Strings()
+	}
+	return nil
+}
+
+// GetInt returns the first integer value of a top-level element; ok is false if the element is absent or unparseable.
+func (ds *DataSet) GetInt(tag Tag) (int, bool) {
+	if el, ok := ds.FindElement(tag); ok {
+		if values := el.Ints(); len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return 0, false
+}
 
+// GetFloat64s returns the numeric values of a top-level element (e.g. the three components of Image Position (Patient)).
+func (ds *DataSet) GetFloat64s(tag Tag) []float64 {
+	if el, ok := ds.FindElement(tag); ok {
+		return el.Float64s()
+	}
+	return nil
 }
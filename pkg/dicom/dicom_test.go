@@ -0,0 +1,281 @@
+// pkg/dicom/dicom_test.go
+package dicom
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	testStudyUID = "1.2.826.0.1.3680043.2.1143.1"
+	testLimit    = 1 << 20
+)
+
+// explicit encodes an Explicit VR Little Endian element.
+func explicit(tag Tag, vr VR, value []byte) []byte {
+	return explicitHeader(tag, vr, uint32(len(value)), value)
+}
+
+// explicitHeader encodes an Explicit VR element header of the given length, followed by value.
+func explicitHeader(tag Tag, vr VR, length uint32, value []byte) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag.Group())
+	b = binary.LittleEndian.AppendUint16(b, tag.Element())
+	b = append(b, vr...)
+	if vr.hasLongLength() {
+		b = append(b, 0, 0)
+		b = binary.LittleEndian.AppendUint32(b, length)
+	} else {
+		b = binary.LittleEndian.AppendUint16(b, uint16(length))
+	}
+	return append(b, value...)
+}
+
+// implicit encodes an Implicit VR Little Endian element, or an item or delimiter when tag is one of those.
+func implicit(tag Tag, length uint32, value []byte) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag.Group())
+	b = binary.LittleEndian.AppendUint16(b, tag.Element())
+	b = binary.LittleEndian.AppendUint32(b, length)
+	return append(b, value...)
+}
+
+// item encodes a defined-length item.
+func item(elements ...[]byte) []byte {
+	body := bytes.Join(elements, nil)
+	return implicit(TagItem, uint32(len(body)), body)
+}
+
+// undefinedItem encodes an item of undefined length, closed by an Item Delimitation Item.
+func undefinedItem(elements ...[]byte) []byte {
+	b := implicit(TagItem, undefinedLength, bytes.Join(elements, nil))
+	return append(b, implicit(TagItemDelimitationItem, 0, nil)...)
+}
+
+// sequenceEnd is the Sequence Delimitation Item closing a sequence of undefined length.
+var sequenceEnd = implicit(TagSequenceDelimitationItem, 0, nil)
+
+// text pads a string value to an even length, as PS3.5 requires.
+func text(s string) []byte {
+	if len(s)%2 == 1 {
+		s += " "
+	}
+	return []byte(s)
+}
+
+// part10 wraps a dataset in the preamble, the DICM prefix and a file meta group declaring transferSyntax.
+func part10(transferSyntax string, dataset ...[]byte) []byte {
+	b := make([]byte, preambleLength)
+	b = append(b, magicPrefix...)
+	uid := []byte(transferSyntax)
+	if len(uid)%2 == 1 {
+		uid = append(uid, 0) // UI values are padded with NUL
+	}
+	b = append(b, explicit(TagTransferSyntaxUID, VRUI, uid)...)
+	return append(b, bytes.Join(dataset, nil)...)
+}
+
+// deflated is part10 with the dataset compressed as the Deflated Explicit VR Little Endian transfer syntax requires.
+func deflated(dataset ...[]byte) []byte {
+	var compressed bytes.Buffer
+	w, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	w.Write(bytes.Join(dataset, nil))
+	w.Close()
+	return part10(DeflatedExplicitVRLittleEndian, compressed.Bytes())
+}
+
+func TestParse(t *testing.T) {
+	name := explicit(TagPatientName, VRPN, text("DOE^JANE"))
+	study := explicit(TagStudyInstanceUID, VRUI, text(testStudyUID))
+	code := explicit(0x00080100, VRSH, text("T-28000"))
+
+	tests := []struct {
+		name  string
+		data  []byte
+		check func(t *testing.T, ds *DataSet)
+	}{
+		{"explicit VR", part10(ExplicitVRLittleEndian, name, study), func(t *testing.T, ds *DataSet) {
+			if ds.StudyInstanceUID != testStudyUID || ds.GetString(TagPatientName) != "DOE^JANE" {
+				t.Errorf("study %q, patient %q", ds.StudyInstanceUID, ds.GetString(TagPatientName))
+			}
+		}},
+		{"implicit VR resolves VRs from the dictionary", part10(ImplicitVRLittleEndian,
+			implicit(0x00090010, 8, text("ACME 1.0")), implicit(0x00091010, 2, []byte{1, 2}), implicit(TagPatientName, 8, text("DOE^JANE"))),
+			func(t *testing.T, ds *DataSet) {
+				for tag, want := range map[Tag]VR{TagPatientName: VRPN, 0x00090010: VRLO, 0x00091010: VRUN} {
+					if e, ok := ds.FindElement(tag); !ok || e.VR != want {
+						t.Errorf("%s: VR %v, want %s", tag, e, want)
+					}
+				}
+				if got := ds.GetString(TagPatientName); got != "DOE^JANE" {
+					t.Errorf("Patient Name %q", got)
+				}
+			}},
+		{"implicit VR unknown attribute holding items is read as a sequence", part10(ImplicitVRLittleEndian,
+			implicit(0x00091020, uint32(len(item(implicit(TagPatientName, 8, text("DOE^JANE"))))), item(implicit(TagPatientName, 8, text("DOE^JANE"))))),
+			func(t *testing.T, ds *DataSet) {
+				e, _ := ds.FindElement(0x00091020)
+				if e == nil || e.VR != VRSQ || len(e.Items) != 1 {
+					t.Fatalf("private element %+v, want a sequence of one item", e)
+				}
+				if name, ok := e.Items[0].FindElement(TagPatientName); !ok || name.StringValue() != "DOE^JANE" {
+					t.Error("item content not parsed")
+				}
+			}},
+		{"defined-length sequence and items", part10(ExplicitVRLittleEndian,
+			explicit(TagReferencedImageSequence, VRSQ, append(item(code), item(code, name)...)), study),
+			func(t *testing.T, ds *DataSet) {
+				e, _ := ds.FindElement(TagReferencedImageSequence)
+				if e == nil || len(e.Items) != 2 || len(e.Items[1].Elements) != 2 {
+					t.Fatalf("sequence %+v, want 2 items", e)
+				}
+				if ds.StudyInstanceUID != testStudyUID {
+					t.Error("element after the sequence not parsed")
+				}
+			}},
+		{"undefined-length sequence and items, nested", part10(ExplicitVRLittleEndian,
+			explicitHeader(TagReferencedImageSequence, VRSQ, undefinedLength, bytes.Join([][]byte{
+				undefinedItem(code, explicitHeader(TagSourceImageSequence, VRSQ, undefinedLength, append(undefinedItem(name), sequenceEnd...))),
+				item(code),
+				sequenceEnd,
+			}, nil)), study),
+			func(t *testing.T, ds *DataSet) {
+				e, _ := ds.FindElement(TagReferencedImageSequence)
+				if e == nil || len(e.Items) != 2 {
+					t.Fatalf("sequence %+v, want 2 items", e)
+				}
+				inner, ok := e.Items[0].FindElement(TagSourceImageSequence)
+				if !ok || len(inner.Items) != 1 {
+					t.Fatalf("nested sequence %+v, want 1 item", inner)
+				}
+				if name, ok := inner.Items[0].FindElement(TagPatientName); !ok || name.StringValue() != "DOE^JANE" {
+					t.Error("nested item content not parsed")
+				}
+				if ds.StudyInstanceUID != testStudyUID {
+					t.Error("element after the sequence not parsed")
+				}
+			}},
+		{"empty undefined-length sequence", part10(ExplicitVRLittleEndian,
+			explicitHeader(TagReferencedImageSequence, VRSQ, undefinedLength, sequenceEnd), study),
+			func(t *testing.T, ds *DataSet) {
+				if e, ok := ds.FindElement(TagReferencedImageSequence); !ok || len(e.Items) != 0 || ds.StudyInstanceUID != testStudyUID {
+					t.Errorf("sequence %+v", e)
+				}
+			}},
+		{"explicit VR UN of undefined length is an implicit VR sequence", part10(ExplicitVRLittleEndian,
+			explicitHeader(0x00091030, VRUN, undefinedLength, append(undefinedItem(implicit(TagPatientName, 8, text("DOE^JANE"))), sequenceEnd...)), study),
+			func(t *testing.T, ds *DataSet) {
+				e, _ := ds.FindElement(0x00091030)
+				if e == nil || e.VR != VRSQ || len(e.Items) != 1 {
+					t.Fatalf("element %+v, want a sequence of one item", e)
+				}
+				if name, ok := e.Items[0].FindElement(TagPatientName); !ok || name.VR != VRPN || name.StringValue() != "DOE^JANE" {
+					t.Errorf("item element %+v, want an implicit VR Patient Name", name)
+				}
+				if ds.StudyInstanceUID != testStudyUID {
+					t.Error("explicit VR element after the sequence not parsed")
+				}
+			}},
+		{"encapsulated pixel data", part10(RLELossless,
+			explicitHeader(TagPixelData, VROB, undefinedLength, bytes.Join([][]byte{implicit(TagItem, 0, nil), implicit(TagItem, 4, []byte{1, 2, 3, 4}), sequenceEnd}, nil)), study),
+			func(t *testing.T, ds *DataSet) {
+				e, _ := ds.FindElement(TagPixelData)
+				if e == nil || len(e.Fragments) != 2 || len(e.Fragments[1]) != 4 || ds.StudyInstanceUID != testStudyUID {
+					t.Errorf("pixel data %+v, want an empty offset table and one fragment", e)
+				}
+			}},
+		{"deflated explicit VR", deflated(name, study), func(t *testing.T, ds *DataSet) {
+			if ds.StudyInstanceUID != testStudyUID || ds.GetString(TagPatientName) != "DOE^JANE" || ds.TransferSyntaxUID != DeflatedExplicitVRLittleEndian {
+				t.Errorf("study %q, patient %q, transfer syntax %q", ds.StudyInstanceUID, ds.GetString(TagPatientName), ds.TransferSyntaxUID)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := Parse(bytes.NewReader(tt.data), testLimit)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tt.check(t, ds)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	name := explicit(TagPatientName, VRPN, text("DOE^JANE"))
+	nested := explicit(0x00080100, VRSH, text("T-28000"))
+	for i := 0; i <= maxSequenceDepth; i++ {
+		nested = explicit(TagReferencedImageSequence, VRSQ, item(nested))
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		limit   int64
+		wantErr error  // Checked with errors.Is when set
+		wantMsg string // Checked against the message otherwise
+	}{
+		{"no DICM prefix", append(make([]byte, preambleLength), "DICX"...), testLimit, ErrNotDICOM, ""},
+		{"shorter than the preamble", []byte("DICM"), testLimit, ErrNotDICOM, ""},
+		{"big endian", part10(ExplicitVRBigEndian, name), testLimit, ErrUnsupportedTransferSyntax, ""},
+		{"no transfer syntax", append(append(make([]byte, preambleLength), magicPrefix...), name...), testLimit, ErrUnsupportedTransferSyntax, ""},
+		{"truncated value", part10(ExplicitVRLittleEndian, explicitHeader(TagPatientName, VRPN, 16, text("DOE^JANE"))), testLimit, errTruncated, ""},
+		{"truncated header", part10(ExplicitVRLittleEndian, name, []byte{0x10, 0x00, 0x20}), testLimit, errTruncated, ""},
+		{"truncated long length", part10(ExplicitVRLittleEndian, []byte{0xE0, 0x7F, 0x10, 0x00, 'O', 'W', 0, 0, 4}), testLimit, errTruncated, ""},
+		{"truncated implicit VR value", part10(ImplicitVRLittleEndian, implicit(TagPatientName, 100, text("DOE^JANE"))), testLimit, errTruncated, ""},
+		{"invalid VR", part10(ExplicitVRLittleEndian, explicit(TagPatientName, "pn", text("DOE^JANE"))), testLimit, nil, `invalid VR "pn"`},
+		{"sequence longer than the data", part10(ExplicitVRLittleEndian, explicitHeader(TagReferencedImageSequence, VRSQ, 64, item(name))), testLimit, errTruncated, ""},
+		{"item longer than its sequence", part10(ExplicitVRLittleEndian, explicit(TagReferencedImageSequence, VRSQ, implicit(TagItem, 64, name))), testLimit, errTruncated, ""},
+		{"undefined-length sequence without delimiter", part10(ExplicitVRLittleEndian, explicitHeader(TagReferencedImageSequence, VRSQ, undefinedLength, item(name))), testLimit, errTruncated, ""},
+		{"undefined-length item without delimiter", part10(ExplicitVRLittleEndian, explicitHeader(TagReferencedImageSequence, VRSQ, undefinedLength, implicit(TagItem, undefinedLength, name))), testLimit, errTruncated, ""},
+		{"item delimiter outside an item", part10(ExplicitVRLittleEndian, name, implicit(TagItemDelimitationItem, 0, nil)), testLimit, nil, "item delimiter outside of an item"},
+		{"sequence delimiter in a defined-length sequence", part10(ExplicitVRLittleEndian, explicit(TagReferencedImageSequence, VRSQ, append(item(name), sequenceEnd...))), testLimit, nil, "sequence delimiter in defined-length sequence"},
+		{"element instead of an item", part10(ExplicitVRLittleEndian, explicit(TagReferencedImageSequence, VRSQ, name)), testLimit, nil, "unexpected tag"},
+		{"sequences nested too deep", part10(ExplicitVRLittleEndian, nested), testLimit, nil, "sequence nesting exceeds"},
+		{"fragment longer than the data", part10(RLELossless, explicitHeader(TagPixelData, VROB, undefinedLength, implicit(TagItem, 64, nil))), testLimit, errTruncated, ""},
+		{"corrupt deflate stream", part10(DeflatedExplicitVRLittleEndian, []byte{0xFF, 0xFF, 0xFF, 0xFF}), testLimit, nil, "inflating deflated dataset"},
+		{"input above the size limit", part10(ExplicitVRLittleEndian, name), int64(len(part10(ExplicitVRLittleEndian, name)) - 1), ErrFileTooLarge, ""},
+		{"deflated dataset inflating above the size limit", deflated(explicit(TagPixelData, VROB, make([]byte, 64<<10))), 16 << 10, ErrFileTooLarge, ""},
+		{"no size limit", part10(ExplicitVRLittleEndian, name), 0, nil, "maxSize must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := Parse(bytes.NewReader(tt.data), tt.limit)
+			switch {
+			case err == nil:
+				t.Fatalf("Parse = %+v, want an error", ds)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("error %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && !strings.Contains(err.Error(), tt.wantMsg):
+				t.Errorf("error %v, want one containing %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseSizeLimit(t *testing.T) {
+	data := part10(ExplicitVRLittleEndian, explicit(TagPatientName, VRPN, text("DOE^JANE")))
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("Parse of an input exactly at the limit: %v", err)
+	}
+
+	dataset := explicit(TagPixelData, VROB, make([]byte, 64<<10))
+	if _, err := Parse(bytes.NewReader(deflated(dataset)), int64(len(dataset))); err != nil {
+		t.Errorf("Parse of a deflated dataset inflating exactly to the limit: %v", err)
+	}
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	name := explicit(TagPatientName, VRPN, text("DOE^JANE"))
+	data := part10(ExplicitVRLittleEndian, name, explicitHeader(TagStudyInstanceUID, VRUI, 64, nil))
+	_, err := Parse(bytes.NewReader(data), testLimit)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("error %v, want a *ParseError", err)
+	}
+	if want := len(data) - 8; parseErr.Offset != want || parseErr.Tag != TagStudyInstanceUID {
+		t.Errorf("ParseError at %d for %s, want offset %d for %s", parseErr.Offset, parseErr.Tag, want, TagStudyInstanceUID)
+	}
+}
@@ -0,0 +1,69 @@
+// pkg/dicom/dicomdir.go
+package dicom
+
+import (
+	"path"
+	"strings"
+)
+
+// DirectoryRecord is one record of a DICOMDIR that references a file on the media (PS3.3 F.3.2.2).
+type DirectoryRecord struct {
+	Type           string // Directory Record Type, e.g. "IMAGE", "SR DOCUMENT", "ENCAP DOC"
+	FileID         string // Referenced File ID as a slash-separated path relative to the DICOMDIR
+	SOPClassUID    string
+	SOPInstanceUID string
+}
+
+// IsDirectory reports whether the dataset is a DICOMDIR (Media Storage Directory Storage).
+func (ds *DataSet) IsDirectory() bool {
+	return ds.GetString(TagMediaStorageSOPClassUID) == MediaStorageDirectoryStorage
+}
+
+// DirectoryRecords returns every record of a DICOMDIR that references a file, in directory order.
+// PATIENT/STUDY/SERIES records carry no Referenced File ID and are skipped. File IDs are cleaned so that
+// they cannot escape the directory holding the DICOMDIR: components such as ".." or absolute paths are rejected.
+func (ds *DataSet) DirectoryRecords() []DirectoryRecord {
+	sequence, ok := ds.FindElement(TagDirectoryRecordSequence)
+	if !ok {
+		return nil
+	}
+
+	var records []DirectoryRecord
+	for _, item := range sequence.Items {
+		fileIDElement, ok := item.FindElement(TagReferencedFileID)
+		if !ok {
+			continue
+		}
+		fileID, ok := cleanFileID(fileIDElement.Strings())
+		if !ok {
+			continue
+		}
+		records = append(records, DirectoryRecord{
+			Type:           itemString(item, TagDirectoryRecordType),
+			FileID:         fileID,
+			SOPClassUID:    itemString(item, TagReferencedSOPClassUIDInFile),
+			SOPInstanceUID: itemString(item, TagReferencedSOPInstanceUIDInFile),
+		})
+	}
+	return records
+}
+
+// cleanFileID joins the components of a Referenced File ID (one CS value per path component) into a relative path.
+func cleanFileID(components []string) (string, bool) {
+	if len(components) == 0 {
+		return "", false
+	}
+	for _, c := range components {
+		if c == "" || c == "." || c == ".." || strings.ContainsAny(c, `/\:`) {
+			return "", false
+		}
+	}
+	return path.Join(components...), true
+}
+
+func itemString(item *Item, tag Tag) string {
+	if e, ok := item.FindElement(tag); ok {
+		return e.StringValue()
+	}
+	return ""
+}
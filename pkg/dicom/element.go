@@ -0,0 +1,132 @@
+// pkg/dicom/element.go
+package dicom
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// undefinedLength is the 0xFFFFFFFF length marker used by sequences, items and encapsulated pixel data.
+const undefinedLength uint32 = 0xFFFFFFFF
+
+// Element is a single parsed data element.
+// Exactly one of Value, Items or Fragments is populated, depending on the element's VR:
+// sequences carry Items, encapsulated Pixel Data carries Fragments, and everything else carries raw Value bytes.
+type Element struct {
+	Tag       Tag      // Attribute tag
+	VR        VR       // Value Representation (explicit, or resolved from the dictionary for Implicit VR data)
+	Value     []byte   // Raw little-endian value bytes, including any trailing padding
+	Items     []*Item  // Sequence items when VR is SQ
+	Fragments [][]byte // Encapsulated Pixel Data fragments; Fragments[0] is the Basic Offset Table (possibly empty)
+}
+
+// Item is one item of a sequence; it holds a nested list of elements.
+type Item struct {
+	Elements []*Element
+}
+
+// FindElement returns the element with the given tag directly inside the item.
+func (it *Item) FindElement(tag Tag) (*Element, bool) {
+	return findElement(it.Elements, tag)
+}
+
+// IsEncapsulated reports whether the element holds encapsulated (compressed) pixel data fragments.
+func (e *Element) IsEncapsulated() bool {
+	return e.Fragments != nil
+}
+
+// StringValue returns the element value as text with DICOM padding (trailing spaces and NULs) removed.
+// For non-string VRs it returns an empty string.
+func (e *Element) StringValue() string {
+	if !e.VR.IsString() && e.VR != VRUN {
+		return ""
+	}
+	return strings.TrimRight(string(e.Value), " \x00")
+}
+
+// Strings returns the individual values of a multi-valued string element, each trimmed of padding.
+func (e *Element) Strings() []string {
+	s := e.StringValue()
+	if s == "" {
+		return nil
+	}
+	if !e.VR.isMultiValued() {
+		return []string{s}
+	}
+	values := strings.Split(s, `\`)
+	for i, v := range values {
+		values[i] = strings.TrimSpace(strings.TrimRight(v, "\x00"))
+	}
+	return values
+}
+
+// Ints returns the element value as integers. IS values are parsed from text; US, SS, UL and SL
+// values are decoded from their little-endian binary form. Unparseable values are skipped.
+func (e *Element) Ints() []int {
+	var out []int
+	switch e.VR {
+	case VRIS:
+		for _, v := range e.Strings() {
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				out = append(out, n)
+			}
+		}
+	case VRUS:
+		for i := 0; i+2 <= len(e.Value); i += 2 {
+			out = append(out, int(binary.LittleEndian.Uint16(e.Value[i:])))
+		}
+	case VRSS:
+		for i := 0; i+2 <= len(e.Value); i += 2 {
+			out = append(out, int(int16(binary.LittleEndian.Uint16(e.Value[i:]))))
+		}
+	case VRUL:
+		for i := 0; i+4 <= len(e.Value); i += 4 {
+			out = append(out, int(binary.LittleEndian.Uint32(e.Value[i:])))
+		}
+	case VRSL:
+		for i := 0; i+4 <= len(e.Value); i += 4 {
+			out = append(out, int(int32(binary.LittleEndian.Uint32(e.Value[i:]))))
+		}
+	}
+	return out
+}
+
+// Float64s returns the element value as floating point numbers. DS (and IS) values are parsed from text;
+// FL and FD values are decoded from binary. Unparseable values are skipped.
+func (e *Element) Float64s() []float64 {
+	var out []float64
+	switch e.VR {
+	case VRDS, VRIS:
+		for _, v := range e.Strings() {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				out = append(out, f)
+			}
+		}
+	case VRFL:
+		for i := 0; i+4 <= len(e.Value); i += 4 {
+			out = append(out, float64(math.Float32frombits(binary.LittleEndian.Uint32(e.Value[i:]))))
+		}
+	case VRFD:
+		for i := 0; i+8 <= len(e.Value); i += 8 {
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(e.Value[i:])))
+		}
+	default:
+		for _, n := range e.Ints() {
+			out = append(out, float64(n))
+		}
+	}
+	return out
+}
+
+// findElement performs a linear search over a flat element list. Datasets are small enough
+// (typically a few hundred elements) that an index would not pay for itself.
+func findElement(elements []*Element, tag Tag) (*Element, bool) {
+	for _, el := range elements {
+		if el.Tag == tag {
+			return el, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,127 @@
+// pkg/dicom/modify.go
+package dicom
+
+import (
+	"sort"
+	"strings"
+)
+
+// NewStringElement builds an element of a string VR from its values, joined with backslashes and padded to an
+// even length (UI with a trailing NUL, every other VR with a trailing space) as PS3.5 6.2 requires.
+func NewStringElement(tag Tag, vr VR, values ...string) *Element {
+	e := &Element{Tag: tag, VR: vr}
+	e.SetStrings(values...)
+	return e
+}
+
+// SetStrings replaces the value of a string element, padding it to an even length.
+func (e *Element) SetStrings(values ...string) {
+	value := []byte(strings.Join(values, `\`))
+	if len(value)%2 == 1 {
+		if e.VR == VRUI {
+			value = append(value, 0x00)
+		} else {
+			value = append(value, ' ')
+		}
+	}
+	e.Value = value
+	e.Items = nil
+	e.Fragments = nil
+}
+
+// Put inserts e into the dataset, replacing any element with the same tag. Group 0002 elements go to the
+// file meta information. Elements are kept in ascending tag order, as PS3.5 7.1 requires.
+// Call RefreshIdentifiers afterwards if a Study/Series/SOP Instance UID was changed.
+func (ds *DataSet) Put(e *Element) {
+	if e.Tag.Group() == 0x0002 {
+		ds.Meta = putElement(ds.Meta, e)
+		return
+	}
+	ds.Elements = putElement(ds.Elements, e)
+}
+
+// Put inserts e into the item, replacing any element with the same tag.
+func (it *Item) Put(e *Element) {
+	it.Elements = putElement(it.Elements, e)
+}
+
+func putElement(elements []*Element, e *Element) []*Element {
+	i := sort.Search(len(elements), func(i int) bool { return elements[i].Tag >= e.Tag })
+	if i < len(elements) && elements[i].Tag == e.Tag {
+		elements[i] = e
+		return elements
+	}
+	elements = append(elements, nil)
+	copy(elements[i+1:], elements[i:])
+	elements[i] = e
+	return elements
+}
+
+// Clone returns a copy of the dataset whose elements and sequence items can be modified or removed without
+// affecting the original. Value bytes and pixel data fragments are shared, so replace them rather than
+// writing into them.
+func (ds *DataSet) Clone() *DataSet {
+	clone := *ds
+	clone.Meta = cloneElements(ds.Meta)
+	clone.Elements = cloneElements(ds.Elements)
+	return &clone
+}
+
+func cloneElements(elements []*Element) []*Element {
+	if elements == nil {
+		return nil
+	}
+	out := make([]*Element, len(elements))
+	for i, e := range elements {
+		c := *e
+		if e.Items != nil {
+			c.Items = make([]*Item, len(e.Items))
+			for j, item := range e.Items {
+				c.Items[j] = &Item{Elements: cloneElements(item.Elements)}
+			}
+		}
+		out[i] = &c
+	}
+	return out
+}
+
+// Subset returns a dataset holding only the main dataset elements with the given tags, with the identifiers and
+// transfer syntax of ds but without its file meta information. Elements are shared with ds, as by Clone.
+func (ds *DataSet) Subset(tags ...Tag) *DataSet {
+	keep := make(map[Tag]bool, len(tags))
+	for _, tag := range tags {
+		keep[tag] = true
+	}
+	subset := &DataSet{
+		StudyInstanceUID:  ds.StudyInstanceUID,
+		SeriesInstanceUID: ds.SeriesInstanceUID,
+		SOPInstanceUID:    ds.SOPInstanceUID,
+		TransferSyntaxUID: ds.TransferSyntaxUID,
+	}
+	for _, e := range ds.Elements {
+		if keep[e.Tag] {
+			subset.Elements = append(subset.Elements, e)
+		}
+	}
+	return subset
+}
+
+// Size returns the number of value bytes held by the dataset's elements, pixel data fragments and sequence items
+// included: roughly the memory the parsed dataset takes.
+func (ds *DataSet) Size() int64 {
+	return elementsSize(ds.Meta) + elementsSize(ds.Elements)
+}
+
+func elementsSize(elements []*Element) int64 {
+	var size int64
+	for _, e := range elements {
+		size += int64(len(e.Value))
+		for _, fragment := range e.Fragments {
+			size += int64(len(fragment))
+		}
+		for _, item := range e.Items {
+			size += elementsSize(item.Elements)
+		}
+	}
+	return size
+}
@@ -0,0 +1,181 @@
+// pkg/dicom/modules.go
+package dicom
+
+// PatientModule holds the identifying Patient Module attributes. These are PHI and must be
+// removed by the anonymization layer before anything is stored or sent to an external model.
+type PatientModule struct {
+	PatientName      string
+	PatientID        string
+	PatientBirthDate string // DA, YYYYMMDD
+	PatientSex       string
+	PatientAge       string // AS, e.g. "064Y"
+}
+
+// StudyModule holds General Study Module attributes.
+type StudyModule struct {
+	StudyInstanceUID       string
+	StudyID                string
+	StudyDate              string // DA, YYYYMMDD
+	StudyTime              string // TM, HHMMSS.FFFFFF
+	AccessionNumber        string
+	StudyDescription       string
+	ReferringPhysicianName string
+}
+
+// SeriesModule holds General Series Module attributes.
+type SeriesModule struct {
+	SeriesInstanceUID string
+	Modality          string
+	SeriesNumber      int
+	SeriesDescription string
+	BodyPartExamined  string
+	FrameOfReference  string // Frame of Reference UID; slices sharing it share a patient coordinate system
+}
+
+// InstanceModule holds SOP Common / General Image attributes identifying a single instance.
+type InstanceModule struct {
+	SOPClassUID    string
+	SOPInstanceUID string
+	InstanceNumber int
+	ContentDate    string
+	ContentTime    string
+	ImageType      []string
+}
+
+// PixelModule holds Image Pixel Module attributes plus the Modality LUT (rescale) and VOI LUT (window) values
+// required to interpret the stored pixel values.
+type PixelModule struct {
+	Rows                      int
+	Columns                   int
+	SamplesPerPixel           int
+	PhotometricInterpretation string
+	PlanarConfiguration       int
+	BitsAllocated             int
+	BitsStored                int
+	HighBit                   int
+	PixelRepresentation       int // 0 = unsigned, 1 = two's complement
+	NumberOfFrames            int
+	RescaleSlope              float64
+	RescaleIntercept          float64
+	WindowCenter              []float64
+	WindowWidth               []float64
+}
+
+// Geometry holds Image Plane Module attributes describing where the image sits in patient space.
+type Geometry struct {
+	ImagePositionPatient    [3]float64 // x, y, z of the centre of the first transmitted pixel, in mm
+	ImageOrientationPatient [6]float64 // Row and column direction cosines
+	PixelSpacing            [2]float64 // Row spacing, column spacing in mm
+	SliceThickness          float64
+	SliceLocation           float64
+	HasPosition             bool // ImagePositionPatient was present and well formed
+	HasOrientation          bool // ImageOrientationPatient was present and well formed
+	HasPixelSpacing         bool // PixelSpacing was present and well formed
+}
+
+// Patient returns the Patient Module attributes of the dataset.
+func (ds *DataSet) Patient() PatientModule {
+	return PatientModule{
+		PatientName:      ds.GetString(TagPatientName),
+		PatientID:        ds.GetString(TagPatientID),
+		PatientBirthDate: ds.GetString(TagPatientBirthDate),
+		PatientSex:       ds.GetString(TagPatientSex),
+		PatientAge:       ds.GetString(TagPatientAge),
+	}
+}
+
+// Study returns the General Study Module attributes of the dataset.
+func (ds *DataSet) Study() StudyModule {
+	return StudyModule{
+		StudyInstanceUID:       ds.GetString(TagStudyInstanceUID),
+		StudyID:                ds.GetString(TagStudyID),
+		StudyDate:              ds.GetString(TagStudyDate),
+		StudyTime:              ds.GetString(TagStudyTime),
+		AccessionNumber:        ds.GetString(TagAccessionNumber),
+		StudyDescription:       ds.GetString(TagStudyDescription),
+		ReferringPhysicianName: ds.GetString(TagReferringPhysicianName),
+	}
+}
+
+// Series returns the General Series Module attributes of the dataset.
+func (ds *DataSet) Series() SeriesModule {
+	seriesNumber, _ := ds.GetInt(TagSeriesNumber)
+	return SeriesModule{
+		SeriesInstanceUID: ds.GetString(TagSeriesInstanceUID),
+		Modality:          ds.GetString(TagModality),
+		SeriesNumber:      seriesNumber,
+		SeriesDescription: ds.GetString(TagSeriesDescription),
+		BodyPartExamined:  ds.GetString(TagBodyPartExamined),
+		FrameOfReference:  ds.GetString(TagFrameOfReferenceUID),
+	}
+}
+
+// Instance returns the SOP Common / General Image attributes of the dataset.
+func (ds *DataSet) Instance() InstanceModule {
+	instanceNumber, _ := ds.GetInt(TagInstanceNumber)
+	return InstanceModule{
+		SOPClassUID:    ds.GetString(TagSOPClassUID),
+		SOPInstanceUID: ds.GetString(TagSOPInstanceUID),
+		InstanceNumber: instanceNumber,
+		ContentDate:    ds.GetString(TagContentDate),
+		ContentTime:    ds.GetString(TagContentTime),
+		ImageType:      ds.GetStrings(TagImageType),
+	}
+}
+
+// Pixel returns the Image Pixel Module attributes of the dataset. Missing attributes take the
+// defaults PS3.3 implies: one sample per pixel, one frame, and an identity rescale (slope 1, intercept 0).
+func (ds *DataSet) Pixel() PixelModule {
+	pm := PixelModule{
+		SamplesPerPixel:           1,
+		NumberOfFrames:            1,
+		RescaleSlope:              1,
+		PhotometricInterpretation: ds.GetString(TagPhotometricInterpretation),
+		WindowCenter:              ds.GetFloat64s(TagWindowCenter),
+		WindowWidth:               ds.GetFloat64s(TagWindowWidth),
+	}
+	pm.Rows, _ = ds.GetInt(TagRows)
+	pm.Columns, _ = ds.GetInt(TagColumns)
+	if v, ok := ds.GetInt(TagSamplesPerPixel); ok && v > 0 {
+		pm.SamplesPerPixel = v
+	}
+	pm.PlanarConfiguration, _ = ds.GetInt(TagPlanarConfiguration)
+	pm.BitsAllocated, _ = ds.GetInt(TagBitsAllocated)
+	pm.BitsStored, _ = ds.GetInt(TagBitsStored)
+	pm.HighBit, _ = ds.GetInt(TagHighBit)
+	pm.PixelRepresentation, _ = ds.GetInt(TagPixelRepresentation)
+	if v, ok := ds.GetInt(TagNumberOfFrames); ok && v > 0 {
+		pm.NumberOfFrames = v
+	}
+	if v := ds.GetFloat64s(TagRescaleSlope); len(v) > 0 && v[0] != 0 {
+		pm.RescaleSlope = v[0]
+	}
+	if v := ds.GetFloat64s(TagRescaleIntercept); len(v) > 0 {
+		pm.RescaleIntercept = v[0]
+	}
+	return pm
+}
+
+// Geometry returns the Image Plane Module attributes of the dataset.
+func (ds *DataSet) Geometry() Geometry {
+	var g Geometry
+	if v := ds.GetFloat64s(TagImagePositionPatient); len(v) == 3 {
+		copy(g.ImagePositionPatient[:], v)
+		g.HasPosition = true
+	}
+	if v := ds.GetFloat64s(TagImageOrientationPatient); len(v) == 6 {
+		copy(g.ImageOrientationPatient[:], v)
+		g.HasOrientation = true
+	}
+	if v := ds.GetFloat64s(TagPixelSpacing); len(v) == 2 {
+		copy(g.PixelSpacing[:], v)
+		g.HasPixelSpacing = true
+	}
+	if v := ds.GetFloat64s(TagSliceThickness); len(v) > 0 {
+		g.SliceThickness = v[0]
+	}
+	if v := ds.GetFloat64s(TagSliceLocation); len(v) > 0 {
+		g.SliceLocation = v[0]
+	}
+	return g
+}
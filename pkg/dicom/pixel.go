@@ -0,0 +1,262 @@
+// pkg/dicom/pixel.go
+package dicom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoPixelData is returned when the dataset has no Pixel Data (7FE0,0010) element.
+	ErrNoPixelData = errors.New("dicom: dataset has no pixel data")
+	// ErrUnsupportedPixelData is returned for pixel encodings the decoder cannot handle (e.g. JPEG family transfer syntaxes).
+	ErrUnsupportedPixelData = errors.New("dicom: unsupported pixel data encoding")
+)
+
+const (
+	// maxFramePixels bounds the pixels of one frame (8192 × 8192, far above any CT slice or radiograph), so that
+	// crafted dimensions cannot make the decoder allocate gigabytes.
+	maxFramePixels = 8192 * 8192
+	// maxFrames bounds the frames of one object.
+	maxFrames = 4096
+	// maxObjectPixels bounds the pixels of all frames of one object together, so that many large frames cannot add
+	// up to gigabytes either.
+	maxObjectPixels = 8192 * 8192
+)
+
+// FrameBuffer holds every decoded frame of an image together with the attributes needed to interpret them.
+type FrameBuffer struct {
+	Rows                      int
+	Columns                   int
+	SamplesPerPixel           int
+	BitsAllocated             int
+	BitsStored                int
+	PixelRepresentation       int // 0 = unsigned, 1 = two's complement
+	PhotometricInterpretation string
+	RescaleSlope              float64
+	RescaleIntercept          float64
+	Frames                    []Frame
+}
+
+// Frame is one decoded frame.
+type Frame struct {
+	Index int // Zero-based frame number within the object
+
+	// Stored holds the stored pixel values (masked to BitsStored and sign-extended) of multi-sample (colour) images,
+	// Rows*Columns*SamplesPerPixel entries in row-major order with samples interleaved (planar data is converted to
+	// interleaved). It is nil for single-sample images, whose stored values are only kept rescaled, as Values.
+	Stored []int32
+
+	// Values holds the Modality LUT output (stored value*RescaleSlope + RescaleIntercept), one entry per pixel.
+	// For CT this is Hounsfield units. It is nil for multi-sample (colour) images, which have no rescale.
+	Values []float32
+}
+
+// PixelCount returns the number of pixels (not samples) in a single frame.
+func (fb *FrameBuffer) PixelCount() int {
+	return fb.Rows * fb.Columns
+}
+
+// DecodePixelData decodes every frame of the dataset's Pixel Data. Native (uncompressed) data, RLE Lossless and
+// data from the Deflated transfer syntax (inflated at parse time) are supported; other encapsulated syntaxes
+// return ErrUnsupportedPixelData.
+func (ds *DataSet) DecodePixelData() (*FrameBuffer, error) {
+	el, ok := ds.FindElement(TagPixelData)
+	if !ok {
+		return nil, ErrNoPixelData
+	}
+
+	pm := ds.Pixel()
+	if err := validatePixelModule(pm); err != nil {
+		return nil, err
+	}
+
+	fb := &FrameBuffer{
+		Rows:                      pm.Rows,
+		Columns:                   pm.Columns,
+		SamplesPerPixel:           pm.SamplesPerPixel,
+		BitsAllocated:             pm.BitsAllocated,
+		BitsStored:                pm.BitsStored,
+		PixelRepresentation:       pm.PixelRepresentation,
+		PhotometricInterpretation: pm.PhotometricInterpretation,
+		RescaleSlope:              pm.RescaleSlope,
+		RescaleIntercept:          pm.RescaleIntercept,
+	}
+
+	// Each frame is converted as soon as it is read, so that only one frame of native bytes is held at a time
+	frameSize := pm.Rows * pm.Columns * pm.SamplesPerPixel * (pm.BitsAllocated / 8)
+	switch {
+	case !el.IsEncapsulated():
+		if frameSize > len(el.Value)/pm.NumberOfFrames { // Division rather than multiplication: the frame count comes from the file
+			return nil, fmt.Errorf("dicom: pixel data holds %d bytes, expected %d bytes for each of %d frame(s)", len(el.Value), frameSize, pm.NumberOfFrames)
+		}
+		for i := 0; i < pm.NumberOfFrames; i++ {
+			raw := el.Value[i*frameSize : (i+1)*frameSize]
+			if pm.PlanarConfiguration == 1 && pm.SamplesPerPixel > 1 {
+				raw = interleavePlanes(raw, pm.Rows*pm.Columns, pm.SamplesPerPixel, pm.BitsAllocated/8)
+			}
+			fb.Frames = append(fb.Frames, decodeFrame(i, raw, pm))
+		}
+
+	case ds.TransferSyntaxUID == RLELossless:
+		fragments, err := frameFragments(el, pm.NumberOfFrames)
+		if err != nil {
+			return nil, err
+		}
+		for i, fragment := range fragments {
+			raw, err := decodeRLEFrame(fragment, pm.Rows*pm.Columns, pm.SamplesPerPixel, pm.BitsAllocated/8)
+			if err != nil {
+				return nil, fmt.Errorf("dicom: decoding RLE frame %d: %w", i, err)
+			}
+			fb.Frames = append(fb.Frames, decodeFrame(i, raw, pm))
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: transfer syntax %s", ErrUnsupportedPixelData, ds.TransferSyntaxUID)
+	}
+
+	return fb, nil
+}
+
+// decodeFrame converts the native bytes of a frame: single-sample images straight into modality values, colour
+// images into stored values.
+func decodeFrame(index int, raw []byte, pm PixelModule) Frame {
+	unpacker := newSampleUnpacker(pm)
+	count := len(raw) / unpacker.bytesPerSample
+	frame := Frame{Index: index}
+	if pm.SamplesPerPixel == 1 {
+		frame.Values = make([]float32, count)
+		for i := range frame.Values {
+			frame.Values[i] = float32(float64(unpacker.at(raw, i))*pm.RescaleSlope + pm.RescaleIntercept)
+		}
+		return frame
+	}
+	frame.Stored = make([]int32, count)
+	for i := range frame.Stored {
+		frame.Stored[i] = unpacker.at(raw, i)
+	}
+	return frame
+}
+
+// validatePixelModule rejects pixel descriptions the decoder cannot (or should not) allocate for.
+func validatePixelModule(pm PixelModule) error {
+	switch {
+	case pm.Rows <= 0 || pm.Columns <= 0:
+		return fmt.Errorf("dicom: invalid image dimensions %dx%d", pm.Rows, pm.Columns)
+	case pm.Rows > maxFramePixels/pm.Columns:
+		return fmt.Errorf("dicom: image dimensions %dx%d exceed %d pixels", pm.Rows, pm.Columns, maxFramePixels)
+	case pm.NumberOfFrames <= 0 || pm.NumberOfFrames > maxFrames:
+		return fmt.Errorf("dicom: invalid number of frames %d (at most %d are decoded)", pm.NumberOfFrames, maxFrames)
+	case pm.NumberOfFrames > maxObjectPixels/(pm.Rows*pm.Columns):
+		return fmt.Errorf("dicom: %d frames of %dx%d exceed %d pixels", pm.NumberOfFrames, pm.Rows, pm.Columns, maxObjectPixels)
+	case pm.BitsAllocated != 8 && pm.BitsAllocated != 16 && pm.BitsAllocated != 32:
+		return fmt.Errorf("%w: %d bits allocated", ErrUnsupportedPixelData, pm.BitsAllocated)
+	case pm.BitsStored <= 0 || pm.BitsStored > pm.BitsAllocated:
+		return fmt.Errorf("dicom: invalid bits stored %d for %d bits allocated", pm.BitsStored, pm.BitsAllocated)
+	case pm.SamplesPerPixel != 1 && pm.SamplesPerPixel != 3:
+		return fmt.Errorf("%w: %d samples per pixel", ErrUnsupportedPixelData, pm.SamplesPerPixel)
+	}
+	return nil
+}
+
+// sampleUnpacker reads little-endian native samples as stored values, honouring High Bit / Bits Stored masking and
+// Pixel Representation sign extension.
+type sampleUnpacker struct {
+	bytesPerSample int
+	shift          uint
+	mask, signBit  uint32
+	signed         bool
+}
+
+func newSampleUnpacker(pm PixelModule) sampleUnpacker {
+	highBit := pm.HighBit
+	if highBit <= 0 || highBit >= pm.BitsAllocated {
+		highBit = pm.BitsStored - 1
+	}
+	u := sampleUnpacker{
+		bytesPerSample: pm.BitsAllocated / 8,
+		shift:          uint(highBit + 1 - pm.BitsStored),
+		mask:           uint32(1)<<uint(pm.BitsStored) - 1,
+		signBit:        uint32(1) << uint(pm.BitsStored-1),
+		signed:         pm.PixelRepresentation == 1,
+	}
+	if pm.BitsStored == 32 {
+		u.mask = 0xFFFFFFFF
+	}
+	return u
+}
+
+// at returns the stored value of sample i of raw.
+func (u sampleUnpacker) at(raw []byte, i int) int32 {
+	var v uint32
+	switch u.bytesPerSample {
+	case 1:
+		v = uint32(raw[i])
+	case 2:
+		v = uint32(binary.LittleEndian.Uint16(raw[i*2:]))
+	case 4:
+		v = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	v = (v >> u.shift) & u.mask
+	if u.signed && v&u.signBit != 0 {
+		v |= ^u.mask // Sign-extend two's complement values
+	}
+	return int32(v)
+}
+
+// interleavePlanes converts colour-by-plane (R...G...B...) data into colour-by-pixel (RGBRGB...) order.
+func interleavePlanes(raw []byte, pixels, samples, bytesPerSample int) []byte {
+	out := make([]byte, len(raw))
+	for s := 0; s < samples; s++ {
+		for i := 0; i < pixels; i++ {
+			src := (s*pixels + i) * bytesPerSample
+			dst := (i*samples + s) * bytesPerSample
+			copy(out[dst:dst+bytesPerSample], raw[src:src+bytesPerSample])
+		}
+	}
+	return out
+}
+
+// frameFragments groups encapsulated fragments into one byte slice per frame. It uses the
+// common one-fragment-per-frame layout when possible and otherwise the Basic Offset Table.
+func frameFragments(el *Element, numFrames int) ([][]byte, error) {
+	if len(el.Fragments) < 2 {
+		return nil, fmt.Errorf("dicom: encapsulated pixel data has no fragments")
+	}
+	offsetTable, fragments := el.Fragments[0], el.Fragments[1:]
+
+	if len(fragments) == numFrames {
+		return fragments, nil
+	}
+	if numFrames == 1 {
+		var joined []byte
+		for _, f := range fragments {
+			joined = append(joined, f...)
+		}
+		return [][]byte{joined}, nil
+	}
+	if len(offsetTable) != numFrames*4 {
+		return nil, fmt.Errorf("dicom: %d fragments for %d frames and no usable basic offset table", len(fragments), numFrames)
+	}
+
+	// Offsets in the table are measured from the first byte of the first fragment's item tag,
+	// so each fragment accounts for its 8-byte item header as well as its value.
+	frames := make([][]byte, numFrames)
+	position := uint32(0)
+	frame := -1
+	for _, f := range fragments {
+		for frame+1 < numFrames && binary.LittleEndian.Uint32(offsetTable[(frame+1)*4:]) == position {
+			frame++
+		}
+		if frame < 0 {
+			return nil, fmt.Errorf("dicom: basic offset table does not start at the first fragment")
+		}
+		frames[frame] = append(frames[frame], f...)
+		position += 8 + uint32(len(f))
+	}
+	if frame != numFrames-1 {
+		return nil, fmt.Errorf("dicom: basic offset table references %d frames but fragments cover %d", numFrames, frame+1)
+	}
+	return frames, nil
+}
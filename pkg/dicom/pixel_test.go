@@ -0,0 +1,186 @@
+// pkg/dicom/pixel_test.go
+package dicom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// rleImage builds an RLE Lossless greyscale dataset of one frame, made of the given PackBits-encoded segments. The
+// segment count written in the header is the number of segments unless headerSegments is set.
+func rleImage(rows, columns, bitsAllocated, headerSegments int, segments ...[]byte) *DataSet {
+	ds := &DataSet{TransferSyntaxUID: RLELossless}
+	ds.Put(usElement(TagSamplesPerPixel, 1))
+	ds.Put(NewStringElement(TagPhotometricInterpretation, VRCS, "MONOCHROME2"))
+	ds.Put(usElement(TagRows, rows))
+	ds.Put(usElement(TagColumns, columns))
+	ds.Put(usElement(TagBitsAllocated, bitsAllocated))
+	ds.Put(usElement(TagBitsStored, bitsAllocated))
+	ds.Put(usElement(TagHighBit, bitsAllocated-1))
+	ds.Put(usElement(TagPixelRepresentation, 0))
+
+	if headerSegments == 0 {
+		headerSegments = len(segments)
+	}
+	frame := make([]byte, rleHeaderLength)
+	binary.LittleEndian.PutUint32(frame, uint32(headerSegments))
+	for i, segment := range segments {
+		binary.LittleEndian.PutUint32(frame[4+i*4:], uint32(len(frame)))
+		frame = append(frame, segment...)
+	}
+	ds.Put(&Element{Tag: TagPixelData, VR: VROB, Fragments: [][]byte{{}, frame}})
+	return ds
+}
+
+// literal is a PackBits literal run of the given bytes (at most 128).
+func literal(values ...byte) []byte {
+	return append([]byte{byte(len(values) - 1)}, values...)
+}
+
+// replicate is a PackBits replicate run of value, count times (2 to 128).
+func replicate(value byte, count int) []byte {
+	return []byte{byte(int8(1 - count)), value}
+}
+
+func TestDecodeRLE(t *testing.T) {
+	ramp := make([]byte, 16)
+	for i := range ramp {
+		ramp[i] = byte(i * 10)
+	}
+	tests := []struct {
+		name    string
+		data    *DataSet
+		want    []float32 // First values of the frame
+		wantErr string
+	}{
+		{"literal run", rleImage(4, 4, 8, 0, literal(ramp...)), []float32{0, 10, 20, 30}, ""},
+		{"replicate run", rleImage(4, 4, 8, 0, replicate(7, 16)), []float32{7, 7, 7, 7}, ""},
+		{"mixed runs", rleImage(4, 4, 8, 0, append(literal(1, 2, 3), replicate(9, 13)...)), []float32{1, 2, 3, 9, 9}, ""},
+		{"16-bit, most significant byte first", rleImage(2, 2, 16, 0, replicate(1, 4), literal(0, 1, 2, 255)), []float32{256, 257, 258, 511}, ""},
+		{"padded segment", rleImage(2, 2, 8, 0, append(literal(4, 3, 2, 1), 0x80)), []float32{4, 3, 2, 1}, ""},
+		{"short segment", rleImage(4, 4, 8, 0, append(literal(1, 2, 3, 4), 0x80)), nil, "segment decoded to 4 bytes, expected 16"},
+		{"truncated literal run", rleImage(4, 4, 8, 0, []byte{15, 1, 2, 3, 4, 5, 6, 7, 8}), nil, "literal run exceeds segment"},
+		{"too few segments for 16 bits", rleImage(2, 2, 16, 0, replicate(1, 4)), nil, "RLE frame has 1 segments, expected 2"},
+		{"more segments than samples × bytes", rleImage(2, 2, 8, 2, replicate(1, 4), replicate(2, 4)), nil, "RLE frame has 2 segments, expected 1"},
+		{"segment too small for its plane", rleImage(64, 64, 16, 0, bytes.Repeat(replicate(0, 128), 150), replicate(1, 128)), nil, "segment 1 holds 2 bytes, too few to decode to 4096"},
+		{"implausible expansion", rleImage(128, 128, 8, 0, bytes.Repeat(replicate(0, 128), 128)), nil, "more than 32 times its size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb, err := tt.data.DecodePixelData()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodePixelData: %v", err)
+			}
+			values := fb.Frames[0].Values
+			if len(values) != fb.PixelCount() {
+				t.Fatalf("%d values for %d pixels", len(values), fb.PixelCount())
+			}
+			for i, want := range tt.want {
+				if values[i] != want {
+					t.Errorf("value %d = %v, want %v", i, values[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeStoredValues(t *testing.T) {
+	tests := []struct {
+		name                string
+		bitsAllocated       int
+		bitsStored          int
+		pixelRepresentation int
+		slope, intercept    string
+		stored              []int // Sample values as written to the file
+		want                []float32
+	}{
+		{"unsigned 8-bit", 8, 8, 0, "", "", []int{0, 1, 128, 255}, []float32{0, 1, 128, 255}},
+		{"unsigned 12-bit ignores bits above High Bit", 16, 12, 0, "", "", []int{0x0123, 0xF123, 0x0FFF, 0x8000}, []float32{0x123, 0x123, 4095, 0}},
+		{"signed 12-bit", 16, 12, 1, "", "", []int{0x0000, 0x07FF, 0x0800, 0x0FFF}, []float32{0, 2047, -2048, -1}},
+		{"unsigned 16-bit", 16, 16, 0, "", "", []int{0, 32768, 65535, 1}, []float32{0, 32768, 65535, 1}},
+		{"signed 16-bit", 16, 16, 1, "", "", []int{0, 0x7FFF, 0x8000, 0xFFFF}, []float32{0, 32767, -32768, -1}},
+		{"CT stored as HU + 1024", 16, 12, 0, "1", "-1024", []int{0, 1024, 1064, 2024}, []float32{-1024, 0, 40, 1000}},
+		{"signed CT with slope", 16, 16, 1, "2", "-1000", []int{100, 0xFFFF, 500, 0}, []float32{-800, -1002, 0, -1000}},
+		{"fractional slope", 16, 16, 0, "0.5", "-10", []int{0, 20, 21, 1000}, []float32{-10, 0, 0.5, 490}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := syntheticImage{
+				rows: 1, columns: len(tt.stored), photometric: "MONOCHROME2",
+				bitsAllocated: tt.bitsAllocated, bitsStored: tt.bitsStored, pixelRepresentation: tt.pixelRepresentation,
+				slope: tt.slope, intercept: tt.intercept,
+				value: func(x, _, _ int) int { return tt.stored[x] },
+			}
+			fb, err := image.dataset().DecodePixelData()
+			if err != nil {
+				t.Fatalf("DecodePixelData: %v", err)
+			}
+			frame := fb.Frames[0]
+			if frame.Stored != nil {
+				t.Error("a greyscale frame kept its stored values as well as its modality values")
+			}
+			for i, want := range tt.want {
+				if frame.Values[i] != want {
+					t.Errorf("value %d = %v, want %v", i, frame.Values[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeColourKeepsStoredValues(t *testing.T) {
+	image := syntheticImage{
+		rows: 1, columns: 2, photometric: "RGB", bitsAllocated: 8, bitsStored: 8, planar: 1,
+		value: func(x, _, sample int) int { return x*100 + sample },
+	}
+	fb, err := image.dataset().DecodePixelData()
+	if err != nil {
+		t.Fatalf("DecodePixelData: %v", err)
+	}
+	frame := fb.Frames[0]
+	want := []int32{0, 1, 2, 100, 101, 102}
+	if frame.Values != nil || len(frame.Stored) != len(want) {
+		t.Fatalf("frame has %d stored and %d modality values, want %d stored only", len(frame.Stored), len(frame.Values), len(want))
+	}
+	for i := range want {
+		if frame.Stored[i] != want[i] {
+			t.Errorf("stored sample %d = %d, want %d (planes interleaved)", i, frame.Stored[i], want[i])
+		}
+	}
+}
+
+func TestDecodePixelDataLimits(t *testing.T) {
+	header := func(rows, columns, frames int) *DataSet {
+		ds := syntheticImage{rows: 1, columns: 1, photometric: "MONOCHROME2", bitsAllocated: 16, bitsStored: 16, value: func(int, int, int) int { return 0 }}.dataset()
+		ds.Put(usElement(TagRows, rows))
+		ds.Put(usElement(TagColumns, columns))
+		ds.Put(NewStringElement(TagNumberOfFrames, VRIS, strconv.Itoa(frames)))
+		return ds
+	}
+	tests := []struct {
+		name    string
+		data    *DataSet
+		wantErr string
+	}{
+		{"frame above the pixel limit", header(8192, 8193, 1), "exceed"},
+		{"frames adding up above the object limit", header(8192, 4096, 3), "3 frames of 8192x4096 exceed"},
+		{"too many frames", header(2, 2, maxFrames+1), "invalid number of frames"},
+		{"pixel data shorter than its frames", header(8192, 4096, 2), "pixel data holds 2 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.data.DecodePixelData(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
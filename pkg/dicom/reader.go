@@ -0,0 +1,323 @@
+// pkg/dicom/reader.go
+package dicom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	preambleLength   = 128    // Part 10 files start with a 128-byte preamble...
+	magicPrefix      = "DICM" // ...followed by the "DICM" prefix
+	maxSequenceDepth = 32     // Guards against stack exhaustion from maliciously nested sequences
+)
+
+var (
+	// ErrNotDICOM is returned when the input does not carry the Part 10 preamble and "DICM" prefix.
+	ErrNotDICOM = errors.New("dicom: missing DICM prefix, not a DICOM Part 10 file")
+	// ErrFileTooLarge is returned when the input exceeds the maxSize limit passed to the parser.
+	ErrFileTooLarge = errors.New("dicom: file exceeds maximum allowed size")
+	// ErrUnsupportedTransferSyntax is returned for transfer syntaxes the parser cannot decode (e.g. Explicit VR Big Endian).
+	ErrUnsupportedTransferSyntax = errors.New("dicom: unsupported transfer syntax")
+	// errTruncated is wrapped into a ParseError when an element runs past the end of the input.
+	errTruncated = errors.New("unexpected end of data")
+)
+
+// ParseError describes a malformed element, including the byte offset and tag at which parsing failed.
+type ParseError struct {
+	Offset int   // Byte offset of the element within the (inflated) input
+	Tag    Tag   // Tag being parsed when the error occurred (zero if the tag itself could not be read)
+	Err    error // Underlying cause
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("dicom: parsing element %s at offset %d: %v", e.Tag, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parser decodes little-endian datasets from an in-memory buffer. Reading the whole object
+// into memory is acceptable because callers always bound the input with maxSize.
+type parser struct {
+	buf      []byte
+	pos      int
+	explicit bool // Explicit VR (true) or Implicit VR (false) encoding of the current dataset
+	depth    int  // Current sequence nesting depth
+}
+
+func (p *parser) fail(offset int, tag Tag, err error) error {
+	return &ParseError{Offset: offset, Tag: tag, Err: err}
+}
+
+func (p *parser) remaining() int {
+	return len(p.buf) - p.pos
+}
+
+func (p *parser) readUint16() uint16 {
+	v := binary.LittleEndian.Uint16(p.buf[p.pos:])
+	p.pos += 2
+	return v
+}
+
+func (p *parser) readUint32() uint32 {
+	v := binary.LittleEndian.Uint32(p.buf[p.pos:])
+	p.pos += 4
+	return v
+}
+
+// peekTag returns the next tag without consuming it.
+func (p *parser) peekTag() (Tag, bool) {
+	if p.remaining() < 4 {
+		return 0, false
+	}
+	group := binary.LittleEndian.Uint16(p.buf[p.pos:])
+	element := binary.LittleEndian.Uint16(p.buf[p.pos+2:])
+	return NewTag(group, element), true
+}
+
+// readTag consumes and returns the next tag.
+func (p *parser) readTag() Tag {
+	group := p.readUint16()
+	element := p.readUint16()
+	return NewTag(group, element)
+}
+
+// readMetaGroup reads the group 0002 file meta information, which is always Explicit VR Little Endian.
+func (p *parser) readMetaGroup() ([]*Element, error) {
+	var meta []*Element
+	for {
+		tag, ok := p.peekTag()
+		if !ok || tag.Group() != 0x0002 {
+			return meta, nil
+		}
+		el, err := p.readElement()
+		if err != nil {
+			return nil, err
+		}
+		meta = append(meta, el)
+	}
+}
+
+// readElements reads consecutive elements until end is reached. When inItem is true, an Item
+// Delimitation Item terminates the list early and delimited reports that it was consumed.
+func (p *parser) readElements(end int, inItem bool) (elements []*Element, delimited bool, err error) {
+	for p.pos < end {
+		tag, ok := p.peekTag()
+		if !ok {
+			return nil, false, p.fail(p.pos, 0, errTruncated)
+		}
+		if tag == TagItemDelimitationItem {
+			if !inItem {
+				return nil, false, p.fail(p.pos, tag, errors.New("item delimiter outside of an item"))
+			}
+			if p.remaining() < 8 {
+				return nil, false, p.fail(p.pos, tag, errTruncated)
+			}
+			p.pos += 8 // Tag plus the (zero) length
+			return elements, true, nil
+		}
+		if tag == TagSequenceDelimitationItem {
+			return nil, false, p.fail(p.pos, tag, errors.New("sequence delimiter outside of a sequence"))
+		}
+		el, err := p.readElement()
+		if err != nil {
+			return nil, false, err
+		}
+		elements = append(elements, el)
+	}
+	return elements, false, nil
+}
+
+// readElement reads one data element header and its value, recursing into sequences.
+func (p *parser) readElement() (*Element, error) {
+	start := p.pos
+	if p.remaining() < 8 {
+		return nil, p.fail(start, 0, errTruncated)
+	}
+	tag := p.readTag()
+
+	var vr VR
+	var length uint32
+	if p.explicit {
+		vrBytes := p.buf[p.pos : p.pos+2]
+		if !isValidVR(vrBytes) {
+			return nil, p.fail(start, tag, fmt.Errorf("invalid VR %q", vrBytes))
+		}
+		vr = VR(vrBytes)
+		p.pos += 2
+		if vr.hasLongLength() {
+			if p.remaining() < 6 {
+				return nil, p.fail(start, tag, errTruncated)
+			}
+			p.pos += 2 // Reserved bytes
+			length = p.readUint32()
+		} else {
+			length = uint32(p.readUint16())
+		}
+	} else {
+		vr = LookupVR(tag)
+		length = p.readUint32()
+	}
+
+	el := &Element{Tag: tag, VR: vr}
+
+	switch {
+	case tag == TagPixelData && length == undefinedLength:
+		fragments, err := p.readFragments()
+		if err != nil {
+			return nil, err
+		}
+		el.Fragments = fragments
+
+	case vr == VRSQ:
+		items, err := p.readSequence(tag, length)
+		if err != nil {
+			return nil, err
+		}
+		el.Items = items
+
+	case length == undefinedLength:
+		// PS3.5 section 6.2.2: an element of undefined length that is not a sequence is a UN
+		// element wrapping an Implicit VR Little Endian encoded sequence.
+		explicit := p.explicit
+		p.explicit = false
+		items, err := p.readSequence(tag, length)
+		p.explicit = explicit
+		if err != nil {
+			return nil, err
+		}
+		el.VR = VRSQ
+		el.Items = items
+
+	case !p.explicit && vr == VRUN && p.looksLikeSequence(length):
+		// Implicit VR private or unknown sequences cannot be resolved from the dictionary, so
+		// detect them from the first item tag as most toolkits do.
+		items, err := p.readSequence(tag, length)
+		if err != nil {
+			return nil, err
+		}
+		el.VR = VRSQ
+		el.Items = items
+
+	default:
+		if uint64(length) > uint64(p.remaining()) {
+			return nil, p.fail(start, tag, fmt.Errorf("value length %d exceeds remaining %d bytes: %w", length, p.remaining(), errTruncated))
+		}
+		el.Value = p.buf[p.pos : p.pos+int(length)]
+		p.pos += int(length)
+	}
+	return el, nil
+}
+
+// looksLikeSequence reports whether a defined-length value starts with an Item tag.
+func (p *parser) looksLikeSequence(length uint32) bool {
+	if length < 8 || uint64(length) > uint64(p.remaining()) {
+		return false
+	}
+	tag, ok := p.peekTag()
+	return ok && tag == TagItem
+}
+
+// readSequence reads the items of a sequence of either defined or undefined length.
+func (p *parser) readSequence(tag Tag, length uint32) ([]*Item, error) {
+	start := p.pos
+	if p.depth >= maxSequenceDepth {
+		return nil, p.fail(start, tag, fmt.Errorf("sequence nesting exceeds %d levels", maxSequenceDepth))
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+
+	end := len(p.buf)
+	if length != undefinedLength {
+		if uint64(length) > uint64(p.remaining()) {
+			return nil, p.fail(start, tag, fmt.Errorf("sequence length %d exceeds remaining %d bytes: %w", length, p.remaining(), errTruncated))
+		}
+		end = p.pos + int(length)
+	}
+
+	items := []*Item{}
+	for {
+		if length != undefinedLength && p.pos >= end {
+			break
+		}
+		if p.remaining() < 8 {
+			return nil, p.fail(p.pos, tag, errTruncated)
+		}
+		itemStart := p.pos
+		itemTag := p.readTag()
+		itemLength := p.readUint32()
+
+		switch itemTag {
+		case TagSequenceDelimitationItem:
+			if length != undefinedLength {
+				return nil, p.fail(itemStart, tag, errors.New("sequence delimiter in defined-length sequence"))
+			}
+			return items, nil
+
+		case TagItem:
+			var elements []*Element
+			if itemLength == undefinedLength {
+				var delimited bool
+				var err error
+				elements, delimited, err = p.readElements(end, true)
+				if err != nil {
+					return nil, err
+				}
+				if !delimited {
+					return nil, p.fail(itemStart, tag, fmt.Errorf("item is missing its delimiter: %w", errTruncated))
+				}
+			} else {
+				if uint64(itemLength) > uint64(end-p.pos) {
+					return nil, p.fail(itemStart, tag, fmt.Errorf("item length %d exceeds sequence bounds: %w", itemLength, errTruncated))
+				}
+				itemEnd := p.pos + int(itemLength)
+				var err error
+				elements, _, err = p.readElements(itemEnd, false)
+				if err != nil {
+					return nil, err
+				}
+				if p.pos != itemEnd {
+					return nil, p.fail(itemStart, tag, errors.New("item contents overrun the item length"))
+				}
+			}
+			items = append(items, &Item{Elements: elements})
+
+		default:
+			return nil, p.fail(itemStart, tag, fmt.Errorf("unexpected tag %s inside sequence", itemTag))
+		}
+	}
+
+	if p.pos != end {
+		return nil, p.fail(start, tag, errors.New("sequence contents overrun the sequence length"))
+	}
+	return items, nil
+}
+
+// readFragments reads encapsulated Pixel Data: a sequence of items, the first being the Basic Offset Table.
+func (p *parser) readFragments() ([][]byte, error) {
+	fragments := [][]byte{}
+	for {
+		if p.remaining() < 8 {
+			return nil, p.fail(p.pos, TagPixelData, errTruncated)
+		}
+		itemStart := p.pos
+		itemTag := p.readTag()
+		itemLength := p.readUint32()
+
+		switch itemTag {
+		case TagSequenceDelimitationItem:
+			return fragments, nil
+		case TagItem:
+			if itemLength == undefinedLength || uint64(itemLength) > uint64(p.remaining()) {
+				return nil, p.fail(itemStart, TagPixelData, fmt.Errorf("invalid fragment length %d: %w", itemLength, errTruncated))
+			}
+			fragments = append(fragments, p.buf[p.pos:p.pos+int(itemLength)])
+			p.pos += int(itemLength)
+		default:
+			return nil, p.fail(itemStart, TagPixelData, fmt.Errorf("unexpected tag %s inside encapsulated pixel data", itemTag))
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// pkg/dicom/render.go
+package dicom
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// Window is a VOI window (PS3.3 C.11.2) mapping modality values, e.g. Hounsfield units, onto display grey levels.
+// A zero Width means "derive the window from the frame's own value range".
+type Window struct {
+	Name   string
+	Center float64
+	Width  float64
+}
+
+// Standard CT window presets.
+var (
+	WindowLung        = Window{Name: "lung", Center: -600, Width: 1500}
+	WindowMediastinal = Window{Name: "mediastinal", Center: 40, Width: 400}
+	WindowBone        = Window{Name: "bone", Center: 400, Width: 1800}
+)
+
+// WindowPreset returns the preset with the given name ("lung", "mediastinal" or "bone"), case-insensitively.
+func WindowPreset(name string) (Window, bool) {
+	for _, w := range []Window{WindowLung, WindowMediastinal, WindowBone} {
+		if strings.EqualFold(w.Name, name) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// RenderOptions controls how a frame is converted into an 8-bit image.
+type RenderOptions struct {
+	Window  Window // Window applied to greyscale frames; ignored for colour frames
+	MaxEdge int    // If positive, the image is downscaled so its longest edge is at most MaxEdge pixels
+}
+
+// RenderFrame converts one decoded frame into an 8-bit image: greyscale frames are windowed (and inverted for
+// MONOCHROME1), colour frames are passed through with their samples scaled to 8 bits. The result is then
+// downscaled with an area-average filter if it exceeds opts.MaxEdge. The output is fully deterministic.
+func (fb *FrameBuffer) RenderFrame(index int, opts RenderOptions) (image.Image, error) {
+	if index < 0 || index >= len(fb.Frames) {
+		return nil, fmt.Errorf("dicom: frame %d out of range (%d frames)", index, len(fb.Frames))
+	}
+	frame := fb.Frames[index]
+
+	var img image.Image
+	switch {
+	case fb.SamplesPerPixel == 1:
+		img = fb.renderGray(frame, opts.Window)
+	case fb.SamplesPerPixel == 3 && fb.PhotometricInterpretation == "RGB":
+		img = fb.renderRGB(frame)
+	default:
+		return nil, fmt.Errorf("%w: cannot render photometric interpretation %q", ErrUnsupportedPixelData, fb.PhotometricInterpretation)
+	}
+
+	if opts.MaxEdge > 0 {
+		img = downscale(img, opts.MaxEdge)
+	}
+	return img, nil
+}
+
+// RenderPNG renders one frame with RenderFrame and encodes it as PNG.
+func (fb *FrameBuffer) RenderPNG(index int, opts RenderOptions) ([]byte, error) {
+	img, err := fb.RenderFrame(index, opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("dicom: encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderGray applies the window to the frame's modality values using the PS3.3 C.11.2.1.2 linear function.
+func (fb *FrameBuffer) renderGray(frame Frame, window Window) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, fb.Columns, fb.Rows))
+	values := frame.Values
+
+	center, width := window.Center, window.Width
+	if width < 1 {
+		center, width = autoWindow(values)
+	}
+	lower := center - 0.5 - (width-1)/2
+	upper := center - 0.5 + (width-1)/2
+	invert := fb.PhotometricInterpretation == "MONOCHROME1"
+
+	for i, v := range values {
+		x := float64(v)
+		var level float64
+		switch {
+		case x <= lower:
+			level = 0
+		case x > upper:
+			level = 255
+		default:
+			level = ((x-(center-0.5))/(width-1) + 0.5) * 255
+		}
+		grey := uint8(math.Round(math.Max(0, math.Min(255, level))))
+		if invert {
+			grey = 255 - grey
+		}
+		img.Pix[i] = grey
+	}
+	return img
+}
+
+// renderRGB scales colour samples to 8 bits.
+func (fb *FrameBuffer) renderRGB(frame Frame) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, fb.Columns, fb.Rows))
+	shift := uint(0)
+	if fb.BitsStored > 8 {
+		shift = uint(fb.BitsStored - 8)
+	}
+	for i := 0; i < fb.PixelCount(); i++ {
+		img.Pix[i*4+0] = uint8(frame.Stored[i*3+0] >> shift)
+		img.Pix[i*4+1] = uint8(frame.Stored[i*3+1] >> shift)
+		img.Pix[i*4+2] = uint8(frame.Stored[i*3+2] >> shift)
+		img.Pix[i*4+3] = 0xFF
+	}
+	return img
+}
+
+// autoWindow derives a window covering the full value range, used when no explicit window is configured.
+func autoWindow(values []float32) (center, width float64) {
+	if len(values) == 0 {
+		return 0, 1
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	width = float64(hi-lo) + 1
+	center = float64(lo) + width/2
+	return center, width
+}
+
+// downscale shrinks img so that its longest edge is at most maxEdge, averaging the source pixels
+// covered by each destination pixel. Images already within the limit are returned unchanged.
+func downscale(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxEdge && srcH <= maxEdge {
+		return img
+	}
+
+	scale := float64(maxEdge) / float64(max(srcW, srcH))
+	dstW := max(1, int(math.Round(float64(srcW)*scale)))
+	dstH := max(1, int(math.Round(float64(srcH)*scale)))
+
+	gray, isGray := img.(*image.Gray)
+	var out image.Image
+	var dstGray *image.Gray
+	var dstRGBA *image.RGBA
+	if isGray {
+		dstGray = image.NewGray(image.Rect(0, 0, dstW, dstH))
+		out = dstGray
+	} else {
+		dstRGBA = image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+		out = dstRGBA
+	}
+
+	for dy := 0; dy < dstH; dy++ {
+		y0 := dy * srcH / dstH
+		y1 := max(y0+1, (dy+1)*srcH/dstH)
+		for dx := 0; dx < dstW; dx++ {
+			x0 := dx * srcW / dstW
+			x1 := max(x0+1, (dx+1)*srcW/dstW)
+
+			var sum [4]uint32
+			count := uint32(0)
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					if isGray {
+						sum[0] += uint32(gray.Pix[y*gray.Stride+x])
+					} else {
+						r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+						sum[0], sum[1], sum[2], sum[3] = sum[0]+r>>8, sum[1]+g>>8, sum[2]+b>>8, sum[3]+a>>8
+					}
+					count++
+				}
+			}
+
+			if isGray {
+				dstGray.Pix[dy*dstGray.Stride+dx] = uint8((sum[0] + count/2) / count)
+			} else {
+				dstRGBA.SetRGBA(dx, dy, color.RGBA{
+					R: uint8((sum[0] + count/2) / count),
+					G: uint8((sum[1] + count/2) / count),
+					B: uint8((sum[2] + count/2) / count),
+					A: uint8((sum[3] + count/2) / count),
+				})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+// pkg/dicom/render_test.go
+package dicom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden PNGs in testdata/render")
+
+// syntheticImage describes a small native (uncompressed) image whose stored values are produced by value(x, y).
+type syntheticImage struct {
+	rows, columns       int
+	photometric         string
+	bitsAllocated       int
+	bitsStored          int
+	pixelRepresentation int
+	slope, intercept    string // Rescale Slope and Intercept; "" leaves the element out
+	planar              int    // Planar Configuration of RGB images
+	value               func(x, y, sample int) int
+}
+
+// dataset builds the Explicit VR Little Endian dataset of the image.
+func (s syntheticImage) dataset() *DataSet {
+	ds := &DataSet{TransferSyntaxUID: ExplicitVRLittleEndian}
+	samples := 1
+	if s.photometric == "RGB" {
+		samples = 3
+		ds.Put(usElement(TagPlanarConfiguration, s.planar))
+	}
+	ds.Put(usElement(TagSamplesPerPixel, samples))
+	ds.Put(NewStringElement(TagPhotometricInterpretation, VRCS, s.photometric))
+	ds.Put(usElement(TagRows, s.rows))
+	ds.Put(usElement(TagColumns, s.columns))
+	ds.Put(usElement(TagBitsAllocated, s.bitsAllocated))
+	ds.Put(usElement(TagBitsStored, s.bitsStored))
+	ds.Put(usElement(TagHighBit, s.bitsStored-1))
+	ds.Put(usElement(TagPixelRepresentation, s.pixelRepresentation))
+	if s.slope != "" {
+		ds.Put(NewStringElement(TagRescaleSlope, VRDS, s.slope))
+		ds.Put(NewStringElement(TagRescaleIntercept, VRDS, s.intercept))
+	}
+
+	bytesPerSample := s.bitsAllocated / 8
+	pixels := s.rows * s.columns
+	data := make([]byte, pixels*samples*bytesPerSample)
+	for y := 0; y < s.rows; y++ {
+		for x := 0; x < s.columns; x++ {
+			for sample := 0; sample < samples; sample++ {
+				index := (y*s.columns+x)*samples + sample
+				if s.planar == 1 {
+					index = sample*pixels + y*s.columns + x
+				}
+				v := s.value(x, y, sample)
+				switch bytesPerSample {
+				case 1:
+					data[index] = byte(v)
+				case 2:
+					binary.LittleEndian.PutUint16(data[index*2:], uint16(v))
+				}
+			}
+		}
+	}
+	ds.Put(&Element{Tag: TagPixelData, VR: VROW, Value: data})
+	return ds
+}
+
+func usElement(tag Tag, v int) *Element {
+	value := make([]byte, 2)
+	binary.LittleEndian.PutUint16(value, uint16(v))
+	return &Element{Tag: tag, VR: VRUS, Value: value}
+}
+
+// ctSlice is a 16×16 signed CT slice stored as HU+1024, rising from -1024 HU (air) in the top left corner to
+// about +1000 HU (bone) in the bottom right one, so that every preset has pixels below, inside and above its window.
+var ctSlice = syntheticImage{
+	rows: 16, columns: 16, photometric: "MONOCHROME2",
+	bitsAllocated: 16, bitsStored: 12, pixelRepresentation: 1,
+	slope: "1", intercept: "-1024",
+	value: func(x, y, _ int) int { return (y*16 + x) * 8 },
+}
+
+func TestRenderPNGGolden(t *testing.T) {
+	monochrome1 := ctSlice
+	monochrome1.photometric = "MONOCHROME1"
+
+	rescaled := ctSlice
+	rescaled.pixelRepresentation = 0
+	rescaled.slope, rescaled.intercept = "2.5", "-1200.5"
+
+	large := ctSlice
+	large.rows, large.columns = 24, 40
+	large.value = func(x, y, _ int) int { return (x*x + y*y*3) % 2048 }
+
+	tests := []struct {
+		name   string
+		image  syntheticImage
+		opts   RenderOptions
+		golden string
+	}{
+		{"lung window", ctSlice, RenderOptions{Window: WindowLung}, "ct_lung.png"},
+		{"mediastinal window", ctSlice, RenderOptions{Window: WindowMediastinal}, "ct_mediastinal.png"},
+		{"bone window", ctSlice, RenderOptions{Window: WindowBone}, "ct_bone.png"},
+		{"full range when no window is set", ctSlice, RenderOptions{}, "ct_auto.png"},
+		{"MONOCHROME1 is inverted", monochrome1, RenderOptions{Window: WindowLung}, "monochrome1_lung.png"},
+		{"rescale slope and intercept", rescaled, RenderOptions{Window: WindowMediastinal}, "rescaled_mediastinal.png"},
+		{"downscaled to the maximum edge", large, RenderOptions{Window: WindowBone, MaxEdge: 10}, "downscaled_bone.png"},
+		{"8-bit MONOCHROME2 without rescale", syntheticImage{
+			rows: 8, columns: 12, photometric: "MONOCHROME2", bitsAllocated: 8, bitsStored: 8,
+			value: func(x, y, _ int) int { return x*20 + y },
+		}, RenderOptions{}, "mr_auto.png"},
+		{"planar RGB", syntheticImage{
+			rows: 6, columns: 6, photometric: "RGB", bitsAllocated: 8, bitsStored: 8, planar: 1,
+			value: func(x, y, sample int) int { return []int{x * 40, y * 40, 255 - x*20}[sample] },
+		}, RenderOptions{}, "rgb_planar.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb, err := tt.image.dataset().DecodePixelData()
+			if err != nil {
+				t.Fatalf("DecodePixelData: %v", err)
+			}
+			got, err := fb.RenderPNG(0, tt.opts)
+			if err != nil {
+				t.Fatalf("RenderPNG: %v", err)
+			}
+			again, err := fb.RenderPNG(0, tt.opts)
+			if err != nil {
+				t.Fatalf("RenderPNG: %v", err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Fatal("rendering the same frame twice gave different PNGs")
+			}
+
+			path := filepath.Join("testdata", "render", tt.golden)
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("PNG differs from %s (run with -update to accept the new rendering)", path)
+			}
+		})
+	}
+}
+
+// TestRenderGrayLevels pins the window function on values whose grey levels are known: the window's bounds map to
+// black and white, its centre to mid grey, and MONOCHROME1 inverts them.
+func TestRenderGrayLevels(t *testing.T) {
+	huAt := []int{-1400, -1350, -600, 150, 1000} // Below, at the bottom of, in the middle of, at the top of and above the lung window
+	slice := syntheticImage{
+		rows: 1, columns: len(huAt), photometric: "MONOCHROME2",
+		bitsAllocated: 16, bitsStored: 16, pixelRepresentation: 1, slope: "1", intercept: "0",
+		value: func(x, _, _ int) int { return huAt[x] },
+	}
+	want := []uint8{0, 0, 128, 255, 255}
+
+	for _, photometric := range []string{"MONOCHROME2", "MONOCHROME1"} {
+		slice.photometric = photometric
+		fb, err := slice.dataset().DecodePixelData()
+		if err != nil {
+			t.Fatalf("DecodePixelData: %v", err)
+		}
+		for i, hu := range huAt {
+			if got := fb.Frames[0].Values[i]; got != float32(hu) {
+				t.Fatalf("%s: value %d is %v HU, want %d", photometric, i, got, hu)
+			}
+		}
+		img, err := fb.RenderFrame(0, RenderOptions{Window: WindowLung})
+		if err != nil {
+			t.Fatalf("RenderFrame: %v", err)
+		}
+		gray, ok := img.(*image.Gray)
+		if !ok {
+			t.Fatalf("%s: rendered a %T, want *image.Gray", photometric, img)
+		}
+		for i, level := range want {
+			if photometric == "MONOCHROME1" {
+				level = 255 - level
+			}
+			if got := gray.Pix[i]; got != level {
+				t.Errorf("%s: %d HU rendered as %d, want %d", photometric, huAt[i], got, level)
+			}
+		}
+	}
+}
+
+func TestWindowPreset(t *testing.T) {
+	for _, name := range []string{"lung", "Mediastinal", "BONE"} {
+		if _, ok := WindowPreset(name); !ok {
+			t.Errorf("WindowPreset(%q) not found", name)
+		}
+	}
+	if _, ok := WindowPreset("soft tissue"); ok {
+		t.Error("WindowPreset found an unknown preset")
+	}
+}
+
+func TestRenderFrameOutOfRange(t *testing.T) {
+	fb, err := ctSlice.dataset().DecodePixelData()
+	if err != nil {
+		t.Fatalf("DecodePixelData: %v", err)
+	}
+	for _, index := range []int{-1, 1} {
+		if _, err := fb.RenderFrame(index, RenderOptions{}); err == nil {
+			t.Errorf("RenderFrame(%d) succeeded", index)
+		}
+	}
+}
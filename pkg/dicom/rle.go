@@ -0,0 +1,102 @@
+// pkg/dicom/rle.go
+package dicom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// rleHeaderLength is the size of the RLE header: a segment count followed by 15 segment offsets (PS3.5 Annex G.5).
+const rleHeaderLength = 64
+
+// maxPackBitsRun is the most bytes one two-byte PackBits run (a replicate run) expands to, which bounds what a
+// segment can decode to.
+const maxPackBitsRun = 128
+
+// maxRLEExpansion bounds how many times larger than its encoded size a frame may decode to. PackBits allows 64, but
+// medical images compress a few times at most; only blank images come close, and crafted frames use the maximum to
+// turn a small file into a large allocation.
+const maxRLEExpansion = 32
+
+// decodeRLEFrame decodes one RLE Lossless frame into native little-endian, sample-interleaved bytes.
+// Each sample byte plane is a separate segment, ordered sample by sample with the most significant byte first.
+func decodeRLEFrame(data []byte, pixels, samples, bytesPerSample int) ([]byte, error) {
+	if len(data) < rleHeaderLength {
+		return nil, errors.New("RLE frame shorter than its header")
+	}
+	numSegments := int(binary.LittleEndian.Uint32(data))
+	expected := samples * bytesPerSample
+	if numSegments != expected {
+		return nil, fmt.Errorf("RLE frame has %d segments, expected %d", numSegments, expected)
+	}
+
+	if size := pixels * samples * bytesPerSample; size/maxRLEExpansion > len(data) {
+		return nil, fmt.Errorf("RLE frame of %d bytes would decode to %d bytes, more than %d times its size", len(data), size, maxRLEExpansion)
+	}
+
+	offsets := make([]int, numSegments+1)
+	for i := 0; i < numSegments; i++ {
+		offsets[i] = int(binary.LittleEndian.Uint32(data[4+i*4:]))
+	}
+	offsets[numSegments] = len(data)
+
+	// Check every segment before allocating the frame: the frame size comes from the header, and a segment too short
+	// to expand to a full plane could never fill it.
+	for seg := 0; seg < numSegments; seg++ {
+		start, end := offsets[seg], offsets[seg+1]
+		if start < rleHeaderLength || start > end || end > len(data) {
+			return nil, fmt.Errorf("RLE segment %d has invalid bounds [%d,%d)", seg, start, end)
+		}
+		if (end-start)/2 < (pixels+maxPackBitsRun-1)/maxPackBitsRun {
+			return nil, fmt.Errorf("RLE segment %d holds %d bytes, too few to decode to %d", seg, end-start, pixels)
+		}
+	}
+
+	out := make([]byte, pixels*samples*bytesPerSample)
+	for seg := 0; seg < numSegments; seg++ {
+		start, end := offsets[seg], offsets[seg+1]
+		plane, err := decodePackBits(data[start:end], pixels)
+		if err != nil {
+			return nil, fmt.Errorf("RLE segment %d: %w", seg, err)
+		}
+
+		sample := seg / bytesPerSample
+		byteIndex := bytesPerSample - 1 - seg%bytesPerSample // Segment 0 of a sample is its most significant byte
+		for i, b := range plane {
+			out[(i*samples+sample)*bytesPerSample+byteIndex] = b
+		}
+	}
+	return out, nil
+}
+
+// decodePackBits expands one RLE segment (a PackBits variant) into exactly n bytes.
+// Segments are padded to an even length, so trailing input after n bytes is ignored.
+func decodePackBits(src []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for i := 0; i < len(src) && len(out) < n; {
+		control := int8(src[i])
+		i++
+		switch {
+		case control >= 0: // Literal run of control+1 bytes
+			count := int(control) + 1
+			if i+count > len(src) {
+				return nil, errors.New("literal run exceeds segment")
+			}
+			out = append(out, src[i:i+count]...)
+			i += count
+		case control != -128: // Replicate the next byte -control+1 times
+			if i >= len(src) {
+				return nil, errors.New("replicate run exceeds segment")
+			}
+			for j := 0; j < int(-control)+1; j++ {
+				out = append(out, src[i])
+			}
+			i++
+		}
+	}
+	if len(out) < n {
+		return nil, fmt.Errorf("segment decoded to %d bytes, expected %d", len(out), n)
+	}
+	return out[:n], nil
+}
@@ -0,0 +1,292 @@
+// pkg/dicom/series.go
+package dicom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ErrSeriesTooLarge is returned by Series.Volume for a series with more voxels than the caller allows.
+var ErrSeriesTooLarge = errors.New("dicom: series too large")
+
+// SeriesIssueKind classifies a problem found while assembling a series.
+type SeriesIssueKind string
+
+const (
+	IssueMissingGeometry     SeriesIssueKind = "missing_geometry"     // Position/orientation absent; slices ordered by Instance Number instead
+	IssueMixedOrientation    SeriesIssueKind = "mixed_orientation"    // Instances do not share one Image Orientation (Patient)
+	IssueDuplicateSlice      SeriesIssueKind = "duplicate_slice"      // Two instances occupy the same position; the later one is dropped
+	IssueMissingSlices       SeriesIssueKind = "missing_slices"       // A gap noticeably larger than the typical spacing
+	IssueInconsistentSpacing SeriesIssueKind = "inconsistent_spacing" // A gap that deviates from the typical spacing without being a whole missing slice
+	IssueMismatchedDimension SeriesIssueKind = "mismatched_dimensions"
+)
+
+// Spacing tolerances used when checking slice positions.
+const (
+	duplicatePositionTolerance = 1e-3 // mm; slices closer than this are treated as the same position
+	orientationTolerance       = 1e-3 // Maximum direction cosine difference for "same orientation"
+	spacingRelativeTolerance   = 0.01 // 1% of the typical spacing...
+	spacingAbsoluteTolerance   = 0.01 // ...but never less than 0.01 mm
+	missingSliceRatio          = 1.5  // A gap of more than 1.5x the typical spacing means at least one slice is missing
+)
+
+// SeriesIssue records one problem found while assembling a series.
+type SeriesIssue struct {
+	Kind   SeriesIssueKind `json:"kind"`
+	Index  int             `json:"index"`  // Index into Series.Slices of the slice after the problem (or of the dropped duplicate's neighbour)
+	Detail string          `json:"detail"` // Human-readable description, free of PHI (positions and UIDs only)
+}
+
+// Slice is one instance of a series with its position along the slice normal.
+type Slice struct {
+	DataSet  *DataSet
+	Position float64 // Distance along the slice normal in mm (dot product of Image Position (Patient) and the normal)
+}
+
+// Series is a set of instances sharing Study and Series Instance UIDs, ordered spatially.
+type Series struct {
+	StudyInstanceUID  string
+	SeriesInstanceUID string
+	Modality          string
+	Slices            []Slice    // Sorted by Position (or Instance Number when geometry is missing); duplicates removed
+	Normal            [3]float64 // Unit slice normal (row cosine x column cosine)
+	SliceSpacing      float64    // Median distance between consecutive slices in mm (0 for single-slice series)
+	Issues            []SeriesIssue
+}
+
+// AssembleSeries groups datasets by Study and Series Instance UID and orders each series spatially.
+// Series are returned ordered by Study then Series Instance UID so that the result is deterministic.
+func AssembleSeries(datasets []*DataSet) []*Series {
+	type key struct{ study, series string }
+	groups := map[key][]*DataSet{}
+	var keys []key
+	for _, ds := range datasets {
+		k := key{ds.StudyInstanceUID, ds.SeriesInstanceUID}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], ds)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].study != keys[j].study {
+			return keys[i].study < keys[j].study
+		}
+		return keys[i].series < keys[j].series
+	})
+
+	series := make([]*Series, 0, len(keys))
+	for _, k := range keys {
+		series = append(series, assembleOne(k.study, k.series, groups[k]))
+	}
+	return series
+}
+
+// assembleOne orders the instances of a single series and records any geometry problems.
+func assembleOne(studyUID, seriesUID string, datasets []*DataSet) *Series {
+	s := &Series{StudyInstanceUID: studyUID, SeriesInstanceUID: seriesUID, Modality: datasets[0].Series().Modality}
+
+	// Establish the slice normal from the first instance that has an orientation
+	haveGeometry := true
+	var reference [6]float64
+	for _, ds := range datasets {
+		g := ds.Geometry()
+		if !g.HasPosition || !g.HasOrientation {
+			haveGeometry = false
+			break
+		}
+		if reference == ([6]float64{}) {
+			reference = g.ImageOrientationPatient
+		} else if !sameOrientation(reference, g.ImageOrientationPatient) && !hasIssue(s, IssueMixedOrientation) {
+			s.Issues = append(s.Issues, SeriesIssue{Kind: IssueMixedOrientation, Detail: fmt.Sprintf("instance %s has a different orientation", ds.SOPInstanceUID)})
+		}
+	}
+
+	slices := make([]Slice, len(datasets))
+	if haveGeometry {
+		s.Normal = sliceNormal(reference)
+		for i, ds := range datasets {
+			ipp := ds.Geometry().ImagePositionPatient
+			slices[i] = Slice{DataSet: ds, Position: ipp[0]*s.Normal[0] + ipp[1]*s.Normal[1] + ipp[2]*s.Normal[2]}
+		}
+	} else {
+		if len(datasets) > 1 {
+			s.Issues = append(s.Issues, SeriesIssue{Kind: IssueMissingGeometry, Detail: "image position or orientation missing; ordered by instance number"})
+		}
+		for i, ds := range datasets {
+			slices[i] = Slice{DataSet: ds, Position: float64(ds.Instance().InstanceNumber)}
+		}
+	}
+	sort.SliceStable(slices, func(i, j int) bool {
+		if slices[i].Position != slices[j].Position {
+			return slices[i].Position < slices[j].Position
+		}
+		return slices[i].DataSet.Instance().InstanceNumber < slices[j].DataSet.Instance().InstanceNumber
+	})
+
+	// Drop duplicates (same position, e.g. the same file uploaded twice)
+	for _, sl := range slices {
+		if n := len(s.Slices); n > 0 && haveGeometry && math.Abs(sl.Position-s.Slices[n-1].Position) < duplicatePositionTolerance {
+			s.Issues = append(s.Issues, SeriesIssue{Kind: IssueDuplicateSlice, Index: n - 1, Detail: fmt.Sprintf("instance %s duplicates position %.3f mm", sl.DataSet.SOPInstanceUID, sl.Position)})
+			continue
+		}
+		s.Slices = append(s.Slices, sl)
+	}
+
+	if haveGeometry {
+		s.checkSpacing()
+	}
+	return s
+}
+
+// checkSpacing computes the typical slice spacing and flags gaps and irregular spacing.
+func (s *Series) checkSpacing() {
+	if len(s.Slices) < 2 {
+		return
+	}
+	gaps := make([]float64, len(s.Slices)-1)
+	for i := 1; i < len(s.Slices); i++ {
+		gaps[i-1] = s.Slices[i].Position - s.Slices[i-1].Position
+	}
+	sorted := append([]float64(nil), gaps...)
+	sort.Float64s(sorted)
+	s.SliceSpacing = sorted[len(sorted)/2]
+
+	tolerance := math.Max(spacingAbsoluteTolerance, s.SliceSpacing*spacingRelativeTolerance)
+	for i, gap := range gaps {
+		switch {
+		case gap > s.SliceSpacing*missingSliceRatio:
+			missing := int(math.Round(gap/s.SliceSpacing)) - 1
+			s.Issues = append(s.Issues, SeriesIssue{Kind: IssueMissingSlices, Index: i + 1, Detail: fmt.Sprintf("gap of %.3f mm between %.3f and %.3f mm suggests %d missing slice(s)", gap, s.Slices[i].Position, s.Slices[i+1].Position, max(missing, 1))})
+		case math.Abs(gap-s.SliceSpacing) > tolerance:
+			s.Issues = append(s.Issues, SeriesIssue{Kind: IssueInconsistentSpacing, Index: i + 1, Detail: fmt.Sprintf("spacing of %.3f mm differs from typical %.3f mm", gap, s.SliceSpacing)})
+		}
+	}
+}
+
+// Volume is a 3D block of modality values (Hounsfield units for CT) reconstructed from a series.
+// Voxel (x, y, z) is stored at Data[z*Rows*Columns + y*Columns + x], with z following Series.Slices.
+type Volume struct {
+	Columns     int
+	Rows        int
+	Depth       int
+	Spacing     [3]float64 // Voxel size in mm: column spacing (x), row spacing (y), slice spacing (z)
+	Origin      [3]float64 // Image Position (Patient) of the first slice
+	Orientation [6]float64 // Image Orientation (Patient) shared by all slices
+	Photometric string     // Photometric Interpretation of the source slices
+	Data        []float32
+}
+
+// Voxels returns the rows × columns of every slice of the series added up, read from the slice headers without
+// decoding any pixel data, so that callers can refuse a series before allocating memory for it.
+func (s *Series) Voxels() int64 {
+	var total int64
+	for _, sl := range s.Slices {
+		pm := sl.DataSet.Pixel()
+		total += int64(pm.Rows) * int64(pm.Columns)
+	}
+	return total
+}
+
+// Volume decodes every slice and stacks the first frame of each into a Volume. All slices must share the
+// same dimensions. Only single-sample (greyscale) images can be stacked. A series of more than maxVoxels voxels
+// (see Voxels) returns ErrSeriesTooLarge before any pixel data is decoded.
+func (s *Series) Volume(maxVoxels int64) (*Volume, error) {
+	if len(s.Slices) == 0 {
+		return nil, fmt.Errorf("dicom: series %s has no slices", s.SeriesInstanceUID)
+	}
+	if voxels := s.Voxels(); voxels > maxVoxels {
+		return nil, fmt.Errorf("%w: series %s has %d voxels, at most %d are decoded", ErrSeriesTooLarge, s.SeriesInstanceUID, voxels, maxVoxels)
+	}
+
+	first := s.Slices[0].DataSet
+	geometry := first.Geometry()
+	v := &Volume{Depth: len(s.Slices), Origin: geometry.ImagePositionPatient, Orientation: geometry.ImageOrientationPatient}
+	v.Spacing[0], v.Spacing[1] = geometry.PixelSpacing[1], geometry.PixelSpacing[0] // PixelSpacing is row spacing\column spacing
+	v.Spacing[2] = s.SliceSpacing
+	if v.Spacing[2] == 0 {
+		v.Spacing[2] = geometry.SliceThickness
+	}
+
+	for z := range s.Slices {
+		fb, err := s.DecodeSlice(z)
+		if err != nil {
+			return nil, err
+		}
+		if z == 0 {
+			v.Columns, v.Rows, v.Photometric = fb.Columns, fb.Rows, fb.PhotometricInterpretation
+			v.Data = make([]float32, 0, v.Columns*v.Rows*v.Depth)
+		}
+		v.Data = append(v.Data, fb.Frames[0].Values...)
+	}
+	return v, nil
+}
+
+// DecodeSlice decodes slice z alone, so that a few slices of a series can be rendered without reconstructing its
+// volume. The slice must be a single-sample (greyscale) image with the dimensions of the first slice.
+func (s *Series) DecodeSlice(z int) (*FrameBuffer, error) {
+	if z < 0 || z >= len(s.Slices) {
+		return nil, fmt.Errorf("dicom: slice %d out of range (series %s has %d)", z, s.SeriesInstanceUID, len(s.Slices))
+	}
+	first := s.Slices[0].DataSet.Pixel()
+	if pm := s.Slices[z].DataSet.Pixel(); pm.Columns != first.Columns || pm.Rows != first.Rows {
+		s.Issues = append(s.Issues, SeriesIssue{Kind: IssueMismatchedDimension, Index: z, Detail: fmt.Sprintf("slice is %dx%d, expected %dx%d", pm.Columns, pm.Rows, first.Columns, first.Rows)})
+		return nil, fmt.Errorf("dicom: slice %d of series %s is %dx%d, expected %dx%d", z, s.SeriesInstanceUID, pm.Columns, pm.Rows, first.Columns, first.Rows)
+	}
+	fb, err := s.Slices[z].DataSet.DecodePixelData()
+	if err != nil {
+		return nil, fmt.Errorf("dicom: decoding slice %d of series %s: %w", z, s.SeriesInstanceUID, err)
+	}
+	if fb.SamplesPerPixel != 1 {
+		return nil, fmt.Errorf("%w: cannot stack %d-sample images into a volume", ErrUnsupportedPixelData, fb.SamplesPerPixel)
+	}
+	return fb, nil
+}
+
+// Slice returns the modality values of slice z.
+func (v *Volume) Slice(z int) []float32 {
+	size := v.Rows * v.Columns
+	return v.Data[z*size : (z+1)*size]
+}
+
+// SliceFrameBuffer wraps slice z as a single-frame FrameBuffer so it can be rendered with RenderFrame/RenderPNG.
+func (v *Volume) SliceFrameBuffer(z int) *FrameBuffer {
+	return &FrameBuffer{
+		Rows:                      v.Rows,
+		Columns:                   v.Columns,
+		SamplesPerPixel:           1,
+		PhotometricInterpretation: v.Photometric,
+		RescaleSlope:              1,
+		Frames:                    []Frame{{Index: 0, Values: v.Slice(z)}},
+	}
+}
+
+// sliceNormal returns the cross product of the row and column direction cosines.
+func sliceNormal(iop [6]float64) [3]float64 {
+	r := [3]float64{iop[0], iop[1], iop[2]}
+	c := [3]float64{iop[3], iop[4], iop[5]}
+	return [3]float64{
+		r[1]*c[2] - r[2]*c[1],
+		r[2]*c[0] - r[0]*c[2],
+		r[0]*c[1] - r[1]*c[0],
+	}
+}
+
+func sameOrientation(a, b [6]float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > orientationTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func hasIssue(s *Series, kind SeriesIssueKind) bool {
+	for _, issue := range s.Issues {
+		if issue.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
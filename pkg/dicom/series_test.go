@@ -0,0 +1,71 @@
+// pkg/dicom/series_test.go
+package dicom
+
+import (
+	"errors"
+	"testing"
+)
+
+// ctSeries is a series of depth copies of ctSlice; slice z is stored as ctSlice plus z.
+func ctSeries(depth int) *Series {
+	s := &Series{SeriesInstanceUID: "2.25.7", SliceSpacing: 2.5}
+	for z := 0; z < depth; z++ {
+		image := ctSlice
+		base := ctSlice.value
+		image.value = func(x, y, sample int) int { return base(x, y, sample) + z }
+		s.Slices = append(s.Slices, Slice{DataSet: image.dataset(), Position: float64(z) * 2.5})
+	}
+	return s
+}
+
+func TestSeriesVoxels(t *testing.T) {
+	if got := ctSeries(3).Voxels(); got != 3*16*16 {
+		t.Errorf("Voxels = %d, want %d", got, 3*16*16)
+	}
+}
+
+func TestSeriesVolumeLimit(t *testing.T) {
+	series := ctSeries(3)
+	series.Slices[1].DataSet.Put(&Element{Tag: TagPixelData, VR: VROW, Value: []byte{0}}) // Fails if decoded
+
+	if _, err := series.Volume(3*16*16 - 1); !errors.Is(err, ErrSeriesTooLarge) {
+		t.Errorf("Volume error %v, want %v before decoding", err, ErrSeriesTooLarge)
+	}
+	if _, err := series.Volume(3 * 16 * 16); err == nil || errors.Is(err, ErrSeriesTooLarge) {
+		t.Errorf("Volume error %v, want the decoding error of slice 1", err)
+	}
+
+	volume, err := ctSeries(3).Volume(3 * 16 * 16)
+	if err != nil {
+		t.Fatalf("Volume: %v", err)
+	}
+	if volume.Depth != 3 || len(volume.Data) != 3*16*16 || volume.Spacing[2] != 2.5 {
+		t.Fatalf("Volume is %d slices of %d voxels spaced %v mm, want 3 slices of 256 voxels spaced 2.5 mm", volume.Depth, len(volume.Data), volume.Spacing[2])
+	}
+	if got := volume.Slice(2)[0]; got != -1024+2 {
+		t.Errorf("first voxel of slice 2 is %v HU, want -1022", got)
+	}
+}
+
+func TestSeriesDecodeSlice(t *testing.T) {
+	series := ctSeries(3)
+	series.Slices[0].DataSet.Put(&Element{Tag: TagPixelData, VR: VROW, Value: []byte{0}}) // Only slice 2 is decoded
+
+	fb, err := series.DecodeSlice(2)
+	if err != nil {
+		t.Fatalf("DecodeSlice: %v", err)
+	}
+	if got := fb.Frames[0].Values[0]; got != -1024+2 {
+		t.Errorf("first value of slice 2 is %v HU, want -1022", got)
+	}
+
+	smaller := ctSlice
+	smaller.rows, smaller.columns = 8, 8
+	series.Slices[1].DataSet = smaller.dataset()
+	if _, err := series.DecodeSlice(1); err == nil || !hasIssue(series, IssueMismatchedDimension) {
+		t.Errorf("DecodeSlice of an 8×8 slice in a 16×16 series: error %v, issues %v", err, series.Issues)
+	}
+	if _, err := series.DecodeSlice(3); err == nil {
+		t.Error("DecodeSlice(3) of a 3-slice series succeeded")
+	}
+}
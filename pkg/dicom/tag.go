@@ -0,0 +1,381 @@
+// pkg/dicom/tag.go
+package dicom
+
+import "fmt"
+
+// Tag identifies a DICOM attribute as (group,element), packed into a uint32
+// as group<<16 | element so that tags can be declared as constants and used as map keys.
+type Tag uint32
+
+// NewTag builds a Tag from its group and element numbers.
+func NewTag(group, element uint16) Tag {
+	return Tag(uint32(group)<<16 | uint32(element))
+}
+
+// Group returns the group number of the tag.
+func (t Tag) Group() uint16 { return uint16(t >> 16) }
+
+// Element returns the element number of the tag.
+func (t Tag) Element() uint16 { return uint16(t) }
+
+// IsPrivate reports whether the tag belongs to an odd (private) group.
+func (t Tag) IsPrivate() bool { return t.Group()%2 == 1 }
+
+// IsPrivateCreator reports whether the tag is a private creator reservation, i.e. (gggg,0010-00FF) in an odd group.
+func (t Tag) IsPrivateCreator() bool {
+	return t.IsPrivate() && t.Element() >= 0x0010 && t.Element() <= 0x00FF
+}
+
+// IsGroupLength reports whether the tag is a (gggg,0000) group length element.
+func (t Tag) IsGroupLength() bool { return t.Element() == 0x0000 }
+
+// String renders the tag in the conventional "(gggg,eeee)" notation.
+func (t Tag) String() string {
+	return fmt.Sprintf("(%04X,%04X)", t.Group(), t.Element())
+}
+
+// Name returns the dictionary keyword of the tag, or its "(gggg,eeee)" notation when the tag is unknown.
+func (t Tag) Name() string {
+	if info, ok := dictionary[t]; ok {
+		return info.name
+	}
+	return t.String()
+}
+
+// Well-known tags used by the parser, the typed accessors and the anonymization code.
+const (
+	// File meta information (always Explicit VR Little Endian)
+	TagFileMetaInformationGroupLength Tag = 0x00020000
+	TagFileMetaInformationVersion     Tag = 0x00020001
+	TagMediaStorageSOPClassUID        Tag = 0x00020002
+	TagMediaStorageSOPInstanceUID     Tag = 0x00020003
+	TagTransferSyntaxUID              Tag = 0x00020010
+	TagImplementationClassUID         Tag = 0x00020012
+	TagImplementationVersionName      Tag = 0x00020013
+	TagSourceApplicationEntityTitle   Tag = 0x00020016
+
+	// Media storage directory (DICOMDIR, PS3.3 F.3)
+	TagFileSetID                         Tag = 0x00041130
+	TagDirectoryRecordSequence           Tag = 0x00041220
+	TagDirectoryRecordType               Tag = 0x00041430
+	TagReferencedFileID                  Tag = 0x00041500
+	TagReferencedSOPClassUIDInFile       Tag = 0x00041510
+	TagReferencedSOPInstanceUIDInFile    Tag = 0x00041511
+	TagReferencedTransferSyntaxUIDInFile Tag = 0x00041512
+
+	// SOP common, general study/series/equipment
+	TagSpecificCharacterSet                Tag = 0x00080005
+	TagImageType                           Tag = 0x00080008
+	TagInstanceCreationDate                Tag = 0x00080012
+	TagInstanceCreationTime                Tag = 0x00080013
+	TagInstanceCreatorUID                  Tag = 0x00080014
+	TagSOPClassUID                         Tag = 0x00080016
+	TagSOPInstanceUID                      Tag = 0x00080018
+	TagStudyDate                           Tag = 0x00080020
+	TagSeriesDate                          Tag = 0x00080021
+	TagAcquisitionDate                     Tag = 0x00080022
+	TagContentDate                         Tag = 0x00080023
+	TagAcquisitionDateTime                 Tag = 0x0008002A
+	TagStudyTime                           Tag = 0x00080030
+	TagSeriesTime                          Tag = 0x00080031
+	TagAcquisitionTime                     Tag = 0x00080032
+	TagContentTime                         Tag = 0x00080033
+	TagAccessionNumber                     Tag = 0x00080050
+	TagModality                            Tag = 0x00080060
+	TagManufacturer                        Tag = 0x00080070
+	TagInstitutionName                     Tag = 0x00080080
+	TagInstitutionAddress                  Tag = 0x00080081
+	TagReferringPhysicianName              Tag = 0x00080090
+	TagReferringPhysicianAddress           Tag = 0x00080092
+	TagReferringPhysicianTelephoneNumbers  Tag = 0x00080094
+	TagStationName                         Tag = 0x00081010
+	TagStudyDescription                    Tag = 0x00081030
+	TagSeriesDescription                   Tag = 0x0008103E
+	TagInstitutionalDepartmentName         Tag = 0x00081040
+	TagPhysiciansOfRecord                  Tag = 0x00081048
+	TagPerformingPhysicianName             Tag = 0x00081050
+	TagNameOfPhysiciansReadingStudy        Tag = 0x00081060
+	TagOperatorsName                       Tag = 0x00081070
+	TagAdmittingDiagnosesDescription       Tag = 0x00081080
+	TagManufacturerModelName               Tag = 0x00081090
+	TagReferencedStudySequence             Tag = 0x00081110
+	TagReferencedPerformedProcedureStepSeq Tag = 0x00081111
+	TagReferencedSeriesSequence            Tag = 0x00081115
+	TagReferencedPatientSequence           Tag = 0x00081120
+	TagReferencedImageSequence             Tag = 0x00081140
+	TagReferencedSOPClassUID               Tag = 0x00081150
+	TagReferencedSOPInstanceUID            Tag = 0x00081155
+	TagDerivationDescription               Tag = 0x00082111
+	TagSourceImageSequence                 Tag = 0x00082112
+	TagIrradiationEventUID                 Tag = 0x00083010
+	TagPatientName                         Tag = 0x00100010
+	TagPatientID                           Tag = 0x00100020
+	TagIssuerOfPatientID                   Tag = 0x00100021
+	TagPatientBirthDate                    Tag = 0x00100030
+	TagPatientBirthTime                    Tag = 0x00100032
+	TagPatientSex                          Tag = 0x00100040
+	TagOtherPatientIDs                     Tag = 0x00101000
+	TagOtherPatientNames                   Tag = 0x00101001
+	TagOtherPatientIDsSequence             Tag = 0x00101002
+	TagPatientAge                          Tag = 0x00101010
+	TagPatientSize                         Tag = 0x00101020
+	TagPatientWeight                       Tag = 0x00101030
+	TagPatientAddress                      Tag = 0x00101040
+	TagPatientMotherBirthName              Tag = 0x00101060
+	TagMilitaryRank                        Tag = 0x00101080
+	TagMedicalRecordLocator                Tag = 0x00101090
+	TagCountryOfResidence                  Tag = 0x00102150
+	TagRegionOfResidence                   Tag = 0x00102152
+	TagPatientTelephoneNumbers             Tag = 0x00102154
+	TagEthnicGroup                         Tag = 0x00102160
+	TagOccupation                          Tag = 0x00102180
+	TagAdditionalPatientHistory            Tag = 0x001021B0
+	TagPatientReligiousPreference          Tag = 0x001021F0
+	TagPatientComments                     Tag = 0x00104000
+	TagPatientIdentityRemoved              Tag = 0x00120062
+	TagDeidentificationMethod              Tag = 0x00120063
+	TagBodyPartExamined                    Tag = 0x00180015
+	TagSliceThickness                      Tag = 0x00180050
+	TagKVP                                 Tag = 0x00180060
+	TagSpacingBetweenSlices                Tag = 0x00180088
+	TagDeviceSerialNumber                  Tag = 0x00181000
+	TagSoftwareVersions                    Tag = 0x00181020
+	TagProtocolName                        Tag = 0x00181030
+	TagReconstructionDiameter              Tag = 0x00181100
+	TagConvolutionKernel                   Tag = 0x00181210
+	TagPatientPosition                     Tag = 0x00185100
+	TagStudyInstanceUID                    Tag = 0x0020000D
+	TagSeriesInstanceUID                   Tag = 0x0020000E
+	TagStudyID                             Tag = 0x00200010
+	TagSeriesNumber                        Tag = 0x00200011
+	TagAcquisitionNumber                   Tag = 0x00200012
+	TagInstanceNumber                      Tag = 0x00200013
+	TagImagePositionPatient                Tag = 0x00200032
+	TagImageOrientationPatient             Tag = 0x00200037
+	TagFrameOfReferenceUID                 Tag = 0x00200052
+	TagSynchronizationFrameOfReferenceUID  Tag = 0x00200200
+	TagPositionReferenceIndicator          Tag = 0x00201040
+	TagSliceLocation                       Tag = 0x00201041
+	TagImageComments                       Tag = 0x00204000
+	TagSamplesPerPixel                     Tag = 0x00280002
+	TagPhotometricInterpretation           Tag = 0x00280004
+	TagPlanarConfiguration                 Tag = 0x00280006
+	TagNumberOfFrames                      Tag = 0x00280008
+	TagRows                                Tag = 0x00280010
+	TagColumns                             Tag = 0x00280011
+	TagPixelSpacing                        Tag = 0x00280030
+	TagBitsAllocated                       Tag = 0x00280100
+	TagBitsStored                          Tag = 0x00280101
+	TagHighBit                             Tag = 0x00280102
+	TagPixelRepresentation                 Tag = 0x00280103
+	TagBurnedInAnnotation                  Tag = 0x00280301
+	TagWindowCenter                        Tag = 0x00281050
+	TagWindowWidth                         Tag = 0x00281051
+	TagRescaleIntercept                    Tag = 0x00281052
+	TagRescaleSlope                        Tag = 0x00281053
+	TagRescaleType                         Tag = 0x00281054
+	TagLongitudinalTemporalInfoModified    Tag = 0x00280303
+	TagLossyImageCompression               Tag = 0x00282110
+	TagRequestingPhysician                 Tag = 0x00321032
+	TagRequestedProcedureDescription       Tag = 0x00321060
+	TagStudyComments                       Tag = 0x00324000
+	TagAdmissionID                         Tag = 0x00380010
+	TagPerformedProcedureStepStartDate     Tag = 0x00400244
+	TagPerformedProcedureStepStartTime     Tag = 0x00400245
+	TagPerformedProcedureStepID            Tag = 0x00400253
+	TagPerformedProcedureStepDescription   Tag = 0x00400254
+	TagRequestAttributesSequence           Tag = 0x00400275
+	TagRequestedProcedureID                Tag = 0x00401001
+	TagContentSequence                     Tag = 0x0040A730
+	TagPixelData                           Tag = 0x7FE00010
+	TagItem                                Tag = 0xFFFEE000
+	TagItemDelimitationItem                Tag = 0xFFFEE00D
+	TagSequenceDelimitationItem            Tag = 0xFFFEE0DD
+	TagDataSetTrailingPadding              Tag = 0xFFFCFFFC
+	TagReferencedFrameOfReferenceUID       Tag = 0x30060024
+	TagRelatedFrameOfReferenceUID          Tag = 0x300600C2
+	TagReferencedFrameOfReferenceSequence  Tag = 0x30060010
+	TagRTReferencedStudySequence           Tag = 0x30060012
+	TagRTReferencedSeriesSequence          Tag = 0x30060014
+	TagContourImageSequence                Tag = 0x30060016
+	TagStorageMediaFileSetUID              Tag = 0x00880140
+	TagUID                                 Tag = 0x0040A124
+	TagDimensionOrganizationUID            Tag = 0x00209164
+	TagConcatenationUID                    Tag = 0x00209161
+)
+
+// tagInfo is a dictionary entry: the VR used for Implicit VR decoding and the DICOM keyword.
+type tagInfo struct {
+	vr   VR
+	name string
+}
+
+// dictionary is the subset of the PS3.6 data dictionary this service needs. It is used to
+// resolve VRs when parsing Implicit VR Little Endian datasets; unknown tags decode as UN.
+var dictionary = map[Tag]tagInfo{
+	TagFileMetaInformationGroupLength:      {VRUL, "FileMetaInformationGroupLength"},
+	TagFileMetaInformationVersion:          {VROB, "FileMetaInformationVersion"},
+	TagMediaStorageSOPClassUID:             {VRUI, "MediaStorageSOPClassUID"},
+	TagMediaStorageSOPInstanceUID:          {VRUI, "MediaStorageSOPInstanceUID"},
+	TagTransferSyntaxUID:                   {VRUI, "TransferSyntaxUID"},
+	TagImplementationClassUID:              {VRUI, "ImplementationClassUID"},
+	TagImplementationVersionName:           {VRSH, "ImplementationVersionName"},
+	TagSourceApplicationEntityTitle:        {VRAE, "SourceApplicationEntityTitle"},
+	TagFileSetID:                           {VRCS, "FileSetID"},
+	TagDirectoryRecordSequence:             {VRSQ, "DirectoryRecordSequence"},
+	TagDirectoryRecordType:                 {VRCS, "DirectoryRecordType"},
+	TagReferencedFileID:                    {VRCS, "ReferencedFileID"},
+	TagReferencedSOPClassUIDInFile:         {VRUI, "ReferencedSOPClassUIDInFile"},
+	TagReferencedSOPInstanceUIDInFile:      {VRUI, "ReferencedSOPInstanceUIDInFile"},
+	TagReferencedTransferSyntaxUIDInFile:   {VRUI, "ReferencedTransferSyntaxUIDInFile"},
+	TagSpecificCharacterSet:                {VRCS, "SpecificCharacterSet"},
+	TagImageType:                           {VRCS, "ImageType"},
+	TagInstanceCreationDate:                {VRDA, "InstanceCreationDate"},
+	TagInstanceCreationTime:                {VRTM, "InstanceCreationTime"},
+	TagInstanceCreatorUID:                  {VRUI, "InstanceCreatorUID"},
+	TagSOPClassUID:                         {VRUI, "SOPClassUID"},
+	TagSOPInstanceUID:                      {VRUI, "SOPInstanceUID"},
+	TagStudyDate:                           {VRDA, "StudyDate"},
+	TagSeriesDate:                          {VRDA, "SeriesDate"},
+	TagAcquisitionDate:                     {VRDA, "AcquisitionDate"},
+	TagContentDate:                         {VRDA, "ContentDate"},
+	TagAcquisitionDateTime:                 {VRDT, "AcquisitionDateTime"},
+	TagStudyTime:                           {VRTM, "StudyTime"},
+	TagSeriesTime:                          {VRTM, "SeriesTime"},
+	TagAcquisitionTime:                     {VRTM, "AcquisitionTime"},
+	TagContentTime:                         {VRTM, "ContentTime"},
+	TagAccessionNumber:                     {VRSH, "AccessionNumber"},
+	TagModality:                            {VRCS, "Modality"},
+	TagManufacturer:                        {VRLO, "Manufacturer"},
+	TagInstitutionName:                     {VRLO, "InstitutionName"},
+	TagInstitutionAddress:                  {VRST, "InstitutionAddress"},
+	TagReferringPhysicianName:              {VRPN, "ReferringPhysicianName"},
+	TagReferringPhysicianAddress:           {VRST, "ReferringPhysicianAddress"},
+	TagReferringPhysicianTelephoneNumbers:  {VRSH, "ReferringPhysicianTelephoneNumbers"},
+	TagStationName:                         {VRSH, "StationName"},
+	TagStudyDescription:                    {VRLO, "StudyDescription"},
+	TagSeriesDescription:                   {VRLO, "SeriesDescription"},
+	TagInstitutionalDepartmentName:         {VRLO, "InstitutionalDepartmentName"},
+	TagPhysiciansOfRecord:                  {VRPN, "PhysiciansOfRecord"},
+	TagPerformingPhysicianName:             {VRPN, "PerformingPhysicianName"},
+	TagNameOfPhysiciansReadingStudy:        {VRPN, "NameOfPhysiciansReadingStudy"},
+	TagOperatorsName:                       {VRPN, "OperatorsName"},
+	TagAdmittingDiagnosesDescription:       {VRLO, "AdmittingDiagnosesDescription"},
+	TagManufacturerModelName:               {VRLO, "ManufacturerModelName"},
+	TagReferencedStudySequence:             {VRSQ, "ReferencedStudySequence"},
+	TagReferencedPerformedProcedureStepSeq: {VRSQ, "ReferencedPerformedProcedureStepSequence"},
+	TagReferencedSeriesSequence:            {VRSQ, "ReferencedSeriesSequence"},
+	TagReferencedPatientSequence:           {VRSQ, "ReferencedPatientSequence"},
+	TagReferencedImageSequence:             {VRSQ, "ReferencedImageSequence"},
+	TagReferencedSOPClassUID:               {VRUI, "ReferencedSOPClassUID"},
+	TagReferencedSOPInstanceUID:            {VRUI, "ReferencedSOPInstanceUID"},
+	TagDerivationDescription:               {VRST, "DerivationDescription"},
+	TagSourceImageSequence:                 {VRSQ, "SourceImageSequence"},
+	TagIrradiationEventUID:                 {VRUI, "IrradiationEventUID"},
+	TagPatientName:                         {VRPN, "PatientName"},
+	TagPatientID:                           {VRLO, "PatientID"},
+	TagIssuerOfPatientID:                   {VRLO, "IssuerOfPatientID"},
+	TagPatientBirthDate:                    {VRDA, "PatientBirthDate"},
+	TagPatientBirthTime:                    {VRTM, "PatientBirthTime"},
+	TagPatientSex:                          {VRCS, "PatientSex"},
+	TagOtherPatientIDs:                     {VRLO, "OtherPatientIDs"},
+	TagOtherPatientNames:                   {VRPN, "OtherPatientNames"},
+	TagOtherPatientIDsSequence:             {VRSQ, "OtherPatientIDsSequence"},
+	TagPatientAge:                          {VRAS, "PatientAge"},
+	TagPatientSize:                         {VRDS, "PatientSize"},
+	TagPatientWeight:                       {VRDS, "PatientWeight"},
+	TagPatientAddress:                      {VRLO, "PatientAddress"},
+	TagPatientMotherBirthName:              {VRPN, "PatientMotherBirthName"},
+	TagMilitaryRank:                        {VRLO, "MilitaryRank"},
+	TagMedicalRecordLocator:                {VRLO, "MedicalRecordLocator"},
+	TagCountryOfResidence:                  {VRLO, "CountryOfResidence"},
+	TagRegionOfResidence:                   {VRLO, "RegionOfResidence"},
+	TagPatientTelephoneNumbers:             {VRSH, "PatientTelephoneNumbers"},
+	TagEthnicGroup:                         {VRSH, "EthnicGroup"},
+	TagOccupation:                          {VRSH, "Occupation"},
+	TagAdditionalPatientHistory:            {VRLT, "AdditionalPatientHistory"},
+	TagPatientReligiousPreference:          {VRLO, "PatientReligiousPreference"},
+	TagPatientComments:                     {VRLT, "PatientComments"},
+	TagPatientIdentityRemoved:              {VRCS, "PatientIdentityRemoved"},
+	TagDeidentificationMethod:              {VRLO, "DeidentificationMethod"},
+	TagBodyPartExamined:                    {VRCS, "BodyPartExamined"},
+	TagSliceThickness:                      {VRDS, "SliceThickness"},
+	TagKVP:                                 {VRDS, "KVP"},
+	TagSpacingBetweenSlices:                {VRDS, "SpacingBetweenSlices"},
+	TagDeviceSerialNumber:                  {VRLO, "DeviceSerialNumber"},
+	TagSoftwareVersions:                    {VRLO, "SoftwareVersions"},
+	TagProtocolName:                        {VRLO, "ProtocolName"},
+	TagReconstructionDiameter:              {VRDS, "ReconstructionDiameter"},
+	TagConvolutionKernel:                   {VRSH, "ConvolutionKernel"},
+	TagPatientPosition:                     {VRCS, "PatientPosition"},
+	TagStudyInstanceUID:                    {VRUI, "StudyInstanceUID"},
+	TagSeriesInstanceUID:                   {VRUI, "SeriesInstanceUID"},
+	TagStudyID:                             {VRSH, "StudyID"},
+	TagSeriesNumber:                        {VRIS, "SeriesNumber"},
+	TagAcquisitionNumber:                   {VRIS, "AcquisitionNumber"},
+	TagInstanceNumber:                      {VRIS, "InstanceNumber"},
+	TagImagePositionPatient:                {VRDS, "ImagePositionPatient"},
+	TagImageOrientationPatient:             {VRDS, "ImageOrientationPatient"},
+	TagFrameOfReferenceUID:                 {VRUI, "FrameOfReferenceUID"},
+	TagSynchronizationFrameOfReferenceUID:  {VRUI, "SynchronizationFrameOfReferenceUID"},
+	TagPositionReferenceIndicator:          {VRLO, "PositionReferenceIndicator"},
+	TagSliceLocation:                       {VRDS, "SliceLocation"},
+	TagImageComments:                       {VRLT, "ImageComments"},
+	TagSamplesPerPixel:                     {VRUS, "SamplesPerPixel"},
+	TagPhotometricInterpretation:           {VRCS, "PhotometricInterpretation"},
+	TagPlanarConfiguration:                 {VRUS, "PlanarConfiguration"},
+	TagNumberOfFrames:                      {VRIS, "NumberOfFrames"},
+	TagRows:                                {VRUS, "Rows"},
+	TagColumns:                             {VRUS, "Columns"},
+	TagPixelSpacing:                        {VRDS, "PixelSpacing"},
+	TagBitsAllocated:                       {VRUS, "BitsAllocated"},
+	TagBitsStored:                          {VRUS, "BitsStored"},
+	TagHighBit:                             {VRUS, "HighBit"},
+	TagPixelRepresentation:                 {VRUS, "PixelRepresentation"},
+	TagBurnedInAnnotation:                  {VRCS, "BurnedInAnnotation"},
+	TagWindowCenter:                        {VRDS, "WindowCenter"},
+	TagWindowWidth:                         {VRDS, "WindowWidth"},
+	TagRescaleIntercept:                    {VRDS, "RescaleIntercept"},
+	TagRescaleSlope:                        {VRDS, "RescaleSlope"},
+	TagRescaleType:                         {VRLO, "RescaleType"},
+	TagLongitudinalTemporalInfoModified:    {VRCS, "LongitudinalTemporalInformationModified"},
+	TagLossyImageCompression:               {VRCS, "LossyImageCompression"},
+	TagRequestingPhysician:                 {VRPN, "RequestingPhysician"},
+	TagRequestedProcedureDescription:       {VRLO, "RequestedProcedureDescription"},
+	TagStudyComments:                       {VRLT, "StudyComments"},
+	TagAdmissionID:                         {VRLO, "AdmissionID"},
+	TagPerformedProcedureStepStartDate:     {VRDA, "PerformedProcedureStepStartDate"},
+	TagPerformedProcedureStepStartTime:     {VRTM, "PerformedProcedureStepStartTime"},
+	TagPerformedProcedureStepID:            {VRSH, "PerformedProcedureStepID"},
+	TagPerformedProcedureStepDescription:   {VRLO, "PerformedProcedureStepDescription"},
+	TagRequestAttributesSequence:           {VRSQ, "RequestAttributesSequence"},
+	TagRequestedProcedureID:                {VRSH, "RequestedProcedureID"},
+	TagContentSequence:                     {VRSQ, "ContentSequence"},
+	TagPixelData:                           {VROW, "PixelData"},
+	TagDataSetTrailingPadding:              {VROB, "DataSetTrailingPadding"},
+	TagReferencedFrameOfReferenceUID:       {VRUI, "ReferencedFrameOfReferenceUID"},
+	TagRelatedFrameOfReferenceUID:          {VRUI, "RelatedFrameOfReferenceUID"},
+	TagReferencedFrameOfReferenceSequence:  {VRSQ, "ReferencedFrameOfReferenceSequence"},
+	TagRTReferencedStudySequence:           {VRSQ, "RTReferencedStudySequence"},
+	TagRTReferencedSeriesSequence:          {VRSQ, "RTReferencedSeriesSequence"},
+	TagContourImageSequence:                {VRSQ, "ContourImageSequence"},
+	TagStorageMediaFileSetUID:              {VRUI, "StorageMediaFileSetUID"},
+	TagUID:                                 {VRUI, "UID"},
+	TagDimensionOrganizationUID:            {VRUI, "DimensionOrganizationUID"},
+	TagConcatenationUID:                    {VRUI, "ConcatenationUID"},
+}
+
+// LookupVR returns the dictionary VR for a tag. Group length elements are UL, private creators
+// are LO and any other unknown tag is UN, matching how PS3.5 treats Implicit VR data.
+func LookupVR(tag Tag) VR {
+	if info, ok := dictionary[tag]; ok {
+		return info.vr
+	}
+	switch {
+	case tag.IsGroupLength():
+		return VRUL
+	case tag.IsPrivateCreator():
+		return VRLO
+	}
+	return VRUN
+}
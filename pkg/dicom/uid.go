@@ -0,0 +1,14 @@
+// pkg/dicom/uid.go
+package dicom
+
+// Transfer Syntax UIDs (PS3.6 Annex A) the parser recognizes.
+const (
+	ImplicitVRLittleEndian         = "1.2.840.10008.1.2"
+	ExplicitVRLittleEndian         = "1.2.840.10008.1.2.1"
+	DeflatedExplicitVRLittleEndian = "1.2.840.10008.1.2.1.99"
+	ExplicitVRBigEndian            = "1.2.840.10008.1.2.2" // Retired; not supported
+	RLELossless                    = "1.2.840.10008.1.2.5"
+)
+
+// MediaStorageDirectoryStorage is the SOP Class UID of a DICOMDIR file.
+const MediaStorageDirectoryStorage = "1.2.840.10008.1.3.10"
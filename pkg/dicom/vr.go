@@ -0,0 +1,73 @@
+// pkg/dicom/vr.go
+package dicom
+
+// VR is a DICOM Value Representation, the two-character code describing how an element's value is encoded (PS3.5 section 6.2).
+type VR string
+
+// Value Representations defined by PS3.5.
+const (
+	VRAE VR = "AE" // Application Entity
+	VRAS VR = "AS" // Age String
+	VRAT VR = "AT" // Attribute Tag
+	VRCS VR = "CS" // Code String
+	VRDA VR = "DA" // Date
+	VRDS VR = "DS" // Decimal String
+	VRDT VR = "DT" // Date Time
+	VRFD VR = "FD" // Floating Point Double
+	VRFL VR = "FL" // Floating Point Single
+	VRIS VR = "IS" // Integer String
+	VRLO VR = "LO" // Long String
+	VRLT VR = "LT" // Long Text
+	VROB VR = "OB" // Other Byte
+	VROD VR = "OD" // Other Double
+	VROF VR = "OF" // Other Float
+	VROL VR = "OL" // Other Long
+	VROV VR = "OV" // Other 64-bit Very Long
+	VROW VR = "OW" // Other Word
+	VRPN VR = "PN" // Person Name
+	VRSH VR = "SH" // Short String
+	VRSL VR = "SL" // Signed Long
+	VRSQ VR = "SQ" // Sequence of Items
+	VRSS VR = "SS" // Signed Short
+	VRST VR = "ST" // Short Text
+	VRSV VR = "SV" // Signed 64-bit Very Long
+	VRTM VR = "TM" // Time
+	VRUC VR = "UC" // Unlimited Characters
+	VRUI VR = "UI" // Unique Identifier
+	VRUL VR = "UL" // Unsigned Long
+	VRUN VR = "UN" // Unknown
+	VRUR VR = "UR" // Universal Resource Identifier
+	VRUS VR = "US" // Unsigned Short
+	VRUT VR = "UT" // Unlimited Text
+	VRUV VR = "UV" // Unsigned 64-bit Very Long
+)
+
+// hasLongLength reports whether, in Explicit VR encodings, the VR is followed by two reserved
+// bytes and a 32-bit length instead of a 16-bit length (PS3.5 section 7.1.2).
+func (vr VR) hasLongLength() bool {
+	switch vr {
+	case VROB, VROD, VROF, VROL, VROV, VROW, VRSQ, VRUC, VRUN, VRUR, VRUT, VRSV, VRUV:
+		return true
+	}
+	return false
+}
+
+// IsString reports whether the VR holds character data (as opposed to binary numbers, bulk data or sequences).
+func (vr VR) IsString() bool {
+	switch vr {
+	case VRAE, VRAS, VRCS, VRDA, VRDS, VRDT, VRIS, VRLO, VRLT, VRPN, VRSH, VRST, VRTM, VRUC, VRUI, VRUR, VRUT:
+		return true
+	}
+	return false
+}
+
+// isMultiValued reports whether a string VR may carry several values separated by a backslash.
+// Text VRs (LT, ST, UT, UR) are always single-valued and may legitimately contain backslashes.
+func (vr VR) isMultiValued() bool {
+	return vr.IsString() && vr != VRLT && vr != VRST && vr != VRUT && vr != VRUR
+}
+
+// isValidVR reports whether the two bytes read from an Explicit VR element form a plausible VR code.
+func isValidVR(b []byte) bool {
+	return len(b) == 2 && b[0] >= 'A' && b[0] <= 'Z' && b[1] >= 'A' && b[1] <= 'Z'
+}
@@ -0,0 +1,94 @@
+// pkg/hl7/escape.go
+package hl7
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineSkip bounds the line feeds written for one \.sp n\ formatting command.
+const maxLineSkip = 10
+
+// unescape resolves the escape sequences of a value (HL7 v2.5, 2.7): the delimiters (\F\, \S\, \T\, \R\, \E\),
+// hexadecimal data (\Xhh...\) and the line breaks of formatted text (\.br\, \.sp\, \.ce\). Highlighting, the
+// other formatting commands and character set switches are dropped, as text is read without them; sequences
+// that are unknown or not terminated are kept as they are.
+func unescape(value string, d Delimiters) string {
+	if strings.IndexByte(value, d.Escape) < 0 {
+		return value
+	}
+	var text strings.Builder
+	for i := 0; i < len(value); {
+		if value[i] != d.Escape {
+			text.WriteByte(value[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], d.Escape)
+		if end < 0 {
+			text.WriteString(value[i:])
+			break
+		}
+		sequence := value[i+1 : i+1+end]
+		if resolved, ok := resolveEscape(sequence, d); ok {
+			text.WriteString(resolved)
+		} else {
+			text.WriteString(value[i : i+end+2])
+		}
+		i += end + 2
+	}
+	return text.String()
+}
+
+// resolveEscape returns the text an escape sequence (without its escape characters) stands for.
+func resolveEscape(sequence string, d Delimiters) (string, bool) {
+	switch sequence {
+	case "F":
+		return string(d.Field), true
+	case "S":
+		return string(d.Component), true
+	case "T":
+		return string(d.Subcomponent), true
+	case "R":
+		return string(d.Repetition), true
+	case "E":
+		return string(d.Escape), true
+	case "H", "N": // Start and end of highlighting
+		return "", true
+	case ".br", ".ce": // Line break; centered text starts on a new line
+		return "\n", true
+	case ".fi", ".nf": // Word wrap on and off
+		return "", true
+	}
+	if sequence == "" {
+		return "", false
+	}
+	switch {
+	case strings.HasPrefix(sequence, ".sp"):
+		lines := 1
+		if n, err := strconv.Atoi(strings.TrimSpace(sequence[3:])); err == nil && n > 0 {
+			lines = min(n, maxLineSkip)
+		}
+		return strings.Repeat("\n", lines), true
+	case strings.HasPrefix(sequence, ".in"), strings.HasPrefix(sequence, ".ti"): // Indentation
+		return "", true
+	case sequence[0] == 'X':
+		data, err := hex.DecodeString(sequence[1:])
+		if err != nil {
+			return "", false
+		}
+		if utf8.Valid(data) {
+			return string(data), true
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b) // ISO 8859-1
+		}
+		return string(runes), true
+	case sequence[0] == 'C', sequence[0] == 'M', sequence[0] == 'Z': // Character set switches, local escapes
+		return "", true
+	}
+	return "", false
+}
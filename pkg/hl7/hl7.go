@@ -0,0 +1,315 @@
+// pkg/hl7/hl7.go
+
+// Package hl7 reads HL7 version 2 messages in their pipe-delimited encoding (ER7): the segments of each message,
+// split into fields, repetitions, components and subcomponents with the delimiters the message declares in its MSH
+// segment, and with escape sequences resolved. Files holding several messages (batches, with or without their
+// FHS/BHS headers) and messages framed for MLLP transport are read too. The package knows the encoding only, not
+// the message structures: callers pick the segments and fields they need.
+package hl7
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNotHL7 is returned for data that does not start with an MSH (or batch header) segment.
+	ErrNotHL7 = errors.New("hl7: not an HL7 v2 message")
+	// ErrBadHeader is returned for MSH segments without encoding characters.
+	ErrBadHeader = errors.New("hl7: malformed MSH segment")
+)
+
+// MLLP framing bytes, which enclose each message sent over the Minimal Lower Layer Protocol.
+const (
+	mllpStart = 0x0B
+	mllpEnd   = 0x1C
+)
+
+// Delimiters are the encoding characters of a message, declared in MSH-1 and MSH-2.
+type Delimiters struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	Subcomponent byte
+}
+
+// DefaultDelimiters are the delimiters recommended by the standard, used by almost every sender.
+var DefaultDelimiters = Delimiters{Field: '|', Component: '^', Repetition: '~', Escape: '\\', Subcomponent: '&'}
+
+// Message is one message: its MSH segment and the segments that follow it, in order.
+type Message struct {
+	Delimiters Delimiters
+	Segments   []*Segment
+}
+
+// Segment is one segment of a message.
+type Segment struct {
+	Name   string
+	fields []Field // fields[i] is field i; fields[0] is unused
+}
+
+// Field is a field, as its repetitions.
+type Field []Repetition
+
+// Repetition is one repetition of a field, as its components.
+type Repetition []Component
+
+// Component is one component of a field, as its subcomponents, with escape sequences resolved.
+type Component []string
+
+// Header returns the MSH segment of the message.
+func (m *Message) Header() *Segment {
+	return m.Segments[0]
+}
+
+// Type returns the message type and trigger event of the message (MSH-9), e.g. "ORU" and "R01".
+func (m *Message) Type() (messageType, triggerEvent string) {
+	field := m.Header().Field(9)
+	return field.Component(1), field.Component(2)
+}
+
+// Field returns field n of the segment, numbered as in the standard from 1; nil if the segment has no such field.
+// In MSH, field 1 is the field separator and field 2 the encoding characters, both as they are written.
+func (s *Segment) Field(n int) Field {
+	if n < 1 || n >= len(s.fields) {
+		return nil
+	}
+	return s.fields[n]
+}
+
+// String returns the first component of the first repetition of the field: the whole value of fields that have
+// neither repetitions nor components.
+func (f Field) String() string {
+	return f.Component(1)
+}
+
+// Component returns component n (from 1) of the first repetition of the field.
+func (f Field) Component(n int) string {
+	if len(f) == 0 {
+		return ""
+	}
+	return f[0].Component(n)
+}
+
+// Component returns the first subcomponent of component n (from 1) of the repetition.
+func (r Repetition) Component(n int) string {
+	if n < 1 || n > len(r) || len(r[n-1]) == 0 {
+		return ""
+	}
+	return r[n-1][0]
+}
+
+// Join returns the repetition as one string, with its components and subcomponents separated by the delimiters d
+// but not escaped again. It recovers the text of text fields whose senders did not escape the delimiters in it.
+func (r Repetition) Join(d Delimiters) string {
+	components := make([]string, len(r))
+	for i, component := range r {
+		components[i] = strings.Join(component, string(d.Subcomponent))
+	}
+	return strings.TrimRight(strings.Join(components, string(d.Component)), string(d.Component))
+}
+
+// IsMessage reports whether data starts like an HL7 v2 message or batch: with an MSH, FHS or BHS segment, after an
+// optional byte order mark, MLLP start byte and leading whitespace.
+func IsMessage(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	data = bytes.TrimLeft(data, "\x0B \t\r\n")
+	if len(data) < 8 {
+		return false
+	}
+	switch string(data[:3]) {
+	case "MSH", "FHS", "BHS":
+	default:
+		return false
+	}
+	separator := data[3]
+	return separator > ' ' && separator < 0x7F && !isAlphanumeric(separator) && data[4] != separator
+}
+
+// Parse reads the messages of data. Segments are separated by carriage returns, as the standard has it, or by line
+// feeds, as files edited on other systems have them; lines that do not start with a segment name continue the
+// segment before them. Batch segments (FHS, BHS, BTS, FTS) are skipped. Data that is not valid UTF-8 is read as
+// ISO 8859-1.
+func Parse(data []byte) ([]*Message, error) {
+	var messages []*Message
+	var current *Message
+	for i, raw := range splitSegments(decode(data)) {
+		switch name := raw[:3]; {
+		case name == "MSH":
+			message, err := parseHeader(raw)
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", i+1, err)
+			}
+			current = message
+			messages = append(messages, current)
+		case isBatchSegment(name):
+			current = nil // Batch trailers end the message before them
+		case current == nil:
+			return nil, ErrNotHL7
+		default:
+			current.Segments = append(current.Segments, parseSegment(raw, current.Delimiters))
+		}
+	}
+	if len(messages) == 0 {
+		return nil, ErrNotHL7
+	}
+	return messages, nil
+}
+
+// RemoveSegments returns data without the segments with the given names (and the lines continuing them), with the
+// remaining segments separated by carriage returns. MLLP framing is dropped.
+func RemoveSegments(data []byte, names ...string) []byte {
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+	}
+	var kept bytes.Buffer
+	for _, raw := range splitSegments(decode(data)) {
+		if removed[raw[:3]] {
+			continue
+		}
+		kept.WriteString(raw)
+		kept.WriteByte('\r')
+	}
+	return kept.Bytes()
+}
+
+// decode returns data as a string without a byte order mark or MLLP framing, decoding it as ISO 8859-1 if it is
+// not UTF-8.
+func decode(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	data = bytes.TrimPrefix(bytes.TrimLeft(data, "\x0B \t\r\n"), []byte("\xEF\xBB\xBF"))
+	data = bytes.ReplaceAll(data, []byte{mllpStart}, []byte{'\r'}) // Frames end up as blank lines
+	data = bytes.ReplaceAll(data, []byte{mllpEnd}, []byte{'\r'})
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// splitSegments splits text into segments, joining lines that do not start with a segment name to the segment
+// before them with a line feed (senders wrap long text fields). Blank lines are dropped. Every segment returned is
+// at least three bytes long.
+func splitSegments(text string) []string {
+	var segments []string
+	separator := DefaultDelimiters.Field
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if isHeaderLine(line) {
+			separator = line[3]
+		}
+		if !startsSegment(line, separator) && len(segments) > 0 {
+			segments[len(segments)-1] += "\n" + line
+			continue
+		}
+		if len(line) < 3 {
+			line += strings.Repeat(" ", 3-len(line)) // Garbage before the first segment, rejected by Parse
+		}
+		segments = append(segments, line)
+	}
+	return segments
+}
+
+// isHeaderLine reports whether a line is an MSH, FHS or BHS segment, which declare the delimiters.
+func isHeaderLine(line string) bool {
+	return len(line) > 3 && (strings.HasPrefix(line, "MSH") || strings.HasPrefix(line, "FHS") || strings.HasPrefix(line, "BHS"))
+}
+
+// startsSegment reports whether a line starts with a segment name: three upper-case letters or digits, the first
+// a letter, followed by the field separator or by nothing.
+func startsSegment(line string, separator byte) bool {
+	if len(line) < 3 || line[0] < 'A' || line[0] > 'Z' {
+		return false
+	}
+	for i := 1; i < 3; i++ {
+		if !(line[i] >= 'A' && line[i] <= 'Z' || line[i] >= '0' && line[i] <= '9') {
+			return false
+		}
+	}
+	return len(line) == 3 || line[3] == separator || isHeaderLine(line)
+}
+
+func isBatchSegment(name string) bool {
+	return name == "FHS" || name == "BHS" || name == "BTS" || name == "FTS"
+}
+
+func isAlphanumeric(b byte) bool {
+	return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
+
+// parseHeader reads an MSH segment, which starts a message and declares its delimiters: the field separator right
+// after the segment name, and the component, repetition, escape and subcomponent characters as MSH-2. Characters
+// MSH-2 leaves out keep their default.
+func parseHeader(raw string) (*Message, error) {
+	if len(raw) < 5 {
+		return nil, ErrBadHeader
+	}
+	d := DefaultDelimiters
+	d.Field = raw[3]
+	encoding, _, _ := strings.Cut(raw[4:], string(d.Field))
+	if encoding == "" {
+		return nil, ErrBadHeader
+	}
+	for i, target := range []*byte{&d.Component, &d.Repetition, &d.Escape, &d.Subcomponent} {
+		if i < len(encoding) {
+			*target = encoding[i]
+		}
+	}
+
+	// MSH-1 and MSH-2 are taken as written; the fields after them are read like those of any other segment.
+	segment := parseSegment("MSH"+raw[4+len(encoding):], d)
+	fields := make([]Field, 0, len(segment.fields)+2)
+	fields = append(fields, nil, literal(string(d.Field)), literal(encoding))
+	if len(segment.fields) > 1 {
+		fields = append(fields, segment.fields[1:]...)
+	}
+	segment.fields = fields
+	return &Message{Delimiters: d, Segments: []*Segment{segment}}, nil
+}
+
+// parseSegment splits a segment into its fields.
+func parseSegment(raw string, d Delimiters) *Segment {
+	values := strings.Split(raw, string(d.Field))
+	segment := &Segment{Name: strings.TrimSpace(values[0]), fields: make([]Field, len(values))}
+	for i, value := range values[1:] {
+		segment.fields[i+1] = parseField(value, d)
+	}
+	return segment
+}
+
+// parseField splits a field into repetitions, components and subcomponents, and resolves the escape sequences of
+// each subcomponent. Escape sequences never contain delimiters, so splitting first is safe.
+func parseField(value string, d Delimiters) Field {
+	if value == "" {
+		return nil
+	}
+	repetitions := strings.Split(value, string(d.Repetition))
+	field := make(Field, len(repetitions))
+	for i, repetition := range repetitions {
+		components := strings.Split(repetition, string(d.Component))
+		field[i] = make(Repetition, len(components))
+		for j, component := range components {
+			subcomponents := strings.Split(component, string(d.Subcomponent))
+			for k, subcomponent := range subcomponents {
+				subcomponents[k] = unescape(subcomponent, d)
+			}
+			field[i][j] = subcomponents
+		}
+	}
+	return field
+}
+
+// literal is a field holding value as it is, without splitting or unescaping it.
+func literal(value string) Field {
+	return Field{Repetition{Component{value}}}
+}
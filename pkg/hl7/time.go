@@ -0,0 +1,52 @@
+// pkg/hl7/time.go
+package hl7
+
+import (
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts of the date and time types (DT, DTM, TS) by the number of digits given: a value may
+// stop at any precision from the year to the second.
+var timeLayouts = map[int]string{
+	4:  "2006",
+	6:  "200601",
+	8:  "20060102",
+	10: "2006010215",
+	12: "200601021504",
+	14: "20060102150405",
+}
+
+// ParseTime parses a date or date and time value, YYYY[MM[DD[HH[MM[SS[.S[S[S[S]]]]]]]]][+/-ZZZZ], such as the first
+// component of a TS field. Values without a time zone offset are read as UTC.
+func ParseTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	zone := ""
+	if i := strings.IndexAny(value, "+-"); i >= 4 {
+		value, zone = value[:i], value[i:]
+	}
+	fraction := ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value, fraction = value[:i], value[i:]
+	}
+	layout, ok := timeLayouts[len(value)]
+	if !ok || (fraction != "" && len(value) != 14) {
+		return time.Time{}, false
+	}
+	if fraction != "" {
+		layout += ".9999"
+		value += fraction
+	}
+	if zone != "" {
+		if len(zone) != 5 {
+			return time.Time{}, false
+		}
+		layout += "-0700"
+		value += zone
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,293 @@
+// pkg/pdftext/content.go
+package pdftext
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+)
+
+// maxFormDepth bounds the nesting of form XObjects, which may reference each other.
+const maxFormDepth = 8
+
+// matrix is a PDF transformation matrix [a b c d e f] (ISO 32000-1, 8.3.4).
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n: the transformation m followed by n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func translation(tx, ty float64) matrix {
+	return matrix{1, 0, 0, 1, tx, ty}
+}
+
+// glyph is a character shown on a page, positioned in device space.
+type glyph struct {
+	text string
+	x, y float64 // Origin
+	endX float64 // Origin of the next glyph
+	size float64 // Font size in device space
+}
+
+// graphicsState is the part of the graphics state that positions text (8.4, 9.3).
+type graphicsState struct {
+	ctm                                      matrix
+	font                                     *font
+	fontSize, charSpace, wordSpace, hScaling float64
+	leading, rise                            float64
+}
+
+// interpreter runs the content streams of a page and collects its glyphs.
+type interpreter struct {
+	doc       *document
+	state     graphicsState
+	saved     []graphicsState
+	tm, tlm   matrix // Text matrix and text line matrix
+	glyphs    []glyph
+	unmapped  int
+	images    int
+	formDepth int
+}
+
+func newInterpreter(doc *document) *interpreter {
+	return &interpreter{doc: doc, state: graphicsState{ctm: identity, font: fallbackFont, hScaling: 1}, tm: identity, tlm: identity}
+}
+
+// inlineImageEnd matches the EI operator ending the data of an inline image.
+var inlineImageEnd = regexp.MustCompile(`[\x00\t\n\f\r ]EI(?:[\x00\t\n\f\r ]|$)`)
+
+// run interprets a content stream with its resources. Syntax errors end the stream, keeping the glyphs shown so far.
+func (in *interpreter) run(content []byte, resources dict) {
+	l := &lexer{data: content}
+	var operands []object
+	for {
+		token, err := l.token()
+		if err != nil {
+			return
+		}
+		op, isOperator := token.(keyword)
+		if !isOperator {
+			operand, err := l.objectFrom(token)
+			if err != nil {
+				return
+			}
+			operands = append(operands, operand)
+			continue
+		}
+		if op == "BI" {
+			// Inline image: skip its dictionary and binary data
+			in.images++
+			i := bytes.Index(content[l.pos:], []byte("ID"))
+			if i < 0 {
+				return
+			}
+			end := inlineImageEnd.FindIndex(content[l.pos+i+2:])
+			if end == nil {
+				return
+			}
+			l.pos += i + 2 + end[1]
+			operands = operands[:0]
+			continue
+		}
+		in.execute(op, operands, resources)
+		operands = operands[:0]
+	}
+}
+
+// execute runs one operator. Operators that do not affect text positions are ignored.
+func (in *interpreter) execute(op keyword, operands []object, resources dict) {
+	nums := make([]float64, len(operands))
+	for i, operand := range operands {
+		nums[i], _ = number(operand)
+	}
+	s := &in.state
+	switch op {
+	// Graphics state
+	case "q":
+		in.saved = append(in.saved, in.state)
+	case "Q":
+		if n := len(in.saved); n > 0 {
+			in.state, in.saved = in.saved[n-1], in.saved[:n-1]
+		}
+	case "cm":
+		if len(nums) == 6 {
+			s.ctm = matrix(nums).mul(s.ctm)
+		}
+
+	// Text objects and state
+	case "BT":
+		in.tm, in.tlm = identity, identity
+	case "Tf":
+		if len(operands) == 2 {
+			if fontName, ok := operands[0].(name); ok {
+				s.font = fallbackFont
+				if fonts := in.doc.dictOf(resources["Font"]); fonts != nil && fonts[fontName] != nil {
+					s.font = in.doc.loadFont(fonts[fontName])
+				}
+			}
+			s.fontSize = nums[1]
+		}
+	case "Tc":
+		if len(nums) == 1 {
+			s.charSpace = nums[0]
+		}
+	case "Tw":
+		if len(nums) == 1 {
+			s.wordSpace = nums[0]
+		}
+	case "Tz":
+		if len(nums) == 1 {
+			s.hScaling = nums[0] / 100
+		}
+	case "TL":
+		if len(nums) == 1 {
+			s.leading = nums[0]
+		}
+	case "Ts":
+		if len(nums) == 1 {
+			s.rise = nums[0]
+		}
+
+	// Text positioning
+	case "Td":
+		if len(nums) == 2 {
+			in.moveLine(nums[0], nums[1])
+		}
+	case "TD":
+		if len(nums) == 2 {
+			s.leading = -nums[1]
+			in.moveLine(nums[0], nums[1])
+		}
+	case "Tm":
+		if len(nums) == 6 {
+			in.tm, in.tlm = matrix(nums), matrix(nums)
+		}
+	case "T*":
+		in.moveLine(0, -s.leading)
+
+	// Text showing
+	case "Tj":
+		if len(operands) == 1 {
+			in.show(operands[0])
+		}
+	case "'":
+		if len(operands) == 1 {
+			in.moveLine(0, -s.leading)
+			in.show(operands[0])
+		}
+	case "\"":
+		if len(operands) == 3 {
+			s.wordSpace, s.charSpace = nums[0], nums[1]
+			in.moveLine(0, -s.leading)
+			in.show(operands[2])
+		}
+	case "TJ":
+		if len(operands) == 1 {
+			items, _ := operands[0].(array)
+			for _, item := range items {
+				if adjustment, ok := number(item); ok {
+					// Thousandths of text space, subtracted from the position
+					in.tm = translation(-adjustment/1000*s.fontSize*s.hScaling, 0).mul(in.tm)
+					continue
+				}
+				in.show(item)
+			}
+		}
+
+	// XObjects
+	case "Do":
+		if len(operands) == 1 {
+			if xobjectName, ok := operands[0].(name); ok {
+				in.paintXObject(xobjectName, resources)
+			}
+		}
+	}
+}
+
+// moveLine starts a new line offset from the start of the current one (Td).
+func (in *interpreter) moveLine(tx, ty float64) {
+	in.tlm = translation(tx, ty).mul(in.tlm)
+	in.tm = in.tlm
+}
+
+// show records the glyphs of a shown string and advances the text matrix (9.4.4).
+func (in *interpreter) show(operand object) {
+	text, ok := operand.(string)
+	if !ok {
+		return
+	}
+	s := &in.state
+	for _, code := range s.font.decode(text) {
+		trm := matrix{s.fontSize * s.hScaling, 0, 0, s.fontSize, 0, s.rise}.mul(in.tm).mul(s.ctm)
+		advance := code.width*s.fontSize + s.charSpace
+		if code.single {
+			advance += s.wordSpace
+		}
+		in.tm = translation(advance*s.hScaling, 0).mul(in.tm)
+		next := in.tm.mul(s.ctm)
+
+		glyphText := code.text
+		if glyphText == "" {
+			glyphText = "\uFFFD"
+			in.unmapped++
+		}
+		in.glyphs = append(in.glyphs, glyph{
+			text: glyphText,
+			x:    trm[4],
+			y:    trm[5],
+			endX: next[4],
+			size: math.Hypot(trm[2], trm[3]),
+		})
+	}
+}
+
+// paintXObject counts image XObjects and runs form XObjects with their own resources (8.10).
+func (in *interpreter) paintXObject(xobjectName name, resources dict) {
+	xobjects := in.doc.dictOf(resources["XObject"])
+	if xobjects == nil {
+		return
+	}
+	form, ok := in.doc.resolve(xobjects[xobjectName]).(*stream)
+	if !ok {
+		return
+	}
+	switch form.dict["Subtype"] {
+	case name("Image"):
+		in.images++
+	case name("Form"):
+		if in.formDepth >= maxFormDepth {
+			return
+		}
+		content, err := in.doc.decode(form)
+		if err != nil {
+			return
+		}
+		formResources := in.doc.dictOf(form.dict["Resources"])
+		if formResources == nil {
+			formResources = resources
+		}
+
+		saved, savedTM, savedTLM, depth := in.state, in.tm, in.tlm, len(in.saved)
+		if m, ok := in.doc.resolve(form.dict["Matrix"]).(array); ok && len(m) == 6 {
+			var formMatrix matrix
+			for i, v := range m {
+				formMatrix[i], _ = number(in.doc.resolve(v))
+			}
+			in.state.ctm = formMatrix.mul(in.state.ctm)
+		}
+		in.formDepth++
+		in.run(content, formResources)
+		in.formDepth--
+		in.state, in.tm, in.tlm = saved, savedTM, savedTLM
+		if len(in.saved) > depth {
+			in.saved = in.saved[:depth] // Unbalanced q in the form
+		}
+	}
+}
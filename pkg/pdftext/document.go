@@ -0,0 +1,421 @@
+// pkg/pdftext/document.go
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// maxStreamSize bounds the decoded size of a single stream, which protects against decompression bombs.
+const maxStreamSize = 64 << 20
+
+var errUnsupportedFilter = errors.New("pdftext: unsupported stream filter")
+
+// objectHeader matches the "num gen obj" line starting an indirect object.
+var objectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// document is a loaded PDF. Objects are found by scanning the file for their headers rather than through the
+// cross-reference table, which recovers documents whose table is damaged or stale; when an object is defined more
+// than once (incremental updates), the last definition in the file wins.
+type document struct {
+	data     []byte
+	objects  map[int]object
+	position map[int]int // File offset each object was defined at; for objects in object streams, the stream's
+	trailers []dict      // Trailer dictionaries and cross-reference stream dictionaries, in file order
+	fonts    map[ref]*font
+}
+
+// load scans data for indirect objects, expands object streams and collects the trailers. Malformed data cannot
+// crash the caller: a panic while loading is returned as an error.
+func load(data []byte) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("pdftext: loading document: %v", r)
+		}
+	}()
+	header := data
+	if len(header) > 1024 {
+		header = header[:1024]
+	}
+	if !bytes.Contains(header, []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+
+	d := &document{data: data, objects: map[int]object{}, position: map[int]int{}, fonts: map[ref]*font{}}
+	var objectStreams []int
+	for pos := 0; pos < len(data); {
+		loc := objectHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, err := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[0]
+		l := &lexer{data: data, pos: pos + loc[1]}
+		obj, objErr := l.object()
+		if err != nil || objErr != nil {
+			pos += loc[1]
+			continue
+		}
+		if entries, ok := obj.(dict); ok {
+			if s := d.streamAfter(l, entries); s != nil {
+				obj = s
+				switch entries["Type"] {
+				case name("ObjStm"):
+					objectStreams = append(objectStreams, num)
+				case name("XRef"):
+					d.trailers = append(d.trailers, entries)
+				}
+			}
+		}
+		d.objects[num], d.position[num] = obj, start
+		pos = l.pos
+	}
+
+	for _, num := range objectStreams {
+		d.expandObjectStream(num)
+	}
+	for pos := 0; ; {
+		i := bytes.Index(data[pos:], []byte("trailer"))
+		if i < 0 {
+			break
+		}
+		l := &lexer{data: data, pos: pos + i + len("trailer")}
+		if trailer, err := l.object(); err == nil {
+			if entries, ok := trailer.(dict); ok {
+				d.trailers = append(d.trailers, entries)
+			}
+		}
+		pos += i + len("trailer")
+	}
+
+	for _, trailer := range d.trailers {
+		if trailer["Encrypt"] != nil {
+			return nil, ErrEncrypted
+		}
+	}
+	return d, nil
+}
+
+// streamAfter reads the data of the stream whose dictionary l has just read, or returns nil if no stream follows.
+func (d *document) streamAfter(l *lexer, entries dict) *stream {
+	save := l.pos
+	if token, err := l.token(); err != nil || token != keyword("stream") {
+		l.pos = save
+		return nil
+	}
+	start := l.pos
+	if start < len(d.data) && d.data[start] == '\r' {
+		start++
+	}
+	if start < len(d.data) && d.data[start] == '\n' {
+		start++
+	}
+
+	// Trust /Length when it is known and followed by endstream; otherwise search for endstream
+	length, ok := entries["Length"].(int)
+	if r, isRef := entries["Length"].(ref); isRef {
+		length, ok = d.objects[r.num].(int)
+	}
+	if ok && length >= 0 && start+length <= len(d.data) {
+		after := &lexer{data: d.data, pos: start + length}
+		if token, err := after.token(); err == nil && token == keyword("endstream") {
+			l.pos = after.pos
+			return &stream{dict: entries, raw: d.data[start : start+length]}
+		}
+	}
+	end := bytes.Index(d.data[start:], []byte("endstream"))
+	if end < 0 {
+		l.pos = len(d.data)
+		return &stream{dict: entries, raw: d.data[start:]}
+	}
+	l.pos = start + end + len("endstream")
+	raw := d.data[start : start+end]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return &stream{dict: entries, raw: raw}
+}
+
+// expandObjectStream adds the objects compressed in an object stream (7.5.7), unless a later direct definition
+// replaced them.
+func (d *document) expandObjectStream(num int) {
+	s, ok := d.objects[num].(*stream)
+	if !ok {
+		return // Redefined as a plain object later in the file
+	}
+	count, _ := d.resolve(s.dict["N"]).(int)
+	first, _ := d.resolve(s.dict["First"]).(int)
+	data, err := d.decode(s)
+	if err != nil || first <= 0 || first > len(data) {
+		return
+	}
+
+	header := &lexer{data: data[:first]}
+	for i := 0; i < count; i++ {
+		objectNumber, err1 := header.token()
+		offset, err2 := header.token()
+		n, ok1 := objectNumber.(int)
+		o, ok2 := offset.(int)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 || first+o >= len(data) {
+			return
+		}
+		if position, defined := d.position[n]; defined && position > d.position[num] {
+			continue
+		}
+		l := &lexer{data: data, pos: first + o}
+		obj, err := l.object()
+		if err != nil {
+			continue
+		}
+		d.objects[n], d.position[n] = obj, d.position[num]
+	}
+}
+
+// resolve follows indirect references; a dangling reference is null.
+func (d *document) resolve(o object) object {
+	for i := 0; i < 32; i++ {
+		r, ok := o.(ref)
+		if !ok {
+			return o
+		}
+		o = d.objects[r.num]
+	}
+	return nil
+}
+
+// dictOf resolves o to a dictionary, or the dictionary of a stream.
+func (d *document) dictOf(o object) dict {
+	switch v := d.resolve(o).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+// catalog returns the document catalog: the /Root of the last trailer that has one, else any /Catalog object.
+func (d *document) catalog() dict {
+	for i := len(d.trailers) - 1; i >= 0; i-- {
+		if root := d.dictOf(d.trailers[i]["Root"]); root != nil && root["Pages"] != nil {
+			return root
+		}
+	}
+	for _, obj := range d.objects {
+		if entries, ok := obj.(dict); ok && entries["Type"] == name("Catalog") && entries["Pages"] != nil {
+			return entries
+		}
+	}
+	return nil
+}
+
+// page is a leaf of the page tree with its inherited resources.
+type page struct {
+	entries   dict
+	resources dict
+}
+
+// pages returns the leaves of the page tree in order (7.7.3).
+func (d *document) pages() []page {
+	catalog := d.catalog()
+	if catalog == nil {
+		return nil
+	}
+	var pages []page
+	visited := map[ref]bool{}
+	var walk func(node object, resources dict, depth int)
+	walk = func(node object, resources dict, depth int) {
+		if r, ok := node.(ref); ok {
+			if visited[r] {
+				return
+			}
+			visited[r] = true
+		}
+		entries := d.dictOf(node)
+		if entries == nil || depth > 64 {
+			return
+		}
+		if own := d.dictOf(entries["Resources"]); own != nil {
+			resources = own
+		}
+		kids, isTree := d.resolve(entries["Kids"]).(array)
+		if !isTree && entries["Type"] != name("Pages") {
+			pages = append(pages, page{entries: entries, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	walk(catalog["Pages"], nil, 0)
+	return pages
+}
+
+// decode applies the filters of a stream (7.4). FlateDecode (with PNG predictors), ASCIIHexDecode and
+// ASCII85Decode are supported; LZWDecode, rarely used since PDF 1.2, and the image-only filters are not.
+func (d *document) decode(s *stream) ([]byte, error) {
+	var filters []object
+	var params []object
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = []object{f}
+		params = []object{d.resolve(s.dict["DecodeParms"])}
+	case array:
+		filters = f
+		if p, ok := d.resolve(s.dict["DecodeParms"]).(array); ok {
+			params = p
+		}
+	}
+
+	data := s.raw
+	for i, filter := range filters {
+		var parms dict
+		if i < len(params) {
+			parms = d.dictOf(params[i])
+		}
+		var err error
+		switch d.resolve(filter) {
+		case name("FlateDecode"), name("Fl"):
+			if data, err = inflate(data); err == nil {
+				data, err = d.unpredict(data, parms)
+			}
+		case name("ASCIIHexDecode"), name("AHx"):
+			data, err = decodeASCIIHex(data)
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			err = fmt.Errorf("%w: %v", errUnsupportedFilter, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data up to maxStreamSize. Truncated or corrupt streams, which are common, keep what was
+// decompressed before the error.
+func inflate(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdftext: inflating stream: %w", err)
+	}
+	defer reader.Close()
+	out, err := io.ReadAll(io.LimitReader(reader, maxStreamSize+1))
+	if len(out) > maxStreamSize {
+		return nil, fmt.Errorf("pdftext: stream exceeds %d bytes when decoded", maxStreamSize)
+	}
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("pdftext: inflating stream: %w", err)
+	}
+	return out, nil
+}
+
+// unpredict reverses the PNG predictors of a Flate stream (7.4.4.4).
+func (d *document) unpredict(data []byte, parms dict) ([]byte, error) {
+	predictor, _ := d.resolve(parms["Predictor"]).(int)
+	if predictor < 10 {
+		if predictor == 2 {
+			return nil, fmt.Errorf("%w: TIFF predictor", errUnsupportedFilter)
+		}
+		return data, nil
+	}
+	colors, bits, columns := 1, 8, 1
+	if v, ok := d.resolve(parms["Colors"]).(int); ok && v > 0 {
+		colors = v
+	}
+	if v, ok := d.resolve(parms["BitsPerComponent"]).(int); ok && v > 0 {
+		bits = v
+	}
+	if v, ok := d.resolve(parms["Columns"]).(int); ok && v > 0 {
+		columns = v
+	}
+	bpp := (colors*bits + 7) / 8
+	rowLength := (colors*bits*columns + 7) / 8
+
+	out := make([]byte, 0, len(data))
+	previous := make([]byte, rowLength)
+	for pos := 0; pos+1+rowLength <= len(data); pos += 1 + rowLength {
+		kind, row := data[pos], append([]byte(nil), data[pos+1:pos+1+rowLength]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], previous[i-bpp]
+			}
+			up := previous[i]
+			switch kind {
+			case 1: // Sub
+				row[i] += left
+			case 2: // Up
+				row[i] += up
+			case 3: // Average
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4: // Paeth
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		previous = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// decodeASCIIHex decodes ASCIIHexDecode data, which ends at '>'.
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	digits := make([]byte, 0, len(data))
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if _, ok := hexValue(c); ok {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, fmt.Errorf("pdftext: decoding ASCIIHex stream: %w", err)
+	}
+	return out, nil
+}
+
+// decodeASCII85 decodes ASCII85Decode data, which ends at "~>".
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimLeft(data, " \t\r\n\f\x00"), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	out := make([]byte, 4*len(data)+4) // "z" stands for four bytes
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("pdftext: decoding ASCII85 stream: %w", err)
+	}
+	return out[:n], nil
+}
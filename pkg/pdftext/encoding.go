@@ -0,0 +1,166 @@
+// pkg/pdftext/encoding.go
+package pdftext
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Simple font encodings (ISO 32000-1, Annex D) and the glyph names of Differences arrays. An empty entry is a code
+// the encoding does not define.
+type encoding [256]string
+
+// asciiGlyphs are the glyph names of codes 0x20-0x7E in WinAnsiEncoding and MacRomanEncoding.
+var asciiGlyphs = strings.Fields(`space exclam quotedbl numbersign dollar percent ampersand quotesingle parenleft
+	parenright asterisk plus comma hyphen period slash zero one two three four five six seven eight nine colon
+	semicolon less equal greater question at A B C D E F G H I J K L M N O P Q R S T U V W X Y Z bracketleft
+	backslash bracketright asciicircum underscore grave a b c d e f g h i j k l m n o p q r s t u v w x y z braceleft
+	bar braceright asciitilde`)
+
+// latin1Glyphs are the glyph names of U+00A0-U+00FF, which are codes 0xA0-0xFF in WinAnsiEncoding.
+var latin1Glyphs = strings.Fields(`nbspace exclamdown cent sterling currency yen brokenbar section dieresis
+	copyright ordfeminine guillemotleft logicalnot sfthyphen registered macron degree plusminus twosuperior
+	threesuperior acute mu paragraph periodcentered cedilla onesuperior ordmasculine guillemotright onequarter
+	onehalf threequarters questiondown Agrave Aacute Acircumflex Atilde Adieresis Aring AE Ccedilla Egrave Eacute
+	Ecircumflex Edieresis Igrave Iacute Icircumflex Idieresis Eth Ntilde Ograve Oacute Ocircumflex Otilde Odieresis
+	multiply Oslash Ugrave Uacute Ucircumflex Udieresis Yacute Thorn germandbls agrave aacute acircumflex atilde
+	adieresis aring ae ccedilla egrave eacute ecircumflex edieresis igrave iacute icircumflex idieresis eth ntilde
+	ograve oacute ocircumflex otilde odieresis divide oslash ugrave uacute ucircumflex udieresis yacute thorn
+	ydieresis`)
+
+// otherGlyphs are the remaining glyph names of the standard encodings, plus common ligatures.
+var otherGlyphs = map[string]string{
+	"Euro": "€", "quotesinglbase": "‚", "florin": "ƒ", "quotedblbase": "„", "ellipsis": "…", "dagger": "†",
+	"daggerdbl": "‡", "circumflex": "ˆ", "perthousand": "‰", "Scaron": "Š", "guilsinglleft": "‹", "OE": "Œ",
+	"Zcaron": "Ž", "quoteleft": "‘", "quoteright": "’", "quotedblleft": "“", "quotedblright": "”", "bullet": "•",
+	"endash": "–", "emdash": "—", "tilde": "˜", "trademark": "™", "scaron": "š", "guilsinglright": "›", "oe": "œ",
+	"zcaron": "ž", "Ydieresis": "Ÿ", "fraction": "⁄", "dotlessi": "ı", "Lslash": "Ł", "lslash": "ł", "breve": "˘",
+	"dotaccent": "˙", "ring": "˚", "hungarumlaut": "˝", "ogonek": "˛", "caron": "ˇ", "minus": "−", "fi": "fi",
+	"fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl", "notequal": "≠", "lessequal": "≤", "greaterequal": "≥",
+	"infinity": "∞", "partialdiff": "∂", "summation": "∑", "product": "∏", "pi": "π", "integral": "∫", "Omega": "Ω",
+	"radical": "√", "approxequal": "≈", "Delta": "∆", "lozenge": "◊", "micro": "µ", "middot": "·", "alpha": "α",
+	"beta": "β", "gamma": "γ",
+}
+
+// glyphText maps glyph names to their text.
+var glyphText = func() map[string]string {
+	glyphs := make(map[string]string, len(asciiGlyphs)+len(latin1Glyphs)+len(otherGlyphs))
+	for i, glyph := range asciiGlyphs {
+		glyphs[glyph] = string(rune(0x20 + i))
+	}
+	for i, glyph := range latin1Glyphs {
+		glyphs[glyph] = string(rune(0xA0 + i))
+	}
+	for glyph, text := range otherGlyphs {
+		glyphs[glyph] = text
+	}
+	glyphs["nbspace"], glyphs["sfthyphen"] = " ", "-" // Plain space and hyphen read better in extracted text
+	return glyphs
+}()
+
+// textOfGlyph returns the text of a glyph name (following the Adobe Glyph List conventions for "uniXXXX", "uXXXX",
+// ligatures such as "f_f" and suffixes such as "a.sc"), or "" for unknown names.
+func textOfGlyph(glyph string) string {
+	if text, ok := glyphText[glyph]; ok {
+		return text
+	}
+	if dot := strings.IndexByte(glyph, '.'); dot > 0 {
+		return textOfGlyph(glyph[:dot])
+	}
+	if strings.Contains(glyph, "_") {
+		var text strings.Builder
+		for _, part := range strings.Split(glyph, "_") {
+			t := textOfGlyph(part)
+			if t == "" {
+				return ""
+			}
+			text.WriteString(t)
+		}
+		return text.String()
+	}
+	if hexDigits, ok := strings.CutPrefix(glyph, "uni"); ok && len(hexDigits) >= 4 && len(hexDigits)%4 == 0 {
+		var text strings.Builder
+		for i := 0; i < len(hexDigits); i += 4 {
+			r, err := strconv.ParseUint(hexDigits[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			text.WriteRune(rune(r))
+		}
+		return text.String()
+	}
+	if hexDigits, ok := strings.CutPrefix(glyph, "u"); ok && len(hexDigits) >= 4 && len(hexDigits) <= 6 {
+		if r, err := strconv.ParseUint(hexDigits, 16, 32); err == nil {
+			return string(rune(r))
+		}
+	}
+	return ""
+}
+
+// asciiEncoding is the printable ASCII range shared by the encodings below.
+func asciiEncoding() *encoding {
+	var e encoding
+	for i := 0x20; i < 0x7F; i++ {
+		e[i] = string(rune(i))
+	}
+	return &e
+}
+
+// winAnsiEncoding is WinAnsiEncoding (Windows code page 1252).
+var winAnsiEncoding = func() *encoding {
+	e := asciiEncoding()
+	for i, glyph := range strings.Fields(`Euro - quotesinglbase florin quotedblbase ellipsis dagger daggerdbl circumflex
+		perthousand Scaron guilsinglleft OE - Zcaron - - quoteleft quoteright quotedblleft quotedblright bullet endash
+		emdash tilde trademark scaron guilsinglright oe - zcaron Ydieresis`) {
+		e[0x80+i] = textOfGlyph(glyph)
+	}
+	for i, glyph := range latin1Glyphs {
+		e[0xA0+i] = textOfGlyph(glyph)
+	}
+	return e
+}()
+
+// macRomanEncoding is MacRomanEncoding.
+var macRomanEncoding = func() *encoding {
+	e := asciiEncoding()
+	high := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»…\u00A0ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔ\uF8FFÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range high {
+		e[0x80+i] = string(r)
+	}
+	e[0xCA], e[0xDE], e[0xDF] = " ", "fi", "fl"
+	return e
+}()
+
+// standardEncoding is StandardEncoding, the built-in encoding of most Type 1 fonts.
+var standardEncoding = func() *encoding {
+	e := asciiEncoding()
+	e['\''], e['`'] = "’", "‘"
+	for code, glyph := range map[int]string{
+		0xA1: "exclamdown", 0xA2: "cent", 0xA3: "sterling", 0xA4: "fraction", 0xA5: "yen", 0xA6: "florin",
+		0xA7: "section", 0xA8: "currency", 0xA9: "quotesingle", 0xAA: "quotedblleft", 0xAB: "guillemotleft",
+		0xAC: "guilsinglleft", 0xAD: "guilsinglright", 0xAE: "fi", 0xAF: "fl", 0xB1: "endash", 0xB2: "dagger",
+		0xB3: "daggerdbl", 0xB4: "periodcentered", 0xB6: "paragraph", 0xB7: "bullet", 0xB8: "quotesinglbase",
+		0xB9: "quotedblbase", 0xBA: "quotedblright", 0xBB: "guillemotright", 0xBC: "ellipsis", 0xBD: "perthousand",
+		0xBF: "questiondown", 0xC1: "grave", 0xC2: "acute", 0xC3: "circumflex", 0xC4: "tilde", 0xC5: "macron",
+		0xC6: "breve", 0xC7: "dotaccent", 0xC8: "dieresis", 0xCA: "ring", 0xCB: "cedilla", 0xCD: "hungarumlaut",
+		0xCE: "ogonek", 0xCF: "caron", 0xD0: "emdash", 0xE1: "AE", 0xE3: "ordfeminine", 0xE8: "Lslash", 0xE9: "Oslash",
+		0xEA: "OE", 0xEB: "ordmasculine", 0xF1: "ae", 0xF5: "dotlessi", 0xF8: "lslash", 0xF9: "oslash", 0xFA: "oe",
+		0xFB: "germandbls",
+	} {
+		e[code] = textOfGlyph(glyph)
+	}
+	return e
+}()
+
+// encodingByName returns a predefined encoding, or nil for an unknown name.
+func encodingByName(n name) *encoding {
+	switch n {
+	case "WinAnsiEncoding":
+		return winAnsiEncoding
+	case "MacRomanEncoding":
+		return macRomanEncoding
+	case "StandardEncoding":
+		return standardEncoding
+	}
+	return nil
+}
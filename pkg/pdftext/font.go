@@ -0,0 +1,377 @@
+// pkg/pdftext/font.go
+package pdftext
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// maxCMapEntries bounds the codes a ToUnicode CMap may define, as bfrange entries can span large ranges.
+const maxCMapEntries = 1 << 20
+
+// font maps the character codes of shown strings to text and glyph widths (ISO 32000-1, 9.6-9.10).
+type font struct {
+	composite bool      // Type0: multi-byte codes, CID widths
+	toUnicode *cmap     // From the /ToUnicode stream; nil if absent
+	encoding  *encoding // Simple fonts: the base encoding with the Differences applied
+	ucs2      bool      // Composite fonts whose CMap makes codes UTF-16 (Uni*-UCS2-*, Uni*-UTF16-*)
+
+	firstChar    int
+	widths       []float64       // Simple fonts: widths of firstChar onwards, in text space units
+	cidWidths    map[int]float64 // Composite fonts: widths by CID (Identity CMaps only), in text space units
+	defaultWidth float64         // Width of codes without an entry
+}
+
+// charCode is a character code of a shown string with its text and width.
+type charCode struct {
+	text   string  // "" if the font does not map the code to Unicode
+	width  float64 // Horizontal displacement in text space units, before scaling by the font size
+	single bool    // A single-byte code 32, to which word spacing applies (9.3.3)
+}
+
+// fallbackFont is used when a page shows text without a valid font: Latin text with average widths.
+var fallbackFont = &font{encoding: standardEncoding, defaultWidth: 0.5}
+
+// loadFont reads a font dictionary, caching fonts that are indirect objects.
+func (d *document) loadFont(o object) *font {
+	r, isRef := o.(ref)
+	if isRef {
+		if f, ok := d.fonts[r]; ok {
+			return f
+		}
+	}
+	entries := d.dictOf(o)
+	if entries == nil {
+		return fallbackFont
+	}
+
+	f := &font{composite: entries["Subtype"] == name("Type0")}
+	if s, ok := d.resolve(entries["ToUnicode"]).(*stream); ok {
+		if data, err := d.decode(s); err == nil {
+			f.toUnicode = parseCMap(data)
+		}
+	}
+	if f.composite {
+		d.loadCIDFont(f, entries)
+	} else {
+		d.loadSimpleFont(f, entries)
+	}
+	if isRef {
+		d.fonts[r] = f
+	}
+	return f
+}
+
+// loadSimpleFont reads the encoding and widths of a Type1, TrueType or Type3 font.
+func (d *document) loadSimpleFont(f *font, entries dict) {
+	// 1. Encoding: the named base encoding (StandardEncoding for Type 1, WinAnsi-like for TrueType), then Differences
+	base := standardEncoding
+	if entries["Subtype"] == name("TrueType") {
+		base = winAnsiEncoding
+	}
+	var differences array
+	switch e := d.resolve(entries["Encoding"]).(type) {
+	case name:
+		if named := encodingByName(e); named != nil {
+			base = named
+		}
+	case dict:
+		if baseName, ok := d.resolve(e["BaseEncoding"]).(name); ok {
+			if named := encodingByName(baseName); named != nil {
+				base = named
+			}
+		}
+		differences, _ = d.resolve(e["Differences"]).(array)
+	}
+	enc := *base
+	code := 0
+	for _, item := range differences {
+		switch v := d.resolve(item).(type) {
+		case int:
+			code = v
+		case name:
+			if code >= 0 && code < len(enc) {
+				enc[code] = textOfGlyph(string(v))
+			}
+			code++
+		}
+	}
+	f.encoding = &enc
+
+	// 2. Widths, in glyph space: thousandths of text space, or the FontMatrix of a Type 3 font
+	scale := 0.001
+	if entries["Subtype"] == name("Type3") {
+		if matrix, ok := d.resolve(entries["FontMatrix"]).(array); ok && len(matrix) > 0 {
+			if v, ok := number(d.resolve(matrix[0])); ok {
+				scale = v
+			}
+		}
+	}
+	f.firstChar, _ = d.resolve(entries["FirstChar"]).(int)
+	widths, hasWidths := d.resolve(entries["Widths"]).(array)
+	for _, w := range widths {
+		v, _ := number(d.resolve(w))
+		f.widths = append(f.widths, v*scale)
+	}
+	f.defaultWidth = 0.5 // The standard 14 fonts have no Widths: assume an average glyph
+	if hasWidths {
+		f.defaultWidth = 0
+		if descriptor := d.dictOf(entries["FontDescriptor"]); descriptor != nil {
+			if v, ok := number(d.resolve(descriptor["MissingWidth"])); ok {
+				f.defaultWidth = v * scale
+			}
+		}
+	}
+}
+
+// loadCIDFont reads the CMap and widths of a Type0 font and its descendant CIDFont.
+func (d *document) loadCIDFont(f *font, entries dict) {
+	// Codes are CIDs with the Identity CMaps (and, approximately, embedded CMaps); other predefined CMaps are not
+	// read, so their CIDs and widths are unknown
+	cmapName, predefined := d.resolve(entries["Encoding"]).(name)
+	f.ucs2 = strings.HasPrefix(string(cmapName), "Uni") && (strings.Contains(string(cmapName), "UCS2") || strings.Contains(string(cmapName), "UTF16"))
+	identity := !predefined || strings.HasPrefix(string(cmapName), "Identity")
+
+	f.defaultWidth = 1
+	descendants, _ := d.resolve(entries["DescendantFonts"]).(array)
+	if len(descendants) == 0 {
+		return
+	}
+	descendant := d.dictOf(descendants[0])
+	if descendant == nil {
+		return
+	}
+	if v, ok := number(d.resolve(descendant["DW"])); ok {
+		f.defaultWidth = v / 1000
+	}
+	if !identity {
+		return
+	}
+
+	// /W: "c [w1 w2 ...]" gives consecutive widths from c, "cfirst clast w" one width for a range (9.7.4.3)
+	w, _ := d.resolve(descendant["W"]).(array)
+	f.cidWidths = map[int]float64{}
+	for i := 0; i < len(w); {
+		first, ok := d.resolve(w[i]).(int)
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := d.resolve(w[i+1]).(array); ok {
+			for j, item := range list {
+				v, _ := number(d.resolve(item))
+				f.cidWidths[first+j] = v / 1000
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last, _ := d.resolve(w[i+1]).(int)
+		v, _ := number(d.resolve(w[i+2]))
+		for cid := first; cid <= last && cid-first < maxCMapEntries; cid++ {
+			f.cidWidths[cid] = v / 1000
+		}
+		i += 3
+	}
+}
+
+// decode splits a shown string into character codes.
+func (f *font) decode(s string) []charCode {
+	codes := make([]charCode, 0, len(s))
+	for i := 0; i < len(s); {
+		n := 1
+		switch {
+		case f.toUnicode != nil && len(f.toUnicode.ranges) > 0:
+			n = f.toUnicode.codeLength(s[i:])
+		case f.composite:
+			n = 2
+		}
+		if i+n > len(s) {
+			n = len(s) - i
+		}
+		raw := s[i : i+n]
+		i += n
+
+		value := 0
+		for j := 0; j < len(raw); j++ {
+			value = value<<8 | int(raw[j])
+		}
+		code := charCode{text: f.text(raw, value), width: f.defaultWidth, single: n == 1 && raw[0] == ' '}
+		switch {
+		case !f.composite && value >= f.firstChar && value-f.firstChar < len(f.widths):
+			code.width = f.widths[value-f.firstChar]
+		case f.composite:
+			if w, ok := f.cidWidths[value]; ok {
+				code.width = w
+			}
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// text maps a character code to Unicode: ToUnicode first, then the encoding or the UCS-2 CMap.
+func (f *font) text(raw string, value int) string {
+	if f.toUnicode != nil {
+		if text, ok := f.toUnicode.chars[codeKey(raw)]; ok {
+			return text
+		}
+	}
+	switch {
+	case f.ucs2 && len(raw) == 2:
+		return string(rune(value))
+	case !f.composite && len(raw) == 1:
+		return f.encoding[raw[0]]
+	}
+	return ""
+}
+
+// cmap is a parsed ToUnicode CMap (9.10.3).
+type cmap struct {
+	ranges []codespaceRange
+	chars  map[uint64]string // By codeKey
+}
+
+// codespaceRange is a range of codes of one length; each byte must lie between the bytes of low and high.
+type codespaceRange struct {
+	low, high string
+}
+
+// codeKey identifies a code by its bytes, so that <20> and <0020> stay distinct.
+func codeKey(raw string) uint64 {
+	key := uint64(len(raw)) << 32
+	for i := 0; i < len(raw) && i < 4; i++ {
+		key |= uint64(raw[i]) << (8 * (len(raw) - 1 - i))
+	}
+	return key
+}
+
+// codeLength returns the length of the code at the start of s, per the codespace ranges.
+func (c *cmap) codeLength(s string) int {
+	shortest := 4
+	for _, r := range c.ranges {
+		n := len(r.low)
+		if n < shortest {
+			shortest = n
+		}
+		if n > len(s) {
+			continue
+		}
+		match := true
+		for i := 0; i < n && match; i++ {
+			match = s[i] >= r.low[i] && s[i] <= r.high[i]
+		}
+		if match {
+			return n
+		}
+	}
+	return shortest
+}
+
+// parseCMap reads the codespace ranges, bfchar and bfrange mappings of a ToUnicode CMap. Parsing stops at the first
+// syntax error, keeping the mappings read until then.
+func parseCMap(data []byte) *cmap {
+	c := &cmap{chars: map[uint64]string{}}
+	l := &lexer{data: data}
+	for {
+		token, err := l.token()
+		if err != nil {
+			return c
+		}
+		switch token {
+		case keyword("begincodespacerange"):
+			for {
+				low, high, ok := cmapPair(l, "endcodespacerange")
+				if !ok {
+					break
+				}
+				if len(low) == len(high) && len(low) > 0 && len(low) <= 4 {
+					c.ranges = append(c.ranges, codespaceRange{low: low, high: high})
+				}
+			}
+		case keyword("beginbfchar"):
+			for {
+				src, dst, ok := cmapPair(l, "endbfchar")
+				if !ok {
+					break
+				}
+				c.chars[codeKey(src)] = decodeUTF16(dst)
+			}
+		case keyword("beginbfrange"):
+			for {
+				low, high, ok := cmapPair(l, "endbfrange")
+				if !ok {
+					break
+				}
+				dst, err := l.object()
+				if err != nil {
+					return c
+				}
+				c.addRange(low, high, dst)
+			}
+		}
+	}
+}
+
+// cmapPair reads two strings of a CMap section, or reports false at its end keyword.
+func cmapPair(l *lexer, end keyword) (string, string, bool) {
+	first, err := l.token()
+	if err != nil || first == end {
+		return "", "", false
+	}
+	second, err := l.token()
+	if err != nil {
+		return "", "", false
+	}
+	a, ok1 := first.(string)
+	b, ok2 := second.(string)
+	return a, b, ok1 && ok2
+}
+
+// addRange maps the codes from low to high: from an array of destinations, or from one destination whose last
+// UTF-16 unit is incremented for each code.
+func (c *cmap) addRange(low, high string, dst object) {
+	if len(low) != len(high) || len(low) == 0 || len(low) > 4 {
+		return
+	}
+	first, last := codeKey(low)&0xFFFFFFFF, codeKey(high)&0xFFFFFFFF
+	if last < first || len(c.chars)+int(last-first) > maxCMapEntries {
+		return
+	}
+	keyLength := uint64(len(low)) << 32
+	switch d := dst.(type) {
+	case array:
+		for i, item := range d {
+			if text, ok := item.(string); ok && first+uint64(i) <= last {
+				c.chars[keyLength|(first+uint64(i))] = decodeUTF16(text)
+			}
+		}
+	case string:
+		units := utf16Units(d)
+		if len(units) == 0 {
+			return
+		}
+		for code := first; code <= last; code++ {
+			shifted := append([]uint16(nil), units...)
+			shifted[len(shifted)-1] += uint16(code - first)
+			c.chars[keyLength|code] = string(utf16.Decode(shifted))
+		}
+	}
+}
+
+// decodeUTF16 decodes the UTF-16BE bytes of a CMap destination.
+func decodeUTF16(s string) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
+
+func utf16Units(s string) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	if len(s)%2 == 1 {
+		units = append(units, uint16(s[len(s)-1]))
+	}
+	return units
+}
@@ -0,0 +1,358 @@
+// pkg/pdftext/object.go
+package pdftext
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PDF objects (ISO 32000-1, 7.3). Strings are Go strings holding their raw bytes; names have their #xx escapes
+// decoded. A null is nil.
+type (
+	object  = any // nil, bool, int, float64, string, name, array, dict, ref, keyword or *stream
+	name    string
+	array   []object
+	dict    map[name]object
+	keyword string // Content stream operators and file structure keywords (obj, stream, R, ...)
+	delim   string // "[", "]", "<<", ">>", "{" or "}", only seen by the parser
+)
+
+// ref is an indirect reference ("12 0 R").
+type ref struct {
+	num, gen int
+}
+
+// stream is a stream object with its data still encoded.
+type stream struct {
+	dict dict
+	raw  []byte
+}
+
+// maxNestingDepth bounds how deeply arrays and dictionaries may nest. Real documents nest a few levels; the limit
+// keeps crafted input from exhausting the stack, which would crash the process rather than panic.
+const maxNestingDepth = 64
+
+var errUnexpectedEOF = errors.New("pdftext: unexpected end of data")
+
+// lexer tokenizes PDF syntax, for both the file structure and content streams.
+type lexer struct {
+	data  []byte
+	pos   int
+	depth int // Arrays and dictionaries being read by objectFrom
+}
+
+func isWhite(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch c := l.data[l.pos]; {
+		case isWhite(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next token: a delim, or a complete string, name, number, boolean, null or keyword. It returns
+// io.EOF at the end of the data.
+func (l *lexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	switch c := l.data[l.pos]; c {
+	case '(':
+		return l.literalString()
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return delim("<<"), nil
+		}
+		return l.hexString()
+	case '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return delim(">>"), nil
+		}
+		l.pos++
+		return nil, fmt.Errorf("pdftext: unexpected '>' at offset %d", l.pos-1)
+	case '[', ']', '{', '}':
+		l.pos++
+		return delim(c), nil
+	case ')':
+		l.pos++
+		return nil, fmt.Errorf("pdftext: unexpected ')' at offset %d", l.pos-1)
+	case '/':
+		return l.name(), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhite(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if number, ok := parseNumber(word); ok {
+		return number, nil
+	}
+	return keyword(word), nil
+}
+
+// parseNumber parses an integer or real number; reals such as "-.5" or "4." are accepted.
+func parseNumber(word string) (object, bool) {
+	digits := false
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' || ((c == '+' || c == '-') && i == 0):
+		default:
+			return nil, false
+		}
+	}
+	if !digits {
+		return nil, false
+	}
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+// literalString reads a (string) with its escapes and balanced parentheses (7.3.4.2).
+func (l *lexer) literalString() (string, error) {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return string(out), nil
+			}
+		case '\r':
+			// An unescaped end of line is a line feed, whatever its bytes
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			c = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return "", errUnexpectedEOF
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation
+				if c == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				value := int(c - '0')
+				for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+					value = value*8 + int(l.data[l.pos]-'0')
+					l.pos++
+				}
+				c = byte(value)
+			}
+		}
+		out = append(out, c)
+	}
+	return "", errUnexpectedEOF
+}
+
+// hexString reads a <hex string>; whitespace is ignored and a missing last digit is 0 (7.3.4.3).
+func (l *lexer) hexString() (string, error) {
+	l.pos++ // <
+	var out []byte
+	var high byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			if half {
+				out = append(out, high<<4)
+			}
+			return string(out), nil
+		}
+		value, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if half {
+			out = append(out, high<<4|value)
+		} else {
+			high = value
+		}
+		half = !half
+	}
+	return "", errUnexpectedEOF
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// name reads a /Name, decoding its #xx escapes (7.3.5).
+func (l *lexer) name() name {
+	l.pos++ // /
+	var out []byte
+	for l.pos < len(l.data) && !isWhite(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '#' && l.pos+1 < len(l.data) {
+			high, ok1 := hexValue(l.data[l.pos])
+			low, ok2 := hexValue(l.data[l.pos+1])
+			if ok1 && ok2 {
+				c = high<<4 | low
+				l.pos += 2
+			}
+		}
+		out = append(out, c)
+	}
+	return name(out)
+}
+
+// object reads a complete object: arrays and dictionaries are read recursively and "num gen R" becomes a ref.
+func (l *lexer) object() (object, error) {
+	token, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	return l.objectFrom(token)
+}
+
+// objectFrom completes the object starting with token.
+func (l *lexer) objectFrom(token any) (object, error) {
+	switch t := token.(type) {
+	case delim:
+		if t == "[" || t == "<<" {
+			if l.depth >= maxNestingDepth {
+				return nil, fmt.Errorf("pdftext: objects nested more than %d deep at offset %d", maxNestingDepth, l.pos)
+			}
+			l.depth++
+			defer func() { l.depth-- }()
+		}
+		switch t {
+		case "[":
+			var items array
+			for {
+				next, err := l.token()
+				if err == io.EOF {
+					return nil, errUnexpectedEOF
+				} else if err != nil {
+					return nil, err
+				}
+				if next == delim("]") {
+					return items, nil
+				}
+				item, err := l.objectFrom(next)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+		case "<<":
+			entries := dict{}
+			for {
+				next, err := l.token()
+				if err == io.EOF {
+					return nil, errUnexpectedEOF
+				} else if err != nil {
+					return nil, err
+				}
+				if next == delim(">>") {
+					return entries, nil
+				}
+				key, ok := next.(name)
+				if !ok {
+					return nil, fmt.Errorf("pdftext: dictionary key %v is not a name at offset %d", next, l.pos)
+				}
+				value, err := l.object()
+				if err != nil {
+					return nil, err
+				}
+				entries[key] = value
+			}
+		}
+		return nil, fmt.Errorf("pdftext: unexpected %q at offset %d", string(t), l.pos)
+	case int:
+		// "num gen R" is an indirect reference; anything else leaves the integer alone
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int); ok {
+				if r, err := l.token(); err == nil && r == keyword("R") {
+					return ref{num: t, gen: g}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return token, nil
+}
+
+// number returns a numeric object as a float64.
+func number(o object) (float64, bool) {
+	switch n := o.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
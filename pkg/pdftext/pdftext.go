@@ -0,0 +1,186 @@
+// pkg/pdftext/pdftext.go
+
+// Package pdftext extracts the text layer of PDF documents: the text shown by their content streams, decoded through
+// the fonts' ToUnicode CMaps and encodings and laid out in reading order. Pages without a usable text layer (scans)
+// are reported as such, so that callers can fall back to OCR for them. Encrypted documents are not supported.
+package pdftext
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrNotPDF is returned for data without a PDF header.
+	ErrNotPDF = errors.New("pdftext: not a PDF document")
+	// ErrEncrypted is returned for encrypted documents, whose strings cannot be read without decrypting them.
+	ErrEncrypted = errors.New("pdftext: encrypted PDF documents are not supported")
+	// ErrNoPages is returned when no page tree can be found.
+	ErrNoPages = errors.New("pdftext: no pages found")
+)
+
+// Page is the text layer of one page.
+type Page struct {
+	Number   int    // 1-based
+	Text     string // Lines from top to bottom, each from left to right; blank lines separate paragraphs
+	Glyphs   int    // Characters shown on the page
+	Unmapped int    // Characters whose font has no Unicode mapping, written as U+FFFD
+	Images   int    // Images painted on the page (image XObjects and inline images)
+	Err      error  // Set if the page's content could not be read; Text holds what was read before
+}
+
+// HasText reports whether the page has a usable text layer: at least minChars letters or digits, with at most one
+// character in ten that could not be mapped to Unicode.
+func (p *Page) HasText(minChars int) bool {
+	if p.Glyphs == 0 || p.Unmapped*10 > p.Glyphs {
+		return false
+	}
+	count := 0
+	for _, r := range p.Text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
+	}
+	return count >= minChars
+}
+
+// Extract returns the text layer of every page of a PDF document, in page order. A malformed document is returned
+// as an error, never as a panic.
+func Extract(data []byte) (pages []Page, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("pdftext: reading document: %v", r)
+		}
+	}()
+	doc, err := load(data)
+	if err != nil {
+		return nil, err
+	}
+	leaves := doc.pages()
+	if len(leaves) == 0 {
+		return nil, ErrNoPages
+	}
+	pages = make([]Page, len(leaves))
+	for i, leaf := range leaves {
+		pages[i] = doc.extractPage(leaf, i+1)
+	}
+	return pages, nil
+}
+
+// extractPage interprets the content streams of a page. Malformed content cannot crash the caller: a panic while
+// reading the page is returned as the page's Err.
+func (d *document) extractPage(leaf page, number int) (result Page) {
+	result.Number = number
+	in := newInterpreter(d)
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("pdftext: reading page %d: %v", number, r)
+		}
+		result.Text = layout(in.glyphs)
+		result.Glyphs, result.Unmapped, result.Images = len(in.glyphs), in.unmapped, in.images
+	}()
+
+	var contents []object
+	switch c := d.resolve(leaf.entries["Contents"]).(type) {
+	case *stream:
+		contents = []object{c}
+	case array:
+		contents = c
+	}
+	// The streams of an array form one content stream, which may split an operator's operands across streams
+	var content []byte
+	for _, c := range contents {
+		s, ok := d.resolve(c).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := d.decode(s)
+		if err != nil {
+			result.Err = fmt.Errorf("pdftext: reading page %d: %w", number, err)
+			continue
+		}
+		content = append(append(content, data...), '\n')
+	}
+	in.run(content, leaf.resources)
+	return result
+}
+
+// ligatures spells out ligature characters, which ToUnicode CMaps often map glyphs to.
+var ligatures = strings.NewReplacer("ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl", "ﬅ", "st", "ﬆ", "st")
+
+// line is a row of glyphs sharing a baseline.
+type line struct {
+	y, size float64
+	glyphs  []glyph
+}
+
+// layout orders glyphs into text: glyphs are grouped into lines by baseline, lines are read from top to bottom and
+// glyphs within a line from left to right. A space is inserted where glyphs are further apart than a fraction of the
+// font size, and a blank line where lines are further apart than twice their size. Multi-column layouts are read
+// across the columns.
+func layout(glyphs []glyph) string {
+	if len(glyphs) == 0 {
+		return ""
+	}
+	sorted := make([]glyph, 0, len(glyphs))
+	for _, g := range glyphs {
+		if g.size <= 0 {
+			g.size = 1
+		}
+		sorted = append(sorted, g)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].y > sorted[j].y })
+
+	// 1. Group glyphs into lines: a glyph joins the line if its baseline is within half a font size of the line's
+	var lines []*line
+	for _, g := range sorted {
+		if n := len(lines); n > 0 {
+			current := lines[n-1]
+			if math.Abs(g.y-current.y) <= 0.5*math.Max(g.size, current.size) {
+				current.glyphs = append(current.glyphs, g)
+				current.size = math.Max(current.size, g.size)
+				continue
+			}
+		}
+		lines = append(lines, &line{y: g.y, size: g.size, glyphs: []glyph{g}})
+	}
+
+	// 2. Write each line from left to right
+	var text strings.Builder
+	var previous *line
+	for _, l := range lines {
+		sort.SliceStable(l.glyphs, func(i, j int) bool { return l.glyphs[i].x < l.glyphs[j].x })
+		var row strings.Builder
+		var last *glyph
+		for i := range l.glyphs {
+			g := &l.glyphs[i]
+			if last != nil {
+				if g.text == last.text && math.Abs(g.x-last.x) < 0.1*g.size {
+					continue // Drawn twice, as for fake bold or shadows
+				}
+				if g.x-last.endX > 0.15*g.size && !strings.HasSuffix(row.String(), " ") && !strings.HasPrefix(g.text, " ") {
+					row.WriteByte(' ')
+				}
+			}
+			row.WriteString(g.text)
+			last = g
+		}
+		rowText := strings.TrimSpace(row.String())
+		if rowText == "" {
+			continue
+		}
+		if previous != nil {
+			text.WriteByte('\n')
+			if previous.y-l.y > 2*math.Max(previous.size, l.size) {
+				text.WriteByte('\n')
+			}
+		}
+		text.WriteString(rowText)
+		previous = l
+	}
+	return ligatures.Replace(text.String())
+}
@@ -0,0 +1,70 @@
+// pkg/pdftext/pdftext_test.go
+package pdftext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// onePage is a document whose single page shows "Chest CT report".
+const onePage = "%PDF-1.4\n" +
+	"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+	"2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n" +
+	"3 0 obj\n<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>\nendobj\n" +
+	"4 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n" +
+	"5 0 obj\n<< /Length 44 >>\nstream\nBT /F1 12 Tf 72 720 Td (Chest CT report) Tj ET\nendstream\nendobj\n" +
+	"trailer\n<< /Root 1 0 R >>\n%%EOF\n"
+
+func TestExtract(t *testing.T) {
+	pages, err := Extract([]byte(onePage))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(pages) != 1 || pages[0].Text != "Chest CT report" || pages[0].Err != nil {
+		t.Fatalf("Extract = %+v, want one page reading %q", pages, "Chest CT report")
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"arrays nested too deep", "%PDF-1.4\n1 0 obj\n" + strings.Repeat("[", 1<<20)},
+		{"dictionaries nested too deep", "%PDF-1.4\n1 0 obj\n" + strings.Repeat("<< /A ", 1<<16)},
+		{"no pages", "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := Extract([]byte(tt.data))
+			if err == nil {
+				t.Fatalf("Extract = %+v, want an error", pages)
+			}
+		})
+	}
+}
+
+func TestLoadRedefinedObjectStream(t *testing.T) {
+	data := "%PDF-1.4\n" +
+		"6 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Length 8 >>\nstream\n7 0 42\nendstream\nendobj\n" +
+		"6 0 obj\n42\nendobj\n"
+	doc, err := load([]byte(data))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if doc.objects[6] != 42 {
+		t.Errorf("object 6 is %v, want the later definition 42", doc.objects[6])
+	}
+}
+
+func TestNestingLimit(t *testing.T) {
+	within := strings.Repeat("[", maxNestingDepth) + strings.Repeat("]", maxNestingDepth)
+	if _, err := (&lexer{data: []byte(within)}).object(); err != nil {
+		t.Errorf("%d nested arrays: %v", maxNestingDepth, err)
+	}
+	beyond := strings.Repeat("[", maxNestingDepth+1) + strings.Repeat("]", maxNestingDepth+1)
+	if _, err := (&lexer{data: []byte(beyond)}).object(); err == nil || errors.Is(err, errUnexpectedEOF) {
+		t.Errorf("%d nested arrays: error %v, want the nesting limit", maxNestingDepth+1, err)
+	}
+}